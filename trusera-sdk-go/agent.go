@@ -0,0 +1,134 @@
+package trusera
+
+import "context"
+
+// Agent is a handle for one logical agent in a process that runs several.
+// Events tracked through it are tagged with the agent's ID in their
+// "agent_id" metadata and share the client's queue, transport and flushers.
+// Handles are cheap; create one per agent and keep it, or create them on demand.
+type Agent struct {
+	client *Client
+	id     string
+}
+
+var _ Tracker = (*Agent)(nil)
+
+// ForAgent returns a handle that tracks events on behalf of agentID
+func (c *Client) ForAgent(agentID string) *Agent {
+	return &Agent{client: c, id: agentID}
+}
+
+// ID returns the agent ID events are tagged with
+func (a *Agent) ID() string {
+	return a.id
+}
+
+// Client returns the client the handle shares
+func (a *Agent) Client() *Client {
+	return a.client
+}
+
+// Track queues an event tagged with the agent's ID
+func (a *Agent) Track(event Event) {
+	a.client.Track(a.tag(event))
+}
+
+// TrackContext tracks an event as a child of the active span in ctx, if any
+func (a *Agent) TrackContext(ctx context.Context, event Event) {
+	trackContext(a, ctx, event)
+}
+
+// Flush flushes the shared client, including other agents' events
+func (a *Agent) Flush() error {
+	return a.client.Flush()
+}
+
+// Close is a no-op: the shared client is closed by its owner
+func (a *Agent) Close() error {
+	return nil
+}
+
+// RegisterAgent registers an agent with Trusera through the shared client
+func (a *Agent) RegisterAgent(name, framework string) (string, error) {
+	return a.client.RegisterAgent(name, framework)
+}
+
+// ReportGuardrail records a guardrail violation tagged with the agent's ID
+func (a *Agent) ReportGuardrail(g GuardrailEvent) error {
+	metadata := make(map[string]any, len(g.Metadata)+1)
+	for k, v := range g.Metadata {
+		metadata[k] = v
+	}
+	metadata["agent_id"] = a.id
+	g.Metadata = metadata
+	return a.client.ReportGuardrail(g)
+}
+
+// StartRun begins a new trace whose spans and events are tagged with the
+// agent's ID
+func (a *Agent) StartRun(name string) *Run {
+	run := a.client.StartRun(name)
+	run.tags = mergeTags(run.tags, a.tags())
+	return run
+}
+
+// StartSpan begins a span under the active span in ctx, or a new run if ctx
+// has none, tagged with the agent's ID
+func (a *Agent) StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	ctx, s := a.client.StartSpan(ctx, name)
+	s.tags = mergeTags(s.tags, a.tags())
+	return ctx, s
+}
+
+// tag stamps the agent's ID onto a copy of the event's metadata
+func (a *Agent) tag(e Event) Event {
+	return withTags(e, a.tags())
+}
+
+// tags returns the metadata the agent stamps on events
+func (a *Agent) tags() map[string]any {
+	return map[string]any{"agent_id": a.id}
+}
+
+// withTags sets metadata keys without modifying the caller's map
+func withTags(e Event, tags map[string]any) Event {
+	metadata := make(map[string]any, len(e.Metadata)+len(tags))
+	for k, v := range e.Metadata {
+		metadata[k] = v
+	}
+	for k, v := range tags {
+		metadata[k] = v
+	}
+	e.Metadata = metadata
+	return e
+}
+
+// mergeTags returns a new map holding base overlaid with extra
+func mergeTags(base, extra map[string]any) map[string]any {
+	merged := make(map[string]any, len(base)+len(extra))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
+
+// trackerClient is implemented by the trackers backed by a Client:
+// *Client, *Agent and types embedding them
+type trackerClient interface {
+	trackerClient() *Client
+}
+
+func (c *Client) trackerClient() *Client { return c }
+
+func (a *Agent) trackerClient() *Client { return a.client }
+
+// clientOf returns the client behind t, or nil for other trackers
+func clientOf(t Tracker) *Client {
+	if tc, ok := t.(trackerClient); ok {
+		return tc.trackerClient()
+	}
+	return nil
+}
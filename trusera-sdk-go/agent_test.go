@@ -0,0 +1,94 @@
+package trusera
+
+import (
+	"context"
+	"testing"
+)
+
+func TestForAgentTagsEvents(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	planner := client.ForAgent("planner")
+	executor := client.ForAgent("executor")
+
+	shared := NewEvent(EventToolCall, "search").WithMetadata("source", "test")
+	planner.Track(shared)
+	executor.Track(NewEvent(EventToolCall, "run"))
+	client.Track(NewEvent(EventToolCall, "direct"))
+
+	if _, ok := shared.Metadata["agent_id"]; ok {
+		t.Error("expected the caller's metadata map to be left untouched")
+	}
+
+	events := queuedEvents(client)
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events in the shared queue, got %d", len(events))
+	}
+	if events[0].Metadata["agent_id"] != "planner" || events[0].Metadata["source"] != "test" {
+		t.Errorf("expected planner tag with original metadata, got %v", events[0].Metadata)
+	}
+	if events[1].Metadata["agent_id"] != "executor" {
+		t.Errorf("expected executor tag, got %v", events[1].Metadata)
+	}
+	if _, ok := events[2].Metadata["agent_id"]; ok {
+		t.Errorf("expected untagged event from the client, got %v", events[2].Metadata)
+	}
+}
+
+func TestForAgentSpans(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	agent := client.ForAgent("researcher")
+	run := agent.StartRun("research")
+	step := run.StartSpan("lookup")
+	step.AddEvent(NewEvent(EventToolCall, "web_search"))
+	step.End()
+
+	ctx, span := agent.StartSpan(ContextWithSpan(context.Background(), run.Span), "summarize")
+	_, child := client.StartSpan(ctx, "format")
+	child.End()
+	span.End()
+	run.End()
+
+	events := queuedEvents(client)
+	if len(events) != 5 {
+		t.Fatalf("expected 5 events, got %d", len(events))
+	}
+	for _, e := range events {
+		if e.Metadata["agent_id"] != "researcher" {
+			t.Errorf("expected %s %s to be tagged, got %v", e.Type, e.Name, e.Metadata)
+		}
+		if e.Metadata["trace_id"] != run.TraceID() {
+			t.Errorf("expected %s to stay in the run's trace", e.Name)
+		}
+	}
+}
+
+func TestForAgentGuardrailAndClose(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	agent := client.ForAgent("support-bot")
+	metadata := map[string]any{"channel": "chat"}
+	if err := agent.ReportGuardrail(GuardrailEvent{Policy: "pii.email", Metadata: metadata}); err != nil {
+		t.Fatalf("ReportGuardrail failed: %v", err)
+	}
+	if _, ok := metadata["agent_id"]; ok {
+		t.Error("expected the caller's guardrail metadata to be left untouched")
+	}
+
+	if err := agent.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	agent.Track(NewEvent(EventToolCall, "after-close"))
+
+	events := queuedEvents(client)
+	if len(events) != 2 {
+		t.Fatalf("expected the shared client to stay open, got %d events", len(events))
+	}
+	if events[0].Metadata["agent_id"] != "support-bot" || events[0].Metadata["channel"] != "chat" {
+		t.Errorf("expected tagged guardrail event, got %v", events[0].Metadata)
+	}
+}
@@ -0,0 +1,148 @@
+package trusera
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// configMask replaces the values of secret configuration keys
+const configMask = "[REDACTED]"
+
+// agentConfig holds the configuration last reported by a client
+type agentConfig struct {
+	mu      sync.Mutex
+	version string // Version last accepted by the API
+}
+
+// ReportConfig reports the agent's effective configuration, such as its
+// model, temperature, enabled tools and system prompt, to /v1/agent-configs,
+// where each version is kept so configuration drift across the fleet can be
+// spotted. Call it on startup and whenever the configuration changes; a
+// configuration identical to the last one reported is not sent again.
+//
+// The configuration is masked before it leaves the process: values of keys
+// that name secrets (api_key, token, password, secret, credentials and
+// similar) are replaced with "[REDACTED]", keys containing "prompt" carry
+// the SHA-256 of their text instead of the text, and credentials embedded in
+// other strings are redacted as by DefaultRedactor. Values may be any JSON
+// encodable type. The returned version is the first 12 hex digits of the
+// SHA-256 of the masked configuration, and is returned even if the upload
+// fails.
+func (c *Client) ReportConfig(config map[string]any) (string, error) {
+	masked, err := maskConfig(config)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(masked)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal config: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	version := hex.EncodeToString(sum[:6])
+
+	c.agentConfig.mu.Lock()
+	defer c.agentConfig.mu.Unlock()
+	if version == c.agentConfig.version {
+		return version, nil
+	}
+
+	c.mu.Lock()
+	agentID := c.agentID
+	c.mu.Unlock()
+
+	payload := map[string]any{
+		"version": version,
+		"config":  masked,
+	}
+	if agentID != "" {
+		payload["agent_id"] = agentID
+	}
+	if c.agentConfig.version != "" {
+		payload["previous_version"] = c.agentConfig.version
+	}
+
+	if c.sink != nil {
+		err = c.sink.write("agent_config", agentID, payload)
+	} else {
+		err = c.doJSON(context.Background(), http.MethodPost, "/v1/agent-configs", payload, nil)
+	}
+	if err != nil {
+		return version, fmt.Errorf("failed to report config: %w", err)
+	}
+	c.agentConfig.version = version
+	return version, nil
+}
+
+// maskConfig returns a JSON-shaped copy of config with secrets masked and
+// prompts hashed
+func maskConfig(config map[string]any) (map[string]any, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	var plain map[string]any
+	if err := json.Unmarshal(data, &plain); err != nil {
+		return nil, fmt.Errorf("failed to decode config: %w", err)
+	}
+	if plain == nil {
+		plain = map[string]any{}
+	}
+	return maskValue("", plain, DefaultRedactor()).(map[string]any), nil
+}
+
+// maskValue masks v, found under key, walking nested objects and arrays
+func maskValue(key string, v any, r *PatternRedactor) any {
+	switch {
+	case key != "" && secretKey(key):
+		return configMask
+	case key != "" && strings.Contains(strings.ToLower(key), "prompt"):
+		if s, ok := v.(string); ok {
+			sum := sha256.Sum256([]byte(s))
+			return "sha256:" + hex.EncodeToString(sum[:])
+		}
+	}
+	switch val := v.(type) {
+	case string:
+		return r.redactString(val)
+	case map[string]any:
+		for k, item := range val {
+			val[k] = maskValue(k, item, r)
+		}
+	case []any:
+		for i, item := range val {
+			val[i] = maskValue(key, item, r)
+		}
+	}
+	return v
+}
+
+// secretWords are key segments that name a secret
+var secretWords = map[string]bool{
+	"password": true, "passwd": true, "secret": true, "token": true, "apikey": true,
+	"credential": true, "credentials": true, "authorization": true, "auth": true,
+}
+
+// secretSuffixes catch secret keys written without separators, like apiKey
+var secretSuffixes = []string{"token", "secret", "password", "apikey", "api_key", "privatekey", "private_key", "credentials"}
+
+// secretKey reports whether a configuration key names a secret
+func secretKey(key string) bool {
+	k := strings.ToLower(key)
+	for _, s := range secretSuffixes {
+		if strings.HasSuffix(k, s) {
+			return true
+		}
+	}
+	for _, seg := range strings.FieldsFunc(k, func(r rune) bool { return r == '_' || r == '-' || r == '.' }) {
+		if secretWords[seg] {
+			return true
+		}
+	}
+	return false
+}
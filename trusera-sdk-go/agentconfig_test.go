@@ -0,0 +1,142 @@
+package trusera
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestReportConfig(t *testing.T) {
+	var mu sync.Mutex
+	var got []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/agent-configs" {
+			return
+		}
+		var payload map[string]any
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		got = append(got, payload)
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithAgentID("agent-1"))
+	defer client.Close()
+
+	config := map[string]any{
+		"model":         "gpt-4o",
+		"temperature":   0.2,
+		"tools":         []string{"search", "calculator"},
+		"system_prompt": "You are a helpful assistant.",
+		"openai": map[string]any{
+			"api_key":  "sk-abcdefghijklmnopqrstuvwxyz",
+			"base_url": "https://api.openai.com",
+		},
+		"max_tokens": 512,
+	}
+	v1, err := client.ReportConfig(config)
+	if err != nil {
+		t.Fatalf("report failed: %v", err)
+	}
+	if len(v1) != 12 {
+		t.Errorf("expected a 12 digit version, got %q", v1)
+	}
+	if v, err := client.ReportConfig(config); err != nil || v != v1 {
+		t.Errorf("expected the same version %s, got %s, %v", v1, v, err)
+	}
+	config["temperature"] = 0.7
+	v2, err := client.ReportConfig(config)
+	if err != nil || v2 == v1 {
+		t.Errorf("expected a new version, got %s, %v", v2, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 2 {
+		t.Fatalf("expected 2 reports for 2 versions, got %d", len(got))
+	}
+	first := got[0]
+	if first["agent_id"] != "agent-1" || first["version"] != v1 {
+		t.Errorf("expected agent and version, got %v", first)
+	}
+	cfg := first["config"].(map[string]any)
+	if cfg["openai"].(map[string]any)["api_key"] != configMask {
+		t.Errorf("expected the API key masked, got %v", cfg["openai"])
+	}
+	if cfg["max_tokens"] != 512.0 || cfg["model"] != "gpt-4o" {
+		t.Errorf("expected plain settings kept, got %v", cfg)
+	}
+	if p, _ := cfg["system_prompt"].(string); !strings.HasPrefix(p, "sha256:") {
+		t.Errorf("expected the system prompt hashed, got %q", p)
+	}
+	if got[1]["previous_version"] != v1 {
+		t.Errorf("expected previous_version %s, got %v", v1, got[1]["previous_version"])
+	}
+}
+
+func TestReportConfigRetriesAfterFailure(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/agent-configs" {
+			return
+		}
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	defer client.Close()
+
+	config := map[string]any{"model": "gpt-4o"}
+	if v, err := client.ReportConfig(config); err == nil || v == "" {
+		t.Errorf("expected an error with the version, got %q, %v", v, err)
+	}
+	if _, err := client.ReportConfig(config); err != nil {
+		t.Errorf("expected the retry to succeed, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 uploads, got %d", calls)
+	}
+}
+
+func TestMaskConfig(t *testing.T) {
+	masked, err := maskConfig(map[string]any{
+		"apiKey":        "abc",
+		"auth-token":    "abc",
+		"db_password":   "abc",
+		"client_secret": "abc",
+		"max_tokens":    100,
+		"note":          "call with Bearer abc.def.ghi",
+		"prompts":       []any{"one", "two"},
+	})
+	if err != nil {
+		t.Fatalf("mask failed: %v", err)
+	}
+	for _, k := range []string{"apiKey", "auth-token", "db_password", "client_secret"} {
+		if masked[k] != configMask {
+			t.Errorf("expected %s masked, got %v", k, masked[k])
+		}
+	}
+	if masked["max_tokens"] != 100.0 {
+		t.Errorf("expected max_tokens kept, got %v", masked["max_tokens"])
+	}
+	if note := masked["note"].(string); strings.Contains(note, "abc.def") {
+		t.Errorf("expected the bearer token redacted, got %q", note)
+	}
+	for _, p := range masked["prompts"].([]any) {
+		if !strings.HasPrefix(p.(string), "sha256:") {
+			t.Errorf("expected prompts hashed, got %v", p)
+		}
+	}
+	if _, err := maskConfig(map[string]any{"f": func() {}}); err == nil {
+		t.Error("expected error for a value that cannot be encoded")
+	}
+}
@@ -0,0 +1,150 @@
+package trusera
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// AlertMetric is the value an alert rule watches
+type AlertMetric string
+
+const (
+	AlertErrorRate           AlertMetric = "error_rate"           // Fraction of failed spans and calls, 0 to 1
+	AlertLatencyP95          AlertMetric = "latency_p95_ms"       // 95th percentile span latency in milliseconds
+	AlertCost                AlertMetric = "cost_usd"             // LLM spend in US dollars
+	AlertEventCount          AlertMetric = "event_count"          // Events of AlertRule.EventType, or all events
+	AlertGuardrailViolations AlertMetric = "guardrail_violations" // Guardrail violation count
+)
+
+// AlertOperator compares a metric with an alert rule's threshold
+type AlertOperator string
+
+const (
+	AlertAbove     AlertOperator = ">"
+	AlertAtOrAbove AlertOperator = ">="
+	AlertBelow     AlertOperator = "<"
+	AlertAtOrBelow AlertOperator = "<="
+)
+
+// AlertRule fires when a metric crosses a threshold over a time window, such
+// as an error rate above 5% over 10 minutes for one agent. Firing alerts are
+// delivered to the rule's channels and as alert.triggered webhooks.
+type AlertRule struct {
+	ID        string        `json:"id,omitempty"` // Assigned by CreateAlertRule
+	Name      string        `json:"name"`
+	AgentID   string        `json:"agent_id,omitempty"` // Empty watches every agent
+	Metric    AlertMetric   `json:"metric"`
+	EventType EventType     `json:"event_type,omitempty"` // Only for AlertEventCount
+	Operator  AlertOperator `json:"operator"`
+	Threshold float64       `json:"threshold"`
+	Window    time.Duration `json:"window_ns"`
+	Severity  Severity      `json:"severity,omitempty"` // Defaults to SeverityMedium
+	// Channels are notification channel IDs configured in Trusera, such as a
+	// Slack channel or PagerDuty service
+	Channels  []string  `json:"channels,omitempty"`
+	Disabled  bool      `json:"disabled,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// Validate reports whether the rule is complete enough to create
+func (r AlertRule) Validate() error {
+	switch {
+	case r.Name == "":
+		return errors.New("invalid alert rule: name is required")
+	case r.Metric == "":
+		return errors.New("invalid alert rule: metric is required")
+	case r.EventType != "" && r.Metric != AlertEventCount:
+		return fmt.Errorf("invalid alert rule: event type only applies to %s", AlertEventCount)
+	case r.Window <= 0:
+		return errors.New("invalid alert rule: window must be positive")
+	}
+	switch r.Operator {
+	case AlertAbove, AlertAtOrAbove, AlertBelow, AlertAtOrBelow:
+	default:
+		return fmt.Errorf("invalid alert rule: unknown operator %q", r.Operator)
+	}
+	return nil
+}
+
+// AlertRulePage is one page of ListAlertRules results
+type AlertRulePage struct {
+	Rules []AlertRule `json:"rules"`
+	// NextCursor is passed as ListOptions.Cursor to fetch the next page,
+	// empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// CreateAlertRule creates an alert rule with POST /v1/alert-rules and returns
+// it with its ID, so monitoring can be provisioned alongside the agent
+func (c *Client) CreateAlertRule(ctx context.Context, rule AlertRule) (*AlertRule, error) {
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	rule.ID = ""
+	var created AlertRule
+	if err := c.doJSON(ctx, http.MethodPost, "/v1/alert-rules", rule, &created); err != nil {
+		return nil, fmt.Errorf("failed to create alert rule: %w", err)
+	}
+	return &created, nil
+}
+
+// GetAlertRule fetches an alert rule by ID
+func (c *Client) GetAlertRule(ctx context.Context, id string) (*AlertRule, error) {
+	if id == "" {
+		return nil, errors.New("alert rule ID is required")
+	}
+	var rule AlertRule
+	if err := c.getJSON(ctx, "/v1/alert-rules/"+url.PathEscape(id), nil, &rule); err != nil {
+		return nil, fmt.Errorf("failed to get alert rule: %w", err)
+	}
+	return &rule, nil
+}
+
+// ListAlertRules fetches one page of the organization's alert rules
+func (c *Client) ListAlertRules(ctx context.Context, opts ListOptions) (*AlertRulePage, error) {
+	q := url.Values{}
+	setPage(q, opts.Limit, opts.Cursor)
+	var page AlertRulePage
+	if err := c.getJSON(ctx, "/v1/alert-rules", q, &page); err != nil {
+		return nil, fmt.Errorf("failed to list alert rules: %w", err)
+	}
+	return &page, nil
+}
+
+// UpdateAlertRule replaces the alert rule with rule.ID and returns the stored
+// rule
+func (c *Client) UpdateAlertRule(ctx context.Context, rule AlertRule) (*AlertRule, error) {
+	if rule.ID == "" {
+		return nil, errors.New("alert rule ID is required")
+	}
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	var updated AlertRule
+	if err := c.doJSON(ctx, http.MethodPut, "/v1/alert-rules/"+url.PathEscape(rule.ID), rule, &updated); err != nil {
+		return nil, fmt.Errorf("failed to update alert rule: %w", err)
+	}
+	return &updated, nil
+}
+
+// DeleteAlertRule deletes an alert rule. Deleting a rule that does not exist
+// succeeds, so teardown can be repeated.
+func (c *Client) DeleteAlertRule(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("alert rule ID is required")
+	}
+	err := c.doJSON(ctx, http.MethodDelete, "/v1/alert-rules/"+url.PathEscape(id), nil, nil)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete alert rule: %w", err)
+	}
+	return nil
+}
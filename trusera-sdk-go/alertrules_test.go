@@ -0,0 +1,159 @@
+package trusera
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// alertRuleServer is an in-memory /v1/alert-rules API
+func alertRuleServer(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	rules := map[string]AlertRule{}
+	next := 0
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		id := strings.TrimPrefix(r.URL.Path, "/v1/alert-rules/")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/alert-rules":
+			var rule AlertRule
+			if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+				t.Errorf("failed to decode rule: %v", err)
+			}
+			next++
+			rule.ID = "rule-" + strconv.Itoa(next)
+			rules[rule.ID] = rule
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(rule)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/alert-rules":
+			page := AlertRulePage{}
+			for _, rule := range rules {
+				page.Rules = append(page.Rules, rule)
+			}
+			json.NewEncoder(w).Encode(page)
+		case r.Method == http.MethodGet:
+			rule, ok := rules[id]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(rule)
+		case r.Method == http.MethodPut:
+			var rule AlertRule
+			json.NewDecoder(r.Body).Decode(&rule)
+			rules[id] = rule
+			json.NewEncoder(w).Encode(rule)
+		case r.Method == http.MethodDelete:
+			if _, ok := rules[id]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			delete(rules, id)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+}
+
+func TestAlertRuleCRUD(t *testing.T) {
+	server := alertRuleServer(t)
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	defer client.Close()
+	ctx := context.Background()
+
+	rule, err := client.CreateAlertRule(ctx, AlertRule{
+		Name:      "support-agent errors",
+		AgentID:   "agent-42",
+		Metric:    AlertErrorRate,
+		Operator:  AlertAbove,
+		Threshold: 0.05,
+		Window:    10 * time.Minute,
+		Severity:  SeverityHigh,
+	})
+	if err != nil {
+		t.Fatalf("CreateAlertRule failed: %v", err)
+	}
+	if rule.ID == "" || rule.Window != 10*time.Minute || rule.Threshold != 0.05 {
+		t.Errorf("expected the created rule with an ID, got %+v", rule)
+	}
+
+	got, err := client.GetAlertRule(ctx, rule.ID)
+	if err != nil {
+		t.Fatalf("GetAlertRule failed: %v", err)
+	}
+	if got.Name != "support-agent errors" || got.AgentID != "agent-42" {
+		t.Errorf("expected the stored rule, got %+v", got)
+	}
+
+	got.Threshold = 0.1
+	got.Disabled = true
+	updated, err := client.UpdateAlertRule(ctx, *got)
+	if err != nil {
+		t.Fatalf("UpdateAlertRule failed: %v", err)
+	}
+	if updated.Threshold != 0.1 || !updated.Disabled {
+		t.Errorf("expected the updated rule, got %+v", updated)
+	}
+
+	page, err := client.ListAlertRules(ctx, ListOptions{})
+	if err != nil {
+		t.Fatalf("ListAlertRules failed: %v", err)
+	}
+	if len(page.Rules) != 1 {
+		t.Errorf("expected one rule, got %+v", page)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := client.DeleteAlertRule(ctx, rule.ID); err != nil {
+			t.Errorf("DeleteAlertRule #%d failed: %v", i+1, err)
+		}
+	}
+	if _, err := client.GetAlertRule(ctx, rule.ID); err == nil {
+		t.Error("expected error for deleted rule")
+	}
+}
+
+func TestAlertRuleValidate(t *testing.T) {
+	valid := AlertRule{Name: "cost", Metric: AlertCost, Operator: AlertAbove, Threshold: 50, Window: time.Hour}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("expected valid rule, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*AlertRule)
+	}{
+		{"no name", func(r *AlertRule) { r.Name = "" }},
+		{"no metric", func(r *AlertRule) { r.Metric = "" }},
+		{"bad operator", func(r *AlertRule) { r.Operator = "~" }},
+		{"no window", func(r *AlertRule) { r.Window = 0 }},
+		{"event type on cost", func(r *AlertRule) { r.EventType = EventToolCall }},
+	}
+	for _, tt := range tests {
+		rule := valid
+		tt.modify(&rule)
+		if err := rule.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", tt.name)
+		}
+	}
+
+	client := NewClient("test-key", WithBaseURL("http://127.0.0.1:1"))
+	defer client.Close()
+	if _, err := client.CreateAlertRule(context.Background(), AlertRule{}); err == nil || !strings.Contains(err.Error(), "invalid alert rule") {
+		t.Errorf("expected validation before sending, got %v", err)
+	}
+	if _, err := client.UpdateAlertRule(context.Background(), valid); err == nil {
+		t.Error("expected error for update without ID")
+	}
+}
@@ -0,0 +1,351 @@
+// Package anthropicsdk records Messages API calls made with the Anthropic Go
+// SDK (github.com/anthropics/anthropic-sdk-go) as Trusera EventLLMInvoke
+// events.
+//
+// Use either this adapter or trusera.WrapTransport for a given client, not
+// both, or calls are recorded twice.
+package anthropicsdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+// maxCapture bounds how much of a request or non-streaming response is buffered
+const maxCapture = 1 << 20
+
+// maxArguments bounds how much of each tool use input is recorded
+const maxArguments = 1024
+
+// Option returns a request option that installs Middleware on an Anthropic client:
+//
+//	client := anthropic.NewClient(anthropicsdk.Option(truseraClient))
+func Option(client *trusera.Client) option.RequestOption {
+	return option.WithMiddleware(Middleware(client))
+}
+
+// Middleware records each Messages API call with its model, token usage
+// (including prompt cache reads and writes), stop reason and tool use blocks.
+// Streaming calls also report delta counts and timings. The event is tracked
+// once the response body has been read or closed; other endpoints pass
+// through untouched.
+func Middleware(client *trusera.Client) option.Middleware {
+	return func(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		if !strings.HasSuffix(req.URL.Path, "/messages") {
+			return next(req)
+		}
+		call := newCall(req)
+
+		resp, err := next(req)
+		if err != nil {
+			client.TrackContext(req.Context(), call.event().
+				WithPayload("latency_ms", time.Since(call.start).Milliseconds()).
+				WithPayload("error", err.Error()))
+			return resp, err
+		}
+
+		call.statusCode = resp.StatusCode
+		call.latency = time.Since(call.start)
+		done := func(e trusera.Event) { client.TrackContext(req.Context(), e) }
+
+		if call.streaming || strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+			resp.Body = &streamBody{ReadCloser: resp.Body, call: call, done: done}
+		} else {
+			resp.Body = &responseBody{ReadCloser: resp.Body, call: call, done: done}
+		}
+		return resp, nil
+	}
+}
+
+// call holds what is known about a request before its response arrives
+type call struct {
+	model      string
+	method     string
+	path       string
+	streaming  bool
+	start      time.Time
+	statusCode int
+	latency    time.Duration
+}
+
+// toolUse is a tool_use content block requested by the model
+type toolUse struct {
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// usage is the token usage reported by a Messages response
+type usage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+}
+
+// newCall reads the model and stream flag from the request body and restores it
+func newCall(req *http.Request) *call {
+	c := &call{method: req.Method, path: req.URL.Path, start: time.Now()}
+	if req.Body == nil || req.Body == http.NoBody {
+		return c
+	}
+
+	data, err := io.ReadAll(io.LimitReader(req.Body, maxCapture+1))
+	req.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), req.Body))
+	if err != nil || len(data) > maxCapture {
+		return c
+	}
+
+	var body struct {
+		Model  string `json:"model"`
+		Stream bool   `json:"stream"`
+	}
+	if json.Unmarshal(data, &body) == nil {
+		c.model, c.streaming = body.Model, body.Stream
+	}
+	return c
+}
+
+// event builds the base EventLLMInvoke for a call
+func (c *call) event() trusera.Event {
+	name := trusera.ProviderAnthropic
+	if c.model != "" {
+		name += " " + c.model
+	}
+	e := trusera.NewEvent(trusera.EventLLMInvoke, name).
+		WithPayload("provider", trusera.ProviderAnthropic).
+		WithPayload("model", c.model).
+		WithPayload("method", c.method).
+		WithPayload("path", c.path)
+	if c.statusCode != 0 {
+		e = e.WithPayload("status_code", c.statusCode).
+			WithPayload("latency_ms", c.latency.Milliseconds())
+	}
+	return e
+}
+
+// annotate adds response details to an event
+func annotate(e trusera.Event, model string, u *usage, stopReason string, tools []toolUse) trusera.Event {
+	if model != "" {
+		e = e.WithPayload("model", model)
+	}
+	if u != nil {
+		e = e.WithPayload("prompt_tokens", u.InputTokens).
+			WithPayload("completion_tokens", u.OutputTokens).
+			WithPayload("total_tokens", u.InputTokens+u.OutputTokens).
+			WithPayload("cache_read_tokens", u.CacheReadInputTokens).
+			WithPayload("cache_creation_tokens", u.CacheCreationInputTokens)
+	}
+	if stopReason != "" {
+		e = e.WithPayload("stop_reason", stopReason)
+	}
+	if len(tools) > 0 {
+		for i := range tools {
+			if len(tools[i].Arguments) > maxArguments {
+				tools[i].Arguments = tools[i].Arguments[:maxArguments] + "..."
+			}
+		}
+		e = e.WithPayload("tool_calls", tools)
+	}
+	return e
+}
+
+// responseBody buffers a bounded copy of a JSON response and reports it on EOF or Close
+type responseBody struct {
+	io.ReadCloser
+	call *call
+	done func(trusera.Event)
+	buf  bytes.Buffer
+	once sync.Once
+}
+
+func (b *responseBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && b.buf.Len() < maxCapture {
+		b.buf.Write(p[:min(n, maxCapture-b.buf.Len())])
+	}
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *responseBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *responseBody) finish() {
+	b.once.Do(func() { b.done(parseResponse(b.call.event(), b.buf.Bytes())) })
+}
+
+// parseResponse annotates an event from a Messages API response body
+func parseResponse(e trusera.Event, body []byte) trusera.Event {
+	var resp struct {
+		Model      string `json:"model"`
+		StopReason string `json:"stop_reason"`
+		Usage      *usage `json:"usage"`
+		Content    []struct {
+			Type  string          `json:"type"`
+			ID    string          `json:"id"`
+			Name  string          `json:"name"`
+			Input json.RawMessage `json:"input"`
+		} `json:"content"`
+	}
+	if json.Unmarshal(body, &resp) != nil {
+		return e
+	}
+
+	var tools []toolUse
+	for _, block := range resp.Content {
+		if block.Type == "tool_use" {
+			tools = append(tools, toolUse{ID: block.ID, Name: block.Name, Arguments: string(block.Input)})
+		}
+	}
+	return annotate(e, resp.Model, resp.Usage, resp.StopReason, tools)
+}
+
+// streamBody parses Messages API server-sent events as they are read and
+// reports delta timings, tool use blocks, usage and the stop reason on EOF or Close
+type streamBody struct {
+	io.ReadCloser
+	call *call
+	done func(trusera.Event)
+
+	pending    []byte
+	deltas     int
+	firstDelta time.Duration
+	lastDelta  time.Time
+	maxGap     time.Duration
+	model      string
+	stopReason string
+	usage      *usage
+	tools      map[int]*toolUse
+	order      []int
+	once       sync.Once
+}
+
+func (b *streamBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.feed(p[:n])
+	}
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *streamBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+// feed splits data into lines and handles each complete "data:" line
+func (b *streamBody) feed(data []byte) {
+	b.pending = append(b.pending, data...)
+	for {
+		i := bytes.IndexByte(b.pending, '\n')
+		if i < 0 {
+			break
+		}
+		line := bytes.TrimRight(b.pending[:i], "\r")
+		if payload, ok := bytes.CutPrefix(line, []byte("data:")); ok {
+			b.handle(bytes.TrimSpace(payload))
+		}
+		b.pending = b.pending[i+1:]
+	}
+	if len(b.pending) > maxCapture {
+		b.pending = nil
+	}
+}
+
+// handle records one streamed event
+func (b *streamBody) handle(payload []byte) {
+	var ev struct {
+		Type    string `json:"type"`
+		Index   int    `json:"index"`
+		Message struct {
+			Model string `json:"model"`
+			Usage *usage `json:"usage"`
+		} `json:"message"`
+		ContentBlock struct {
+			Type string `json:"type"`
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"content_block"`
+		Delta struct {
+			Type        string `json:"type"`
+			PartialJSON string `json:"partial_json"`
+			StopReason  string `json:"stop_reason"`
+		} `json:"delta"`
+		Usage *usage `json:"usage"`
+	}
+	if json.Unmarshal(payload, &ev) != nil {
+		return
+	}
+
+	switch ev.Type {
+	case "message_start":
+		b.model = ev.Message.Model
+		b.usage = ev.Message.Usage
+	case "content_block_start":
+		if ev.ContentBlock.Type == "tool_use" {
+			if b.tools == nil {
+				b.tools = make(map[int]*toolUse)
+			}
+			b.tools[ev.Index] = &toolUse{ID: ev.ContentBlock.ID, Name: ev.ContentBlock.Name}
+			b.order = append(b.order, ev.Index)
+		}
+	case "content_block_delta":
+		now := time.Now()
+		if b.deltas == 0 {
+			b.firstDelta = now.Sub(b.call.start)
+		} else if gap := now.Sub(b.lastDelta); gap > b.maxGap {
+			b.maxGap = gap
+		}
+		b.deltas++
+		b.lastDelta = now
+		if tool, ok := b.tools[ev.Index]; ok && ev.Delta.Type == "input_json_delta" && len(tool.Arguments) <= maxArguments {
+			tool.Arguments += ev.Delta.PartialJSON
+		}
+	case "message_delta":
+		if ev.Delta.StopReason != "" {
+			b.stopReason = ev.Delta.StopReason
+		}
+		if ev.Usage != nil {
+			if b.usage == nil {
+				b.usage = &usage{}
+			}
+			// message_delta carries cumulative output tokens
+			b.usage.OutputTokens = ev.Usage.OutputTokens
+		}
+	}
+}
+
+func (b *streamBody) finish() {
+	b.once.Do(func() {
+		e := b.call.event().
+			WithPayload("streaming", true).
+			WithPayload("delta_count", b.deltas).
+			WithPayload("stream_ms", time.Since(b.call.start).Milliseconds())
+		if b.deltas > 0 {
+			e = e.WithPayload("first_delta_ms", b.firstDelta.Milliseconds()).
+				WithPayload("max_delta_gap_ms", b.maxGap.Milliseconds())
+		}
+
+		var tools []toolUse
+		for _, i := range b.order {
+			tools = append(tools, *b.tools[i])
+		}
+		b.done(annotate(e, b.model, b.usage, b.stopReason, tools))
+	})
+}
@@ -0,0 +1,176 @@
+package anthropicsdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+const testModel = anthropic.Model("claude-sonnet-4-5")
+
+type recordingTransport struct {
+	mu     sync.Mutex
+	events []trusera.Event
+}
+
+func (r *recordingTransport) Send(_ context.Context, b trusera.Batch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, b.Events...)
+	return nil
+}
+
+func newTestClients(t *testing.T, handler http.HandlerFunc) (anthropic.Client, *trusera.Client, *recordingTransport) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	rt := &recordingTransport{}
+	tc := trusera.NewClient("test-key", trusera.WithTransport(rt))
+	t.Cleanup(func() { tc.Close() })
+
+	ac := anthropic.NewClient(
+		option.WithBaseURL(server.URL),
+		option.WithAPIKey("sk-ant-test"),
+		option.WithMaxRetries(0),
+		Option(tc),
+	)
+	return ac, tc, rt
+}
+
+func testParams() anthropic.MessageNewParams {
+	return anthropic.MessageNewParams{
+		Model:     testModel,
+		MaxTokens: 256,
+		Messages:  []anthropic.MessageParam{anthropic.NewUserMessage(anthropic.NewTextBlock("weather in Paris?"))},
+	}
+}
+
+func TestMiddlewareMessage(t *testing.T) {
+	ac, tc, rt := newTestClients(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{
+			"id": "msg_1", "type": "message", "role": "assistant", "model": "claude-sonnet-4-5-20250929",
+			"content": [
+				{"type": "text", "text": "Let me check."},
+				{"type": "tool_use", "id": "toolu_1", "name": "get_weather", "input": {"city": "Paris"}}
+			],
+			"stop_reason": "tool_use",
+			"usage": {"input_tokens": 30, "output_tokens": 12, "cache_read_input_tokens": 1000, "cache_creation_input_tokens": 50}
+		}`)
+	})
+
+	if _, err := ac.Messages.New(context.Background(), testParams()); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	tc.Flush()
+
+	if len(rt.events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(rt.events))
+	}
+	e := rt.events[0]
+	if e.Type != trusera.EventLLMInvoke || e.Payload["provider"] != trusera.ProviderAnthropic {
+		t.Errorf("expected anthropic llm_invoke, got %s %v", e.Type, e.Payload["provider"])
+	}
+	if e.Payload["model"] != "claude-sonnet-4-5-20250929" {
+		t.Errorf("expected response model, got %v", e.Payload["model"])
+	}
+	if e.Payload["stop_reason"] != "tool_use" {
+		t.Errorf("expected stop_reason tool_use, got %v", e.Payload["stop_reason"])
+	}
+	if e.Payload["cache_read_tokens"] != 1000 || e.Payload["cache_creation_tokens"] != 50 {
+		t.Errorf("expected cache tokens 1000/50, got %v/%v", e.Payload["cache_read_tokens"], e.Payload["cache_creation_tokens"])
+	}
+	if e.Payload["total_tokens"] != 42 {
+		t.Errorf("expected 42 total tokens, got %v", e.Payload["total_tokens"])
+	}
+	tools, _ := e.Payload["tool_calls"].([]toolUse)
+	if len(tools) != 1 || tools[0].Name != "get_weather" || tools[0].Arguments != `{"city": "Paris"}` {
+		t.Errorf("expected get_weather tool use, got %v", e.Payload["tool_calls"])
+	}
+}
+
+func TestMiddlewareStreaming(t *testing.T) {
+	ac, tc, rt := newTestClients(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		events := []string{
+			`{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[],"usage":{"input_tokens":25,"output_tokens":1,"cache_read_input_tokens":400}}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Checking"}}`,
+			`{"type":"content_block_stop","index":0}`,
+			`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"search","input":{}}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"q\":"}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"go\"}"}}`,
+			`{"type":"content_block_stop","index":1}`,
+			`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":17}}`,
+			`{"type":"message_stop"}`,
+		}
+		for _, ev := range events {
+			var typ struct{ Type string }
+			json.Unmarshal([]byte(ev), &typ)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", typ.Type, ev)
+			w.(http.Flusher).Flush()
+		}
+	})
+
+	stream := ac.Messages.NewStreaming(context.Background(), testParams())
+	for stream.Next() {
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	stream.Close()
+	tc.Flush()
+
+	if len(rt.events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(rt.events))
+	}
+	e := rt.events[0]
+	if e.Payload["streaming"] != true {
+		t.Error("expected streaming event")
+	}
+	if e.Payload["delta_count"] != 3 {
+		t.Errorf("expected 3 deltas, got %v", e.Payload["delta_count"])
+	}
+	if e.Payload["completion_tokens"] != 17 || e.Payload["prompt_tokens"] != 25 {
+		t.Errorf("expected 25/17 tokens, got %v/%v", e.Payload["prompt_tokens"], e.Payload["completion_tokens"])
+	}
+	if e.Payload["cache_read_tokens"] != 400 {
+		t.Errorf("expected 400 cache read tokens, got %v", e.Payload["cache_read_tokens"])
+	}
+	if e.Payload["stop_reason"] != "tool_use" {
+		t.Errorf("expected stop_reason tool_use, got %v", e.Payload["stop_reason"])
+	}
+	tools, _ := e.Payload["tool_calls"].([]toolUse)
+	if len(tools) != 1 || tools[0].Name != "search" || tools[0].Arguments != `{"q":"go"}` {
+		t.Errorf("expected accumulated search tool use, got %v", e.Payload["tool_calls"])
+	}
+}
+
+func TestMiddlewareIgnoresOtherEndpoints(t *testing.T) {
+	ac, tc, rt := newTestClients(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"input_tokens": 10}`)
+	})
+
+	_, err := ac.Messages.CountTokens(context.Background(), anthropic.MessageCountTokensParams{
+		Model:    testModel,
+		Messages: testParams().Messages,
+	})
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	tc.Flush()
+
+	if len(rt.events) != 0 {
+		t.Errorf("expected count_tokens to be ignored, got %d events", len(rt.events))
+	}
+}
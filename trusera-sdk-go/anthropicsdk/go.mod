@@ -0,0 +1,24 @@
+module github.com/Trusera/ai-bom/trusera-sdk-go/anthropicsdk
+
+go 1.24.0
+
+require (
+	github.com/Trusera/ai-bom/trusera-sdk-go v0.0.0
+	github.com/anthropics/anthropic-sdk-go v1.75.0
+)
+
+require (
+	github.com/bahlo/generic-list-go v0.2.0 // indirect
+	github.com/buger/jsonparser v1.1.2 // indirect
+	github.com/invopop/jsonschema v0.14.0 // indirect
+	github.com/pb33f/ordered-map/v2 v2.3.1 // indirect
+	github.com/standard-webhooks/standard-webhooks/libraries v0.0.1 // indirect
+	github.com/tidwall/gjson v1.18.0 // indirect
+	github.com/tidwall/match v1.1.1 // indirect
+	github.com/tidwall/pretty v1.2.1 // indirect
+	github.com/tidwall/sjson v1.2.5 // indirect
+	go.yaml.in/yaml/v4 v4.0.0-rc.2 // indirect
+	golang.org/x/sync v0.16.0 // indirect
+)
+
+replace github.com/Trusera/ai-bom/trusera-sdk-go => ../
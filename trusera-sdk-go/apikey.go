@@ -0,0 +1,75 @@
+package trusera
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// defaultAPIKeyFileInterval is how often WithAPIKeyFile checks for a new key
+const defaultAPIKeyFileInterval = 10 * time.Second
+
+// SetAPIKey replaces the API key used for subsequent API calls. It is safe to
+// call while events are being tracked and delivered.
+func (c *Client) SetAPIKey(key string) {
+	c.keyMu.Lock()
+	c.apiKey = key
+	c.keyMu.Unlock()
+}
+
+// currentAPIKey returns the API key in use
+func (c *Client) currentAPIKey() string {
+	c.keyMu.RLock()
+	defer c.keyMu.RUnlock()
+	return c.apiKey
+}
+
+// WithAPIKeyFile reads the API key from path and reloads it whenever the file
+// changes, so a rotated Kubernetes secret takes effect without a restart.
+// It takes precedence over the apiKey argument and TRUSERA_API_KEY.
+func WithAPIKeyFile(path string) Option {
+	return func(c *Client) {
+		c.apiKeyFile = path
+	}
+}
+
+// readAPIKeyFile returns the trimmed contents of the API key file
+func (c *Client) readAPIKeyFile() (string, error) {
+	data, err := os.ReadFile(c.apiKeyFile)
+	if err != nil {
+		return "", err
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", fmt.Errorf("API key file %s is empty", c.apiKeyFile)
+	}
+	return key, nil
+}
+
+// apiKeyFileLoop polls the API key file and applies changed keys. Contents are
+// compared rather than modification times because mounted secrets are swapped
+// through symlinks.
+func (c *Client) apiKeyFileLoop() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.apiKeyFileInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			key, err := c.readAPIKeyFile()
+			if err != nil {
+				c.logf(LogWarn, "API key reload failed, keeping current key: %v", err)
+				c.reportError(fmt.Errorf("API key reload: %w", err))
+				continue
+			}
+			if key != c.currentAPIKey() {
+				c.SetAPIKey(key)
+				c.logf(LogInfo, "API key reloaded from %s", c.apiKeyFile)
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
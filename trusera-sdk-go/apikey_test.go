@@ -0,0 +1,100 @@
+package trusera
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// authRecorder is a server that remembers the last Authorization header
+func authRecorder(t *testing.T) (*httptest.Server, *atomic.Value) {
+	t.Helper()
+	var auth atomic.Value
+	auth.Store("")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth.Store(r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"agent_id":"agent-1"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &auth
+}
+
+func TestSetAPIKey(t *testing.T) {
+	server, auth := authRecorder(t)
+	client := NewClient("old-key", WithBaseURL(server.URL))
+	defer client.Close()
+
+	client.SetAPIKey("new-key")
+	client.Track(NewEvent(EventToolCall, "tool"))
+	if err := client.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	if got := auth.Load(); got != "Bearer new-key" {
+		t.Errorf("expected rotated key on delivery, got %v", got)
+	}
+	if _, err := client.RegisterAgent("agent", "custom"); err != nil {
+		t.Fatalf("RegisterAgent failed: %v", err)
+	}
+	if got := auth.Load(); got != "Bearer new-key" {
+		t.Errorf("expected rotated key on registration, got %v", got)
+	}
+}
+
+func TestAPIKeyFileReload(t *testing.T) {
+	server, auth := authRecorder(t)
+	path := filepath.Join(t.TempDir(), "api-key")
+	if err := os.WriteFile(path, []byte("first-key\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClient("ignored", WithBaseURL(server.URL), WithAPIKeyFile(path), func(c *Client) {
+		c.apiKeyFileInterval = 10 * time.Millisecond
+	})
+	defer client.Close()
+
+	if got := client.currentAPIKey(); got != "first-key" {
+		t.Fatalf("expected key from file, got %q", got)
+	}
+
+	if err := os.WriteFile(path, []byte("second-key\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for client.currentAPIKey() != "second-key" && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	client.Track(NewEvent(EventToolCall, "tool"))
+	if err := client.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if got := auth.Load(); got != "Bearer second-key" {
+		t.Errorf("expected reloaded key, got %v", got)
+	}
+}
+
+func TestAPIKeyFileKeepsKeyWhenEmptied(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-key")
+	if err := os.WriteFile(path, []byte("first-key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClient("", WithAPIKeyFile(path), WithLogLevel(LogOff), func(c *Client) {
+		c.apiKeyFileInterval = 10 * time.Millisecond
+	})
+	defer client.Close()
+
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if got := client.currentAPIKey(); got != "first-key" {
+		t.Errorf("expected key kept after file was emptied, got %q", got)
+	}
+}
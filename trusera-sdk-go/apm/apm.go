@@ -0,0 +1,316 @@
+// Package apm mirrors Trusera client metrics and critical events into an
+// existing APM backend, such as Datadog through DogStatsD, or New Relic,
+// Grafana and others through OTLP, so teams moving to Trusera keep seeing the
+// numbers in their current dashboards:
+//
+//	bridge := apm.NewBridge(apm.NewDogStatsD("127.0.0.1:8125"), apm.Options{})
+//	client := trusera.NewClient(apiKey, trusera.WithEventProcessor(bridge.Process))
+//	bridge.Watch(client)
+//	defer bridge.Close()
+//
+// Events are still sent to Trusera; the bridge only observes them.
+package apm
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+// defaultInterval is how often points are exported
+const defaultInterval = 10 * time.Second
+
+// Kind is the type of a Point
+type Kind int
+
+const (
+	KindCount Kind = iota // Increase since the previous export
+	KindGauge             // Latest value
+)
+
+// Point is one metric value handed to an Exporter
+type Point struct {
+	Name  string
+	Kind  Kind
+	Value float64
+	Tags  map[string]string
+	Time  time.Time
+}
+
+// Exporter sends points to an APM backend
+type Exporter interface {
+	Export(ctx context.Context, points []Point) error
+	Close() error
+}
+
+// Options configures a Bridge
+type Options struct {
+	// Interval is how often points are exported, 10 seconds by default
+	Interval time.Duration
+	// Prefix is prepended to every metric name, "trusera." by default
+	Prefix string
+	// Tags are added to every point, such as the service or environment
+	Tags map[string]string
+	// OnError is called when an export fails; errors are dropped by default
+	OnError func(error)
+}
+
+// Bridge aggregates client statistics and events into points and exports
+// them periodically
+type Bridge struct {
+	exp  Exporter
+	opts Options
+
+	mu     sync.Mutex
+	counts map[string]*Point // By name and tags
+	gauges map[string]*Point
+	last   trusera.Stats // Statistics at the previous export
+	source interface{ Stats() trusera.Stats }
+
+	done      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// NewBridge creates a bridge exporting to exp. Pass Process to
+// trusera.WithEventProcessor to mirror events, and call Watch to mirror the
+// client's own metrics.
+func NewBridge(exp Exporter, opts Options) *Bridge {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultInterval
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = "trusera."
+	}
+	b := &Bridge{
+		exp:    exp,
+		opts:   opts,
+		counts: map[string]*Point{},
+		gauges: map[string]*Point{},
+		done:   make(chan struct{}),
+	}
+	b.wg.Add(1)
+	go b.loop()
+	return b
+}
+
+// Watch mirrors the statistics of a client, such as *trusera.Client, from
+// the next export on
+func (b *Bridge) Watch(source interface{ Stats() trusera.Stats }) {
+	b.mu.Lock()
+	b.source = source
+	b.last = source.Stats()
+	b.mu.Unlock()
+}
+
+// Process is a trusera.EventProcessor that records the metrics of critical
+// events: guardrail violations, crashes, LLM calls with their tokens, cost
+// and latency, and the client's counters and gauges. Every event is kept.
+func (b *Bridge) Process(e trusera.Event) (trusera.Event, bool) {
+	switch e.Type {
+	case trusera.EventGuardrailViolation:
+		b.count("guardrail.violations", 1, tags(e, "policy", "severity", "action"))
+	case trusera.EventCrash:
+		b.count("crashes", 1, nil)
+	case trusera.EventLLMInvoke:
+		t := tags(e, "provider", "model")
+		b.count("llm.calls", 1, t)
+		if _, failed := e.Payload["error"]; failed {
+			b.count("llm.errors", 1, t)
+		}
+		for _, key := range []string{"prompt_tokens", "completion_tokens", "cost_usd"} {
+			if v, ok := number(e.Payload[key]); ok {
+				b.count("llm."+key, v, t)
+			}
+		}
+		if v, ok := number(e.Payload["latency_ms"]); ok {
+			b.gauge("llm.latency_ms", v, t)
+		}
+	case trusera.EventMetric:
+		v, ok := number(e.Payload["value"])
+		if !ok {
+			break
+		}
+		if e.Payload["kind"] == trusera.MetricGauge {
+			b.gauge("app."+e.Name, v, nil)
+		} else {
+			b.count("app."+e.Name, v, nil)
+		}
+	}
+	return e, true
+}
+
+// Flush exports the points gathered since the previous export
+func (b *Bridge) Flush(ctx context.Context) error {
+	points := b.collect(time.Now())
+	if len(points) == 0 {
+		return nil
+	}
+	return b.exp.Export(ctx, points)
+}
+
+// Close exports the remaining points and closes the exporter
+func (b *Bridge) Close() error {
+	var err error
+	b.closeOnce.Do(func() {
+		close(b.done)
+		b.wg.Wait()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err = b.Flush(ctx)
+		if cerr := b.exp.Close(); err == nil {
+			err = cerr
+		}
+	})
+	return err
+}
+
+func (b *Bridge) loop() {
+	defer b.wg.Done()
+	ticker := time.NewTicker(b.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), b.opts.Interval)
+			if err := b.Flush(ctx); err != nil && b.opts.OnError != nil {
+				b.opts.OnError(err)
+			}
+			cancel()
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// collect returns and resets the aggregated points, adding the change in the
+// watched client's statistics
+func (b *Bridge) collect(now time.Time) []Point {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.source != nil {
+		s := b.source.Stats()
+		b.gaugeLocked("sdk.events.queued", float64(s.Queued), nil)
+		for _, c := range []struct {
+			name      string
+			cur, prev uint64
+		}{
+			{"sdk.events.tracked", s.Tracked, b.last.Tracked},
+			{"sdk.events.flushed", s.Flushed, b.last.Flushed},
+			{"sdk.events.dropped", s.Dropped, b.last.Dropped},
+			{"sdk.flush.errors", s.FlushErrors, b.last.FlushErrors},
+			{"sdk.rate_limited", s.RateLimited, b.last.RateLimited},
+			{"sdk.heartbeat.failures", s.HeartbeatFailures, b.last.HeartbeatFailures},
+		} {
+			if c.cur > c.prev {
+				b.countLocked(c.name, float64(c.cur-c.prev), nil)
+			}
+		}
+		b.last = s
+	}
+
+	points := make([]Point, 0, len(b.counts)+len(b.gauges))
+	for _, m := range []map[string]*Point{b.counts, b.gauges} {
+		for _, p := range m {
+			p.Time = now
+			points = append(points, *p)
+		}
+	}
+	b.counts = map[string]*Point{}
+	b.gauges = map[string]*Point{}
+	sort.Slice(points, func(i, j int) bool { return points[i].Name < points[j].Name })
+	return points
+}
+
+func (b *Bridge) count(name string, v float64, t map[string]string) {
+	b.mu.Lock()
+	b.countLocked(name, v, t)
+	b.mu.Unlock()
+}
+
+func (b *Bridge) gauge(name string, v float64, t map[string]string) {
+	b.mu.Lock()
+	b.gaugeLocked(name, v, t)
+	b.mu.Unlock()
+}
+
+func (b *Bridge) countLocked(name string, v float64, t map[string]string) {
+	p := b.point(b.counts, name, KindCount, t)
+	p.Value += v
+}
+
+func (b *Bridge) gaugeLocked(name string, v float64, t map[string]string) {
+	p := b.point(b.gauges, name, KindGauge, t)
+	p.Value = v
+}
+
+// point returns the aggregate for a name and tags, creating it if needed
+func (b *Bridge) point(m map[string]*Point, name string, kind Kind, t map[string]string) *Point {
+	name = b.opts.Prefix + name
+	all := make(map[string]string, len(b.opts.Tags)+len(t))
+	for k, v := range b.opts.Tags {
+		all[k] = v
+	}
+	for k, v := range t {
+		all[k] = v
+	}
+	key := name + "|" + tagKey(all)
+	p, ok := m[key]
+	if !ok {
+		p = &Point{Name: name, Kind: kind, Tags: all}
+		m[key] = p
+	}
+	return p
+}
+
+// tagKey returns a stable string for a tag set
+func tagKey(t map[string]string) string {
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(t[k])
+		sb.WriteByte(',')
+	}
+	return sb.String()
+}
+
+// tags picks string payload values of an event as tags
+func tags(e trusera.Event, keys ...string) map[string]string {
+	t := map[string]string{}
+	for _, k := range keys {
+		if v, ok := e.Payload[k].(string); ok && v != "" {
+			t[k] = v
+		}
+	}
+	return t
+}
+
+// number converts a numeric payload value
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,140 @@
+package apm
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+type fakeExporter struct {
+	mu     sync.Mutex
+	points []Point
+	closed bool
+}
+
+func (f *fakeExporter) Export(ctx context.Context, points []Point) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.points = append(f.points, points...)
+	return nil
+}
+
+func (f *fakeExporter) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
+
+func (f *fakeExporter) find(name string) (Point, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, p := range f.points {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Point{}, false
+}
+
+type fakeStats struct{ s trusera.Stats }
+
+func (f *fakeStats) Stats() trusera.Stats { return f.s }
+
+func TestBridgeProcessEvents(t *testing.T) {
+	exp := &fakeExporter{}
+	b := NewBridge(exp, Options{Interval: time.Hour, Tags: map[string]string{"env": "prod"}})
+
+	llm := trusera.NewEvent(trusera.EventLLMInvoke, "chat").
+		WithPayload("provider", "openai").
+		WithPayload("model", "gpt-4o").
+		WithPayload("prompt_tokens", 100).
+		WithPayload("completion_tokens", 20).
+		WithPayload("latency_ms", int64(350))
+	for i := 0; i < 2; i++ {
+		if _, keep := b.Process(llm); !keep {
+			t.Error("expected the event to be kept")
+		}
+	}
+	b.Process(trusera.NewEvent(trusera.EventGuardrailViolation, "pii").WithPayload("policy", "pii").WithPayload("severity", "high"))
+	b.Process(trusera.NewEvent(trusera.EventMetric, "queue_depth").WithPayload("kind", trusera.MetricGauge).WithPayload("value", 7.0))
+	b.Process(trusera.NewEvent(trusera.EventToolCall, "search"))
+
+	if err := b.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if !exp.closed {
+		t.Error("expected the exporter to be closed")
+	}
+
+	calls, ok := exp.find("trusera.llm.calls")
+	if !ok || calls.Value != 2 || calls.Kind != KindCount {
+		t.Errorf("expected 2 LLM calls, got %+v", calls)
+	}
+	if calls.Tags["model"] != "gpt-4o" || calls.Tags["env"] != "prod" {
+		t.Errorf("expected model and env tags, got %v", calls.Tags)
+	}
+	if p, _ := exp.find("trusera.llm.prompt_tokens"); p.Value != 200 {
+		t.Errorf("expected 200 prompt tokens, got %v", p.Value)
+	}
+	if p, _ := exp.find("trusera.llm.latency_ms"); p.Value != 350 || p.Kind != KindGauge {
+		t.Errorf("expected a 350ms latency gauge, got %+v", p)
+	}
+	if p, _ := exp.find("trusera.guardrail.violations"); p.Value != 1 || p.Tags["severity"] != "high" {
+		t.Errorf("expected one high severity violation, got %+v", p)
+	}
+	if p, _ := exp.find("trusera.app.queue_depth"); p.Value != 7 || p.Kind != KindGauge {
+		t.Errorf("expected the app gauge, got %+v", p)
+	}
+	if _, ok := exp.find("trusera.llm.errors"); ok {
+		t.Error("expected no LLM errors")
+	}
+}
+
+func TestBridgeWatchStats(t *testing.T) {
+	exp := &fakeExporter{}
+	b := NewBridge(exp, Options{Interval: time.Hour, Prefix: "ai."})
+	defer b.Close()
+
+	src := &fakeStats{trusera.Stats{Tracked: 10, Flushed: 8}}
+	b.Watch(src)
+	src.s = trusera.Stats{Tracked: 15, Flushed: 8, Dropped: 1, Queued: 4}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	if p, _ := exp.find("ai.sdk.events.tracked"); p.Value != 5 {
+		t.Errorf("expected 5 newly tracked events, got %v", p.Value)
+	}
+	if p, _ := exp.find("ai.sdk.events.dropped"); p.Value != 1 {
+		t.Errorf("expected 1 dropped event, got %v", p.Value)
+	}
+	if p, _ := exp.find("ai.sdk.events.queued"); p.Value != 4 || p.Kind != KindGauge {
+		t.Errorf("expected a queue gauge of 4, got %+v", p)
+	}
+	if _, ok := exp.find("ai.sdk.events.flushed"); ok {
+		t.Error("expected no point for an unchanged counter")
+	}
+}
+
+func TestBridgeExportsPeriodically(t *testing.T) {
+	exp := &fakeExporter{}
+	b := NewBridge(exp, Options{Interval: 10 * time.Millisecond})
+	defer b.Close()
+	b.Process(trusera.NewEvent(trusera.EventCrash, "panic"))
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if p, ok := exp.find("trusera.crashes"); ok {
+			if p.Value != 1 {
+				t.Errorf("expected one crash, got %v", p.Value)
+			}
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("expected the crash to be exported by the loop")
+}
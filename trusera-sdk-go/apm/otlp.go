@@ -0,0 +1,204 @@
+package apm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OTLP exports points as OTLP metrics over HTTP with JSON encoding, which
+// the OpenTelemetry Collector, New Relic, Grafana Cloud, Honeycomb and
+// Datadog accept. Counts are sent as monotonic delta sums.
+type OTLP struct {
+	// Headers are sent with every request, such as New Relic's "api-key"
+	Headers map[string]string
+	// ServiceName is the service.name resource attribute, by default
+	// OTEL_SERVICE_NAME or "trusera-sdk-go"
+	ServiceName string
+	// HTTPClient sends the requests; a client with a 10 second timeout by
+	// default
+	HTTPClient *http.Client
+
+	url string
+
+	mu   sync.Mutex
+	last time.Time // End of the previous export, the start of the next delta
+}
+
+// NewOTLP creates an exporter posting to endpoint's /v1/metrics, such as
+// "http://localhost:4318" or "https://otlp.nr-data.net". An empty endpoint
+// follows the OpenTelemetry environment variables:
+// OTEL_EXPORTER_OTLP_METRICS_ENDPOINT, then OTEL_EXPORTER_OTLP_ENDPOINT,
+// then http://localhost:4318. OTEL_EXPORTER_OTLP_HEADERS adds headers.
+func NewOTLP(endpoint string) *OTLP {
+	u := strings.TrimSuffix(endpoint, "/") + "/v1/metrics"
+	if endpoint == "" {
+		u = os.Getenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT")
+	}
+	if u == "" {
+		base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+		if base == "" {
+			base = "http://localhost:4318"
+		}
+		u = strings.TrimSuffix(base, "/") + "/v1/metrics"
+	}
+	o := &OTLP{
+		Headers:     map[string]string{},
+		ServiceName: os.Getenv("OTEL_SERVICE_NAME"),
+		HTTPClient:  &http.Client{Timeout: 10 * time.Second},
+		url:         u,
+		last:        time.Now(),
+	}
+	for _, kv := range strings.Split(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			o.Headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	if o.ServiceName == "" {
+		o.ServiceName = "trusera-sdk-go"
+	}
+	return o
+}
+
+// OTLP JSON encoding of ExportMetricsServiceRequest, limited to the fields
+// the exporter sets
+type (
+	otlpRequest struct {
+		ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+	}
+	otlpResourceMetrics struct {
+		Resource     otlpResource       `json:"resource"`
+		ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeMetrics struct {
+		Scope   otlpScope    `json:"scope"`
+		Metrics []otlpMetric `json:"metrics"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpMetric struct {
+		Name  string     `json:"name"`
+		Sum   *otlpSum   `json:"sum,omitempty"`
+		Gauge *otlpGauge `json:"gauge,omitempty"`
+	}
+	otlpSum struct {
+		DataPoints             []otlpDataPoint `json:"dataPoints"`
+		AggregationTemporality int             `json:"aggregationTemporality"`
+		IsMonotonic            bool            `json:"isMonotonic"`
+	}
+	otlpGauge struct {
+		DataPoints []otlpDataPoint `json:"dataPoints"`
+	}
+	otlpDataPoint struct {
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano,omitempty"`
+		TimeUnixNano      string          `json:"timeUnixNano"`
+		AsDouble          float64         `json:"asDouble"`
+	}
+	otlpAttribute struct {
+		Key   string `json:"key"`
+		Value struct {
+			StringValue string `json:"stringValue"`
+		} `json:"value"`
+	}
+)
+
+// aggregationDelta is AGGREGATION_TEMPORALITY_DELTA
+const aggregationDelta = 1
+
+// Export posts the points as one request, with points of the same name
+// grouped into one metric
+func (o *OTLP) Export(ctx context.Context, points []Point) error {
+	o.mu.Lock()
+	start := o.last
+	o.last = time.Now()
+	o.mu.Unlock()
+
+	var metrics []otlpMetric
+	index := map[string]int{}
+	for _, p := range points {
+		dp := otlpDataPoint{
+			Attributes:   attributes(p.Tags),
+			TimeUnixNano: strconv.FormatInt(p.Time.UnixNano(), 10),
+			AsDouble:     p.Value,
+		}
+		i, ok := index[p.Name]
+		if !ok {
+			i = len(metrics)
+			index[p.Name] = i
+			m := otlpMetric{Name: p.Name}
+			if p.Kind == KindGauge {
+				m.Gauge = &otlpGauge{}
+			} else {
+				m.Sum = &otlpSum{AggregationTemporality: aggregationDelta, IsMonotonic: true}
+			}
+			metrics = append(metrics, m)
+		}
+		if m := &metrics[i]; m.Gauge != nil {
+			m.Gauge.DataPoints = append(m.Gauge.DataPoints, dp)
+		} else {
+			dp.StartTimeUnixNano = strconv.FormatInt(start.UnixNano(), 10)
+			m.Sum.DataPoints = append(m.Sum.DataPoints, dp)
+		}
+	}
+
+	body, err := json.Marshal(otlpRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource:     otlpResource{Attributes: attributes(map[string]string{"service.name": o.ServiceName})},
+		ScopeMetrics: []otlpScopeMetrics{{Scope: otlpScope{Name: "github.com/Trusera/ai-bom/trusera-sdk-go/apm"}, Metrics: metrics}},
+	}}})
+	if err != nil {
+		return fmt.Errorf("failed to marshal metrics: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range o.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := o.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export metrics: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("OTLP endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// attributes converts tags to sorted OTLP string attributes
+func attributes(tags map[string]string) []otlpAttribute {
+	if len(tags) == 0 {
+		return nil
+	}
+	out := make([]otlpAttribute, 0, len(tags))
+	for k, v := range tags {
+		a := otlpAttribute{Key: k}
+		a.Value.StringValue = v
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// Close does nothing; the exporter holds no connections of its own
+func (o *OTLP) Close() error {
+	return nil
+}
@@ -0,0 +1,82 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOTLPExport(t *testing.T) {
+	var req otlpRequest
+	var apiKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/metrics" {
+			t.Errorf("expected /v1/metrics, got %s", r.URL.Path)
+		}
+		apiKey = r.Header.Get("Api-Key")
+		json.NewDecoder(r.Body).Decode(&req)
+	}))
+	defer srv.Close()
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "api-key=secret")
+	t.Setenv("OTEL_SERVICE_NAME", "checkout")
+
+	o := NewOTLP(srv.URL)
+	now := time.Now()
+	err := o.Export(context.Background(), []Point{
+		{Name: "trusera.llm.calls", Kind: KindCount, Value: 2, Tags: map[string]string{"model": "a"}, Time: now},
+		{Name: "trusera.llm.calls", Kind: KindCount, Value: 1, Tags: map[string]string{"model": "b"}, Time: now},
+		{Name: "trusera.sdk.events.queued", Kind: KindGauge, Value: 4, Time: now},
+	})
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if apiKey != "secret" {
+		t.Errorf("expected the api-key header, got %q", apiKey)
+	}
+
+	if len(req.ResourceMetrics) != 1 {
+		t.Fatalf("expected one resource, got %d", len(req.ResourceMetrics))
+	}
+	rm := req.ResourceMetrics[0]
+	if attrs := rm.Resource.Attributes; len(attrs) != 1 || attrs[0].Value.StringValue != "checkout" {
+		t.Errorf("expected service.name checkout, got %+v", attrs)
+	}
+	metrics := rm.ScopeMetrics[0].Metrics
+	if len(metrics) != 2 {
+		t.Fatalf("expected 2 metrics, got %d", len(metrics))
+	}
+	sum := metrics[0].Sum
+	if sum == nil || len(sum.DataPoints) != 2 || !sum.IsMonotonic || sum.AggregationTemporality != aggregationDelta {
+		t.Errorf("expected a delta sum with 2 points, got %+v", metrics[0])
+	} else if sum.DataPoints[0].StartTimeUnixNano == "" {
+		t.Error("expected a start time on sum points")
+	}
+	if g := metrics[1].Gauge; g == nil || g.DataPoints[0].AsDouble != 4 {
+		t.Errorf("expected a gauge of 4, got %+v", metrics[1])
+	}
+}
+
+func TestOTLPExportError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	if err := NewOTLP(srv.URL).Export(context.Background(), []Point{{Name: "x", Value: 1}}); err == nil {
+		t.Error("expected error for a 403 response")
+	}
+}
+
+func TestNewOTLPEndpointFromEnv(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "https://otlp.nr-data.net/")
+	if o := NewOTLP(""); o.url != "https://otlp.nr-data.net/v1/metrics" {
+		t.Errorf("expected the endpoint from the environment, got %s", o.url)
+	}
+	t.Setenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "http://collector:4318/custom")
+	if o := NewOTLP(""); o.url != "http://collector:4318/custom" {
+		t.Errorf("expected the metrics endpoint as is, got %s", o.url)
+	}
+}
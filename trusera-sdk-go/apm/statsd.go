@@ -0,0 +1,124 @@
+package apm
+
+import (
+	"context"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxPacket keeps DogStatsD datagrams below a typical network MTU
+const maxPacket = 1432
+
+// DogStatsD exports points to a StatsD or Datadog agent over UDP, with tags
+// in the DogStatsD "|#key:value" extension
+type DogStatsD struct {
+	addr string
+	// NoTags omits tags for plain StatsD servers that do not understand them
+	NoTags bool
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewDogStatsD creates an exporter sending to addr, such as
+// "127.0.0.1:8125". An empty addr uses DD_AGENT_HOST and DD_DOGSTATSD_PORT,
+// as the Datadog libraries do, falling back to 127.0.0.1:8125.
+func NewDogStatsD(addr string) *DogStatsD {
+	if addr == "" {
+		host, port := os.Getenv("DD_AGENT_HOST"), os.Getenv("DD_DOGSTATSD_PORT")
+		if host == "" {
+			host = "127.0.0.1"
+		}
+		if port == "" {
+			port = "8125"
+		}
+		addr = net.JoinHostPort(host, port)
+	}
+	return &DogStatsD{addr: addr}
+}
+
+// Export sends the points, packing as many lines into each datagram as fit
+func (d *DogStatsD) Export(ctx context.Context, points []Point) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.conn == nil {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "udp", d.addr)
+		if err != nil {
+			return err
+		}
+		d.conn = conn
+	}
+
+	var packet []byte
+	for _, p := range points {
+		line := d.line(p)
+		if len(packet) > 0 && len(packet)+1+len(line) > maxPacket {
+			if _, err := d.conn.Write(packet); err != nil {
+				return err
+			}
+			packet = packet[:0]
+		}
+		if len(packet) > 0 {
+			packet = append(packet, '\n')
+		}
+		packet = append(packet, line...)
+	}
+	if len(packet) > 0 {
+		_, err := d.conn.Write(packet)
+		return err
+	}
+	return nil
+}
+
+// line formats a point as "name:value|type|#key:value,..."
+func (d *DogStatsD) line(p Point) string {
+	var sb strings.Builder
+	sb.WriteString(statsdName(p.Name))
+	sb.WriteByte(':')
+	sb.WriteString(strconv.FormatFloat(p.Value, 'f', -1, 64))
+	if p.Kind == KindGauge {
+		sb.WriteString("|g")
+	} else {
+		sb.WriteString("|c")
+	}
+	if len(p.Tags) > 0 && !d.NoTags {
+		keys := make([]string, 0, len(p.Tags))
+		for k := range p.Tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		sb.WriteString("|#")
+		for i, k := range keys {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(statsdName(k))
+			sb.WriteByte(':')
+			sb.WriteString(statsdName(p.Tags[k]))
+		}
+	}
+	return sb.String()
+}
+
+// statsdReplacer replaces the characters that delimit StatsD fields
+var statsdReplacer = strings.NewReplacer(":", "_", "|", "_", ",", "_", "#", "_", "@", "_", "\n", "_")
+
+// statsdName makes s safe to use as a metric name, tag key or tag value
+func statsdName(s string) string {
+	return statsdReplacer.Replace(s)
+}
+
+// Close closes the UDP socket
+func (d *DogStatsD) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.conn == nil {
+		return nil
+	}
+	return d.conn.Close()
+}
@@ -0,0 +1,85 @@
+package apm
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDogStatsDExport(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer conn.Close()
+
+	d := NewDogStatsD(conn.LocalAddr().String())
+	defer d.Close()
+	err = d.Export(context.Background(), []Point{
+		{Name: "trusera.llm.calls", Kind: KindCount, Value: 3, Tags: map[string]string{"model": "gpt-4o", "env": "prod"}},
+		{Name: "trusera.llm.latency_ms", Kind: KindGauge, Value: 12.5},
+	})
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	buf := make([]byte, maxPacket)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	want := "trusera.llm.calls:3|c|#env:prod,model:gpt-4o\ntrusera.llm.latency_ms:12.5|g"
+	if got := string(buf[:n]); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestDogStatsDLine(t *testing.T) {
+	d := &DogStatsD{NoTags: true}
+	if got := d.line(Point{Name: "a:b|c", Value: 1, Tags: map[string]string{"k": "v"}}); got != "a_b_c:1|c" {
+		t.Errorf("expected sanitized name without tags, got %q", got)
+	}
+}
+
+func TestDogStatsDSplitsPackets(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer conn.Close()
+
+	d := NewDogStatsD(conn.LocalAddr().String())
+	defer d.Close()
+	points := make([]Point, 100)
+	for i := range points {
+		points[i] = Point{Name: "trusera." + strings.Repeat("x", 40), Value: 1}
+	}
+	if err := d.Export(context.Background(), points); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	lines := 0
+	buf := make([]byte, 65536)
+	for lines < len(points) {
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("read failed after %d lines: %v", lines, err)
+		}
+		if n > maxPacket {
+			t.Errorf("expected packets of at most %d bytes, got %d", maxPacket, n)
+		}
+		lines += strings.Count(string(buf[:n]), "\n") + 1
+	}
+}
+
+func TestNewDogStatsDFromEnv(t *testing.T) {
+	t.Setenv("DD_AGENT_HOST", "datadog")
+	t.Setenv("DD_DOGSTATSD_PORT", "9125")
+	if d := NewDogStatsD(""); d.addr != "datadog:9125" {
+		t.Errorf("expected datadog:9125, got %s", d.addr)
+	}
+}
@@ -0,0 +1,236 @@
+package trusera
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrAuditChainBroken is matched by the errors of VerifyAuditChain
+var ErrAuditChainBroken = errors.New("trusera: audit chain broken")
+
+// Audit outcomes
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+	AuditDenied  = "denied"
+)
+
+// AuditEvent is a sensitive agent action recorded in the audit trail, such
+// as issuing a refund or changing a permission
+type AuditEvent struct {
+	Action   string // What was done, such as "refund.issued", required
+	Actor    string // Who did it or asked for it: a user, service or agent
+	Resource string // What it was done to
+	Outcome  string // AuditSuccess (default), AuditFailure or AuditDenied
+	Reason   string
+	Details  map[string]any
+}
+
+// auditLink is the end of one agent's audit chain
+type auditLink struct {
+	chain string // Random ID of the chain, new for each client
+	seq   uint64
+	hash  string
+}
+
+// RecordAudit records an audit event linked to the active span in ctx.
+// Audit events are never sampled out, and each one, including EventAudit
+// events tracked directly, carries the hash of the previous audit event of
+// its agent in its metadata, so the backend and auditors can detect events
+// that were altered or are missing with VerifyAuditChain:
+//
+//   - audit_chain: random ID of the agent's chain, new for each client
+//   - audit_seq: position in the chain, from 1
+//   - audit_prev_hash: audit_hash of the previous event, empty for the first
+//   - audit_hash: SHA-256 of audit_prev_hash, the agent ID, audit_chain,
+//     audit_seq and the event's ID, type, name, timestamp and payload
+//
+// Events are chained as they are queued, after redaction and processors, so
+// events discarded before then are not part of the chain, while events lost
+// after, such as ones evicted from a full queue, leave a gap. An event too
+// large for a batch is truncated before it is chained, never after.
+func (c *Client) RecordAudit(ctx context.Context, a AuditEvent) error {
+	return recordAudit(c, ctx, a)
+}
+
+// RecordAudit records an audit event in the agent's own audit chain
+func (a *Agent) RecordAudit(ctx context.Context, ev AuditEvent) error {
+	return recordAudit(a, ctx, ev)
+}
+
+// recordAudit builds the audit event for a and tracks it with t
+func recordAudit(t Tracker, ctx context.Context, a AuditEvent) error {
+	if a.Action == "" {
+		return errors.New("audit action is required")
+	}
+	if a.Outcome == "" {
+		a.Outcome = AuditSuccess
+	}
+	event := NewEvent(EventAudit, a.Action).
+		WithPayload("action", a.Action).
+		WithPayload("outcome", a.Outcome)
+	if a.Actor != "" {
+		event = event.WithPayload("actor", a.Actor)
+	}
+	if a.Resource != "" {
+		event = event.WithPayload("resource", a.Resource)
+	}
+	if a.Reason != "" {
+		event = event.WithPayload("reason", a.Reason)
+	}
+	if len(a.Details) > 0 {
+		event = event.WithPayload("details", a.Details)
+	}
+	trackContext(t, ctx, event)
+	return nil
+}
+
+// auditChainReserve is kept free in a batch for the chain metadata and the
+// fields stamped after chaining, when shrinking an oversized audit event
+const auditChainReserve = 512
+
+// chainAudit links an audit event to the previous one of its agent, setting
+// its audit_chain, audit_seq, audit_prev_hash and audit_hash metadata. The
+// caller holds c.mu, so chains follow queue order.
+func (c *Client) chainAudit(e *Event) {
+	// Truncate an oversized event now, as fitEvents cannot once it is hashed
+	if limit := c.maxBatchBytes - batchOverhead - auditChainReserve; limit > 0 && shrinkable(*e) {
+		if size, err := encodedSize(*e); err == nil && size > limit {
+			*e, _ = shrinkEvent(*e, size, limit)
+			c.stats.truncated++
+		}
+	}
+
+	agentID, _ := e.Metadata["agent_id"].(string)
+	if c.auditChains == nil {
+		c.auditChains = make(map[string]auditLink)
+	}
+	prev, ok := c.auditChains[agentID]
+	if !ok {
+		prev.chain = generateID()
+	}
+	next := auditLink{chain: prev.chain, seq: prev.seq + 1}
+	next.hash = auditHash(prev.hash, agentID, next.chain, next.seq, *e)
+	c.auditChains[agentID] = next
+
+	*e = withTags(*e, map[string]any{
+		"audit_chain":     next.chain,
+		"audit_seq":       next.seq,
+		"audit_prev_hash": prev.hash,
+		"audit_hash":      next.hash,
+	})
+}
+
+// auditHash returns the hex SHA-256 of the previous hash, the chain
+// position and the event's ID, type, name, timestamp and payload, one per
+// line, with the payload in its canonical JSON encoding
+func auditHash(prevHash, agentID, chain string, seq uint64, e Event) string {
+	payload, err := canonicalJSON(e.Payload)
+	if err != nil {
+		payload = []byte(fmt.Sprint(e.Payload))
+	}
+	h := sha256.New()
+	for _, field := range []string{prevHash, agentID, chain, strconv.FormatUint(seq, 10), e.ID, string(e.Type), e.Name, e.Timestamp} {
+		h.Write([]byte(field))
+		h.Write([]byte{'\n'})
+	}
+	h.Write(payload)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// canonicalJSON encodes v the way it encodes after a JSON round trip, so a
+// payload hashes the same as tracked and as read back: structs become
+// objects with sorted keys and numbers are decoded as float64 and written as
+// encoding/json writes them, which also rounds integers beyond 2^53 alike
+func canonicalJSON(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	return json.Marshal(decoded)
+}
+
+// VerifyAuditChain checks the audit events among events, such as those read
+// back from the API or a local sink, and returns an error wrapping
+// ErrAuditChainBroken at the first event that was altered, or that does not
+// follow the previous event of its chain because events are missing. Events
+// may be in any order. Each chain is checked from the lowest sequence number
+// present, so pass a chain from its start, at sequence 1, to also detect
+// events missing before it.
+func VerifyAuditChain(events []Event) error {
+	chains := map[string][]Event{}
+	var order []string
+	for _, e := range events {
+		if e.Type != EventAudit {
+			continue
+		}
+		chain, _ := e.Metadata["audit_chain"].(string)
+		if chain == "" {
+			return fmt.Errorf("%w: event %s is not chained", ErrAuditChainBroken, e.ID)
+		}
+		if _, ok := chains[chain]; !ok {
+			order = append(order, chain)
+		}
+		chains[chain] = append(chains[chain], e)
+	}
+
+	for _, chain := range order {
+		byseq := map[uint64]Event{}
+		var first, last uint64
+		for _, e := range chains[chain] {
+			seq, ok := auditSeq(e.Metadata["audit_seq"])
+			if !ok {
+				return fmt.Errorf("%w: event %s has no sequence", ErrAuditChainBroken, e.ID)
+			}
+			byseq[seq] = e
+			if first == 0 || seq < first {
+				first = seq
+			}
+			if seq > last {
+				last = seq
+			}
+		}
+
+		prevHash, _ := byseq[first].Metadata["audit_prev_hash"].(string)
+		for seq := first; seq <= last; seq++ {
+			e, ok := byseq[seq]
+			if !ok {
+				return fmt.Errorf("%w: chain %s is missing event %d", ErrAuditChainBroken, chain, seq)
+			}
+			agentID, _ := e.Metadata["agent_id"].(string)
+			claimed, _ := e.Metadata["audit_prev_hash"].(string)
+			hash, _ := e.Metadata["audit_hash"].(string)
+			if claimed != prevHash {
+				return fmt.Errorf("%w: event %d of chain %s does not follow event %d", ErrAuditChainBroken, seq, chain, seq-1)
+			}
+			if auditHash(prevHash, agentID, chain, seq, e) != hash {
+				return fmt.Errorf("%w: event %d of chain %s was altered", ErrAuditChainBroken, seq, chain)
+			}
+			prevHash = hash
+		}
+	}
+	return nil
+}
+
+// auditSeq reads a sequence number as tracked or as decoded from JSON
+func auditSeq(v any) (uint64, bool) {
+	switch v := v.(type) {
+	case uint64:
+		return v, v > 0
+	case float64:
+		return uint64(v), v >= 1 && v == float64(uint64(v))
+	case json.Number:
+		n, err := strconv.ParseUint(string(v), 10, 64)
+		return n, err == nil && n > 0
+	}
+	return 0, false
+}
@@ -0,0 +1,144 @@
+package trusera
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRecordAuditChainsEvents(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+	agent := client.ForAgent("billing")
+
+	for _, action := range []string{"refund.issued", "role.granted"} {
+		if err := client.RecordAudit(context.Background(), AuditEvent{Action: action, Actor: "alice", Resource: "order-1"}); err != nil {
+			t.Fatalf("RecordAudit failed: %v", err)
+		}
+	}
+	agent.RecordAudit(context.Background(), AuditEvent{Action: "invoice.voided", Outcome: AuditDenied, Reason: "over limit"})
+	client.Track(NewEvent(EventAudit, "key.rotated").WithPayload("action", "key.rotated"))
+
+	events := queuedEvents(client)
+	if len(events) != 4 {
+		t.Fatalf("expected 4 events, got %d", len(events))
+	}
+	first, second, agentEvent, third := events[0], events[1], events[2], events[3]
+	if first.Payload["outcome"] != AuditSuccess || first.Payload["actor"] != "alice" {
+		t.Errorf("unexpected audit payload: %v", first.Payload)
+	}
+	if first.Metadata["audit_seq"] != uint64(1) || first.Metadata["audit_prev_hash"] != "" {
+		t.Errorf("expected the chain to start at 1, got %v", first.Metadata)
+	}
+	if second.Metadata["audit_seq"] != uint64(2) || second.Metadata["audit_prev_hash"] != first.Metadata["audit_hash"] {
+		t.Errorf("expected the second event linked to the first, got %v", second.Metadata)
+	}
+	if third.Metadata["audit_seq"] != uint64(3) || third.Metadata["audit_chain"] != first.Metadata["audit_chain"] {
+		t.Errorf("expected directly tracked audit events chained, got %v", third.Metadata)
+	}
+	if agentEvent.Metadata["audit_seq"] != uint64(1) || agentEvent.Metadata["audit_chain"] == first.Metadata["audit_chain"] {
+		t.Errorf("expected the agent to have its own chain, got %v", agentEvent.Metadata)
+	}
+
+	if err := VerifyAuditChain([]Event{third, agentEvent, first, second}); err != nil {
+		t.Errorf("expected the chains to verify in any order, got %v", err)
+	}
+}
+
+func TestVerifyAuditChainAfterJSON(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	// Struct fields come back in sorted order and large integers as float64
+	type target struct {
+		Table string `json:"table"`
+		Rows  int    `json:"rows"`
+	}
+	client.RecordAudit(context.Background(), AuditEvent{Action: "export", Details: map[string]any{
+		"target": target{Table: "customers", Rows: 1200},
+		"id":     uint64(1)<<60 + 1,
+		"ratio":  0.1,
+	}})
+	client.RecordAudit(context.Background(), AuditEvent{Action: "delete", Resource: "customers/42"})
+
+	data, err := json.Marshal(queuedEvents(client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []Event
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyAuditChain(decoded); err != nil {
+		t.Errorf("expected events read back from JSON to verify, got %v", err)
+	}
+}
+
+func TestAuditChainSurvivesBatchSizeLimit(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000), WithMaxBatchBytes(2048))
+	defer client.Close()
+
+	client.RecordAudit(context.Background(), AuditEvent{Action: "export", Reason: strings.Repeat("x", 10000)})
+	client.RecordAudit(context.Background(), AuditEvent{Action: "delete"})
+
+	events, _ := client.fitEvents(queuedEvents(client))
+	if len(events) != 2 {
+		t.Fatalf("expected both events kept, got %d", len(events))
+	}
+	if events[0].Metadata["truncated_from_bytes"] == nil {
+		t.Errorf("expected the oversized event truncated, got %v", events[0].Metadata)
+	}
+	if err := VerifyAuditChain(events); err != nil {
+		t.Errorf("expected the truncated event to verify, got %v", err)
+	}
+}
+
+func TestVerifyAuditChainDetectsTampering(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+	for _, action := range []string{"a", "b", "c"} {
+		client.RecordAudit(context.Background(), AuditEvent{Action: action, Resource: "r"})
+	}
+	events := queuedEvents(client)
+
+	altered := append([]Event(nil), events...)
+	altered[1].Payload = map[string]any{"action": "b", "outcome": AuditSuccess, "resource": "other"}
+	if err := VerifyAuditChain(altered); !errors.Is(err, ErrAuditChainBroken) {
+		t.Errorf("expected an altered payload detected, got %v", err)
+	}
+
+	if err := VerifyAuditChain([]Event{events[0], events[2]}); !errors.Is(err, ErrAuditChainBroken) {
+		t.Errorf("expected a missing event detected, got %v", err)
+	}
+
+	rechained := append([]Event(nil), events...)
+	rechained[2] = withTags(rechained[2], map[string]any{"audit_prev_hash": events[0].Metadata["audit_hash"]})
+	if err := VerifyAuditChain(rechained); !errors.Is(err, ErrAuditChainBroken) {
+		t.Errorf("expected a relinked event detected, got %v", err)
+	}
+
+	if err := VerifyAuditChain(events[1:]); err != nil {
+		t.Errorf("expected a window of the chain to verify, got %v", err)
+	}
+}
+
+func TestRecordAuditRequiresAction(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	if err := client.RecordAudit(context.Background(), AuditEvent{}); err == nil {
+		t.Error("expected error for missing action")
+	}
+}
+
+func TestAuditBypassesSampling(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000), WithSampler(Probabilistic(0)))
+	defer client.Close()
+
+	client.RecordAudit(context.Background(), AuditEvent{Action: "login"})
+	if got := len(queuedEvents(client)); got != 1 {
+		t.Errorf("expected audit events kept, got %d", got)
+	}
+}
@@ -0,0 +1,345 @@
+package trusera
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	// defaultMaxBatchBytes keeps request bodies well under the API's limit
+	defaultMaxBatchBytes = 4 << 20
+
+	// batchOverhead is reserved in every batch for the envelope around the
+	// events: the agent ID, field names and brackets
+	batchOverhead = 256
+
+	// truncatedSuffix marks a string shortened to fit the batch size limit
+	truncatedSuffix = "...[truncated]"
+
+	// maxTruncations bounds the passes made to shrink a single event
+	maxTruncations = 16
+)
+
+// ErrEventTooLarge is reported when an event is dropped because it does not
+// fit in a batch even after its strings are truncated
+var ErrEventTooLarge = errors.New("trusera: event too large")
+
+// ErrUnencodable is reported when an event is dropped because it cannot be
+// encoded as JSON, for example when its payload holds a NaN or a channel.
+// Transports wrap it when a batch fails to encode, so that delivery drops
+// the batch instead of retrying it and counting it against the breaker.
+var ErrUnencodable = errors.New("trusera: event cannot be encoded")
+
+// WithMaxBatchBytes sets the largest batch sent in one request, measured as
+// uncompressed JSON (default 4 MiB). Larger batches are split. An event that
+// does not fit on its own has its longest payload and metadata strings
+// truncated, and is dropped if it still does not fit.
+func WithMaxBatchBytes(n int) Option {
+	return func(c *Client) {
+		if n > batchOverhead {
+			c.maxBatchBytes = n
+		}
+	}
+}
+
+// fitEvents shrinks or drops events that cannot fit in a batch on their own
+// and returns the rest with their encoded sizes. When an upper bound on the
+// size shows the whole batch fits, the events are not encoded and the
+// bounds are returned instead.
+func (c *Client) fitEvents(events []Event) ([]Event, []int) {
+	if sizes, ok := c.boundEvents(events); ok {
+		return events, sizes
+	}
+
+	limit := c.maxBatchBytes - batchOverhead
+	out := events[:0]
+	sizes := make([]int, 0, len(events))
+	for _, e := range events {
+		size, err := encodedSize(e)
+		if err != nil {
+			c.logf(LogWarn, "dropping %s event %q: %v", e.Type, e.Name, err)
+			c.reportError(fmt.Errorf("%s event %q: %w: %w", e.Type, e.Name, ErrUnencodable, err))
+			c.mu.Lock()
+			c.stats.dropped++
+			c.mu.Unlock()
+			continue
+		}
+		if size > limit {
+			from := size
+			if shrinkable(e) {
+				e, size = shrinkEvent(e, size, limit)
+			}
+			if size > limit {
+				c.logf(LogWarn, "dropping %s event %q of %d bytes: larger than the %d byte batch limit", e.Type, e.Name, from, c.maxBatchBytes)
+				c.reportError(fmt.Errorf("%s event %q of %d bytes: %w", e.Type, e.Name, from, ErrEventTooLarge))
+				c.mu.Lock()
+				c.stats.dropped++
+				c.mu.Unlock()
+				continue
+			}
+			c.logf(LogDebug, "truncated %s event %q from %d to %d bytes", e.Type, e.Name, from, size)
+			c.mu.Lock()
+			c.stats.truncated++
+			c.mu.Unlock()
+		}
+		out = append(out, e)
+		sizes = append(sizes, size)
+	}
+	return out, sizes
+}
+
+// sendFitted sends events in batches of at most maxBatchBytes, stopping at
+// the first failure. Rejected events are dead-lettered.
+func (c *Client) sendFitted(ctx context.Context, events []Event) error {
+	events, sizes := c.fitEvents(events)
+	for len(events) > 0 {
+		n := c.batchLen(sizes)
+		err := c.sendEvents(ctx, events[:n])
+		if errors.Is(err, ErrUnencodable) {
+			c.dropUnencodable(events[:n], err)
+			err = nil
+		}
+		if errors.Is(err, ErrRejected) {
+			n, err = c.isolateRejected(ctx, events[:n], err)
+		}
+		if err != nil {
+			return err
+		}
+		events, sizes = events[n:], sizes[n:]
+	}
+	return nil
+}
+
+// dropUnencodable drops a batch that a transport failed to encode. fitEvents
+// drops events that fail to encode as JSON, so this is left for encodings
+// that differ, such as that of GRPCTransport.
+func (c *Client) dropUnencodable(events []Event, err error) {
+	c.logf(LogWarn, "dropping %d events: %v", len(events), err)
+	c.reportError(err)
+	c.mu.Lock()
+	c.stats.dropped += uint64(len(events))
+	c.mu.Unlock()
+}
+
+// batchLen returns how many of the leading events fit in one batch, at
+// least one
+func (c *Client) batchLen(sizes []int) int {
+	total := batchOverhead
+	for i, size := range sizes {
+		total += size + 1 // Separating comma
+		if total > c.maxBatchBytes && i > 0 {
+			return i
+		}
+	}
+	return len(sizes)
+}
+
+// shrinkable reports whether shrinkEvent may truncate e. Truncating a
+// chained audit event would break its hash, and those were fitted before
+// chaining. Truncating the ciphertext of an encrypted field would make it
+// undecryptable, so such events are sent whole or dropped.
+func shrinkable(e Event) bool {
+	_, chained := e.Metadata["audit_hash"]
+	_, encrypted := e.Metadata["encrypted_fields"]
+	return !chained && !encrypted
+}
+
+// shrinkEvent truncates the longest strings in the event's payload and
+// metadata until it encodes to at most limit bytes, recording the original
+// size in the truncated_from_bytes metadata. It returns the event with its
+// new size, which is still over limit if there was nothing left to cut.
+func shrinkEvent(e Event, size, limit int) (Event, int) {
+	from := size
+	metadata := make(map[string]any, len(e.Metadata)+1)
+	for k, v := range e.Metadata {
+		metadata[k] = v
+	}
+	metadata["truncated_from_bytes"] = from
+	e.Metadata = metadata
+	// Cutting strings cannot make an encodable event fail to encode
+	size, _ = encodedSize(e)
+
+	for i := 0; i < maxTruncations && size > limit; i++ {
+		root, path, s := longestString(map[string]any{"payload": e.Payload, "metadata": e.Metadata}, nil)
+		if len(s) <= len(truncatedSuffix) {
+			break
+		}
+		// JSON escaping makes encoded strings longer than their bytes, so
+		// cutting the excess may not be enough and the loop tries again
+		keep := len(s) - (size - limit) - len(truncatedSuffix)
+		if keep < 0 {
+			keep = 0
+		}
+		for keep > 0 && !utf8.RuneStart(s[keep]) {
+			keep--
+		}
+		cut := s[:keep] + truncatedSuffix
+		if root == "payload" {
+			e.Payload = redactPath(e.Payload, path, cut)
+		} else {
+			e.Metadata = redactPath(e.Metadata, path, cut)
+		}
+		size, _ = encodedSize(e)
+	}
+	return e, size
+}
+
+// longestString finds the longest string in m and its nested maps,
+// returning the top-level key it sits under, its path below that key and
+// its value
+func longestString(m map[string]any, prefix []string) (root string, path []string, s string) {
+	for k, v := range m {
+		keys := append(prefix[:len(prefix):len(prefix)], k)
+		switch v := v.(type) {
+		case string:
+			if len(v) > len(s) {
+				path, s = keys, v
+			}
+		case map[string]any:
+			if _, p, nested := longestString(v, keys); len(nested) > len(s) {
+				path, s = p, nested
+			}
+		}
+	}
+	if len(path) > 0 && prefix == nil {
+		return path[0], path[1:], s
+	}
+	return "", path, s
+}
+
+// boundEvents returns upper bounds on the encoded sizes of events if they
+// are known and the events fit in one batch
+func (c *Client) boundEvents(events []Event) ([]int, bool) {
+	sizes := make([]int, len(events))
+	total := batchOverhead
+	for i, e := range events {
+		size, ok := maxEventSize(e)
+		if !ok {
+			return nil, false
+		}
+		sizes[i] = size
+		if total += size + 1; total > c.maxBatchBytes {
+			return nil, false
+		}
+	}
+	return sizes, true
+}
+
+// eventOverhead bounds the encoded size of an event's field names, quotes,
+// sequence and clock skew
+const eventOverhead = 160
+
+// maxEventSize returns an upper bound on the size of e encoded as JSON, and
+// false if it holds values the bound does not cover
+func maxEventSize(e Event) (int, bool) {
+	payload, ok := maxJSONSize(e.Payload, 0)
+	if !ok {
+		return 0, false
+	}
+	metadata, ok := maxJSONSize(e.Metadata, 0)
+	if !ok {
+		return 0, false
+	}
+	return eventOverhead + maxStringSize(e.ID) + maxStringSize(string(e.Type)) +
+		maxStringSize(e.Name) + maxStringSize(e.Timestamp) + payload + metadata, true
+}
+
+// maxJSONDepth stops maxJSONSize from following deeply nested or cyclic values
+const maxJSONDepth = 32
+
+// maxJSONSize returns an upper bound on the size of v encoded as JSON for the
+// types events commonly hold
+func maxJSONSize(v any, depth int) (int, bool) {
+	if depth > maxJSONDepth {
+		return 0, false
+	}
+	switch v := v.(type) {
+	case nil, bool:
+		return 5, true
+	case string:
+		return maxStringSize(v), true
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return 20, true
+	case float32:
+		return 24, !math.IsNaN(float64(v)) && !math.IsInf(float64(v), 0)
+	case float64:
+		// NaN and infinities fail to encode, which only encoding reports
+		return 24, !math.IsNaN(v) && !math.IsInf(v, 0)
+	case []string:
+		n := 2
+		for _, s := range v {
+			n += maxStringSize(s) + 1
+		}
+		return n, true
+	case map[string]string:
+		n := 2
+		for k, s := range v {
+			n += maxStringSize(k) + maxStringSize(s) + 2
+		}
+		return n, true
+	case []any:
+		n := 2
+		for _, x := range v {
+			size, ok := maxJSONSize(x, depth+1)
+			if !ok {
+				return 0, false
+			}
+			n += size + 1
+		}
+		return n, true
+	case map[string]any:
+		n := 2
+		for k, x := range v {
+			size, ok := maxJSONSize(x, depth+1)
+			if !ok {
+				return 0, false
+			}
+			n += maxStringSize(k) + size + 2
+		}
+		return n, true
+	}
+	return 0, false
+}
+
+// maxStringSize bounds the encoded size of s, where every byte may be
+// escaped as \u00XX
+func maxStringSize(s string) int {
+	return 6*len(s) + 2
+}
+
+// sizer measures encoded JSON without keeping it
+type sizer struct {
+	n   countingWriter
+	enc *json.Encoder
+}
+
+// countingWriter counts the bytes written to it and discards them
+type countingWriter int
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	*w += countingWriter(len(p))
+	return len(p), nil
+}
+
+var sizerPool = sync.Pool{New: func() any {
+	s := new(sizer)
+	s.enc = json.NewEncoder(&s.n)
+	return s
+}}
+
+// encodedSize returns the size of e encoded as JSON, or the error encoding
+// it fails with
+func encodedSize(e Event) (int, error) {
+	s := sizerPool.Get().(*sizer)
+	defer sizerPool.Put(s)
+	s.n = 0
+	if err := s.enc.Encode(e); err != nil {
+		return 0, err
+	}
+	return int(s.n) - 1, nil // Encode ends with a newline
+}
@@ -0,0 +1,216 @@
+package trusera
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLargeBatchIsSplitBySize(t *testing.T) {
+	rt := &recordingTransport{}
+	client := NewClient("k", WithTransport(rt), WithMaxBatchBytes(4096), WithFlushInterval(time.Hour))
+	defer client.Close()
+
+	for i := 0; i < 10; i++ {
+		client.Track(NewEvent(EventLLMInvoke, "chat").WithPayload("prompt", strings.Repeat("x", 1000)))
+	}
+	if err := client.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if len(rt.batches) < 3 {
+		t.Fatalf("expected the events to be split into at least 3 batches, got %d", len(rt.batches))
+	}
+	total := 0
+	for _, b := range rt.batches {
+		data, _ := json.Marshal(b)
+		if len(data) > 4096 {
+			t.Errorf("expected batches of at most 4096 bytes, got %d", len(data))
+		}
+		total += len(b.Events)
+	}
+	if total != 10 {
+		t.Errorf("expected 10 events delivered, got %d", total)
+	}
+}
+
+func TestOversizedEventIsTruncated(t *testing.T) {
+	rt := &recordingTransport{}
+	client := NewClient("k", WithTransport(rt), WithMaxBatchBytes(2048), WithFlushInterval(time.Hour))
+	defer client.Close()
+
+	client.Track(NewEvent(EventLLMInvoke, "chat").
+		WithPayload("model", "gpt-4o").
+		WithPayload("prompt", strings.Repeat("é", 5000)))
+	if err := client.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if len(rt.batches) != 1 || len(rt.batches[0].Events) != 1 {
+		t.Fatalf("expected one event delivered, got %+v", rt.batches)
+	}
+	e := rt.batches[0].Events[0]
+	prompt, _ := e.Payload["prompt"].(string)
+	if !strings.HasSuffix(prompt, truncatedSuffix) {
+		t.Errorf("expected the prompt to be truncated, got %d bytes", len(prompt))
+	}
+	if !strings.HasPrefix(prompt, "é") || !json.Valid([]byte(`"`+strings.TrimSuffix(prompt, truncatedSuffix)+`"`)) {
+		t.Errorf("expected truncation on a character boundary")
+	}
+	if e.Payload["model"] != "gpt-4o" {
+		t.Errorf("expected short fields to be kept, got %v", e.Payload["model"])
+	}
+	if e.Metadata["truncated_from_bytes"] == nil {
+		t.Error("expected truncated_from_bytes metadata")
+	}
+	if size, _ := encodedSize(e); size > 2048 {
+		t.Errorf("expected the event to fit in the batch, got %d bytes", size)
+	}
+	if stats := client.Stats(); stats.Truncated != 1 {
+		t.Errorf("expected 1 truncated event, got %d", stats.Truncated)
+	}
+}
+
+func TestEventThatCannotShrinkIsDropped(t *testing.T) {
+	rt := &recordingTransport{}
+	var reported error
+	client := NewClient("k", WithTransport(rt), WithMaxBatchBytes(1024), WithFlushInterval(time.Hour),
+		WithErrorHandler(func(err error) { reported = err }))
+	defer client.Close()
+
+	big := NewEvent(EventToolCall, "search")
+	for i := 0; i < 200; i++ {
+		big = big.WithPayload(fmt.Sprintf("field_%03d", i), i)
+	}
+	client.Track(big)
+	client.Track(NewEvent(EventToolCall, "small"))
+	if err := client.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if len(rt.batches) != 1 || len(rt.batches[0].Events) != 1 || rt.batches[0].Events[0].Name != "small" {
+		t.Fatalf("expected only the small event delivered, got %+v", rt.batches)
+	}
+	if !errors.Is(reported, ErrEventTooLarge) {
+		t.Errorf("expected ErrEventTooLarge to be reported, got %v", reported)
+	}
+	if stats := client.Stats(); stats.Dropped != 1 {
+		t.Errorf("expected 1 dropped event, got %d", stats.Dropped)
+	}
+}
+
+func TestFailedSplitBatchKeepsRemainingEvents(t *testing.T) {
+	rt := &recordingTransport{err: errors.New("boom")}
+	client := NewClient("k", WithTransport(rt), WithMaxBatchBytes(4096), WithFlushInterval(time.Hour),
+		WithCircuitBreaker(5, time.Minute))
+	defer client.Close()
+
+	for i := 0; i < 6; i++ {
+		client.Track(NewEvent(EventLLMInvoke, "chat").WithPayload("prompt", strings.Repeat("x", 1000)))
+	}
+	if err := client.Flush(); err == nil {
+		t.Fatal("expected Flush to fail")
+	}
+
+	rt.mu.Lock()
+	attempts := len(rt.batches)
+	rt.mu.Unlock()
+	if attempts != 1 {
+		t.Errorf("expected delivery to stop after the first failed batch, got %d attempts", attempts)
+	}
+	if stats := client.Stats(); stats.Dropped != 0 {
+		t.Errorf("expected the events to be kept, got %d dropped", stats.Dropped)
+	}
+}
+
+func TestMaxEventSizeBoundsEncoding(t *testing.T) {
+	events := []Event{
+		NewEvent(EventLLMInvoke, "chat").
+			WithPayload("prompt", "<script>\"quoted\"\n\x01</script>").
+			WithPayload("tokens", int64(-9223372036854775808)).
+			WithPayload("temperature", -1.2345678901234567e-308).
+			WithPayload("messages", []any{map[string]any{"role": "user", "content": "hé"}, nil, true}).
+			WithPayload("tags", []string{"a", " "}).
+			WithMetadata("labels", map[string]string{"env": "prod"}),
+		{Type: EventToolCall, Sequence: 1<<64 - 1, ClockSkewMs: -1 << 63},
+	}
+	for _, e := range events {
+		bound, ok := maxEventSize(e)
+		if !ok {
+			t.Fatalf("expected a bound for %s event", e.Type)
+		}
+		if size, _ := encodedSize(e); size > bound {
+			t.Errorf("expected %s event of %d bytes to be within the bound, got %d", e.Type, size, bound)
+		}
+	}
+
+	if _, ok := maxEventSize(NewEvent(EventToolCall, "t").WithPayload("when", time.Now())); ok {
+		t.Error("expected no bound for values of other types")
+	}
+}
+
+func TestUnencodableEventIsDropped(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var reported error
+	client := NewClient("k", WithBaseURL(server.URL), WithFlushInterval(time.Hour),
+		WithCircuitBreaker(1, time.Minute), WithSpillDir(t.TempDir()),
+		WithErrorHandler(func(err error) { reported = err }))
+	defer client.Close()
+
+	client.Track(NewEvent(EventLLMInvoke, "chat").WithPayload("score", math.NaN()))
+	client.Track(NewEvent(EventToolCall, "search"))
+	for i := 0; i < 2; i++ {
+		if err := client.Flush(); err != nil {
+			t.Fatalf("Flush failed: %v", err)
+		}
+	}
+
+	if n := requests.Load(); n != 1 {
+		t.Errorf("expected the encodable event delivered in 1 request, got %d", n)
+	}
+	if !errors.Is(reported, ErrUnencodable) {
+		t.Errorf("expected ErrUnencodable to be reported, got %v", reported)
+	}
+	if stats := client.Stats(); stats.Dropped != 1 {
+		t.Errorf("expected 1 dropped event, got %d", stats.Dropped)
+	}
+	if state := client.BreakerState(); state != BreakerClosed {
+		t.Errorf("expected the breaker closed, got %v", state)
+	}
+}
+
+func TestBatchThatFailsToEncodeSkipsBreaker(t *testing.T) {
+	rt := &recordingTransport{err: fmt.Errorf("failed to encode events: %w", ErrUnencodable)}
+	client := NewClient("k", WithTransport(rt), WithFlushInterval(time.Hour), WithCircuitBreaker(1, time.Minute))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "search"))
+	if err := client.Flush(); !errors.Is(err, ErrUnencodable) {
+		t.Fatalf("expected ErrUnencodable, got %v", err)
+	}
+	if state := client.BreakerState(); state != BreakerClosed {
+		t.Errorf("expected the breaker closed, got %v", state)
+	}
+	if stats := client.Stats(); stats.Dropped != 1 || stats.Queued != 0 {
+		t.Errorf("expected the batch dropped rather than kept, got %+v", stats)
+	}
+}
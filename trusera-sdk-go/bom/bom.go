@@ -0,0 +1,268 @@
+// Package bom assembles an AI Bill of Materials (AI-BOM) describing an agent
+// process: the models it calls, the prompts and tools it declares, the Go
+// modules it was built from and the lineage of the data behind them.
+package bom
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sdkModulePath is the module path of the Trusera Go SDK
+const sdkModulePath = "github.com/Trusera/ai-bom/trusera-sdk-go"
+
+// Model describes an AI model used by the agent
+type Model struct {
+	Name          string
+	Version       string
+	Provider      string // e.g. "openai", "anthropic"
+	Task          string // e.g. "text-generation", "embeddings"
+	License       string
+	ProvenanceURI string   // Where the weights were obtained
+	Checksum      string   // Hex-encoded SHA-256 of the weights
+	Datasets      []string // Names of declared datasets the model was trained or fine-tuned on
+	Card          *ModelCard
+	Properties    map[string]string
+}
+
+// Dataset describes a training, fine-tuning or evaluation dataset
+type Dataset struct {
+	Name          string
+	Version       string
+	Description   string
+	Type          string // e.g. "text", "image", "structured"; SPDX exports others as "other"
+	License       string
+	ProvenanceURI string // Where the dataset was obtained
+	Checksum      string // Hex-encoded SHA-256 of the dataset contents
+	Properties    map[string]string
+}
+
+// Prompt describes a prompt template declared by the agent.
+// Only the SHA-256 hash of the template is emitted in the BOM.
+type Prompt struct {
+	Name     string
+	Version  string
+	Template string
+}
+
+// Hash returns the hex-encoded SHA-256 of the prompt template
+func (p Prompt) Hash() string {
+	sum := sha256.Sum256([]byte(p.Template))
+	return hex.EncodeToString(sum[:])
+}
+
+// Tool describes a tool or function the agent can invoke
+type Tool struct {
+	Name        string
+	Description string
+	Endpoint    string
+	Schema      map[string]any // JSON Schema of the tool's parameters
+	Permissions []Permission   // What the tool can do on the agent's behalf
+}
+
+// Permission is a capability a tool grants the agent
+type Permission string
+
+// Permissions reviewed by security teams. Custom values are allowed.
+const (
+	PermissionCodeExecution Permission = "code_execution" // Runs code or shell commands
+	PermissionFileRead      Permission = "file_read"      // Reads local files
+	PermissionFileWrite     Permission = "file_write"     // Writes or deletes local files
+	PermissionInternalAPI   Permission = "internal_api"   // Calls services inside the network
+	PermissionExternalAPI   Permission = "external_api"   // Calls third-party services
+	PermissionDatabase      Permission = "database"       // Queries or modifies databases
+	PermissionEmail         Permission = "email"          // Sends email or messages
+	PermissionPayments      Permission = "payments"       // Moves money
+)
+
+// Has reports whether the tool was declared with permission p
+func (t Tool) Has(p Permission) bool {
+	for _, tp := range t.Permissions {
+		if tp == p {
+			return true
+		}
+	}
+	return false
+}
+
+// permissionList joins the tool's permissions for formats without a list type
+func (t Tool) permissionList() string {
+	names := make([]string, len(t.Permissions))
+	for i, p := range t.Permissions {
+		names[i] = string(p)
+	}
+	return strings.Join(names, ",")
+}
+
+// Dependency describes a Go module linked into the agent binary
+type Dependency struct {
+	Path    string
+	Version string
+	Sum     string
+}
+
+// BOM is the assembled, format-neutral AI Bill of Materials
+type BOM struct {
+	SerialNumber string
+	Timestamp    time.Time
+	AgentName    string
+	AgentVersion string
+	Models       []Model
+	Datasets     []Dataset
+	Prompts      []Prompt
+	Tools        []Tool
+	Dependencies []Dependency
+	Lineage      []Lineage
+	Compliance   *Compliance // Set by WithCompliance
+
+	// Vulnerabilities holds findings attached by ScanVulnerabilities
+	Vulnerabilities []Vulnerability
+}
+
+// Builder collects declarations and produces a BOM. It is safe for concurrent use.
+type Builder struct {
+	mu  sync.Mutex
+	bom BOM
+}
+
+// NewBuilder creates a Builder for the named agent
+func NewBuilder(agentName string) *Builder {
+	return &Builder{bom: BOM{AgentName: agentName}}
+}
+
+// WithVersion sets the agent version
+func (b *Builder) WithVersion(version string) *Builder {
+	b.mu.Lock()
+	b.bom.AgentVersion = version
+	b.mu.Unlock()
+	return b
+}
+
+// AddModel declares a model used by the agent
+func (b *Builder) AddModel(m Model) *Builder {
+	b.mu.Lock()
+	b.bom.Models = append(b.bom.Models, m)
+	b.mu.Unlock()
+	return b
+}
+
+// AddDataset declares a dataset behind the agent's models. Reference it from
+// Model.Datasets to record that a model was trained on it.
+func (b *Builder) AddDataset(d Dataset) *Builder {
+	b.mu.Lock()
+	b.bom.Datasets = append(b.bom.Datasets, d)
+	b.mu.Unlock()
+	return b
+}
+
+// AddPrompt declares a prompt template used by the agent
+func (b *Builder) AddPrompt(p Prompt) *Builder {
+	b.mu.Lock()
+	b.bom.Prompts = append(b.bom.Prompts, p)
+	b.mu.Unlock()
+	return b
+}
+
+// AddTool declares a tool the agent can invoke
+func (b *Builder) AddTool(t Tool) *Builder {
+	b.mu.Lock()
+	b.bom.Tools = append(b.bom.Tools, t)
+	b.mu.Unlock()
+	return b
+}
+
+// CollectBuildInfo records the Go modules the running binary was built from.
+// The main module version is used as the agent version if none was set.
+func (b *Builder) CollectBuildInfo() *Builder {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return b
+	}
+	return b.collect(info)
+}
+
+// CollectBuildInfoFrom records the Go modules from another binary's build
+// info, as returned by debug/buildinfo.ReadFile
+func (b *Builder) CollectBuildInfoFrom(info *debug.BuildInfo) *Builder {
+	if info == nil {
+		return b
+	}
+	return b.collect(info)
+}
+
+// AddDependency declares a Go module dependency, e.g. one read from go.mod
+func (b *Builder) AddDependency(d Dependency) *Builder {
+	b.mu.Lock()
+	b.bom.Dependencies = append(b.bom.Dependencies, d)
+	b.mu.Unlock()
+	return b
+}
+
+func (b *Builder) collect(info *debug.BuildInfo) *Builder {
+	deps := make([]Dependency, 0, len(info.Deps))
+	for _, d := range info.Deps {
+		if d.Replace != nil {
+			d = d.Replace
+		}
+		deps = append(deps, Dependency{Path: d.Path, Version: d.Version, Sum: d.Sum})
+	}
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Path < deps[j].Path })
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.bom.AgentVersion == "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		b.bom.AgentVersion = info.Main.Version
+	}
+	b.bom.Dependencies = deps
+	return b
+}
+
+// Build returns a snapshot of the collected declarations with a fresh serial number
+func (b *Builder) Build() *BOM {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	out := b.bom
+	out.Models = append([]Model(nil), b.bom.Models...)
+	out.Datasets = append([]Dataset(nil), b.bom.Datasets...)
+	out.Prompts = append([]Prompt(nil), b.bom.Prompts...)
+	out.Tools = append([]Tool(nil), b.bom.Tools...)
+	out.Dependencies = append([]Dependency(nil), b.bom.Dependencies...)
+	out.Lineage = append([]Lineage(nil), b.bom.Lineage...)
+	if b.bom.Compliance != nil {
+		c := *b.bom.Compliance
+		c.ModelCards = append([]string(nil), c.ModelCards...)
+		out.Compliance = &c
+	}
+	out.SerialNumber = "urn:uuid:" + newUUID()
+	out.Timestamp = time.Now().UTC()
+	return &out
+}
+
+// SDKVersion returns the linked Trusera SDK version, if it is a dependency
+func (b *BOM) SDKVersion() string {
+	for _, d := range b.Dependencies {
+		if d.Path == sdkModulePath {
+			return d.Version
+		}
+	}
+	return ""
+}
+
+// newUUID returns a random RFC 4122 version 4 UUID
+func newUUID() string {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	u[6] = (u[6] & 0x0f) | 0x40
+	u[8] = (u[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
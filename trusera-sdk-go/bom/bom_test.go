@@ -0,0 +1,97 @@
+package bom
+
+import (
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestBuilderCollectsDeclarations(t *testing.T) {
+	b := NewBuilder("support-agent").
+		WithVersion("1.2.0").
+		AddModel(Model{Name: "gpt-4o", Provider: "openai", Task: "text-generation"}).
+		AddPrompt(Prompt{Name: "system", Version: "3", Template: "You are helpful."}).
+		AddTool(Tool{Name: "web_search", Description: "Search the web"})
+
+	doc := b.Build()
+
+	if doc.AgentName != "support-agent" || doc.AgentVersion != "1.2.0" {
+		t.Errorf("unexpected agent identity: %s@%s", doc.AgentName, doc.AgentVersion)
+	}
+	if len(doc.Models) != 1 || len(doc.Prompts) != 1 || len(doc.Tools) != 1 {
+		t.Errorf("expected 1 model, prompt and tool, got %d/%d/%d", len(doc.Models), len(doc.Prompts), len(doc.Tools))
+	}
+	if !strings.HasPrefix(doc.SerialNumber, "urn:uuid:") {
+		t.Errorf("expected urn:uuid serial number, got %s", doc.SerialNumber)
+	}
+	if doc.Timestamp.IsZero() {
+		t.Error("expected non-zero timestamp")
+	}
+}
+
+func TestBuildReturnsSnapshot(t *testing.T) {
+	b := NewBuilder("agent").AddModel(Model{Name: "m1"})
+	first := b.Build()
+	b.AddModel(Model{Name: "m2"})
+
+	if len(first.Models) != 1 {
+		t.Errorf("expected snapshot to be unaffected by later declarations, got %d models", len(first.Models))
+	}
+	if second := b.Build(); second.SerialNumber == first.SerialNumber {
+		t.Error("expected a fresh serial number per build")
+	}
+}
+
+func TestPromptHash(t *testing.T) {
+	p := Prompt{Template: "hello"}
+	want := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if got := p.Hash(); got != want {
+		t.Errorf("expected hash %s, got %s", want, got)
+	}
+}
+
+func TestCollectBuildInfo(t *testing.T) {
+	info := &debug.BuildInfo{
+		Main: debug.Module{Path: "example.com/agent", Version: "v0.3.0"},
+		Deps: []*debug.Module{
+			{Path: "golang.org/x/net", Version: "v0.20.0", Sum: "h1:abc"},
+			{Path: sdkModulePath, Version: "v1.0.0"},
+			{Path: "example.com/old", Version: "v1.0.0", Replace: &debug.Module{Path: "example.com/fork", Version: "v1.0.1"}},
+		},
+	}
+
+	doc := NewBuilder("agent").collect(info).Build()
+
+	if doc.AgentVersion != "v0.3.0" {
+		t.Errorf("expected agent version from main module, got %s", doc.AgentVersion)
+	}
+	if len(doc.Dependencies) != 3 {
+		t.Fatalf("expected 3 dependencies, got %d", len(doc.Dependencies))
+	}
+	if doc.Dependencies[0].Path != "example.com/fork" {
+		t.Errorf("expected replaced module to be recorded, got %s", doc.Dependencies[0].Path)
+	}
+	if doc.SDKVersion() != "v1.0.0" {
+		t.Errorf("expected SDK version v1.0.0, got %q", doc.SDKVersion())
+	}
+}
+
+func TestCollectBuildInfoKeepsExplicitVersion(t *testing.T) {
+	info := &debug.BuildInfo{Main: debug.Module{Version: "v9.9.9"}}
+	doc := NewBuilder("agent").WithVersion("2.0.0").collect(info).Build()
+
+	if doc.AgentVersion != "2.0.0" {
+		t.Errorf("expected explicit version to win, got %s", doc.AgentVersion)
+	}
+}
+
+func TestAddDependency(t *testing.T) {
+	doc := NewBuilder("agent").
+		CollectBuildInfoFrom(nil).
+		AddDependency(Dependency{Path: "golang.org/x/net", Version: "v0.20.0"}).
+		Build()
+
+	if len(doc.Dependencies) != 1 || doc.Dependencies[0].Path != "golang.org/x/net" {
+		t.Errorf("expected declared dependency, got %+v", doc.Dependencies)
+	}
+}
@@ -0,0 +1,129 @@
+package bom
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RiskCategory is the EU AI Act risk tier of an AI system
+type RiskCategory string
+
+const (
+	RiskProhibited RiskCategory = "prohibited" // Unacceptable risk, Article 5
+	RiskHigh       RiskCategory = "high"       // Annex III use cases and safety components, Article 6
+	RiskLimited    RiskCategory = "limited"    // Transparency obligations only, Article 50
+	RiskMinimal    RiskCategory = "minimal"
+)
+
+// OversightMode is how humans supervise the agent's decisions
+type OversightMode string
+
+const (
+	OversightInTheLoop OversightMode = "human_in_the_loop" // A human approves each decision
+	OversightOnTheLoop OversightMode = "human_on_the_loop" // A human monitors and can intervene
+	OversightInCommand OversightMode = "human_in_command"  // A human decides when and how the system is used
+	OversightNone      OversightMode = "none"              // Fully automated
+)
+
+// Role is the organization's role for the AI system under the EU AI Act
+type Role string
+
+const (
+	RoleProvider    Role = "provider"    // Develops the system or places it on the market
+	RoleDeployer    Role = "deployer"    // Uses the system under its authority
+	RoleImporter    Role = "importer"    // Brings a system from outside the EU to market
+	RoleDistributor Role = "distributor" // Makes a system available without changing it
+)
+
+// Compliance is the regulatory metadata of an AI system, as needed for the EU
+// AI Act technical documentation and the NIST AI RMF Map function
+type Compliance struct {
+	IntendedPurpose string // What the system is for and in which context
+	RiskCategory    RiskCategory
+	Oversight       OversightMode
+	Role            Role
+	ModelCards      []string // URIs of the model cards of the models used
+}
+
+// Validate reports every missing or unknown field. High-risk systems must
+// also declare human oversight and at least one model card, and prohibited
+// systems are rejected.
+func (c Compliance) Validate() error {
+	var problems []string
+	if strings.TrimSpace(c.IntendedPurpose) == "" {
+		problems = append(problems, "intended purpose is required")
+	}
+	switch c.RiskCategory {
+	case RiskHigh, RiskLimited, RiskMinimal:
+	case RiskProhibited:
+		problems = append(problems, "prohibited systems may not be deployed")
+	case "":
+		problems = append(problems, "risk category is required")
+	default:
+		problems = append(problems, fmt.Sprintf("unknown risk category %q", c.RiskCategory))
+	}
+	switch c.Oversight {
+	case OversightInTheLoop, OversightOnTheLoop, OversightInCommand, OversightNone:
+	case "":
+		problems = append(problems, "human oversight mode is required")
+	default:
+		problems = append(problems, fmt.Sprintf("unknown human oversight mode %q", c.Oversight))
+	}
+	switch c.Role {
+	case RoleProvider, RoleDeployer, RoleImporter, RoleDistributor:
+	case "":
+		problems = append(problems, "role is required")
+	default:
+		problems = append(problems, fmt.Sprintf("unknown role %q", c.Role))
+	}
+	if c.RiskCategory == RiskHigh {
+		if c.Oversight == OversightNone {
+			problems = append(problems, "high-risk systems require human oversight")
+		}
+		if len(c.ModelCards) == 0 {
+			problems = append(problems, "high-risk systems require a model card")
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid compliance metadata: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// WithCompliance declares the agent's regulatory metadata
+func (b *Builder) WithCompliance(c Compliance) *Builder {
+	b.mu.Lock()
+	b.bom.Compliance = &c
+	b.mu.Unlock()
+	return b
+}
+
+// ValidateCompliance checks that compliance metadata was declared and is
+// complete, so a BOM can be exported for a compliance review
+func (b *BOM) ValidateCompliance() error {
+	if b.Compliance == nil {
+		return errors.New("no compliance metadata declared")
+	}
+	return b.Compliance.Validate()
+}
+
+// properties returns the metadata as flat name/value pairs for formats
+// without a place for it
+func (c *Compliance) properties() map[string]string {
+	props := map[string]string{}
+	if c.IntendedPurpose != "" {
+		props["trusera:compliance:intended_purpose"] = c.IntendedPurpose
+	}
+	if c.RiskCategory != "" {
+		props["trusera:compliance:risk_category"] = string(c.RiskCategory)
+	}
+	if c.Oversight != "" {
+		props["trusera:compliance:human_oversight"] = string(c.Oversight)
+	}
+	if c.Role != "" {
+		props["trusera:compliance:role"] = string(c.Role)
+	}
+	return props
+}
@@ -0,0 +1,99 @@
+package bom
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func supportCompliance() Compliance {
+	return Compliance{
+		IntendedPurpose: "Triage credit applications for human review",
+		RiskCategory:    RiskHigh,
+		Oversight:       OversightInTheLoop,
+		Role:            RoleDeployer,
+		ModelCards:      []string{"https://huggingface.co/acme/support-llama"},
+	}
+}
+
+func TestComplianceValidate(t *testing.T) {
+	if err := supportCompliance().Validate(); err != nil {
+		t.Errorf("expected valid metadata, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Compliance)
+		want   string
+	}{
+		{"missing purpose", func(c *Compliance) { c.IntendedPurpose = " " }, "intended purpose is required"},
+		{"unknown risk", func(c *Compliance) { c.RiskCategory = "severe" }, `unknown risk category "severe"`},
+		{"prohibited", func(c *Compliance) { c.RiskCategory = RiskProhibited }, "prohibited systems may not be deployed"},
+		{"missing role", func(c *Compliance) { c.Role = "" }, "role is required"},
+		{"unknown oversight", func(c *Compliance) { c.Oversight = "sometimes" }, `unknown human oversight mode "sometimes"`},
+		{"high risk without oversight", func(c *Compliance) { c.Oversight = OversightNone }, "high-risk systems require human oversight"},
+		{"high risk without model card", func(c *Compliance) { c.ModelCards = nil }, "high-risk systems require a model card"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := supportCompliance()
+			tt.modify(&c)
+			err := c.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	if err := (Compliance{IntendedPurpose: "Summaries", RiskCategory: RiskMinimal, Oversight: OversightNone, Role: RoleProvider}).Validate(); err != nil {
+		t.Errorf("expected minimal-risk system without model card to be valid, got %v", err)
+	}
+}
+
+func TestValidateCompliance(t *testing.T) {
+	if err := NewBuilder("agent").Build().ValidateCompliance(); err == nil {
+		t.Error("expected error without compliance metadata")
+	}
+	if err := NewBuilder("agent").WithCompliance(supportCompliance()).Build().ValidateCompliance(); err != nil {
+		t.Errorf("expected valid compliance metadata, got %v", err)
+	}
+}
+
+func TestMarshalCycloneDXCompliance(t *testing.T) {
+	data, _ := NewBuilder("support-agent").WithCompliance(supportCompliance()).Build().MarshalCycloneDX()
+	var out cdxDocument
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+
+	agent := out.Metadata.Component
+	props := map[string]string{}
+	for _, p := range agent.Properties {
+		props[p.Name] = p.Value
+	}
+	if props["trusera:compliance:risk_category"] != "high" || props["trusera:compliance:role"] != "deployer" || props["trusera:compliance:human_oversight"] != "human_in_the_loop" {
+		t.Errorf("unexpected compliance properties %v", props)
+	}
+	if len(agent.ExtRefs) != 1 || agent.ExtRefs[0].Type != "model-card" {
+		t.Errorf("expected model card reference, got %+v", agent.ExtRefs)
+	}
+}
+
+func TestMarshalSPDXCompliance(t *testing.T) {
+	data, _ := NewBuilder("support-agent").WithCompliance(supportCompliance()).Build().MarshalSPDX()
+	var out spdxDocument
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+
+	agent := out.Graph[2]
+	if agent.Type != "ai_AIPackage" || agent.Name != "support-agent" {
+		t.Fatalf("expected agent as AI package, got %+v", agent)
+	}
+	if agent.RiskAssessment != "high" || agent.AutonomyType != "no" || agent.AppInfo != "Triage credit applications for human review" {
+		t.Errorf("unexpected AI profile fields %+v", agent)
+	}
+	if !strings.Contains(agent.Comment, "role: deployer") || !strings.Contains(agent.Comment, "model cards: https://huggingface.co/acme/support-llama") {
+		t.Errorf("unexpected comment %q", agent.Comment)
+	}
+}
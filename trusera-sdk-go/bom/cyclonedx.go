@@ -0,0 +1,568 @@
+package bom
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CycloneDX media type and spec version emitted by MarshalCycloneDX
+const (
+	CycloneDXMediaType   = "application/vnd.cyclonedx+json; version=1.6"
+	cycloneDXSpecVersion = "1.6"
+)
+
+type cdxDocument struct {
+	BOMFormat       string             `json:"bomFormat"`
+	SpecVersion     string             `json:"specVersion"`
+	SerialNumber    string             `json:"serialNumber"`
+	Version         int                `json:"version"`
+	Metadata        cdxMetadata        `json:"metadata"`
+	Components      []cdxComponent     `json:"components,omitempty"`
+	Services        []cdxService       `json:"services,omitempty"`
+	Dependencies    []cdxDependency    `json:"dependencies,omitempty"`
+	Vulnerabilities []cdxVulnerability `json:"vulnerabilities,omitempty"`
+}
+
+type cdxMetadata struct {
+	Timestamp string        `json:"timestamp"`
+	Tools     *cdxTools     `json:"tools,omitempty"`
+	Component *cdxComponent `json:"component,omitempty"`
+}
+
+type cdxTools struct {
+	Components []cdxComponent `json:"components"`
+}
+
+type cdxComponent struct {
+	Type        string        `json:"type"`
+	BOMRef      string        `json:"bom-ref,omitempty"`
+	Name        string        `json:"name"`
+	Version     string        `json:"version,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Supplier    *cdxEntity    `json:"supplier,omitempty"`
+	Licenses    []cdxLicense  `json:"licenses,omitempty"`
+	PURL        string        `json:"purl,omitempty"`
+	Hashes      []cdxHash     `json:"hashes,omitempty"`
+	ExtRefs     []cdxExtRef   `json:"externalReferences,omitempty"`
+	ModelCard   *cdxModelCard `json:"modelCard,omitempty"`
+	Pedigree    *cdxPedigree  `json:"pedigree,omitempty"`
+	Properties  []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxPedigree struct {
+	Ancestors []cdxComponent `json:"ancestors,omitempty"`
+}
+
+type cdxExtRef struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type cdxEntity struct {
+	Name string `json:"name"`
+}
+
+type cdxLicense struct {
+	License cdxLicenseName `json:"license"`
+}
+
+type cdxLicenseName struct {
+	Name string `json:"name"`
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cdxModelCard struct {
+	ModelParameters      *cdxModelParameters      `json:"modelParameters,omitempty"`
+	QuantitativeAnalysis *cdxQuantitativeAnalysis `json:"quantitativeAnalysis,omitempty"`
+	Considerations       *cdxConsiderations       `json:"considerations,omitempty"`
+}
+
+type cdxQuantitativeAnalysis struct {
+	PerformanceMetrics []cdxMetric `json:"performanceMetrics,omitempty"`
+}
+
+type cdxMetric struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	Slice string `json:"slice,omitempty"`
+}
+
+type cdxConsiderations struct {
+	UseCases              []string                  `json:"useCases,omitempty"`
+	TechnicalLimitations  []string                  `json:"technicalLimitations,omitempty"`
+	EthicalConsiderations []cdxEthicalConsideration `json:"ethicalConsiderations,omitempty"`
+}
+
+type cdxEthicalConsideration struct {
+	Name string `json:"name"`
+}
+
+type cdxModelParameters struct {
+	Task     string       `json:"task,omitempty"`
+	Datasets []cdxDataRef `json:"datasets,omitempty"`
+}
+
+type cdxDataRef struct {
+	Ref string `json:"ref"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cdxService struct {
+	BOMRef      string        `json:"bom-ref,omitempty"`
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Endpoints   []string      `json:"endpoints,omitempty"`
+	Properties  []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+type cdxVulnerability struct {
+	ID             string         `json:"id"`
+	Source         *cdxVulnSource `json:"source,omitempty"`
+	References     []cdxVulnRef   `json:"references,omitempty"`
+	Ratings        []cdxRating    `json:"ratings,omitempty"`
+	Description    string         `json:"description,omitempty"`
+	Recommendation string         `json:"recommendation,omitempty"`
+	Analysis       *cdxAnalysis   `json:"analysis,omitempty"`
+	Affects        []cdxAffects   `json:"affects,omitempty"`
+}
+
+type cdxVulnSource struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type cdxVulnRef struct {
+	ID     string        `json:"id"`
+	Source cdxVulnSource `json:"source"`
+}
+
+type cdxRating struct {
+	Severity string `json:"severity,omitempty"`
+	Method   string `json:"method,omitempty"`
+	Vector   string `json:"vector,omitempty"`
+}
+
+type cdxAnalysis struct {
+	State string `json:"state"`
+}
+
+type cdxAffects struct {
+	Ref string `json:"ref"`
+}
+
+// MarshalCycloneDX serializes the BOM as a CycloneDX 1.6 ML-BOM JSON document
+func (b *BOM) MarshalCycloneDX() ([]byte, error) {
+	return json.MarshalIndent(b.cycloneDX(), "", "  ")
+}
+
+func (b *BOM) cycloneDX() cdxDocument {
+	agentRef := AgentRef(b.AgentName)
+	lineage := b.cdxLineage()
+	doc := cdxDocument{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  cycloneDXSpecVersion,
+		SerialNumber: b.SerialNumber,
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: b.Timestamp.Format(time.RFC3339),
+			Component: &cdxComponent{
+				Type:    "application",
+				BOMRef:  agentRef,
+				Name:    b.AgentName,
+				Version: b.AgentVersion,
+			},
+		},
+	}
+
+	if v := b.SDKVersion(); v != "" {
+		doc.Metadata.Tools = &cdxTools{Components: []cdxComponent{{
+			Type:    "library",
+			Name:    "trusera-sdk-go",
+			Version: v,
+			PURL:    "pkg:golang/" + sdkModulePath + "@" + v,
+		}}}
+	}
+
+	// bom-refs are derived from names, so an element declared twice under one
+	// name gets a numbered ref. Lineage edges refer to the first.
+	used := map[string]bool{agentRef: true}
+	uniqueRef := func(ref string) string {
+		unique := ref
+		for n := 2; used[unique]; n++ {
+			unique = ref + "#" + strconv.Itoa(n)
+		}
+		used[unique] = true
+		return unique
+	}
+
+	var refs []string
+
+	for _, m := range b.Models {
+		c := cdxComponent{
+			Type:       "machine-learning-model",
+			BOMRef:     uniqueRef(ModelRef(m.Name)),
+			Name:       m.Name,
+			Version:    m.Version,
+			Properties: sortedProperties(m.Properties),
+		}
+		if m.Provider != "" {
+			c.Supplier = &cdxEntity{Name: m.Provider}
+		}
+		c.Licenses = cdxLicenses(m.License)
+		c.Hashes = cdxSHA256(m.Checksum)
+		c.ExtRefs = cdxDistribution(m.ProvenanceURI)
+		var card cdxModelCard
+		if m.Card != nil {
+			card = cdxCard(&c, m.Properties, *m.Card)
+		}
+		var datasets []string
+		for _, name := range m.Datasets {
+			datasets = appendUnique(datasets, DatasetRef(name))
+		}
+		for _, ref := range lineage.datasets[c.BOMRef] {
+			datasets = appendUnique(datasets, ref)
+		}
+		if m.Task != "" || len(datasets) > 0 {
+			card.ModelParameters = &cdxModelParameters{Task: m.Task}
+			for _, ref := range datasets {
+				card.ModelParameters.Datasets = append(card.ModelParameters.Datasets, cdxDataRef{Ref: ref})
+			}
+		}
+		if card != (cdxModelCard{}) {
+			c.ModelCard = &card
+		}
+		for _, base := range lineage.ancestors[c.BOMRef] {
+			if c.Pedigree == nil {
+				c.Pedigree = &cdxPedigree{}
+			}
+			c.Pedigree.Ancestors = append(c.Pedigree.Ancestors, b.cdxAncestor(base))
+		}
+		doc.Components = append(doc.Components, c)
+		refs = append(refs, c.BOMRef)
+	}
+
+	for _, d := range b.Datasets {
+		c := cdxComponent{
+			Type:     "data",
+			BOMRef:   uniqueRef(DatasetRef(d.Name)),
+			Name:     d.Name,
+			Version:  d.Version,
+			Licenses: cdxLicenses(d.License),
+			Hashes:   cdxSHA256(d.Checksum),
+			ExtRefs:  cdxDistribution(d.ProvenanceURI),
+		}
+		props := map[string]string{"trusera:kind": "dataset"}
+		for k, v := range d.Properties {
+			props[k] = v
+		}
+		if d.Type != "" {
+			props["trusera:dataset_type"] = d.Type
+		}
+		if d.Description != "" {
+			props["trusera:description"] = d.Description
+		}
+		c.Properties = sortedProperties(props)
+		doc.Components = append(doc.Components, c)
+		refs = append(refs, c.BOMRef)
+	}
+
+	for _, p := range b.Prompts {
+		c := cdxComponent{
+			Type:       "data",
+			BOMRef:     uniqueRef(PromptRef(p.Name)),
+			Name:       p.Name,
+			Version:    p.Version,
+			Hashes:     []cdxHash{{Alg: "SHA-256", Content: p.Hash()}},
+			Properties: []cdxProperty{{Name: "trusera:kind", Value: "prompt"}},
+		}
+		doc.Components = append(doc.Components, c)
+		refs = append(refs, c.BOMRef)
+	}
+
+	for _, d := range b.Dependencies {
+		purl := "pkg:golang/" + d.Path
+		if d.Version != "" {
+			purl += "@" + d.Version
+		}
+		c := cdxComponent{
+			Type:    "library",
+			BOMRef:  uniqueRef(purl),
+			Name:    d.Path,
+			Version: d.Version,
+			PURL:    purl,
+		}
+		if d.Sum != "" {
+			c.Properties = []cdxProperty{{Name: "go:sum", Value: d.Sum}}
+		}
+		doc.Components = append(doc.Components, c)
+		refs = append(refs, c.BOMRef)
+	}
+
+	for _, t := range b.Tools {
+		s := cdxService{
+			BOMRef:      uniqueRef(ToolRef(t.Name)),
+			Name:        t.Name,
+			Description: t.Description,
+		}
+		if t.Endpoint != "" {
+			s.Endpoints = []string{t.Endpoint}
+		}
+		for _, p := range t.Permissions {
+			s.Properties = append(s.Properties, cdxProperty{Name: "trusera:permission", Value: string(p)})
+		}
+		if len(t.Schema) > 0 {
+			if schema, err := json.Marshal(t.Schema); err == nil {
+				s.Properties = append(s.Properties, cdxProperty{Name: "trusera:input_schema", Value: string(schema)})
+			}
+		}
+		doc.Services = append(doc.Services, s)
+		refs = append(refs, s.BOMRef)
+	}
+
+	for _, name := range b.systems() {
+		doc.Services = append(doc.Services, cdxService{
+			BOMRef:     uniqueRef(SystemRef(name)),
+			Name:       name,
+			Properties: []cdxProperty{{Name: "trusera:kind", Value: "system"}},
+		})
+	}
+
+	for _, ref := range lineage.dependsOn[agentRef] {
+		refs = appendUnique(refs, ref)
+	}
+	doc.Dependencies = []cdxDependency{{Ref: agentRef, DependsOn: refs}}
+	for _, ref := range lineage.order {
+		if ref != agentRef {
+			doc.Dependencies = append(doc.Dependencies, cdxDependency{Ref: ref, DependsOn: lineage.dependsOn[ref]})
+		}
+	}
+
+	// Dependencies carry no relation, so each edge is also recorded as a
+	// property of the element it leads to
+	if c := b.Compliance; c != nil {
+		doc.Metadata.Component.Properties = sortedProperties(c.properties())
+		for _, uri := range c.ModelCards {
+			doc.Metadata.Component.ExtRefs = append(doc.Metadata.Component.ExtRefs, cdxExtRef{Type: "model-card", URL: uri})
+		}
+	}
+	doc.Metadata.Component.Properties = append(doc.Metadata.Component.Properties, lineage.properties[agentRef]...)
+	for i := range doc.Components {
+		doc.Components[i].Properties = append(doc.Components[i].Properties, lineage.properties[doc.Components[i].BOMRef]...)
+	}
+	for i := range doc.Services {
+		doc.Services[i].Properties = append(doc.Services[i].Properties, lineage.properties[doc.Services[i].BOMRef]...)
+	}
+
+	for _, v := range b.Vulnerabilities {
+		doc.Vulnerabilities = append(doc.Vulnerabilities, cdxVuln(v))
+	}
+	return doc
+}
+
+// cdxLineage is the BOM's lineage arranged for CycloneDX
+type cdxLineage struct {
+	dependsOn  map[string][]string // Element to the elements it is built from or fed by
+	order      []string            // Keys of dependsOn in order of first appearance
+	datasets   map[string][]string // Model to the datasets it was trained or fine-tuned on
+	ancestors  map[string][]string // Fine-tuned model to the names of its base models
+	properties map[string][]cdxProperty
+}
+
+func (b *BOM) cdxLineage() cdxLineage {
+	l := cdxLineage{
+		dependsOn:  map[string][]string{},
+		datasets:   map[string][]string{},
+		ancestors:  map[string][]string{},
+		properties: map[string][]cdxProperty{},
+	}
+	for _, e := range b.Lineage {
+		if _, ok := l.dependsOn[e.To]; !ok {
+			l.order = append(l.order, e.To)
+		}
+		l.dependsOn[e.To] = appendUnique(l.dependsOn[e.To], e.From)
+		l.properties[e.To] = append(l.properties[e.To], cdxProperty{Name: "trusera:lineage", Value: string(e.Relation) + " " + e.From})
+
+		fromKind, fromName := splitRef(e.From)
+		if toKind, _ := splitRef(e.To); toKind != kindModel {
+			continue
+		}
+		switch {
+		case fromKind == kindDataset && (e.Relation == LineageTrains || e.Relation == LineageFineTunes):
+			l.datasets[e.To] = appendUnique(l.datasets[e.To], e.From)
+		case fromKind == kindModel && e.Relation == LineageFineTunes:
+			l.ancestors[e.To] = appendUnique(l.ancestors[e.To], fromName)
+		}
+	}
+	return l
+}
+
+// cdxAncestor describes a base model in a fine-tuned model's pedigree, with
+// the version it was declared with, if any
+func (b *BOM) cdxAncestor(name string) cdxComponent {
+	c := cdxComponent{Type: "machine-learning-model", Name: name}
+	for _, m := range b.Models {
+		if m.Name == name {
+			c.Version = m.Version
+			c.Hashes = cdxSHA256(m.Checksum)
+			c.ExtRefs = cdxDistribution(m.ProvenanceURI)
+		}
+	}
+	return c
+}
+
+// cdxCard converts a model card, filling in the component's description,
+// license and properties from it. The out-of-scope use and the hash of the
+// Markdown card have no CycloneDX field and become properties.
+func cdxCard(c *cdxComponent, props map[string]string, card ModelCard) cdxModelCard {
+	var out cdxModelCard
+	c.Description = card.Description
+	if c.Licenses == nil {
+		c.Licenses = cdxLicenses(card.License)
+	}
+
+	for _, m := range card.Metrics {
+		if out.QuantitativeAnalysis == nil {
+			out.QuantitativeAnalysis = &cdxQuantitativeAnalysis{}
+		}
+		out.QuantitativeAnalysis.PerformanceMetrics = append(out.QuantitativeAnalysis.PerformanceMetrics,
+			cdxMetric{Type: m.Type, Value: m.Value, Slice: m.Dataset})
+	}
+	if card.IntendedUse != "" || len(card.Limitations) > 0 || len(card.EthicalConsiderations) > 0 {
+		out.Considerations = &cdxConsiderations{TechnicalLimitations: card.Limitations}
+		if card.IntendedUse != "" {
+			out.Considerations.UseCases = []string{card.IntendedUse}
+		}
+		for _, e := range card.EthicalConsiderations {
+			out.Considerations.EthicalConsiderations = append(out.Considerations.EthicalConsiderations, cdxEthicalConsideration{Name: e})
+		}
+	}
+
+	merged := map[string]string{}
+	for k, v := range props {
+		merged[k] = v
+	}
+	if card.OutOfScopeUse != "" {
+		merged["trusera:model_card:out_of_scope_use"] = card.OutOfScopeUse
+	}
+	if hash := card.MarkdownHash(); hash != "" {
+		merged["trusera:model_card:sha256"] = hash
+	}
+	c.Properties = sortedProperties(merged)
+	return out
+}
+
+// appendUnique appends s to list unless it is already there
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+// cdxVuln converts a finding into a CycloneDX vulnerability with VEX analysis
+func cdxVuln(v Vulnerability) cdxVulnerability {
+	out := cdxVulnerability{
+		ID:          v.ID,
+		Description: v.Summary,
+		Analysis:    &cdxAnalysis{State: v.State},
+	}
+	if v.Source == "osv" {
+		out.Source = &cdxVulnSource{Name: "OSV", URL: "https://osv.dev/vulnerability/" + v.ID}
+	} else if v.Source != "" {
+		out.Source = &cdxVulnSource{Name: v.Source}
+	}
+	for _, alias := range v.Aliases {
+		src := cdxVulnSource{Name: "OSV"}
+		if strings.HasPrefix(alias, "CVE-") {
+			src = cdxVulnSource{Name: "NVD", URL: "https://nvd.nist.gov/vuln/detail/" + alias}
+		} else if strings.HasPrefix(alias, "GHSA-") {
+			src = cdxVulnSource{Name: "GitHub", URL: "https://github.com/advisories/" + alias}
+		}
+		out.References = append(out.References, cdxVulnRef{ID: alias, Source: src})
+	}
+	if v.Severity != "" || v.CVSS != "" {
+		out.Ratings = []cdxRating{{Severity: v.Severity, Method: cvssMethod(v.CVSS), Vector: v.CVSS}}
+	}
+	if v.FixedIn != "" {
+		out.Recommendation = "Upgrade to " + v.FixedIn
+	}
+	for _, a := range v.Affects {
+		out.Affects = append(out.Affects, cdxAffects{Ref: "pkg:golang/" + a})
+	}
+	return out
+}
+
+// cvssMethod names the CycloneDX rating method for a CVSS vector
+func cvssMethod(vector string) string {
+	switch {
+	case strings.HasPrefix(vector, "CVSS:4"):
+		return "CVSSv4"
+	case strings.HasPrefix(vector, "CVSS:3.1"):
+		return "CVSSv31"
+	case strings.HasPrefix(vector, "CVSS:3"):
+		return "CVSSv3"
+	case vector != "":
+		return "CVSSv2"
+	}
+	return ""
+}
+
+// cdxLicenses returns a named license entry, if any
+func cdxLicenses(name string) []cdxLicense {
+	if name == "" {
+		return nil
+	}
+	return []cdxLicense{{License: cdxLicenseName{Name: name}}}
+}
+
+// cdxSHA256 returns a SHA-256 hash entry, if any
+func cdxSHA256(sum string) []cdxHash {
+	if sum == "" {
+		return nil
+	}
+	return []cdxHash{{Alg: "SHA-256", Content: sum}}
+}
+
+// cdxDistribution returns a distribution reference for a provenance URI, if any
+func cdxDistribution(uri string) []cdxExtRef {
+	if uri == "" {
+		return nil
+	}
+	return []cdxExtRef{{Type: "distribution", URL: uri}}
+}
+
+// sortedProperties converts a map into CycloneDX properties in a stable order
+func sortedProperties(props map[string]string) []cdxProperty {
+	if len(props) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := make([]cdxProperty, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, cdxProperty{Name: k, Value: props[k]})
+	}
+	return out
+}
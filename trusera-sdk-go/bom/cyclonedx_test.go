@@ -0,0 +1,202 @@
+package bom
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMarshalCycloneDX(t *testing.T) {
+	doc := NewBuilder("support-agent").
+		WithVersion("1.0.0").
+		AddModel(Model{Name: "claude-3-5-sonnet", Provider: "anthropic", Task: "text-generation", License: "proprietary"}).
+		AddPrompt(Prompt{Name: "system", Template: "Be concise."}).
+		AddTool(Tool{Name: "lookup_order", Endpoint: "https://orders.internal/api"}).
+		Build()
+	doc.Dependencies = []Dependency{{Path: sdkModulePath, Version: "v1.0.0"}}
+
+	data, err := doc.MarshalCycloneDX()
+	if err != nil {
+		t.Fatalf("MarshalCycloneDX failed: %v", err)
+	}
+
+	var out cdxDocument
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+
+	if out.BOMFormat != "CycloneDX" || out.SpecVersion != "1.6" {
+		t.Errorf("unexpected format %s %s", out.BOMFormat, out.SpecVersion)
+	}
+	if out.SerialNumber != doc.SerialNumber {
+		t.Errorf("expected serial number %s, got %s", doc.SerialNumber, out.SerialNumber)
+	}
+	if out.Metadata.Component == nil || out.Metadata.Component.Name != "support-agent" {
+		t.Error("expected agent as metadata component")
+	}
+	if out.Metadata.Tools == nil || out.Metadata.Tools.Components[0].Version != "v1.0.0" {
+		t.Error("expected SDK listed as metadata tool")
+	}
+
+	types := map[string]int{}
+	for _, c := range out.Components {
+		types[c.Type]++
+	}
+	if types["machine-learning-model"] != 1 || types["data"] != 1 || types["library"] != 1 {
+		t.Errorf("unexpected component types: %v", types)
+	}
+
+	model := out.Components[0]
+	if model.Supplier == nil || model.Supplier.Name != "anthropic" {
+		t.Error("expected model supplier")
+	}
+	if model.ModelCard == nil || model.ModelCard.ModelParameters.Task != "text-generation" {
+		t.Error("expected model card task")
+	}
+
+	if len(out.Services) != 1 || out.Services[0].Endpoints[0] != "https://orders.internal/api" {
+		t.Errorf("expected tool as service, got %+v", out.Services)
+	}
+
+	if len(out.Dependencies) != 1 || len(out.Dependencies[0].DependsOn) != 4 {
+		t.Errorf("expected agent to depend on all 4 components/services, got %+v", out.Dependencies)
+	}
+}
+
+func TestMarshalCycloneDXToolPermissions(t *testing.T) {
+	doc := NewBuilder("support-agent").
+		AddTool(Tool{
+			Name:        "run_python",
+			Schema:      map[string]any{"type": "object", "required": []string{"code"}},
+			Permissions: []Permission{PermissionCodeExecution, PermissionFileWrite},
+		}).
+		Build()
+
+	data, err := doc.MarshalCycloneDX()
+	if err != nil {
+		t.Fatalf("MarshalCycloneDX failed: %v", err)
+	}
+	var out cdxDocument
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+
+	if len(out.Services) != 1 {
+		t.Fatalf("expected tool as service, got %+v", out.Services)
+	}
+	var permissions []string
+	var schema string
+	for _, p := range out.Services[0].Properties {
+		switch p.Name {
+		case "trusera:permission":
+			permissions = append(permissions, p.Value)
+		case "trusera:input_schema":
+			schema = p.Value
+		}
+	}
+	if len(permissions) != 2 || permissions[0] != "code_execution" || permissions[1] != "file_write" {
+		t.Errorf("expected both permissions as properties, got %v", permissions)
+	}
+	if schema != `{"required":["code"],"type":"object"}` {
+		t.Errorf("expected the input schema as a property, got %q", schema)
+	}
+	if !doc.Tools[0].Has(PermissionCodeExecution) || doc.Tools[0].Has(PermissionEmail) {
+		t.Error("expected Has to report declared permissions only")
+	}
+}
+
+func TestMarshalCycloneDXEmpty(t *testing.T) {
+	data, err := NewBuilder("empty").Build().MarshalCycloneDX()
+	if err != nil {
+		t.Fatalf("MarshalCycloneDX failed: %v", err)
+	}
+
+	var out map[string]any
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if _, ok := out["components"]; ok {
+		t.Error("expected components to be omitted when empty")
+	}
+}
+
+func TestMarshalCycloneDXUniqueRefs(t *testing.T) {
+	doc := NewBuilder("support-agent").
+		AddModel(Model{Name: "gpt-4o", Provider: "openai"}).
+		AddModel(Model{Name: "gpt-4o", Provider: "azure"}).
+		AddModel(Model{Name: "gpt-4o#2"}).
+		AddTool(Tool{Name: "search"}).
+		AddTool(Tool{Name: "search"}).
+		Build()
+	doc.Dependencies = []Dependency{{Path: "golang.org/x/net", Version: "v0.20.0"}, {Path: "golang.org/x/net", Version: "v0.20.0"}}
+
+	data, err := doc.MarshalCycloneDX()
+	if err != nil {
+		t.Fatalf("MarshalCycloneDX failed: %v", err)
+	}
+	var out cdxDocument
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+
+	var refs []string
+	for _, c := range out.Components {
+		refs = append(refs, c.BOMRef)
+	}
+	for _, s := range out.Services {
+		refs = append(refs, s.BOMRef)
+	}
+	want := []string{"model:gpt-4o", "model:gpt-4o#2", "model:gpt-4o#2#2", "pkg:golang/golang.org/x/net@v0.20.0",
+		"pkg:golang/golang.org/x/net@v0.20.0#2", "tool:search", "tool:search#2"}
+	if strings.Join(refs, " ") != strings.Join(want, " ") {
+		t.Errorf("expected unique bom-refs %v, got %v", want, refs)
+	}
+	if deps := out.Dependencies[0].DependsOn; len(deps) != len(want) {
+		t.Errorf("expected the agent to depend on every element, got %v", deps)
+	}
+}
+
+func TestMarshalCycloneDXDatasets(t *testing.T) {
+	doc := NewBuilder("support-agent").
+		AddDataset(Dataset{
+			Name:          "support-tickets",
+			Version:       "2024-06",
+			Type:          "text",
+			License:       "CC-BY-4.0",
+			ProvenanceURI: "s3://datasets/support-tickets.parquet",
+			Checksum:      "ab12",
+		}).
+		AddModel(Model{
+			Name:          "support-llama",
+			ProvenanceURI: "https://huggingface.co/acme/support-llama",
+			Checksum:      "cd34",
+			Datasets:      []string{"support-tickets"},
+		}).
+		Build()
+
+	var out cdxDocument
+	data, _ := doc.MarshalCycloneDX()
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if len(out.Components) != 2 {
+		t.Fatalf("expected model and dataset components, got %+v", out.Components)
+	}
+
+	model, dataset := out.Components[0], out.Components[1]
+	if model.Hashes[0].Content != "cd34" || model.ExtRefs[0].URL != "https://huggingface.co/acme/support-llama" {
+		t.Errorf("expected model checksum and provenance, got %+v", model)
+	}
+	if model.ModelCard == nil || model.ModelCard.ModelParameters.Datasets[0].Ref != "dataset:support-tickets" {
+		t.Errorf("expected model card to reference the dataset, got %+v", model.ModelCard)
+	}
+	if dataset.Type != "data" || dataset.BOMRef != "dataset:support-tickets" || dataset.Version != "2024-06" {
+		t.Errorf("unexpected dataset component %+v", dataset)
+	}
+	if dataset.Licenses[0].License.Name != "CC-BY-4.0" || dataset.Hashes[0].Content != "ab12" {
+		t.Errorf("expected dataset license and checksum, got %+v", dataset)
+	}
+	if dataset.ExtRefs[0].Type != "distribution" {
+		t.Errorf("expected provenance as distribution reference, got %+v", dataset.ExtRefs)
+	}
+}
@@ -0,0 +1,33 @@
+package bom
+
+import "fmt"
+
+// Format identifies a BOM serialization format
+type Format string
+
+const (
+	FormatCycloneDX Format = "cyclonedx"
+	FormatSPDX      Format = "spdx"
+)
+
+// Export serializes the BOM in the requested format
+func (b *BOM) Export(format Format) ([]byte, error) {
+	switch format {
+	case FormatCycloneDX:
+		return b.MarshalCycloneDX()
+	case FormatSPDX:
+		return b.MarshalSPDX()
+	default:
+		return nil, fmt.Errorf("unsupported bom format %q", format)
+	}
+}
+
+// MediaType returns the HTTP content type for a format
+func (f Format) MediaType() string {
+	switch f {
+	case FormatSPDX:
+		return SPDXMediaType
+	default:
+		return CycloneDXMediaType
+	}
+}
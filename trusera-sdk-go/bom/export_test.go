@@ -0,0 +1,50 @@
+package bom
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	doc := NewBuilder("agent").AddModel(Model{Name: "gpt-4o"}).Build()
+
+	tests := []struct {
+		format Format
+		key    string
+	}{
+		{FormatCycloneDX, "bomFormat"},
+		{FormatSPDX, "@graph"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			data, err := doc.Export(tt.format)
+			if err != nil {
+				t.Fatalf("Export failed: %v", err)
+			}
+
+			var out map[string]any
+			if err := json.Unmarshal(data, &out); err != nil {
+				t.Fatalf("output is not valid JSON: %v", err)
+			}
+			if _, ok := out[tt.key]; !ok {
+				t.Errorf("expected key %q in %s output", tt.key, tt.format)
+			}
+		})
+	}
+}
+
+func TestExportUnsupportedFormat(t *testing.T) {
+	if _, err := NewBuilder("agent").Build().Export("xml"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestFormatMediaType(t *testing.T) {
+	if FormatSPDX.MediaType() != SPDXMediaType {
+		t.Errorf("unexpected SPDX media type: %s", FormatSPDX.MediaType())
+	}
+	if FormatCycloneDX.MediaType() != CycloneDXMediaType {
+		t.Errorf("unexpected CycloneDX media type: %s", FormatCycloneDX.MediaType())
+	}
+}
@@ -0,0 +1,95 @@
+package bom
+
+import "strings"
+
+// Relation names a lineage edge. Edges point the way data flows, from the
+// upstream element to the one built from or fed by it.
+type Relation string
+
+// Relations understood by the CycloneDX and SPDX serializers. Custom values
+// are allowed and serialized as generic dependencies.
+const (
+	LineageTrains    Relation = "trains"     // Dataset → model trained on it
+	LineageFineTunes Relation = "fine_tunes" // Dataset or base model → fine-tuned model
+	LineageServes    Relation = "serves"     // Model → agent calling it
+	LineageGrounds   Relation = "grounds"    // Dataset → agent retrieving from it
+	LineageWritesTo  Relation = "writes_to"  // Tool → downstream system it changes
+	LineageReadsFrom Relation = "reads_from" // Upstream system → tool reading it
+)
+
+// Lineage is a directed edge between two elements of the BOM, named by their
+// references, e.g. DatasetRef("tickets") → ModelRef("support-llama")
+type Lineage struct {
+	From     string
+	To       string
+	Relation Relation
+}
+
+// Element kinds used in references
+const (
+	kindAgent   = "agent"
+	kindModel   = "model"
+	kindDataset = "dataset"
+	kindPrompt  = "prompt"
+	kindTool    = "tool"
+	kindSystem  = "system"
+)
+
+// AgentRef references the agent the BOM describes
+func AgentRef(name string) string { return kindAgent + ":" + name }
+
+// ModelRef references a declared model
+func ModelRef(name string) string { return kindModel + ":" + name }
+
+// DatasetRef references a declared dataset
+func DatasetRef(name string) string { return kindDataset + ":" + name }
+
+// PromptRef references a declared prompt
+func PromptRef(name string) string { return kindPrompt + ":" + name }
+
+// ToolRef references a declared tool
+func ToolRef(name string) string { return kindTool + ":" + name }
+
+// SystemRef references a system outside the agent, such as a CRM a tool
+// writes to. Systems need no declaration; lineage edges add them to the BOM.
+func SystemRef(name string) string { return kindSystem + ":" + name }
+
+// splitRef returns the kind and name of a reference
+func splitRef(ref string) (kind, name string) {
+	kind, name, _ = strings.Cut(ref, ":")
+	return kind, name
+}
+
+// AddLineage records that data flows from one element to another, such as a
+// dataset fine-tuning a model or a tool writing to a downstream system.
+// References are built with AgentRef, ModelRef, DatasetRef, PromptRef,
+// ToolRef and SystemRef.
+func (b *Builder) AddLineage(from, to string, relation Relation) *Builder {
+	b.mu.Lock()
+	b.bom.Lineage = append(b.bom.Lineage, Lineage{From: from, To: to, Relation: relation})
+	b.mu.Unlock()
+	return b
+}
+
+// AgentRef references the agent the builder describes
+func (b *Builder) AgentRef() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return AgentRef(b.bom.AgentName)
+}
+
+// systems returns the names of the systems lineage edges refer to, in order
+// of first appearance
+func (b *BOM) systems() []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, l := range b.Lineage {
+		for _, ref := range []string{l.From, l.To} {
+			if kind, name := splitRef(ref); kind == kindSystem && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
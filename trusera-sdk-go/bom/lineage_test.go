@@ -0,0 +1,125 @@
+package bom
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func lineageBOM() *BOM {
+	b := NewBuilder("support-agent").
+		AddDataset(Dataset{Name: "support-tickets"}).
+		AddModel(Model{Name: "llama-3-8b", Provider: "meta"}).
+		AddModel(Model{Name: "support-llama"}).
+		AddTool(Tool{Name: "create_ticket"})
+	b.AddLineage(DatasetRef("support-tickets"), ModelRef("support-llama"), LineageFineTunes).
+		AddLineage(ModelRef("llama-3-8b"), ModelRef("support-llama"), LineageFineTunes).
+		AddLineage(ModelRef("support-llama"), b.AgentRef(), LineageServes).
+		AddLineage(ToolRef("create_ticket"), SystemRef("zendesk"), LineageWritesTo)
+	return b.Build()
+}
+
+func TestAddLineage(t *testing.T) {
+	doc := lineageBOM()
+	if len(doc.Lineage) != 4 {
+		t.Fatalf("expected 4 lineage edges, got %d", len(doc.Lineage))
+	}
+	if l := doc.Lineage[2]; l.From != "model:support-llama" || l.To != "agent:support-agent" || l.Relation != LineageServes {
+		t.Errorf("unexpected edge %+v", l)
+	}
+	if systems := doc.systems(); len(systems) != 1 || systems[0] != "zendesk" {
+		t.Errorf("expected zendesk system, got %v", systems)
+	}
+}
+
+func TestMarshalCycloneDXLineage(t *testing.T) {
+	data, err := lineageBOM().MarshalCycloneDX()
+	if err != nil {
+		t.Fatalf("MarshalCycloneDX failed: %v", err)
+	}
+	var out cdxDocument
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+
+	var tuned *cdxComponent
+	for i, c := range out.Components {
+		if c.BOMRef == "model:support-llama" {
+			tuned = &out.Components[i]
+		}
+	}
+	if tuned == nil {
+		t.Fatal("expected fine-tuned model component")
+	}
+	if tuned.ModelCard == nil || len(tuned.ModelCard.ModelParameters.Datasets) != 1 || tuned.ModelCard.ModelParameters.Datasets[0].Ref != "dataset:support-tickets" {
+		t.Errorf("expected fine-tuning dataset on model card, got %+v", tuned.ModelCard)
+	}
+	if tuned.Pedigree == nil || len(tuned.Pedigree.Ancestors) != 1 || tuned.Pedigree.Ancestors[0].Name != "llama-3-8b" {
+		t.Errorf("expected base model as pedigree ancestor, got %+v", tuned.Pedigree)
+	}
+
+	var system *cdxService
+	for i, s := range out.Services {
+		if s.BOMRef == "system:zendesk" {
+			system = &out.Services[i]
+		}
+	}
+	if system == nil {
+		t.Fatalf("expected downstream system as service, got %+v", out.Services)
+	}
+	found := false
+	for _, p := range system.Properties {
+		if p.Name == "trusera:lineage" && p.Value == "writes_to tool:create_ticket" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected lineage property on system, got %+v", system.Properties)
+	}
+
+	deps := map[string][]string{}
+	for _, d := range out.Dependencies {
+		deps[d.Ref] = d.DependsOn
+	}
+	if len(deps["model:support-llama"]) != 2 {
+		t.Errorf("expected fine-tuned model to depend on dataset and base model, got %v", deps["model:support-llama"])
+	}
+	if len(deps["system:zendesk"]) != 1 || deps["system:zendesk"][0] != "tool:create_ticket" {
+		t.Errorf("expected system to depend on tool, got %v", deps["system:zendesk"])
+	}
+	if len(deps["agent:support-agent"]) != 4 {
+		t.Errorf("expected agent to depend on its 4 components/services, got %v", deps["agent:support-agent"])
+	}
+}
+
+func TestMarshalSPDXLineage(t *testing.T) {
+	data, err := lineageBOM().MarshalSPDX()
+	if err != nil {
+		t.Fatalf("MarshalSPDX failed: %v", err)
+	}
+	var out spdxDocument
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+
+	names := map[string]string{}
+	rels := map[string]spdxElement{}
+	for _, el := range out.Graph {
+		names[el.SpdxID] = el.Name
+		if el.Type == "Relationship" && el.Comment != "" {
+			rels[el.Comment+" "+el.RelationshipType] = el
+		}
+	}
+
+	if r, ok := rels["fine_tunes descendantOf"]; !ok || names[r.From] != "support-llama" || names[r.To[0]] != "llama-3-8b" {
+		t.Errorf("expected fine-tuned model descendantOf base model, got %+v", r)
+	}
+	if r, ok := rels["fine_tunes trainedOn"]; !ok || names[r.From] != "support-llama" || names[r.To[0]] != "support-tickets" {
+		t.Errorf("expected fine-tuned model trainedOn dataset, got %+v", r)
+	}
+	if r, ok := rels["serves dependsOn"]; !ok || names[r.From] != "support-agent" {
+		t.Errorf("expected agent dependsOn model, got %+v", r)
+	}
+	if r, ok := rels["writes_to hasOutput"]; !ok || names[r.From] != "create_ticket" || names[r.To[0]] != "zendesk" {
+		t.Errorf("expected tool hasOutput system, got %+v", r)
+	}
+}
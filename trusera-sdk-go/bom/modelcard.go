@@ -0,0 +1,144 @@
+package bom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// ModelCard documents a model: what it is for, where it falls short and how
+// it was evaluated. Fill in the structured fields, keep the original Markdown,
+// or both; ParseModelCard extracts the fields from a HuggingFace README.
+type ModelCard struct {
+	Description           string
+	IntendedUse           string
+	OutOfScopeUse         string
+	Limitations           []string
+	EthicalConsiderations []string
+	Metrics               []Metric
+	License               string
+	Markdown              string // Original card, kept verbatim for auditors
+}
+
+// Metric is one evaluation result reported by a model card
+type Metric struct {
+	Type    string // e.g. "accuracy" or "f1"
+	Value   string
+	Dataset string // Evaluation dataset, if any
+}
+
+// IsZero reports whether the card has no content
+func (c ModelCard) IsZero() bool {
+	return c.Description == "" && c.IntendedUse == "" && c.OutOfScopeUse == "" &&
+		len(c.Limitations) == 0 && len(c.EthicalConsiderations) == 0 &&
+		len(c.Metrics) == 0 && c.License == "" && c.Markdown == ""
+}
+
+// MarkdownHash returns the hex-encoded SHA-256 of the Markdown card, or ""
+// if there is none
+func (c ModelCard) MarkdownHash() string {
+	if c.Markdown == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(c.Markdown))
+	return hex.EncodeToString(sum[:])
+}
+
+// placeholder fills sections left empty in the HuggingFace card template
+const placeholder = "[More Information Needed]"
+
+// ParseModelCard reads a Markdown model card, such as a HuggingFace
+// README.md, keeping it verbatim in Markdown. The license comes from the
+// YAML front matter and the other fields from sections with the usual
+// headings ("Model Description", "Direct Use", "Out-of-Scope Use", "Bias,
+// Risks, and Limitations", ...). Sections that are missing or still hold the
+// template placeholder are left empty.
+func ParseModelCard(markdown string) ModelCard {
+	card := ModelCard{Markdown: markdown}
+	body := strings.ReplaceAll(markdown, "\r\n", "\n")
+
+	if rest, ok := strings.CutPrefix(body, "---\n"); ok {
+		if front, after, ok := strings.Cut(rest, "\n---"); ok {
+			body = after
+			for _, line := range strings.Split(front, "\n") {
+				if v, ok := strings.CutPrefix(line, "license:"); ok {
+					card.License = strings.Trim(strings.TrimSpace(v), `"'`)
+				}
+			}
+		}
+	}
+
+	var heading string
+	var text []string
+	section := func() {
+		content := strings.TrimSpace(strings.Join(text, "\n"))
+		text = nil
+		if content == "" || content == placeholder {
+			return
+		}
+		h := strings.ToLower(heading)
+		switch {
+		case strings.Contains(h, "out-of-scope") || strings.Contains(h, "out of scope"):
+			card.OutOfScopeUse = content
+		case strings.Contains(h, "direct use") || strings.Contains(h, "intended use") || h == "uses":
+			if card.IntendedUse == "" {
+				card.IntendedUse = content
+			}
+		case strings.Contains(h, "limitation") || strings.Contains(h, "risks"):
+			card.Limitations = append(card.Limitations, markdownItems(content)...)
+		case strings.Contains(h, "ethic"):
+			card.EthicalConsiderations = append(card.EthicalConsiderations, markdownItems(content)...)
+		case strings.Contains(h, "description") || heading == "":
+			if card.Description == "" {
+				card.Description = content
+			}
+		}
+	}
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "#") {
+			section()
+			heading = strings.TrimSpace(strings.TrimLeft(line, "#"))
+			if strings.HasPrefix(line, "# ") {
+				heading = "" // The title, followed by the summary
+			}
+			continue
+		}
+		text = append(text, line)
+	}
+	section()
+	return card
+}
+
+// markdownItems splits a section into its list items, or returns the whole
+// section as one item if it is not a list
+func markdownItems(content string) []string {
+	var items []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if item, ok := strings.CutPrefix(line, "- "); ok {
+			items = append(items, item)
+		} else if item, ok := strings.CutPrefix(line, "* "); ok {
+			items = append(items, item)
+		} else if line != "" && len(items) > 0 {
+			items[len(items)-1] += " " + line
+		} else if line != "" {
+			return []string{content}
+		}
+	}
+	return items
+}
+
+// AttachModelCard sets the card of the declared model with the given name,
+// declaring the model if it was not
+func (b *Builder) AttachModelCard(model string, card ModelCard) *Builder {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i := range b.bom.Models {
+		if b.bom.Models[i].Name == model {
+			b.bom.Models[i].Card = &card
+			return b
+		}
+	}
+	b.bom.Models = append(b.bom.Models, Model{Name: model, Card: &card})
+	return b
+}
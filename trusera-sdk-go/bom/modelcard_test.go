@@ -0,0 +1,131 @@
+package bom
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const huggingFaceCard = `---
+license: llama3.1
+datasets:
+- acme/support-tickets
+---
+
+# Model Card for support-llama
+
+Llama 3 fine-tuned to answer customer support tickets.
+
+## Uses
+
+### Direct Use
+
+Drafting replies to support tickets for agent review.
+
+### Out-of-Scope Use
+
+Legal or medical advice.
+
+## Bias, Risks, and Limitations
+
+- English only
+- May invent order numbers
+
+## Training Details
+
+[More Information Needed]
+`
+
+func TestParseModelCard(t *testing.T) {
+	card := ParseModelCard(huggingFaceCard)
+
+	if card.Markdown != huggingFaceCard {
+		t.Error("expected Markdown kept verbatim")
+	}
+	if card.License != "llama3.1" {
+		t.Errorf("expected license llama3.1, got %q", card.License)
+	}
+	if card.Description != "Llama 3 fine-tuned to answer customer support tickets." {
+		t.Errorf("unexpected description %q", card.Description)
+	}
+	if card.IntendedUse != "Drafting replies to support tickets for agent review." {
+		t.Errorf("unexpected intended use %q", card.IntendedUse)
+	}
+	if card.OutOfScopeUse != "Legal or medical advice." {
+		t.Errorf("unexpected out-of-scope use %q", card.OutOfScopeUse)
+	}
+	if len(card.Limitations) != 2 || card.Limitations[1] != "May invent order numbers" {
+		t.Errorf("unexpected limitations %q", card.Limitations)
+	}
+	if !ParseModelCard("").IsZero() || card.IsZero() {
+		t.Error("unexpected IsZero result")
+	}
+}
+
+func TestAttachModelCardToBuilder(t *testing.T) {
+	doc := NewBuilder("agent").
+		AddModel(Model{Name: "support-llama", Provider: "acme"}).
+		AttachModelCard("support-llama", ModelCard{Description: "tuned"}).
+		AttachModelCard("gpt-4o", ModelCard{Description: "hosted"}).
+		Build()
+
+	if len(doc.Models) != 2 || doc.Models[0].Card.Description != "tuned" || doc.Models[1].Name != "gpt-4o" {
+		t.Errorf("unexpected models %+v", doc.Models)
+	}
+}
+
+func TestMarshalModelCard(t *testing.T) {
+	card := ParseModelCard(huggingFaceCard)
+	card.EthicalConsiderations = []string{"Replies must be reviewed before sending"}
+	card.Metrics = []Metric{{Type: "accuracy", Value: "0.91", Dataset: "tickets-eval"}}
+	doc := NewBuilder("agent").
+		AddModel(Model{Name: "support-llama", Task: "text-generation", Card: &card}).
+		Build()
+
+	var cdx cdxDocument
+	data, _ := doc.MarshalCycloneDX()
+	if err := json.Unmarshal(data, &cdx); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	model := cdx.Components[0]
+	if model.Description != card.Description || len(model.Licenses) != 1 || model.Licenses[0].License.Name != "llama3.1" {
+		t.Errorf("expected description and license from card, got %+v", model)
+	}
+	mc := model.ModelCard
+	if mc == nil || mc.ModelParameters.Task != "text-generation" || mc.Considerations == nil || mc.QuantitativeAnalysis == nil {
+		t.Fatalf("unexpected model card %+v", mc)
+	}
+	if len(mc.Considerations.TechnicalLimitations) != 2 || mc.Considerations.UseCases[0] != card.IntendedUse || mc.Considerations.EthicalConsiderations[0].Name != card.EthicalConsiderations[0] {
+		t.Errorf("unexpected considerations %+v", mc.Considerations)
+	}
+	if m := mc.QuantitativeAnalysis.PerformanceMetrics[0]; m.Type != "accuracy" || m.Value != "0.91" || m.Slice != "tickets-eval" {
+		t.Errorf("unexpected metric %+v", m)
+	}
+	props := map[string]string{}
+	for _, p := range model.Properties {
+		props[p.Name] = p.Value
+	}
+	if props["trusera:model_card:sha256"] != card.MarkdownHash() || props["trusera:model_card:out_of_scope_use"] != card.OutOfScopeUse {
+		t.Errorf("unexpected properties %v", props)
+	}
+
+	var spdx spdxDocument
+	data, _ = doc.MarshalSPDX()
+	if err := json.Unmarshal(data, &spdx); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	for _, el := range spdx.Graph {
+		if el.Type != "ai_AIPackage" {
+			continue
+		}
+		if el.Limitation != "English only; May invent order numbers" || el.AppInfo != card.IntendedUse || spdxLicense(spdx, el.SpdxID) != "llama3.1" {
+			t.Errorf("unexpected AI package %+v", el)
+		}
+		if len(el.Metric) != 1 || el.Metric[0].Key != "accuracy (tickets-eval)" {
+			t.Errorf("unexpected metrics %+v", el.Metric)
+		}
+		if !strings.Contains(el.Comment, "model card sha256: "+card.MarkdownHash()) {
+			t.Errorf("expected card hash in comment, got %q", el.Comment)
+		}
+	}
+}
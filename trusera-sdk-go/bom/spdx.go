@@ -0,0 +1,551 @@
+package bom
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SPDX media type and spec version emitted by MarshalSPDX
+const (
+	SPDXMediaType   = "application/spdx+json"
+	spdxSpecVersion = "3.0.1"
+	spdxContext     = "https://spdx.org/rdf/3.0.1/spdx-context.jsonld"
+	spdxCreationRef = "_:creationinfo"
+)
+
+type spdxDocument struct {
+	Context string        `json:"@context"`
+	Graph   []spdxElement `json:"@graph"`
+}
+
+// spdxElement is a union of the SPDX 3.0 element shapes used in the AI-BOM.
+// Unused fields are omitted so each element only carries its own properties.
+type spdxElement struct {
+	Type         string `json:"type"`
+	ID           string `json:"@id,omitempty"`
+	SpdxID       string `json:"spdxId,omitempty"`
+	CreationInfo string `json:"creationInfo,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
+	Comment      string `json:"comment,omitempty"`
+
+	// CreationInfo
+	SpecVersion string   `json:"specVersion,omitempty"`
+	Created     string   `json:"created,omitempty"`
+	CreatedBy   []string `json:"createdBy,omitempty"`
+
+	// SpdxDocument / software_Sbom
+	ProfileConformance []string `json:"profileConformance,omitempty"`
+	RootElement        []string `json:"rootElement,omitempty"`
+	Element            []string `json:"element,omitempty"`
+	SbomType           []string `json:"software_sbomType,omitempty"`
+
+	// software_Package / ai_AIPackage / dataset_DatasetPackage
+	SuppliedBy     string     `json:"suppliedBy,omitempty"`
+	PackageVersion string     `json:"software_packageVersion,omitempty"`
+	PackageURL     string     `json:"software_packageUrl,omitempty"`
+	DownloadLoc    string     `json:"software_downloadLocation,omitempty"`
+	PrimaryPurpose string     `json:"software_primaryPurpose,omitempty"`
+	VerifiedUsing  []spdxHash `json:"verifiedUsing,omitempty"`
+	TypeOfModel    []string   `json:"ai_typeOfModel,omitempty"`
+	AutonomyType   string     `json:"ai_autonomyType,omitempty"`
+	RiskAssessment string     `json:"ai_safetyRiskAssessment,omitempty"`
+	AppInfo        string     `json:"ai_informationAboutApplication,omitempty"`
+	Limitation     string     `json:"ai_limitation,omitempty"`
+	Metric         []spdxDict `json:"ai_metric,omitempty"`
+	DatasetType    []string   `json:"dataset_datasetType,omitempty"`
+
+	// simplelicensing_LicenseExpression
+	LicenseExpression string `json:"simplelicensing_licenseExpression,omitempty"`
+
+	// Relationship
+	From             string   `json:"from,omitempty"`
+	RelationshipType string   `json:"relationshipType,omitempty"`
+	To               []string `json:"to,omitempty"`
+}
+
+type spdxDict struct {
+	Type  string `json:"type"`
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"`
+}
+
+type spdxHash struct {
+	Type      string `json:"type"`
+	Algorithm string `json:"algorithm"`
+	HashValue string `json:"hashValue"`
+}
+
+// MarshalSPDX serializes the BOM as an SPDX 3.0 JSON-LD document using the
+// AI and Dataset profiles. Licenses are simplelicensing_LicenseExpression
+// elements linked to their packages, and dataset types outside the SPDX
+// vocabulary are exported as "other" with the original type in the comment.
+func (b *BOM) MarshalSPDX() ([]byte, error) {
+	return json.MarshalIndent(b.spdx(), "", "  ")
+}
+
+func (b *BOM) spdx() spdxDocument {
+	ns := b.SerialNumber + "#"
+	id := func(kind, name string) string {
+		return ns + "SPDXRef-" + kind + "-" + spdxIDSafe(name)
+	}
+
+	creatorID := ns + "SPDXRef-Creator"
+	docID := ns + "SPDXRef-Document"
+	sbomID := ns + "SPDXRef-AIBOM"
+	agentID := id("Agent", b.AgentName)
+
+	graph := []spdxElement{
+		{
+			Type:        "CreationInfo",
+			ID:          spdxCreationRef,
+			SpecVersion: spdxSpecVersion,
+			Created:     b.Timestamp.Format(time.RFC3339),
+			CreatedBy:   []string{creatorID},
+		},
+		{
+			Type:         "SoftwareAgent",
+			SpdxID:       creatorID,
+			CreationInfo: spdxCreationRef,
+			Name:         "trusera-sdk-go",
+		},
+		{
+			Type:           "software_Package",
+			SpdxID:         agentID,
+			CreationInfo:   spdxCreationRef,
+			Name:           b.AgentName,
+			PackageVersion: b.AgentVersion,
+			PrimaryPurpose: "application",
+		},
+	}
+	if c := b.Compliance; c != nil {
+		spdxCompliance(&graph[2], c)
+	}
+
+	elements := []string{agentID}
+	var deps []string
+	var rels []spdxElement
+	suppliers := map[string]string{}
+
+	// Licenses are separate elements that packages point to. The SDK records
+	// the license a supplier states and does not analyze the contents, so the
+	// relationship is hasDeclaredLicense.
+	licenses := map[string]string{}
+	declareLicense := func(from, license string) {
+		if license == "" {
+			return
+		}
+		licID, ok := licenses[license]
+		if !ok {
+			licID = id("License", strconv.Itoa(len(licenses)+1))
+			licenses[license] = licID
+			graph = append(graph, spdxElement{
+				Type:              "simplelicensing_LicenseExpression",
+				SpdxID:            licID,
+				CreationInfo:      spdxCreationRef,
+				LicenseExpression: license,
+			})
+			elements = append(elements, licID)
+		}
+		rels = append(rels, spdxElement{
+			Type:             "Relationship",
+			SpdxID:           from + "-HasDeclaredLicense",
+			CreationInfo:     spdxCreationRef,
+			From:             from,
+			RelationshipType: "hasDeclaredLicense",
+			To:               []string{licID},
+		})
+	}
+
+	for _, m := range b.Models {
+		el := spdxElement{
+			Type:           "ai_AIPackage",
+			SpdxID:         id("Model", m.Name),
+			CreationInfo:   spdxCreationRef,
+			Name:           m.Name,
+			PackageVersion: m.Version,
+			DownloadLoc:    m.ProvenanceURI,
+			PrimaryPurpose: "model",
+			VerifiedUsing:  spdxSHA256(m.Checksum),
+		}
+		if len(m.Datasets) > 0 {
+			rel := spdxElement{
+				Type:             "Relationship",
+				SpdxID:           id("Relationship", m.Name+"-TrainedOn"),
+				CreationInfo:     spdxCreationRef,
+				From:             el.SpdxID,
+				RelationshipType: "trainedOn",
+			}
+			for _, name := range m.Datasets {
+				rel.To = append(rel.To, id("Dataset", name))
+			}
+			rels = append(rels, rel)
+		}
+		if m.Task != "" {
+			el.TypeOfModel = []string{m.Task}
+		}
+		license := m.License
+		if m.Card != nil {
+			spdxCard(&el, *m.Card)
+			if license == "" {
+				license = m.Card.License
+			}
+		}
+		declareLicense(el.SpdxID, license)
+		if m.Provider != "" {
+			orgID, ok := suppliers[m.Provider]
+			if !ok {
+				orgID = id("Org", m.Provider)
+				suppliers[m.Provider] = orgID
+				graph = append(graph, spdxElement{
+					Type:         "Organization",
+					SpdxID:       orgID,
+					CreationInfo: spdxCreationRef,
+					Name:         m.Provider,
+				})
+				elements = append(elements, orgID)
+			}
+			el.SuppliedBy = orgID
+		}
+		graph = append(graph, el)
+		elements = append(elements, el.SpdxID)
+		deps = append(deps, el.SpdxID)
+	}
+
+	for _, d := range b.Datasets {
+		el := spdxElement{
+			Type:           "dataset_DatasetPackage",
+			SpdxID:         id("Dataset", d.Name),
+			CreationInfo:   spdxCreationRef,
+			Name:           d.Name,
+			Description:    d.Description,
+			PackageVersion: d.Version,
+			DownloadLoc:    d.ProvenanceURI,
+			PrimaryPurpose: "data",
+			DatasetType:    []string{spdxDatasetType(d.Type)},
+			VerifiedUsing:  spdxSHA256(d.Checksum),
+		}
+		if el.DatasetType[0] == "other" {
+			el.Comment = "dataset type: " + d.Type
+		}
+		declareLicense(el.SpdxID, d.License)
+		graph = append(graph, el)
+		elements = append(elements, el.SpdxID)
+		deps = append(deps, el.SpdxID)
+	}
+
+	// Relationships are listed after the elements they connect
+	for _, rel := range rels {
+		graph = append(graph, rel)
+		elements = append(elements, rel.SpdxID)
+	}
+
+	for _, name := range b.systems() {
+		el := spdxElement{
+			Type:           "software_Package",
+			SpdxID:         id("System", name),
+			CreationInfo:   spdxCreationRef,
+			Name:           name,
+			Comment:        "external system",
+			PrimaryPurpose: "other",
+		}
+		graph = append(graph, el)
+		elements = append(elements, el.SpdxID)
+	}
+
+	ref := func(r string) string {
+		kind, name := splitRef(r)
+		switch kind {
+		case kindAgent:
+			return agentID
+		case kindModel, kindDataset, kindPrompt, kindTool, kindSystem:
+			return id(strings.ToUpper(kind[:1])+kind[1:], name)
+		}
+		return id("Element", r)
+	}
+	for i, l := range b.Lineage {
+		from, relType, to := spdxLineage(l, ref(l.From), ref(l.To))
+		rel := spdxElement{
+			Type:             "Relationship",
+			SpdxID:           id("Lineage", strconv.Itoa(i+1)),
+			CreationInfo:     spdxCreationRef,
+			Comment:          string(l.Relation),
+			From:             from,
+			RelationshipType: relType,
+			To:               []string{to},
+		}
+		graph = append(graph, rel)
+		elements = append(elements, rel.SpdxID)
+	}
+
+	for _, p := range b.Prompts {
+		el := spdxElement{
+			Type:           "dataset_DatasetPackage",
+			SpdxID:         id("Prompt", p.Name),
+			CreationInfo:   spdxCreationRef,
+			Name:           p.Name,
+			Comment:        "prompt template",
+			PackageVersion: p.Version,
+			PrimaryPurpose: "data",
+			DatasetType:    []string{"text"},
+			VerifiedUsing:  []spdxHash{{Type: "Hash", Algorithm: "sha256", HashValue: p.Hash()}},
+		}
+		graph = append(graph, el)
+		elements = append(elements, el.SpdxID)
+		deps = append(deps, el.SpdxID)
+	}
+
+	for _, t := range b.Tools {
+		comment := "agent tool"
+		if len(t.Permissions) > 0 {
+			comment += "; permissions: " + t.permissionList()
+		}
+		el := spdxElement{
+			Type:           "software_Package",
+			SpdxID:         id("Tool", t.Name),
+			CreationInfo:   spdxCreationRef,
+			Name:           t.Name,
+			Description:    t.Description,
+			Comment:        comment,
+			DownloadLoc:    t.Endpoint,
+			PrimaryPurpose: "application",
+		}
+		graph = append(graph, el)
+		elements = append(elements, el.SpdxID)
+		deps = append(deps, el.SpdxID)
+	}
+
+	for _, d := range b.Dependencies {
+		purl := "pkg:golang/" + d.Path
+		if d.Version != "" {
+			purl += "@" + d.Version
+		}
+		el := spdxElement{
+			Type:           "software_Package",
+			SpdxID:         id("Package", d.Path+"@"+d.Version),
+			CreationInfo:   spdxCreationRef,
+			Name:           d.Path,
+			PackageVersion: d.Version,
+			PackageURL:     purl,
+			PrimaryPurpose: "library",
+		}
+		graph = append(graph, el)
+		elements = append(elements, el.SpdxID)
+		deps = append(deps, el.SpdxID)
+	}
+
+	for _, v := range b.Vulnerabilities {
+		vulnID := id("Vulnerability", v.ID)
+		graph = append(graph, spdxElement{
+			Type:         "security_Vulnerability",
+			SpdxID:       vulnID,
+			CreationInfo: spdxCreationRef,
+			Name:         v.ID,
+			Description:  v.Summary,
+		})
+		elements = append(elements, vulnID)
+
+		relType, vexType := vexRelationship(v.State)
+		rel := spdxElement{
+			Type:             vexType,
+			SpdxID:           id("VEX", v.ID),
+			CreationInfo:     spdxCreationRef,
+			From:             vulnID,
+			RelationshipType: relType,
+		}
+		for _, a := range v.Affects {
+			rel.To = append(rel.To, id("Package", a))
+		}
+		graph = append(graph, rel)
+		elements = append(elements, rel.SpdxID)
+	}
+
+	if len(deps) > 0 {
+		relID := ns + "SPDXRef-Relationship-Agent-DependsOn"
+		graph = append(graph, spdxElement{
+			Type:             "Relationship",
+			SpdxID:           relID,
+			CreationInfo:     spdxCreationRef,
+			From:             agentID,
+			RelationshipType: "dependsOn",
+			To:               deps,
+		})
+		elements = append(elements, relID)
+	}
+
+	profiles := []string{"core", "software", "ai", "dataset"}
+	if len(licenses) > 0 {
+		profiles = append(profiles, "simpleLicensing")
+	}
+	if len(b.Vulnerabilities) > 0 {
+		profiles = append(profiles, "security")
+	}
+
+	graph = append(graph,
+		spdxElement{
+			Type:         "software_Sbom",
+			SpdxID:       sbomID,
+			CreationInfo: spdxCreationRef,
+			Name:         b.AgentName + " AI-BOM",
+			RootElement:  []string{agentID},
+			Element:      elements,
+			SbomType:     []string{"runtime"},
+		},
+		spdxElement{
+			Type:               "SpdxDocument",
+			SpdxID:             docID,
+			CreationInfo:       spdxCreationRef,
+			Name:               b.AgentName,
+			ProfileConformance: profiles,
+			RootElement:        []string{sbomID},
+			Element:            append([]string{sbomID, creatorID}, elements...),
+		},
+	)
+
+	return spdxDocument{Context: spdxContext, Graph: graph}
+}
+
+// spdxCard fills in an AI package from its model card. The out-of-scope use
+// and the hash of the Markdown card go in the comment.
+func spdxCard(el *spdxElement, card ModelCard) {
+	el.Description = card.Description
+	el.AppInfo = card.IntendedUse
+	el.Limitation = strings.Join(card.Limitations, "; ")
+	for _, m := range card.Metrics {
+		key := m.Type
+		if m.Dataset != "" {
+			key += " (" + m.Dataset + ")"
+		}
+		el.Metric = append(el.Metric, spdxDict{Type: "DictionaryEntry", Key: key, Value: m.Value})
+	}
+
+	var notes []string
+	if card.OutOfScopeUse != "" {
+		notes = append(notes, "out-of-scope use: "+card.OutOfScopeUse)
+	}
+	if len(card.EthicalConsiderations) > 0 {
+		notes = append(notes, "ethical considerations: "+strings.Join(card.EthicalConsiderations, "; "))
+	}
+	if hash := card.MarkdownHash(); hash != "" {
+		notes = append(notes, "model card sha256: "+hash)
+	}
+	el.Comment = strings.Join(notes, "; ")
+}
+
+// spdxCompliance declares the agent as an AI package so it can carry the AI
+// profile's risk and autonomy fields. The role, oversight mode and model
+// cards have no SPDX field and go in the comment.
+func spdxCompliance(el *spdxElement, c *Compliance) {
+	el.Type = "ai_AIPackage"
+	el.AppInfo = c.IntendedPurpose
+	switch c.RiskCategory {
+	case RiskProhibited:
+		el.RiskAssessment = "serious"
+	case RiskHigh:
+		el.RiskAssessment = "high"
+	case RiskLimited:
+		el.RiskAssessment = "medium"
+	case RiskMinimal:
+		el.RiskAssessment = "low"
+	}
+	switch c.Oversight {
+	case OversightInTheLoop, OversightInCommand:
+		el.AutonomyType = "no"
+	case OversightOnTheLoop, OversightNone:
+		el.AutonomyType = "yes"
+	}
+
+	var notes []string
+	if c.Role != "" {
+		notes = append(notes, "role: "+string(c.Role))
+	}
+	if c.Oversight != "" {
+		notes = append(notes, "human oversight: "+string(c.Oversight))
+	}
+	if len(c.ModelCards) > 0 {
+		notes = append(notes, "model cards: "+strings.Join(c.ModelCards, ", "))
+	}
+	el.Comment = strings.Join(notes, "; ")
+}
+
+// spdxLineage maps a lineage edge onto an SPDX 3.0 relationship, which points
+// from the element the relationship describes: a model is trainedOn a
+// dataset, a fine-tuned model is a descendantOf its base model, and a tool
+// hasOutput to the system it writes to
+func spdxLineage(l Lineage, upstream, downstream string) (from, relType, to string) {
+	fromKind, _ := splitRef(l.From)
+	switch l.Relation {
+	case LineageTrains:
+		return downstream, "trainedOn", upstream
+	case LineageFineTunes:
+		if fromKind == kindModel {
+			return downstream, "descendantOf", upstream
+		}
+		return downstream, "trainedOn", upstream
+	case LineageGrounds, LineageReadsFrom:
+		return downstream, "hasInput", upstream
+	case LineageWritesTo:
+		return upstream, "hasOutput", downstream
+	}
+	return downstream, "dependsOn", upstream
+}
+
+// spdxDatasetTypes is the SPDX 3.0 dataset type vocabulary, keyed by its
+// lower-case form
+var spdxDatasetTypes = map[string]string{
+	"structured": "structured", "numeric": "numeric", "text": "text",
+	"categorical": "categorical", "graph": "graph", "timeseries": "timeseries",
+	"timestamp": "timestamp", "sensor": "sensor", "image": "image",
+	"syntactic": "syntactic", "audio": "audio", "video": "video",
+	"other": "other", "noassertion": "noAssertion",
+	// Common names for the same types
+	"tabular": "structured", "time-series": "timeseries", "time_series": "timeseries",
+}
+
+// spdxDatasetType maps a Dataset.Type onto the SPDX 3.0 vocabulary. An empty
+// type is noAssertion and any other value is other.
+func spdxDatasetType(t string) string {
+	if t == "" {
+		return "noAssertion"
+	}
+	if v, ok := spdxDatasetTypes[strings.ToLower(t)]; ok {
+		return v
+	}
+	return "other"
+}
+
+// vexRelationship maps a VEX state to an SPDX 3.0 relationship type and the
+// assessment relationship class that carries it
+func vexRelationship(state string) (relType, class string) {
+	switch state {
+	case VEXExploitable:
+		return "affects", "security_VexAffectedVulnAssessmentRelationship"
+	case VEXNotAffected:
+		return "doesNotAffect", "security_VexNotAffectedVulnAssessmentRelationship"
+	case VEXResolved:
+		return "fixedIn", "security_VexFixedVulnAssessmentRelationship"
+	default:
+		return "underInvestigationFor", "security_VexUnderInvestigationVulnAssessmentRelationship"
+	}
+}
+
+// spdxSHA256 returns a SHA-256 integrity method, if any
+func spdxSHA256(sum string) []spdxHash {
+	if sum == "" {
+		return nil
+	}
+	return []spdxHash{{Type: "Hash", Algorithm: "sha256", HashValue: sum}}
+}
+
+// spdxIDSafe replaces characters that are not valid in an SPDX element ID
+func spdxIDSafe(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		}
+		return '-'
+	}, s)
+}
@@ -0,0 +1,273 @@
+package bom
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMarshalSPDX(t *testing.T) {
+	doc := NewBuilder("support-agent").
+		WithVersion("1.0.0").
+		AddModel(Model{Name: "gpt-4o", Provider: "openai", Task: "text-generation", License: "proprietary"}).
+		AddModel(Model{Name: "text-embedding-3-small", Provider: "openai", Task: "embeddings"}).
+		AddPrompt(Prompt{Name: "system", Template: "Be concise."}).
+		AddTool(Tool{Name: "lookup_order", Permissions: []Permission{PermissionInternalAPI, PermissionEmail}}).
+		Build()
+	doc.Dependencies = []Dependency{{Path: "golang.org/x/net", Version: "v0.20.0"}}
+
+	data, err := doc.MarshalSPDX()
+	if err != nil {
+		t.Fatalf("MarshalSPDX failed: %v", err)
+	}
+
+	var out spdxDocument
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+
+	if out.Context != spdxContext {
+		t.Errorf("expected SPDX 3.0 context, got %s", out.Context)
+	}
+
+	byType := map[string][]spdxElement{}
+	for _, el := range out.Graph {
+		byType[el.Type] = append(byType[el.Type], el)
+	}
+
+	if len(byType["ai_AIPackage"]) != 2 {
+		t.Errorf("expected 2 AI packages, got %d", len(byType["ai_AIPackage"]))
+	}
+	if len(byType["Organization"]) != 1 {
+		t.Errorf("expected a single deduplicated supplier, got %d", len(byType["Organization"]))
+	}
+	if len(byType["dataset_DatasetPackage"]) != 1 {
+		t.Errorf("expected prompt as dataset package, got %d", len(byType["dataset_DatasetPackage"]))
+	}
+	if len(byType["software_Package"]) != 3 {
+		t.Errorf("expected agent, tool and dependency packages, got %d", len(byType["software_Package"]))
+	}
+	for _, el := range byType["software_Package"] {
+		if el.Name == "lookup_order" && el.Comment != "agent tool; permissions: internal_api,email" {
+			t.Errorf("expected tool permissions in the comment, got %q", el.Comment)
+		}
+	}
+
+	docs := byType["SpdxDocument"]
+	if len(docs) != 1 {
+		t.Fatalf("expected one SpdxDocument, got %d", len(docs))
+	}
+	conformance := strings.Join(docs[0].ProfileConformance, ",")
+	if !strings.Contains(conformance, "ai") || !strings.Contains(conformance, "dataset") {
+		t.Errorf("expected ai and dataset profile conformance, got %s", conformance)
+	}
+
+	rels := byType["Relationship"]
+	if len(rels) != 2 || rels[1].RelationshipType != "dependsOn" || len(rels[1].To) != 5 {
+		t.Errorf("expected a license and agent dependsOn 5 elements, got %+v", rels)
+	}
+
+	model := byType["ai_AIPackage"][0]
+	if spdxLicense(out, model.SpdxID) != "proprietary" || !strings.Contains(conformance, "simpleLicensing") {
+		t.Errorf("expected the model's declared license, got %+v", byType["simplelicensing_LicenseExpression"])
+	}
+	if model.SuppliedBy != byType["Organization"][0].SpdxID {
+		t.Error("expected model to reference its supplier")
+	}
+	if !strings.HasPrefix(model.SpdxID, doc.SerialNumber+"#") {
+		t.Errorf("expected element IDs namespaced by serial number, got %s", model.SpdxID)
+	}
+}
+
+// spdxLicense returns the license expression an element declares
+func spdxLicense(doc spdxDocument, from string) string {
+	var licID string
+	for _, el := range doc.Graph {
+		if el.RelationshipType == "hasDeclaredLicense" && el.From == from {
+			licID = el.To[0]
+		}
+	}
+	for _, el := range doc.Graph {
+		if el.SpdxID == licID && el.Type == "simplelicensing_LicenseExpression" {
+			return el.LicenseExpression
+		}
+	}
+	return ""
+}
+
+func TestSPDXIDSafe(t *testing.T) {
+	if got := spdxIDSafe("golang.org/x/net@v0.20.0"); got != "golang.org-x-net-v0.20.0" {
+		t.Errorf("unexpected sanitized ID: %s", got)
+	}
+}
+
+func TestMarshalSPDXDatasets(t *testing.T) {
+	doc := NewBuilder("support-agent").
+		AddDataset(Dataset{Name: "support-tickets", Type: "text", License: "CC-BY-4.0", Checksum: "ab12"}).
+		AddModel(Model{Name: "support-llama", ProvenanceURI: "https://huggingface.co/acme/support-llama", Datasets: []string{"support-tickets"}}).
+		Build()
+
+	var out spdxDocument
+	data, _ := doc.MarshalSPDX()
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+
+	var model, dataset, trainedOn *spdxElement
+	for i, el := range out.Graph {
+		switch {
+		case el.Type == "ai_AIPackage":
+			model = &out.Graph[i]
+		case el.Type == "dataset_DatasetPackage":
+			dataset = &out.Graph[i]
+		case el.RelationshipType == "trainedOn":
+			trainedOn = &out.Graph[i]
+		}
+	}
+	if model == nil || model.DownloadLoc != "https://huggingface.co/acme/support-llama" {
+		t.Fatalf("expected model with download location, got %+v", model)
+	}
+	if dataset == nil || spdxLicense(out, dataset.SpdxID) != "CC-BY-4.0" || dataset.VerifiedUsing[0].HashValue != "ab12" {
+		t.Fatalf("expected dataset with license and checksum, got %+v", dataset)
+	}
+	if trainedOn == nil || trainedOn.From != model.SpdxID || trainedOn.To[0] != dataset.SpdxID {
+		t.Errorf("expected trainedOn relationship from model to dataset, got %+v", trainedOn)
+	}
+}
+
+// spdxClass lists the properties an SPDX 3.0.1 class defines or inherits
+// (https://spdx.github.io/spdx-spec/v3.0.1/model/), restricted to those a
+// Trusera BOM can carry, and which of them are required
+type spdxClass struct {
+	props    []string
+	required []string
+}
+
+var (
+	spdxElementProps  = []string{"type", "spdxId", "creationInfo", "name", "summary", "description", "comment", "verifiedUsing"}
+	spdxPackageProps  = append(append([]string{}, spdxElementProps...), "suppliedBy", "software_primaryPurpose", "software_packageVersion", "software_packageUrl", "software_downloadLocation")
+	spdxRelationProps = append(append([]string{}, spdxElementProps...), "from", "relationshipType", "to")
+	spdxElementReq    = []string{"type", "spdxId", "creationInfo"}
+	spdxRelationReq   = append(append([]string{}, spdxElementReq...), "from", "relationshipType", "to")
+)
+
+var spdxClasses = map[string]spdxClass{
+	"CreationInfo":     {[]string{"type", "@id", "specVersion", "created", "createdBy"}, []string{"specVersion", "created", "createdBy"}},
+	"SoftwareAgent":    {spdxElementProps, spdxElementReq},
+	"Organization":     {spdxElementProps, spdxElementReq},
+	"software_Package": {spdxPackageProps, spdxElementReq},
+	"ai_AIPackage": {append(append([]string{}, spdxPackageProps...), "ai_typeOfModel", "ai_autonomyType", "ai_safetyRiskAssessment",
+		"ai_informationAboutApplication", "ai_limitation", "ai_metric"), spdxElementReq},
+	"dataset_DatasetPackage":                                   {append(append([]string{}, spdxPackageProps...), "dataset_datasetType"), append(append([]string{}, spdxElementReq...), "dataset_datasetType")},
+	"simplelicensing_LicenseExpression":                        {append(append([]string{}, spdxElementProps...), "simplelicensing_licenseExpression"), append(append([]string{}, spdxElementReq...), "simplelicensing_licenseExpression")},
+	"Relationship":                                             {spdxRelationProps, spdxRelationReq},
+	"security_Vulnerability":                                   {spdxElementProps, spdxElementReq},
+	"security_VexUnderInvestigationVulnAssessmentRelationship": {spdxRelationProps, spdxRelationReq},
+	"software_Sbom":                                            {append(append([]string{}, spdxElementProps...), "rootElement", "element", "software_sbomType"), spdxElementReq},
+	"SpdxDocument":                                             {append(append([]string{}, spdxElementProps...), "rootElement", "element", "profileConformance"), spdxElementReq},
+}
+
+// spdxVocabularies lists the allowed values of properties with a vocabulary
+var spdxVocabularies = map[string][]string{
+	"relationshipType": {"dependsOn", "trainedOn", "descendantOf", "hasInput", "hasOutput", "hasDeclaredLicense",
+		"hasConcludedLicense", "affects", "doesNotAffect", "fixedIn", "underInvestigationFor"},
+	"software_primaryPurpose": {"application", "data", "library", "model", "other"},
+	"dataset_datasetType": {"structured", "numeric", "text", "categorical", "graph", "timeseries", "timestamp",
+		"sensor", "image", "syntactic", "audio", "video", "other", "noAssertion"},
+	"ai_autonomyType":         {"yes", "no", "noAssertion"},
+	"ai_safetyRiskAssessment": {"serious", "high", "medium", "low"},
+	"software_sbomType":       {"analyzed", "build", "deployed", "design", "runtime", "source"},
+	"profileConformance":      {"core", "software", "ai", "dataset", "security", "simpleLicensing"},
+}
+
+func TestMarshalSPDXMatchesModel(t *testing.T) {
+	b := NewBuilder("support-agent").
+		WithVersion("1.0.0").
+		WithCompliance(Compliance{IntendedPurpose: "support", RiskCategory: RiskHigh, Oversight: OversightOnTheLoop}).
+		AddDataset(Dataset{Name: "support-tickets", Type: "Tabular", License: "CC-BY-4.0"}).
+		AddDataset(Dataset{Name: "docs", Type: "retrieval", License: "CC-BY-4.0"}).
+		AddDataset(Dataset{Name: "calls"}).
+		AddModel(Model{Name: "support-llama", Provider: "meta", Task: "text-generation", License: "llama3.1",
+			Datasets: []string{"support-tickets"}, Card: &ModelCard{Metrics: []Metric{{Type: "accuracy", Value: "0.9"}}}}).
+		AddPrompt(Prompt{Name: "system", Template: "Be concise."}).
+		AddTool(Tool{Name: "create_ticket"})
+	b.AddLineage(DatasetRef("docs"), b.AgentRef(), LineageGrounds).
+		AddLineage(ToolRef("create_ticket"), SystemRef("zendesk"), LineageWritesTo)
+	doc := b.Build()
+	doc.Dependencies = []Dependency{{Path: "golang.org/x/net", Version: "v0.20.0"}}
+	doc.Vulnerabilities = []Vulnerability{{ID: "GO-2024-2687", Affects: []string{"golang.org/x/net@v0.20.0"}}}
+
+	data, err := doc.MarshalSPDX()
+	if err != nil {
+		t.Fatalf("MarshalSPDX failed: %v", err)
+	}
+	var out struct {
+		Context string           `json:"@context"`
+		Graph   []map[string]any `json:"@graph"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+
+	ids := map[string]bool{spdxCreationRef: true}
+	for _, el := range out.Graph {
+		if id, ok := el["spdxId"].(string); ok {
+			if ids[id] {
+				t.Errorf("duplicate spdxId %s", id)
+			}
+			ids[id] = true
+		}
+	}
+
+	for _, el := range out.Graph {
+		typ, _ := el["type"].(string)
+		class, ok := spdxClasses[typ]
+		if !ok {
+			t.Errorf("unexpected class %q", typ)
+			continue
+		}
+		for prop, value := range el {
+			if !slices.Contains(class.props, prop) {
+				t.Errorf("%s %v: property %s is not defined for the class", typ, el["spdxId"], prop)
+			}
+			if vocab, ok := spdxVocabularies[prop]; ok {
+				for _, v := range spdxStrings(value) {
+					if !slices.Contains(vocab, v) {
+						t.Errorf("%s %v: %s %q is not in the vocabulary", typ, el["spdxId"], prop, v)
+					}
+				}
+			}
+			switch prop {
+			case "creationInfo", "suppliedBy", "from", "to", "createdBy", "element", "rootElement":
+				for _, ref := range spdxStrings(value) {
+					if !ids[ref] {
+						t.Errorf("%s %v: %s references unknown element %s", typ, el["spdxId"], prop, ref)
+					}
+				}
+			}
+		}
+		for _, prop := range class.required {
+			if _, ok := el[prop]; !ok {
+				t.Errorf("%s %v: missing required property %s", typ, el["spdxId"], prop)
+			}
+		}
+	}
+}
+
+// spdxStrings returns a string or list of strings property as a list
+func spdxStrings(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		var out []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
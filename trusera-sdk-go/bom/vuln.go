@@ -0,0 +1,271 @@
+package bom
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultOSVURL is the public OSV API
+const defaultOSVURL = "https://api.osv.dev"
+
+// VEX analysis states attached to findings
+const (
+	VEXInTriage    = "in_triage"
+	VEXExploitable = "exploitable"
+	VEXNotAffected = "not_affected"
+	VEXResolved    = "resolved"
+)
+
+// Vulnerability is a known vulnerability affecting one or more dependencies
+type Vulnerability struct {
+	ID       string   // e.g. "GO-2024-2687"
+	Aliases  []string // e.g. CVE and GHSA identifiers
+	Summary  string
+	Severity string // "low", "medium", "high" or "critical", if known
+	CVSS     string // CVSS vector, if known
+	Source   string // Database the finding came from, e.g. "osv"
+	Affects  []string
+	FixedIn  string // First fixed version, if known
+	State    string // VEX analysis state, VEXInTriage unless set by the source
+}
+
+// VulnerabilitySource looks up known vulnerabilities for Go module
+// dependencies. Affects must hold the "path@version" of each matched
+// dependency. *trusera.Client implements it using the Trusera API.
+type VulnerabilitySource interface {
+	LookupVulnerabilities(ctx context.Context, deps []Dependency) ([]Vulnerability, error)
+}
+
+// ScanVulnerabilities queries each source for vulnerabilities in the BOM's
+// Go dependencies and attaches the findings to the BOM, replacing those of
+// any earlier scan. OSV is queried if no source is given.
+func (b *BOM) ScanVulnerabilities(ctx context.Context, sources ...VulnerabilitySource) error {
+	if len(sources) == 0 {
+		sources = []VulnerabilitySource{&OSV{}}
+	}
+
+	var deps []Dependency
+	for _, d := range b.Dependencies {
+		if d.Version != "" && d.Version != "(devel)" {
+			deps = append(deps, d)
+		}
+	}
+
+	byID := map[string]*Vulnerability{}
+	for _, src := range sources {
+		found, err := src.LookupVulnerabilities(ctx, deps)
+		if err != nil {
+			return err
+		}
+		for _, v := range found {
+			if existing, ok := byID[v.ID]; ok {
+				existing.Aliases = mergeStrings(existing.Aliases, v.Aliases)
+				existing.Affects = mergeStrings(existing.Affects, v.Affects)
+				continue
+			}
+			v := v
+			if v.State == "" {
+				v.State = VEXInTriage
+			}
+			byID[v.ID] = &v
+		}
+	}
+
+	b.Vulnerabilities = make([]Vulnerability, 0, len(byID))
+	for _, v := range byID {
+		b.Vulnerabilities = append(b.Vulnerabilities, *v)
+	}
+	sort.Slice(b.Vulnerabilities, func(i, j int) bool { return b.Vulnerabilities[i].ID < b.Vulnerabilities[j].ID })
+	return nil
+}
+
+// mergeStrings returns the sorted union of a and b
+func mergeStrings(a, b []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, s := range append(append([]string(nil), a...), b...) {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// OSV is a VulnerabilitySource backed by the OSV database (https://osv.dev)
+type OSV struct {
+	BaseURL    string       // Defaults to https://api.osv.dev
+	HTTPClient *http.Client // Defaults to a client with a 30 second timeout
+}
+
+type osvQuery struct {
+	Package osvPackage `json:"package"`
+	Version string     `json:"version"`
+}
+
+type osvPackage struct {
+	Name      string `json:"name"`
+	Ecosystem string `json:"ecosystem"`
+}
+
+type osvVuln struct {
+	ID       string   `json:"id"`
+	Aliases  []string `json:"aliases"`
+	Summary  string   `json:"summary"`
+	Details  string   `json:"details"`
+	Severity []struct {
+		Type  string `json:"type"`
+		Score string `json:"score"`
+	} `json:"severity"`
+	Affected []struct {
+		Package osvPackage `json:"package"`
+		Ranges  []struct {
+			Events []map[string]string `json:"events"`
+		} `json:"ranges"`
+	} `json:"affected"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+}
+
+// LookupVulnerabilities batches the dependencies into one OSV query, then
+// fetches the details of each vulnerability found
+func (o *OSV) LookupVulnerabilities(ctx context.Context, deps []Dependency) ([]Vulnerability, error) {
+	if len(deps) == 0 {
+		return nil, nil
+	}
+
+	queries := make([]osvQuery, len(deps))
+	for i, d := range deps {
+		// OSV's Go ecosystem uses versions without the "v" prefix
+		queries[i] = osvQuery{Package: osvPackage{Name: d.Path, Ecosystem: "Go"}, Version: strings.TrimPrefix(d.Version, "v")}
+	}
+
+	var batch struct {
+		Results []struct {
+			Vulns []struct {
+				ID string `json:"id"`
+			} `json:"vulns"`
+		} `json:"results"`
+	}
+	if err := o.do(ctx, http.MethodPost, "/v1/querybatch", map[string]any{"queries": queries}, &batch); err != nil {
+		return nil, err
+	}
+
+	affects := map[string][]string{}
+	var ids []string
+	for i, r := range batch.Results {
+		if i >= len(deps) {
+			break
+		}
+		for _, v := range r.Vulns {
+			if _, ok := affects[v.ID]; !ok {
+				ids = append(ids, v.ID)
+			}
+			affects[v.ID] = append(affects[v.ID], deps[i].Path+"@"+deps[i].Version)
+		}
+	}
+
+	out := make([]Vulnerability, 0, len(ids))
+	for _, id := range ids {
+		var detail osvVuln
+		if err := o.do(ctx, http.MethodGet, "/v1/vulns/"+id, nil, &detail); err != nil {
+			return nil, err
+		}
+		out = append(out, detail.vulnerability(affects[id]))
+	}
+	return out, nil
+}
+
+// vulnerability converts an OSV record into a finding
+func (v *osvVuln) vulnerability(affects []string) Vulnerability {
+	out := Vulnerability{
+		ID:       v.ID,
+		Aliases:  v.Aliases,
+		Summary:  v.Summary,
+		Severity: strings.ToLower(v.DatabaseSpecific.Severity),
+		Source:   "osv",
+		Affects:  affects,
+	}
+	if out.Summary == "" {
+		out.Summary = v.Details
+	}
+	if out.Severity == "moderate" {
+		out.Severity = "medium"
+	}
+	for _, s := range v.Severity {
+		if strings.HasPrefix(s.Type, "CVSS") {
+			out.CVSS = s.Score
+		}
+	}
+
+	paths := map[string]bool{}
+	for _, a := range affects {
+		paths[a[:strings.LastIndex(a, "@")]] = true
+	}
+	for _, a := range v.Affected {
+		if !paths[a.Package.Name] {
+			continue
+		}
+		for _, r := range a.Ranges {
+			for _, e := range r.Events {
+				if fixed := e["fixed"]; fixed != "" {
+					out.FixedIn = "v" + strings.TrimPrefix(fixed, "v")
+				}
+			}
+		}
+	}
+	return out
+}
+
+// do sends a JSON request to the OSV API and decodes the response
+func (o *OSV) do(ctx context.Context, method, path string, body, out any) error {
+	baseURL := o.BaseURL
+	if baseURL == "" {
+		baseURL = defaultOSVURL
+	}
+	hc := o.HTTPClient
+	if hc == nil {
+		hc = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal osv query: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(baseURL, "/")+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create osv request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		return fmt.Errorf("osv request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+		return fmt.Errorf("osv returned status %d for %s", resp.StatusCode, path)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 32<<20)).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode osv response: %w", err)
+	}
+	return nil
+}
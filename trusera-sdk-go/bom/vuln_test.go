@@ -0,0 +1,149 @@
+package bom
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newOSVServer fakes the OSV API with one vulnerability in golang.org/x/net
+func newOSVServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/querybatch":
+			var body struct {
+				Queries []osvQuery `json:"queries"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("invalid querybatch body: %v", err)
+			}
+			results := make([]map[string]any, len(body.Queries))
+			for i, q := range body.Queries {
+				results[i] = map[string]any{}
+				if q.Package.Ecosystem != "Go" {
+					t.Errorf("expected Go ecosystem, got %q", q.Package.Ecosystem)
+				}
+				if q.Package.Name == "golang.org/x/net" && q.Version == "0.20.0" {
+					results[i]["vulns"] = []map[string]string{{"id": "GO-2024-2687"}}
+				}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"results": results})
+		case "/v1/vulns/GO-2024-2687":
+			_, _ = w.Write([]byte(`{
+				"id": "GO-2024-2687",
+				"aliases": ["CVE-2023-45288", "GHSA-4v7x-pqxf-cx7m"],
+				"summary": "HTTP/2 CONTINUATION flood in net/http",
+				"severity": [{"type": "CVSS_V3", "score": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H"}],
+				"affected": [{"package": {"name": "golang.org/x/net", "ecosystem": "Go"},
+					"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "0.23.0"}]}]}],
+				"database_specific": {"severity": "MODERATE"}
+			}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func vulnerableBOM() *BOM {
+	doc := NewBuilder("agent").Build()
+	doc.Dependencies = []Dependency{
+		{Path: "golang.org/x/net", Version: "v0.20.0"},
+		{Path: "golang.org/x/text", Version: "v0.14.0"},
+	}
+	return doc
+}
+
+func TestScanVulnerabilitiesOSV(t *testing.T) {
+	server := newOSVServer(t)
+	doc := vulnerableBOM()
+
+	if err := doc.ScanVulnerabilities(context.Background(), &OSV{BaseURL: server.URL}); err != nil {
+		t.Fatalf("ScanVulnerabilities failed: %v", err)
+	}
+
+	if len(doc.Vulnerabilities) != 1 {
+		t.Fatalf("expected 1 finding, got %+v", doc.Vulnerabilities)
+	}
+	v := doc.Vulnerabilities[0]
+	if v.ID != "GO-2024-2687" || v.Severity != "medium" || v.FixedIn != "v0.23.0" || v.State != VEXInTriage {
+		t.Errorf("unexpected finding %+v", v)
+	}
+	if len(v.Affects) != 1 || v.Affects[0] != "golang.org/x/net@v0.20.0" {
+		t.Errorf("expected x/net to be affected, got %v", v.Affects)
+	}
+}
+
+// staticSource returns fixed findings
+type staticSource []Vulnerability
+
+func (s staticSource) LookupVulnerabilities(context.Context, []Dependency) ([]Vulnerability, error) {
+	return s, nil
+}
+
+func TestScanVulnerabilitiesMergesSources(t *testing.T) {
+	doc := vulnerableBOM()
+	a := staticSource{{ID: "GO-1", Aliases: []string{"CVE-1"}, Affects: []string{"golang.org/x/net@v0.20.0"}}}
+	b := staticSource{
+		{ID: "GO-1", Aliases: []string{"GHSA-1"}, Affects: []string{"golang.org/x/net@v0.20.0"}},
+		{ID: "GO-2", State: VEXNotAffected, Affects: []string{"golang.org/x/text@v0.14.0"}},
+	}
+
+	if err := doc.ScanVulnerabilities(context.Background(), a, b); err != nil {
+		t.Fatalf("ScanVulnerabilities failed: %v", err)
+	}
+
+	if len(doc.Vulnerabilities) != 2 {
+		t.Fatalf("expected 2 merged findings, got %+v", doc.Vulnerabilities)
+	}
+	if got := doc.Vulnerabilities[0].Aliases; len(got) != 2 {
+		t.Errorf("expected aliases merged across sources, got %v", got)
+	}
+	if doc.Vulnerabilities[1].State != VEXNotAffected {
+		t.Errorf("expected source VEX state kept, got %q", doc.Vulnerabilities[1].State)
+	}
+}
+
+func TestVulnerabilitiesExported(t *testing.T) {
+	doc := vulnerableBOM()
+	doc.Vulnerabilities = []Vulnerability{{
+		ID:       "GO-2024-2687",
+		Aliases:  []string{"CVE-2023-45288"},
+		Severity: "medium",
+		CVSS:     "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H",
+		Source:   "osv",
+		Affects:  []string{"golang.org/x/net@v0.20.0"},
+		FixedIn:  "v0.23.0",
+		State:    VEXInTriage,
+	}}
+
+	cdx := doc.cycloneDX()
+	if len(cdx.Vulnerabilities) != 1 {
+		t.Fatalf("expected CycloneDX vulnerability, got %+v", cdx.Vulnerabilities)
+	}
+	v := cdx.Vulnerabilities[0]
+	if v.Affects[0].Ref != "pkg:golang/golang.org/x/net@v0.20.0" {
+		t.Errorf("expected affects to reference the library purl, got %v", v.Affects)
+	}
+	if v.Analysis.State != "in_triage" || v.Ratings[0].Method != "CVSSv31" || v.References[0].Source.Name != "NVD" {
+		t.Errorf("unexpected CycloneDX vulnerability %+v", v)
+	}
+
+	spdx := doc.spdx()
+	var vex *spdxElement
+	for i, el := range spdx.Graph {
+		if el.RelationshipType == "underInvestigationFor" {
+			vex = &spdx.Graph[i]
+		}
+	}
+	if vex == nil || vex.Type != "security_VexUnderInvestigationVulnAssessmentRelationship" {
+		t.Fatalf("expected SPDX VEX relationship, got %+v", vex)
+	}
+	if want := doc.SerialNumber + "#SPDXRef-Package-golang.org-x-net-v0.20.0"; vex.To[0] != want {
+		t.Errorf("expected VEX to target %s, got %v", want, vex.To)
+	}
+}
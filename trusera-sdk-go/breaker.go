@@ -0,0 +1,295 @@
+package trusera
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxSpillBatches bounds how many batches are kept on disk while the circuit is open
+const maxSpillBatches = 1000
+
+// spillQuarantineDir is the subdirectory of the spill directory holding
+// batches that could not be decrypted
+const spillQuarantineDir = "quarantine"
+
+// ErrCircuitOpen is returned by Flush while the circuit breaker is open.
+// The events are kept in the queue, or on disk if a spill directory is set.
+var ErrCircuitOpen = errors.New("trusera: circuit breaker open")
+
+// BreakerState is the state of the client's circuit breaker
+type BreakerState int32
+
+const (
+	BreakerClosed   BreakerState = iota // Requests flow normally
+	BreakerOpen                         // Requests are skipped until the cooldown elapses
+	BreakerHalfOpen                     // A single probe request is in flight
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// breaker trips after consecutive failures and lets one probe through per cooldown
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+}
+
+// WithCircuitBreaker opens the circuit after threshold consecutive flush or
+// heartbeat failures. While open, Flush returns ErrCircuitOpen without
+// contacting the API and keeps events queued; after cooldown a single probe
+// is let through and its outcome closes or reopens the circuit.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(c *Client) {
+		if threshold < 1 {
+			threshold = 1
+		}
+		c.breaker = &breaker{threshold: threshold, cooldown: cooldown}
+	}
+}
+
+// WithSpillDir writes batches that cannot be delivered to dir as JSON files
+// instead of holding them in memory or dropping them. Spilled batches are
+// replayed once the API is reachable again, including by a later process
+// using the same directory.
+func WithSpillDir(dir string) Option {
+	return func(c *Client) {
+		c.spillDir = dir
+	}
+}
+
+// BreakerState returns the current circuit breaker state. It is always
+// BreakerClosed when no breaker is configured.
+func (c *Client) BreakerState() BreakerState {
+	if c.breaker == nil {
+		return BreakerClosed
+	}
+	c.breaker.mu.Lock()
+	defer c.breaker.mu.Unlock()
+	return c.breaker.state
+}
+
+// allow reports whether a request may be made, moving an open circuit to
+// half-open once the cooldown has elapsed
+func (b *breaker) allow() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		return true
+	case BreakerHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+// done records the outcome of a request made after allow returned true
+func (b *breaker) done(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		b.state = BreakerClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// cancel ends a request made after allow returned true without an outcome,
+// such as a batch that failed to encode and was never sent
+func (b *breaker) cancel() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// The cooldown has passed, so the next request probes again
+	if b.state == BreakerHalfOpen {
+		b.state = BreakerOpen
+	}
+}
+
+// holdEvents keeps events that could not be delivered, spilling them to disk
+// if a spill directory is set. It reports whether the events were kept.
+func (c *Client) holdEvents(events []Event) bool {
+	if c.spillDir != "" {
+		if err := c.spill(events); err != nil {
+			c.logf(LogWarn, "spill failed: %v", err)
+			return false
+		}
+		return true
+	}
+	c.requeueEvents(events)
+	return true
+}
+
+// spill writes events to a new batch file in the spill directory
+func (c *Client) spill(events []Event) error {
+	if err := os.MkdirAll(c.spillDir, 0o700); err != nil {
+		return err
+	}
+	files, err := c.spillFiles()
+	if err != nil {
+		return err
+	}
+	if len(files) >= maxSpillBatches {
+		return fmt.Errorf("spill directory holds %d batches", len(files))
+	}
+
+	data, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.spillSeq++
+	name := fmt.Sprintf("batch-%020d-%06d.json", time.Now().UnixNano(), c.spillSeq%1000000)
+	c.mu.Unlock()
+
+	if data, err = c.sealSpill(name, data); err != nil {
+		return err
+	}
+	tmp := filepath.Join(c.spillDir, name+".tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(c.spillDir, name))
+}
+
+// spillFiles lists spilled batch files, oldest first
+func (c *Client) spillFiles() ([]string, error) {
+	entries, err := os.ReadDir(c.spillDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), "batch-") && strings.HasSuffix(e.Name(), ".json") {
+			files = append(files, filepath.Join(c.spillDir, e.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// replaySpill delivers spilled batches oldest first, stopping at the first
+// failure, and returns how many requests it sent. Files are split into
+// batches of at most maxBatchBytes, and each batch waits its turn with the
+// rate limiter; reserved says the caller already reserved the first one. A
+// file is removed once all its batches are sent; after a partial failure it
+// is sent again whole, and the API deduplicates the batches already
+// delivered by their idempotency keys.
+func (c *Client) replaySpill(ctx context.Context, reserved bool) (int, error) {
+	if c.spillDir == "" {
+		return 0, nil
+	}
+	c.spillMu.Lock()
+	defer c.spillMu.Unlock()
+
+	files, err := c.spillFiles()
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return sent, err
+		}
+		if data, err = c.openSpill(filepath.Base(path), data); err != nil {
+			c.quarantineSpill(path, err)
+			continue
+		}
+		var events []Event
+		if err := json.Unmarshal(data, &events); err != nil {
+			c.logf(LogWarn, "discarding corrupt spill file %s: %v", path, err)
+			os.Remove(path)
+			continue
+		}
+
+		events, sizes := c.fitEvents(events)
+		for len(events) > 0 {
+			if (sent > 0 || !reserved) && !c.limiter.reserve() {
+				return sent, ErrRateLimited
+			}
+			if !c.breaker.allow() {
+				return sent, ErrCircuitOpen
+			}
+			n := c.batchLen(sizes)
+			err := c.sendEvents(ctx, events[:n])
+			sent++
+			if errors.Is(err, ErrRateLimited) {
+				c.breaker.done(nil)
+				return sent, err
+			}
+			c.breaker.done(err)
+			if err != nil {
+				return sent, err
+			}
+			events, sizes = events[n:], sizes[n:]
+		}
+		os.Remove(path)
+	}
+	return sent, nil
+}
+
+// quarantineSpill moves a spill file that cannot be decrypted, or is not
+// encrypted although a key is set, out of the replay queue, so it no longer
+// counts towards maxSpillBatches, keeping its name so Replay can still
+// decrypt it with the right key
+func (c *Client) quarantineSpill(path string, reason error) {
+	dir := filepath.Join(c.spillDir, spillQuarantineDir)
+	dest := filepath.Join(dir, filepath.Base(path))
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		c.logf(LogWarn, "skipping spill file %s: %v (quarantine failed: %v)", path, reason, err)
+		return
+	}
+	if err := os.Rename(path, dest); err != nil {
+		c.logf(LogWarn, "skipping spill file %s: %v (quarantine failed: %v)", path, reason, err)
+		return
+	}
+	c.logf(LogWarn, "quarantined spill file %s: %v; replay it using Replay or trusera replay once checked", dest, reason)
+}
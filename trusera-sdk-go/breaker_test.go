@@ -0,0 +1,196 @@
+package trusera
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyTransport fails while down is set and counts every call
+type flakyTransport struct {
+	down  atomic.Bool
+	calls atomic.Int32
+	sent  atomic.Int32
+}
+
+func (f *flakyTransport) Send(_ context.Context, b Batch) error {
+	f.calls.Add(1)
+	if f.down.Load() {
+		return errors.New("backend unavailable")
+	}
+	f.sent.Add(int32(len(b.Events)))
+	return nil
+}
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	ft := &flakyTransport{}
+	ft.down.Store(true)
+	client := NewClient("test-key", WithTransport(ft), WithCircuitBreaker(2, 50*time.Millisecond))
+	defer client.Close()
+
+	for i := 0; i < 2; i++ {
+		client.Track(NewEvent(EventToolCall, "step"))
+		if err := client.Flush(); err == nil {
+			t.Fatal("expected flush error while backend is down")
+		}
+	}
+	if client.BreakerState() != BreakerOpen {
+		t.Fatalf("expected breaker open, got %s", client.BreakerState())
+	}
+
+	calls := ft.calls.Load()
+	client.Track(NewEvent(EventToolCall, "step"))
+	if err := client.Flush(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+	if ft.calls.Load() != calls {
+		t.Error("expected no request while breaker is open")
+	}
+	if n := len(queuedEvents(client)); n != 3 {
+		t.Errorf("expected 3 events held in memory, got %d", n)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	ft.down.Store(false)
+	if err := client.Flush(); err != nil {
+		t.Fatalf("expected probe to succeed, got %v", err)
+	}
+	if client.BreakerState() != BreakerClosed {
+		t.Errorf("expected breaker closed, got %s", client.BreakerState())
+	}
+	if ft.sent.Load() != 3 {
+		t.Errorf("expected 3 events delivered, got %d", ft.sent.Load())
+	}
+	if s := client.Stats(); s.Dropped != 0 {
+		t.Errorf("expected no dropped events, got %d", s.Dropped)
+	}
+}
+
+func TestCircuitBreakerFailedProbeReopens(t *testing.T) {
+	ft := &flakyTransport{}
+	ft.down.Store(true)
+	client := NewClient("test-key", WithTransport(ft), WithCircuitBreaker(1, 20*time.Millisecond))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "step"))
+	client.Flush()
+	time.Sleep(30 * time.Millisecond)
+
+	if err := client.Flush(); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected probe to reach the backend and fail, got %v", err)
+	}
+	if client.BreakerState() != BreakerOpen {
+		t.Errorf("expected breaker to reopen, got %s", client.BreakerState())
+	}
+}
+
+func TestSpillDirReplaysAcrossClients(t *testing.T) {
+	dir := t.TempDir()
+	ft := &flakyTransport{}
+	ft.down.Store(true)
+
+	client := NewClient("test-key", WithTransport(ft), WithCircuitBreaker(1, time.Hour), WithSpillDir(dir))
+	client.Track(NewEvent(EventToolCall, "a"))
+	client.Flush()
+	client.Track(NewEvent(EventToolCall, "b"))
+	if err := client.Flush(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if n := len(queuedEvents(client)); n != 0 {
+		t.Errorf("expected spilled events to leave memory, got %d queued", n)
+	}
+	client.Track(NewEvent(EventToolCall, "c"))
+	if err := client.Close(); err != nil {
+		t.Fatalf("expected events to be spilled on close, got %v", err)
+	}
+
+	files, _ := os.ReadDir(dir)
+	if len(files) != 3 {
+		t.Fatalf("expected 3 spill files, got %d", len(files))
+	}
+
+	ft.down.Store(false)
+	next := NewClient("test-key", WithTransport(ft), WithSpillDir(dir))
+	defer next.Close()
+	if err := next.Flush(); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if ft.sent.Load() != 3 {
+		t.Errorf("expected 3 replayed events, got %d", ft.sent.Load())
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("expected spill files removed after replay, got %d", len(files))
+	}
+}
+
+func TestHeartbeatFailuresOpenBreaker(t *testing.T) {
+	client := NewClient("test-key",
+		WithBaseURL("http://127.0.0.1:1"),
+		WithCircuitBreaker(2, time.Hour),
+	)
+	defer client.Close()
+	client.fleetAgentID = "fleet-1"
+
+	client.sendHeartbeat()
+	client.sendHeartbeat()
+	if client.BreakerState() != BreakerOpen {
+		t.Fatalf("expected breaker open after heartbeat failures, got %s", client.BreakerState())
+	}
+
+	client.sendHeartbeat()
+	if s := client.Stats(); s.HeartbeatFailures != 2 {
+		t.Errorf("expected heartbeat skipped while open, got %d failures", s.HeartbeatFailures)
+	}
+}
+
+func TestSpillReplayHonorsRateLimit(t *testing.T) {
+	dir := t.TempDir()
+	ft := &flakyTransport{}
+	ft.down.Store(true)
+	client := NewClient("test-key", WithTransport(ft), WithCircuitBreaker(1, time.Hour), WithSpillDir(dir))
+	for _, name := range []string{"a", "b", "c"} {
+		client.Track(NewEvent(EventToolCall, name))
+		client.Flush()
+	}
+	client.Close()
+
+	ft.down.Store(false)
+	next := NewClient("test-key", WithTransport(ft), WithSpillDir(dir))
+	defer next.Close()
+	next.limiter.interval = time.Hour // As after a 429
+	if err := next.Flush(); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	if ft.sent.Load() != 1 {
+		t.Errorf("expected one spilled batch sent before the limit, got %d", ft.sent.Load())
+	}
+	if files, _ := next.spillFiles(); len(files) != 2 {
+		t.Errorf("expected 2 spill files left, got %d", len(files))
+	}
+}
+
+func TestSpillReplaySplitsLargeFiles(t *testing.T) {
+	dir := t.TempDir()
+	ft := &flakyTransport{}
+	ft.down.Store(true)
+	client := NewClient("test-key", WithTransport(ft), WithCircuitBreaker(1, time.Hour), WithSpillDir(dir), WithBatchSize(1000))
+	for i := 0; i < 6; i++ {
+		client.Track(NewEvent(EventToolCall, "t").WithPayload("output", strings.Repeat("x", 400)))
+	}
+	client.Close()
+
+	ft.down.Store(false)
+	calls := ft.calls.Load()
+	next := NewClient("test-key", WithTransport(ft), WithSpillDir(dir), WithMaxBatchBytes(1200))
+	defer next.Close()
+	if err := next.Flush(); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if ft.sent.Load() != 6 || ft.calls.Load()-calls < 3 {
+		t.Errorf("expected 6 events in several requests, got %d in %d", ft.sent.Load(), ft.calls.Load()-calls)
+	}
+}
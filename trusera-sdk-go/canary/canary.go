@@ -0,0 +1,218 @@
+// Package canary compares models during a rollout. Choose routes a stable
+// fraction of traffic to a canary variant, and Shadow runs a candidate model
+// alongside the primary one without affecting the caller, recording both
+// calls' latency and tokens and a summary of how the responses differ:
+//
+//	shadow := canary.NewShadow(client, canary.Options{SampleRate: 0.1})
+//	resp, err := shadow.Run(ctx, "answer", callGPT4o, callCandidate)
+//
+// Events tracked with the contexts the calls receive, including those
+// recorded by trusera.WrapTransport, carry "variant" metadata.
+package canary
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+// Variant labels
+const (
+	VariantPrimary = "primary"
+	VariantShadow  = "shadow"
+	VariantStable  = "stable"
+	VariantCanary  = "canary"
+)
+
+// defaultTimeout bounds a shadow call that outlives the primary call
+const defaultTimeout = 30 * time.Second
+
+// Choose deterministically assigns key, such as a user or session ID, to
+// VariantCanary with probability fraction, or else to VariantStable. The
+// same key always gets the same variant for a given fraction, and raising
+// the fraction only moves keys from stable to canary. The returned context
+// is labeled with the variant.
+func Choose(ctx context.Context, key string, fraction float64) (context.Context, string) {
+	variant := VariantStable
+	if bucket(key) < fraction {
+		variant = VariantCanary
+	}
+	return trusera.ContextWithVariant(ctx, variant), variant
+}
+
+// bucket maps key uniformly onto [0, 1)
+func bucket(key string) float64 {
+	sum := sha256.Sum256([]byte(key))
+	return float64(binary.BigEndian.Uint64(sum[:8])>>11) / (1 << 53)
+}
+
+// Response is what a model call returns, as far as comparisons need it
+type Response struct {
+	Text             string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Func calls a model. It should use ctx for its requests, so their events
+// carry the variant label.
+type Func func(ctx context.Context) (Response, error)
+
+// Options configures a Shadow
+type Options struct {
+	// SampleRate is the fraction of calls that also run the shadow, 1 (every
+	// call) when zero
+	SampleRate float64
+	// Timeout bounds each shadow call, 30 seconds by default. Shadow calls
+	// are not canceled when the primary call's context is.
+	Timeout time.Duration
+	// IncludeText adds both response texts to comparison events. They are
+	// left out by default, as responses may hold personal data.
+	IncludeText bool
+	// Compare scores the similarity of two responses from 0 to 1, word
+	// overlap by default
+	Compare func(primary, shadow string) float64
+}
+
+// Shadow runs shadow calls and records comparisons
+type Shadow struct {
+	client *trusera.Client
+	opts   Options
+	wg     sync.WaitGroup
+}
+
+// NewShadow creates a Shadow recording comparisons with client
+func NewShadow(client *trusera.Client, opts Options) *Shadow {
+	if opts.SampleRate <= 0 {
+		opts.SampleRate = 1
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.Compare == nil {
+		opts.Compare = Similarity
+	}
+	return &Shadow{client: client, opts: opts}
+}
+
+// outcome is the result of one side of a comparison
+type outcome struct {
+	resp    Response
+	err     error
+	latency time.Duration
+}
+
+// Run calls primary and returns its result. For a sample of calls, shadow is
+// called concurrently; once both finish a trusera.EventComparison event
+// named name is tracked. Shadow errors and panics never reach the caller,
+// and Run does not wait for the shadow call.
+func (s *Shadow) Run(ctx context.Context, name string, primary, shadow Func) (Response, error) {
+	if shadow == nil || rand.Float64() >= s.opts.SampleRate {
+		return primary(trusera.ContextWithVariant(ctx, VariantPrimary))
+	}
+
+	done := make(chan outcome, 1)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		sctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.opts.Timeout)
+		defer cancel()
+		done <- call(trusera.ContextWithVariant(sctx, VariantShadow), shadow)
+	}()
+
+	// The primary call runs as it would without a shadow, panics included
+	start := time.Now()
+	var p outcome
+	p.resp, p.err = primary(trusera.ContextWithVariant(ctx, VariantPrimary))
+	p.latency = time.Since(start)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.record(ctx, name, p, <-done)
+	}()
+	return p.resp, p.err
+}
+
+// Wait blocks until every shadow call started so far has finished and been
+// recorded. Call it before closing the client.
+func (s *Shadow) Wait() {
+	s.wg.Wait()
+}
+
+// call runs a shadow call, timing it and turning a panic into an error
+func call(ctx context.Context, fn Func) (o outcome) {
+	start := time.Now()
+	defer func() {
+		o.latency = time.Since(start)
+		if r := recover(); r != nil {
+			o.err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	o.resp, o.err = fn(ctx)
+	return o
+}
+
+// record tracks the comparison of a primary and a shadow call
+func (s *Shadow) record(ctx context.Context, name string, p, sh outcome) {
+	e := trusera.NewEvent(trusera.EventComparison, name).
+		WithPayload("latency_delta_ms", sh.latency.Milliseconds()-p.latency.Milliseconds())
+	e = side(e, VariantPrimary, p, s.opts.IncludeText)
+	e = side(e, VariantShadow, sh, s.opts.IncludeText)
+	if p.err == nil && sh.err == nil {
+		e = e.WithPayload("identical", p.resp.Text == sh.resp.Text).
+			WithPayload("similarity", s.opts.Compare(p.resp.Text, sh.resp.Text)).
+			WithPayload("length_delta", len(sh.resp.Text)-len(p.resp.Text))
+	}
+	s.client.TrackContext(ctx, e)
+}
+
+// side adds one call's results to e under prefix
+func side(e trusera.Event, prefix string, o outcome, includeText bool) trusera.Event {
+	e = e.WithPayload(prefix+"_latency_ms", o.latency.Milliseconds())
+	if o.resp.Model != "" {
+		e = e.WithPayload(prefix+"_model", o.resp.Model)
+	}
+	if o.err != nil {
+		return e.WithPayload(prefix+"_error", o.err.Error())
+	}
+	e = e.WithPayload(prefix+"_prompt_tokens", o.resp.PromptTokens).
+		WithPayload(prefix+"_completion_tokens", o.resp.CompletionTokens)
+	if includeText {
+		e = e.WithPayload(prefix+"_text", o.resp.Text)
+	}
+	return e
+}
+
+// Similarity scores two texts from 0 to 1 by the overlap of their
+// lower-cased words (Jaccard index). Two empty texts are identical.
+func Similarity(a, b string) float64 {
+	wa, wb := words(a), words(b)
+	if len(wa) == 0 && len(wb) == 0 {
+		return 1
+	}
+	shared := 0
+	for w := range wa {
+		if wb[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(wa)+len(wb)-shared)
+}
+
+// words returns the set of lower-cased words in s
+func words(s string) map[string]bool {
+	set := map[string]bool{}
+	for _, w := range strings.Fields(strings.ToLower(s)) {
+		set[strings.Trim(w, ".,;:!?\"'()[]{}")] = true
+	}
+	delete(set, "")
+	return set
+}
@@ -0,0 +1,156 @@
+package canary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/truseratest"
+)
+
+func TestChoose(t *testing.T) {
+	canaries := 0
+	for i := 0; i < 10000; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		ctx, v := Choose(context.Background(), key, 0.2)
+		if label, _ := trusera.VariantFromContext(ctx); label != v {
+			t.Fatalf("expected the context labeled %s, got %s", v, label)
+		}
+		if _, again := Choose(context.Background(), key, 0.2); again != v {
+			t.Fatalf("expected a stable assignment for %s", key)
+		}
+		if v == VariantCanary {
+			canaries++
+			if _, wider := Choose(context.Background(), key, 0.5); wider != VariantCanary {
+				t.Fatalf("expected %s to stay canary as the fraction grows", key)
+			}
+		}
+	}
+	if canaries < 1800 || canaries > 2200 {
+		t.Errorf("expected about 2000 canaries, got %d", canaries)
+	}
+}
+
+func TestShadowRecordsComparison(t *testing.T) {
+	client := truseratest.NewRecordingClient()
+	defer client.Close()
+	s := NewShadow(client.Client, Options{IncludeText: true})
+
+	primary := func(ctx context.Context) (Response, error) {
+		client.TrackContext(ctx, trusera.NewEvent(trusera.EventLLMInvoke, "openai gpt-4o"))
+		return Response{Text: "The refund was issued.", Model: "gpt-4o", PromptTokens: 10, CompletionTokens: 5}, nil
+	}
+	shadow := func(ctx context.Context) (Response, error) {
+		client.TrackContext(ctx, trusera.NewEvent(trusera.EventLLMInvoke, "openai gpt-5"))
+		time.Sleep(20 * time.Millisecond)
+		return Response{Text: "The refund was issued today.", Model: "gpt-5", PromptTokens: 10, CompletionTokens: 6}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	resp, err := s.Run(ctx, "answer", primary, shadow)
+	cancel() // The shadow call must outlive the caller's context
+	if err != nil || resp.Model != "gpt-4o" {
+		t.Fatalf("expected the primary response, got %+v, %v", resp, err)
+	}
+	s.Wait()
+
+	if llm := client.Events(truseratest.OfType(trusera.EventLLMInvoke), truseratest.Named("openai gpt-5")); len(llm) != 1 || llm[0].Metadata["variant"] != VariantShadow {
+		t.Errorf("expected the shadow call labeled, got %+v", llm)
+	}
+	if llm := client.Events(truseratest.Named("openai gpt-4o")); len(llm) != 1 || llm[0].Metadata["variant"] != VariantPrimary {
+		t.Errorf("expected the primary call labeled, got %+v", llm)
+	}
+
+	events := client.Events(truseratest.OfType(trusera.EventComparison))
+	if len(events) != 1 {
+		t.Fatalf("expected 1 comparison, got %d", len(events))
+	}
+	p := events[0].Payload
+	if events[0].Name != "answer" || p["primary_model"] != "gpt-4o" || p["shadow_model"] != "gpt-5" {
+		t.Errorf("expected both models, got %v", p)
+	}
+	if p["shadow_completion_tokens"] != 6 || p["identical"] != false || p["length_delta"] != 6 {
+		t.Errorf("expected tokens and a diff summary, got %v", p)
+	}
+	if sim, _ := p["similarity"].(float64); math.Abs(sim-0.8) > 1e-9 {
+		t.Errorf("expected similarity 0.8, got %v", p["similarity"])
+	}
+	if d, _ := p["latency_delta_ms"].(int64); d < 15 {
+		t.Errorf("expected the shadow to be slower, got %v", p["latency_delta_ms"])
+	}
+	if p["shadow_text"] != "The refund was issued today." {
+		t.Errorf("expected the shadow text, got %v", p["shadow_text"])
+	}
+}
+
+func TestShadowFailuresStayHidden(t *testing.T) {
+	client := truseratest.NewRecordingClient()
+	defer client.Close()
+	s := NewShadow(client.Client, Options{Timeout: 10 * time.Millisecond})
+
+	primary := func(ctx context.Context) (Response, error) { return Response{Text: "ok"}, nil }
+	for _, shadow := range []Func{
+		func(ctx context.Context) (Response, error) { panic("boom") },
+		func(ctx context.Context) (Response, error) { <-ctx.Done(); return Response{}, ctx.Err() },
+	} {
+		if resp, err := s.Run(context.Background(), "answer", primary, shadow); err != nil || resp.Text != "ok" {
+			t.Errorf("expected the primary result, got %+v, %v", resp, err)
+		}
+	}
+	s.Wait()
+
+	events := client.Events(truseratest.OfType(trusera.EventComparison))
+	if len(events) != 2 {
+		t.Fatalf("expected 2 comparisons, got %d", len(events))
+	}
+	if events[0].Payload["shadow_error"] != "panic: boom" {
+		t.Errorf("expected the panic recorded, got %v", events[0].Payload)
+	}
+	if events[1].Payload["shadow_error"] != context.DeadlineExceeded.Error() {
+		t.Errorf("expected the timeout recorded, got %v", events[1].Payload)
+	}
+	if _, ok := events[0].Payload["similarity"]; ok || events[0].Payload["shadow_text"] != nil {
+		t.Errorf("expected no diff or text for a failed shadow, got %v", events[0].Payload)
+	}
+}
+
+func TestShadowSampling(t *testing.T) {
+	client := truseratest.NewRecordingClient()
+	defer client.Close()
+	s := NewShadow(client.Client, Options{SampleRate: 0.000001})
+
+	calls := 0
+	shadow := func(ctx context.Context) (Response, error) { calls++; return Response{}, nil }
+	wantErr := errors.New("primary failed")
+	for i := 0; i < 100; i++ {
+		if _, err := s.Run(context.Background(), "answer", func(ctx context.Context) (Response, error) {
+			return Response{}, wantErr
+		}, shadow); err != wantErr {
+			t.Fatalf("expected the primary error, got %v", err)
+		}
+	}
+	s.Wait()
+	if calls > 1 {
+		t.Errorf("expected the shadow to be sampled out, got %d calls", calls)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want float64
+	}{
+		{"", "", 1},
+		{"Hello, world!", "hello world", 1},
+		{"a b", "c d", 0},
+		{"a b c", "a b d", 0.5},
+	} {
+		if got := Similarity(tc.a, tc.b); got != tc.want {
+			t.Errorf("Similarity(%q, %q): expected %v, got %v", tc.a, tc.b, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package trusera
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// CaptureLevel controls how much prompt and completion content leaves the process
+type CaptureLevel string
+
+const (
+	// CaptureFull sends content verbatim. It is the default.
+	CaptureFull CaptureLevel = "full"
+	// CaptureTruncated sends at most the capture limit of each value
+	CaptureTruncated CaptureLevel = "truncated"
+	// CaptureHashed replaces content with its SHA-256 hash
+	CaptureHashed CaptureLevel = "hashed"
+	// CaptureMetadataOnly removes content entirely
+	CaptureMetadataOnly CaptureLevel = "metadata-only"
+)
+
+// defaultCaptureLimit is the byte limit used by CaptureTruncated
+const defaultCaptureLimit = 1024
+
+// contentKeys are the payload keys that carry prompt or completion content
+var contentKeys = []string{
+	"prompt", "prompts", "messages", "completion", "response",
+	"content", "input", "output", "body_snippet",
+}
+
+// WithCaptureLevel sets how prompt and completion payloads are captured.
+// It can also be set with TRUSERA_CAPTURE_LEVEL.
+func WithCaptureLevel(level CaptureLevel) Option {
+	return func(c *Client) {
+		c.captureLevel = level
+	}
+}
+
+// WithCaptureLimit sets the byte limit applied by CaptureTruncated
+func WithCaptureLimit(n int) Option {
+	return func(c *Client) {
+		if n > 0 {
+			c.captureLimit = n
+		}
+	}
+}
+
+// WithCaptureKeys treats additional payload keys as prompt or completion content
+func WithCaptureKeys(keys ...string) Option {
+	return func(c *Client) {
+		c.captureKeys = append(c.captureKeys, keys...)
+	}
+}
+
+// resolveCaptureLevel applies TRUSERA_CAPTURE_LEVEL and validates the level
+func (c *Client) resolveCaptureLevel() error {
+	if c.captureLevel == "" {
+		c.captureLevel = CaptureLevel(envOrDefault("TRUSERA_CAPTURE_LEVEL", string(CaptureFull)))
+	}
+	switch c.captureLevel {
+	case CaptureFull, CaptureTruncated, CaptureHashed, CaptureMetadataOnly:
+		return nil
+	}
+	return fmt.Errorf("unknown capture level %q", c.captureLevel)
+}
+
+// capture enforces the capture level on an event's content payloads
+func (c *Client) capture(e Event) Event {
+	if c.captureLevel == CaptureFull || len(e.Payload) == 0 {
+		return e
+	}
+
+	var payload map[string]any
+	for _, keys := range [][]string{contentKeys, c.captureKeys} {
+		for _, key := range keys {
+			v, ok := e.Payload[key]
+			if !ok || v == nil {
+				continue
+			}
+			if payload == nil {
+				// Copy so the caller's map is left untouched
+				payload = make(map[string]any, len(e.Payload))
+				for k, v := range e.Payload {
+					payload[k] = v
+				}
+			}
+			if c.captureLevel == CaptureMetadataOnly {
+				delete(payload, key)
+				continue
+			}
+
+			s := contentString(v)
+			if c.captureLevel == CaptureHashed {
+				payload[key] = "sha256:" + HashContent(s)
+			} else if len(s) > c.captureLimit {
+				payload[key] = truncateUTF8(s, c.captureLimit) + "..."
+			}
+		}
+	}
+	if payload != nil {
+		e.Payload = payload
+	}
+	return e
+}
+
+// contentString renders a payload value as text, JSON-encoding non-strings
+func contentString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a rune
+func truncateUTF8(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,101 @@
+package trusera
+
+import (
+	"strings"
+	"testing"
+)
+
+func llmEvent() Event {
+	return NewEvent(EventLLMInvoke, "chat").
+		WithPayload("prompt", "what is the capital of France?").
+		WithPayload("messages", []map[string]string{{"role": "user", "content": "hi"}}).
+		WithPayload("prompt_tokens", 12)
+}
+
+func TestCaptureFullByDefault(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	client.Track(llmEvent())
+
+	e := queuedEvents(client)[0]
+	if e.Payload["prompt"] != "what is the capital of France?" {
+		t.Errorf("expected prompt sent verbatim, got %v", e.Payload["prompt"])
+	}
+}
+
+func TestCaptureHashed(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000), WithCaptureLevel(CaptureHashed))
+	defer client.Close()
+
+	client.Track(llmEvent())
+
+	e := queuedEvents(client)[0]
+	if e.Payload["prompt"] != "sha256:"+HashContent("what is the capital of France?") {
+		t.Errorf("expected hashed prompt, got %v", e.Payload["prompt"])
+	}
+	if got, _ := e.Payload["messages"].(string); !strings.HasPrefix(got, "sha256:") {
+		t.Errorf("expected hashed messages, got %v", e.Payload["messages"])
+	}
+	if e.Payload["prompt_tokens"] != 12 {
+		t.Errorf("expected non-content payload kept, got %v", e.Payload["prompt_tokens"])
+	}
+}
+
+func TestCaptureTruncated(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000), WithCaptureLevel(CaptureTruncated), WithCaptureLimit(8))
+	defer client.Close()
+
+	client.Track(NewEvent(EventLLMInvoke, "chat").
+		WithPayload("output", "héllo wörld").
+		WithPayload("input", "short"))
+
+	e := queuedEvents(client)[0]
+	if e.Payload["output"] != "héllo w..." {
+		t.Errorf("expected output truncated to 8 bytes, got %q", e.Payload["output"])
+	}
+	if e.Payload["input"] != "short" {
+		t.Errorf("expected short input untouched, got %v", e.Payload["input"])
+	}
+}
+
+func TestCaptureMetadataOnly(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000),
+		WithCaptureLevel(CaptureMetadataOnly),
+		WithCaptureKeys("transcript"),
+	)
+	defer client.Close()
+
+	original := llmEvent().WithPayload("transcript", "secret")
+	client.Track(original)
+
+	e := queuedEvents(client)[0]
+	for _, key := range []string{"prompt", "messages", "transcript"} {
+		if _, ok := e.Payload[key]; ok {
+			t.Errorf("expected %s removed, got %v", key, e.Payload[key])
+		}
+	}
+	if e.Payload["prompt_tokens"] != 12 {
+		t.Errorf("expected metadata kept, got %v", e.Payload)
+	}
+	if _, ok := original.Payload["prompt"]; !ok {
+		t.Error("expected caller's payload map to be left untouched")
+	}
+}
+
+func TestCaptureLevelFromEnv(t *testing.T) {
+	t.Setenv("TRUSERA_CAPTURE_LEVEL", "hashed")
+	client := NewClient("test-key")
+	defer client.Close()
+
+	if client.captureLevel != CaptureHashed {
+		t.Errorf("expected hashed from env, got %q", client.captureLevel)
+	}
+}
+
+func TestResolveCaptureLevelRejectsUnknown(t *testing.T) {
+	c := &Client{captureLevel: "partial"}
+	if err := c.resolveCaptureLevel(); err == nil {
+		t.Error("expected error for unknown capture level")
+	}
+}
@@ -0,0 +1,77 @@
+// Package celgo compiles Trusera event policy expressions with cel-go
+// (github.com/google/cel-go), the Go implementation of the Common Expression
+// Language. Programs that use event policies import it for its side effect:
+//
+//	import _ "github.com/Trusera/ai-bom/trusera-sdk-go/celgo"
+//
+// Expressions see one variable, event, a map with the keys id, type, name,
+// payload, metadata and timestamp, and must evaluate to a bool. The standard
+// CEL definitions, including macros such as exists and all, and the string
+// extensions (lowerAscii, split, ...) are available:
+//
+//	event.type == "llm_invoke" && event.payload.model.startsWith("gpt-")
+//	event.payload.messages.exists(m, m.role == "system")
+package celgo
+
+import (
+	"fmt"
+	"sync"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+)
+
+// costLimit bounds the work of one evaluation, as policies run on every
+// tracked event
+const costLimit = 1_000_000
+
+func init() {
+	trusera.RegisterPolicyCompiler(Compile)
+}
+
+var env = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("event", cel.MapType(cel.StringType, cel.DynType)),
+		ext.Strings(),
+	)
+})
+
+// Compile parses and type-checks a policy expression. It is registered with
+// trusera.RegisterPolicyCompiler when the package is imported.
+func Compile(expression string) (trusera.PolicyProgram, error) {
+	e, err := env()
+	if err != nil {
+		return nil, err
+	}
+	ast, iss := e.Compile(expression)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	if t := ast.OutputType(); !t.IsExactType(cel.BoolType) && !t.IsExactType(cel.DynType) {
+		return nil, fmt.Errorf("expression evaluates to %s, want bool", t)
+	}
+	prg, err := e.Program(ast, cel.CostLimit(costLimit))
+	if err != nil {
+		return nil, err
+	}
+	return program{prg}, nil
+}
+
+// program is a compiled policy expression
+type program struct {
+	prg cel.Program
+}
+
+// Match evaluates the expression with event bound to the event variable
+func (p program) Match(event map[string]any) (bool, error) {
+	out, _, err := p.prg.Eval(map[string]any{"event": event})
+	if err != nil {
+		return false, err
+	}
+	b, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expression evaluated to %s, want bool", out.Type())
+	}
+	return b, nil
+}
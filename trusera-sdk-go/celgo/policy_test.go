@@ -0,0 +1,98 @@
+package celgo
+
+import (
+	"testing"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/truseratest"
+)
+
+func TestCompile(t *testing.T) {
+	event := map[string]any{
+		"type": "llm_invoke",
+		"name": "chat",
+		"payload": map[string]any{
+			"model":    "gpt-4o",
+			"tokens":   1200,
+			"cost":     0.02,
+			"tags":     []string{"prod", "eu"},
+			"messages": []any{map[string]any{"role": "system"}, map[string]any{"role": "user"}},
+		},
+		"metadata": map[string]any{"labels": map[string]string{"team": "search"}},
+	}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`event.type == "llm_invoke" && event.payload.model.startsWith("gpt-")`, true},
+		{`event.payload.tokens > 1000 && event.payload.cost < 0.05`, true},
+		{`"eu" in event.payload.tags`, true},
+		{`event.payload.messages.exists(m, m.role == "system")`, true},
+		{`event.payload.messages.all(m, m.role == "user")`, false},
+		{`event.metadata.labels.team.upperAscii() == "SEARCH"`, true},
+		{`has(event.payload.prompt)`, false},
+		{`event.name.matches("^ch")`, true},
+	}
+	for _, tt := range tests {
+		prg, err := Compile(tt.expr)
+		if err != nil {
+			t.Errorf("%s: compile failed: %v", tt.expr, err)
+			continue
+		}
+		got, err := prg.Match(event)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: expected %v, got %v (%v)", tt.expr, tt.want, got, err)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, expr := range []string{`event.name ==`, `1 + 1`, `unknown.name == "x"`} {
+		if _, err := Compile(expr); err == nil {
+			t.Errorf("expected %s to fail to compile", expr)
+		}
+	}
+
+	// CEL reports a missing key as an error rather than null
+	prg, err := Compile(`event.payload.prompt == "x"`)
+	if err != nil {
+		t.Fatalf("compile failed: %v", err)
+	}
+	if _, err := prg.Match(map[string]any{"payload": map[string]any{}}); err == nil {
+		t.Error("expected an error for a missing key")
+	}
+
+	// Dynamic values are checked when evaluated
+	prg, err = Compile(`event.name`)
+	if err != nil {
+		t.Fatalf("compile failed: %v", err)
+	}
+	if _, err := prg.Match(map[string]any{"name": "chat"}); err == nil {
+		t.Error("expected an error for a string result")
+	}
+}
+
+func TestEventPoliciesUseCEL(t *testing.T) {
+	rec := truseratest.NewRecordingClient(trusera.WithEventPolicies(
+		trusera.EventPolicy{Name: "no-shell", Expression: `event.type == "tool_call" && event.name.startsWith("shell")`, Action: trusera.EventPolicyBlock},
+		trusera.EventPolicy{Name: "risky-tools", Expression: `event.type == "tool_call" && event.payload.risk > 3`, Action: trusera.EventPolicyBlock},
+		trusera.EventPolicy{Name: "system-prompts", Expression: `has(event.payload.messages) && event.payload.messages.exists(m, m.role == "system")`,
+			Action: trusera.EventPolicyRedact, Fields: []string{"payload.messages"}},
+	))
+	defer rec.Close()
+
+	rec.Track(trusera.NewEvent(trusera.EventToolCall, "shell_exec").WithPayload("risk", 1))
+	rec.Track(trusera.NewEvent(trusera.EventLLMInvoke, "chat").
+		WithPayload("messages", []any{map[string]any{"role": "system", "content": "secret"}}))
+	rec.Track(trusera.NewEvent(trusera.EventToolCall, "search").WithPayload("risk", 1))
+	// Selecting the missing risk key fails, so the block policy matches
+	rec.Track(trusera.NewEvent(trusera.EventToolCall, "fetch"))
+
+	events := rec.Events()
+	if len(events) != 2 || events[0].Name != "chat" || events[1].Name != "search" {
+		t.Fatalf("expected the chat and search events kept, got %+v", events)
+	}
+	if events[0].Payload["messages"] != "[REDACTED:system-prompts]" {
+		t.Errorf("expected the messages redacted, got %v", events[0].Payload)
+	}
+}
@@ -0,0 +1,128 @@
+package trusera
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Bounds for clock skew samples taken from response Date headers
+const (
+	// dateResolution is the precision of the HTTP Date header. Samples
+	// assume the server's clock was halfway through the reported second.
+	dateResolution = time.Second
+	// maxSkewSampleRTT discards samples from slow round trips, whose
+	// midpoint says little about when the server produced its Date
+	maxSkewSampleRTT = 5 * time.Second
+	// skewSmoothing weights each new sample in the running estimate
+	skewSmoothing = 0.125
+)
+
+// WithClockSkewEstimate estimates how far the local clock is from the API's,
+// NTP-style, from the Date header of every API response. Once an estimate
+// exists, each tracked event carries it as ClockSkewMs so the server can
+// correct the event's timestamp, e.g. when ordering events replayed after an
+// outage.
+func WithClockSkewEstimate() Option {
+	return func(c *Client) {
+		c.clock = &clockSkew{}
+	}
+}
+
+// ClockSkew returns the estimated offset of the API's clock from the local
+// clock (positive when the local clock is behind), and false until an
+// estimate is available or if WithClockSkewEstimate is not set
+func (c *Client) ClockSkew() (time.Duration, bool) {
+	if c.clock == nil {
+		return 0, false
+	}
+	return c.clock.estimate()
+}
+
+// clockSkew keeps a smoothed estimate of the server clock offset
+type clockSkew struct {
+	mu     sync.Mutex
+	offset time.Duration
+	ok     bool
+}
+
+// observe folds in a sample from a request sent at sent and answered at
+// received with the given Date header
+func (s *clockSkew) observe(sent, received time.Time, date string) {
+	rtt := received.Sub(sent)
+	if date == "" || rtt < 0 || rtt > maxSkewSampleRTT {
+		return
+	}
+	serverTime, err := http.ParseTime(date)
+	if err != nil {
+		return
+	}
+	// The server stamped its Date somewhere between sending and receiving;
+	// like NTP, assume the middle of the round trip
+	local := sent.Add(rtt / 2)
+	sample := serverTime.Add(dateResolution / 2).Sub(local)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ok {
+		s.offset, s.ok = sample, true
+		return
+	}
+	s.offset += time.Duration(float64(sample-s.offset) * skewSmoothing)
+}
+
+// estimate returns the current offset estimate
+func (s *clockSkew) estimate() (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.offset, s.ok
+}
+
+// clockTransport samples the Date header of every response
+type clockTransport struct {
+	base  http.RoundTripper
+	clock *clockSkew
+}
+
+func (t *clockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	sent := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		t.clock.observe(sent, time.Now(), resp.Header.Get("Date"))
+	}
+	return resp, err
+}
+
+// installClockTransport wraps the client's HTTP transport with clock skew sampling
+func (c *Client) installClockTransport() {
+	if c.clock == nil {
+		return
+	}
+	base := c.httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	hc := *c.httpClient
+	hc.Transport = &clockTransport{base: base, clock: c.clock}
+	c.httpClient = &hc
+}
+
+// stampEvent assigns the event's sequence number, clock skew and scope, and
+// chains audit events. The caller holds c.mu, so sequence numbers follow
+// queue order.
+func (c *Client) stampEvent(e *Event) {
+	c.sequence++
+	e.Sequence = c.sequence
+	if e.Type == EventAudit {
+		c.chainAudit(e)
+	}
+	if skew, ok := c.ClockSkew(); ok {
+		e.ClockSkewMs = skew.Milliseconds()
+	}
+	if e.ProjectID == "" {
+		e.ProjectID = c.projectID
+	}
+	if e.TeamID == "" {
+		e.TeamID = c.teamID
+	}
+}
@@ -0,0 +1,90 @@
+package trusera
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClockSkewObserve(t *testing.T) {
+	var s clockSkew
+	sent := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	received := sent.Add(200 * time.Millisecond)
+
+	// Server clock 10s ahead: it reports 12:00:10 during the round trip
+	s.observe(sent, received, "Tue, 01 Jan 2030 12:00:10 GMT")
+	offset, ok := s.estimate()
+	if !ok {
+		t.Fatal("expected an estimate after one sample")
+	}
+	if want := 10*time.Second + 400*time.Millisecond; offset != want {
+		t.Errorf("expected offset %v, got %v", want, offset)
+	}
+
+	s.observe(sent, sent.Add(10*time.Second), "Tue, 01 Jan 2030 13:00:00 GMT")
+	s.observe(sent, received, "not a date")
+	if again, _ := s.estimate(); again != offset {
+		t.Errorf("expected slow and malformed samples ignored, got %v", again)
+	}
+
+	s.observe(sent, received, "Tue, 01 Jan 2030 12:00:18 GMT")
+	if smoothed, _ := s.estimate(); smoothed != offset+time.Second {
+		t.Errorf("expected the new sample weighted by 1/8, got %v", smoothed)
+	}
+}
+
+func TestClockSkewFromResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	client := NewClient("k", WithBaseURL(server.URL), WithClockSkewEstimate(), WithFlushInterval(time.Hour))
+	defer client.Close()
+
+	if _, ok := client.ClockSkew(); ok {
+		t.Fatal("expected no estimate before any response")
+	}
+	client.Track(NewEvent(EventToolCall, "a"))
+	if err := client.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	skew, ok := client.ClockSkew()
+	if !ok || skew > -59*time.Minute || skew < -61*time.Minute {
+		t.Fatalf("expected the server to be about an hour behind, got %v (%v)", skew, ok)
+	}
+
+	client.Track(NewEvent(EventToolCall, "b"))
+	events := queuedEvents(client)
+	if len(events) != 1 || events[0].ClockSkewMs != skew.Milliseconds() {
+		t.Errorf("expected the estimate stamped on new events, got %+v", events)
+	}
+}
+
+func TestTrackAssignsSequence(t *testing.T) {
+	client := NewClient("k", WithTransport(&recordingTransport{}), WithFlushInterval(time.Hour))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "a"))
+	client.Track(Event{Type: EventToolCall, Name: "b"})
+	client.Track(NewEvent(EventToolCall, "c"))
+
+	events := queuedEvents(client)
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	for i, e := range events {
+		if e.Sequence != uint64(i+1) {
+			t.Errorf("event %d: expected sequence %d, got %d", i, i+1, e.Sequence)
+		}
+		if _, err := time.Parse(time.RFC3339Nano, e.Timestamp); err != nil {
+			t.Errorf("event %d: expected a timestamp, got %q", i, e.Timestamp)
+		}
+		if e.ClockSkewMs != 0 {
+			t.Errorf("event %d: expected no skew without WithClockSkewEstimate, got %d", i, e.ClockSkewMs)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+// Package cloud detects the cloud instance an agent runs on by querying the
+// AWS, GCP and Azure instance metadata services, so operators can map fleet
+// agents to their infrastructure.
+//
+//	meta, _ := cloud.Detect(ctx, cloud.Options{})
+//	client := trusera.NewClient(apiKey, trusera.WithFleetEnricher(meta))
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Cloud providers reported in Metadata.Provider
+const (
+	ProviderAWS   = "aws"
+	ProviderGCP   = "gcp"
+	ProviderAzure = "azure"
+)
+
+// Default instance metadata service endpoints
+const (
+	DefaultAWSEndpoint   = "http://169.254.169.254"
+	DefaultGCPEndpoint   = "http://metadata.google.internal"
+	DefaultAzureEndpoint = "http://169.254.169.254"
+)
+
+// defaultTimeout bounds detection so startup off-cloud is not held up
+const defaultTimeout = 2 * time.Second
+
+// Options configures Detect
+type Options struct {
+	// Providers limits detection to the given providers. Defaults to all.
+	Providers []string
+	// Timeout bounds the whole detection. Defaults to 2s.
+	Timeout time.Duration
+	// Endpoint overrides, mainly for tests
+	AWSEndpoint   string
+	GCPEndpoint   string
+	AzureEndpoint string
+	// HTTPClient is used for metadata requests. It must not go through a
+	// proxy, which could not reach the link-local metadata address.
+	HTTPClient *http.Client
+}
+
+// Metadata describes a cloud instance. It implements trusera.Enricher.
+type Metadata struct {
+	Provider     string `json:"provider"`
+	Region       string `json:"region,omitempty"`
+	Zone         string `json:"zone,omitempty"`
+	InstanceID   string `json:"instance_id,omitempty"`
+	InstanceType string `json:"instance_type,omitempty"`
+	AccountID    string `json:"account_id,omitempty"` // AWS account, GCP project or Azure subscription
+}
+
+// Detect queries the metadata services of all providers concurrently and
+// returns the instance's metadata, or nil when none answers, e.g. off-cloud.
+// The error joins the providers' failures and is only returned with nil
+// metadata, so it can usually be ignored.
+func Detect(ctx context.Context, opts Options) (*Metadata, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.HTTPClient == nil {
+		// No proxy: metadata services are only reachable directly
+		opts.HTTPClient = &http.Client{Transport: &http.Transport{}}
+	}
+	if len(opts.Providers) == 0 {
+		opts.Providers = []string{ProviderAWS, ProviderGCP, ProviderAzure}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	detectors := map[string]func(context.Context, Options) (*Metadata, error){
+		ProviderAWS:   detectAWS,
+		ProviderGCP:   detectGCP,
+		ProviderAzure: detectAzure,
+	}
+
+	type result struct {
+		meta *Metadata
+		err  error
+	}
+	results := make([]chan result, len(opts.Providers))
+	for i, p := range opts.Providers {
+		results[i] = make(chan result, 1)
+		detect, ok := detectors[p]
+		if !ok {
+			results[i] <- result{err: fmt.Errorf("unknown provider %q", p)}
+			continue
+		}
+		go func(ch chan<- result, p string) {
+			meta, err := detect(ctx, opts)
+			if err != nil {
+				err = fmt.Errorf("%s: %w", p, err)
+			}
+			ch <- result{meta, err}
+		}(results[i], p)
+	}
+
+	// Providers are checked in order, so a match on an earlier one wins
+	var errs []error
+	for _, ch := range results {
+		r := <-ch
+		if r.meta != nil {
+			return r.meta, nil
+		}
+		errs = append(errs, r.err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// Name returns "cloud", the key attributes are reported under
+func (m *Metadata) Name() string {
+	return "cloud"
+}
+
+// Attributes returns the metadata as key/value pairs, or nil for a nil Metadata
+func (m *Metadata) Attributes() map[string]string {
+	if m == nil {
+		return nil
+	}
+	attrs := map[string]string{}
+	set := func(k, v string) {
+		if v != "" {
+			attrs[k] = v
+		}
+	}
+	set("provider", m.Provider)
+	set("region", m.Region)
+	set("zone", m.Zone)
+	set("instance_id", m.InstanceID)
+	set("instance_type", m.InstanceType)
+	set("account_id", m.AccountID)
+	return attrs
+}
+
+// detectAWS reads the EC2 instance identity document, using an IMDSv2
+// session token when the service issues one
+func detectAWS(ctx context.Context, opts Options) (*Metadata, error) {
+	base := endpoint(opts.AWSEndpoint, DefaultAWSEndpoint)
+
+	header := http.Header{}
+	tokenReq, err := http.NewRequestWithContext(ctx, http.MethodPut, base+"/latest/api/token", nil)
+	if err != nil {
+		return nil, err
+	}
+	tokenReq.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+	if token, err := fetch(opts.HTTPClient, tokenReq); err == nil {
+		header.Set("X-aws-ec2-metadata-token", string(token))
+	} else if ctx.Err() != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		AccountID        string `json:"accountId"`
+		Region           string `json:"region"`
+		AvailabilityZone string `json:"availabilityZone"`
+		InstanceID       string `json:"instanceId"`
+		InstanceType     string `json:"instanceType"`
+	}
+	if err := getJSON(ctx, opts.HTTPClient, base+"/latest/dynamic/instance-identity/document", header, &doc); err != nil {
+		return nil, err
+	}
+	if doc.InstanceID == "" {
+		return nil, errors.New("identity document has no instance ID")
+	}
+	return &Metadata{
+		Provider:     ProviderAWS,
+		Region:       doc.Region,
+		Zone:         doc.AvailabilityZone,
+		InstanceID:   doc.InstanceID,
+		InstanceType: doc.InstanceType,
+		AccountID:    doc.AccountID,
+	}, nil
+}
+
+// detectGCP reads the Compute Engine instance and project metadata
+func detectGCP(ctx context.Context, opts Options) (*Metadata, error) {
+	base := endpoint(opts.GCPEndpoint, DefaultGCPEndpoint) + "/computeMetadata/v1"
+	header := http.Header{"Metadata-Flavor": {"Google"}}
+
+	var instance struct {
+		ID          json.Number `json:"id"`
+		MachineType string      `json:"machineType"` // projects/<num>/machineTypes/<type>
+		Zone        string      `json:"zone"`        // projects/<num>/zones/<zone>
+	}
+	if err := getJSON(ctx, opts.HTTPClient, base+"/instance/?recursive=true", header, &instance); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/project/project-id", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header
+	project, err := fetch(opts.HTTPClient, req)
+	if err != nil {
+		return nil, err
+	}
+
+	zone := lastSegment(instance.Zone)
+	m := &Metadata{
+		Provider:     ProviderGCP,
+		Zone:         zone,
+		InstanceID:   instance.ID.String(),
+		InstanceType: lastSegment(instance.MachineType),
+		AccountID:    strings.TrimSpace(string(project)),
+	}
+	// Zones are <region>-<letter>, e.g. us-central1-a
+	if i := strings.LastIndex(zone, "-"); i > 0 {
+		m.Region = zone[:i]
+	}
+	return m, nil
+}
+
+// detectAzure reads the Azure Instance Metadata Service compute document
+func detectAzure(ctx context.Context, opts Options) (*Metadata, error) {
+	base := endpoint(opts.AzureEndpoint, DefaultAzureEndpoint)
+	header := http.Header{"Metadata": {"true"}}
+
+	var compute struct {
+		Location       string `json:"location"`
+		Zone           string `json:"zone"`
+		VMID           string `json:"vmId"`
+		VMSize         string `json:"vmSize"`
+		SubscriptionID string `json:"subscriptionId"`
+	}
+	if err := getJSON(ctx, opts.HTTPClient, base+"/metadata/instance/compute?api-version=2021-02-01", header, &compute); err != nil {
+		return nil, err
+	}
+	if compute.VMID == "" {
+		return nil, errors.New("compute metadata has no VM ID")
+	}
+	return &Metadata{
+		Provider:     ProviderAzure,
+		Region:       compute.Location,
+		Zone:         compute.Zone,
+		InstanceID:   compute.VMID,
+		InstanceType: compute.VMSize,
+		AccountID:    compute.SubscriptionID,
+	}, nil
+}
+
+// getJSON fetches url with header and decodes the JSON response into v
+func getJSON(ctx context.Context, hc *http.Client, url string, header http.Header, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header = header
+	body, err := fetch(hc, req)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// fetch performs req and returns the body of a 200 response
+func fetch(hc *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metadata service returned status %d", resp.StatusCode)
+	}
+	return body, nil
+}
+
+func endpoint(override, def string) string {
+	if override != "" {
+		return strings.TrimSuffix(override, "/")
+	}
+	return def
+}
+
+// lastSegment returns the part of a resource path after the last slash
+func lastSegment(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
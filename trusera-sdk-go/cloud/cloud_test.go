@@ -0,0 +1,141 @@
+package cloud
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+var _ trusera.Enricher = (*Metadata)(nil)
+
+// notFound is a metadata endpoint for a provider the instance is not on
+func notFound(t *testing.T) string {
+	t.Helper()
+	server := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestDetectAWS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/latest/api/token":
+			if r.Method != http.MethodPut || r.Header.Get("X-aws-ec2-metadata-token-ttl-seconds") == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte("imds-token"))
+		case "/latest/dynamic/instance-identity/document":
+			if r.Header.Get("X-aws-ec2-metadata-token") != "imds-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"accountId":"123456789012","region":"eu-west-1","availabilityZone":"eu-west-1b",
+				"instanceId":"i-0abc","instanceType":"g5.xlarge"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	meta, err := Detect(context.Background(), Options{
+		AWSEndpoint:   server.URL,
+		GCPEndpoint:   notFound(t),
+		AzureEndpoint: notFound(t),
+	})
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	want := Metadata{Provider: ProviderAWS, Region: "eu-west-1", Zone: "eu-west-1b",
+		InstanceID: "i-0abc", InstanceType: "g5.xlarge", AccountID: "123456789012"}
+	if *meta != want {
+		t.Errorf("expected %+v, got %+v", want, *meta)
+	}
+}
+
+func TestDetectGCP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/computeMetadata/v1/instance/":
+			w.Write([]byte(`{"id":4520031799277581759,"machineType":"projects/42/machineTypes/a2-highgpu-1g",
+				"zone":"projects/42/zones/us-central1-a"}`))
+		case "/computeMetadata/v1/project/project-id":
+			w.Write([]byte("ml-prod"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	meta, err := Detect(context.Background(), Options{
+		AWSEndpoint:   notFound(t),
+		GCPEndpoint:   server.URL,
+		AzureEndpoint: notFound(t),
+	})
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	want := Metadata{Provider: ProviderGCP, Region: "us-central1", Zone: "us-central1-a",
+		InstanceID: "4520031799277581759", InstanceType: "a2-highgpu-1g", AccountID: "ml-prod"}
+	if *meta != want {
+		t.Errorf("expected %+v, got %+v", want, *meta)
+	}
+}
+
+func TestDetectAzure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metadata/instance/compute" || r.Header.Get("Metadata") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"location":"westeurope","zone":"2","vmId":"vm-1","vmSize":"Standard_NC6s_v3",
+			"subscriptionId":"sub-1"}`))
+	}))
+	defer server.Close()
+
+	meta, err := Detect(context.Background(), Options{Providers: []string{ProviderAzure}, AzureEndpoint: server.URL})
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	if meta.Provider != ProviderAzure || meta.Region != "westeurope" || meta.InstanceType != "Standard_NC6s_v3" || meta.AccountID != "sub-1" {
+		t.Errorf("unexpected metadata %+v", meta)
+	}
+	if attrs := meta.Attributes(); attrs["instance_id"] != "vm-1" || attrs["zone"] != "2" {
+		t.Errorf("unexpected attributes %v", attrs)
+	}
+}
+
+func TestDetectOffCloud(t *testing.T) {
+	hang := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer hang.Close()
+
+	start := time.Now()
+	meta, err := Detect(context.Background(), Options{
+		Timeout:       100 * time.Millisecond,
+		AWSEndpoint:   hang.URL,
+		GCPEndpoint:   notFound(t),
+		AzureEndpoint: notFound(t),
+	})
+	if meta != nil {
+		t.Errorf("expected nil metadata off-cloud, got %+v", meta)
+	}
+	if err == nil {
+		t.Error("expected the providers' errors")
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Error("expected detection to respect the timeout")
+	}
+	if attrs := meta.Attributes(); attrs != nil {
+		t.Errorf("expected nil attributes for nil metadata, got %v", attrs)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Trusera/ai-bom/trusera-sdk-go/config"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/k8s"
+)
+
+// Annotations and labels that mark AI workloads
+const (
+	agentKey     = "trusera.io/agent"
+	frameworkKey = "trusera.io/framework"
+)
+
+// knownImages maps image name fragments of inference servers to the
+// framework they are registered as
+var knownImages = []struct {
+	fragment  string
+	framework string
+}{
+	{"vllm", "vllm"},
+	{"ollama", "ollama"},
+	{"text-generation-inference", "tgi"},
+	{"tritonserver", "triton"},
+	{"localai", "localai"},
+	{"llama.cpp", "llama.cpp"},
+}
+
+// discover registers AI workloads that have not been registered yet
+func (d *discoverer) discover(ctx context.Context) error {
+	var agents []*config.Agent
+	if d.sidecar {
+		m, err := k8s.Detect(ctx, k8s.Options{QueryAPI: true})
+		if m == nil {
+			return err
+		}
+		// A sidecar was added to the pod on purpose, so no marker is needed
+		p := k8s.Pod{Name: m.PodName, Namespace: m.Namespace, NodeName: m.NodeName,
+			Deployment: m.Deployment, Labels: m.Labels,
+			Containers: []k8s.Container{{Name: m.ContainerName, Image: m.ContainerImage}}}
+		a, _ := workloadAgent(p)
+		if a == nil {
+			a = newAgent(p, "", "custom")
+		}
+		agents = append(agents, a)
+	} else {
+		pods, err := k8s.ListPods(ctx, d.k8s, d.node)
+		if err != nil {
+			return err
+		}
+		for _, p := range pods {
+			if a, ok := workloadAgent(p); ok {
+				agents = append(agents, a)
+			}
+		}
+	}
+
+	for _, a := range agents {
+		if d.seen[a.Name] {
+			continue
+		}
+		if err := config.Apply(ctx, d.client, a); err != nil {
+			d.logf("failed to register %s: %v", a.Name, err)
+			continue
+		}
+		d.seen[a.Name] = true
+		d.logf("registered %s (%s) as fleet agent %s", a.Name, a.Framework, a.ID)
+	}
+	return nil
+}
+
+// workloadAgent returns the fleet agent for a pod that is an AI workload.
+// Replicas of a Deployment map to the same agent.
+func workloadAgent(p k8s.Pod) (*config.Agent, bool) {
+	marker, ok := p.Annotations[agentKey]
+	if !ok {
+		marker, ok = p.Labels[agentKey]
+	}
+	if ok && marker == "false" {
+		return nil, false
+	}
+	framework := p.Annotations[frameworkKey]
+	if framework == "" {
+		framework = imageFramework(p.Containers)
+	}
+	if !ok && framework == "" {
+		return nil, false
+	}
+	if marker == "true" {
+		marker = ""
+	}
+	if framework == "" {
+		framework = "custom"
+	}
+	return newAgent(p, marker, framework), true
+}
+
+// newAgent builds the fleet agent for a pod, named <namespace>/<name>
+func newAgent(p k8s.Pod, name, framework string) *config.Agent {
+	if name == "" {
+		name = p.Deployment
+	}
+	if name == "" {
+		name = p.Name
+	}
+	labels := map[string]string{"k8s.namespace": p.Namespace, "discovery": "trusera-agent"}
+	if p.Deployment != "" {
+		labels["k8s.deployment"] = p.Deployment
+	}
+	if len(p.Containers) > 0 && p.Containers[0].Image != "" {
+		labels["k8s.image"] = p.Containers[0].Image
+	}
+	return &config.Agent{Name: p.Namespace + "/" + name, Framework: framework, Labels: labels}
+}
+
+// imageFramework returns the framework of the first container running a
+// known inference server image, or "" if there is none
+func imageFramework(containers []k8s.Container) string {
+	for _, c := range containers {
+		image := strings.ToLower(c.Image)
+		for _, k := range knownImages {
+			if strings.Contains(image, k.fragment) {
+				return k.framework
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+// maxBatch bounds the size of a decompressed batch accepted on the socket
+const maxBatch = 8 << 20
+
+// newIngestHandler serves the ingestion socket: POST /v1/events queues a
+// batch on client, and GET /health answers 200
+func newIngestHandler(client *trusera.Client) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/v1/events", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var body io.Reader = r.Body
+		switch r.Header.Get("Content-Encoding") {
+		case "":
+		case "gzip":
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				http.Error(w, "invalid gzip body", http.StatusBadRequest)
+				return
+			}
+			defer zr.Close()
+			body = zr
+		default:
+			http.Error(w, "unsupported content encoding", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		var batch trusera.Batch
+		if err := json.NewDecoder(io.LimitReader(body, maxBatch)).Decode(&batch); err != nil {
+			http.Error(w, "malformed batch", http.StatusBadRequest)
+			return
+		}
+		track := client.Track
+		if batch.AgentID != "" {
+			track = client.ForAgent(batch.AgentID).Track
+		}
+		for _, e := range batch.Events {
+			if e.ID == "" {
+				e.ID = trusera.NewEvent(e.Type, e.Name).ID
+			}
+			track(e)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	return mux
+}
@@ -0,0 +1,150 @@
+// Command trusera-agent runs next to AI workloads in Kubernetes, as a
+// DaemonSet or a sidecar. It discovers the AI workloads on its node,
+// registers them with the Trusera fleet, and accepts events over a Unix
+// socket for applications that cannot link the SDK.
+//
+// Usage:
+//
+//	trusera-agent [-node name] [-sidecar] [-interval 1m] [-socket /var/run/trusera/trusera.sock]
+//
+// As a DaemonSet, pods on the node are registered when they carry the
+// trusera.io/agent annotation or label, whose value names the agent ("true"
+// uses the Deployment name), or run a known inference server image such as
+// vLLM, Ollama, Text Generation Inference or Triton. trusera.io/agent: "false"
+// opts a pod out, and trusera.io/framework sets the framework. The service
+// account needs permission to list pods. With -sidecar only the agent's own
+// pod is registered.
+//
+// Go applications send events to the socket with trusera.WithRelay. Others
+// post event batches in the SDK's format, {"agent_id": "...", "events":
+// [...]}, to POST /v1/events on the socket, optionally gzipped. Events are
+// batched, retried and spilled by the SDK client inside the agent.
+//
+// The API key and URL are read from TRUSERA_API_KEY and TRUSERA_API_URL, or
+// from the -api-key and -api-url flags.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/k8s"
+)
+
+// DefaultSocket is where the ingestion socket is created by default
+const DefaultSocket = "/var/run/trusera/trusera.sock"
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stderr))
+}
+
+// run starts the agent and returns the process exit code once it is
+// interrupted or fails
+func run(args []string, stderr io.Writer) int {
+	fs := flag.NewFlagSet("trusera-agent", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	apiKey := fs.String("api-key", "", "API key (default $TRUSERA_API_KEY)")
+	apiURL := fs.String("api-url", "", "API base URL (default $TRUSERA_API_URL or https://api.trusera.io)")
+	socket := fs.String("socket", DefaultSocket, "Unix socket to accept events on; empty disables ingestion")
+	node := fs.String("node", os.Getenv("NODE_NAME"), "node to discover workloads on (default $NODE_NAME)")
+	sidecar := fs.Bool("sidecar", false, "register only the agent's own pod")
+	interval := fs.Duration("interval", time.Minute, "discovery interval")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if !*sidecar && *node == "" {
+		fmt.Fprintln(stderr, "trusera-agent: -node or NODE_NAME is required unless -sidecar is set")
+		return 2
+	}
+
+	opts := []trusera.Option{trusera.WithAgentName("trusera-agent"), trusera.WithLogLevel(trusera.LogWarn)}
+	if *apiURL != "" {
+		opts = append(opts, trusera.WithBaseURL(*apiURL))
+	}
+	client := trusera.NewClient(*apiKey, opts...)
+	defer client.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *socket != "" {
+		ln, err := listenUnix(*socket)
+		if err != nil {
+			fmt.Fprintf(stderr, "trusera-agent: %v\n", err)
+			return 1
+		}
+		srv := &http.Server{Handler: newIngestHandler(client), ReadHeaderTimeout: 10 * time.Second}
+		go srv.Serve(ln)
+		defer srv.Shutdown(context.Background())
+	}
+
+	d := &discoverer{
+		client:  client,
+		node:    *node,
+		sidecar: *sidecar,
+		seen:    map[string]bool{},
+		logf: func(format string, args ...any) {
+			fmt.Fprintf(stderr, "trusera-agent: "+format+"\n", args...)
+		},
+	}
+	d.run(ctx, *interval)
+	return 0
+}
+
+// discoverer registers the AI workloads it finds with the fleet
+type discoverer struct {
+	client  *trusera.Client
+	k8s     k8s.Options
+	node    string
+	sidecar bool
+	seen    map[string]bool // Fleet names already registered
+	logf    func(format string, args ...any)
+}
+
+// run discovers workloads every interval until ctx is done
+func (d *discoverer) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := d.discover(ctx); err != nil && ctx.Err() == nil {
+			d.logf("discovery failed: %v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// listenUnix listens on a Unix socket, replacing a stale socket file left by
+// a previous run. The socket is writable by the agent's group, so pods
+// sharing its volume can connect.
+func listenUnix(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o660); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
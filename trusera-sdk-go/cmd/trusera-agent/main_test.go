@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/k8s"
+)
+
+func TestWorkloadAgent(t *testing.T) {
+	tests := []struct {
+		name      string
+		pod       k8s.Pod
+		want      string
+		framework string
+	}{
+		{"annotation names agent", k8s.Pod{Namespace: "cx", Deployment: "bot",
+			Annotations: map[string]string{agentKey: "support-bot", frameworkKey: "langchaingo"}}, "cx/support-bot", "langchaingo"},
+		{"label uses deployment", k8s.Pod{Namespace: "cx", Deployment: "bot",
+			Labels: map[string]string{agentKey: "true"}}, "cx/bot", "custom"},
+		{"known image", k8s.Pod{Namespace: "inference", Name: "ollama-0",
+			Containers: []k8s.Container{{Image: "docker.io/ollama/ollama:0.3"}}}, "inference/ollama-0", "ollama"},
+		{"opted out", k8s.Pod{Namespace: "inference", Annotations: map[string]string{agentKey: "false"},
+			Containers: []k8s.Container{{Image: "vllm/vllm-openai"}}}, "", ""},
+		{"unrelated", k8s.Pod{Namespace: "web", Name: "nginx",
+			Containers: []k8s.Container{{Image: "nginx:1.27"}}}, "", ""},
+	}
+	for _, tt := range tests {
+		a, ok := workloadAgent(tt.pod)
+		if tt.want == "" {
+			if ok {
+				t.Errorf("%s: expected no agent, got %+v", tt.name, a)
+			}
+			continue
+		}
+		if !ok || a.Name != tt.want || a.Framework != tt.framework {
+			t.Errorf("%s: expected %s (%s), got %+v", tt.name, tt.want, tt.framework, a)
+		}
+	}
+}
+
+func TestDiscoverRegistersWorkloadsOnce(t *testing.T) {
+	var mu sync.Mutex
+	var applied []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/pods":
+			pod := func(name string, annotations map[string]string, image string) map[string]any {
+				return map[string]any{
+					"metadata": map[string]any{"name": name, "namespace": "ml", "annotations": annotations},
+					"spec":     map[string]any{"containers": []map[string]string{{"name": "main", "image": image}}},
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]any{"items": []any{
+				pod("vllm-0", nil, "vllm/vllm-openai:v0.6.0"),
+				pod("vllm-1", map[string]string{agentKey: "vllm-0"}, "vllm/vllm-openai:v0.6.0"),
+				pod("redis-0", nil, "redis:7"),
+			}})
+		case strings.HasPrefix(r.URL.Path, "/v1/config/agent/"):
+			mu.Lock()
+			applied = append(applied, strings.TrimPrefix(r.URL.EscapedPath(), "/v1/config/agent/"))
+			mu.Unlock()
+			io.Copy(w, r.Body)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	saDir := t.TempDir()
+	os.WriteFile(filepath.Join(saDir, "token"), []byte("sa-token"), 0o600)
+
+	client := trusera.NewClient("test-key", trusera.WithBaseURL(server.URL))
+	defer client.Close()
+	d := &discoverer{
+		client: client,
+		k8s:    k8s.Options{ServiceAccountDir: saDir, APIServer: server.URL, HTTPClient: server.Client()},
+		node:   "node-a",
+		seen:   map[string]bool{},
+		logf:   t.Logf,
+	}
+	for i := 0; i < 2; i++ {
+		if err := d.discover(context.Background()); err != nil {
+			t.Fatalf("discover failed: %v", err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(applied) != 1 || applied[0] != "ml%2Fvllm-0" {
+		t.Errorf("expected the vLLM workload registered once, got %v", applied)
+	}
+}
+
+func TestIngestSocket(t *testing.T) {
+	var mu sync.Mutex
+	var batches []trusera.Batch
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/events" {
+			var b trusera.Batch
+			json.NewDecoder(r.Body).Decode(&b)
+			mu.Lock()
+			batches = append(batches, b)
+			mu.Unlock()
+		}
+	}))
+	defer api.Close()
+
+	client := trusera.NewClient("test-key", trusera.WithBaseURL(api.URL))
+	defer client.Close()
+
+	path := filepath.Join(t.TempDir(), "trusera.sock")
+	ln, err := listenUnix(path)
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	srv := &http.Server{Handler: newIngestHandler(client)}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	hc := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(`{"agent_id": "agent-42", "events": [{"type": "tool_call", "name": "search", "payload": {}}]}`))
+	zw.Close()
+	req, _ := http.NewRequest(http.MethodPost, "http://trusera/v1/events", &gz)
+	req.Header.Set("Content-Encoding", "gzip")
+	resp, err := hc.Do(req)
+	if err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", resp.StatusCode)
+	}
+
+	resp, err = hc.Post("http://trusera/v1/events", "application/json", strings.NewReader(`{"events": [`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for malformed batch, got %d", resp.StatusCode)
+	}
+
+	if err := client.Flush(); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batches) != 1 || len(batches[0].Events) != 1 {
+		t.Fatalf("expected one forwarded event, got %+v", batches)
+	}
+	if e := batches[0].Events[0]; e.Metadata["agent_id"] != "agent-42" || e.ID == "" {
+		t.Errorf("expected the event forwarded for agent-42 with an ID, got %+v", batches)
+	}
+}
+
+func TestRunRequiresNode(t *testing.T) {
+	t.Setenv("NODE_NAME", "")
+	var stderr bytes.Buffer
+	if code := run([]string{"-socket", ""}, &stderr); code != 2 {
+		t.Errorf("expected exit 2, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "NODE_NAME") {
+		t.Errorf("expected node error, got %s", stderr.String())
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"debug/buildinfo"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Trusera/ai-bom/trusera-sdk-go/bom"
+)
+
+func runBOM(args []string, stdout, stderr io.Writer) error {
+	fs, api := newFlagSet("bom", stderr)
+	gomod := fs.String("gomod", "", "go.mod to read dependencies from (default ./go.mod)")
+	binary := fs.String("binary", "", "built Go binary to read dependencies from")
+	name := fs.String("name", "", "agent name (default: module name)")
+	version := fs.String("version", "", "agent version")
+	format := fs.String("format", string(bom.FormatCycloneDX), "output format: cyclonedx or spdx")
+	out := fs.String("o", "-", "output file, - for stdout")
+	scan := fs.Bool("scan", false, "look up known vulnerabilities in OSV")
+	upload := fs.Bool("upload", false, "upload the BOM to Trusera")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *gomod != "" && *binary != "" {
+		return errors.New("-gomod and -binary are mutually exclusive")
+	}
+
+	var (
+		b      *bom.Builder
+		module string
+	)
+	if *binary != "" {
+		info, err := buildinfo.ReadFile(*binary)
+		if err != nil {
+			return fmt.Errorf("failed to read build info: %w", err)
+		}
+		module = info.Main.Path
+		b = bom.NewBuilder(agentName(*name, module)).CollectBuildInfoFrom(info)
+	} else {
+		if *gomod == "" {
+			*gomod = "go.mod"
+		}
+		data, err := os.ReadFile(*gomod)
+		if err != nil {
+			return err
+		}
+		var deps []bom.Dependency
+		module, deps = parseGoMod(data)
+		b = bom.NewBuilder(agentName(*name, module))
+		for _, d := range deps {
+			b.AddDependency(d)
+		}
+	}
+	if *version != "" {
+		b.WithVersion(*version)
+	}
+	doc := b.Build()
+
+	if *scan {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := doc.ScanVulnerabilities(ctx); err != nil {
+			return err
+		}
+		fmt.Fprintf(stderr, "found %d known vulnerabilities\n", len(doc.Vulnerabilities))
+	}
+
+	data, err := doc.Export(bom.Format(*format))
+	if err != nil {
+		return err
+	}
+	if *out == "-" {
+		_, err = stdout.Write(append(data, '\n'))
+	} else {
+		err = os.WriteFile(*out, append(data, '\n'), 0o644)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *upload {
+		client := api.client()
+		defer client.Close()
+		if err := client.UploadBOM(doc); err != nil {
+			return err
+		}
+		fmt.Fprintln(stderr, "uploaded BOM", doc.SerialNumber)
+	}
+	return nil
+}
+
+// agentName defaults the agent name to the last element of the module path
+func agentName(name, module string) string {
+	if name != "" {
+		return name
+	}
+	if module == "" {
+		return "agent"
+	}
+	return path.Base(module)
+}
+
+// parseGoMod reads the module path and required modules from a go.mod file.
+// Replacements with a module version are applied; local path replacements
+// keep the required version.
+func parseGoMod(data []byte) (module string, deps []bom.Dependency) {
+	replaced := map[string]bom.Dependency{}
+	var required []bom.Dependency
+
+	block := ""
+	lines := bufio.NewScanner(bytes.NewReader(data))
+	for lines.Scan() {
+		line := lines.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if block != "" {
+			if fields[0] == ")" {
+				block = ""
+				continue
+			}
+			fields = append([]string{block}, fields...)
+		} else if len(fields) == 2 && fields[1] == "(" {
+			block = fields[0]
+			continue
+		}
+
+		switch fields[0] {
+		case "module":
+			if len(fields) >= 2 {
+				module = strings.Trim(fields[1], `"`)
+			}
+		case "require":
+			if len(fields) >= 3 {
+				required = append(required, bom.Dependency{Path: strings.Trim(fields[1], `"`), Version: fields[2]})
+			}
+		case "replace":
+			// old [v] => new [v]
+			arrow := -1
+			for i, f := range fields {
+				if f == "=>" {
+					arrow = i
+				}
+			}
+			if arrow > 0 && len(fields) == arrow+3 {
+				replaced[fields[1]] = bom.Dependency{Path: fields[arrow+1], Version: fields[arrow+2]}
+			}
+		}
+	}
+
+	for _, d := range required {
+		if r, ok := replaced[d.Path]; ok {
+			d = r
+		}
+		deps = append(deps, d)
+	}
+	return module, deps
+}
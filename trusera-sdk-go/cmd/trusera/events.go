@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+func runRegister(args []string, stdout, stderr io.Writer) error {
+	fs, api := newFlagSet("register", stderr)
+	name := fs.String("name", "", "agent name (required)")
+	framework := fs.String("framework", "custom", "agent framework")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return errors.New("-name is required")
+	}
+
+	client := api.client()
+	defer client.Close()
+
+	id, err := client.RegisterAgent(*name, *framework)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, id)
+	return nil
+}
+
+func runSend(args []string, stdout, stderr io.Writer) error {
+	fs, api := newFlagSet("send", stderr)
+	eventType := fs.String("type", string(trusera.EventToolCall), "event type")
+	name := fs.String("name", "test-event", "event name")
+	agentID := fs.String("agent", "", "agent ID to attribute events to")
+	count := fs.Int("count", 1, "number of events to send")
+	var payload multiFlag
+	fs.Var(&payload, "payload", "payload `key=value` (repeatable); values are parsed as JSON when possible")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	event := trusera.NewEvent(trusera.EventType(*eventType), *name)
+	for _, kv := range payload {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			return fmt.Errorf("invalid -payload %q, want key=value", kv)
+		}
+		var v any
+		if json.Unmarshal([]byte(value), &v) != nil {
+			v = value
+		}
+		event = event.WithPayload(key, v)
+	}
+
+	var opts []trusera.Option
+	if *agentID != "" {
+		opts = append(opts, trusera.WithAgentID(*agentID))
+	}
+	client := api.client(opts...)
+	for i := 0; i < *count; i++ {
+		e := event
+		e.ID = trusera.NewEvent(e.Type, e.Name).ID
+		client.Track(e)
+	}
+	if err := client.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "sent %d event(s)\n", *count)
+	return nil
+}
+
+func runReplay(args []string, stdout, stderr io.Writer) error {
+	fs, api := newFlagSet("replay", stderr)
+	agentID := fs.String("agent", "", "agent ID for events recorded without one")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var opts []trusera.Option
+	if *agentID != "" {
+		opts = append(opts, trusera.WithAgentID(*agentID))
+	}
+	client := api.client(opts...)
+	defer client.Close()
+
+	total := 0
+	for _, name := range files {
+		n, err := replayFile(ctx, client, name)
+		total += n
+		if err != nil {
+			return fmt.Errorf("%s: %w (replayed %d event(s))", name, err, total)
+		}
+	}
+	fmt.Fprintf(stdout, "replayed %d event(s)\n", total)
+	return nil
+}
+
+// replayFile replays one dump, reading stdin for "-"
+func replayFile(ctx context.Context, client *trusera.Client, name string) (int, error) {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+		r = f
+	}
+	return client.Replay(ctx, r)
+}
+
+func runTail(args []string, stdout, stderr io.Writer) error {
+	fs, api := newFlagSet("tail", stderr)
+	agentID := fs.String("agent", "", "only show events from this agent")
+	eventType := fs.String("type", "", "only show events of this type")
+	since := fs.Duration("since", 5*time.Minute, "show events from this long ago")
+	interval := fs.Duration("interval", 2*time.Second, "poll interval")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	client := api.client()
+	defer client.Close()
+
+	t := &tailer{
+		client:    client,
+		agentID:   *agentID,
+		eventType: *eventType,
+		since:     time.Now().Add(-*since).UTC(),
+		seen:      map[string]bool{},
+		out:       json.NewEncoder(stdout),
+	}
+	return t.run(ctx, *interval)
+}
+
+// tailer polls the events API and prints events it has not printed before
+type tailer struct {
+	client    *trusera.Client
+	agentID   string
+	eventType string
+	since     time.Time
+	seen      map[string]bool
+	out       *json.Encoder
+}
+
+func (t *tailer) run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := t.poll(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// poll fetches events newer than the last one printed
+func (t *tailer) poll(ctx context.Context) error {
+	var events []trusera.Event
+	err := t.client.EachEvent(ctx, trusera.EventFilter{
+		AgentID: t.agentID,
+		Type:    trusera.EventType(t.eventType),
+		Since:   t.since,
+	}, func(e trusera.Event) error {
+		events = append(events, e)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range events {
+		ts, err := time.Parse(time.RFC3339, e.Timestamp)
+		if t.seen[e.ID] || (err == nil && ts.Before(t.since)) {
+			continue
+		}
+		if err := t.out.Encode(e); err != nil {
+			return err
+		}
+		if err == nil && ts.After(t.since) {
+			// Only events from the newest second can be returned again
+			t.since = ts
+			t.seen = map[string]bool{}
+		}
+		t.seen[e.ID] = true
+	}
+	return nil
+}
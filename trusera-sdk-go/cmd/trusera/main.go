@@ -0,0 +1,109 @@
+// Command trusera generates and uploads AI-BOMs, registers agents, sends test
+// events, tails live events from the Trusera API and replays event dumps, for
+// CI pipelines and debugging without writing Go code.
+//
+// Usage:
+//
+//	trusera bom      [-gomod go.mod | -binary path] [-format cyclonedx|spdx] [-o file] [-scan] [-upload]
+//	trusera register -name agent [-framework custom]
+//	trusera send     [-type tool_call] [-name test-event] [-payload key=value]... [-count 1]
+//	trusera tail     [-agent id] [-type tool_call] [-since 5m] [-interval 2s]
+//	trusera replay   [-agent id] [file ...]
+//
+// The API key and URL are read from TRUSERA_API_KEY and TRUSERA_API_URL, or
+// from the -api-key and -api-url flags of each command. replay decrypts
+// encrypted spill files with the base64 key in TRUSERA_SPILL_KEY.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+// command is a trusera subcommand
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) error
+}
+
+var commands = []command{
+	{"bom", "Generate an AI-BOM from go.mod or a built binary, optionally uploading it", runBOM},
+	{"register", "Register an agent and print its ID", runRegister},
+	{"send", "Send test events", runSend},
+	{"tail", "Print live events from the API until interrupted", runTail},
+	{"replay", "Re-ingest events from local mode or spill files (stdin if none given)", runReplay},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run dispatches to a subcommand and returns the process exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		usage(stderr)
+		return 2
+	}
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			if err := cmd.run(args[1:], stdout, stderr); err != nil {
+				if err == flag.ErrHelp {
+					return 2
+				}
+				fmt.Fprintf(stderr, "trusera %s: %v\n", cmd.name, err)
+				return 1
+			}
+			return 0
+		}
+	}
+	fmt.Fprintf(stderr, "trusera: unknown command %q\n\n", args[0])
+	usage(stderr)
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: trusera <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-9s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'trusera <command> -h' for command flags.")
+}
+
+// apiFlags are the connection flags shared by every command
+type apiFlags struct {
+	apiKey string
+	apiURL string
+}
+
+func newFlagSet(name string, stderr io.Writer) (*flag.FlagSet, *apiFlags) {
+	fs := flag.NewFlagSet("trusera "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	api := &apiFlags{}
+	fs.StringVar(&api.apiKey, "api-key", "", "API key (default $TRUSERA_API_KEY)")
+	fs.StringVar(&api.apiURL, "api-url", "", "API base URL (default $TRUSERA_API_URL or https://api.trusera.io)")
+	return fs, api
+}
+
+// client creates an SDK client from the connection flags
+func (a *apiFlags) client(opts ...trusera.Option) *trusera.Client {
+	opts = append([]trusera.Option{trusera.WithLogLevel(trusera.LogWarn)}, opts...)
+	if a.apiURL != "" {
+		opts = append(opts, trusera.WithBaseURL(a.apiURL))
+	}
+	return trusera.NewClient(a.apiKey, opts...)
+}
+
+// multiFlag collects a repeatable string flag
+type multiFlag []string
+
+func (m *multiFlag) String() string     { return strings.Join(*m, ",") }
+func (m *multiFlag) Set(v string) error { *m = append(*m, v); return nil }
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+const testGoMod = `module github.com/acme/support-agent
+
+go 1.22
+
+require github.com/Trusera/ai-bom/trusera-sdk-go v0.3.0
+
+require (
+	golang.org/x/net v0.20.0 // indirect
+	golang.org/x/text v0.14.0
+)
+
+replace golang.org/x/text => golang.org/x/text v0.15.0
+replace github.com/Trusera/ai-bom/trusera-sdk-go => ../sdk
+`
+
+func TestParseGoMod(t *testing.T) {
+	module, deps := parseGoMod([]byte(testGoMod))
+
+	if module != "github.com/acme/support-agent" {
+		t.Errorf("expected module path, got %q", module)
+	}
+	if len(deps) != 3 {
+		t.Fatalf("expected 3 dependencies, got %+v", deps)
+	}
+	if deps[0].Path != "github.com/Trusera/ai-bom/trusera-sdk-go" || deps[0].Version != "v0.3.0" {
+		t.Errorf("expected local replacement to keep required version, got %+v", deps[0])
+	}
+	if deps[2].Version != "v0.15.0" {
+		t.Errorf("expected versioned replacement applied, got %+v", deps[2])
+	}
+}
+
+func TestBOMFromGoMod(t *testing.T) {
+	dir := t.TempDir()
+	gomod := filepath.Join(dir, "go.mod")
+	if err := os.WriteFile(gomod, []byte(testGoMod), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "bom.json")
+
+	var stderr bytes.Buffer
+	if code := run([]string{"bom", "-gomod", gomod, "-format", "spdx", "-o", out}, &bytes.Buffer{}, &stderr); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"name": "support-agent"`) || !strings.Contains(string(data), "pkg:golang/golang.org/x/net@v0.20.0") {
+		t.Errorf("expected SPDX document with agent and dependencies, got %s", data)
+	}
+}
+
+func TestBOMFromBinary(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"bom", "-binary", exe, "-name", "cli-test"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `"bomFormat": "CycloneDX"`) {
+		t.Errorf("expected CycloneDX output, got %s", stdout.String())
+	}
+}
+
+func TestRegisterAndSend(t *testing.T) {
+	var mu sync.Mutex
+	var events []trusera.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/agents":
+			_, _ = w.Write([]byte(`{"agent_id": "agent-42"}`))
+		case "/v1/events":
+			var body struct {
+				Events []trusera.Event `json:"events"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			events = append(events, body.Events...)
+			mu.Unlock()
+		}
+	}))
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"register", "-api-url", server.URL, "-api-key", "k", "-name", "ci-agent"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("register failed with %d: %s", code, stderr.String())
+	}
+	if got := strings.TrimSpace(stdout.String()); got != "agent-42" {
+		t.Errorf("expected agent ID printed, got %q", got)
+	}
+
+	stdout.Reset()
+	args := []string{"send", "-api-url", server.URL, "-api-key", "k", "-name", "smoke", "-payload", "tokens=12", "-payload", "tool=search", "-count", "2"}
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("send failed with %d: %s", code, stderr.String())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 2 || events[0].ID == events[1].ID {
+		t.Fatalf("expected 2 distinct events, got %+v", events)
+	}
+	if events[0].Payload["tokens"] != float64(12) || events[0].Payload["tool"] != "search" {
+		t.Errorf("expected parsed payload, got %v", events[0].Payload)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	var mu sync.Mutex
+	var batches []trusera.Batch
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch trusera.Batch
+		_ = json.NewDecoder(r.Body).Decode(&batch)
+		mu.Lock()
+		batches = append(batches, batch)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	dump := filepath.Join(t.TempDir(), "events.jsonl")
+	data := `{"kind":"event","timestamp":"2030-01-01T00:00:05Z","data":{"id":"e1","type":"tool_call","name":"search","timestamp":"2030-01-01T00:00:00Z"}}
+{"kind":"heartbeat","timestamp":"2030-01-01T00:00:06Z","data":{}}
+`
+	if err := os.WriteFile(dump, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"replay", "-api-url", server.URL, "-api-key", "k", "-agent", "agent-42", dump}
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("replay failed with %d: %s", code, stderr.String())
+	}
+	if got := strings.TrimSpace(stdout.String()); got != "replayed 1 event(s)" {
+		t.Errorf("expected replay count printed, got %q", got)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batches) != 1 || batches[0].AgentID != "agent-42" || len(batches[0].Events) != 1 {
+		t.Fatalf("expected one batch for agent-42, got %+v", batches)
+	}
+	if ts := batches[0].Events[0].Timestamp; ts != "2030-01-01T00:00:00Z" {
+		t.Errorf("expected the original timestamp, got %q", ts)
+	}
+}
+
+func TestReplayEncryptedSpillFile(t *testing.T) {
+	var mu sync.Mutex
+	var events []trusera.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch trusera.Batch
+		_ = json.NewDecoder(r.Body).Decode(&batch)
+		mu.Lock()
+		events = append(events, batch.Events...)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	// Spill a batch the way a client with an encryption key does
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{3}, 32)
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	spiller := trusera.NewClient("k", trusera.WithBaseURL(down.URL),
+		trusera.WithCircuitBreaker(1, time.Hour), trusera.WithSpillDir(dir), trusera.WithSpillEncryption(key))
+	spiller.Track(trusera.NewEvent(trusera.EventToolCall, "search"))
+	spiller.Close()
+	down.Close()
+	files, _ := filepath.Glob(filepath.Join(dir, "batch-*.json"))
+	if len(files) != 1 {
+		t.Fatalf("expected 1 spill file, got %d", len(files))
+	}
+
+	t.Setenv("TRUSERA_SPILL_KEY", base64.StdEncoding.EncodeToString(key))
+	var stdout, stderr bytes.Buffer
+	args := []string{"replay", "-api-url", server.URL, "-api-key", "k", files[0]}
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("replay failed with %d: %s", code, stderr.String())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 1 || events[0].Name != "search" {
+		t.Errorf("expected the spilled event replayed, got %+v", events)
+	}
+}
+
+func TestTailPrintsNewEventsOnce(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("agent_id") != "agent-42" {
+			t.Errorf("expected agent filter, got %s", r.URL.RawQuery)
+		}
+		_, _ = w.Write([]byte(`{"events": [
+			{"id": "e1", "type": "tool_call", "name": "search", "timestamp": "2030-01-01T00:00:00Z"},
+			{"id": "e2", "type": "tool_call", "name": "fetch", "timestamp": "2030-01-01T00:00:01Z"}
+		]}`))
+	}))
+	defer server.Close()
+
+	var stdout bytes.Buffer
+	client := trusera.NewClient("key", trusera.WithBaseURL(server.URL))
+	defer client.Close()
+
+	tl := &tailer{
+		client:  client,
+		agentID: "agent-42",
+		since:   time.Now().Add(-time.Minute),
+		seen:    map[string]bool{},
+		out:     json.NewEncoder(&stdout),
+	}
+	for i := 0; i < 2; i++ {
+		if err := tl.poll(context.Background()); err != nil {
+			t.Fatalf("poll failed: %v", err)
+		}
+	}
+
+	if lines := strings.Count(stdout.String(), "\n"); lines != 2 {
+		t.Errorf("expected each event printed once, got %d lines: %s", lines, stdout.String())
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	var stderr bytes.Buffer
+	if code := run([]string{"deploy"}, &bytes.Buffer{}, &stderr); code != 2 {
+		t.Errorf("expected exit 2, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "unknown command") {
+		t.Errorf("expected usage error, got %s", stderr.String())
+	}
+}
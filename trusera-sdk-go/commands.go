@@ -0,0 +1,424 @@
+package trusera
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// CommandType names a command sent by the fleet API to a running agent
+type CommandType string
+
+const (
+	CommandFlush           CommandType = "flush"            // Deliver queued events now
+	CommandPauseCapture    CommandType = "pause_capture"    // Discard tracked events until resumed
+	CommandResumeCapture   CommandType = "resume_capture"   // Track events again
+	CommandRotateKey       CommandType = "rotate_key"       // Switch to args.api_key, or reload the API key file
+	CommandSendDiagnostics CommandType = "send_diagnostics" // Report stats, health and config in the ack
+	CommandShutdown        CommandType = "shutdown"         // Run CommandOptions.OnShutdown, or close the client
+)
+
+// DefaultAllowedCommands are accepted when CommandOptions.Allow is empty.
+// Key rotation and shutdown must be allowed explicitly.
+var DefaultAllowedCommands = []CommandType{CommandFlush, CommandPauseCapture, CommandResumeCapture, CommandSendDiagnostics}
+
+// Command is an instruction from the fleet API
+type Command struct {
+	ID        string          `json:"id"`
+	AgentID   string          `json:"agent_id"` // Fleet agent the command is for
+	Type      CommandType     `json:"type"`
+	Args      json.RawMessage `json:"args,omitempty"`
+	IssuedAt  time.Time       `json:"issued_at"`
+	Signature string          `json:"signature,omitempty"`
+}
+
+// SigningPayload returns the bytes a command's signature covers: the ID,
+// agent ID, type, issue time (RFC 3339, UTC) and raw args, separated by
+// newlines
+func (cmd Command) SigningPayload() []byte {
+	var b bytes.Buffer
+	b.WriteString(cmd.ID)
+	b.WriteByte('\n')
+	b.WriteString(cmd.AgentID)
+	b.WriteByte('\n')
+	b.WriteString(string(cmd.Type))
+	b.WriteByte('\n')
+	b.WriteString(cmd.IssuedAt.UTC().Format(time.RFC3339))
+	b.WriteByte('\n')
+	b.Write(cmd.Args)
+	return b.Bytes()
+}
+
+// CommandVerifier checks a command's signature and returns an error to reject it
+type CommandVerifier func(Command) error
+
+// HMACVerifier accepts commands whose signature is the hex-encoded
+// HMAC-SHA256 of their signing payload under secret
+func HMACVerifier(secret []byte) CommandVerifier {
+	return func(cmd Command) error {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(cmd.SigningPayload())
+		want := mac.Sum(nil)
+		got, err := hex.DecodeString(cmd.Signature)
+		if err != nil || !hmac.Equal(got, want) {
+			return errors.New("invalid command signature")
+		}
+		return nil
+	}
+}
+
+// Ed25519Verifier accepts commands whose signature is the base64-encoded
+// Ed25519 signature of their signing payload by the key's owner
+func Ed25519Verifier(pub ed25519.PublicKey) CommandVerifier {
+	return func(cmd Command) error {
+		sig, err := base64.StdEncoding.DecodeString(cmd.Signature)
+		if err != nil || !ed25519.Verify(pub, cmd.SigningPayload(), sig) {
+			return errors.New("invalid command signature")
+		}
+		return nil
+	}
+}
+
+// CommandOptions configures the fleet command channel
+type CommandOptions struct {
+	// Allow lists the accepted command types. Defaults to DefaultAllowedCommands.
+	Allow []CommandType
+	// Verify checks each command's signature. Without it commands are
+	// trusted on the strength of the authenticated API connection alone.
+	// With it, commands must also carry their issue time and this agent's
+	// fleet ID, so a signed command cannot be replayed later or against
+	// other agents sharing the key.
+	Verify CommandVerifier
+	// MaxAge rejects commands issued longer ago than this. Defaults to 5m.
+	// Executed command IDs are remembered in memory for MaxAge, so a
+	// command can be replayed within MaxAge of its issue after a restart;
+	// keep it short.
+	MaxAge time.Duration
+	// OnShutdown handles the shutdown command, e.g. by stopping the
+	// application. Without it the client is closed.
+	OnShutdown func(Command)
+	// Wait is how long the API may hold a poll open. Defaults to 30s.
+	Wait time.Duration
+}
+
+// Defaults for CommandOptions
+const (
+	defaultCommandMaxAge = 5 * time.Minute
+	defaultCommandWait   = 30 * time.Second
+	maxCommandBackoff    = time.Minute
+	maxSeenCommands      = 10000
+)
+
+// commandChannel holds the command channel configuration and state
+type commandChannel struct {
+	opts    CommandOptions
+	allowed map[CommandType]bool
+
+	mu   sync.Mutex
+	seen map[string]time.Time // Executed command IDs, until they expire
+}
+
+// commandResult is reported back to the API for each command
+type commandResult struct {
+	Status string `json:"status"` // "ok", "rejected" or "failed"
+	Error  string `json:"error,omitempty"`
+	Result any    `json:"result,omitempty"`
+}
+
+// WithCommands opens a command channel once fleet registration succeeds.
+// The client long-polls /api/v1/fleet/{id}/commands and acknowledges each
+// command with its outcome. Commands not on the allow-list, with an invalid
+// signature, for another agent, too old or already seen are rejected.
+func WithCommands(opts CommandOptions) Option {
+	return func(c *Client) {
+		if len(opts.Allow) == 0 {
+			opts.Allow = DefaultAllowedCommands
+		}
+		if opts.MaxAge <= 0 {
+			opts.MaxAge = defaultCommandMaxAge
+		}
+		if opts.Wait <= 0 {
+			opts.Wait = defaultCommandWait
+		}
+		ch := &commandChannel{opts: opts, allowed: map[CommandType]bool{}, seen: map[string]time.Time{}}
+		for _, t := range opts.Allow {
+			ch.allowed[t] = true
+		}
+		c.commands = ch
+	}
+}
+
+// CapturePaused reports whether event capture was paused by a fleet command
+func (c *Client) CapturePaused() bool {
+	return c.capturePaused.Load()
+}
+
+func (c *Client) commandLoop() {
+	defer c.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-c.done
+		cancel()
+	}()
+
+	backoff := time.Second
+	for {
+		cmds, err := c.pollCommands(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			c.logf(LogWarn, "fleet command poll failed: %v", err)
+			c.reportError(fmt.Errorf("fleet command poll: %w", err))
+			select {
+			case <-time.After(backoff):
+				backoff = min(backoff*2, maxCommandBackoff)
+			case <-c.done:
+				return
+			}
+			continue
+		}
+		backoff = time.Second
+
+		for _, cmd := range cmds {
+			result := c.handleCommand(cmd)
+			if err := c.ackCommand(ctx, cmd, result); err != nil {
+				c.logf(LogWarn, "fleet command %s ack failed: %v", cmd.ID, err)
+			}
+			if result.Status == "ok" && cmd.Type == CommandShutdown {
+				c.shutdownForCommand(cmd)
+				return
+			}
+		}
+	}
+}
+
+// pollCommands waits for pending commands
+func (c *Client) pollCommands(ctx context.Context) ([]Command, error) {
+	c.mu.Lock()
+	fleetID := c.fleetAgentID
+	c.mu.Unlock()
+
+	wait := c.commands.opts.Wait
+	u := fmt.Sprintf("%s/api/v1/fleet/%s/commands?wait=%s", c.baseURL, url.PathEscape(fleetID),
+		strconv.Itoa(int(wait.Seconds())))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.currentAPIKey())
+
+	// The API holds the request for up to wait, beyond the client's usual timeout
+	hc := *c.httpClient
+	if hc.Timeout > 0 {
+		hc.Timeout += wait
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+	if resp.StatusCode >= 400 {
+		return nil, newAPIError(resp)
+	}
+
+	var result struct {
+		Data []Command `json:"data"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return result.Data, nil
+}
+
+// handleCommand checks and executes a command
+func (c *Client) handleCommand(cmd Command) commandResult {
+	c.mu.Lock()
+	fleetID := c.fleetAgentID
+	c.mu.Unlock()
+	if err := c.commands.accept(cmd, fleetID); err != nil {
+		c.logf(LogWarn, "rejected fleet command %s (%s): %v", cmd.ID, cmd.Type, err)
+		return commandResult{Status: "rejected", Error: err.Error()}
+	}
+
+	c.logf(LogInfo, "executing fleet command %s (%s)", cmd.ID, cmd.Type)
+	result, err := c.executeCommand(cmd)
+	if err != nil {
+		return commandResult{Status: "failed", Error: err.Error()}
+	}
+	return commandResult{Status: "ok", Result: result}
+}
+
+// accept applies the allow-list, signature, target, age and replay checks
+// for the agent registered as fleetID
+func (ch *commandChannel) accept(cmd Command, fleetID string) error {
+	if !ch.allowed[cmd.Type] {
+		return fmt.Errorf("command %q is not allowed", cmd.Type)
+	}
+	if ch.opts.Verify != nil {
+		if err := ch.opts.Verify(cmd); err != nil {
+			return err
+		}
+		// The signature only protects what it covers
+		if cmd.IssuedAt.IsZero() {
+			return errors.New("signed command has no issue time")
+		}
+		if cmd.AgentID == "" {
+			return errors.New("signed command has no agent ID")
+		}
+	}
+	if cmd.AgentID != "" && cmd.AgentID != fleetID {
+		return fmt.Errorf("command is for agent %s", cmd.AgentID)
+	}
+	now := time.Now()
+	if !cmd.IssuedAt.IsZero() && now.Sub(cmd.IssuedAt) > ch.opts.MaxAge {
+		return fmt.Errorf("command issued %s ago", now.Sub(cmd.IssuedAt).Round(time.Second))
+	}
+
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if _, ok := ch.seen[cmd.ID]; ok {
+		return errors.New("command already executed")
+	}
+	// IDs are kept until their command would be too old anyway. Evicting
+	// one sooner would allow a replay, so a full set rejects commands.
+	for id, expires := range ch.seen {
+		if now.After(expires) {
+			delete(ch.seen, id)
+		}
+	}
+	if len(ch.seen) >= maxSeenCommands {
+		return errors.New("too many recent commands")
+	}
+	issued := cmd.IssuedAt
+	if issued.IsZero() {
+		issued = now
+	}
+	ch.seen[cmd.ID] = issued.Add(ch.opts.MaxAge)
+	return nil
+}
+
+// executeCommand runs an accepted command and returns its result
+func (c *Client) executeCommand(cmd Command) (any, error) {
+	switch cmd.Type {
+	case CommandFlush:
+		return nil, c.Flush()
+	case CommandPauseCapture:
+		c.capturePaused.Store(true)
+		return nil, nil
+	case CommandResumeCapture:
+		c.capturePaused.Store(false)
+		return nil, nil
+	case CommandRotateKey:
+		return nil, c.rotateKeyForCommand(cmd)
+	case CommandSendDiagnostics:
+		return c.diagnostics(), nil
+	case CommandShutdown:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown command %q", cmd.Type)
+}
+
+// rotateKeyForCommand switches to the key in the command's args, or
+// reloads the API key file when the command carries none
+func (c *Client) rotateKeyForCommand(cmd Command) error {
+	var args struct {
+		APIKey string `json:"api_key"`
+	}
+	if len(cmd.Args) > 0 {
+		if err := json.Unmarshal(cmd.Args, &args); err != nil {
+			return fmt.Errorf("invalid args: %w", err)
+		}
+	}
+	if args.APIKey != "" {
+		c.SetAPIKey(args.APIKey)
+		return nil
+	}
+	if c.apiKeyFile == "" {
+		return errors.New("no api_key given and no API key file configured")
+	}
+	key, err := c.readAPIKeyFile()
+	if err != nil {
+		return err
+	}
+	c.SetAPIKey(key)
+	return nil
+}
+
+// diagnostics describes the client's state for the send_diagnostics command
+func (c *Client) diagnostics() map[string]interface{} {
+	health, stats := c.healthInfo()
+	d := map[string]interface{}{
+		"sdk_version":    sdkVersion,
+		"go_version":     runtime.Version(),
+		"stats":          stats,
+		"health":         health,
+		"capture_paused": c.CapturePaused(),
+		"uptime_seconds": int64(time.Since(c.startedAt).Seconds()),
+	}
+	if cfg, ok := c.RemoteConfig(); ok {
+		d["remote_config_version"] = cfg.Version
+	}
+	return d
+}
+
+// shutdownForCommand hands the shutdown command to the application, or
+// closes the client. Close waits for the command loop, so it runs on its own
+// goroutine after the loop has returned.
+func (c *Client) shutdownForCommand(cmd Command) {
+	if c.commands.opts.OnShutdown != nil {
+		go c.commands.opts.OnShutdown(cmd)
+		return
+	}
+	go c.Close()
+}
+
+// ackCommand reports a command's outcome to the API
+func (c *Client) ackCommand(ctx context.Context, cmd Command, result commandResult) error {
+	c.mu.Lock()
+	fleetID := c.fleetAgentID
+	c.mu.Unlock()
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	u := fmt.Sprintf("%s/api/v1/fleet/%s/commands/%s/ack", c.baseURL, url.PathEscape(fleetID), url.PathEscape(cmd.ID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.currentAPIKey())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return newAPIError(resp)
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	return nil
+}
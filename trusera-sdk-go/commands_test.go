@@ -0,0 +1,261 @@
+package trusera
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// commandServer serves fleet registration and hands out queued commands on
+// the long-poll endpoint, recording each ack by command ID
+type commandServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	pending []Command
+	acks    map[string]commandResult
+	acked   chan struct{}
+}
+
+func newCommandServer(t *testing.T, cmds ...Command) *commandServer {
+	t.Helper()
+	s := &commandServer{pending: cmds, acks: map[string]commandResult{}, acked: make(chan struct{}, 100)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/fleet/register":
+			json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"id": "fleet-1"}})
+		case r.URL.Path == "/api/v1/fleet/fleet-1/commands":
+			s.mu.Lock()
+			cmds := s.pending
+			s.pending = nil
+			s.mu.Unlock()
+			if len(cmds) == 0 {
+				// Hold the poll open like the API would
+				select {
+				case <-r.Context().Done():
+				case <-time.After(50 * time.Millisecond):
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{"data": cmds})
+		case strings.HasSuffix(r.URL.Path, "/ack"):
+			id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/fleet/fleet-1/commands/"), "/ack")
+			var result commandResult
+			_ = json.NewDecoder(r.Body).Decode(&result)
+			s.mu.Lock()
+			s.acks[id] = result
+			s.mu.Unlock()
+			s.acked <- struct{}{}
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *commandServer) waitAcks(t *testing.T, n int) map[string]commandResult {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-s.acked:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for ack %d of %d", i+1, n)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acks := make(map[string]commandResult, len(s.acks))
+	for id, r := range s.acks {
+		acks[id] = r
+	}
+	return acks
+}
+
+func TestCommandsExecuteAllowed(t *testing.T) {
+	now := time.Now()
+	server := newCommandServer(t,
+		Command{ID: "c1", Type: CommandPauseCapture, IssuedAt: now},
+		Command{ID: "c2", Type: CommandSendDiagnostics, IssuedAt: now},
+		Command{ID: "c3", Type: CommandShutdown, IssuedAt: now},
+		Command{ID: "c4", Type: CommandPauseCapture, IssuedAt: now.Add(-time.Hour)},
+	)
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithAutoRegister(), WithCommands(CommandOptions{}))
+	defer client.Close()
+
+	acks := server.waitAcks(t, 4)
+	if acks["c1"].Status != "ok" || !client.CapturePaused() {
+		t.Errorf("expected pause_capture to pause capture, got %+v", acks["c1"])
+	}
+	diag, ok := acks["c2"].Result.(map[string]any)
+	if acks["c2"].Status != "ok" || !ok || diag["capture_paused"] != true {
+		t.Errorf("expected diagnostics in the ack, got %+v", acks["c2"])
+	}
+	if acks["c3"].Status != "rejected" {
+		t.Errorf("expected shutdown to be rejected by the default allow-list, got %+v", acks["c3"])
+	}
+	if acks["c4"].Status != "rejected" {
+		t.Errorf("expected a stale command to be rejected, got %+v", acks["c4"])
+	}
+
+	client.Track(NewEvent(EventToolCall, "search"))
+	if n := len(queuedEvents(client)); n != 0 {
+		t.Errorf("expected no events while capture is paused, got %d", n)
+	}
+}
+
+func TestCommandsRejectReplay(t *testing.T) {
+	ch := &commandChannel{opts: CommandOptions{MaxAge: time.Minute}, allowed: map[CommandType]bool{CommandFlush: true}, seen: map[string]time.Time{}}
+	cmd := Command{ID: "c1", Type: CommandFlush, IssuedAt: time.Now()}
+	if err := ch.accept(cmd, "fleet-1"); err != nil {
+		t.Fatalf("expected the first delivery to be accepted, got %v", err)
+	}
+	if err := ch.accept(cmd, "fleet-1"); err == nil {
+		t.Error("expected a replayed command to be rejected")
+	}
+}
+
+func TestCommandsRotateKey(t *testing.T) {
+	server := newCommandServer(t,
+		Command{ID: "c1", Type: CommandRotateKey, Args: json.RawMessage(`{"api_key":"new-key"}`), IssuedAt: time.Now()},
+	)
+
+	client := NewClient("old-key", WithBaseURL(server.URL), WithAutoRegister(),
+		WithCommands(CommandOptions{Allow: []CommandType{CommandRotateKey}}))
+	defer client.Close()
+
+	acks := server.waitAcks(t, 1)
+	if acks["c1"].Status != "ok" {
+		t.Fatalf("expected rotate_key to succeed, got %+v", acks["c1"])
+	}
+	if key := client.currentAPIKey(); key != "new-key" {
+		t.Errorf("expected rotated key, got %q", key)
+	}
+}
+
+func TestCommandsShutdownCallback(t *testing.T) {
+	server := newCommandServer(t, Command{ID: "c1", Type: CommandShutdown, IssuedAt: time.Now()})
+
+	called := make(chan Command, 1)
+	client := NewClient("test-key", WithBaseURL(server.URL), WithAutoRegister(),
+		WithCommands(CommandOptions{
+			Allow:      []CommandType{CommandShutdown},
+			OnShutdown: func(cmd Command) { called <- cmd },
+		}))
+	defer client.Close()
+
+	select {
+	case cmd := <-called:
+		if cmd.ID != "c1" {
+			t.Errorf("expected command c1, got %s", cmd.ID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected OnShutdown to be called")
+	}
+}
+
+func TestHMACVerifier(t *testing.T) {
+	secret := []byte("s3cret")
+	cmd := Command{ID: "c1", Type: CommandFlush, Args: json.RawMessage(`{}`), IssuedAt: time.Now()}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(cmd.SigningPayload())
+	cmd.Signature = hex.EncodeToString(mac.Sum(nil))
+
+	verify := HMACVerifier(secret)
+	if err := verify(cmd); err != nil {
+		t.Errorf("expected a valid signature, got %v", err)
+	}
+	cmd.Type = CommandShutdown
+	if err := verify(cmd); err == nil {
+		t.Error("expected a tampered command to fail verification")
+	}
+}
+
+func TestEd25519Verifier(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := Command{ID: "c1", Type: CommandFlush, IssuedAt: time.Now()}
+	cmd.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(priv, cmd.SigningPayload()))
+
+	verify := Ed25519Verifier(pub)
+	if err := verify(cmd); err != nil {
+		t.Errorf("expected a valid signature, got %v", err)
+	}
+	cmd.ID = "c2"
+	if err := verify(cmd); err == nil {
+		t.Error("expected a tampered command to fail verification")
+	}
+
+	ch := &commandChannel{opts: CommandOptions{Verify: verify, MaxAge: time.Minute}, allowed: map[CommandType]bool{CommandFlush: true}, seen: map[string]time.Time{}}
+	if err := ch.accept(cmd, "fleet-1"); err == nil {
+		t.Error("expected the channel to reject an unverified command")
+	}
+}
+
+func TestSignedCommandsAreBoundToAgentAndTime(t *testing.T) {
+	secret := []byte("s3cret")
+	sign := func(cmd Command) Command {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(cmd.SigningPayload())
+		cmd.Signature = hex.EncodeToString(mac.Sum(nil))
+		return cmd
+	}
+	ch := &commandChannel{opts: CommandOptions{Verify: HMACVerifier(secret), MaxAge: time.Minute},
+		allowed: map[CommandType]bool{CommandShutdown: true}, seen: map[string]time.Time{}}
+
+	undated := sign(Command{ID: "c1", AgentID: "fleet-1", Type: CommandShutdown})
+	if err := ch.accept(undated, "fleet-1"); err == nil {
+		t.Error("expected a signed command without an issue time to be rejected")
+	}
+	untargeted := sign(Command{ID: "c2", Type: CommandShutdown, IssuedAt: time.Now()})
+	if err := ch.accept(untargeted, "fleet-1"); err == nil {
+		t.Error("expected a signed command without an agent ID to be rejected")
+	}
+	other := sign(Command{ID: "c3", AgentID: "fleet-2", Type: CommandShutdown, IssuedAt: time.Now()})
+	if err := ch.accept(other, "fleet-1"); err == nil {
+		t.Error("expected a command for another agent to be rejected")
+	}
+	other.AgentID = "fleet-1"
+	if err := ch.accept(other, "fleet-1"); err == nil {
+		t.Error("expected retargeting a signed command to break its signature")
+	}
+	ok := sign(Command{ID: "c4", AgentID: "fleet-1", Type: CommandShutdown, IssuedAt: time.Now()})
+	if err := ch.accept(ok, "fleet-1"); err != nil {
+		t.Errorf("expected a signed command for this agent to be accepted, got %v", err)
+	}
+}
+
+func TestCommandsRememberIDsForMaxAge(t *testing.T) {
+	ch := &commandChannel{opts: CommandOptions{MaxAge: time.Minute}, allowed: map[CommandType]bool{CommandFlush: true}, seen: map[string]time.Time{}}
+	for i := 0; i < maxSeenCommands; i++ {
+		ch.seen[strconv.Itoa(i)] = time.Now().Add(time.Minute)
+	}
+	if err := ch.accept(Command{ID: "new", Type: CommandFlush, IssuedAt: time.Now()}, "fleet-1"); err == nil {
+		t.Error("expected a full set of recent IDs to reject commands rather than forget one")
+	}
+
+	for id := range ch.seen {
+		ch.seen[id] = time.Now().Add(-time.Second)
+	}
+	if err := ch.accept(Command{ID: "new", Type: CommandFlush, IssuedAt: time.Now()}, "fleet-1"); err != nil {
+		t.Errorf("expected expired IDs to be forgotten, got %v", err)
+	}
+	if len(ch.seen) != 1 {
+		t.Errorf("expected only the new ID remembered, got %d", len(ch.seen))
+	}
+}
@@ -0,0 +1,77 @@
+package trusera
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Compressor wraps w so that bytes written to the result are compressed.
+// Closing the returned writer must flush all compressed output to w.
+type Compressor func(w io.Writer) (io.WriteCloser, error)
+
+var (
+	compressorsMu sync.RWMutex
+	compressors   = map[string]Compressor{
+		"gzip": func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil },
+	}
+)
+
+// RegisterCompressor makes a content encoding available to WithCompression.
+// gzip is built in; importing the zstdgo module registers zstd.
+func RegisterCompressor(encoding string, c Compressor) {
+	compressorsMu.Lock()
+	compressors[encoding] = c
+	compressorsMu.Unlock()
+}
+
+// WithCompression compresses event batches with the named content encoding
+// ("gzip", or any encoding added with RegisterCompressor). The client refuses
+// to start with an encoding that is not registered.
+func WithCompression(encoding string) Option {
+	return func(c *Client) {
+		c.compression = encoding
+	}
+}
+
+// resolveCompression checks that the compression set by WithCompression is
+// registered
+func (c *Client) resolveCompression() error {
+	if c.compression == "" || lookupCompressor(c.compression) != nil {
+		return nil
+	}
+	if c.compression == "zstd" {
+		return errors.New(`unknown compression "zstd"; import github.com/Trusera/ai-bom/trusera-sdk-go/zstdgo`)
+	}
+	return fmt.Errorf("unknown compression %q; register it with RegisterCompressor", c.compression)
+}
+
+func lookupCompressor(encoding string) Compressor {
+	compressorsMu.RLock()
+	defer compressorsMu.RUnlock()
+	return compressors[encoding]
+}
+
+// compress encodes data with the named encoding
+func compress(encoding string, data []byte) ([]byte, error) {
+	c := lookupCompressor(encoding)
+	if c == nil {
+		return nil, fmt.Errorf("unknown compression %q", encoding)
+	}
+
+	var buf bytes.Buffer
+	w, err := c(&buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,90 @@
+package trusera
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithCompressionGzip(t *testing.T) {
+	var encoding string
+	var received Batch
+	var wireSize, rawSize int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding = r.Header.Get("Content-Encoding")
+		compressed, _ := io.ReadAll(r.Body)
+		wireSize = len(compressed)
+
+		zr, err := gzip.NewReader(strings.NewReader(string(compressed)))
+		if err != nil {
+			t.Errorf("body is not gzip: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		raw, _ := io.ReadAll(zr)
+		rawSize = len(raw)
+		json.Unmarshal(raw, &received)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithCompression("gzip"), WithBatchSize(1000))
+	defer client.Close()
+
+	for i := 0; i < 50; i++ {
+		client.Track(NewEvent(EventLLMInvoke, "chat").WithPayload("prompt", strings.Repeat("verbose prompt ", 20)))
+	}
+	if err := client.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	if encoding != "gzip" {
+		t.Errorf("expected Content-Encoding gzip, got %q", encoding)
+	}
+	if len(received.Events) != 50 {
+		t.Errorf("expected 50 events after decompression, got %d", len(received.Events))
+	}
+	if wireSize*10 > rawSize {
+		t.Errorf("expected >10x compression, got %d -> %d bytes", rawSize, wireSize)
+	}
+}
+
+func TestWithCompressionUnknownIsRejected(t *testing.T) {
+	for _, encoding := range []string{"brotli", "zstd"} {
+		c := &Client{compression: encoding}
+		if err := c.resolveCompression(); err == nil || !strings.Contains(err.Error(), encoding) {
+			t.Errorf("expected unknown compression %s to be rejected, got %v", encoding, err)
+		}
+	}
+	c := &Client{compression: "gzip"}
+	if err := c.resolveCompression(); err != nil {
+		t.Errorf("expected gzip to be accepted, got %v", err)
+	}
+}
+
+func TestRegisterCompressor(t *testing.T) {
+	RegisterCompressor("identity-test", func(w io.Writer) (io.WriteCloser, error) {
+		return nopWriteCloser{w}, nil
+	})
+
+	client := NewClient("test-key", WithCompression("identity-test"))
+	defer client.Close()
+
+	if client.compression != "identity-test" {
+		t.Errorf("expected registered compressor to be accepted, got %q", client.compression)
+	}
+
+	out, err := compress("identity-test", []byte("abc"))
+	if err != nil || string(out) != "abc" {
+		t.Errorf("unexpected output %q, %v", out, err)
+	}
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
@@ -0,0 +1,264 @@
+// Package config manages Trusera configuration declaratively. Fleet agents,
+// projects and alert rules are plain resources, identified by kind and name,
+// that can be read with Get and created or replaced with Apply, which makes
+// the package a base for a Terraform provider or a GitOps controller:
+//
+//	m, err := config.Load(f) // JSON kept in version control
+//	if err != nil {
+//		return err
+//	}
+//	if err := m.Apply(ctx, client); err != nil {
+//		return err
+//	}
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+// Kind is the type of a resource
+type Kind string
+
+const (
+	KindProject   Kind = "project"
+	KindAgent     Kind = "agent"
+	KindAlertRule Kind = "alert_rule"
+)
+
+// Resource is a declaratively managed piece of configuration. Its name is
+// unique within its kind.
+type Resource interface {
+	Kind() Kind
+	ResourceName() string
+	Validate() error
+}
+
+// Project groups agents and the teams that own them
+type Project struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Team        string            `json:"team,omitempty"` // Owning team, as in trusera.WithTeam
+	Labels      map[string]string `json:"labels,omitempty"`
+}
+
+func (p *Project) Kind() Kind           { return KindProject }
+func (p *Project) ResourceName() string { return p.Name }
+
+// Validate reports whether the project can be applied
+func (p *Project) Validate() error {
+	if p.Name == "" {
+		return errors.New("invalid project: name is required")
+	}
+	return nil
+}
+
+// Agent is a fleet agent and the remote configuration its clients poll, see
+// trusera.WithRemoteConfig
+type Agent struct {
+	Name      string                `json:"name"`
+	ID        string                `json:"id,omitempty"` // Fleet agent ID, assigned by Trusera
+	Framework string                `json:"framework,omitempty"`
+	Project   string                `json:"project,omitempty"` // Name of a Project
+	Labels    map[string]string     `json:"labels,omitempty"`
+	Config    *trusera.RemoteConfig `json:"config,omitempty"`
+}
+
+func (a *Agent) Kind() Kind           { return KindAgent }
+func (a *Agent) ResourceName() string { return a.Name }
+
+// Validate reports whether the agent can be applied
+func (a *Agent) Validate() error {
+	if a.Name == "" {
+		return errors.New("invalid agent: name is required")
+	}
+	return nil
+}
+
+// AlertRule is an alert rule managed by name. Its fields are those of
+// trusera.AlertRule.
+type AlertRule struct {
+	trusera.AlertRule
+}
+
+func (r *AlertRule) Kind() Kind           { return KindAlertRule }
+func (r *AlertRule) ResourceName() string { return r.Name }
+
+// Get reads the resource with r's kind and name into r
+func Get(ctx context.Context, c *trusera.Client, r Resource) error {
+	return c.GetConfigResource(ctx, string(r.Kind()), r.ResourceName(), r)
+}
+
+// Apply creates or replaces the resource with r's kind and name, then updates
+// r to the stored resource, including fields assigned by Trusera such as IDs.
+// Applying an unchanged resource is a no-op.
+func Apply(ctx context.Context, c *trusera.Client, r Resource) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	return c.ApplyConfigResource(ctx, string(r.Kind()), r.ResourceName(), r, r)
+}
+
+// Delete deletes the resource with r's kind and name. Deleting one that does
+// not exist succeeds.
+func Delete(ctx context.Context, c *trusera.Client, r Resource) error {
+	return c.DeleteConfigResource(ctx, string(r.Kind()), r.ResourceName())
+}
+
+// Manifest is a set of resources, stored as JSON
+type Manifest struct {
+	Projects   []Project   `json:"projects,omitempty"`
+	Agents     []Agent     `json:"agents,omitempty"`
+	AlertRules []AlertRule `json:"alert_rules,omitempty"`
+}
+
+// Load reads a manifest written by Write or by hand. Unknown fields are
+// rejected so typos do not silently drop settings.
+func Load(r io.Reader) (*Manifest, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var m Manifest
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Write writes the manifest as indented JSON
+func (m *Manifest) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// Resources returns the manifest's resources in the order they are applied:
+// projects, then the agents in them, then alert rules
+func (m *Manifest) Resources() []Resource {
+	var out []Resource
+	for i := range m.Projects {
+		out = append(out, &m.Projects[i])
+	}
+	for i := range m.Agents {
+		out = append(out, &m.Agents[i])
+	}
+	for i := range m.AlertRules {
+		out = append(out, &m.AlertRules[i])
+	}
+	return out
+}
+
+// Validate checks every resource and that names are unique within each kind
+func (m *Manifest) Validate() error {
+	seen := map[Kind]map[string]bool{}
+	for _, r := range m.Resources() {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+		if seen[r.Kind()] == nil {
+			seen[r.Kind()] = map[string]bool{}
+		}
+		if seen[r.Kind()][r.ResourceName()] {
+			return fmt.Errorf("duplicate %s %q", r.Kind(), r.ResourceName())
+		}
+		seen[r.Kind()][r.ResourceName()] = true
+	}
+	return nil
+}
+
+// Apply applies every resource in dependency order, stopping at the first
+// error, and updates the manifest to the stored resources. Resources missing
+// from the manifest are left alone; see Prune.
+func (m *Manifest) Apply(ctx context.Context, c *trusera.Client) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	for _, r := range m.Resources() {
+		if err := Apply(ctx, c, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Prune deletes resources that exist in Trusera but not in the manifest,
+// alert rules first, and returns them as "kind/name"
+func (m *Manifest) Prune(ctx context.Context, c *trusera.Client) ([]string, error) {
+	want := map[Kind]map[string]bool{}
+	for _, r := range m.Resources() {
+		if want[r.Kind()] == nil {
+			want[r.Kind()] = map[string]bool{}
+		}
+		want[r.Kind()][r.ResourceName()] = true
+	}
+	current, err := Export(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := current.Resources()
+	var deleted []string
+	for i := len(resources) - 1; i >= 0; i-- {
+		r := resources[i]
+		if want[r.Kind()][r.ResourceName()] {
+			continue
+		}
+		if err := Delete(ctx, c, r); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, fmt.Sprintf("%s/%s", r.Kind(), r.ResourceName()))
+	}
+	return deleted, nil
+}
+
+// Export reads every project, agent and alert rule into a manifest, e.g. to
+// bring existing configuration under version control
+func Export(ctx context.Context, c *trusera.Client) (*Manifest, error) {
+	var m Manifest
+	if err := list(ctx, c, KindProject, func(raw json.RawMessage) error {
+		m.Projects = append(m.Projects, Project{})
+		return json.Unmarshal(raw, &m.Projects[len(m.Projects)-1])
+	}); err != nil {
+		return nil, err
+	}
+	if err := list(ctx, c, KindAgent, func(raw json.RawMessage) error {
+		m.Agents = append(m.Agents, Agent{})
+		return json.Unmarshal(raw, &m.Agents[len(m.Agents)-1])
+	}); err != nil {
+		return nil, err
+	}
+	if err := list(ctx, c, KindAlertRule, func(raw json.RawMessage) error {
+		m.AlertRules = append(m.AlertRules, AlertRule{})
+		return json.Unmarshal(raw, &m.AlertRules[len(m.AlertRules)-1])
+	}); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// list calls fn for every resource of kind, following pages
+func list(ctx context.Context, c *trusera.Client, kind Kind, fn func(json.RawMessage) error) error {
+	var opts trusera.ListOptions
+	for {
+		page, err := c.ListConfigResources(ctx, string(kind), opts)
+		if err != nil {
+			return err
+		}
+		for _, raw := range page.Resources {
+			if err := fn(raw); err != nil {
+				return fmt.Errorf("failed to decode %s: %w", kind, err)
+			}
+		}
+		if page.NextCursor == "" || page.NextCursor == opts.Cursor {
+			return nil
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
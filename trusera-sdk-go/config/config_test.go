@@ -0,0 +1,186 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+// store is an in-memory /v1/config API
+type store struct {
+	mu        sync.Mutex
+	resources map[string]map[string]json.RawMessage // Kind, then name
+	puts      int
+}
+
+func newStore(t *testing.T) (*store, *trusera.Client) {
+	s := &store{resources: map[string]map[string]json.RawMessage{}}
+	server := httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(server.Close)
+	client := trusera.NewClient("test-key", trusera.WithBaseURL(server.URL))
+	t.Cleanup(func() { client.Close() })
+	return s, client
+}
+
+func (s *store) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kind, name, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v1/config/"), "/")
+	switch {
+	case r.Method == http.MethodGet && name == "":
+		page := trusera.ConfigPage{}
+		names := make([]string, 0, len(s.resources[kind]))
+		for n := range s.resources[kind] {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			page.Resources = append(page.Resources, s.resources[kind][n])
+		}
+		json.NewEncoder(w).Encode(page)
+	case r.Method == http.MethodGet:
+		raw, ok := s.resources[kind][name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(raw)
+	case r.Method == http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		if kind == string(KindAgent) {
+			// Trusera assigns fleet IDs
+			var a map[string]any
+			json.Unmarshal(body, &a)
+			a["id"] = "fleet-" + name
+			body, _ = json.Marshal(a)
+		}
+		if s.resources[kind] == nil {
+			s.resources[kind] = map[string]json.RawMessage{}
+		}
+		s.resources[kind][name] = body
+		s.puts++
+		w.Write(body)
+	case r.Method == http.MethodDelete:
+		if _, ok := s.resources[kind][name]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(s.resources[kind], name)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+const testManifest = `{
+  "projects": [{"name": "support", "team": "cx"}],
+  "agents": [{"name": "support-bot", "framework": "langchaingo", "project": "support",
+              "config": {"version": "1", "log_level": "warn"}}],
+  "alert_rules": [{"name": "support errors", "metric": "error_rate", "operator": ">",
+                   "threshold": 0.05, "window_ns": 600000000000}]
+}`
+
+func TestApplyAndGet(t *testing.T) {
+	_, client := newStore(t)
+	ctx := context.Background()
+
+	agent := &Agent{Name: "support-bot", Framework: "langchaingo", Labels: map[string]string{"env": "prod"}}
+	if err := Apply(ctx, client, agent); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if agent.ID != "fleet-support-bot" {
+		t.Errorf("expected the assigned fleet ID, got %q", agent.ID)
+	}
+
+	got := &Agent{Name: "support-bot"}
+	if err := Get(ctx, client, got); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Framework != "langchaingo" || got.Labels["env"] != "prod" || got.ID != agent.ID {
+		t.Errorf("expected the applied agent, got %+v", got)
+	}
+
+	if err := Delete(ctx, client, got); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := Delete(ctx, client, got); err != nil {
+		t.Errorf("expected deleting a missing resource to succeed, got %v", err)
+	}
+	if err := Get(ctx, client, &Agent{Name: "support-bot"}); err == nil {
+		t.Error("expected error for deleted agent")
+	}
+
+	if err := Apply(ctx, client, &Project{}); err == nil {
+		t.Error("expected validation error for unnamed project")
+	}
+}
+
+func TestManifestApplyExportPrune(t *testing.T) {
+	s, client := newStore(t)
+	ctx := context.Background()
+
+	m, err := Load(strings.NewReader(testManifest))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if m.AlertRules[0].Window != 10*time.Minute {
+		t.Errorf("expected alert rule window, got %v", m.AlertRules[0].Window)
+	}
+	if err := m.Apply(ctx, client); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if s.puts != 3 || m.Agents[0].ID == "" {
+		t.Errorf("expected three resources applied with agent ID set, got %d puts, %+v", s.puts, m.Agents[0])
+	}
+
+	// Resources created outside the manifest are pruned
+	if err := Apply(ctx, client, &Project{Name: "scratch"}); err != nil {
+		t.Fatal(err)
+	}
+	exported, err := Export(ctx, client)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if len(exported.Projects) != 2 || len(exported.Agents) != 1 || len(exported.AlertRules) != 1 {
+		t.Fatalf("expected all resources exported, got %+v", exported)
+	}
+	if exported.Agents[0].Config == nil || exported.Agents[0].Config.LogLevel != "warn" {
+		t.Errorf("expected agent remote config exported, got %+v", exported.Agents[0])
+	}
+
+	deleted, err := m.Prune(ctx, client)
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0] != "project/scratch" {
+		t.Errorf("expected only the scratch project pruned, got %v", deleted)
+	}
+
+	var buf bytes.Buffer
+	if err := exported.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(&buf); err != nil {
+		t.Errorf("expected exported manifest to load, got %v", err)
+	}
+}
+
+func TestLoadRejectsInvalidManifests(t *testing.T) {
+	for name, doc := range map[string]string{
+		"unknown field": `{"projects": [{"name": "a", "owner": "b"}]}`,
+		"duplicate":     `{"projects": [{"name": "a"}, {"name": "a"}]}`,
+		"invalid rule":  `{"alert_rules": [{"name": "r", "metric": "error_rate", "operator": "~", "window_ns": 1}]}`,
+	} {
+		if _, err := Load(strings.NewReader(doc)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package trusera
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ConfigPage is one page of ListConfigResources results
+type ConfigPage struct {
+	Resources []json.RawMessage `json:"resources"`
+	// NextCursor is passed as ListOptions.Cursor to fetch the next page,
+	// empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// GetConfigResource fetches the declarative resource of kind, such as
+// "agent", with the given name from GET /v1/config/{kind}/{name} and decodes
+// it into out. The config package wraps it with typed resources.
+func (c *Client) GetConfigResource(ctx context.Context, kind, name string, out any) error {
+	path, err := configPath(kind, name)
+	if err != nil {
+		return err
+	}
+	if err := c.getJSON(ctx, path, nil, out); err != nil {
+		return fmt.Errorf("failed to get %s %q: %w", kind, name, err)
+	}
+	return nil
+}
+
+// ApplyConfigResource creates or replaces a declarative resource with PUT
+// /v1/config/{kind}/{name} and decodes the stored resource into out, if not
+// nil. Applying the same spec again changes nothing.
+func (c *Client) ApplyConfigResource(ctx context.Context, kind, name string, spec, out any) error {
+	path, err := configPath(kind, name)
+	if err != nil {
+		return err
+	}
+	if err := c.doJSON(ctx, http.MethodPut, path, spec, out); err != nil {
+		return fmt.Errorf("failed to apply %s %q: %w", kind, name, err)
+	}
+	return nil
+}
+
+// DeleteConfigResource deletes a declarative resource. Deleting one that does
+// not exist succeeds.
+func (c *Client) DeleteConfigResource(ctx context.Context, kind, name string) error {
+	path, err := configPath(kind, name)
+	if err != nil {
+		return err
+	}
+	err = c.doJSON(ctx, http.MethodDelete, path, nil, nil)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete %s %q: %w", kind, name, err)
+	}
+	return nil
+}
+
+// ListConfigResources fetches one page of the declarative resources of kind
+func (c *Client) ListConfigResources(ctx context.Context, kind string, opts ListOptions) (*ConfigPage, error) {
+	if kind == "" {
+		return nil, errors.New("resource kind is required")
+	}
+	q := url.Values{}
+	setPage(q, opts.Limit, opts.Cursor)
+	var page ConfigPage
+	if err := c.getJSON(ctx, "/v1/config/"+url.PathEscape(kind), q, &page); err != nil {
+		return nil, fmt.Errorf("failed to list %s resources: %w", kind, err)
+	}
+	return &page, nil
+}
+
+// configPath returns the API path of a declarative resource
+func configPath(kind, name string) (string, error) {
+	if kind == "" {
+		return "", errors.New("resource kind is required")
+	}
+	if name == "" {
+		return "", fmt.Errorf("%s name is required", kind)
+	}
+	return "/v1/config/" + url.PathEscape(kind) + "/" + url.PathEscape(name), nil
+}
@@ -0,0 +1,44 @@
+package trusera
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConfigResourcePaths(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.EscapedPath())
+		w.Write([]byte(`{"name": "on call"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	defer client.Close()
+	ctx := context.Background()
+
+	var out struct {
+		Name string `json:"name"`
+	}
+	if err := client.ApplyConfigResource(ctx, "project", "on call", map[string]string{"name": "on call"}, &out); err != nil {
+		t.Fatalf("ApplyConfigResource failed: %v", err)
+	}
+	if out.Name != "on call" {
+		t.Errorf("expected the stored resource decoded, got %+v", out)
+	}
+	if err := client.GetConfigResource(ctx, "project", "on call", &out); err != nil {
+		t.Fatalf("GetConfigResource failed: %v", err)
+	}
+	if len(paths) != 2 || paths[0] != "PUT /v1/config/project/on%20call" || paths[1] != "GET /v1/config/project/on%20call" {
+		t.Errorf("unexpected requests %v", paths)
+	}
+
+	if err := client.GetConfigResource(ctx, "project", "", &out); err == nil {
+		t.Error("expected error for empty name")
+	}
+	if _, err := client.ListConfigResources(ctx, "", ListOptions{}); err == nil {
+		t.Error("expected error for empty kind")
+	}
+}
@@ -0,0 +1,73 @@
+package trusera
+
+import (
+	"github.com/Trusera/ai-bom/trusera-sdk-go/costs"
+)
+
+// WithPricing overrides the model pricing table used to estimate LLM costs
+func WithPricing(table *costs.Table) Option {
+	return func(c *Client) {
+		if table != nil {
+			c.pricing = table
+		}
+	}
+}
+
+// Pricing returns the client's pricing table so prices can be adjusted at runtime
+func (c *Client) Pricing() *costs.Table {
+	return c.pricing
+}
+
+// CostSummary returns estimated LLM spend per model and agent since the client started
+func (c *Client) CostSummary() costs.Summary {
+	return c.costTracker.Summary()
+}
+
+// annotateCost adds an estimated cost_usd to LLM and embedding events that
+// carry a model and token usage, and records the spend under the event's
+// agent_id metadata or the client's agent. Events for unknown models are
+// left as-is.
+func (c *Client) annotateCost(event Event) Event {
+	if (event.Type != EventLLMInvoke && event.Type != EventEmbedding) || event.Payload == nil {
+		return event
+	}
+	if _, done := event.Payload["cost_usd"]; done {
+		return event
+	}
+
+	model, _ := event.Payload["model"].(string)
+	in, okIn := toInt(event.Payload["prompt_tokens"])
+	out, okOut := toInt(event.Payload["completion_tokens"])
+	if model == "" || (!okIn && !okOut) {
+		return event
+	}
+
+	cost, ok := c.pricing.Estimate(model, in, out)
+	if !ok {
+		return event
+	}
+
+	// Events of ForAgent handles are charged to their agent
+	agentID, _ := event.Metadata["agent_id"].(string)
+	if agentID == "" {
+		c.mu.Lock()
+		agentID = c.agentID
+		c.mu.Unlock()
+	}
+
+	c.costTracker.Record(agentID, model, in, out, cost)
+	return event.WithPayload("cost_usd", cost)
+}
+
+// toInt converts a JSON-ish numeric payload value to int
+func toInt(v any) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), true
+	}
+	return 0, false
+}
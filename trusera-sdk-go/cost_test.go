@@ -0,0 +1,100 @@
+package trusera
+
+import (
+	"testing"
+
+	"github.com/Trusera/ai-bom/trusera-sdk-go/costs"
+)
+
+func TestTrackAnnotatesLLMCost(t *testing.T) {
+	client := NewClient("test-key", WithAgentID("agent-1"), WithBatchSize(1000))
+	defer client.Close()
+
+	client.Track(NewEvent(EventLLMInvoke, "chat").
+		WithPayload("model", "gpt-4o").
+		WithPayload("prompt_tokens", 1000).
+		WithPayload("completion_tokens", 1000))
+
+	events := queuedEvents(client)
+	cost, ok := events[0].Payload["cost_usd"].(float64)
+	if !ok {
+		t.Fatalf("expected cost_usd annotation, got %v", events[0].Payload)
+	}
+	if want := 0.0125; cost < want-1e-9 || cost > want+1e-9 {
+		t.Errorf("expected cost %v, got %v", want, cost)
+	}
+
+	summary := client.CostSummary()
+	if summary.ByModel["gpt-4o"].Calls != 1 {
+		t.Errorf("expected 1 gpt-4o call, got %+v", summary.ByModel)
+	}
+	if summary.ByAgent["agent-1"].CostUSD != cost {
+		t.Errorf("expected spend attributed to agent-1, got %+v", summary.ByAgent)
+	}
+}
+
+func TestTrackSkipsUnknownModelsAndOtherEvents(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	client.Track(NewEvent(EventLLMInvoke, "chat").
+		WithPayload("model", "in-house-llm").
+		WithPayload("prompt_tokens", 10))
+	client.Track(NewEvent(EventToolCall, "tool").
+		WithPayload("model", "gpt-4o").
+		WithPayload("prompt_tokens", 10))
+
+	for _, e := range queuedEvents(client) {
+		if _, ok := e.Payload["cost_usd"]; ok {
+			t.Errorf("did not expect cost on %s event", e.Name)
+		}
+	}
+	if client.CostSummary().Total.Calls != 0 {
+		t.Error("expected no recorded spend")
+	}
+}
+
+func TestWithPricing(t *testing.T) {
+	table := costs.NewTable(map[string]costs.Price{"in-house-llm": {InputPerMTok: 1_000_000}})
+	client := NewClient("test-key", WithPricing(table), WithBatchSize(1000))
+	defer client.Close()
+
+	client.Track(NewEvent(EventLLMInvoke, "chat").
+		WithPayload("model", "in-house-llm").
+		WithPayload("prompt_tokens", 2))
+
+	if got := queuedEvents(client)[0].Payload["cost_usd"]; got != 2.0 {
+		t.Errorf("expected cost 2.0 from custom table, got %v", got)
+	}
+
+	client.Pricing().Set("gpt-4o", costs.Price{InputPerMTok: 1})
+	if p, _ := client.Pricing().Lookup("gpt-4o"); p.InputPerMTok != 1 {
+		t.Error("expected runtime override to apply")
+	}
+}
+
+func TestCostSummaryByForAgent(t *testing.T) {
+	client := NewClient("test-key", WithAgentID("parent"), WithBatchSize(1000))
+	defer client.Close()
+
+	chat := func(tokens int) Event {
+		return NewEvent(EventLLMInvoke, "chat").
+			WithPayload("model", "gpt-4o").
+			WithPayload("prompt_tokens", tokens).
+			WithPayload("completion_tokens", tokens)
+	}
+	client.ForAgent("planner").Track(chat(1000))
+	client.ForAgent("coder").Track(chat(2000))
+	client.ForAgent("coder").Track(chat(2000))
+	client.Track(chat(1000))
+
+	summary := client.CostSummary()
+	for agent, calls := range map[string]int{"planner": 1, "coder": 2, "parent": 1} {
+		if got := summary.ByAgent[agent].Calls; got != calls {
+			t.Errorf("expected %d calls charged to %s, got %d", calls, agent, got)
+		}
+	}
+	if coder, planner := summary.ByAgent["coder"].CostUSD, summary.ByAgent["planner"].CostUSD; coder <= planner {
+		t.Errorf("expected the coder's spend above the planner's, got %v and %v", coder, planner)
+	}
+}
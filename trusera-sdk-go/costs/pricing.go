@@ -0,0 +1,96 @@
+// Package costs estimates the USD cost of LLM calls from token usage and
+// aggregates spend per model and agent.
+package costs
+
+import (
+	"strings"
+	"sync"
+)
+
+// Price is the cost of a model in USD per million tokens
+type Price struct {
+	InputPerMTok  float64
+	OutputPerMTok float64
+}
+
+// defaultPrices lists public list prices in USD per million tokens.
+// Dated model variants (e.g. "gpt-4o-2024-08-06") resolve by longest prefix.
+var defaultPrices = map[string]Price{
+	"gpt-4o":                 {InputPerMTok: 2.50, OutputPerMTok: 10.00},
+	"gpt-4o-mini":            {InputPerMTok: 0.15, OutputPerMTok: 0.60},
+	"gpt-4-turbo":            {InputPerMTok: 10.00, OutputPerMTok: 30.00},
+	"gpt-4":                  {InputPerMTok: 30.00, OutputPerMTok: 60.00},
+	"gpt-3.5-turbo":          {InputPerMTok: 0.50, OutputPerMTok: 1.50},
+	"o1":                     {InputPerMTok: 15.00, OutputPerMTok: 60.00},
+	"o1-mini":                {InputPerMTok: 1.10, OutputPerMTok: 4.40},
+	"o3-mini":                {InputPerMTok: 1.10, OutputPerMTok: 4.40},
+	"text-embedding-3-small": {InputPerMTok: 0.02},
+	"text-embedding-3-large": {InputPerMTok: 0.13},
+	"claude-3-5-sonnet":      {InputPerMTok: 3.00, OutputPerMTok: 15.00},
+	"claude-3-7-sonnet":      {InputPerMTok: 3.00, OutputPerMTok: 15.00},
+	"claude-3-5-haiku":       {InputPerMTok: 0.80, OutputPerMTok: 4.00},
+	"claude-3-opus":          {InputPerMTok: 15.00, OutputPerMTok: 75.00},
+	"claude-3-haiku":         {InputPerMTok: 0.25, OutputPerMTok: 1.25},
+	"claude-sonnet-4":        {InputPerMTok: 3.00, OutputPerMTok: 15.00},
+	"claude-opus-4":          {InputPerMTok: 15.00, OutputPerMTok: 75.00},
+}
+
+// Table maps model names to prices. It is safe for concurrent use.
+type Table struct {
+	mu     sync.RWMutex
+	prices map[string]Price
+}
+
+// NewTable creates a pricing table from the given prices
+func NewTable(prices map[string]Price) *Table {
+	t := &Table{prices: make(map[string]Price, len(prices))}
+	for model, p := range prices {
+		t.prices[strings.ToLower(model)] = p
+	}
+	return t
+}
+
+// DefaultTable returns a copy of the built-in pricing table
+func DefaultTable() *Table {
+	return NewTable(defaultPrices)
+}
+
+// Set adds or overrides the price for a model
+func (t *Table) Set(model string, p Price) {
+	t.mu.Lock()
+	t.prices[strings.ToLower(model)] = p
+	t.mu.Unlock()
+}
+
+// Lookup returns the price for a model, matching exactly first and then by
+// the longest known prefix
+func (t *Table) Lookup(model string) (Price, bool) {
+	model = strings.ToLower(model)
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if p, ok := t.prices[model]; ok {
+		return p, true
+	}
+
+	var best string
+	for name := range t.prices {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return Price{}, false
+	}
+	return t.prices[best], true
+}
+
+// Estimate returns the USD cost of a call, or false if the model is unknown
+func (t *Table) Estimate(model string, inputTokens, outputTokens int) (float64, bool) {
+	p, ok := t.Lookup(model)
+	if !ok {
+		return 0, false
+	}
+	return (float64(inputTokens)*p.InputPerMTok + float64(outputTokens)*p.OutputPerMTok) / 1e6, true
+}
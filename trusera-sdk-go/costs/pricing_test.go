@@ -0,0 +1,62 @@
+package costs
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLookupExactAndPrefix(t *testing.T) {
+	table := DefaultTable()
+
+	tests := []struct {
+		model string
+		input float64
+	}{
+		{"gpt-4o", 2.50},
+		{"gpt-4o-2024-08-06", 2.50},
+		{"gpt-4o-mini-2024-07-18", 0.15},
+		{"GPT-4O", 2.50},
+		{"claude-3-5-sonnet-20241022", 3.00},
+	}
+
+	for _, tt := range tests {
+		p, ok := table.Lookup(tt.model)
+		if !ok {
+			t.Errorf("%s: expected price to be found", tt.model)
+			continue
+		}
+		if p.InputPerMTok != tt.input {
+			t.Errorf("%s: expected input price %v, got %v", tt.model, tt.input, p.InputPerMTok)
+		}
+	}
+
+	if _, ok := table.Lookup("my-finetune"); ok {
+		t.Error("expected unknown model to miss")
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	table := NewTable(map[string]Price{"m": {InputPerMTok: 1, OutputPerMTok: 2}})
+
+	cost, ok := table.Estimate("m", 1_000_000, 500_000)
+	if !ok {
+		t.Fatal("expected estimate")
+	}
+	if math.Abs(cost-2.0) > 1e-9 {
+		t.Errorf("expected cost 2.0, got %v", cost)
+	}
+}
+
+func TestSetOverridesPrice(t *testing.T) {
+	table := DefaultTable()
+	table.Set("gpt-4o", Price{InputPerMTok: 1, OutputPerMTok: 1})
+
+	p, _ := table.Lookup("gpt-4o")
+	if p.InputPerMTok != 1 {
+		t.Errorf("expected overridden price, got %v", p.InputPerMTok)
+	}
+
+	if fresh, _ := DefaultTable().Lookup("gpt-4o"); fresh.InputPerMTok != 2.50 {
+		t.Error("expected override not to leak into the built-in table")
+	}
+}
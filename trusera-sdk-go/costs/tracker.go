@@ -0,0 +1,86 @@
+package costs
+
+import (
+	"sync"
+	"time"
+)
+
+// Usage aggregates token counts and spend
+type Usage struct {
+	Calls        int     `json:"calls"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+func (u *Usage) add(in, out int, cost float64) {
+	u.Calls++
+	u.InputTokens += in
+	u.OutputTokens += out
+	u.CostUSD += cost
+}
+
+// Summary is a point-in-time view of aggregated spend
+type Summary struct {
+	Since   time.Time        `json:"since"`
+	Total   Usage            `json:"total"`
+	ByModel map[string]Usage `json:"by_model"`
+	ByAgent map[string]Usage `json:"by_agent"`
+}
+
+// Tracker accumulates spend per model and agent. It is safe for concurrent use.
+type Tracker struct {
+	mu      sync.Mutex
+	since   time.Time
+	total   Usage
+	byModel map[string]*Usage
+	byAgent map[string]*Usage
+}
+
+// NewTracker creates an empty Tracker
+func NewTracker() *Tracker {
+	return &Tracker{
+		since:   time.Now().UTC(),
+		byModel: make(map[string]*Usage),
+		byAgent: make(map[string]*Usage),
+	}
+}
+
+// Record adds a single call to the aggregates
+func (t *Tracker) Record(agent, model string, inputTokens, outputTokens int, costUSD float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.total.add(inputTokens, outputTokens, costUSD)
+	bucket(t.byModel, model).add(inputTokens, outputTokens, costUSD)
+	bucket(t.byAgent, agent).add(inputTokens, outputTokens, costUSD)
+}
+
+// Summary returns a copy of the current aggregates
+func (t *Tracker) Summary() Summary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := Summary{
+		Since:   t.since,
+		Total:   t.total,
+		ByModel: make(map[string]Usage, len(t.byModel)),
+		ByAgent: make(map[string]Usage, len(t.byAgent)),
+	}
+	for k, v := range t.byModel {
+		s.ByModel[k] = *v
+	}
+	for k, v := range t.byAgent {
+		s.ByAgent[k] = *v
+	}
+	return s
+}
+
+func bucket(m map[string]*Usage, key string) *Usage {
+	u, ok := m[key]
+	if !ok {
+		u = &Usage{}
+		m[key] = u
+	}
+	return u
+}
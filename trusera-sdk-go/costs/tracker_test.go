@@ -0,0 +1,46 @@
+package costs
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestTrackerSummary(t *testing.T) {
+	tr := NewTracker()
+	tr.Record("agent-a", "gpt-4o", 100, 50, 0.5)
+	tr.Record("agent-a", "claude-3-haiku", 10, 5, 0.25)
+	tr.Record("agent-b", "gpt-4o", 100, 50, 0.5)
+
+	s := tr.Summary()
+
+	if s.Total.Calls != 3 || s.Total.CostUSD != 1.25 {
+		t.Errorf("unexpected total: %+v", s.Total)
+	}
+	if s.ByModel["gpt-4o"].Calls != 2 || s.ByModel["gpt-4o"].InputTokens != 200 {
+		t.Errorf("unexpected gpt-4o usage: %+v", s.ByModel["gpt-4o"])
+	}
+	if s.ByAgent["agent-a"].CostUSD != 0.75 {
+		t.Errorf("unexpected agent-a spend: %+v", s.ByAgent["agent-a"])
+	}
+	if s.Since.IsZero() {
+		t.Error("expected start time")
+	}
+}
+
+func TestTrackerConcurrent(t *testing.T) {
+	tr := NewTracker()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tr.Record("a", "m", 1, 1, 0.01)
+		}()
+	}
+	wg.Wait()
+
+	if got := tr.Summary().Total.Calls; got != 50 {
+		t.Errorf("expected 50 calls, got %d", got)
+	}
+}
@@ -0,0 +1,122 @@
+package trusera
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+)
+
+// crashFlushTimeout bounds how long a crash report and the events queued
+// before it are sent for before the process is allowed to die
+const crashFlushTimeout = 5 * time.Second
+
+// crashReporter is the client set by WithCrashReporting, used by Recover
+// when the context has no span
+var crashReporter atomic.Pointer[Client]
+
+// WithCrashReporting makes the client the process-wide crash reporter used
+// by Recover when the context carries no span. Any signals given, such as
+// syscall.SIGTERM, are caught once: the client is closed, delivering queued
+// events for up to 5 seconds, and the signal is raised again so the process
+// exits as it otherwise would. Only list signals the program does not handle
+// itself.
+func WithCrashReporting(signals ...os.Signal) Option {
+	return func(c *Client) {
+		c.crashReporting = true
+		c.crashSignals = signals
+	}
+}
+
+// Recover reports a panic in the calling goroutine and panics again with the
+// same value, so the program still crashes. It must be deferred directly:
+//
+//	defer trusera.Recover(ctx)
+//
+// The panic value and stack trace are sent as an EventCrash event, linked to
+// the active span in ctx, ahead of any queued events. The client is then
+// closed and its fleet agent deregistered as crashed. The client is the one
+// that started the active span in ctx, otherwise the one created with
+// WithCrashReporting; without either, the panic is not reported.
+func Recover(ctx context.Context) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	c := crashReporter.Load()
+	if s := SpanFromContext(ctx); s != nil && s.client != nil {
+		c = s.client
+	}
+	if c != nil {
+		c.reportCrash(ctx, v, debug.Stack())
+	}
+	panic(v)
+}
+
+// installCrashReporting registers the client as crash reporter and starts
+// watching its crash signals
+func (c *Client) installCrashReporting() {
+	if !c.crashReporting {
+		return
+	}
+	crashReporter.Store(c)
+	if len(c.crashSignals) == 0 {
+		return
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, c.crashSignals...)
+	go func() {
+		select {
+		case sig := <-ch:
+			c.logf(LogWarn, "received %v, flushing events before exit", sig)
+			ctx, cancel := context.WithTimeout(context.Background(), crashFlushTimeout)
+			c.CloseContext(ctx)
+			cancel()
+			signal.Reset(sig)
+			if p, err := os.FindProcess(os.Getpid()); err != nil || p.Signal(sig) != nil {
+				os.Exit(1)
+			}
+		case <-c.done:
+			signal.Stop(ch)
+		}
+	}()
+}
+
+// reportCrash sends a crash event for panic value v synchronously, then
+// closes the client
+func (c *Client) reportCrash(ctx context.Context, v any, stack []byte) {
+	msg := fmt.Sprint(v)
+	c.logf(LogError, "panic: %s", msg)
+
+	c.mu.Lock()
+	c.crash = msg
+	c.mu.Unlock()
+
+	event := NewEvent(EventCrash, "panic").
+		WithPayload("panic", msg).
+		WithPayload("panic_type", fmt.Sprintf("%T", v)).
+		WithPayload("stack", string(stack))
+	if s := SpanFromContext(ctx); s != nil {
+		event = s.link(event)
+	}
+
+	sendCtx, cancel := context.WithTimeout(context.Background(), crashFlushTimeout)
+	defer cancel()
+	if event, ok := c.prepare(event); ok {
+		c.mu.Lock()
+		c.stampEvent(&event)
+		c.stats.tracked++
+		c.mu.Unlock()
+		// Sent on its own first, so a long queue cannot hold it up
+		if err := c.sendFitted(sendCtx, []Event{event}); err != nil {
+			c.requeueEvents([]Event{event})
+		}
+	}
+	if err := c.CloseContext(sendCtx); err != nil {
+		c.logf(LogWarn, "events lost in crash: %v", err)
+	}
+}
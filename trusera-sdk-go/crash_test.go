@@ -0,0 +1,101 @@
+package trusera
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRecoverReportsPanicAndPanicsAgain(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]Event
+	var deregister map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/api/v1/fleet/register":
+			json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"id": "fleet-9"}})
+		case "/api/v1/fleet/fleet-9/deregister":
+			json.NewDecoder(r.Body).Decode(&deregister)
+		case "/v1/events":
+			var batch Batch
+			json.NewDecoder(r.Body).Decode(&batch)
+			batches = append(batches, batch.Events)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithAutoRegister(), WithFlushInterval(time.Hour))
+	client.Track(NewEvent(EventToolCall, "search"))
+	ctx, span := client.StartSpan(context.Background(), "run")
+
+	var repanicked any
+	func() {
+		defer func() { repanicked = recover() }()
+		defer Recover(ctx)
+		panic("boom")
+	}()
+
+	if repanicked != "boom" {
+		t.Errorf("expected the panic to continue, got %v", repanicked)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batches) < 2 || len(batches[0]) != 1 {
+		t.Fatalf("expected the crash sent on its own before the queue, got %v", batches)
+	}
+	crash := batches[0][0]
+	if crash.Type != EventCrash || crash.Payload["panic"] != "boom" || crash.Metadata["trace_id"] != span.TraceID() {
+		t.Errorf("expected a crash event linked to the span, got %+v", crash)
+	}
+	if stack, _ := crash.Payload["stack"].(string); !strings.Contains(stack, "TestRecoverReportsPanicAndPanicsAgain") {
+		t.Errorf("expected the stack of the panicking goroutine, got %q", stack)
+	}
+	if batches[1][0].Name != "search" {
+		t.Errorf("expected the queued event delivered after the crash, got %v", batches[1])
+	}
+	if deregister["reason"] != "crashed" || deregister["panic"] != "boom" {
+		t.Errorf("expected the fleet agent deregistered as crashed, got %v", deregister)
+	}
+}
+
+func TestRecoverUsesCrashReporter(t *testing.T) {
+	transport := &recordingTransport{}
+	client := NewClient("k", WithTransport(transport), WithCrashReporting(), WithFlushInterval(time.Hour))
+	defer client.Close()
+
+	func() {
+		defer func() { recover() }()
+		defer Recover(context.Background())
+		var m map[string]int
+		m["x"] = 1
+	}()
+
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if len(transport.batches) != 1 || !strings.Contains(transport.batches[0].Events[0].Payload["panic"].(string), "nil map") {
+		t.Fatalf("expected the runtime panic reported, got %v", transport.batches)
+	}
+	if crashReporter.Load() != nil {
+		t.Error("expected the crash reporter cleared once the client is closed")
+	}
+}
+
+func TestRecoverWithoutPanic(t *testing.T) {
+	transport := &recordingTransport{}
+	client := NewClient("k", WithTransport(transport), WithCrashReporting(), WithFlushInterval(time.Hour))
+	defer client.Close()
+
+	func() {
+		defer Recover(context.Background())
+	}()
+	if stats := client.Stats(); stats.Tracked != 0 {
+		t.Errorf("expected nothing reported, got %d events", stats.Tracked)
+	}
+}
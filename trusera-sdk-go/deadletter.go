@@ -0,0 +1,185 @@
+package trusera
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DeadLetter is an event the API rejected permanently, as recorded in the
+// dead-letter file
+type DeadLetter struct {
+	Event      Event     `json:"event"`
+	AgentID    string    `json:"agent_id,omitempty"`
+	Reason     string    `json:"reason"`
+	StatusCode int       `json:"status_code,omitempty"`
+	RequestID  string    `json:"request_id,omitempty"`
+	RejectedAt time.Time `json:"rejected_at"`
+}
+
+// deadLetters appends rejected events to a JSON lines file
+type deadLetters struct {
+	path string
+	mu   sync.Mutex
+}
+
+// WithDeadLetterFile writes events the API rejects with a client error that
+// retrying cannot fix (ErrRejected), one DeadLetter per line, to path instead
+// of retrying them. The TRUSERA_DEAD_LETTER_FILE environment variable does
+// the same. Without a dead-letter file such events are dropped with a
+// warning.
+//
+// When a batch is rejected, its halves are sent again until the events at
+// fault are isolated, so the rest of the batch is still delivered.
+func WithDeadLetterFile(path string) Option {
+	return func(c *Client) {
+		c.deadLetters.path = path
+	}
+}
+
+// DeadLetters returns the events in the dead-letter file, oldest first
+func (c *Client) DeadLetters() ([]DeadLetter, error) {
+	if c.deadLetters.path == "" {
+		return nil, nil
+	}
+	c.deadLetters.mu.Lock()
+	defer c.deadLetters.mu.Unlock()
+	return ReadDeadLetters(c.deadLetters.path)
+}
+
+// PurgeDeadLetters empties the dead-letter file
+func (c *Client) PurgeDeadLetters() error {
+	if c.deadLetters.path == "" {
+		return nil
+	}
+	c.deadLetters.mu.Lock()
+	defer c.deadLetters.mu.Unlock()
+	if err := os.Remove(c.deadLetters.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to purge dead letters: %w", err)
+	}
+	return nil
+}
+
+// ReadDeadLetters reads a dead-letter file. A missing file holds no events.
+func ReadDeadLetters(path string) ([]DeadLetter, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open dead letters: %w", err)
+	}
+	defer f.Close()
+
+	var out []DeadLetter
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64<<20)
+	for n := 1; scanner.Scan(); n++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var d DeadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &d); err != nil {
+			return out, fmt.Errorf("failed to decode dead letter %d: %w", n, err)
+		}
+		out = append(out, d)
+	}
+	if err := scanner.Err(); err != nil {
+		return out, fmt.Errorf("failed to read dead letters: %w", err)
+	}
+	return out, nil
+}
+
+// resolveDeadLetters applies TRUSERA_DEAD_LETTER_FILE if no file was set
+func (c *Client) resolveDeadLetters() {
+	if c.deadLetters.path == "" {
+		c.deadLetters.path = os.Getenv("TRUSERA_DEAD_LETTER_FILE")
+	}
+}
+
+// isolateRejected finds the events of a rejected batch at fault by sending
+// its halves again, dead-lettering single events that are still rejected.
+// It returns how many leading events were delivered or dead-lettered before
+// a send failed for another reason, with that error.
+func (c *Client) isolateRejected(ctx context.Context, events []Event, cause error) (int, error) {
+	if len(events) == 1 {
+		c.deadLetter(events[0], cause)
+		return 1, nil
+	}
+	mid := len(events) / 2
+	for start, end := 0, mid; start < len(events); start, end = end, len(events) {
+		err := c.sendEvents(ctx, events[start:end])
+		if errors.Is(err, ErrRejected) {
+			n, err := c.isolateRejected(ctx, events[start:end], err)
+			if err != nil {
+				return start + n, err
+			}
+		} else if err != nil {
+			return start, err
+		}
+	}
+	return len(events), nil
+}
+
+// deadLetter records an event rejected with cause, or drops it if there is
+// no dead-letter file
+func (c *Client) deadLetter(e Event, cause error) {
+	c.mu.Lock()
+	agentID := c.agentID
+	c.mu.Unlock()
+
+	d := DeadLetter{Event: e, AgentID: agentID, Reason: cause.Error(), RejectedAt: time.Now().UTC()}
+	var apiErr *APIError
+	if errors.As(cause, &apiErr) {
+		d.StatusCode, d.RequestID, d.Reason = apiErr.StatusCode, apiErr.RequestID, apiErr.Body
+		if d.Reason == "" {
+			d.Reason = apiErr.Error()
+		}
+	}
+
+	err := c.deadLetters.write(d)
+	c.mu.Lock()
+	if err == nil {
+		c.stats.deadLettered++
+	} else {
+		c.stats.dropped++
+	}
+	c.mu.Unlock()
+
+	switch {
+	case err == nil:
+		c.logf(LogWarn, "%s event %q rejected by the API, written to %s: %v", e.Type, e.Name, c.deadLetters.path, cause)
+	case c.deadLetters.path == "":
+		c.logf(LogWarn, "dropping %s event %q rejected by the API: %v", e.Type, e.Name, cause)
+	default:
+		c.logf(LogWarn, "dropping %s event %q rejected by the API: %v (dead-letter write failed: %v)", e.Type, e.Name, cause, err)
+	}
+}
+
+// write appends d to the file
+func (d *deadLetters) write(letter DeadLetter) error {
+	if d.path == "" {
+		return errors.New("no dead-letter file")
+	}
+	line, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	f, err := os.OpenFile(d.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,99 @@
+package trusera
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// rejectingServer answers 422 to any batch holding an event named "bad" and
+// records the events of the batches it accepts
+func rejectingServer(t *testing.T) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var accepted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch Batch
+		json.NewDecoder(r.Body).Decode(&batch)
+		for _, e := range batch.Events {
+			if e.Name == "bad" {
+				w.Header().Set("X-Request-Id", "req-1")
+				http.Error(w, "schema error: payload.size must be a number", http.StatusUnprocessableEntity)
+				return
+			}
+		}
+		mu.Lock()
+		for _, e := range batch.Events {
+			accepted = append(accepted, e.Name)
+		}
+		mu.Unlock()
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), accepted...)
+	}
+}
+
+func TestRejectedEventsAreDeadLettered(t *testing.T) {
+	server, accepted := rejectingServer(t)
+	t.Setenv("TRUSERA_API_URL", server.URL)
+	path := filepath.Join(t.TempDir(), "dlq.jsonl")
+	client := NewClient("k", WithDeadLetterFile(path), WithFlushInterval(time.Hour))
+	defer client.Close()
+
+	for _, name := range []string{"a", "b", "bad", "c", "d"} {
+		client.Track(NewEvent(EventToolCall, name))
+	}
+	if err := client.Flush(); !errors.Is(err, ErrRejected) {
+		t.Fatalf("expected ErrRejected, got %v", err)
+	}
+
+	if got := accepted(); len(got) != 4 {
+		t.Errorf("expected the 4 valid events delivered, got %v", got)
+	}
+	letters, err := client.DeadLetters()
+	if err != nil {
+		t.Fatalf("DeadLetters failed: %v", err)
+	}
+	if len(letters) != 1 {
+		t.Fatalf("expected 1 dead letter, got %d", len(letters))
+	}
+	d := letters[0]
+	if d.Event.Name != "bad" || d.StatusCode != 422 || d.RequestID != "req-1" || d.Reason != "schema error: payload.size must be a number" {
+		t.Errorf("unexpected dead letter: %+v", d)
+	}
+	if stats := client.Stats(); stats.DeadLettered != 1 || stats.Queued != 0 {
+		t.Errorf("expected 1 dead-lettered and none queued, got %+v", stats)
+	}
+
+	if err := client.PurgeDeadLetters(); err != nil {
+		t.Fatalf("PurgeDeadLetters failed: %v", err)
+	}
+	if letters, _ := client.DeadLetters(); len(letters) != 0 {
+		t.Errorf("expected no dead letters after purging, got %d", len(letters))
+	}
+}
+
+func TestRejectedEventsAreNotRetried(t *testing.T) {
+	server, _ := rejectingServer(t)
+	t.Setenv("TRUSERA_API_URL", server.URL)
+	client := NewClient("k", WithCircuitBreaker(3, time.Minute), WithFlushInterval(time.Hour))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "bad"))
+	client.Flush()
+
+	stats := client.Stats()
+	if stats.Queued != 0 || stats.Dropped != 1 {
+		t.Errorf("expected the rejected event dropped rather than kept, got %d queued and %d dropped", stats.Queued, stats.Dropped)
+	}
+	if client.BreakerState() != BreakerClosed {
+		t.Errorf("expected rejections not to trip the breaker, got %v", client.BreakerState())
+	}
+}
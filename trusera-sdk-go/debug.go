@@ -0,0 +1,126 @@
+package trusera
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// debugBodyLimit is how much of each response body debug mode logs
+const debugBodyLimit = 512
+
+// WithDebug logs every API request the client makes, with its method, URL,
+// request size, duration, status and the start of the response body, at
+// LogDebug. It lowers the log level to LogDebug. Setting TRUSERA_DEBUG=1
+// does the same.
+func WithDebug() Option {
+	return func(c *Client) {
+		c.debug = true
+	}
+}
+
+// installDebugTransport wraps the client's HTTP transport with request
+// tracing if debug mode is on
+func (c *Client) installDebugTransport() {
+	if env := strings.ToLower(os.Getenv("TRUSERA_DEBUG")); env == "1" || env == "true" {
+		c.debug = true
+	}
+	if !c.debug {
+		return
+	}
+	if LogLevel(c.logLevel.Load()) > LogDebug {
+		c.logLevel.Store(int32(LogDebug))
+	}
+	base := c.httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	hc := *c.httpClient
+	hc.Transport = &debugTransport{base: base, logf: c.logf}
+	c.httpClient = &hc
+}
+
+// debugTransport logs each round trip once its response body is closed
+type debugTransport struct {
+	base http.RoundTripper
+	logf func(level LogLevel, format string, args ...any)
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var sent *countingReader
+	if req.Body != nil && req.ContentLength < 0 {
+		sent = &countingReader{r: req.Body}
+		req = req.Clone(req.Context())
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{sent, req.Body}
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	elapsed := time.Since(start)
+
+	size := req.ContentLength
+	if sent != nil {
+		size = sent.n.Load()
+	}
+	if err != nil {
+		t.logf(LogDebug, "%s %s (%d bytes) failed after %v: %v", req.Method, req.URL.Redacted(), size, elapsed.Round(time.Millisecond), err)
+		return resp, err
+	}
+	resp.Body = &debugBody{ReadCloser: resp.Body, done: func(body []byte, more bool) {
+		if sent != nil {
+			size = sent.n.Load()
+		}
+		suffix := ""
+		if more {
+			suffix = "..."
+		}
+		t.logf(LogDebug, "%s %s (%d bytes) -> %s in %v: %q%s", req.Method, req.URL.Redacted(), size, resp.Status, elapsed.Round(time.Millisecond), body, suffix)
+	}}
+	return resp, nil
+}
+
+// countingReader counts the bytes of a streamed request body
+type countingReader struct {
+	r io.Reader
+	n atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// debugBody keeps the start of a response body as it is read and reports it
+// when the body is closed
+type debugBody struct {
+	io.ReadCloser
+	head []byte
+	more bool
+	once sync.Once
+	done func(head []byte, more bool)
+}
+
+func (b *debugBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := debugBodyLimit - len(b.head); room > 0 {
+		b.head = append(b.head, p[:min(n, room)]...)
+		b.more = b.more || n > room
+	} else if n > 0 {
+		b.more = true
+	}
+	return n, err
+}
+
+func (b *debugBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.done(b.head, b.more) })
+	return err
+}
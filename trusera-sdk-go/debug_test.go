@@ -0,0 +1,80 @@
+package trusera
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDebugLogsRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"accepted":1,"detail":"` + strings.Repeat("x", 600) + `"}`))
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var lines []string
+	client := NewClient("k", WithBaseURL(server.URL), WithDebug(), WithFlushInterval(time.Hour),
+		WithLogger(LoggerFunc(func(level LogLevel, msg string) {
+			mu.Lock()
+			defer mu.Unlock()
+			if level == LogDebug && strings.HasPrefix(msg, "POST ") {
+				lines = append(lines, msg)
+			}
+		})))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "search"))
+	if err := client.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 traced request, got %q", lines)
+	}
+	line := lines[0]
+	for _, want := range []string{"POST " + server.URL + "/v1/events (", " bytes) -> 202 Accepted in ", `{\"accepted\":1,`, `xxx"...`} {
+		if !strings.Contains(line, want) {
+			t.Errorf("expected %q in %q", want, line)
+		}
+	}
+	if strings.Count(line, "x") > debugBodyLimit {
+		t.Errorf("expected the response body truncated to %d bytes, got %q", debugBodyLimit, line)
+	}
+}
+
+func TestDebugFromEnvironment(t *testing.T) {
+	t.Setenv("TRUSERA_DEBUG", "1")
+	client := NewClient("k", WithLogLevel(LogWarn))
+	defer client.Close()
+
+	if _, ok := client.httpClient.Transport.(*debugTransport); !ok {
+		t.Errorf("expected requests to be traced, got %T", client.httpClient.Transport)
+	}
+	if level := LogLevel(client.logLevel.Load()); level != LogDebug {
+		t.Errorf("expected debug mode to lower the log level, got %s", level)
+	}
+}
+
+func TestDebugLogsFailedRequests(t *testing.T) {
+	var got string
+	client := NewClient("k", WithBaseURL("http://127.0.0.1:1"), WithDebug(), WithFlushInterval(time.Hour),
+		WithLogger(LoggerFunc(func(level LogLevel, msg string) {
+			if strings.HasPrefix(msg, "POST ") {
+				got = msg
+			}
+		})))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "search"))
+	client.Flush()
+	if !strings.Contains(got, "failed after") {
+		t.Errorf("expected the failed request traced, got %q", got)
+	}
+}
@@ -0,0 +1,301 @@
+// Package discovery builds an inventory of the AI models a process uses by
+// scanning its environment variables, configuration files, the HuggingFace
+// cache and GGUF model files, and reports it to the Trusera fleet API.
+package discovery
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/bom"
+)
+
+// Sources a model can be discovered from
+const (
+	SourceEnv         = "env"
+	SourceConfig      = "config"
+	SourceHuggingFace = "huggingface_cache"
+	SourceGGUF        = "gguf"
+	SourceServer      = "inference_server" // Listed by a local server, see the localllm package
+)
+
+// DefaultEnvVars are the environment variables inspected for model names,
+// mapped to the provider they imply
+var DefaultEnvVars = map[string]string{
+	"OPENAI_MODEL":            "openai",
+	"AZURE_OPENAI_DEPLOYMENT": "azure-openai",
+	"ANTHROPIC_MODEL":         "anthropic",
+	"GEMINI_MODEL":            "google",
+	"MISTRAL_MODEL":           "mistral",
+	"COHERE_MODEL":            "cohere",
+	"OLLAMA_MODEL":            "ollama",
+	"LLM_MODEL":               "",
+	"MODEL_NAME":              "",
+}
+
+// ggufMagic starts every GGUF file
+var ggufMagic = []byte("GGUF")
+
+// configModelLine matches `model: x`, `"model": "x"` and `MODEL=x` style
+// settings in YAML, JSON, TOML and dotenv files
+var configModelLine = regexp.MustCompile(`(?i)^\s*"?([\w.-]*model[\w.-]*)"?\s*[:=]\s*["']?([^"',#\s]+)`)
+
+// Model is one discovered model
+type Model struct {
+	Name         string `json:"name"`
+	Provider     string `json:"provider,omitempty"`
+	Source       string `json:"source"`
+	Location     string `json:"location,omitempty"` // Env var, config file or model path
+	Format       string `json:"format,omitempty"`
+	SizeBytes    int64  `json:"size_bytes,omitempty"`
+	Quantization string `json:"quantization,omitempty"` // Such as Q4_K_M, when the source reports it
+}
+
+// Inventory is the result of a scan
+type Inventory struct {
+	Models    []Model   `json:"models"`
+	ScannedAt time.Time `json:"scanned_at"`
+}
+
+// Options selects what Scan inspects. The zero value scans the default
+// environment variables, the HuggingFace cache and GGUF files the process
+// has open.
+type Options struct {
+	EnvVars     map[string]string // Defaults to DefaultEnvVars
+	ConfigFiles []string          // Files searched for model settings
+	HFCacheDir  string            // Defaults to HF_HUB_CACHE, HF_HOME/hub or ~/.cache/huggingface/hub
+	ModelDirs   []string          // Directories searched recursively for GGUF files
+	SkipHFCache bool
+	SkipOpen    bool // Skip inspecting files the process has open or mapped (Linux only)
+}
+
+// Scan builds a model inventory. Unreadable sources are skipped.
+func Scan(opts Options) *Inventory {
+	s := &scanner{seen: map[string]bool{}}
+
+	envVars := opts.EnvVars
+	if envVars == nil {
+		envVars = DefaultEnvVars
+	}
+	s.scanEnv(envVars)
+	for _, path := range opts.ConfigFiles {
+		s.scanConfig(path)
+	}
+	if !opts.SkipHFCache {
+		s.scanHFCache(hfCacheDir(opts.HFCacheDir))
+	}
+	for _, dir := range opts.ModelDirs {
+		s.scanModelDir(dir)
+	}
+	if !opts.SkipOpen && runtime.GOOS == "linux" {
+		s.scanOpenFiles()
+	}
+
+	sort.Slice(s.models, func(i, j int) bool {
+		if s.models[i].Source != s.models[j].Source {
+			return s.models[i].Source < s.models[j].Source
+		}
+		return s.models[i].Name < s.models[j].Name
+	})
+	return &Inventory{Models: s.models, ScannedAt: time.Now().UTC()}
+}
+
+// AddToBOM adds every discovered model to an AI-BOM
+func (inv *Inventory) AddToBOM(b *bom.Builder) {
+	for _, m := range inv.Models {
+		props := map[string]string{"trusera:discovery_source": m.Source}
+		if m.Location != "" {
+			props["trusera:location"] = m.Location
+		}
+		if m.Format != "" {
+			props["trusera:format"] = m.Format
+		}
+		b.AddModel(bom.Model{Name: m.Name, Provider: m.Provider, Properties: props})
+	}
+}
+
+// Reporter sends an inventory to the fleet API; *trusera.Client satisfies it
+type Reporter interface {
+	ReportInventory(ctx context.Context, inventory any) error
+}
+
+var _ Reporter = (*trusera.Client)(nil)
+
+// Report sends the inventory to /api/v1/fleet/{id}/inventory
+func Report(ctx context.Context, r Reporter, inv *Inventory) error {
+	return r.ReportInventory(ctx, inv)
+}
+
+// scanner accumulates models, ignoring duplicates
+type scanner struct {
+	models []Model
+	seen   map[string]bool
+}
+
+func (s *scanner) add(m Model) {
+	key := m.Source + "|" + m.Provider + "|" + m.Name + "|" + m.Location
+	if m.Name == "" || s.seen[key] {
+		return
+	}
+	s.seen[key] = true
+	s.models = append(s.models, m)
+}
+
+func (s *scanner) scanEnv(vars map[string]string) {
+	for name, provider := range vars {
+		if v := strings.TrimSpace(os.Getenv(name)); v != "" {
+			s.add(Model{Name: v, Provider: provider, Source: SourceEnv, Location: name})
+		}
+	}
+}
+
+func (s *scanner) scanConfig(path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	lines := bufio.NewScanner(f)
+	for lines.Scan() {
+		m := configModelLine.FindStringSubmatch(lines.Text())
+		if m == nil || !looksLikeModelName(m[2]) {
+			continue
+		}
+		s.add(Model{Name: m[2], Provider: providerFromKey(m[1]), Source: SourceConfig, Location: path})
+	}
+}
+
+// looksLikeModelName rejects numbers, booleans and nulls such as max_model_len: 4096
+func looksLikeModelName(v string) bool {
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return false
+	}
+	switch strings.ToLower(v) {
+	case "true", "false", "null", "none", "{", "[":
+		return false
+	}
+	return true
+}
+
+// providerFromKey guesses a provider from a setting name like openai_model
+func providerFromKey(key string) string {
+	key = strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+	for name, provider := range DefaultEnvVars {
+		if provider != "" && strings.HasPrefix(key, strings.SplitN(name, "_", 2)[0]+"_") {
+			return provider
+		}
+	}
+	return ""
+}
+
+// hfCacheDir resolves the HuggingFace hub cache location
+func hfCacheDir(dir string) string {
+	if dir != "" {
+		return dir
+	}
+	if v := os.Getenv("HF_HUB_CACHE"); v != "" {
+		return v
+	}
+	if v := os.Getenv("HF_HOME"); v != "" {
+		return filepath.Join(v, "hub")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".cache", "huggingface", "hub")
+}
+
+// scanHFCache lists cached repositories, stored as models--{org}--{name}
+func (s *scanner) scanHFCache(dir string) {
+	if dir == "" {
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		name, ok := strings.CutPrefix(e.Name(), "models--")
+		if !e.IsDir() || !ok {
+			continue
+		}
+		s.add(Model{
+			Name:     strings.ReplaceAll(name, "--", "/"),
+			Provider: "huggingface",
+			Source:   SourceHuggingFace,
+			Location: filepath.Join(dir, e.Name()),
+		})
+	}
+}
+
+func (s *scanner) scanModelDir(dir string) {
+	_ = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".gguf") {
+			s.addGGUF(path)
+		}
+		return nil
+	})
+}
+
+// scanOpenFiles finds GGUF files the process has open or memory-mapped, as
+// llama.cpp based runtimes do
+func (s *scanner) scanOpenFiles() {
+	if fds, err := os.ReadDir("/proc/self/fd"); err == nil {
+		for _, fd := range fds {
+			if target, err := os.Readlink(filepath.Join("/proc/self/fd", fd.Name())); err == nil {
+				s.addGGUFIfNamed(target)
+			}
+		}
+	}
+	if maps, err := os.ReadFile("/proc/self/maps"); err == nil {
+		for _, line := range bytes.Split(maps, []byte("\n")) {
+			if fields := strings.Fields(string(line)); len(fields) >= 6 {
+				s.addGGUFIfNamed(fields[5])
+			}
+		}
+	}
+}
+
+func (s *scanner) addGGUFIfNamed(path string) {
+	if strings.EqualFold(filepath.Ext(path), ".gguf") {
+		s.addGGUF(path)
+	}
+}
+
+// addGGUF records path if it really is a GGUF file
+func (s *scanner) addGGUF(path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	magic := make([]byte, len(ggufMagic))
+	if _, err := io.ReadFull(f, magic); err != nil || !bytes.Equal(magic, ggufMagic) {
+		return
+	}
+	var size int64
+	if info, err := f.Stat(); err == nil {
+		size = info.Size()
+	}
+	s.add(Model{
+		Name:      strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		Source:    SourceGGUF,
+		Location:  path,
+		Format:    "gguf",
+		SizeBytes: size,
+	})
+}
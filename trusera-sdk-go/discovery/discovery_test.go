@@ -0,0 +1,137 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/bom"
+)
+
+// find returns the first model from source, or nil
+func find(inv *Inventory, source, name string) *Model {
+	for i, m := range inv.Models {
+		if m.Source == source && m.Name == name {
+			return &inv.Models[i]
+		}
+	}
+	return nil
+}
+
+func TestScanEnv(t *testing.T) {
+	t.Setenv("OPENAI_MODEL", "gpt-4o")
+
+	inv := Scan(Options{SkipHFCache: true, SkipOpen: true})
+
+	m := find(inv, SourceEnv, "gpt-4o")
+	if m == nil {
+		t.Fatalf("expected gpt-4o from env, got %+v", inv.Models)
+	}
+	if m.Provider != "openai" || m.Location != "OPENAI_MODEL" {
+		t.Errorf("expected openai via OPENAI_MODEL, got %+v", m)
+	}
+}
+
+func TestScanConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "agent.yaml")
+	config := "anthropic_model: claude-sonnet-4\nmax_model_len: 4096\nname: agent\n"
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	jsonPath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(jsonPath, []byte("{\n  \"model\": \"mistral-large\"\n}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	inv := Scan(Options{EnvVars: map[string]string{}, ConfigFiles: []string{path, jsonPath}, SkipHFCache: true, SkipOpen: true})
+
+	if len(inv.Models) != 2 {
+		t.Fatalf("expected 2 models, got %+v", inv.Models)
+	}
+	if m := find(inv, SourceConfig, "claude-sonnet-4"); m == nil || m.Provider != "anthropic" {
+		t.Errorf("expected anthropic model from yaml, got %+v", inv.Models)
+	}
+	if find(inv, SourceConfig, "mistral-large") == nil {
+		t.Errorf("expected model from json, got %+v", inv.Models)
+	}
+}
+
+func TestScanHFCacheAndGGUF(t *testing.T) {
+	cache := t.TempDir()
+	if err := os.Mkdir(filepath.Join(cache, "models--meta-llama--Llama-3.1-8B"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(cache, "datasets--squad"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	models := t.TempDir()
+	if err := os.WriteFile(filepath.Join(models, "phi-3.Q4.gguf"), []byte("GGUF\x03\x00\x00\x00"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(models, "fake.gguf"), []byte("not a model"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	inv := Scan(Options{EnvVars: map[string]string{}, HFCacheDir: cache, ModelDirs: []string{models}, SkipOpen: true})
+
+	if len(inv.Models) != 2 {
+		t.Fatalf("expected 2 models, got %+v", inv.Models)
+	}
+	if m := find(inv, SourceHuggingFace, "meta-llama/Llama-3.1-8B"); m == nil || m.Provider != "huggingface" {
+		t.Errorf("expected HuggingFace repo, got %+v", inv.Models)
+	}
+	if m := find(inv, SourceGGUF, "phi-3.Q4"); m == nil || m.Format != "gguf" || m.SizeBytes != 8 {
+		t.Errorf("expected GGUF model, got %+v", inv.Models)
+	}
+}
+
+func TestAddToBOM(t *testing.T) {
+	inv := &Inventory{Models: []Model{{Name: "gpt-4o", Provider: "openai", Source: SourceEnv, Location: "OPENAI_MODEL"}}}
+	b := bom.NewBuilder("agent")
+	inv.AddToBOM(b)
+
+	built := b.Build()
+	if len(built.Models) != 1 || built.Models[0].Properties["trusera:discovery_source"] != "env" {
+		t.Errorf("expected discovered model in BOM, got %+v", built.Models)
+	}
+}
+
+func TestReport(t *testing.T) {
+	var got Inventory
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/fleet/register":
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"id": "fleet-1"}})
+		case "/api/v1/fleet/fleet-1/inventory":
+			_ = json.NewDecoder(r.Body).Decode(&got)
+		}
+	}))
+	defer server.Close()
+
+	client := trusera.NewClient("test-key", trusera.WithBaseURL(server.URL), trusera.WithAutoRegister())
+	defer client.Close()
+
+	inv := &Inventory{Models: []Model{{Name: "gpt-4o", Source: SourceEnv}}}
+	if err := Report(context.Background(), client, inv); err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+	if len(got.Models) != 1 || got.Models[0].Name != "gpt-4o" {
+		t.Errorf("expected inventory posted, got %+v", got)
+	}
+}
+
+func TestReportRequiresRegistration(t *testing.T) {
+	client := trusera.NewClient("test-key")
+	defer client.Close()
+
+	if err := Report(context.Background(), client, &Inventory{}); err != trusera.ErrNotRegistered {
+		t.Errorf("expected ErrNotRegistered, got %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package trusera
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// ErrEgressBlocked is returned by intercepted HTTP clients for requests to
+// hosts outside the egress allow-list in ModeBlock
+var ErrEgressBlocked = errors.New("trusera: egress blocked")
+
+// EgressPolicy restricts the hosts that intercepted HTTP clients, such as
+// those tools make their calls with, may reach. It can be set locally with
+// WithEgressPolicy or delivered in RemoteConfig.
+type EgressPolicy struct {
+	// Mode is ModeBlock (the default) to reject requests to other hosts, or
+	// ModeWarn or ModeLog to only record them
+	Mode EnforcementMode `json:"mode,omitempty"`
+	// AllowedHosts are host names ("api.github.com"), wildcards matching
+	// subdomains ("*.googleapis.com"), IP addresses or CIDR ranges
+	// ("10.0.0.0/8"). The Trusera API is always allowed.
+	AllowedHosts []string `json:"allowed_hosts"`
+}
+
+// egressRules is the compiled form of an EgressPolicy
+type egressRules struct {
+	policy   EgressPolicy
+	hosts    map[string]bool
+	suffixes []string // From wildcards, with the leading dot
+	nets     []*net.IPNet
+}
+
+// WithEgressPolicy enforces p on the requests of HTTP clients intercepted
+// with WrapHTTPClient, CreateInterceptedClient or InterceptDefault, turning
+// them from monitors into enforcement points. Requests to other hosts are
+// reported as egress_allow_list guardrail violations. An egress policy in
+// RemoteConfig replaces it.
+func WithEgressPolicy(p EgressPolicy) Option {
+	return func(c *Client) {
+		c.egress = compileEgress(p)
+	}
+}
+
+// EgressPolicy returns the egress policy in effect, remote or local
+func (c *Client) EgressPolicy() (EgressPolicy, bool) {
+	rules := c.egressRules()
+	if rules == nil {
+		return EgressPolicy{}, false
+	}
+	return rules.policy, true
+}
+
+// egressRules returns the remote egress rules if any, else the local ones
+func (c *Client) egressRules() *egressRules {
+	if rs := c.remote.Load(); rs != nil && rs.egress != nil {
+		return rs.egress
+	}
+	return c.egress
+}
+
+// compileEgress compiles p, defaulting its mode to ModeBlock
+func compileEgress(p EgressPolicy) *egressRules {
+	if p.Mode == "" {
+		p.Mode = ModeBlock
+	}
+	r := &egressRules{policy: p, hosts: map[string]bool{}}
+	for _, h := range p.AllowedHosts {
+		h = strings.ToLower(strings.TrimSpace(h))
+		switch {
+		case h == "":
+		case strings.HasPrefix(h, "*."):
+			r.suffixes = append(r.suffixes, h[1:])
+		case strings.Contains(h, "/"):
+			if _, n, err := net.ParseCIDR(h); err == nil {
+				r.nets = append(r.nets, n)
+			}
+		default:
+			r.hosts[strings.Trim(h, "[]")] = true
+		}
+	}
+	return r
+}
+
+// allows reports whether host, without a port, is on the allow-list
+func (r *egressRules) allows(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if r.hosts[host] {
+		return true
+	}
+	for _, s := range r.suffixes {
+		if strings.HasSuffix(host, s) {
+			return true
+		}
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		for _, n := range r.nets {
+			if n.Contains(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkEgress returns the egress rules that deny a request to u, or nil if
+// it is allowed or no policy is in effect. Requests to the client's own API
+// are always allowed.
+func (c *Client) checkEgress(u *url.URL) *egressRules {
+	rules := c.egressRules()
+	if rules == nil || rules.allows(u.Hostname()) {
+		return nil
+	}
+	if api, err := url.Parse(c.baseURL); err == nil && strings.EqualFold(api.Hostname(), u.Hostname()) {
+		return nil
+	}
+	return rules
+}
+
+// egressViolation builds the guardrail violation reported for a denied request
+func egressViolation(u *url.URL, mode EnforcementMode) Event {
+	action := GuardrailLogged
+	switch mode {
+	case ModeBlock:
+		action = GuardrailBlocked
+	case ModeWarn:
+		action = GuardrailWarned
+	}
+	e, _ := GuardrailEvent{
+		Policy:   "egress_allow_list",
+		Severity: SeverityHigh,
+		Action:   action,
+		Source:   "trusera",
+		Message:  fmt.Sprintf("request to %s is outside the egress allow-list", u.Hostname()),
+		Metadata: map[string]any{"host": u.Hostname()},
+	}.event()
+	return e
+}
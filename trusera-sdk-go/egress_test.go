@@ -0,0 +1,157 @@
+package trusera
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestEgressRulesAllows(t *testing.T) {
+	rules := compileEgress(EgressPolicy{AllowedHosts: []string{"api.github.com", "*.googleapis.com", "10.0.0.0/8", "::1"}})
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"api.github.com", true},
+		{"API.GitHub.com.", true},
+		{"github.com", false},
+		{"storage.googleapis.com", true},
+		{"googleapis.com", false},
+		{"evilgoogleapis.com", false},
+		{"10.1.2.3", true},
+		{"192.168.0.1", false},
+		{"::1", true},
+	}
+	for _, tt := range tests {
+		if got := rules.allows(tt.host); got != tt.want {
+			t.Errorf("allows(%q): expected %v, got %v", tt.host, tt.want, got)
+		}
+	}
+	if rules.policy.Mode != ModeBlock {
+		t.Errorf("expected block mode by default, got %q", rules.policy.Mode)
+	}
+}
+
+func TestEgressBlocksUnlistedHosts(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("backend should not be called for a denied host")
+	}))
+	defer backend.Close()
+
+	client := NewClient("test-key", WithBatchSize(1000), WithEgressPolicy(EgressPolicy{AllowedHosts: []string{"api.github.com"}}))
+	defer client.Close()
+	httpClient := WrapHTTPClient(&http.Client{}, client, InterceptorOptions{
+		Enforcement:     ModeLog,
+		ExcludePatterns: []string{backend.URL},
+	})
+
+	_, err := httpClient.Get(backend.URL + "/exfil")
+	if !errors.Is(err, ErrEgressBlocked) {
+		t.Fatalf("expected ErrEgressBlocked, got %v", err)
+	}
+
+	events := queuedEvents(client)
+	if len(events) != 2 {
+		t.Fatalf("expected a violation and a blocked call, got %v", events)
+	}
+	if v := events[0]; v.Type != EventGuardrailViolation || v.Name != "egress_allow_list" || v.Payload["action"] != "blocked" ||
+		v.Metadata["host"] != "127.0.0.1" {
+		t.Errorf("unexpected violation: %+v", v)
+	}
+	if c := events[1]; c.Type != EventAPICall || c.Payload["blocked"] != true || c.Payload["egress_denied"] != "127.0.0.1" {
+		t.Errorf("unexpected blocked call event: %+v", c)
+	}
+}
+
+func TestEgressWarnModeAllows(t *testing.T) {
+	called := false
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer backend.Close()
+
+	client := NewClient("test-key", WithBatchSize(1000), WithEgressPolicy(EgressPolicy{Mode: ModeWarn}))
+	defer client.Close()
+	httpClient := WrapHTTPClient(&http.Client{}, client.ForAgent("researcher"), InterceptorOptions{Enforcement: ModeLog})
+
+	resp, err := httpClient.Get(backend.URL)
+	if err != nil {
+		t.Fatalf("expected the request allowed in warn mode, got %v", err)
+	}
+	resp.Body.Close()
+	if !called {
+		t.Error("expected the backend called")
+	}
+
+	v := queuedEvents(client)[0]
+	if v.Type != EventGuardrailViolation || v.Payload["action"] != "warned" || v.Metadata["agent_id"] != "researcher" {
+		t.Errorf("expected a warned violation tagged with the agent, got %+v", v)
+	}
+}
+
+func TestEgressAllowsTruseraAPI(t *testing.T) {
+	client := NewClient("test-key", WithBaseURL("https://api.trusera.io"), WithEgressPolicy(EgressPolicy{}))
+	defer client.Close()
+
+	if rules := client.checkEgress(&url.URL{Scheme: "https", Host: "api.trusera.io"}); rules != nil {
+		t.Error("expected the Trusera API always allowed")
+	}
+	if rules := client.checkEgress(&url.URL{Scheme: "https", Host: "example.com"}); rules == nil {
+		t.Error("expected other hosts denied by an empty allow-list")
+	}
+}
+
+func TestEgressPolicyFromRemoteConfig(t *testing.T) {
+	client := NewClient("test-key", WithEgressPolicy(EgressPolicy{AllowedHosts: []string{"example.com"}}))
+	defer client.Close()
+
+	if err := client.applyRemoteConfig(RemoteConfig{Version: "v2", Egress: &EgressPolicy{
+		Mode: ModeLog, AllowedHosts: []string{"*.internal"},
+	}}); err != nil {
+		t.Fatalf("applyRemoteConfig failed: %v", err)
+	}
+
+	p, ok := client.EgressPolicy()
+	if !ok || p.Mode != ModeLog || len(p.AllowedHosts) != 1 {
+		t.Errorf("expected the remote policy in effect, got %+v", p)
+	}
+	if client.checkEgress(&url.URL{Host: "example.com"}) == nil {
+		t.Error("expected the remote allow-list to replace the local one")
+	}
+	if client.checkEgress(&url.URL{Host: "db.internal:5432"}) != nil {
+		t.Error("expected the remote allow-list applied")
+	}
+}
+
+func TestEgressWithoutPolicy(t *testing.T) {
+	client := NewClient("test-key")
+	defer client.Close()
+
+	if _, ok := client.EgressPolicy(); ok {
+		t.Error("expected no egress policy by default")
+	}
+	if client.checkEgress(&url.URL{Host: "anywhere.example"}) != nil {
+		t.Error("expected every host allowed without a policy")
+	}
+}
+
+// embeddedClient is a tracker embedding a Client, like truseratest.RecordingClient
+type embeddedClient struct {
+	*Client
+}
+
+func TestClientOf(t *testing.T) {
+	client := NewClient("test-key")
+	defer client.Close()
+
+	for _, tr := range []Tracker{client, client.ForAgent("a"), embeddedClient{client}} {
+		if clientOf(tr) != client {
+			t.Errorf("expected the client behind %T", tr)
+		}
+	}
+	if clientOf(&fakeTracker{}) != nil {
+		t.Error("expected no client behind other trackers")
+	}
+}
@@ -0,0 +1,193 @@
+package trusera
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// defaultFailbackInterval is how often the primary endpoint is probed while
+// the client uses a fallback
+const defaultFailbackInterval = 30 * time.Second
+
+// WithEndpoints sets the primary Trusera API base URL and fallbacks, such as
+// the deployments of another region, tried in order when the active one is
+// unreachable or answers 502, 503 or 504. While a fallback is active the
+// primary is probed with GET /health, and once it answers with anything but
+// a 5xx status requests go back to it. Failover covers every API call,
+// including event batches, heartbeats and BOM uploads.
+func WithEndpoints(primary string, fallbacks ...string) Option {
+	return func(c *Client) {
+		c.baseURL = primary
+		c.fallbackURLs = append([]string(nil), fallbacks...)
+	}
+}
+
+// WithFailbackInterval sets how often the primary endpoint is probed while a
+// fallback is active, 30 seconds by default
+func WithFailbackInterval(d time.Duration) Option {
+	return func(c *Client) {
+		c.failbackInterval = d
+	}
+}
+
+// ActiveEndpoint returns the base URL API calls currently go to: the primary
+// base URL, or the fallback in use after a failover
+func (c *Client) ActiveEndpoint() string {
+	if c.endpoints == nil {
+		return c.baseURL
+	}
+	return c.endpoints.raw[c.endpoints.active.Load()]
+}
+
+// endpoints are the base URLs of the API, primary first
+type endpoints struct {
+	raw    []string
+	urls   []*url.URL
+	active atomic.Int32      // Index of the endpoint in use
+	base   http.RoundTripper // Transport below failover, used for probes
+}
+
+// installFailoverTransport routes API calls to the active endpoint
+func (c *Client) installFailoverTransport() error {
+	if len(c.fallbackURLs) == 0 {
+		return nil
+	}
+	eps := &endpoints{}
+	for _, raw := range append([]string{c.baseURL}, c.fallbackURLs...) {
+		if err := c.validateBaseURL(raw); err != nil {
+			return err
+		}
+		u, err := url.Parse(strings.TrimSuffix(raw, "/"))
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("invalid endpoint %q", raw)
+		}
+		eps.raw = append(eps.raw, raw)
+		eps.urls = append(eps.urls, u)
+	}
+	if c.failbackInterval <= 0 {
+		c.failbackInterval = defaultFailbackInterval
+	}
+
+	base := c.httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	eps.base = base
+	c.endpoints = eps
+	hc := *c.httpClient
+	hc.Transport = &failoverTransport{base: base, eps: eps, logf: c.logf}
+	c.httpClient = &hc
+	return nil
+}
+
+// failoverTransport sends requests for the primary endpoint to the active
+// one, moving on to the next endpoint when it fails
+type failoverTransport struct {
+	base http.RoundTripper
+	eps  *endpoints
+	logf func(level LogLevel, format string, args ...any)
+}
+
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	primary := t.eps.urls[0]
+	if req.URL.Scheme != primary.Scheme || req.URL.Host != primary.Host || !strings.HasPrefix(req.URL.Path, primary.Path) {
+		return t.base.RoundTrip(req)
+	}
+
+	start := int(t.eps.active.Load())
+	var resp *http.Response
+	var err error
+	for n := 0; n < len(t.eps.urls); n++ {
+		if n > 0 && req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			return resp, err // The body was consumed and cannot be sent again
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+			resp.Body.Close()
+		}
+
+		i := (start + n) % len(t.eps.urls)
+		r := req.Clone(req.Context())
+		r.Host = ""
+		r.URL.Scheme = t.eps.urls[i].Scheme
+		r.URL.Host = t.eps.urls[i].Host
+		r.URL.Path = t.eps.urls[i].Path + strings.TrimPrefix(req.URL.Path, primary.Path)
+		r.URL.RawPath = ""
+		if n > 0 && req.GetBody != nil {
+			if r.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err = t.base.RoundTrip(r)
+		if !shouldFailover(req.Context(), resp, err) {
+			if i != start && t.eps.active.CompareAndSwap(int32(start), int32(i)) {
+				t.logf(LogWarn, "failed over from %s to %s", t.eps.raw[start], t.eps.raw[i])
+			}
+			return resp, err
+		}
+	}
+	return resp, err
+}
+
+// shouldFailover reports whether a request should be tried at the next
+// endpoint: the endpoint could not be reached or its gateway is down
+func shouldFailover(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		return ctx.Err() == nil
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// failbackLoop probes the primary endpoint while a fallback is active and
+// switches back once it is healthy
+func (c *Client) failbackLoop() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.failbackInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			active := c.endpoints.active.Load()
+			if active == 0 || !c.probePrimary() {
+				continue
+			}
+			if c.endpoints.active.CompareAndSwap(active, 0) {
+				c.logf(LogInfo, "primary endpoint %s is healthy again, failing back", c.endpoints.raw[0])
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// probePrimary reports whether the primary endpoint answers GET /health
+// without a server error
+func (c *Client) probePrimary() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.endpoints.raw[0], "/")+"/health", nil)
+	if err != nil {
+		return false
+	}
+	// Bypass the failover transport, which would route the probe to the
+	// active fallback
+	resp, err := c.endpoints.base.RoundTrip(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	return resp.StatusCode < 500
+}
@@ -0,0 +1,98 @@
+package trusera
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithEndpointsFailsOverAndBack(t *testing.T) {
+	var primaryDown atomic.Bool
+	primaryDown.Store(true)
+	var primaryEvents, fallbackEvents atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if primaryDown.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path == "/v1/events" {
+			primaryEvents.Add(1)
+		}
+	}))
+	defer primary.Close()
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path == "/eu/v1/events" && len(body) > 0 {
+			fallbackEvents.Add(1)
+		}
+	}))
+	defer fallback.Close()
+
+	client := NewClient("test-key",
+		WithEndpoints(primary.URL, fallback.URL+"/eu"),
+		WithFailbackInterval(10*time.Millisecond),
+	)
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "a"))
+	if err := client.Flush(); err != nil {
+		t.Fatalf("expected failover to deliver the batch, got %v", err)
+	}
+	if fallbackEvents.Load() != 1 || client.ActiveEndpoint() != fallback.URL+"/eu" {
+		t.Fatalf("expected batch sent to the fallback, got %d, active %s", fallbackEvents.Load(), client.ActiveEndpoint())
+	}
+
+	// The fallback stays active while the primary is down
+	client.Track(NewEvent(EventToolCall, "b"))
+	client.Flush()
+	if fallbackEvents.Load() != 2 {
+		t.Errorf("expected second batch on the fallback, got %d", fallbackEvents.Load())
+	}
+
+	primaryDown.Store(false)
+	deadline := time.Now().Add(time.Second)
+	for client.ActiveEndpoint() != primary.URL && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if client.ActiveEndpoint() != primary.URL {
+		t.Fatalf("expected fail-back to the primary, active %s", client.ActiveEndpoint())
+	}
+	client.Track(NewEvent(EventToolCall, "c"))
+	if err := client.Flush(); err != nil || primaryEvents.Load() != 1 {
+		t.Errorf("expected batch on the primary after fail-back, got %d (%v)", primaryEvents.Load(), err)
+	}
+}
+
+func TestWithEndpointsKeepsClientErrors(t *testing.T) {
+	var fallbackCalls atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer primary.Close()
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fallbackCalls.Add(1)
+	}))
+	defer fallback.Close()
+
+	client := NewClient("test-key", WithEndpoints(primary.URL, fallback.URL))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "a"))
+	if err := client.Flush(); err == nil {
+		t.Error("expected the primary's 401 to be returned")
+	}
+	if fallbackCalls.Load() != 0 || client.ActiveEndpoint() != primary.URL {
+		t.Errorf("expected no failover on a client error, got %d fallback calls", fallbackCalls.Load())
+	}
+}
+
+func TestActiveEndpointWithoutFallbacks(t *testing.T) {
+	client := NewClient("test-key", WithBaseURL("http://localhost:1"))
+	defer client.Close()
+	if client.ActiveEndpoint() != "http://localhost:1" {
+		t.Errorf("expected base URL, got %s", client.ActiveEndpoint())
+	}
+}
@@ -0,0 +1,113 @@
+package trusera
+
+import "context"
+
+// Enricher describes the environment an agent runs in, such as its
+// Kubernetes pod or cloud instance
+type Enricher interface {
+	// Name is the key attributes are reported under, e.g. "k8s"
+	Name() string
+	// Attributes returns the detected values, e.g. {"namespace": "prod"}
+	Attributes() map[string]string
+}
+
+// HeartbeatEnricher is an Enricher whose values change while the process
+// runs, such as GPU utilization. Fleet heartbeats report its
+// HeartbeatAttributes instead of the Attributes resolved when the client was
+// created, which are still used for registration and events.
+type HeartbeatEnricher interface {
+	Enricher
+	// HeartbeatAttributes returns the current values. ctx expires after 5 seconds.
+	HeartbeatAttributes(ctx context.Context) map[string]string
+}
+
+// enrichment is an enricher's attributes, resolved once when the client is created
+type enrichment struct {
+	name   string
+	attrs  map[string]string
+	events bool              // Also attach to event metadata
+	live   HeartbeatEnricher // Asked again for every heartbeat
+}
+
+// WithEnricher attaches an enricher's attributes to fleet registration and
+// heartbeats under its name, and to the metadata of every tracked event as
+// "<name>.<key>". Enrichers without attributes are ignored.
+func WithEnricher(e Enricher) Option {
+	return addEnricher(e, true)
+}
+
+// WithFleetEnricher is like WithEnricher but only attaches the attributes to
+// fleet registration and heartbeats, for values that rarely matter per event
+func WithFleetEnricher(e Enricher) Option {
+	return addEnricher(e, false)
+}
+
+func addEnricher(e Enricher, events bool) Option {
+	return func(c *Client) {
+		if e == nil {
+			return
+		}
+		attrs := e.Attributes()
+		if len(attrs) == 0 {
+			return
+		}
+		copied := make(map[string]string, len(attrs))
+		for k, v := range attrs {
+			copied[k] = v
+		}
+		live, _ := e.(HeartbeatEnricher)
+		c.enrichments = append(c.enrichments, enrichment{name: e.Name(), attrs: copied, events: events, live: live})
+		c.enrichEvents = c.enrichEvents || events
+	}
+}
+
+// enrich adds enricher attributes to a copy of the event's metadata. Values
+// already set on the event win.
+func (c *Client) enrich(e Event) Event {
+	if !c.enrichEvents {
+		return e
+	}
+	metadata := make(map[string]any, len(e.Metadata))
+	for _, en := range c.enrichments {
+		if !en.events {
+			continue
+		}
+		for k, v := range en.attrs {
+			metadata[en.name+"."+k] = v
+		}
+	}
+	for k, v := range e.Metadata {
+		metadata[k] = v
+	}
+	e.Metadata = metadata
+	return e
+}
+
+// addEnrichments adds enricher attributes to a fleet registration or
+// heartbeat payload
+func (c *Client) addEnrichments(payload map[string]interface{}) {
+	for _, en := range c.enrichments {
+		payload[en.name] = en.attrs
+	}
+}
+
+// addHeartbeatEnrichments adds enricher attributes to a heartbeat payload,
+// asking heartbeat enrichers for their current values. Those that return
+// none keep the attributes from creation.
+func (c *Client) addHeartbeatEnrichments(payload map[string]interface{}) {
+	c.addEnrichments(payload)
+	var ctx context.Context
+	for _, en := range c.enrichments {
+		if en.live == nil {
+			continue
+		}
+		if ctx == nil {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(context.Background(), healthCheckTimeout)
+			defer cancel()
+		}
+		if attrs := en.live.HeartbeatAttributes(ctx); len(attrs) > 0 {
+			payload[en.name] = attrs
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package trusera
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// staticEnricher reports fixed attributes
+type staticEnricher struct {
+	name  string
+	attrs map[string]string
+}
+
+func (e staticEnricher) Name() string                  { return e.name }
+func (e staticEnricher) Attributes() map[string]string { return e.attrs }
+
+func TestEnricherTagsEvents(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000),
+		WithEnricher(staticEnricher{"k8s", map[string]string{"namespace": "prod", "pod_name": "agent-0"}}),
+		WithEnricher(staticEnricher{"empty", nil}),
+	)
+	defer client.Close()
+
+	own := NewEvent(EventToolCall, "a").WithMetadata("k8s.namespace", "override")
+	client.Track(own)
+	client.Track(NewEvent(EventToolCall, "b"))
+
+	events := queuedEvents(client)
+	if events[0].Metadata["k8s.namespace"] != "override" || events[0].Metadata["k8s.pod_name"] != "agent-0" {
+		t.Errorf("expected event values to win over enrichment, got %v", events[0].Metadata)
+	}
+	if events[1].Metadata["k8s.namespace"] != "prod" {
+		t.Errorf("expected enrichment on every event, got %v", events[1].Metadata)
+	}
+	if len(client.enrichments) != 1 {
+		t.Errorf("expected enrichers without attributes to be ignored, got %d", len(client.enrichments))
+	}
+}
+
+func TestEnricherInFleetPayloads(t *testing.T) {
+	var mu sync.Mutex
+	payloads := map[string]map[string]any{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		payloads[r.URL.Path] = body
+		mu.Unlock()
+		if r.URL.Path == "/api/v1/fleet/register" {
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"id": "fleet-1"}})
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithAutoRegister(),
+		WithEnricher(staticEnricher{"k8s", map[string]string{"namespace": "prod"}}))
+	defer client.Close()
+	client.sendHeartbeat()
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, path := range []string{"/api/v1/fleet/register", "/api/v1/fleet/fleet-1/heartbeat"} {
+		attrs, _ := payloads[path]["k8s"].(map[string]any)
+		if attrs["namespace"] != "prod" {
+			t.Errorf("expected k8s attributes in %s, got %v", path, payloads[path]["k8s"])
+		}
+	}
+}
+
+func TestFleetEnricherSkipsEvents(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000),
+		WithFleetEnricher(staticEnricher{"cloud", map[string]string{"provider": "aws"}}))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "a"))
+
+	if _, ok := queuedEvents(client)[0].Metadata["cloud.provider"]; ok {
+		t.Error("expected fleet-only enrichment to be left off events")
+	}
+	payload := map[string]interface{}{}
+	client.addEnrichments(payload)
+	if attrs, _ := payload["cloud"].(map[string]string); attrs["provider"] != "aws" {
+		t.Errorf("expected fleet payload enrichment, got %v", payload)
+	}
+}
+
+// gaugeEnricher reports a value that changes between heartbeats
+type gaugeEnricher struct {
+	staticEnricher
+	current func() map[string]string
+}
+
+func (e gaugeEnricher) HeartbeatAttributes(context.Context) map[string]string { return e.current() }
+
+func TestHeartbeatEnricherRefreshesHeartbeats(t *testing.T) {
+	util := "10"
+	client := NewClient("test-key", WithBatchSize(1000),
+		WithFleetEnricher(gaugeEnricher{staticEnricher{"gpu", map[string]string{"count": "1"}}, func() map[string]string {
+			if util == "" {
+				return nil
+			}
+			return map[string]string{"count": "1", "0.utilization_percent": util}
+		}}))
+	defer client.Close()
+
+	for _, tc := range []struct{ util, want string }{{"10", "10"}, {"85", "85"}, {"", ""}} {
+		util = tc.util
+		payload := map[string]interface{}{}
+		client.addHeartbeatEnrichments(payload)
+		attrs := payload["gpu"].(map[string]string)
+		if attrs["0.utilization_percent"] != tc.want || attrs["count"] != "1" {
+			t.Errorf("expected utilization %q, got %v", tc.want, attrs)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package trusera
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxErrorBody is how much of an error response body APIError keeps
+const maxErrorBody = 512
+
+// Sentinel errors matched by errors.Is against an *APIError
+var (
+	ErrUnauthorized = errors.New("trusera: unauthorized")
+	ErrRateLimited  = errors.New("trusera: rate limited")
+	// ErrRejected matches client errors that retrying cannot fix, such as a
+	// schema error (400, 422) or an oversized request (413)
+	ErrRejected = errors.New("trusera: rejected")
+)
+
+// APIError is returned when the Trusera API answers with an error status
+type APIError struct {
+	StatusCode int
+	RequestID  string        // X-Request-Id of the response, if any
+	Body       string        // Start of the response body
+	RetryAfter time.Duration // From the Retry-After or X-RateLimit-Reset header, if any
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "API returned status %d", e.StatusCode)
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, " (request %s)", e.RequestID)
+	}
+	if e.Body != "" {
+		fmt.Fprintf(&b, ": %s", e.Body)
+	}
+	return b.String()
+}
+
+// Is reports whether the status matches ErrUnauthorized (401, 403),
+// ErrRateLimited (429) or ErrRejected (any other 4xx except 408)
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrRejected:
+		switch e.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden, http.StatusRequestTimeout, http.StatusTooManyRequests:
+			return false
+		}
+		return e.StatusCode >= 400 && e.StatusCode < 500
+	}
+	return false
+}
+
+// newAPIError builds an APIError from an error response, consuming its body
+func newAPIError(resp *http.Response) *APIError {
+	excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	// Drain the rest to allow connection reuse
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Request-Id"),
+		Body:       strings.TrimSpace(string(excerpt)),
+		RetryAfter: retryAfter(resp.Header),
+	}
+}
+
+// retryAfter reads how long to wait before the next request from the
+// Retry-After header, falling back to X-RateLimit-Reset. Reset values larger
+// than a year of seconds are taken as a Unix timestamp.
+func retryAfter(h http.Header) time.Duration {
+	if v := h.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			return time.Duration(secs) * time.Second
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return time.Until(t)
+		}
+	}
+	if v := h.Get("X-RateLimit-Reset"); v != "" {
+		if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+			if secs > 365*24*60*60 {
+				return time.Until(time.Unix(secs, 0))
+			}
+			return time.Duration(secs) * time.Second
+		}
+	}
+	return 0
+}
+
+// WithErrorHandler registers a callback for failures that happen in the
+// background, where no caller receives an error: batch delivery by the flush
+// workers, fleet registration, heartbeats, deregistration, remote config
+// polling and API key reloads. It is called synchronously from SDK
+// goroutines and must not block.
+func WithErrorHandler(fn func(error)) Option {
+	return func(c *Client) {
+		c.errorHandler = fn
+	}
+}
+
+// reportError passes a background failure to the error handler, if any
+func (c *Client) reportError(err error) {
+	if err != nil && c.errorHandler != nil {
+		c.errorHandler(err)
+	}
+}
@@ -0,0 +1,139 @@
+package trusera
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFlushReturnsAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-123")
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":"slow down"}` + strings.Repeat("x", 2*maxErrorBody)))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "tool"))
+	err := client.Flush()
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %T: %v", err, err)
+	}
+	if apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected status 429, got %d", apiErr.StatusCode)
+	}
+	if apiErr.RequestID != "req-123" {
+		t.Errorf("expected request ID req-123, got %q", apiErr.RequestID)
+	}
+	if apiErr.RetryAfter != 7*time.Second {
+		t.Errorf("expected Retry-After 7s, got %v", apiErr.RetryAfter)
+	}
+	if !strings.HasPrefix(apiErr.Body, `{"error":"slow down"}`) || len(apiErr.Body) > maxErrorBody {
+		t.Errorf("expected truncated body excerpt, got %d bytes", len(apiErr.Body))
+	}
+	if !errors.Is(err, ErrRateLimited) {
+		t.Error("expected errors.Is(err, ErrRateLimited)")
+	}
+	if errors.Is(err, ErrUnauthorized) {
+		t.Error("expected 429 not to match ErrUnauthorized")
+	}
+	if !strings.Contains(err.Error(), "status 429") || !strings.Contains(err.Error(), "req-123") {
+		t.Errorf("expected status and request ID in message, got %q", err.Error())
+	}
+}
+
+func TestAPIErrorUnauthorized(t *testing.T) {
+	for _, status := range []int{http.StatusUnauthorized, http.StatusForbidden} {
+		err := error(&APIError{StatusCode: status})
+		if !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("expected status %d to match ErrUnauthorized", status)
+		}
+	}
+	if errors.Is(&APIError{StatusCode: http.StatusInternalServerError}, ErrUnauthorized) {
+		t.Error("expected status 500 not to match ErrUnauthorized")
+	}
+}
+
+func TestAPIErrorRejected(t *testing.T) {
+	for status, want := range map[int]bool{
+		http.StatusBadRequest:            true,
+		http.StatusRequestEntityTooLarge: true,
+		http.StatusUnprocessableEntity:   true,
+		http.StatusUnauthorized:          false,
+		http.StatusForbidden:             false,
+		http.StatusRequestTimeout:        false,
+		http.StatusTooManyRequests:       false,
+		http.StatusBadGateway:            false,
+	} {
+		if got := errors.Is(&APIError{StatusCode: status}, ErrRejected); got != want {
+			t.Errorf("expected status %d to match ErrRejected: %v, got %v", status, want, got)
+		}
+	}
+}
+
+func TestRegisterAgentReturnsAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad key", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	defer client.Close()
+
+	_, err := client.RegisterAgent("agent", "custom")
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestErrorHandlerReceivesDeliveryFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	errs := make(chan error, 10)
+	client := NewClient("test-key", WithBaseURL(server.URL), WithBatchSize(1), WithErrorHandler(func(err error) {
+		errs <- err
+	}))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "tool"))
+
+	select {
+	case err := <-errs:
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("expected wrapped 503 APIError, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected error handler to be called")
+	}
+}
+
+func TestErrorHandlerReceivesHeartbeatFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	var got error
+	client := NewClient("test-key", WithBaseURL(server.URL), WithErrorHandler(func(err error) { got = err }))
+	defer client.Close()
+	client.fleetAgentID = "fleet-1"
+
+	client.sendHeartbeat()
+
+	if !errors.Is(got, ErrUnauthorized) {
+		t.Errorf("expected heartbeat ErrUnauthorized, got %v", got)
+	}
+}
@@ -0,0 +1,54 @@
+package trusera
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// evalSubmission is the body posted to /v1/evals
+type evalSubmission struct {
+	AgentID string `json:"agent_id,omitempty"`
+	Run     any    `json:"run"`
+}
+
+// SubmitEvalRun posts an evaluation run to /v1/evals, linked to the agent the
+// client registered so CI evaluations appear next to its production
+// telemetry. The run is sent as JSON; the evals package describes one.
+func (c *Client) SubmitEvalRun(ctx context.Context, run any) error {
+	c.mu.Lock()
+	agentID := c.agentID
+	c.mu.Unlock()
+
+	if c.sink != nil {
+		return c.sink.write("eval", agentID, run)
+	}
+
+	body, err := json.Marshal(evalSubmission{AgentID: agentID, Run: run})
+	if err != nil {
+		return fmt.Errorf("failed to marshal eval run: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/v1/evals", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.currentAPIKey())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to submit eval run: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return newAPIError(resp)
+	}
+	// Drain body to allow connection reuse
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	return nil
+}
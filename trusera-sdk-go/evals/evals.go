@@ -0,0 +1,164 @@
+// Package evals reports offline evaluation runs, such as a CI test suite
+// scored against a dataset, so their results land next to the production
+// telemetry of the agent under test.
+package evals
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+// Case is the result of one evaluation case
+type Case struct {
+	ID       string             `json:"id"`
+	Score    float64            `json:"score"`
+	Passed   bool               `json:"passed"`
+	Metrics  map[string]float64 `json:"metrics,omitempty"` // Secondary scores, e.g. "faithfulness"
+	Duration time.Duration      `json:"duration_ns,omitempty"`
+	Error    string             `json:"error,omitempty"` // Set when the case failed to run
+}
+
+// Summary aggregates the cases of a run
+type Summary struct {
+	Total     int                `json:"total"`
+	Passed    int                `json:"passed"`
+	Failed    int                `json:"failed"`
+	Errored   int                `json:"errored"`
+	PassRate  float64            `json:"pass_rate"`
+	MeanScore float64            `json:"mean_score"`
+	Metrics   map[string]float64 `json:"metrics,omitempty"` // Mean of each case metric
+}
+
+// EvalRun describes one run of an evaluation suite
+type EvalRun struct {
+	Suite        string         `json:"suite"`
+	DatasetHash  string         `json:"dataset_hash,omitempty"` // See HashDataset
+	Model        string         `json:"model"`
+	ModelVersion string         `json:"model_version,omitempty"`
+	Provider     string         `json:"provider,omitempty"`
+	GitCommit    string         `json:"git_commit,omitempty"` // Defaults to the CI commit, if known
+	StartedAt    time.Time      `json:"started_at"`
+	FinishedAt   time.Time      `json:"finished_at"`
+	Cases        []Case         `json:"cases"`
+	Summary      *Summary       `json:"summary,omitempty"` // Computed from Cases if nil
+	Metadata     map[string]any `json:"metadata,omitempty"`
+}
+
+// Summarize aggregates the run's cases
+func (r *EvalRun) Summarize() Summary {
+	s := Summary{Total: len(r.Cases)}
+	sums := make(map[string]float64)
+	counts := make(map[string]int)
+	var total float64
+	for _, c := range r.Cases {
+		switch {
+		case c.Error != "":
+			s.Errored++
+		case c.Passed:
+			s.Passed++
+		default:
+			s.Failed++
+		}
+		total += c.Score
+		for k, v := range c.Metrics {
+			sums[k] += v
+			counts[k]++
+		}
+	}
+	if s.Total > 0 {
+		s.PassRate = float64(s.Passed) / float64(s.Total)
+		s.MeanScore = total / float64(s.Total)
+	}
+	if len(sums) > 0 {
+		s.Metrics = make(map[string]float64, len(sums))
+		for k, v := range sums {
+			s.Metrics[k] = v / float64(counts[k])
+		}
+	}
+	return s
+}
+
+// Submitter sends an eval run to the Trusera API; *trusera.Client satisfies it
+type Submitter interface {
+	SubmitEvalRun(ctx context.Context, run any) error
+}
+
+var _ Submitter = (*trusera.Client)(nil)
+
+// ciCommitVars are environment variables CI systems set to the commit built
+var ciCommitVars = []string{"GITHUB_SHA", "CI_COMMIT_SHA", "BUILDKITE_COMMIT", "CIRCLE_SHA1", "GIT_COMMIT"}
+
+// SubmitEvalRun validates the run, fills in its summary, finish time and
+// commit, and sends it to /v1/evals
+func SubmitEvalRun(ctx context.Context, s Submitter, run *EvalRun) error {
+	if run == nil || run.Suite == "" {
+		return errors.New("eval suite is required")
+	}
+	if run.Model == "" {
+		return errors.New("eval model is required")
+	}
+	for i, c := range run.Cases {
+		if c.ID == "" {
+			return fmt.Errorf("eval case %d has no ID", i)
+		}
+	}
+
+	if run.FinishedAt.IsZero() {
+		run.FinishedAt = time.Now().UTC()
+	}
+	if run.StartedAt.IsZero() {
+		run.StartedAt = run.FinishedAt
+	}
+	if run.GitCommit == "" {
+		for _, name := range ciCommitVars {
+			if v := os.Getenv(name); v != "" {
+				run.GitCommit = v
+				break
+			}
+		}
+	}
+	if run.Summary == nil {
+		summary := run.Summarize()
+		run.Summary = &summary
+	}
+	return s.SubmitEvalRun(ctx, run)
+}
+
+// HashDataset returns the SHA-256 of a dataset as "sha256:<hex>", so runs
+// against the same dataset can be compared
+func HashDataset(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", fmt.Errorf("failed to hash dataset: %w", err)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HashDatasetFiles hashes one or more dataset files, in sorted path order
+func HashDatasetFiles(paths ...string) (string, error) {
+	sorted := append([]string(nil), paths...)
+	sort.Strings(sorted)
+
+	h := sha256.New()
+	for _, p := range sorted {
+		f, err := os.Open(p)
+		if err != nil {
+			return "", fmt.Errorf("failed to hash dataset: %w", err)
+		}
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to hash dataset: %w", err)
+		}
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,99 @@
+package evals
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+func TestSubmitEvalRun(t *testing.T) {
+	t.Setenv("GITHUB_SHA", "abc123")
+
+	var got struct {
+		AgentID string  `json:"agent_id"`
+		Run     EvalRun `json:"run"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/evals" {
+			_ = json.NewDecoder(r.Body).Decode(&got)
+		}
+	}))
+	defer server.Close()
+
+	client := trusera.NewClient("test-key", trusera.WithBaseURL(server.URL), trusera.WithAgentID("agent-1"))
+	defer client.Close()
+
+	run := &EvalRun{
+		Suite:        "support-regression",
+		DatasetHash:  "sha256:00",
+		Model:        "gpt-4o",
+		ModelVersion: "2024-08-06",
+		Cases: []Case{
+			{ID: "refund", Score: 1, Passed: true, Metrics: map[string]float64{"faithfulness": 0.9}},
+			{ID: "escalate", Score: 0.5, Metrics: map[string]float64{"faithfulness": 0.5}},
+			{ID: "timeout", Error: "deadline exceeded"},
+		},
+	}
+	if err := SubmitEvalRun(context.Background(), client, run); err != nil {
+		t.Fatalf("SubmitEvalRun failed: %v", err)
+	}
+
+	if got.AgentID != "agent-1" || got.Run.Suite != "support-regression" || len(got.Run.Cases) != 3 {
+		t.Fatalf("expected the run posted for agent-1, got %+v", got)
+	}
+	if got.Run.GitCommit != "abc123" || got.Run.FinishedAt.IsZero() {
+		t.Errorf("expected commit and finish time filled in, got %+v", got.Run)
+	}
+	s := got.Run.Summary
+	if s == nil || s.Total != 3 || s.Passed != 1 || s.Failed != 1 || s.Errored != 1 || s.MeanScore != 0.5 {
+		t.Fatalf("unexpected summary %+v", s)
+	}
+	if s.Metrics["faithfulness"] != 0.7 {
+		t.Errorf("expected mean faithfulness 0.7, got %v", s.Metrics)
+	}
+}
+
+func TestSubmitEvalRunValidates(t *testing.T) {
+	client := trusera.NewClient("test-key")
+	defer client.Close()
+
+	for _, run := range []*EvalRun{nil, {Model: "gpt-4o"}, {Suite: "s"}, {Suite: "s", Model: "m", Cases: []Case{{}}}} {
+		if err := SubmitEvalRun(context.Background(), client, run); err == nil {
+			t.Errorf("expected error for %+v", run)
+		}
+	}
+}
+
+func TestHashDataset(t *testing.T) {
+	a, err := HashDataset(strings.NewReader("q,a\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(a, "sha256:") || len(a) != len("sha256:")+64 {
+		t.Errorf("unexpected hash %q", a)
+	}
+
+	dir := t.TempDir()
+	one, two := filepath.Join(dir, "a.csv"), filepath.Join(dir, "b.csv")
+	os.WriteFile(one, []byte("q,a\n"), 0o600)
+	os.WriteFile(two, []byte("x,y\n"), 0o600)
+
+	h1, err := HashDatasetFiles(one, two)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, _ := HashDatasetFiles(two, one)
+	if h1 != h2 {
+		t.Errorf("expected the hash independent of argument order, got %s and %s", h1, h2)
+	}
+	if _, err := HashDatasetFiles(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
@@ -0,0 +1,66 @@
+package trusera
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSubmitEvalRun(t *testing.T) {
+	var got struct {
+		AgentID string         `json:"agent_id"`
+		Run     map[string]any `json:"run"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/evals" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithAgentID("agent-1"))
+	defer client.Close()
+
+	if err := client.SubmitEvalRun(context.Background(), map[string]any{"suite": "smoke"}); err != nil {
+		t.Fatalf("SubmitEvalRun failed: %v", err)
+	}
+	if got.AgentID != "agent-1" || got.Run["suite"] != "smoke" {
+		t.Errorf("expected the run linked to agent-1, got %+v", got)
+	}
+}
+
+func TestSubmitEvalRunErrorsAndLocalMode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	defer client.Close()
+	if err := client.SubmitEvalRun(context.Background(), map[string]any{}); err == nil {
+		t.Error("expected error for 400 response")
+	}
+
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	local := NewClient("", WithLocalSink(path), WithAgentID("agent-1"))
+	if err := local.SubmitEvalRun(context.Background(), map[string]any{"suite": "smoke"}); err != nil {
+		t.Fatalf("SubmitEvalRun failed: %v", err)
+	}
+	local.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"kind":"eval"`) || !strings.Contains(string(data), `"suite":"smoke"`) {
+		t.Errorf("expected an eval record, got %s", data)
+	}
+}
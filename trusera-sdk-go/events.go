@@ -10,12 +10,29 @@ import (
 type EventType string
 
 const (
-	EventToolCall   EventType = "tool_call"
-	EventLLMInvoke  EventType = "llm_invoke"
-	EventDataAccess EventType = "data_access"
-	EventAPICall    EventType = "api_call"
-	EventFileWrite  EventType = "file_write"
-	EventDecision   EventType = "decision"
+	EventToolCall           EventType = "tool_call"
+	EventLLMInvoke          EventType = "llm_invoke"
+	EventDataAccess         EventType = "data_access"
+	EventAPICall            EventType = "api_call"
+	EventFileWrite          EventType = "file_write"
+	EventDecision           EventType = "decision"
+	EventGuardrailViolation EventType = "guardrail_violation"
+	EventLifecycle          EventType = "lifecycle"
+	EventSpan               EventType = "span"
+	EventLog                EventType = "log"
+	EventTiming             EventType = "timing" // Latency summary from TimeOperation
+	EventMetric             EventType = "metric" // Counter or gauge value
+	EventFeedback           EventType = "feedback"
+	EventCrash              EventType = "crash"    // Panic reported by Recover
+	EventResource           EventType = "resource" // Runtime sample from WithRuntimeMetrics
+	EventEmbedding          EventType = "embedding"
+	EventVectorStore        EventType = "vector_store"
+	EventRetrieval          EventType = "retrieval"  // Documents passed to Span.AddRetrieval
+	EventDrift              EventType = "drift"      // Runtime behavior not declared in the AI-BOM
+	EventComparison         EventType = "comparison" // Primary and shadow model calls compared by the canary package
+	EventExposure           EventType = "exposure"   // Unit first assigned to an experiment variant
+	EventSecurity           EventType = "security"   // Suspicious content entering an agent's context
+	EventAudit              EventType = "audit"      // Sensitive action in an agent's hash-chained audit trail
 )
 
 // Event represents an agent action tracked by Trusera
@@ -25,7 +42,16 @@ type Event struct {
 	Name      string         `json:"name"`
 	Payload   map[string]any `json:"payload"`
 	Metadata  map[string]any `json:"metadata,omitempty"`
-	Timestamp string         `json:"timestamp"`
+	Timestamp string         `json:"timestamp"` // RFC 3339 with nanoseconds, from the local clock
+	// Sequence orders events tracked by one client, including those sharing a timestamp
+	Sequence uint64 `json:"sequence,omitempty"`
+	// ClockSkewMs is the estimated offset of the API's clock from the local
+	// clock when the event was tracked. See WithClockSkewEstimate.
+	ClockSkewMs int64 `json:"clock_skew_ms,omitempty"`
+	// ProjectID and TeamID partition telemetry within the organization. See
+	// WithProject and WithTeam.
+	ProjectID string `json:"project_id,omitempty"`
+	TeamID    string `json:"team_id,omitempty"`
 }
 
 // generateID creates a random hex ID
@@ -39,15 +65,39 @@ func generateID() string {
 	return hex.EncodeToString(b)
 }
 
-// NewEvent creates a new event with generated ID and timestamp
+// newUUID creates a random RFC 4122 version 4 UUID. It is formatted in
+// place so that the string is its only allocation.
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+
+	var s [36]byte
+	hex.Encode(s[0:8], b[0:4])
+	s[8] = '-'
+	hex.Encode(s[9:13], b[4:6])
+	s[13] = '-'
+	hex.Encode(s[14:18], b[6:8])
+	s[18] = '-'
+	hex.Encode(s[19:23], b[8:10])
+	s[23] = '-'
+	hex.Encode(s[24:], b[10:])
+	return string(s[:])
+}
+
+// NewEvent creates a new event with a UUID and timestamp. The UUID lets the
+// API discard duplicates when a batch is retried.
 func NewEvent(eventType EventType, name string) Event {
 	return Event{
-		ID:        generateID(),
+		ID:        newUUID(),
 		Type:      eventType,
 		Name:      name,
 		Payload:   make(map[string]any),
 		Metadata:  make(map[string]any),
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
 	}
 }
 
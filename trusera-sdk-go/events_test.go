@@ -2,6 +2,7 @@ package trusera
 
 import (
 	"encoding/json"
+	"regexp"
 	"testing"
 	"time"
 )
@@ -155,3 +156,10 @@ func TestChainedBuilderPattern(t *testing.T) {
 		t.Errorf("expected 2 metadata entries, got %d", len(event.Metadata))
 	}
 }
+
+func TestEventIDIsUUID(t *testing.T) {
+	pattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	if id := NewEvent(EventToolCall, "test").ID; !pattern.MatchString(id) {
+		t.Errorf("expected a version 4 UUID, got %q", id)
+	}
+}
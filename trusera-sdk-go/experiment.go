@@ -0,0 +1,157 @@
+package trusera
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+)
+
+// maxExposures bounds the units remembered per experiment to deduplicate
+// exposure events; the set starts over once it is full
+const maxExposures = 100000
+
+// Default experiment variants
+const (
+	VariantControl   = "control"
+	VariantTreatment = "treatment"
+)
+
+// Variant is one arm of an experiment. Weights are relative; a variant with
+// weight 2 gets twice the units of one with weight 1.
+type Variant struct {
+	Name   string
+	Weight float64
+}
+
+// Experiment assigns units, such as users or sessions, to the variants of
+// an A/B experiment. It is safe for concurrent use.
+type Experiment struct {
+	client   *Client
+	name     string
+	mu       sync.Mutex
+	variants []Variant
+	exposed  map[string]string // Unit to the variant its exposure was tracked for
+}
+
+// experiments holds the experiments of a client, by name
+type experiments struct {
+	mu     sync.Mutex
+	byName map[string]*Experiment
+}
+
+// Assignment is the variant a unit was assigned to
+type Assignment struct {
+	Experiment string
+	Variant    string
+	UnitID     string
+}
+
+// experimentContextKey is the context key for the assignments in effect
+type experimentContextKey struct{}
+
+// Experiment returns the experiment called name, creating it on first use.
+// Variants replace the experiment's variants; without any, a new experiment
+// splits units evenly between VariantControl and VariantTreatment. Changing
+// the variants or their weights reassigns some units.
+func (c *Client) Experiment(name string, variants ...Variant) *Experiment {
+	c.experiments.mu.Lock()
+	defer c.experiments.mu.Unlock()
+	if c.experiments.byName == nil {
+		c.experiments.byName = make(map[string]*Experiment)
+	}
+	x, ok := c.experiments.byName[name]
+	if !ok {
+		x = &Experiment{
+			client:   c,
+			name:     name,
+			variants: []Variant{{Name: VariantControl, Weight: 1}, {Name: VariantTreatment, Weight: 1}},
+			exposed:  make(map[string]string),
+		}
+		c.experiments.byName[name] = x
+	}
+	if len(variants) > 0 {
+		x.mu.Lock()
+		x.variants = append([]Variant(nil), variants...)
+		x.mu.Unlock()
+	}
+	return x
+}
+
+// Assign deterministically assigns unitID to a variant: the same unit always
+// gets the same variant of the same experiment, in any process, and
+// different experiments split units independently. The first assignment of
+// each unit tracks an EventExposure event, so outcomes can be compared only
+// across units that actually saw a variant. Use Assignment.Context to label
+// the events that follow, such as LLM calls and their cost.
+func (x *Experiment) Assign(unitID string) Assignment {
+	a := Assignment{Experiment: x.name, UnitID: unitID}
+
+	x.mu.Lock()
+	a.Variant = pickVariant(x.variants, x.name, unitID)
+	first := x.exposed[unitID] != a.Variant
+	if first {
+		if len(x.exposed) >= maxExposures {
+			x.exposed = make(map[string]string)
+		}
+		x.exposed[unitID] = a.Variant
+	}
+	x.mu.Unlock()
+
+	if first && a.Variant != "" {
+		x.client.Track(NewEvent(EventExposure, x.name).
+			WithPayload("experiment", x.name).
+			WithPayload("variant", a.Variant).
+			WithPayload("unit_id", unitID))
+	}
+	return a
+}
+
+// pickVariant maps the hash of the experiment and unit onto the cumulative
+// variant weights. It returns "" when no variant has a positive weight.
+func pickVariant(variants []Variant, experiment, unitID string) string {
+	var total float64
+	for _, v := range variants {
+		if v.Weight > 0 {
+			total += v.Weight
+		}
+	}
+	if total == 0 {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(experiment + "\x00" + unitID))
+	point := float64(binary.BigEndian.Uint64(sum[:8])>>11) / (1 << 53) * total
+	last := ""
+	for _, v := range variants {
+		if v.Weight <= 0 {
+			continue
+		}
+		if point < v.Weight {
+			return v.Name
+		}
+		point -= v.Weight
+		last = v.Name
+	}
+	return last
+}
+
+// Context returns a copy of ctx carrying the assignment. Events tracked
+// with the context, by WrapTransport or TrackContext, carry an
+// "experiments" metadata map from experiment name to variant, which holds
+// every assignment in ctx.
+func (a Assignment) Context(ctx context.Context) context.Context {
+	prev := ExperimentsFromContext(ctx)
+	all := make(map[string]string, len(prev)+1)
+	for k, v := range prev {
+		all[k] = v
+	}
+	all[a.Experiment] = a.Variant
+	return context.WithValue(ctx, experimentContextKey{}, all)
+}
+
+// ExperimentsFromContext returns the experiment variants in ctx, by
+// experiment name. The map must not be modified.
+func ExperimentsFromContext(ctx context.Context) map[string]string {
+	m, _ := ctx.Value(experimentContextKey{}).(map[string]string)
+	return m
+}
@@ -0,0 +1,74 @@
+package trusera
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestExperimentAssign(t *testing.T) {
+	client := NewClient("test-key", WithLocalSink(t.TempDir()+"/events.jsonl"))
+	defer client.Close()
+
+	x := client.Experiment("prompt-v2")
+	if client.Experiment("prompt-v2") != x {
+		t.Error("expected the same experiment for the same name")
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 2000; i++ {
+		a := x.Assign(fmt.Sprintf("user-%d", i))
+		if again := x.Assign(a.UnitID); again != a {
+			t.Fatalf("expected a stable assignment, got %+v then %+v", a, again)
+		}
+		counts[a.Variant]++
+	}
+	if len(counts) != 2 || math.Abs(float64(counts[VariantControl]-1000)) > 100 {
+		t.Errorf("expected an even control/treatment split, got %v", counts)
+	}
+
+	if tracked := client.Stats().Tracked; tracked != 2000 {
+		t.Errorf("expected one exposure per unit, got %d", tracked)
+	}
+}
+
+func TestExperimentWeightsAndExposure(t *testing.T) {
+	client := NewClient("test-key", WithLocalSink(t.TempDir()+"/events.jsonl"), WithSampler(Probabilistic(0)))
+	defer client.Close()
+
+	x := client.Experiment("model", Variant{Name: "gpt-4o", Weight: 0}, Variant{Name: "gpt-4.1", Weight: 3})
+	a := x.Assign("user-1")
+	if a.Variant != "gpt-4.1" || a.Experiment != "model" || a.UnitID != "user-1" {
+		t.Errorf("expected the only weighted variant, got %+v", a)
+	}
+
+	events := queuedEvents(client)
+	if len(events) != 1 {
+		t.Fatalf("expected the exposure kept despite sampling, got %d events", len(events))
+	}
+	e := events[0]
+	if e.Type != EventExposure || e.Name != "model" || e.Payload["variant"] != "gpt-4.1" || e.Payload["unit_id"] != "user-1" {
+		t.Errorf("expected an exposure event, got %+v", e)
+	}
+
+	if other := client.Experiment("other", Variant{Name: "off"}).Assign("user-1"); other.Variant != "" {
+		t.Errorf("expected no variant without positive weights, got %q", other.Variant)
+	}
+}
+
+func TestAssignmentContext(t *testing.T) {
+	client := NewClient("test-key", WithLocalSink(t.TempDir()+"/events.jsonl"))
+	defer client.Close()
+
+	ctx := client.Experiment("prompt", Variant{Name: "v2", Weight: 1}).Assign("user-1").Context(context.Background())
+	ctx = client.Experiment("model", Variant{Name: "mini", Weight: 1}).Assign("user-1").Context(ctx)
+	client.TrackContext(ctx, NewEvent(EventLLMInvoke, "openai gpt-4o-mini"))
+
+	events := queuedEvents(client)
+	last := events[len(events)-1]
+	xs, _ := last.Metadata["experiments"].(map[string]any)
+	if xs["prompt"] != "v2" || xs["model"] != "mini" {
+		t.Errorf("expected both experiments on the event, got %v", last.Metadata)
+	}
+}
@@ -0,0 +1,99 @@
+package trusera
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Thumbs is a binary thumbs-up/down rating
+type Thumbs string
+
+const (
+	ThumbsUp   Thumbs = "up"
+	ThumbsDown Thumbs = "down"
+)
+
+// FeedbackEvent is human feedback on a traced run, such as a rating from a
+// chat UI or a label from a reviewer. At least one of Rating, Thumbs,
+// Comment or Label must be set.
+type FeedbackEvent struct {
+	RunID     string  // Trace ID of the run the feedback is about (Run.TraceID()), required
+	SpanID    string  // Span within the run, e.g. one answer, if the feedback is that specific
+	SessionID string  // Conversation the run belongs to
+	UserID    string  // User who gave the feedback
+	Rating    float64 // Score from 0 to MaxRating
+	MaxRating float64 // Scale of Rating, e.g. 5 for stars; Rating is omitted when 0
+	Thumbs    Thumbs
+	Comment   string // Free text, redacted like any other payload
+	Label     string // Category such as "hallucination" or "helpful"
+	Source    string // Product surface the feedback came from
+	Metadata  map[string]any
+}
+
+// RecordFeedback records human feedback linked to the run it is about.
+// Feedback is never sampled out.
+func (c *Client) RecordFeedback(f FeedbackEvent) error {
+	if f.RunID == "" {
+		return errors.New("feedback run ID is required")
+	}
+	if f.Thumbs != "" && f.Thumbs != ThumbsUp && f.Thumbs != ThumbsDown {
+		return fmt.Errorf("invalid thumbs %q", f.Thumbs)
+	}
+	if f.MaxRating < 0 || f.Rating < 0 || f.Rating > f.MaxRating {
+		return fmt.Errorf("rating %g is outside 0..%g", f.Rating, f.MaxRating)
+	}
+	if f.MaxRating == 0 && f.Thumbs == "" && f.Comment == "" && f.Label == "" {
+		return errors.New("feedback needs a rating, thumbs, comment or label")
+	}
+
+	name := f.Label
+	if name == "" {
+		name = "feedback"
+	}
+	event := NewEvent(EventFeedback, name).WithMetadata("trace_id", f.RunID)
+	if f.SpanID != "" {
+		event = event.WithMetadata("parent_span_id", f.SpanID)
+	}
+	if f.SessionID != "" {
+		event = event.WithMetadata("session_id", f.SessionID)
+	}
+	if f.UserID != "" {
+		event = event.WithMetadata("user_id", f.UserID)
+	}
+	if f.MaxRating > 0 {
+		event = event.
+			WithPayload("rating", f.Rating).
+			WithPayload("max_rating", f.MaxRating).
+			WithPayload("normalized_rating", f.Rating/f.MaxRating)
+	}
+	if f.Thumbs != "" {
+		event = event.WithPayload("thumbs", string(f.Thumbs))
+	}
+	if f.Comment != "" {
+		event = event.WithPayload("comment", f.Comment)
+	}
+	if f.Label != "" {
+		event = event.WithPayload("label", f.Label)
+	}
+	if f.Source != "" {
+		event = event.WithPayload("source", f.Source)
+	}
+	for k, v := range f.Metadata {
+		event = event.WithMetadata(k, v)
+	}
+
+	c.Track(event)
+	return nil
+}
+
+// RecordFeedback records feedback on one of the session's runs, filling in
+// the session and user IDs if they are not set
+func (s *Session) RecordFeedback(f FeedbackEvent) error {
+	if f.SessionID == "" {
+		f.SessionID = s.id
+	}
+	if f.UserID == "" {
+		f.UserID = s.userID
+	}
+	return s.client.RecordFeedback(f)
+}
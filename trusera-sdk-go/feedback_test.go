@@ -0,0 +1,74 @@
+package trusera
+
+import "testing"
+
+func TestRecordFeedback(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000), WithSampler(Probabilistic(0)))
+	defer client.Close()
+
+	run := client.StartRun("answer")
+	err := client.RecordFeedback(FeedbackEvent{
+		RunID:     run.TraceID(),
+		SpanID:    run.SpanID(),
+		Rating:    4,
+		MaxRating: 5,
+		Thumbs:    ThumbsUp,
+		Comment:   "Great answer",
+		Label:     "helpful",
+		Source:    "chat-ui",
+		Metadata:  map[string]any{"tenant_id": "acme"},
+	})
+	if err != nil {
+		t.Fatalf("RecordFeedback failed: %v", err)
+	}
+
+	events := queuedEvents(client)
+	if len(events) != 1 {
+		t.Fatalf("expected feedback to bypass sampling, got %d events", len(events))
+	}
+	e := events[0]
+	if e.Type != EventFeedback || e.Name != "helpful" {
+		t.Errorf("expected feedback named after its label, got %s %s", e.Type, e.Name)
+	}
+	if e.Metadata["trace_id"] != run.TraceID() || e.Metadata["parent_span_id"] != run.SpanID() || e.Metadata["tenant_id"] != "acme" {
+		t.Errorf("expected feedback linked to the run, got %v", e.Metadata)
+	}
+	if e.Payload["rating"] != 4.0 || e.Payload["normalized_rating"] != 0.8 || e.Payload["thumbs"] != "up" ||
+		e.Payload["comment"] != "Great answer" || e.Payload["source"] != "chat-ui" {
+		t.Errorf("unexpected payload: %v", e.Payload)
+	}
+}
+
+func TestRecordFeedbackValidation(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	for name, f := range map[string]FeedbackEvent{
+		"no run":       {Thumbs: ThumbsUp},
+		"empty":        {RunID: "r"},
+		"bad thumbs":   {RunID: "r", Thumbs: "sideways"},
+		"out of scale": {RunID: "r", Rating: 6, MaxRating: 5},
+	} {
+		if err := client.RecordFeedback(f); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if n := len(queuedEvents(client)); n != 0 {
+		t.Errorf("expected invalid feedback to be dropped, got %d events", n)
+	}
+}
+
+func TestSessionRecordFeedback(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	session := client.StartSession("user-1", "conv-9")
+	if err := session.RecordFeedback(FeedbackEvent{RunID: "trace-1", Thumbs: ThumbsDown}); err != nil {
+		t.Fatalf("RecordFeedback failed: %v", err)
+	}
+
+	events := queuedEvents(client)
+	if len(events) != 1 || events[0].Metadata["session_id"] != "conv-9" || events[0].Metadata["user_id"] != "user-1" {
+		t.Errorf("expected the session's IDs filled in, got %+v", events)
+	}
+}
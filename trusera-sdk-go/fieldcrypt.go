@@ -0,0 +1,279 @@
+package trusera
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// FieldEncryptionAlg identifies the scheme of encrypted payload fields: a
+// random AES-256-GCM key per field, wrapped with RSA-OAEP and SHA-256
+const FieldEncryptionAlg = "RSA-OAEP-256+A256GCM"
+
+// minFieldKeyBits is the smallest RSA key accepted by WithFieldEncryption
+const minFieldKeyBits = 2048
+
+// ErrNotEncrypted is returned by DecryptField for values that are not
+// encrypted fields
+var ErrNotEncrypted = errors.New("trusera: field is not encrypted")
+
+// fieldCrypt holds the settings of WithFieldEncryption
+type fieldCrypt struct {
+	pub    *rsa.PublicKey
+	keyID  string
+	fields []string // Nil for the content keys
+	err    error    // Why the key is unusable, reported by NewClient
+}
+
+// WithFieldEncryption encrypts the named payload fields of every event with
+// publicKey before they leave the process, so that prompts and completions
+// are stored by Trusera only as ciphertext and can be read only by holders of
+// the private key, such as reviewers granted access through the customer's
+// KMS. Without fields, the content fields are encrypted: prompt, prompts,
+// messages, completion, response, content, input, output, body_snippet and
+// those added by WithCaptureKeys.
+//
+// publicKey must be an *rsa.PublicKey of at least 2048 bits; NewClient
+// refuses to start with any other. Each field value is replaced by an object
+// holding its JSON encoding, encrypted with a fresh AES-256-GCM key that is
+// itself encrypted with RSA-OAEP (SHA-256), and the ID of publicKey as
+// SigningKeyID computes it:
+//
+//	{"alg": "RSA-OAEP-256+A256GCM", "kid": "...", "key": "...", "nonce": "...", "ciphertext": "..."}
+//
+// The event ID and field name are authenticated, so ciphertext cannot be
+// moved to another event or field unnoticed. Fields are encrypted last, after
+// content classifiers, redaction, capture levels, processors and policies
+// have seen the plaintext, and the names of the encrypted fields are listed
+// in the encrypted_fields metadata. Such events are never truncated to fit
+// WithMaxBatchBytes; one that does not fit is dropped with ErrEventTooLarge.
+// Decrypt with DecryptField or DecryptEvent.
+func WithFieldEncryption(publicKey crypto.PublicKey, fields ...string) Option {
+	return func(c *Client) {
+		fc := &fieldCrypt{}
+		if len(fields) > 0 {
+			fc.fields = append([]string(nil), fields...)
+		}
+		pub, ok := publicKey.(*rsa.PublicKey)
+		switch {
+		case !ok:
+			fc.err = fmt.Errorf("unsupported key type %T, want *rsa.PublicKey", publicKey)
+		case pub.N.BitLen() < minFieldKeyBits:
+			fc.err = fmt.Errorf("RSA key of %d bits is too small, want at least %d", pub.N.BitLen(), minFieldKeyBits)
+		default:
+			fc.pub = pub
+			fc.keyID, fc.err = SigningKeyID(pub)
+		}
+		c.fieldCrypt = fc
+	}
+}
+
+// encryptFields replaces the fields selected by WithFieldEncryption with
+// their ciphertext. A field that cannot be encrypted is removed rather than
+// sent in the clear.
+func (c *Client) encryptFields(e Event) Event {
+	fc := c.fieldCrypt
+	if fc == nil || fc.pub == nil || len(e.Payload) == 0 {
+		return e
+	}
+	keys := fc.fields
+	if keys == nil {
+		keys = append(append([]string(nil), contentKeys...), c.captureKeys...)
+	}
+
+	var payload map[string]any
+	var encrypted, failed []string
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		v, ok := e.Payload[key]
+		if !ok || v == nil || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if payload == nil {
+			// Copy so the caller's map is left untouched
+			payload = make(map[string]any, len(e.Payload))
+			for k, v := range e.Payload {
+				payload[k] = v
+			}
+		}
+		sealed, err := fc.seal(e.ID, key, v)
+		if err != nil {
+			delete(payload, key)
+			failed = append(failed, key)
+			continue
+		}
+		payload[key] = sealed
+		encrypted = append(encrypted, key)
+	}
+	if payload == nil {
+		return e
+	}
+	e.Payload = payload
+	if len(encrypted) > 0 {
+		e = e.WithMetadata("encrypted_fields", encrypted)
+	}
+	if len(failed) > 0 {
+		e = e.WithMetadata("encryption_failed_fields", failed)
+	}
+	return e
+}
+
+// seal encrypts the JSON encoding of v as field of event eventID
+func (fc *fieldCrypt) seal(eventID, field string, v any) (map[string]any, error) {
+	plaintext, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	aead, err := newFieldAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	wrapped, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, fc.pub, dataKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"alg":        FieldEncryptionAlg,
+		"kid":        fc.keyID,
+		"key":        base64.StdEncoding.EncodeToString(wrapped),
+		"nonce":      base64.StdEncoding.EncodeToString(nonce),
+		"ciphertext": base64.StdEncoding.EncodeToString(aead.Seal(nil, nonce, plaintext, fieldAAD(eventID, field))),
+	}, nil
+}
+
+// DecryptField decrypts the value of field in event eventID, as encrypted by
+// WithFieldEncryption, with the private key of the client's public key. key
+// may be an *rsa.PrivateKey or any crypto.Decrypter accepting
+// *rsa.OAEPOptions, such as a KMS-backed key. The value may be as tracked or
+// as decoded from JSON; it is returned as decoded from JSON, so a string
+// field is returned as a string and a message list as []any. Values that are
+// not encrypted return ErrNotEncrypted.
+func DecryptField(key crypto.Decrypter, eventID, field string, value any) (any, error) {
+	env, ok := fieldEnvelope(value)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotEncrypted, field)
+	}
+	if env["alg"] != FieldEncryptionAlg {
+		return nil, fmt.Errorf("field %s: unsupported algorithm %q", field, env["alg"])
+	}
+	var raw [3][]byte
+	for i, name := range []string{"key", "nonce", "ciphertext"} {
+		b, err := base64.StdEncoding.DecodeString(env[name])
+		if err != nil {
+			return nil, fmt.Errorf("field %s: invalid %s: %w", field, name, err)
+		}
+		raw[i] = b
+	}
+
+	dataKey, err := key.Decrypt(rand.Reader, raw[0], &rsa.OAEPOptions{Hash: crypto.SHA256})
+	if err != nil {
+		return nil, fmt.Errorf("field %s: failed to unwrap key: %w", field, err)
+	}
+	aead, err := newFieldAEAD(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("field %s: %w", field, err)
+	}
+	if len(raw[1]) != aead.NonceSize() {
+		return nil, fmt.Errorf("field %s: invalid nonce", field)
+	}
+	plaintext, err := aead.Open(nil, raw[1], raw[2], fieldAAD(eventID, field))
+	if err != nil {
+		return nil, fmt.Errorf("field %s: %w", field, err)
+	}
+	var v any
+	if err := json.Unmarshal(plaintext, &v); err != nil {
+		return nil, fmt.Errorf("field %s: %w", field, err)
+	}
+	return v, nil
+}
+
+// DecryptEvent returns e with the fields listed in its encrypted_fields
+// metadata decrypted by DecryptField, for reviewers reading events back from
+// the API or a local sink. e is returned unchanged if it has no encrypted
+// fields.
+func DecryptEvent(key crypto.Decrypter, e Event) (Event, error) {
+	var fields []string
+	switch v := e.Metadata["encrypted_fields"].(type) {
+	case []string:
+		fields = v
+	case []any:
+		for _, f := range v {
+			if s, ok := f.(string); ok {
+				fields = append(fields, s)
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return e, nil
+	}
+
+	payload := make(map[string]any, len(e.Payload))
+	for k, v := range e.Payload {
+		payload[k] = v
+	}
+	for _, field := range fields {
+		v, err := DecryptField(key, e.ID, field, payload[field])
+		if err != nil {
+			return e, err
+		}
+		payload[field] = v
+	}
+	e.Payload = payload
+	metadata := make(map[string]any, len(e.Metadata))
+	for k, v := range e.Metadata {
+		if k != "encrypted_fields" {
+			metadata[k] = v
+		}
+	}
+	e.Metadata = metadata
+	return e, nil
+}
+
+// fieldEnvelope reads an encrypted field as tracked or as decoded from JSON
+func fieldEnvelope(value any) (map[string]string, bool) {
+	env := map[string]string{}
+	switch v := value.(type) {
+	case map[string]any:
+		for k, x := range v {
+			if s, ok := x.(string); ok {
+				env[k] = s
+			}
+		}
+	case map[string]string:
+		env = v
+	default:
+		return nil, false
+	}
+	_, ok := env["ciphertext"]
+	return env, ok && env["alg"] != ""
+}
+
+// newFieldAEAD returns the AES-GCM cipher for a data key
+func newFieldAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// fieldAAD binds a field's ciphertext to its event and name
+func fieldAAD(eventID, field string) []byte {
+	return []byte(eventID + "\x00" + field)
+}
@@ -0,0 +1,163 @@
+package trusera
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	fieldKeyOnce sync.Once
+	fieldKey     *rsa.PrivateKey
+)
+
+// testFieldKey returns an RSA key shared by the tests, as generating one is slow
+func testFieldKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	fieldKeyOnce.Do(func() {
+		fieldKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+	})
+	if fieldKey == nil {
+		t.Fatal("failed to generate RSA key")
+	}
+	return fieldKey
+}
+
+func TestFieldEncryptionContentFields(t *testing.T) {
+	key := testFieldKey(t)
+	client := NewClient("test-key", WithBatchSize(1000), WithFieldEncryption(&key.PublicKey))
+	defer client.Close()
+
+	messages := []any{map[string]any{"role": "user", "content": "my SSN is 078-05-1120"}}
+	payload := map[string]any{"model": "gpt-4o", "messages": messages, "completion": "noted"}
+	client.Track(Event{Type: EventLLMInvoke, Name: "chat", Payload: payload})
+
+	e := queuedEvents(client)[0]
+	data, _ := json.Marshal(e)
+	if strings.Contains(string(data), "078-05-1120") || strings.Contains(string(data), "noted") {
+		t.Fatalf("expected content encrypted, got %s", data)
+	}
+	if e.Payload["model"] != "gpt-4o" {
+		t.Errorf("expected other fields in the clear, got %v", e.Payload["model"])
+	}
+	env, _ := e.Payload["completion"].(map[string]any)
+	keyID, _ := SigningKeyID(&key.PublicKey)
+	if env["alg"] != FieldEncryptionAlg || env["kid"] != keyID {
+		t.Errorf("unexpected envelope: %v", env)
+	}
+	if _, ok := payload["completion"].(string); !ok {
+		t.Error("expected the caller's payload untouched")
+	}
+
+	// Decrypt as a reviewer would, from the JSON sent to the API
+	var sent Event
+	if err := json.Unmarshal(data, &sent); err != nil {
+		t.Fatalf("failed to decode event: %v", err)
+	}
+	got, err := DecryptEvent(key, sent)
+	if err != nil {
+		t.Fatalf("DecryptEvent failed: %v", err)
+	}
+	if got.Payload["completion"] != "noted" {
+		t.Errorf("expected the completion decrypted, got %v", got.Payload["completion"])
+	}
+	msgs, _ := got.Payload["messages"].([]any)
+	if len(msgs) != 1 || msgs[0].(map[string]any)["content"] != "my SSN is 078-05-1120" {
+		t.Errorf("expected the messages decrypted, got %v", got.Payload["messages"])
+	}
+	if got.Metadata["encrypted_fields"] != nil {
+		t.Errorf("expected encrypted_fields removed, got %v", got.Metadata)
+	}
+}
+
+func TestFieldEncryptionNamedFields(t *testing.T) {
+	key := testFieldKey(t)
+	client := NewClient("test-key", WithBatchSize(1000), WithFieldEncryption(&key.PublicKey, "ticket"))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "lookup").WithPayload("ticket", "T-1").WithPayload("output", "ok"))
+
+	e := queuedEvents(client)[0]
+	if e.Payload["output"] != "ok" {
+		t.Errorf("expected unnamed fields in the clear, got %v", e.Payload["output"])
+	}
+	v, err := DecryptField(key, e.ID, "ticket", e.Payload["ticket"])
+	if err != nil || v != "T-1" {
+		t.Errorf("expected T-1, got %v (%v)", v, err)
+	}
+	if fields, _ := e.Metadata["encrypted_fields"].([]string); len(fields) != 1 || fields[0] != "ticket" {
+		t.Errorf("expected encrypted_fields [ticket], got %v", e.Metadata["encrypted_fields"])
+	}
+}
+
+func TestDecryptFieldRejects(t *testing.T) {
+	key := testFieldKey(t)
+	client := NewClient("test-key", WithBatchSize(1000), WithFieldEncryption(&key.PublicKey))
+	defer client.Close()
+	client.Track(NewEvent(EventLLMInvoke, "chat").WithPayload("prompt", "hello"))
+	e := queuedEvents(client)[0]
+
+	if _, err := DecryptField(key, "other-event", "prompt", e.Payload["prompt"]); err == nil {
+		t.Error("expected ciphertext moved to another event to fail")
+	}
+	if _, err := DecryptField(key, e.ID, "completion", e.Payload["prompt"]); err == nil {
+		t.Error("expected ciphertext moved to another field to fail")
+	}
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	if _, err := DecryptField(other, e.ID, "prompt", e.Payload["prompt"]); err == nil {
+		t.Error("expected the wrong key to fail")
+	}
+	if _, err := DecryptField(key, e.ID, "prompt", "hello"); !errors.Is(err, ErrNotEncrypted) {
+		t.Errorf("expected ErrNotEncrypted, got %v", err)
+	}
+}
+
+func TestWithFieldEncryptionRejectsKeys(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	small, _ := rsa.GenerateKey(rand.Reader, 1024)
+	for _, pub := range []any{&ecKey.PublicKey, &small.PublicKey, nil} {
+		c := &Client{}
+		WithFieldEncryption(pub)(c)
+		if c.fieldCrypt.err == nil {
+			t.Errorf("expected %T to be rejected", pub)
+		}
+	}
+}
+
+func TestFieldEncryptionIsNotTruncated(t *testing.T) {
+	key := testFieldKey(t)
+	rt := &recordingTransport{}
+	var reported error
+	client := NewClient("test-key", WithTransport(rt), WithFlushInterval(time.Hour), WithMaxBatchBytes(4000),
+		WithFieldEncryption(&key.PublicKey), WithErrorHandler(func(err error) { reported = err }))
+	defer client.Close()
+
+	client.Track(NewEvent(EventLLMInvoke, "long").WithPayload("prompt", strings.Repeat("x", 5000)))
+	client.Track(NewEvent(EventLLMInvoke, "short").WithPayload("prompt", "hello"))
+	if err := client.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if len(rt.batches) != 1 || len(rt.batches[0].Events) != 1 {
+		t.Fatalf("expected only the short event delivered, got %+v", rt.batches)
+	}
+	e := rt.batches[0].Events[0]
+	if e.Name != "short" || e.Metadata["truncated_from_bytes"] != nil {
+		t.Fatalf("expected the short event whole, got %+v", e)
+	}
+	if got, err := DecryptEvent(key, e); err != nil || got.Payload["prompt"] != "hello" {
+		t.Errorf("expected the delivered event to decrypt, got %v (%v)", got.Payload, err)
+	}
+	if !errors.Is(reported, ErrEventTooLarge) {
+		t.Errorf("expected ErrEventTooLarge for the long event, got %v", reported)
+	}
+}
@@ -0,0 +1,75 @@
+package trusera
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// defaultClient is the process-wide client set by Init or SetDefault
+var defaultClient atomic.Pointer[Client]
+
+// Init creates the process-wide default client used by the package-level
+// Track, TrackContext, StartRun, Flush and Close functions, so libraries can
+// record events without a client being passed to them. The API key comes
+// from TRUSERA_API_KEY unless an option sets one. A previous default client
+// is closed.
+func Init(opts ...Option) *Client {
+	c := NewClient("", opts...)
+	if prev := defaultClient.Swap(c); prev != nil {
+		prev.Close()
+	}
+	return c
+}
+
+// SetDefault makes an existing client the default client, or clears it if c
+// is nil. The previous default client is returned and not closed.
+func SetDefault(c *Client) *Client {
+	return defaultClient.Swap(c)
+}
+
+// Default returns the default client, or nil before Init or SetDefault.
+// Libraries can check it to skip building events nobody will send.
+func Default() *Client {
+	return defaultClient.Load()
+}
+
+// Track records an event with the default client. It does nothing without
+// one.
+func Track(event Event) {
+	if c := defaultClient.Load(); c != nil {
+		c.Track(event)
+	}
+}
+
+// TrackContext records an event with the default client, linked to the
+// active span in ctx. It does nothing without one.
+func TrackContext(ctx context.Context, event Event) {
+	if c := defaultClient.Load(); c != nil {
+		c.TrackContext(ctx, event)
+	}
+}
+
+// StartRun begins a run with the default client. Without one the run and its
+// spans are still usable but report nothing.
+func StartRun(name string) *Run {
+	return defaultClient.Load().StartRun(name)
+}
+
+// Flush sends the default client's queued events. It does nothing without
+// one.
+func Flush() error {
+	if c := defaultClient.Load(); c != nil {
+		return c.Flush()
+	}
+	return nil
+}
+
+// Close closes the default client and clears it, so later package-level
+// calls do nothing. Call it before the program exits, typically deferred
+// after Init.
+func Close() error {
+	if c := defaultClient.Swap(nil); c != nil {
+		return c.Close()
+	}
+	return nil
+}
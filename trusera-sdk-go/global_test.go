@@ -0,0 +1,52 @@
+package trusera
+
+import (
+	"context"
+	"testing"
+)
+
+// closed reports whether Close was called on c
+func closed(c *Client) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+func TestDefaultClient(t *testing.T) {
+	t.Cleanup(func() { SetDefault(nil) })
+
+	// Without a default client the package-level helpers are no-ops
+	Track(NewEvent(EventToolCall, "ignored"))
+	run := StartRun("ignored")
+	run.StartSpan("step").End()
+	run.End()
+	if err := Flush(); err != nil {
+		t.Errorf("expected Flush without a client to succeed, got %v", err)
+	}
+
+	client := Init(WithBatchSize(1000))
+	if Default() != client {
+		t.Fatal("expected Init to set the default client")
+	}
+
+	Track(NewEvent(EventToolCall, "search"))
+	run = StartRun("answer")
+	TrackContext(ContextWithSpan(context.Background(), run.Span), NewEvent(EventDecision, "route"))
+	run.End()
+
+	events := queuedEvents(client)
+	if len(events) != 3 || events[0].Name != "search" || events[1].Metadata["trace_id"] != run.TraceID() || events[2].Type != EventSpan {
+		t.Errorf("unexpected events %+v", events)
+	}
+
+	next := Init(WithBatchSize(1000))
+	if !closed(client) || Default() != next {
+		t.Error("expected Init to close and replace the previous default client")
+	}
+	if err := Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	if Default() != nil || !closed(next) {
+		t.Error("expected Close to close and clear the default client")
+	}
+}
@@ -0,0 +1,156 @@
+// Package gpu detects the NVIDIA GPUs an agent can use, so fleets running
+// local inference can be mapped to their accelerators. It reads them through
+// NVML when built with the nvml tag and cgo, and otherwise runs nvidia-smi.
+//
+//	inv, err := gpu.Detect(ctx, gpu.Options{})
+//	if err != nil {
+//		log.Printf("gpu inventory unavailable: %v", err)
+//	}
+//	client := trusera.NewClient(apiKey, trusera.WithFleetEnricher(inv))
+//
+// Fleet registration reports each GPU's model and memory, and every
+// heartbeat its current memory use, utilization and temperature.
+package gpu
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// Sources reported in Inventory.Source
+const (
+	SourceNVML = "nvml"
+	SourceSMI  = "nvidia-smi"
+)
+
+// defaultTimeout bounds each query so a wedged driver cannot hold up startup
+const defaultTimeout = 5 * time.Second
+
+// errNVMLUnavailable is returned by queryNVML when the SDK was built without
+// NVML support or the library cannot be loaded
+var errNVMLUnavailable = errors.New("NVML unavailable")
+
+// Options configures Detect
+type Options struct {
+	// SMIPath is the nvidia-smi executable. Defaults to "nvidia-smi" on the PATH.
+	SMIPath string
+	// Timeout bounds each query. Defaults to 5s.
+	Timeout time.Duration
+	// SkipNVML goes straight to nvidia-smi even when NVML is available
+	SkipNVML bool
+}
+
+// Device describes one GPU
+type Device struct {
+	Index              int    `json:"index"`
+	UUID               string `json:"uuid,omitempty"`
+	Model              string `json:"model"`
+	MemoryTotalMB      int64  `json:"memory_total_mb"`
+	MemoryUsedMB       int64  `json:"memory_used_mb"`
+	UtilizationPercent int    `json:"utilization_percent"`
+	TemperatureC       int    `json:"temperature_c,omitempty"`
+}
+
+// Inventory lists the GPUs found. It implements trusera.HeartbeatEnricher.
+type Inventory struct {
+	Source        string   `json:"source"`
+	DriverVersion string   `json:"driver_version,omitempty"`
+	Devices       []Device `json:"devices"`
+
+	opts Options
+}
+
+// Detect returns the GPUs visible to the process, or nil when there are
+// none or no NVIDIA driver is installed.
+func Detect(ctx context.Context, opts Options) (*Inventory, error) {
+	if opts.SMIPath == "" {
+		opts.SMIPath = "nvidia-smi"
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	inv, err := query(ctx, opts)
+	if err != nil || len(inv.Devices) == 0 {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// query reads the devices through NVML, falling back to nvidia-smi
+func query(ctx context.Context, opts Options) (*Inventory, error) {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	if !opts.SkipNVML {
+		devices, driver, err := queryNVML()
+		if err == nil {
+			return &Inventory{Source: SourceNVML, DriverVersion: driver, Devices: devices, opts: opts}, nil
+		}
+		if !errors.Is(err, errNVMLUnavailable) {
+			return nil, err
+		}
+	}
+	devices, driver, err := querySMI(ctx, opts.SMIPath)
+	if err != nil {
+		return nil, err
+	}
+	return &Inventory{Source: SourceSMI, DriverVersion: driver, Devices: devices, opts: opts}, nil
+}
+
+// Name returns "gpu", the key attributes are reported under
+func (inv *Inventory) Name() string {
+	return "gpu"
+}
+
+// Attributes returns what does not change while the process runs: the
+// driver, the number of GPUs and their total memory, and each GPU's model,
+// UUID and memory as "<index>.<field>". It returns nil for a nil Inventory.
+func (inv *Inventory) Attributes() map[string]string {
+	if inv == nil {
+		return nil
+	}
+	attrs := map[string]string{
+		"source": inv.Source,
+		"count":  strconv.Itoa(len(inv.Devices)),
+	}
+	if inv.DriverVersion != "" {
+		attrs["driver_version"] = inv.DriverVersion
+	}
+	var total int64
+	for _, d := range inv.Devices {
+		prefix := strconv.Itoa(d.Index) + "."
+		attrs[prefix+"model"] = d.Model
+		attrs[prefix+"memory_total_mb"] = strconv.FormatInt(d.MemoryTotalMB, 10)
+		if d.UUID != "" {
+			attrs[prefix+"uuid"] = d.UUID
+		}
+		total += d.MemoryTotalMB
+	}
+	attrs["memory_total_mb"] = strconv.FormatInt(total, 10)
+	return attrs
+}
+
+// HeartbeatAttributes queries the GPUs again and returns Attributes with each
+// GPU's memory use, utilization and temperature added. It returns nil if the
+// query fails.
+func (inv *Inventory) HeartbeatAttributes(ctx context.Context) map[string]string {
+	if inv == nil {
+		return nil
+	}
+	current, err := query(ctx, inv.opts)
+	if err != nil || len(current.Devices) == 0 {
+		return nil
+	}
+	attrs := current.Attributes()
+	for _, d := range current.Devices {
+		prefix := strconv.Itoa(d.Index) + "."
+		attrs[prefix+"memory_used_mb"] = strconv.FormatInt(d.MemoryUsedMB, 10)
+		attrs[prefix+"utilization_percent"] = strconv.Itoa(d.UtilizationPercent)
+		if d.TemperatureC != 0 {
+			attrs[prefix+"temperature_c"] = strconv.Itoa(d.TemperatureC)
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,84 @@
+package gpu
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+var _ trusera.HeartbeatEnricher = (*Inventory)(nil)
+
+const smiOutput = `0, GPU-5d3c1a2b, NVIDIA A100-SXM4-80GB, 550.54.15, 81920, 40960, 87, 64
+1, GPU-9e8f7a6b, NVIDIA A100-SXM4-80GB, 550.54.15, 81920, 1024, 0, [N/A]
+`
+
+// fakeSMI writes an nvidia-smi stand-in printing output
+func fakeSMI(t *testing.T, output string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "out.csv"), []byte(output), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "nvidia-smi")
+	script := "#!/bin/sh\ncat " + filepath.Join(dir, "out.csv") + "\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseSMI(t *testing.T) {
+	devices, driver, err := parseSMI([]byte(smiOutput))
+	if err != nil {
+		t.Fatalf("parseSMI failed: %v", err)
+	}
+	want := []Device{
+		{Index: 0, UUID: "GPU-5d3c1a2b", Model: "NVIDIA A100-SXM4-80GB", MemoryTotalMB: 81920, MemoryUsedMB: 40960, UtilizationPercent: 87, TemperatureC: 64},
+		{Index: 1, UUID: "GPU-9e8f7a6b", Model: "NVIDIA A100-SXM4-80GB", MemoryTotalMB: 81920, MemoryUsedMB: 1024},
+	}
+	if driver != "550.54.15" || !reflect.DeepEqual(devices, want) {
+		t.Errorf("expected driver 550.54.15 and %+v, got %q and %+v", want, driver, devices)
+	}
+
+	if _, _, err := parseSMI([]byte("0, only, three\n")); err == nil {
+		t.Error("expected an error for truncated output")
+	}
+}
+
+func TestDetectWithSMI(t *testing.T) {
+	inv, err := Detect(context.Background(), Options{SMIPath: fakeSMI(t, smiOutput), SkipNVML: true})
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	attrs := inv.Attributes()
+	want := map[string]string{
+		"source": SourceSMI, "count": "2", "driver_version": "550.54.15", "memory_total_mb": "163840",
+		"0.model": "NVIDIA A100-SXM4-80GB", "0.uuid": "GPU-5d3c1a2b", "0.memory_total_mb": "81920",
+		"1.model": "NVIDIA A100-SXM4-80GB", "1.uuid": "GPU-9e8f7a6b", "1.memory_total_mb": "81920",
+	}
+	if !reflect.DeepEqual(attrs, want) {
+		t.Errorf("expected static attributes %v, got %v", want, attrs)
+	}
+
+	live := inv.HeartbeatAttributes(context.Background())
+	if live["0.utilization_percent"] != "87" || live["0.memory_used_mb"] != "40960" || live["0.temperature_c"] != "64" {
+		t.Errorf("expected current usage in heartbeat attributes, got %v", live)
+	}
+	if _, ok := live["1.temperature_c"]; ok || live["count"] != "2" {
+		t.Errorf("expected unreported values left out, got %v", live)
+	}
+}
+
+func TestDetectWithoutGPUs(t *testing.T) {
+	inv, err := Detect(context.Background(), Options{SMIPath: filepath.Join(t.TempDir(), "nvidia-smi"), SkipNVML: true})
+	if inv != nil || err != nil {
+		t.Errorf("expected no inventory and no error without nvidia-smi, got %v, %v", inv, err)
+	}
+	if attrs := inv.Attributes(); attrs != nil {
+		t.Errorf("expected nil attributes, got %v", attrs)
+	}
+}
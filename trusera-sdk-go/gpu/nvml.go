@@ -0,0 +1,123 @@
+//go:build nvml && cgo && linux
+
+package gpu
+
+/*
+#cgo LDFLAGS: -ldl
+#include <dlfcn.h>
+
+// NVML types and entry points, declared here so the driver's headers are not
+// needed to build. libnvidia-ml is opened at run time, so the binary still
+// starts on hosts without the driver.
+typedef int nvmlReturn_t;
+typedef void *nvmlDevice_t;
+typedef struct { unsigned long long total, free, used; } nvmlMemory_t;
+typedef struct { unsigned int gpu, memory; } nvmlUtilization_t;
+
+static nvmlReturn_t (*nvmlInit)(void);
+static nvmlReturn_t (*nvmlSystemGetDriverVersion)(char *, unsigned int);
+static nvmlReturn_t (*nvmlDeviceGetCount)(unsigned int *);
+static nvmlReturn_t (*nvmlDeviceGetHandleByIndex)(unsigned int, nvmlDevice_t *);
+static nvmlReturn_t (*nvmlDeviceGetName)(nvmlDevice_t, char *, unsigned int);
+static nvmlReturn_t (*nvmlDeviceGetUUID)(nvmlDevice_t, char *, unsigned int);
+static nvmlReturn_t (*nvmlDeviceGetMemoryInfo)(nvmlDevice_t, nvmlMemory_t *);
+static nvmlReturn_t (*nvmlDeviceGetUtilizationRates)(nvmlDevice_t, nvmlUtilization_t *);
+static nvmlReturn_t (*nvmlDeviceGetTemperature)(nvmlDevice_t, int, unsigned int *);
+
+// nvml_load opens the library and initializes it, returning -1 if the
+// library or a symbol is missing, or NVML's error code
+static int nvml_load(void) {
+	void *lib = dlopen("libnvidia-ml.so.1", RTLD_LAZY);
+	if (!lib) return -1;
+	if (!(nvmlInit = dlsym(lib, "nvmlInit_v2")) ||
+	    !(nvmlSystemGetDriverVersion = dlsym(lib, "nvmlSystemGetDriverVersion")) ||
+	    !(nvmlDeviceGetCount = dlsym(lib, "nvmlDeviceGetCount_v2")) ||
+	    !(nvmlDeviceGetHandleByIndex = dlsym(lib, "nvmlDeviceGetHandleByIndex_v2")) ||
+	    !(nvmlDeviceGetName = dlsym(lib, "nvmlDeviceGetName")) ||
+	    !(nvmlDeviceGetUUID = dlsym(lib, "nvmlDeviceGetUUID")) ||
+	    !(nvmlDeviceGetMemoryInfo = dlsym(lib, "nvmlDeviceGetMemoryInfo")) ||
+	    !(nvmlDeviceGetUtilizationRates = dlsym(lib, "nvmlDeviceGetUtilizationRates")) ||
+	    !(nvmlDeviceGetTemperature = dlsym(lib, "nvmlDeviceGetTemperature"))) {
+		return -1;
+	}
+	return nvmlInit();
+}
+
+static int nvml_driver_version(char *buf, unsigned int n) { return nvmlSystemGetDriverVersion(buf, n); }
+static int nvml_device_count(unsigned int *n) { return nvmlDeviceGetCount(n); }
+static int nvml_device(unsigned int i, nvmlDevice_t *d) { return nvmlDeviceGetHandleByIndex(i, d); }
+static int nvml_name(nvmlDevice_t d, char *buf, unsigned int n) { return nvmlDeviceGetName(d, buf, n); }
+static int nvml_uuid(nvmlDevice_t d, char *buf, unsigned int n) { return nvmlDeviceGetUUID(d, buf, n); }
+static int nvml_memory(nvmlDevice_t d, nvmlMemory_t *m) { return nvmlDeviceGetMemoryInfo(d, m); }
+static int nvml_utilization(nvmlDevice_t d, nvmlUtilization_t *u) { return nvmlDeviceGetUtilizationRates(d, u); }
+static int nvml_temperature(nvmlDevice_t d, unsigned int *t) { return nvmlDeviceGetTemperature(d, 0, t); }
+*/
+import "C"
+
+import (
+	"fmt"
+	"sync"
+)
+
+// nvmlStringLen fits the longest name, UUID and version NVML returns
+const nvmlStringLen = 96
+
+var (
+	nvmlOnce sync.Once
+	nvmlErr  error
+)
+
+// queryNVML reads the devices through libnvidia-ml, loading it on first use
+func queryNVML() ([]Device, string, error) {
+	nvmlOnce.Do(func() {
+		switch rc := C.nvml_load(); rc {
+		case 0:
+		case -1:
+			nvmlErr = errNVMLUnavailable
+		default:
+			nvmlErr = fmt.Errorf("%w: nvmlInit returned %d", errNVMLUnavailable, int(rc))
+		}
+	})
+	if nvmlErr != nil {
+		return nil, "", nvmlErr
+	}
+
+	var buf [nvmlStringLen]C.char
+	str := func(rc C.int) string {
+		if rc != 0 {
+			return ""
+		}
+		return C.GoString(&buf[0])
+	}
+	driver := str(C.nvml_driver_version(&buf[0], nvmlStringLen))
+
+	var count C.uint
+	if rc := C.nvml_device_count(&count); rc != 0 {
+		return nil, "", fmt.Errorf("gpu: nvmlDeviceGetCount returned %d", int(rc))
+	}
+	devices := make([]Device, 0, int(count))
+	for i := C.uint(0); i < count; i++ {
+		var d C.nvmlDevice_t
+		if rc := C.nvml_device(i, &d); rc != 0 {
+			return nil, "", fmt.Errorf("gpu: nvmlDeviceGetHandleByIndex(%d) returned %d", int(i), int(rc))
+		}
+		dev := Device{Index: int(i)}
+		dev.Model = str(C.nvml_name(d, &buf[0], nvmlStringLen))
+		dev.UUID = str(C.nvml_uuid(d, &buf[0], nvmlStringLen))
+		var mem C.nvmlMemory_t
+		if C.nvml_memory(d, &mem) == 0 {
+			dev.MemoryTotalMB = int64(mem.total >> 20)
+			dev.MemoryUsedMB = int64(mem.used >> 20)
+		}
+		var util C.nvmlUtilization_t
+		if C.nvml_utilization(d, &util) == 0 {
+			dev.UtilizationPercent = int(util.gpu)
+		}
+		var temp C.uint
+		if C.nvml_temperature(d, &temp) == 0 {
+			dev.TemperatureC = int(temp)
+		}
+		devices = append(devices, dev)
+	}
+	return devices, driver, nil
+}
@@ -0,0 +1,8 @@
+//go:build !nvml || !cgo || !linux
+
+package gpu
+
+// queryNVML is only available when built with the nvml tag and cgo on Linux
+func queryNVML() ([]Device, string, error) {
+	return nil, "", errNVMLUnavailable
+}
@@ -0,0 +1,86 @@
+package gpu
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// smiFields are queried from nvidia-smi, in this order
+const smiFields = "index,uuid,name,driver_version,memory.total,memory.used,utilization.gpu,temperature.gpu"
+
+// querySMI runs nvidia-smi. A missing executable means there are no GPUs.
+func querySMI(ctx context.Context, path string) ([]Device, string, error) {
+	cmd := exec.CommandContext(ctx, path, "--query-gpu="+smiFields, "--format=csv,noheader,nounits")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist) {
+		return nil, "", nil
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, "", fmt.Errorf("gpu: nvidia-smi: %w: %s", err, msg)
+		}
+		return nil, "", fmt.Errorf("gpu: nvidia-smi: %w", err)
+	}
+	return parseSMI(out)
+}
+
+// parseSMI reads nvidia-smi CSV output without header or units
+func parseSMI(out []byte) ([]Device, string, error) {
+	r := csv.NewReader(bytes.NewReader(out))
+	r.TrimLeadingSpace = true
+	r.FieldsPerRecord = strings.Count(smiFields, ",") + 1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, "", fmt.Errorf("gpu: parse nvidia-smi output: %w", err)
+	}
+
+	var driver string
+	devices := make([]Device, 0, len(records))
+	for _, rec := range records {
+		index, err := strconv.Atoi(rec[0])
+		if err != nil {
+			return nil, "", fmt.Errorf("gpu: parse nvidia-smi output: index %q", rec[0])
+		}
+		devices = append(devices, Device{
+			Index:              index,
+			UUID:               smiValue(rec[1]),
+			Model:              smiValue(rec[2]),
+			MemoryTotalMB:      smiInt(rec[4]),
+			MemoryUsedMB:       smiInt(rec[5]),
+			UtilizationPercent: int(smiInt(rec[6])),
+			TemperatureC:       int(smiInt(rec[7])),
+		})
+		if driver == "" {
+			driver = smiValue(rec[3])
+		}
+	}
+	return devices, driver, nil
+}
+
+// smiValue returns a field, or "" for the placeholders nvidia-smi prints for
+// values a GPU does not report, such as "[N/A]" and "[Not Supported]"
+func smiValue(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "[") {
+		return ""
+	}
+	return s
+}
+
+// smiInt parses a numeric field, reading missing values as 0
+func smiInt(s string) int64 {
+	n, err := strconv.ParseFloat(smiValue(s), 64)
+	if err != nil {
+		return 0
+	}
+	return int64(n)
+}
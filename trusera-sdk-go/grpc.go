@@ -0,0 +1,351 @@
+package trusera
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// grpcIngestMethod is the full gRPC method name for batch ingestion
+const grpcIngestMethod = "/trusera.events.v1.EventService/Ingest"
+
+// grpcResourceExhausted is the gRPC status code the server uses for rate limiting
+const grpcResourceExhausted = "8"
+
+// GRPCTransport sends protobuf-encoded batches to the Trusera gRPC ingestion
+// endpoint over HTTP/2. The wire schema is proto/trusera/events/v1/events.proto.
+// It uses net/http directly, so the target must be an https:// URL (HTTP/2 is
+// negotiated via TLS ALPN). Given to WithTransport or a route, it sends the
+// client's API key instead of APIKey, following SetAPIKey and WithAPIKeyFile,
+// and signs with the key given to WithSigningKey.
+type GRPCTransport struct {
+	Target      string // e.g. "https://grpc.trusera.io"
+	APIKey      string
+	HTTPClient  *http.Client
+	Compression string        // grpc-encoding for messages, e.g. "gzip"
+	Signer      crypto.Signer // Signs the request body, see WithSigningKey
+
+	keyFunc    func() string        // Overrides APIKey so the client can rotate keys
+	signerFunc func() crypto.Signer // Overrides Signer when it returns a key
+}
+
+// bindTransport makes a gRPC transport read the client's API key on each
+// request and sign with the client's signing key. Other transports are left
+// alone.
+func (c *Client) bindTransport(t Transport) {
+	if g, ok := t.(*GRPCTransport); ok {
+		g.keyFunc = c.currentAPIKey
+		// Options may set the key after WithTransport, so it is read when sending
+		g.signerFunc = func() crypto.Signer { return c.signer }
+	}
+}
+
+// NewGRPCTransport creates a gRPC transport for target. Its HTTP client
+// honors HTTPS_PROXY and NO_PROXY.
+func NewGRPCTransport(target, apiKey string) *GRPCTransport {
+	return &GRPCTransport{
+		Target:     strings.TrimSuffix(target, "/"),
+		APIKey:     apiKey,
+		HTTPClient: &http.Client{Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, ForceAttemptHTTP2: true}},
+	}
+}
+
+// Send performs a unary Ingest call
+func (t *GRPCTransport) Send(ctx context.Context, batch Batch) error {
+	msg, err := encodeBatchProto(batch)
+	if err != nil {
+		return fmt.Errorf("failed to encode events: %w: %w", ErrUnencodable, err)
+	}
+
+	// Length-prefixed message: 1 byte compression flag, 4 byte big-endian length
+	frame := make([]byte, 5, 5+len(msg))
+	if t.Compression != "" {
+		if msg, err = compress(t.Compression, msg); err != nil {
+			return fmt.Errorf("failed to compress events: %w", err)
+		}
+		frame[0] = 1
+	}
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(msg)))
+	frame = append(frame, msg...)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.Target+grpcIngestMethod, bytes.NewReader(frame))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/grpc+proto")
+	req.Header.Set("TE", "trailers")
+	key := t.APIKey
+	if t.keyFunc != nil {
+		key = t.keyFunc()
+	}
+	req.Header.Set("Authorization", "Bearer "+key)
+	req.Header.Set(IdempotencyKeyHeader, batch.idempotencyKey())
+	if t.Compression != "" {
+		req.Header.Set("Grpc-Encoding", t.Compression)
+	}
+	signer := t.Signer
+	if t.signerFunc != nil {
+		if s := t.signerFunc(); s != nil {
+			signer = s
+		}
+	}
+	if signer != nil {
+		if err := signRequest(req, signer, frame); err != nil {
+			return err
+		}
+	}
+
+	httpClient := t.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send events: %w", err)
+	}
+	defer resp.Body.Close()
+	// Trailers are only populated once the body has been read to EOF
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+
+	if resp.ProtoMajor != 2 {
+		return fmt.Errorf("gRPC requires HTTP/2, server responded with %s", resp.Proto)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &APIError{
+			StatusCode: resp.StatusCode,
+			RequestID:  resp.Header.Get("X-Request-Id"),
+			RetryAfter: retryAfter(resp.Header),
+		}
+	}
+
+	status := resp.Trailer.Get("Grpc-Status")
+	msgText := resp.Trailer.Get("Grpc-Message")
+	if status == "" {
+		// Trailers-only responses carry the status in headers
+		status = resp.Header.Get("Grpc-Status")
+		msgText = resp.Header.Get("Grpc-Message")
+	}
+	if status == grpcResourceExhausted {
+		return fmt.Errorf("gRPC status %s: %s: %w", status, msgText, ErrRateLimited)
+	}
+	if status != "0" {
+		return fmt.Errorf("gRPC status %s: %s", status, msgText)
+	}
+
+	return nil
+}
+
+// encodeBatchProto encodes a Batch as a trusera.events.v1.EventBatch message
+func encodeBatchProto(batch Batch) ([]byte, error) {
+	var buf []byte
+	buf = appendProtoString(buf, 1, batch.AgentID)
+
+	for _, e := range batch.Events {
+		msg, err := encodeEventProto(e)
+		if err != nil {
+			return nil, err
+		}
+		buf = appendProtoBytes(buf, 2, msg)
+	}
+	return buf, nil
+}
+
+// encodeEventProto encodes an Event as a trusera.events.v1.Event message
+func encodeEventProto(e Event) ([]byte, error) {
+	var buf []byte
+	buf = appendProtoString(buf, 1, e.ID)
+	buf = appendProtoString(buf, 2, string(e.Type))
+	buf = appendProtoString(buf, 3, e.Name)
+
+	if len(e.Payload) > 0 {
+		payload, err := encodeProtoStruct(e.Payload, 0)
+		if err != nil {
+			return nil, fmt.Errorf("payload: %w", err)
+		}
+		buf = appendProtoBytes(buf, 4, payload)
+	}
+	if len(e.Metadata) > 0 {
+		metadata, err := encodeProtoStruct(e.Metadata, 0)
+		if err != nil {
+			return nil, fmt.Errorf("metadata: %w", err)
+		}
+		buf = appendProtoBytes(buf, 5, metadata)
+	}
+
+	buf = appendProtoString(buf, 6, e.Timestamp)
+	buf = appendProtoVarint(buf, 7, e.Sequence)
+	buf = appendProtoVarint(buf, 8, uint64(e.ClockSkewMs)) // int64 is sent as two's complement
+	buf = appendProtoString(buf, 9, e.ProjectID)
+	buf = appendProtoString(buf, 10, e.TeamID)
+	return buf, nil
+}
+
+// maxProtoDepth bounds the nesting of encoded values, so a map that contains
+// itself fails as it does with encoding/json instead of overflowing the stack
+const maxProtoDepth = 1000
+
+// encodeProtoStruct encodes a map as a google.protobuf.Struct message. Keys
+// are sorted so a batch always encodes to the same bytes.
+func encodeProtoStruct[V any](m map[string]V, depth int) ([]byte, error) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf, entry []byte
+	for _, k := range keys {
+		value, err := encodeProtoValue(m[k], depth+1)
+		if err != nil {
+			return nil, err
+		}
+		// map<string, Value> fields = 1, as key = 1, value = 2 entries
+		entry = appendProtoString(entry[:0], 1, k)
+		entry = appendProtoBytes(entry, 2, value)
+		buf = appendProtoBytes(buf, 1, entry)
+	}
+	return buf, nil
+}
+
+// encodeProtoList encodes a slice as a google.protobuf.ListValue message
+func encodeProtoList[T any](list []T, depth int) ([]byte, error) {
+	var buf []byte
+	for _, item := range list {
+		value, err := encodeProtoValue(item, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		buf = appendProtoBytes(buf, 1, value)
+	}
+	return buf, nil
+}
+
+// encodeProtoValue encodes v as a google.protobuf.Value message. Numbers are
+// sent as doubles, as in JSON. Values of types it does not walk, such as
+// structs and typed slices, are encoded as their JSON encoding decodes.
+func encodeProtoValue(v any, depth int) ([]byte, error) {
+	if depth > maxProtoDepth {
+		return nil, errors.New("value nested too deeply")
+	}
+	switch val := v.(type) {
+	case nil:
+		return []byte{1 << 3, 0}, nil // null_value = 1, NULL_VALUE
+	case bool:
+		if val {
+			return []byte{4 << 3, 1}, nil // bool_value = 4
+		}
+		return []byte{4 << 3, 0}, nil
+	case string:
+		return appendProtoBytes(nil, 3, []byte(val)), nil
+	case float64:
+		return encodeProtoNumber(val)
+	case float32:
+		return encodeProtoNumber(float64(val))
+	case int:
+		return encodeProtoNumber(float64(val))
+	case int8:
+		return encodeProtoNumber(float64(val))
+	case int16:
+		return encodeProtoNumber(float64(val))
+	case int32:
+		return encodeProtoNumber(float64(val))
+	case int64:
+		return encodeProtoNumber(float64(val))
+	case uint:
+		return encodeProtoNumber(float64(val))
+	case uint8:
+		return encodeProtoNumber(float64(val))
+	case uint16:
+		return encodeProtoNumber(float64(val))
+	case uint32:
+		return encodeProtoNumber(float64(val))
+	case uint64:
+		return encodeProtoNumber(float64(val))
+	case json.Number:
+		f, err := val.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return encodeProtoNumber(f)
+	case map[string]any:
+		return encodeProtoNested(5, val, depth, encodeProtoStruct[any])
+	case map[string]string:
+		return encodeProtoNested(5, val, depth, encodeProtoStruct[string])
+	case []any:
+		return encodeProtoNested(6, val, depth, encodeProtoList[any])
+	case []string:
+		return encodeProtoNested(6, val, depth, encodeProtoList[string])
+	case []map[string]any:
+		return encodeProtoNested(6, val, depth, encodeProtoList[map[string]any])
+	case json.RawMessage:
+		var decoded any
+		if err := json.Unmarshal(val, &decoded); err != nil {
+			return nil, err
+		}
+		return encodeProtoValue(decoded, depth)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		var decoded any
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			return nil, err
+		}
+		return encodeProtoValue(decoded, depth)
+	}
+}
+
+// encodeProtoNested encodes a struct_value (5) or list_value (6)
+func encodeProtoNested[T any](field int, v T, depth int, encode func(T, int) ([]byte, error)) ([]byte, error) {
+	msg, err := encode(v, depth)
+	if err != nil {
+		return nil, err
+	}
+	return appendProtoBytes(nil, field, msg), nil
+}
+
+// encodeProtoNumber encodes a number_value. Like JSON, Value has no
+// representation for NaN and infinities.
+func encodeProtoNumber(f float64) ([]byte, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("unsupported value: %v", f)
+	}
+	buf := []byte{2<<3 | 1} // number_value = 2, 64-bit
+	return binary.LittleEndian.AppendUint64(buf, math.Float64bits(f)), nil
+}
+
+// appendProtoVarint appends a varint (wire type 0) field, omitting zero values per proto3
+func appendProtoVarint(buf []byte, field int, v uint64) []byte {
+	if v == 0 {
+		return buf
+	}
+	buf = binary.AppendUvarint(buf, uint64(field)<<3)
+	return binary.AppendUvarint(buf, v)
+}
+
+// appendProtoString appends a length-delimited string field, omitting empty values per proto3
+func appendProtoString(buf []byte, field int, s string) []byte {
+	if s == "" {
+		return buf
+	}
+	return appendProtoBytes(buf, field, []byte(s))
+}
+
+// appendProtoBytes appends a length-delimited (wire type 2) field
+func appendProtoBytes(buf []byte, field int, b []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(field)<<3|2)
+	buf = binary.AppendUvarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
@@ -0,0 +1,318 @@
+package trusera
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// decodeProtoFields splits a protobuf message into its length-delimited fields
+func decodeProtoFields(t *testing.T, b []byte) map[int][][]byte {
+	t.Helper()
+	fields := map[int][][]byte{}
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 || key&7 != 2 {
+			t.Fatalf("unexpected protobuf key %d", key)
+		}
+		b = b[n:]
+		size, n := binary.Uvarint(b)
+		b = b[n:]
+		fields[int(key>>3)] = append(fields[int(key>>3)], b[:size])
+		b = b[size:]
+	}
+	return fields
+}
+
+// decodeProtoStruct decodes a google.protobuf.Struct into the values
+// encoding/json would decode
+func decodeProtoStruct(t *testing.T, b []byte) map[string]any {
+	t.Helper()
+	m := map[string]any{}
+	for _, entry := range decodeProtoFields(t, b)[1] {
+		kv := decodeProtoFields(t, entry)
+		m[string(kv[1][0])] = decodeProtoValue(t, kv[2][0])
+	}
+	return m
+}
+
+// decodeProtoValue decodes a google.protobuf.Value
+func decodeProtoValue(t *testing.T, b []byte) any {
+	t.Helper()
+	switch b[0] {
+	case 1 << 3:
+		return nil
+	case 2<<3 | 1:
+		return math.Float64frombits(binary.LittleEndian.Uint64(b[1:]))
+	case 4 << 3:
+		return b[1] == 1
+	}
+	fields := decodeProtoFields(t, b)
+	switch {
+	case fields[3] != nil:
+		return string(fields[3][0])
+	case fields[5] != nil:
+		return decodeProtoStruct(t, fields[5][0])
+	case fields[6] != nil:
+		list := []any{}
+		for _, item := range decodeProtoFields(t, fields[6][0])[1] {
+			list = append(list, decodeProtoValue(t, item))
+		}
+		return list
+	}
+	t.Fatalf("unexpected Value %x", b)
+	return nil
+}
+
+func newGRPCServer(t *testing.T, handler func(batch map[int][][]byte, w http.ResponseWriter)) *httptest.Server {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != grpcIngestMethod || r.Header.Get("Content-Type") != "application/grpc+proto" {
+			t.Errorf("unexpected request %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		frame, _ := io.ReadAll(r.Body)
+		if len(frame) < 5 || int(binary.BigEndian.Uint32(frame[1:5])) != len(frame)-5 {
+			t.Errorf("invalid gRPC frame")
+		}
+		w.Header().Set("Content-Type", "application/grpc+proto")
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+		handler(decodeProtoFields(t, frame[5:]), w)
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	return server
+}
+
+func TestGRPCTransportSend(t *testing.T) {
+	var got map[int][][]byte
+	server := newGRPCServer(t, func(batch map[int][][]byte, w http.ResponseWriter) {
+		got = batch
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Grpc-Status", "0")
+	})
+	defer server.Close()
+
+	tr := NewGRPCTransport(server.URL, "k")
+	tr.HTTPClient = server.Client()
+
+	event := NewEvent(EventToolCall, "search").WithPayload("q", "go")
+	event.ProjectID = "payments"
+	if err := tr.Send(context.Background(), Batch{AgentID: "agent-1", Events: []Event{event}}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	if string(got[1][0]) != "agent-1" {
+		t.Errorf("expected agent_id field, got %q", got[1])
+	}
+	if len(got[2]) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(got[2]))
+	}
+
+	ev := decodeProtoFields(t, got[2][0])
+	if string(ev[1][0]) != event.ID || string(ev[2][0]) != "tool_call" || string(ev[3][0]) != "search" {
+		t.Errorf("unexpected event fields: %q", ev)
+	}
+	if payload := decodeProtoStruct(t, ev[4][0]); !reflect.DeepEqual(payload, map[string]any{"q": "go"}) {
+		t.Errorf("expected Struct payload, got %v", payload)
+	}
+	if _, ok := ev[5]; ok {
+		t.Error("expected empty metadata to be omitted")
+	}
+	if string(ev[9][0]) != "payments" || ev[10] != nil {
+		t.Errorf("expected project_id field and no team_id, got %q %q", ev[9], ev[10])
+	}
+}
+
+func TestGRPCTransportCompression(t *testing.T) {
+	var encoding string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding = r.Header.Get("Grpc-Encoding")
+		frame, _ := io.ReadAll(r.Body)
+		if frame[0] != 1 {
+			t.Error("expected compressed flag to be set")
+		}
+		if _, err := gzip.NewReader(bytes.NewReader(frame[5:])); err != nil {
+			t.Errorf("expected gzip message: %v", err)
+		}
+		w.Header().Set("Grpc-Status", "0")
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	tr := NewGRPCTransport(server.URL, "k")
+	tr.HTTPClient = server.Client()
+	tr.Compression = "gzip"
+
+	if err := tr.Send(context.Background(), Batch{AgentID: "a"}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if encoding != "gzip" {
+		t.Errorf("expected grpc-encoding gzip, got %q", encoding)
+	}
+}
+
+func TestGRPCTransportErrorStatus(t *testing.T) {
+	server := newGRPCServer(t, func(_ map[int][][]byte, w http.ResponseWriter) {
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Grpc-Status", "16")
+		w.Header().Set("Grpc-Message", "invalid api key")
+	})
+	defer server.Close()
+
+	tr := NewGRPCTransport(server.URL, "k")
+	tr.HTTPClient = server.Client()
+
+	err := tr.Send(context.Background(), Batch{})
+	if err == nil || !strings.Contains(err.Error(), "invalid api key") {
+		t.Errorf("expected gRPC status error, got %v", err)
+	}
+}
+
+func TestGRPCTransportRequiresHTTP2(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tr := NewGRPCTransport(server.URL, "k")
+	tr.HTTPClient = server.Client()
+
+	if err := tr.Send(context.Background(), Batch{}); err == nil {
+		t.Error("expected error for HTTP/1.1 response")
+	}
+}
+
+func TestGRPCTransportWithClient(t *testing.T) {
+	calls := 0
+	server := newGRPCServer(t, func(_ map[int][][]byte, w http.ResponseWriter) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Grpc-Status", "0")
+	})
+	defer server.Close()
+
+	tr := NewGRPCTransport(server.URL, "k")
+	tr.HTTPClient = server.Client()
+
+	client := NewClient("k", WithTransport(tr))
+	client.Track(NewEvent(EventToolCall, "a"))
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("expected clean close, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 gRPC call, got %d", calls)
+	}
+}
+
+func TestGRPCTransportFollowsKeyRotation(t *testing.T) {
+	server := newGRPCServer(t, func(_ map[int][][]byte, w http.ResponseWriter) {
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Grpc-Status", "0")
+	})
+	defer server.Close()
+
+	keys := &keyRecorder{next: server.Client().Transport}
+	tr := NewGRPCTransport(server.URL, "old-key")
+	tr.HTTPClient = &http.Client{Transport: keys}
+	client := NewClient("old-key", WithTransport(tr), WithFlushInterval(time.Hour))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "a"))
+	client.Flush()
+	client.SetAPIKey("new-key")
+	client.Track(NewEvent(EventToolCall, "b"))
+	client.Flush()
+
+	if len(keys.keys) != 2 || keys.keys[0] != "Bearer old-key" || keys.keys[1] != "Bearer new-key" {
+		t.Errorf("expected the rotated key on the second call, got %v", keys.keys)
+	}
+}
+
+func TestGRPCTransportEncodesStruct(t *testing.T) {
+	type usage struct {
+		Tokens int `json:"tokens"`
+	}
+	payload := map[string]any{
+		"messages": []map[string]any{{"role": "user", "content": "hi"}},
+		"tags":     []string{"prod"},
+		"labels":   map[string]string{"team": "search"},
+		"raw":      json.RawMessage(`{"a":[1,null]}`),
+		"usage":    usage{Tokens: 12},
+		"count":    int64(3),
+		"cost":     0.25,
+		"stream":   false,
+		"stop":     nil,
+		"empty":    "",
+	}
+	msg, err := encodeEventProto(Event{Payload: payload})
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	var want map[string]any
+	data, _ := json.Marshal(payload)
+	_ = json.Unmarshal(data, &want)
+	got := decodeProtoStruct(t, decodeProtoFields(t, msg)[4][0])
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the payload as JSON decodes it\n got: %v\nwant: %v", got, want)
+	}
+
+	if _, err := encodeEventProto(Event{Payload: map[string]any{"score": math.NaN()}}); err == nil {
+		t.Error("expected NaN to fail to encode")
+	}
+	loop := map[string]any{}
+	loop["self"] = loop
+	if _, err := encodeEventProto(Event{Metadata: loop}); err == nil {
+		t.Error("expected a map containing itself to fail to encode")
+	}
+}
+
+func TestGRPCTransportSignsBatches(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		frame, _ := io.ReadAll(r.Body)
+		if err := VerifySignature(pub, frame, r.Header.Get(SignatureHeader)); err != nil {
+			t.Errorf("expected a valid signature of the gRPC message: %v", err)
+		}
+		w.Header().Set("Grpc-Status", "0")
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	tr := NewGRPCTransport(server.URL, "k")
+	tr.HTTPClient = server.Client()
+	tr.Compression = "gzip"
+	// The signing key is picked up even when given after the transport
+	client := NewClient("k", WithTransport(tr), WithSigningKey(priv))
+	client.Track(NewEvent(EventToolCall, "a"))
+	if err := client.Close(); err != nil {
+		t.Fatalf("expected clean close, got %v", err)
+	}
+}
+
+// keyRecorder records the Authorization header of each request
+type keyRecorder struct {
+	next http.RoundTripper
+	keys []string
+}
+
+func (k *keyRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	k.keys = append(k.keys, req.Header.Get("Authorization"))
+	return k.next.RoundTrip(req)
+}
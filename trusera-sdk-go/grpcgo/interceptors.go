@@ -0,0 +1,207 @@
+// Package grpcgo records gRPC calls made and served with grpc-go
+// (google.golang.org/grpc) as Trusera spans and propagates trace context
+// over gRPC metadata, so agents in a fleet that call each other over gRPC
+// share one trace.
+package grpcgo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// traceparentKey is the W3C traceparent header as gRPC metadata key
+var traceparentKey = strings.ToLower(trusera.TraceparentHeader)
+
+// SpanStarter starts spans; *trusera.Client and *trusera.Agent satisfy it
+type SpanStarter interface {
+	StartSpan(ctx context.Context, name string) (context.Context, *trusera.Span)
+}
+
+// Options configures the interceptors
+type Options struct {
+	// Skip excludes methods, such as health checks, from instrumentation.
+	// It receives the full method name, e.g. "/grpc.health.v1.Health/Check".
+	Skip func(fullMethod string) bool
+}
+
+func (o Options) skip(fullMethod string) bool {
+	return o.Skip != nil && o.Skip(fullMethod)
+}
+
+// UnaryServerInterceptor records each unary RPC served as a span, continuing
+// the caller's trace if its metadata carries a traceparent
+func UnaryServerInterceptor(tracer SpanStarter, opts Options) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if opts.skip(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		ctx, span := startServerSpan(ctx, tracer, info.FullMethod)
+		resp, err := handler(ctx, req)
+		finish(span, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor records each streaming RPC served as a span that
+// ends when the handler returns
+func StreamServerInterceptor(tracer SpanStarter, opts Options) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if opts.skip(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		ctx, span := startServerSpan(ss.Context(), tracer, info.FullMethod)
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		finish(span, err)
+		return err
+	}
+}
+
+// UnaryClientInterceptor records each unary RPC made as a span under the
+// active span in ctx and sends the span to the server as a traceparent
+func UnaryClientInterceptor(tracer SpanStarter, opts Options) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		if opts.skip(method) {
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}
+		ctx, span := startClientSpan(ctx, tracer, method, cc)
+		err := invoker(ctx, method, req, reply, cc, callOpts...)
+		finish(span, err)
+		return err
+	}
+}
+
+// StreamClientInterceptor records each streaming RPC made as a span that
+// ends when the stream returns its final message or an error
+func StreamClientInterceptor(tracer SpanStarter, opts Options) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if opts.skip(method) {
+			return streamer(ctx, desc, cc, method, callOpts...)
+		}
+		ctx, span := startClientSpan(ctx, tracer, method, cc)
+		cs, err := streamer(ctx, desc, cc, method, callOpts...)
+		if err != nil {
+			finish(span, err)
+			return nil, err
+		}
+		return &clientStream{ClientStream: cs, span: span, serverStreams: desc.ServerStreams}, nil
+	}
+}
+
+// startServerSpan starts the span for an incoming RPC
+func startServerSpan(ctx context.Context, tracer SpanStarter, fullMethod string) (context.Context, *trusera.Span) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if tp := md.Get(traceparentKey); len(tp) > 0 {
+			ctx = trusera.ExtractHeaders(ctx, http.Header{trusera.TraceparentHeader: tp[:1]})
+		}
+	}
+	ctx, span := tracer.StartSpan(ctx, fullMethod)
+	annotate(span, fullMethod, "server")
+	return ctx, span
+}
+
+// startClientSpan starts the span for an outgoing RPC and adds its
+// traceparent to the outgoing metadata
+func startClientSpan(ctx context.Context, tracer SpanStarter, method string, cc *grpc.ClientConn) (context.Context, *trusera.Span) {
+	ctx, span := tracer.StartSpan(ctx, method)
+	annotate(span, method, "client")
+	if cc != nil {
+		span.SetAttribute("rpc.target", cc.Target())
+	}
+
+	h := http.Header{}
+	trusera.InjectHeaders(ctx, h)
+	if tp := h.Get(trusera.TraceparentHeader); tp != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, traceparentKey, tp)
+	}
+	return ctx, span
+}
+
+// annotate records the RPC's service and method on span
+func annotate(span *trusera.Span, fullMethod, role string) {
+	service, method := splitMethod(fullMethod)
+	span.SetAttribute("rpc.system", "grpc")
+	span.SetAttribute("rpc.role", role)
+	span.SetAttribute("rpc.service", service)
+	span.SetAttribute("rpc.method", method)
+}
+
+// splitMethod splits "/pkg.Service/Method" into its service and method
+func splitMethod(fullMethod string) (string, string) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return "", fullMethod
+	}
+	return service, method
+}
+
+// finish records the RPC's status code on span and ends it
+func finish(span *trusera.Span, err error) {
+	code := status.Code(err)
+	span.SetAttribute("rpc.grpc.status_code", code.String())
+	if code != codes.OK {
+		span.SetError(err)
+	}
+	span.End()
+}
+
+// serverStream carries the span's context to streaming handlers
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// clientStream ends the span when the RPC completes
+type clientStream struct {
+	grpc.ClientStream
+	span          *trusera.Span
+	serverStreams bool
+	once          sync.Once
+}
+
+func (s *clientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case errors.Is(err, io.EOF):
+		s.end(nil)
+	case err != nil:
+		s.end(err)
+	case !s.serverStreams:
+		// Client-streaming RPCs complete with their single response
+		s.end(nil)
+	}
+	return err
+}
+
+func (s *clientStream) SendMsg(m any) error {
+	err := s.ClientStream.SendMsg(m)
+	if err != nil && !errors.Is(err, io.EOF) {
+		s.end(err)
+	}
+	return err
+}
+
+func (s *clientStream) Header() (metadata.MD, error) {
+	md, err := s.ClientStream.Header()
+	if err != nil {
+		s.end(err)
+	}
+	return md, err
+}
+
+func (s *clientStream) end(err error) {
+	s.once.Do(func() { finish(s.span, err) })
+}
@@ -0,0 +1,110 @@
+package grpcgo
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/truseratest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newHealthPair starts an instrumented health server and returns a client
+// connection instrumented with the other recording client
+func newHealthPair(t *testing.T, server, client *truseratest.RecordingClient) (*health.Server, healthpb.HealthClient) {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryServerInterceptor(server.Client, Options{})),
+		grpc.StreamInterceptor(StreamServerInterceptor(server.Client, Options{})),
+	)
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(srv, hs)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(client.Client, Options{})),
+		grpc.WithStreamInterceptor(StreamClientInterceptor(client.Client, Options{})),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return hs, healthpb.NewHealthClient(conn)
+}
+
+func TestUnaryPropagatesTrace(t *testing.T) {
+	server, client := truseratest.NewRecordingClient(), truseratest.NewRecordingClient()
+	defer server.Close()
+	defer client.Close()
+	_, hc := newHealthPair(t, server, client)
+
+	ctx, run := client.StartSpan(context.Background(), "plan")
+	if _, err := hc.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if _, err := hc.Check(ctx, &healthpb.HealthCheckRequest{Service: "missing"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+	run.End()
+
+	calls := client.Events(truseratest.OfType(trusera.EventSpan), truseratest.Named("/grpc.health.v1.Health/Check"))
+	served := server.Events(truseratest.OfType(trusera.EventSpan), truseratest.Named("/grpc.health.v1.Health/Check"))
+	if len(calls) != 2 || len(served) != 2 {
+		t.Fatalf("expected 2 client and 2 server spans, got %d and %d", len(calls), len(served))
+	}
+
+	call, serve := calls[0], served[0]
+	if call.Metadata["trace_id"] != run.TraceID() || serve.Metadata["trace_id"] != run.TraceID() {
+		t.Errorf("expected both spans in the caller's trace, got %v and %v", call.Metadata, serve.Metadata)
+	}
+	if serve.Metadata["parent_span_id"] != call.Metadata["span_id"] {
+		t.Errorf("expected the server span under the client span, got %v and %v", serve.Metadata, call.Metadata)
+	}
+	if call.Payload["rpc.service"] != "grpc.health.v1.Health" || call.Payload["rpc.method"] != "Check" ||
+		call.Payload["rpc.role"] != "client" || serve.Payload["rpc.role"] != "server" {
+		t.Errorf("unexpected RPC attributes %v / %v", call.Payload, serve.Payload)
+	}
+	if calls[1].Payload["status"] != "error" || served[1].Payload["rpc.grpc.status_code"] != "NotFound" {
+		t.Errorf("expected the failed call recorded on both sides, got %v / %v", calls[1].Payload, served[1].Payload)
+	}
+}
+
+func TestStreamSpansEnd(t *testing.T) {
+	server, client := truseratest.NewRecordingClient(), truseratest.NewRecordingClient()
+	defer server.Close()
+	defer client.Close()
+	hs, hc := newHealthPair(t, server, client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := hc.Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	hs.Shutdown() // Sends NOT_SERVING
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	cancel()
+	if _, err := stream.Recv(); status.Code(err) != codes.Canceled {
+		t.Fatalf("expected Canceled, got %v", err)
+	}
+
+	call := truseratest.AssertEventEmitted(t, client, truseratest.Named("/grpc.health.v1.Health/Watch"))
+	if call.Payload["rpc.grpc.status_code"] != "Canceled" {
+		t.Errorf("expected the stream's final status, got %v", call.Payload)
+	}
+}
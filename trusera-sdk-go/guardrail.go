@@ -0,0 +1,92 @@
+package trusera
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+// Severity grades a guardrail violation
+type Severity string
+
+const (
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
+
+// GuardrailAction is what a guardrail did about a violation
+type GuardrailAction string
+
+const (
+	GuardrailBlocked  GuardrailAction = "blocked"
+	GuardrailRedacted GuardrailAction = "redacted"
+	GuardrailWarned   GuardrailAction = "warned"
+	GuardrailLogged   GuardrailAction = "logged"
+)
+
+// GuardrailEvent describes a policy violation found by a guardrail framework
+// or custom validator. Matched content is never sent, only its SHA-256 hash.
+type GuardrailEvent struct {
+	Policy         string          // Name of the violated policy, required
+	Severity       Severity        // Defaults to SeverityMedium
+	Action         GuardrailAction // Defaults to GuardrailLogged
+	MatchedContent string          // Hashed before sending
+	ContentHash    string          // Used instead of hashing MatchedContent, if set
+	Source         string          // Guardrail framework or validator that fired
+	Message        string
+	Metadata       map[string]any
+}
+
+// ReportGuardrail records a guardrail violation. Violations are never
+// sampled out.
+func (c *Client) ReportGuardrail(g GuardrailEvent) error {
+	event, err := g.event()
+	if err != nil {
+		return err
+	}
+	c.Track(event)
+	return nil
+}
+
+// event builds the EventGuardrailViolation for g, applying the defaults
+func (g GuardrailEvent) event() (Event, error) {
+	if g.Policy == "" {
+		return Event{}, errors.New("guardrail policy is required")
+	}
+	if g.Severity == "" {
+		g.Severity = SeverityMedium
+	}
+	if g.Action == "" {
+		g.Action = GuardrailLogged
+	}
+	if g.ContentHash == "" && g.MatchedContent != "" {
+		g.ContentHash = HashContent(g.MatchedContent)
+	}
+
+	event := NewEvent(EventGuardrailViolation, g.Policy).
+		WithPayload("policy", g.Policy).
+		WithPayload("severity", string(g.Severity)).
+		WithPayload("action", string(g.Action))
+	if g.ContentHash != "" {
+		event = event.WithPayload("content_hash", g.ContentHash)
+	}
+	if g.Source != "" {
+		event = event.WithPayload("source", g.Source)
+	}
+	if g.Message != "" {
+		event = event.WithPayload("message", g.Message)
+	}
+	for k, v := range g.Metadata {
+		event = event.WithMetadata(k, v)
+	}
+	return event, nil
+}
+
+// HashContent returns the hex-encoded SHA-256 of s, the form in which
+// guardrail matches are reported
+func HashContent(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
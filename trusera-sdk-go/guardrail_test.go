@@ -0,0 +1,83 @@
+package trusera
+
+import "testing"
+
+func TestReportGuardrail(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	err := client.ReportGuardrail(GuardrailEvent{
+		Policy:         "pii.ssn",
+		Severity:       SeverityHigh,
+		Action:         GuardrailBlocked,
+		MatchedContent: "123-45-6789",
+		Source:         "custom-validator",
+		Metadata:       map[string]any{"tenant_id": "acme"},
+	})
+	if err != nil {
+		t.Fatalf("ReportGuardrail failed: %v", err)
+	}
+
+	events := queuedEvents(client)
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	e := events[0]
+	if e.Type != EventGuardrailViolation || e.Name != "pii.ssn" {
+		t.Errorf("expected guardrail_violation pii.ssn, got %s %s", e.Type, e.Name)
+	}
+	if e.Payload["severity"] != "high" || e.Payload["action"] != "blocked" || e.Payload["source"] != "custom-validator" {
+		t.Errorf("unexpected payload: %v", e.Payload)
+	}
+	if e.Payload["content_hash"] != HashContent("123-45-6789") {
+		t.Errorf("expected hashed content, got %v", e.Payload["content_hash"])
+	}
+	for _, v := range e.Payload {
+		if v == "123-45-6789" {
+			t.Error("matched content must not be sent")
+		}
+	}
+	if e.Metadata["tenant_id"] != "acme" {
+		t.Errorf("expected metadata to be kept, got %v", e.Metadata)
+	}
+}
+
+func TestReportGuardrailDefaults(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	if err := client.ReportGuardrail(GuardrailEvent{Policy: "toxicity", ContentHash: "abc"}); err != nil {
+		t.Fatalf("ReportGuardrail failed: %v", err)
+	}
+
+	e := queuedEvents(client)[0]
+	if e.Payload["severity"] != "medium" || e.Payload["action"] != "logged" {
+		t.Errorf("expected medium/logged defaults, got %v", e.Payload)
+	}
+	if e.Payload["content_hash"] != "abc" {
+		t.Errorf("expected precomputed hash to be kept, got %v", e.Payload["content_hash"])
+	}
+}
+
+func TestReportGuardrailRequiresPolicy(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	if err := client.ReportGuardrail(GuardrailEvent{}); err == nil {
+		t.Error("expected error for missing policy")
+	}
+	if got := client.Stats().Tracked; got != 0 {
+		t.Errorf("expected nothing tracked, got %d", got)
+	}
+}
+
+func TestReportGuardrailBypassesSampling(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000), WithSampler(Probabilistic(0)))
+	defer client.Close()
+
+	_ = client.ReportGuardrail(GuardrailEvent{Policy: "jailbreak"})
+
+	if got := len(queuedEvents(client)); got != 1 {
+		t.Errorf("expected violation kept despite sampling, got %d", got)
+	}
+}
@@ -0,0 +1,166 @@
+package trusera
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// healthInfo reports SDK health for the fleet heartbeat. It returns the stats
+// snapshot it was built from so the caller can mark it as reported once the
+// heartbeat is delivered.
+func (c *Client) healthInfo() (map[string]interface{}, Stats) {
+	s := c.Stats()
+
+	c.mu.Lock()
+	prev := c.lastHeartbeatStats
+	c.mu.Unlock()
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	lastFlush := map[string]interface{}{
+		"ok":         s.LastFlushError == "",
+		"latency_ms": s.LastFlushDuration.Milliseconds(),
+	}
+	if !s.LastFlushAt.IsZero() {
+		lastFlush["at"] = s.LastFlushAt.UTC().Format(time.RFC3339)
+	}
+	if s.LastFlushError != "" {
+		lastFlush["error"] = s.LastFlushError
+	}
+
+	health := map[string]interface{}{
+		"queue_depth":    s.Queued,
+		"max_queue_size": c.maxQueueSize,
+		"dropped_total":  s.Dropped,
+		"breaker_state":  c.BreakerState().String(),
+		"rate_limited":   s.RateLimited,
+		"last_flush":     lastFlush,
+		"since_last_heartbeat": map[string]interface{}{
+			"tracked":       s.Tracked - prev.Tracked,
+			"flushed":       s.Flushed - prev.Flushed,
+			"dropped":       s.Dropped - prev.Dropped,
+			"sampled_out":   s.SampledOut - prev.SampledOut,
+			"filtered":      s.Filtered - prev.Filtered,
+			"truncated":     s.Truncated - prev.Truncated,
+			"dead_lettered": s.DeadLettered - prev.DeadLettered,
+			"invalid":       s.Invalid - prev.Invalid,
+			"flush_errors":  s.FlushErrors - prev.FlushErrors,
+		},
+		"memory": map[string]interface{}{
+			"heap_alloc_bytes": mem.HeapAlloc,
+			"heap_inuse_bytes": mem.HeapInuse,
+			"sys_bytes":        mem.Sys,
+			"num_gc":           mem.NumGC,
+			"goroutines":       runtime.NumGoroutine(),
+		},
+	}
+	if len(c.healthChecks) > 0 {
+		health["checks"], health["status"] = c.runHealthChecks()
+	}
+	return health, s
+}
+
+// markHeartbeat records the stats reported by a delivered heartbeat, so the
+// next one counts events from there
+func (c *Client) markHeartbeat(s Stats) {
+	c.mu.Lock()
+	c.lastHeartbeatStats = s
+	c.mu.Unlock()
+}
+
+// healthCheckTimeout bounds how long a heartbeat waits for a health check
+const healthCheckTimeout = 5 * time.Second
+
+// HealthState is the outcome of a health check
+type HealthState string
+
+const (
+	Healthy   HealthState = "healthy"
+	Degraded  HealthState = "degraded"
+	Unhealthy HealthState = "unhealthy"
+)
+
+// HealthStatus is the result of an application health check
+type HealthStatus struct {
+	State   HealthState    `json:"state"`
+	Message string         `json:"message,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// healthCheck is a named application health check
+type healthCheck struct {
+	name string
+	fn   func() HealthStatus
+}
+
+// WithHealthCheck registers an application health check, such as database or
+// vector store reachability, whose result is included in every fleet
+// heartbeat. Checks that panic or take longer than 5 seconds are reported as
+// unhealthy. Registering a name again replaces the earlier check.
+func WithHealthCheck(name string, fn func() HealthStatus) Option {
+	return func(c *Client) {
+		if fn == nil {
+			return
+		}
+		for i, hc := range c.healthChecks {
+			if hc.name == name {
+				c.healthChecks[i].fn = fn
+				return
+			}
+		}
+		c.healthChecks = append(c.healthChecks, healthCheck{name: name, fn: fn})
+	}
+}
+
+// runHealthChecks runs all checks concurrently and returns their results and
+// the worst state among them
+func (c *Client) runHealthChecks() (map[string]HealthStatus, HealthState) {
+	results := make([]HealthStatus, len(c.healthChecks))
+	var wg sync.WaitGroup
+	for i, hc := range c.healthChecks {
+		wg.Add(1)
+		go func(i int, hc healthCheck) {
+			defer wg.Done()
+			results[i] = runHealthCheck(hc.fn)
+		}(i, hc)
+	}
+	wg.Wait()
+
+	overall := Healthy
+	out := make(map[string]HealthStatus, len(results))
+	for i, r := range results {
+		if r.State != Healthy && r.State != Degraded {
+			r.State = Unhealthy
+		}
+		if r.State == Unhealthy || (r.State == Degraded && overall == Healthy) {
+			overall = r.State
+		}
+		out[c.healthChecks[i].name] = r
+	}
+	return out, overall
+}
+
+// runHealthCheck calls fn, converting panics and timeouts into unhealthy results
+func runHealthCheck(fn func() HealthStatus) HealthStatus {
+	done := make(chan HealthStatus, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- HealthStatus{State: Unhealthy, Message: fmt.Sprintf("health check panicked: %v", r)}
+			}
+		}()
+		done <- fn()
+	}()
+
+	timer := time.NewTimer(healthCheckTimeout)
+	defer timer.Stop()
+	select {
+	case status := <-done:
+		return status
+	case <-timer.C:
+		return HealthStatus{State: Unhealthy, Message: "health check timed out"}
+	}
+}
@@ -0,0 +1,118 @@
+package trusera
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestHeartbeatReportsHealth(t *testing.T) {
+	var mu sync.Mutex
+	var heartbeats []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/fleet/fleet-1/heartbeat":
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			heartbeats = append(heartbeats, body)
+			mu.Unlock()
+		case "/v1/events":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithBatchSize(1000))
+	defer client.Close()
+	client.fleetAgentID = "fleet-1"
+
+	client.Track(NewEvent(EventToolCall, "a"))
+	client.Track(NewEvent(EventToolCall, "b"))
+	_ = client.Flush()
+	client.Track(NewEvent(EventToolCall, "c"))
+	client.sendHeartbeat()
+	client.sendHeartbeat()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(heartbeats) != 2 {
+		t.Fatalf("expected 2 heartbeats, got %d", len(heartbeats))
+	}
+
+	health, ok := heartbeats[0]["health"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected health in heartbeat, got %v", heartbeats[0])
+	}
+	if health["queue_depth"] != float64(1) || health["breaker_state"] != "closed" {
+		t.Errorf("unexpected queue health: %v", health)
+	}
+	lastFlush := health["last_flush"].(map[string]any)
+	if lastFlush["ok"] != false || lastFlush["error"] == nil {
+		t.Errorf("expected failed last flush, got %v", lastFlush)
+	}
+	since := health["since_last_heartbeat"].(map[string]any)
+	if since["tracked"] != float64(3) || since["flush_errors"] != float64(1) || since["dropped"] != float64(2) {
+		t.Errorf("unexpected counts since last heartbeat: %v", since)
+	}
+	if mem := health["memory"].(map[string]any); mem["heap_alloc_bytes"].(float64) <= 0 {
+		t.Errorf("expected memory stats, got %v", mem)
+	}
+
+	since = heartbeats[1]["health"].(map[string]any)["since_last_heartbeat"].(map[string]any)
+	if since["tracked"] != float64(0) {
+		t.Errorf("expected counts reset after a delivered heartbeat, got %v", since)
+	}
+}
+
+func TestHealthChecksInHeartbeat(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL),
+		WithHealthCheck("database", func() HealthStatus {
+			return HealthStatus{State: Healthy, Details: map[string]any{"latency_ms": 3}}
+		}),
+		WithHealthCheck("vector_store", func() HealthStatus {
+			return HealthStatus{State: Degraded, Message: "replica lag"}
+		}),
+		WithHealthCheck("cache", func() HealthStatus { panic("boom") }),
+	)
+	defer client.Close()
+	client.fleetAgentID = "fleet-1"
+
+	client.sendHeartbeat()
+
+	health := body["health"].(map[string]any)
+	if health["status"] != "unhealthy" {
+		t.Errorf("expected worst state overall, got %v", health["status"])
+	}
+	checks := health["checks"].(map[string]any)
+	if db := checks["database"].(map[string]any); db["state"] != "healthy" || db["details"] == nil {
+		t.Errorf("unexpected database check %v", db)
+	}
+	if vs := checks["vector_store"].(map[string]any); vs["state"] != "degraded" || vs["message"] != "replica lag" {
+		t.Errorf("unexpected vector_store check %v", vs)
+	}
+	if cache := checks["cache"].(map[string]any); cache["state"] != "unhealthy" {
+		t.Errorf("expected panicking check reported unhealthy, got %v", cache)
+	}
+}
+
+func TestWithHealthCheckReplacesByName(t *testing.T) {
+	client := NewClient("test-key",
+		WithHealthCheck("db", func() HealthStatus { return HealthStatus{State: Unhealthy} }),
+		WithHealthCheck("db", func() HealthStatus { return HealthStatus{State: Healthy} }),
+	)
+	defer client.Close()
+
+	checks, overall := client.runHealthChecks()
+	if len(checks) != 1 || overall != Healthy {
+		t.Errorf("expected the later check to replace the first, got %v %s", checks, overall)
+	}
+}
@@ -0,0 +1,134 @@
+package trusera
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Metric kinds reported in the "kind" payload of EventMetric events
+const (
+	MetricCounter = "counter"
+	MetricGauge   = "gauge"
+)
+
+// Counter is a monotonically increasing count, such as tool call errors.
+// Increments are aggregated in memory and reported as one EventMetric event
+// per flush carrying the increase since the previous flush.
+type Counter struct {
+	name  string
+	delta atomic.Uint64 // Increase since the last flush
+	total atomic.Uint64
+}
+
+// Inc adds one to the counter
+func (m *Counter) Inc() {
+	m.Add(1)
+}
+
+// Add adds n to the counter
+func (m *Counter) Add(n uint64) {
+	m.delta.Add(n)
+	m.total.Add(n)
+}
+
+// Gauge is a value that can go up and down, such as open sessions. The
+// latest value is reported as an EventMetric event on each flush after it
+// was set.
+type Gauge struct {
+	name  string
+	bits  atomic.Uint64 // math.Float64bits of the value
+	dirty atomic.Bool   // Set since the last flush
+}
+
+// Set records the gauge's current value
+func (m *Gauge) Set(v float64) {
+	m.bits.Store(math.Float64bits(v))
+	m.dirty.Store(true)
+}
+
+// Value returns the gauge's current value
+func (m *Gauge) Value() float64 {
+	return math.Float64frombits(m.bits.Load())
+}
+
+// instruments holds the counters and gauges created by a client
+type instruments struct {
+	mu       sync.Mutex
+	counters map[string]*Counter
+	gauges   map[string]*Gauge
+}
+
+// Counter returns the counter named name, creating it on first use. Keep the
+// returned handle for hot paths; increments never allocate or take a lock.
+func (c *Client) Counter(name string) *Counter {
+	c.instruments.mu.Lock()
+	defer c.instruments.mu.Unlock()
+
+	if c.instruments.counters == nil {
+		c.instruments.counters = make(map[string]*Counter)
+	}
+	m := c.instruments.counters[name]
+	if m == nil {
+		m = &Counter{name: name}
+		c.instruments.counters[name] = m
+	}
+	return m
+}
+
+// Gauge returns the gauge named name, creating it on first use
+func (c *Client) Gauge(name string) *Gauge {
+	c.instruments.mu.Lock()
+	defer c.instruments.mu.Unlock()
+
+	if c.instruments.gauges == nil {
+		c.instruments.gauges = make(map[string]*Gauge)
+	}
+	m := c.instruments.gauges[name]
+	if m == nil {
+		m = &Gauge{name: name}
+		c.instruments.gauges[name] = m
+	}
+	return m
+}
+
+// flushInstruments tracks a metric event for every counter that increased
+// and every gauge that was set since the last call
+func (c *Client) flushInstruments() {
+	c.instruments.mu.Lock()
+	counters := make([]*Counter, 0, len(c.instruments.counters))
+	for _, m := range c.instruments.counters {
+		counters = append(counters, m)
+	}
+	gauges := make([]*Gauge, 0, len(c.instruments.gauges))
+	for _, m := range c.instruments.gauges {
+		gauges = append(gauges, m)
+	}
+	c.instruments.mu.Unlock()
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	for _, m := range counters {
+		if delta := m.delta.Swap(0); delta > 0 {
+			c.Track(NewEvent(EventMetric, m.name).
+				WithPayload("kind", MetricCounter).
+				WithPayload("value", delta).
+				WithPayload("total", m.total.Load()).
+				WithPayload("observed_at", now))
+		}
+	}
+	for _, m := range gauges {
+		if m.dirty.Swap(false) {
+			c.Track(NewEvent(EventMetric, m.name).
+				WithPayload("kind", MetricGauge).
+				WithPayload("value", m.Value()).
+				WithPayload("observed_at", now))
+		}
+	}
+}
+
+// flushAggregates turns operation timings, counters and gauges into events
+func (c *Client) flushAggregates() {
+	c.flushTimings()
+	c.flushInstruments()
+}
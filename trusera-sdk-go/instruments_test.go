@@ -0,0 +1,77 @@
+package trusera
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// metricEvents returns the metric events delivered to transport by name
+func metricEvents(transport *recordingTransport) map[string]Event {
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	events := map[string]Event{}
+	for _, b := range transport.batches {
+		for _, e := range b.Events {
+			if e.Type == EventMetric {
+				events[e.Name] = e
+			}
+		}
+	}
+	transport.batches = nil
+	return events
+}
+
+func TestCounterAndGauge(t *testing.T) {
+	transport := &recordingTransport{}
+	client := NewClient("k", WithTransport(transport), WithFlushInterval(time.Hour))
+	defer client.Close()
+
+	failures := client.Counter("tool_call_errors")
+	if client.Counter("tool_call_errors") != failures {
+		t.Fatal("expected the same counter for the same name")
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			failures.Inc()
+		}()
+	}
+	wg.Wait()
+	failures.Add(5)
+	client.Gauge("open_sessions").Set(3)
+	client.Gauge("open_sessions").Set(7)
+	client.Counter("unused")
+
+	if n := len(queuedEvents(client)); n != 0 {
+		t.Fatalf("expected metrics to stay aggregated until flush, got %d events", n)
+	}
+	if err := client.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	metrics := metricEvents(transport)
+	if len(metrics) != 2 {
+		t.Fatalf("expected only changed metrics reported, got %+v", metrics)
+	}
+	if p := metrics["tool_call_errors"].Payload; p["kind"] != MetricCounter || p["value"] != uint64(15) || p["total"] != uint64(15) {
+		t.Errorf("unexpected counter event %v", p)
+	}
+	if p := metrics["open_sessions"].Payload; p["kind"] != MetricGauge || p["value"] != 7.0 {
+		t.Errorf("unexpected gauge event %v", p)
+	}
+
+	failures.Inc()
+	if err := client.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	metrics = metricEvents(transport)
+	if len(metrics) != 1 {
+		t.Fatalf("expected only the counter reported again, got %+v", metrics)
+	}
+	if p := metrics["tool_call_errors"].Payload; p["value"] != uint64(1) || p["total"] != uint64(16) {
+		t.Errorf("expected the increase since the last flush, got %v", p)
+	}
+}
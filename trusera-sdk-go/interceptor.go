@@ -28,7 +28,7 @@ type InterceptorOptions struct {
 }
 
 // WrapHTTPClient wraps an http.Client to intercept all outbound requests
-func WrapHTTPClient(client *http.Client, truseraClient *Client, opts InterceptorOptions) *http.Client {
+func WrapHTTPClient(client *http.Client, truseraClient Tracker, opts InterceptorOptions) *http.Client {
 	if client == nil {
 		client = &http.Client{}
 	}
@@ -50,12 +50,17 @@ func WrapHTTPClient(client *http.Client, truseraClient *Client, opts Interceptor
 // interceptingTransport wraps http.RoundTripper
 type interceptingTransport struct {
 	base   http.RoundTripper
-	client *Client
+	client Tracker
 	opts   InterceptorOptions
 }
 
 // RoundTrip intercepts and records HTTP requests
 func (t *interceptingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Enforce the client's egress policy, even on excluded URLs
+	if err := t.enforceEgress(req); err != nil {
+		return nil, err
+	}
+
 	// Check if URL should be excluded from interception
 	if t.shouldExclude(req.URL.String()) {
 		return t.base.RoundTrip(req)
@@ -99,21 +104,21 @@ func (t *interceptingTransport) RoundTrip(req *http.Request) (*http.Response, er
 
 		switch t.opts.Enforcement {
 		case ModeBlock:
-			t.client.Track(event)
+			trackContext(t.client, req.Context(), event)
 			return nil, errors.New("request blocked by Trusera policy")
 
 		case ModeWarn:
 			event = event.WithMetadata("warning", "URL matches block pattern but allowed in warn mode")
-			t.client.Track(event)
+			trackContext(t.client, req.Context(), event)
 			// Continue with request
 
 		case ModeLog:
 			// Just record, no action
-			t.client.Track(event)
+			trackContext(t.client, req.Context(), event)
 		}
 	} else {
 		event = event.WithPayload("enforcement_action", "allowed")
-		t.client.Track(event)
+		trackContext(t.client, req.Context(), event)
 	}
 
 	// Forward request to base transport
@@ -124,7 +129,7 @@ func (t *interceptingTransport) RoundTrip(req *http.Request) (*http.Response, er
 			WithPayload("method", req.Method).
 			WithPayload("url", req.URL.String()).
 			WithPayload("error", err.Error())
-		t.client.Track(errorEvent)
+		trackContext(t.client, req.Context(), errorEvent)
 		return resp, err
 	}
 
@@ -134,11 +139,38 @@ func (t *interceptingTransport) RoundTrip(req *http.Request) (*http.Response, er
 		WithPayload("url", req.URL.String()).
 		WithPayload("status_code", resp.StatusCode).
 		WithPayload("status", resp.Status)
-	t.client.Track(responseEvent)
+	trackContext(t.client, req.Context(), responseEvent)
 
 	return resp, nil
 }
 
+// enforceEgress records a request to a host outside the egress allow-list
+// of the tracker's client, and rejects it in ModeBlock
+func (t *interceptingTransport) enforceEgress(req *http.Request) error {
+	c := clientOf(t.client)
+	if c == nil {
+		return nil
+	}
+	rules := c.checkEgress(req.URL)
+	if rules == nil {
+		return nil
+	}
+
+	mode := rules.policy.Mode
+	trackContext(t.client, req.Context(), egressViolation(req.URL, mode))
+	if mode != ModeBlock {
+		return nil
+	}
+	trackContext(t.client, req.Context(), NewEvent(EventAPICall, req.Method+" "+req.URL.String()).
+		WithPayload("method", req.Method).
+		WithPayload("url", req.URL.String()).
+		WithPayload("blocked", true).
+		WithPayload("enforcement_action", "blocked").
+		WithPayload("egress_denied", req.URL.Hostname()).
+		WithMetadata("enforcement_mode", string(mode)))
+	return fmt.Errorf("%w: %s is not on the allow-list", ErrEgressBlocked, req.URL.Hostname())
+}
+
 // shouldExclude checks if URL matches any exclude patterns
 func (t *interceptingTransport) shouldExclude(url string) bool {
 	for _, pattern := range t.opts.ExcludePatterns {
@@ -182,12 +214,12 @@ func sanitizeHeaders(headers http.Header) map[string]string {
 }
 
 // CreateInterceptedClient creates a new http.Client with Trusera interception
-func CreateInterceptedClient(truseraClient *Client, opts InterceptorOptions) *http.Client {
+func CreateInterceptedClient(truseraClient Tracker, opts InterceptorOptions) *http.Client {
 	return WrapHTTPClient(&http.Client{}, truseraClient, opts)
 }
 
 // InterceptDefault wraps http.DefaultClient with Trusera interception
-func InterceptDefault(truseraClient *Client, opts InterceptorOptions) {
+func InterceptDefault(truseraClient Tracker, opts InterceptorOptions) {
 	http.DefaultClient = WrapHTTPClient(http.DefaultClient, truseraClient, opts)
 }
 
@@ -0,0 +1,57 @@
+package trusera
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrNotRegistered is returned by fleet calls made before the client has
+// registered with the fleet (see WithAutoRegister)
+var ErrNotRegistered = errors.New("client is not registered with the fleet")
+
+// ReportInventory posts a model inventory to /api/v1/fleet/{id}/inventory.
+// The inventory is sent as JSON; the discovery package builds one by scanning
+// the process.
+func (c *Client) ReportInventory(ctx context.Context, inventory any) error {
+	c.mu.Lock()
+	fleetID := c.fleetAgentID
+	c.mu.Unlock()
+	if fleetID == "" {
+		return ErrNotRegistered
+	}
+
+	if c.sink != nil {
+		return c.sink.write("inventory", fleetID, inventory)
+	}
+
+	body, err := json.Marshal(inventory)
+	if err != nil {
+		return fmt.Errorf("failed to marshal inventory: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/v1/fleet/%s/inventory", c.baseURL, fleetID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.currentAPIKey())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to report inventory: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return newAPIError(resp)
+	}
+	// Drain body to allow connection reuse
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	return nil
+}
@@ -0,0 +1,348 @@
+// Package k8s detects the Kubernetes pod an agent runs in so that fleet
+// registration, heartbeats and events can be mapped to workloads. It reads
+// downward API environment variables and label files and can fill in the
+// rest by looking the pod up with the service account token.
+//
+//	meta, err := k8s.Detect(ctx, k8s.Options{QueryAPI: true})
+//	if err != nil {
+//		log.Printf("k8s metadata incomplete: %v", err)
+//	}
+//	client := trusera.NewClient(apiKey, trusera.WithEnricher(meta))
+package k8s
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default locations of the service account and downward API volume
+const (
+	DefaultServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	DefaultLabelsFile        = "/etc/podinfo/labels"
+)
+
+// podTemplateHashLabel is set by the Deployment controller on its pods
+const podTemplateHashLabel = "pod-template-hash"
+
+// Options configures Detect. The zero value reads the standard downward API
+// variables and files without contacting the API server.
+type Options struct {
+	// ServiceAccountDir holds the token, namespace and ca.crt files.
+	// Defaults to DefaultServiceAccountDir.
+	ServiceAccountDir string
+	// LabelsFile is a downward API volume file with the pod's labels.
+	// Defaults to DefaultLabelsFile.
+	LabelsFile string
+	// QueryAPI looks the pod up through the API server with the service
+	// account token to fill in fields the downward API did not provide. The
+	// service account needs permission to get its own pod.
+	QueryAPI bool
+	// APIServer overrides the API server URL derived from
+	// KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT
+	APIServer string
+	// HTTPClient overrides the client used for API server requests, which
+	// by default trusts the service account's ca.crt
+	HTTPClient *http.Client
+}
+
+// Metadata describes the pod the process runs in. It implements
+// trusera.Enricher.
+type Metadata struct {
+	PodName        string            `json:"pod_name,omitempty"`
+	Namespace      string            `json:"namespace,omitempty"`
+	NodeName       string            `json:"node_name,omitempty"`
+	PodIP          string            `json:"pod_ip,omitempty"`
+	ServiceAccount string            `json:"service_account,omitempty"`
+	ContainerName  string            `json:"container_name,omitempty"`
+	ContainerImage string            `json:"container_image,omitempty"`
+	Deployment     string            `json:"deployment,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
+}
+
+// Detect returns the pod's metadata, or nil outside Kubernetes. Downward API
+// values are read from POD_NAME, POD_NAMESPACE, NODE_NAME, POD_IP,
+// SERVICE_ACCOUNT, CONTAINER_NAME and CONTAINER_IMAGE, falling back to the
+// hostname and the service account namespace. An error reports a failed API
+// lookup; the metadata gathered before it is still returned.
+func Detect(ctx context.Context, opts Options) (*Metadata, error) {
+	if opts.ServiceAccountDir == "" {
+		opts.ServiceAccountDir = DefaultServiceAccountDir
+	}
+	if opts.LabelsFile == "" {
+		opts.LabelsFile = DefaultLabelsFile
+	}
+
+	_, tokenErr := os.Stat(filepath.Join(opts.ServiceAccountDir, "token"))
+	if os.Getenv("KUBERNETES_SERVICE_HOST") == "" && tokenErr != nil {
+		return nil, nil
+	}
+
+	m := &Metadata{
+		PodName:        os.Getenv("POD_NAME"),
+		Namespace:      os.Getenv("POD_NAMESPACE"),
+		NodeName:       os.Getenv("NODE_NAME"),
+		PodIP:          os.Getenv("POD_IP"),
+		ServiceAccount: os.Getenv("SERVICE_ACCOUNT"),
+		ContainerName:  os.Getenv("CONTAINER_NAME"),
+		ContainerImage: os.Getenv("CONTAINER_IMAGE"),
+	}
+	if m.PodName == "" {
+		// Pods get their name as hostname unless spec.hostname overrides it
+		m.PodName, _ = os.Hostname()
+	}
+	if m.Namespace == "" {
+		if b, err := os.ReadFile(filepath.Join(opts.ServiceAccountDir, "namespace")); err == nil {
+			m.Namespace = strings.TrimSpace(string(b))
+		}
+	}
+	if labels, err := readLabelsFile(opts.LabelsFile); err == nil {
+		m.Labels = labels
+	}
+	m.Deployment = deploymentName(m.PodName, m.Labels)
+
+	if !opts.QueryAPI || m.complete() {
+		return m, nil
+	}
+	if err := m.lookupPod(ctx, opts); err != nil {
+		return m, fmt.Errorf("k8s: pod lookup: %w", err)
+	}
+	return m, nil
+}
+
+// Name returns "k8s", the key attributes are reported under
+func (m *Metadata) Name() string {
+	return "k8s"
+}
+
+// Attributes returns the metadata as flat key/value pairs, with each label
+// as "label.<key>". It returns nil for a nil Metadata.
+func (m *Metadata) Attributes() map[string]string {
+	if m == nil {
+		return nil
+	}
+	attrs := map[string]string{}
+	set := func(k, v string) {
+		if v != "" {
+			attrs[k] = v
+		}
+	}
+	set("pod_name", m.PodName)
+	set("namespace", m.Namespace)
+	set("node_name", m.NodeName)
+	set("pod_ip", m.PodIP)
+	set("service_account", m.ServiceAccount)
+	set("container_name", m.ContainerName)
+	set("container_image", m.ContainerImage)
+	set("deployment", m.Deployment)
+	for k, v := range m.Labels {
+		set("label."+k, v)
+	}
+	return attrs
+}
+
+// complete reports whether an API lookup would add nothing
+func (m *Metadata) complete() bool {
+	return m.PodName != "" && m.Namespace != "" && m.NodeName != "" &&
+		m.ContainerImage != "" && m.Labels != nil
+}
+
+// readLabelsFile parses a downward API labels file of key="value" lines
+func readLabelsFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	labels := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		labels[strings.TrimSpace(key)] = value
+	}
+	return labels, scanner.Err()
+}
+
+// deploymentName derives the owning Deployment from the pod name, which the
+// Deployment controller builds as <deployment>-<pod-template-hash>-<suffix>.
+// The app.kubernetes.io/name label is used when the hash is unknown.
+func deploymentName(podName string, labels map[string]string) string {
+	hash := labels[podTemplateHashLabel]
+	if i := strings.LastIndex(podName, "-"); i > 0 && hash != "" {
+		if prefix, ok := strings.CutSuffix(podName[:i], "-"+hash); ok {
+			return prefix
+		}
+	}
+	return labels["app.kubernetes.io/name"]
+}
+
+// pod is the subset of the Pod API object read by the package
+type pod struct {
+	Metadata struct {
+		Name            string            `json:"name"`
+		Namespace       string            `json:"namespace"`
+		Labels          map[string]string `json:"labels"`
+		Annotations     map[string]string `json:"annotations"`
+		OwnerReferences []struct {
+			Kind string `json:"kind"`
+			Name string `json:"name"`
+		} `json:"ownerReferences"`
+	} `json:"metadata"`
+	Spec struct {
+		NodeName           string `json:"nodeName"`
+		ServiceAccountName string `json:"serviceAccountName"`
+		Containers         []struct {
+			Name  string `json:"name"`
+			Image string `json:"image"`
+		} `json:"containers"`
+	} `json:"spec"`
+	Status struct {
+		PodIP string `json:"podIP"`
+	} `json:"status"`
+}
+
+// lookupPod fills in missing fields from the pod object on the API server
+func (m *Metadata) lookupPod(ctx context.Context, opts Options) error {
+	if m.PodName == "" || m.Namespace == "" {
+		return errors.New("pod name and namespace are required")
+	}
+	var p pod
+	path := fmt.Sprintf("/api/v1/namespaces/%s/pods/%s", url.PathEscape(m.Namespace), url.PathEscape(m.PodName))
+	if err := apiGet(ctx, opts, path, &p); err != nil {
+		return err
+	}
+	m.merge(p)
+	return nil
+}
+
+// apiGet sends a GET request for path to the API server with the service
+// account token and decodes the JSON response into out
+func apiGet(ctx context.Context, opts Options, path string, out any) error {
+	if opts.ServiceAccountDir == "" {
+		opts.ServiceAccountDir = DefaultServiceAccountDir
+	}
+	token, err := os.ReadFile(filepath.Join(opts.ServiceAccountDir, "token"))
+	if err != nil {
+		return err
+	}
+
+	server := opts.APIServer
+	if server == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" {
+			return errors.New("KUBERNETES_SERVICE_HOST is not set")
+		}
+		if port == "" {
+			port = "443"
+		}
+		server = "https://" + net.JoinHostPort(host, port)
+	}
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		if httpClient, err = inClusterClient(opts.ServiceAccountDir); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(server, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+		return fmt.Errorf("API server returned status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 32<<20)).Decode(out)
+}
+
+// merge fills empty fields from a pod object
+func (m *Metadata) merge(p pod) {
+	if m.NodeName == "" {
+		m.NodeName = p.Spec.NodeName
+	}
+	if m.PodIP == "" {
+		m.PodIP = p.Status.PodIP
+	}
+	if m.ServiceAccount == "" {
+		m.ServiceAccount = p.Spec.ServiceAccountName
+	}
+	if m.Labels == nil && len(p.Metadata.Labels) > 0 {
+		m.Labels = p.Metadata.Labels
+	}
+	if m.ContainerImage == "" {
+		for _, c := range p.Spec.Containers {
+			// Without a container name the pod's first container is assumed
+			if m.ContainerName == "" || c.Name == m.ContainerName {
+				m.ContainerName, m.ContainerImage = c.Name, c.Image
+				break
+			}
+		}
+	}
+	if m.Deployment == "" {
+		m.Deployment = deploymentName(m.PodName, m.Labels)
+	}
+	if m.Deployment == "" {
+		m.Deployment = ownerDeployment(p, m.Labels)
+	}
+}
+
+// ownerDeployment derives the Deployment from the pod's owning ReplicaSet,
+// which is named <deployment>-<pod-template-hash>
+func ownerDeployment(p pod, labels map[string]string) string {
+	hash := labels[podTemplateHashLabel]
+	if hash == "" {
+		return ""
+	}
+	for _, ref := range p.Metadata.OwnerReferences {
+		if ref.Kind == "ReplicaSet" {
+			return strings.TrimSuffix(ref.Name, "-"+hash)
+		}
+	}
+	return ""
+}
+
+// inClusterClient returns an HTTP client trusting the service account's CA
+func inClusterClient(dir string) (*http.Client, error) {
+	ca, err := os.ReadFile(filepath.Join(dir, "ca.crt"))
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("no certificates in ca.crt")
+	}
+	return &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+		},
+	}, nil
+}
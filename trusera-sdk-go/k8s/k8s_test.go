@@ -0,0 +1,182 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+var _ trusera.Enricher = (*Metadata)(nil)
+
+// clearEnv unsets the downward API variables for the test
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, k := range []string{"KUBERNETES_SERVICE_HOST", "KUBERNETES_SERVICE_PORT", "POD_NAME", "POD_NAMESPACE",
+		"NODE_NAME", "POD_IP", "SERVICE_ACCOUNT", "CONTAINER_NAME", "CONTAINER_IMAGE"} {
+		t.Setenv(k, "")
+	}
+}
+
+// serviceAccountDir writes a token and namespace file
+func serviceAccountDir(t *testing.T, namespace string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "token"), []byte("sa-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "namespace"), []byte(namespace), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestDetectOutsideKubernetes(t *testing.T) {
+	clearEnv(t)
+
+	m, err := Detect(context.Background(), Options{ServiceAccountDir: t.TempDir()})
+	if err != nil || m != nil {
+		t.Errorf("expected nil metadata outside Kubernetes, got %+v, %v", m, err)
+	}
+	if attrs := m.Attributes(); attrs != nil {
+		t.Errorf("expected nil attributes for nil metadata, got %v", attrs)
+	}
+}
+
+func TestDetectDownwardAPI(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	t.Setenv("POD_NAME", "agent-7d4b9c8f6d-x2k9p")
+	t.Setenv("NODE_NAME", "node-a")
+	t.Setenv("CONTAINER_IMAGE", "registry.example.com/agent:1.4.0")
+
+	labels := filepath.Join(t.TempDir(), "labels")
+	content := "app=\"agent\"\npod-template-hash=\"7d4b9c8f6d\"\nteam=\"ml-platform\"\n"
+	if err := os.WriteFile(labels, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := Detect(context.Background(), Options{
+		ServiceAccountDir: serviceAccountDir(t, "agents\n"),
+		LabelsFile:        labels,
+	})
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	if m.PodName != "agent-7d4b9c8f6d-x2k9p" || m.Namespace != "agents" || m.NodeName != "node-a" {
+		t.Errorf("unexpected pod identity: %+v", m)
+	}
+	if m.Deployment != "agent" {
+		t.Errorf("expected deployment agent, got %q", m.Deployment)
+	}
+	if m.Labels["team"] != "ml-platform" {
+		t.Errorf("expected labels from the downward API file, got %v", m.Labels)
+	}
+
+	attrs := m.Attributes()
+	if attrs["container_image"] != "registry.example.com/agent:1.4.0" || attrs["label.team"] != "ml-platform" {
+		t.Errorf("unexpected attributes: %v", attrs)
+	}
+	if _, ok := attrs["pod_ip"]; ok {
+		t.Error("expected empty fields to be omitted")
+	}
+}
+
+func TestDetectQueriesAPIServer(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("POD_NAME", "worker-0")
+
+	var auth, path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, path = r.Header.Get("Authorization"), r.URL.Path
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"metadata": map[string]any{
+				"labels": map[string]string{"app.kubernetes.io/name": "worker"},
+			},
+			"spec": map[string]any{
+				"nodeName":           "node-b",
+				"serviceAccountName": "worker-sa",
+				"containers": []map[string]string{
+					{"name": "worker", "image": "worker:2.0"},
+					{"name": "sidecar", "image": "proxy:1.0"},
+				},
+			},
+			"status": map[string]any{"podIP": "10.1.2.3"},
+		})
+	}))
+	defer server.Close()
+
+	m, err := Detect(context.Background(), Options{
+		ServiceAccountDir: serviceAccountDir(t, "jobs"),
+		LabelsFile:        filepath.Join(t.TempDir(), "missing"),
+		QueryAPI:          true,
+		APIServer:         server.URL,
+		HTTPClient:        server.Client(),
+	})
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	if auth != "Bearer sa-token" {
+		t.Errorf("expected service account token, got %q", auth)
+	}
+	if path != "/api/v1/namespaces/jobs/pods/worker-0" {
+		t.Errorf("unexpected pod path %q", path)
+	}
+	if m.NodeName != "node-b" || m.PodIP != "10.1.2.3" || m.ServiceAccount != "worker-sa" {
+		t.Errorf("expected fields from the pod object, got %+v", m)
+	}
+	if m.ContainerName != "worker" || m.ContainerImage != "worker:2.0" {
+		t.Errorf("expected first container, got %s %s", m.ContainerName, m.ContainerImage)
+	}
+	if m.Deployment != "worker" {
+		t.Errorf("expected deployment from app.kubernetes.io/name, got %q", m.Deployment)
+	}
+}
+
+func TestDetectAPIErrorKeepsDownwardValues(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("POD_NAME", "worker-0")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	m, err := Detect(context.Background(), Options{
+		ServiceAccountDir: serviceAccountDir(t, "jobs"),
+		LabelsFile:        filepath.Join(t.TempDir(), "missing"),
+		QueryAPI:          true,
+		APIServer:         server.URL,
+		HTTPClient:        server.Client(),
+	})
+	if err == nil {
+		t.Error("expected error for a forbidden pod lookup")
+	}
+	if m == nil || m.PodName != "worker-0" || m.Namespace != "jobs" {
+		t.Errorf("expected downward API values despite the error, got %+v", m)
+	}
+}
+
+func TestDeploymentName(t *testing.T) {
+	tests := []struct {
+		pod    string
+		labels map[string]string
+		want   string
+	}{
+		{"api-5f7c9d-abcde", map[string]string{"pod-template-hash": "5f7c9d"}, "api"},
+		{"my-agent-5f7c9d-abcde", map[string]string{"pod-template-hash": "5f7c9d"}, "my-agent"},
+		{"job-xyz", map[string]string{"pod-template-hash": "5f7c9d"}, ""},
+		{"db-0", map[string]string{"app.kubernetes.io/name": "db"}, "db"},
+		{"standalone", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := deploymentName(tt.pod, tt.labels); got != tt.want {
+			t.Errorf("deploymentName(%q) = %q, want %q", tt.pod, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// Pod is a pod returned by ListPods
+type Pod struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace"`
+	NodeName    string            `json:"node_name,omitempty"`
+	PodIP       string            `json:"pod_ip,omitempty"`
+	Deployment  string            `json:"deployment,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Containers  []Container       `json:"containers"`
+}
+
+// Container is a container of a Pod
+type Container struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+}
+
+// ListPods returns the pods scheduled on a node, in every namespace, so a
+// DaemonSet can discover the workloads next to it. The service account needs
+// permission to list pods cluster-wide.
+func ListPods(ctx context.Context, opts Options, nodeName string) ([]Pod, error) {
+	if nodeName == "" {
+		return nil, errors.New("k8s: node name is required")
+	}
+	var list struct {
+		Items []pod `json:"items"`
+	}
+	q := url.Values{"fieldSelector": {"spec.nodeName=" + nodeName}}
+	if err := apiGet(ctx, opts, "/api/v1/pods?"+q.Encode(), &list); err != nil {
+		return nil, fmt.Errorf("k8s: list pods: %w", err)
+	}
+
+	pods := make([]Pod, 0, len(list.Items))
+	for _, p := range list.Items {
+		out := Pod{
+			Name:        p.Metadata.Name,
+			Namespace:   p.Metadata.Namespace,
+			NodeName:    p.Spec.NodeName,
+			PodIP:       p.Status.PodIP,
+			Labels:      p.Metadata.Labels,
+			Annotations: p.Metadata.Annotations,
+		}
+		for _, c := range p.Spec.Containers {
+			out.Containers = append(out.Containers, Container{Name: c.Name, Image: c.Image})
+		}
+		out.Deployment = deploymentName(out.Name, out.Labels)
+		if out.Deployment == "" {
+			out.Deployment = ownerDeployment(p, out.Labels)
+		}
+		pods = append(pods, out)
+	}
+	return pods, nil
+}
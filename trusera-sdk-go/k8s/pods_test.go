@@ -0,0 +1,63 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListPods(t *testing.T) {
+	var selector string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/pods" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		selector = r.URL.Query().Get("fieldSelector")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"items": []map[string]any{{
+				"metadata": map[string]any{
+					"name":            "vllm-5f7d8c9b4-abcde",
+					"namespace":       "inference",
+					"labels":          map[string]string{"pod-template-hash": "5f7d8c9b4"},
+					"annotations":     map[string]string{"trusera.io/agent": "true"},
+					"ownerReferences": []map[string]string{{"kind": "ReplicaSet", "name": "vllm-5f7d8c9b4"}},
+				},
+				"spec": map[string]any{
+					"nodeName":   "node-a",
+					"containers": []map[string]string{{"name": "server", "image": "vllm/vllm-openai:v0.6.0"}},
+				},
+				"status": map[string]any{"podIP": "10.1.0.7"},
+			}},
+		})
+	}))
+	defer server.Close()
+
+	pods, err := ListPods(context.Background(), Options{
+		ServiceAccountDir: serviceAccountDir(t, "trusera"),
+		APIServer:         server.URL,
+		HTTPClient:        server.Client(),
+	}, "node-a")
+	if err != nil {
+		t.Fatalf("ListPods failed: %v", err)
+	}
+	if selector != "spec.nodeName=node-a" {
+		t.Errorf("expected node field selector, got %q", selector)
+	}
+	if len(pods) != 1 {
+		t.Fatalf("expected one pod, got %+v", pods)
+	}
+	p := pods[0]
+	if p.Namespace != "inference" || p.Deployment != "vllm" || p.PodIP != "10.1.0.7" {
+		t.Errorf("unexpected pod %+v", p)
+	}
+	if p.Annotations["trusera.io/agent"] != "true" || len(p.Containers) != 1 || p.Containers[0].Image != "vllm/vllm-openai:v0.6.0" {
+		t.Errorf("expected annotations and containers, got %+v", p)
+	}
+
+	if _, err := ListPods(context.Background(), Options{}, ""); err == nil {
+		t.Error("expected error without node name")
+	}
+}
@@ -0,0 +1,209 @@
+// Package langchaingo records LangChainGo chains, LLM calls, tools, agent
+// steps and retrievers as Trusera events through a callbacks.Handler.
+package langchaingo
+
+import (
+	"context"
+	"sort"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// maxText bounds how much tool, agent and LLM text is copied into an event
+const maxText = 1024
+
+// framework is reported in the "framework" metadata of every event
+const framework = "langchaingo"
+
+// Handler implements callbacks.Handler by tracking each callback as a Trusera
+// event. Events are linked to the active span in the callback context, so
+// wrapping a chain call in trusera.Client.StartSpan groups its events.
+type Handler struct {
+	client *trusera.Client
+}
+
+var _ callbacks.Handler = (*Handler)(nil)
+
+// NewHandler returns a callbacks.Handler that reports to client
+func NewHandler(client *trusera.Client) *Handler {
+	return &Handler{client: client}
+}
+
+func (h *Handler) track(ctx context.Context, e trusera.Event) {
+	h.client.TrackContext(ctx, e.WithMetadata("framework", framework))
+}
+
+// HandleText is a no-op; text is captured by the LLM and chain callbacks
+func (h *Handler) HandleText(context.Context, string) {}
+
+// HandleStreamingFunc is a no-op; streamed output is reported once generation ends
+func (h *Handler) HandleStreamingFunc(context.Context, []byte) {}
+
+// HandleLLMStart records a prompt-based LLM call
+func (h *Handler) HandleLLMStart(ctx context.Context, prompts []string) {
+	h.track(ctx, trusera.NewEvent(trusera.EventLLMInvoke, "llm_start").
+		WithPayload("prompt_count", len(prompts)))
+}
+
+// HandleLLMGenerateContentStart records the start of a GenerateContent call
+func (h *Handler) HandleLLMGenerateContentStart(ctx context.Context, ms []llms.MessageContent) {
+	h.track(ctx, trusera.NewEvent(trusera.EventLLMInvoke, "llm_start").
+		WithPayload("message_count", len(ms)))
+}
+
+// HandleLLMGenerateContentEnd records the response with token usage when the
+// provider reports it
+func (h *Handler) HandleLLMGenerateContentEnd(ctx context.Context, res *llms.ContentResponse) {
+	event := trusera.NewEvent(trusera.EventLLMInvoke, "llm_end")
+	if res == nil || len(res.Choices) == 0 {
+		h.track(ctx, event.WithPayload("choices", 0))
+		return
+	}
+
+	first := res.Choices[0]
+	event = event.
+		WithPayload("choices", len(res.Choices)).
+		WithPayload("stop_reason", first.StopReason).
+		WithPayload("content", truncate(first.Content))
+	if first.FuncCall != nil {
+		event = event.WithPayload("function_call", first.FuncCall.Name)
+	}
+
+	info := first.GenerationInfo
+	in, okIn := firstInt(info, "PromptTokens", "InputTokens")
+	out, okOut := firstInt(info, "CompletionTokens", "OutputTokens")
+	if okIn || okOut {
+		total, ok := firstInt(info, "TotalTokens")
+		if !ok {
+			total = in + out
+		}
+		event = event.
+			WithPayload("prompt_tokens", in).
+			WithPayload("completion_tokens", out).
+			WithPayload("total_tokens", total)
+	}
+	if model, ok := info["Model"].(string); ok && model != "" {
+		event = event.WithPayload("model", model)
+	}
+	h.track(ctx, event)
+}
+
+// HandleLLMError records a failed LLM call
+func (h *Handler) HandleLLMError(ctx context.Context, err error) {
+	h.track(ctx, trusera.NewEvent(trusera.EventLLMInvoke, "llm_error").
+		WithPayload("error", errString(err)))
+}
+
+// HandleChainStart records a chain starting with the names of its inputs
+func (h *Handler) HandleChainStart(ctx context.Context, inputs map[string]any) {
+	h.track(ctx, trusera.NewEvent(trusera.EventDecision, "chain_start").
+		WithPayload("input_keys", keys(inputs)))
+}
+
+// HandleChainEnd records a chain finishing with the names of its outputs
+func (h *Handler) HandleChainEnd(ctx context.Context, outputs map[string]any) {
+	h.track(ctx, trusera.NewEvent(trusera.EventDecision, "chain_end").
+		WithPayload("output_keys", keys(outputs)))
+}
+
+// HandleChainError records a failed chain
+func (h *Handler) HandleChainError(ctx context.Context, err error) {
+	h.track(ctx, trusera.NewEvent(trusera.EventDecision, "chain_error").
+		WithPayload("error", errString(err)))
+}
+
+// HandleToolStart records a tool invocation
+func (h *Handler) HandleToolStart(ctx context.Context, input string) {
+	h.track(ctx, trusera.NewEvent(trusera.EventToolCall, "tool_start").
+		WithPayload("input", truncate(input)))
+}
+
+// HandleToolEnd records a tool result
+func (h *Handler) HandleToolEnd(ctx context.Context, output string) {
+	h.track(ctx, trusera.NewEvent(trusera.EventToolCall, "tool_end").
+		WithPayload("output", truncate(output)))
+}
+
+// HandleToolError records a failed tool invocation
+func (h *Handler) HandleToolError(ctx context.Context, err error) {
+	h.track(ctx, trusera.NewEvent(trusera.EventToolCall, "tool_error").
+		WithPayload("error", errString(err)))
+}
+
+// HandleAgentAction records the tool an agent decided to call
+func (h *Handler) HandleAgentAction(ctx context.Context, action schema.AgentAction) {
+	event := trusera.NewEvent(trusera.EventDecision, action.Tool).
+		WithPayload("tool", action.Tool).
+		WithPayload("tool_input", truncate(action.ToolInput)).
+		WithPayload("log", truncate(action.Log))
+	if action.ToolID != "" {
+		event = event.WithPayload("tool_id", action.ToolID)
+	}
+	h.track(ctx, event)
+}
+
+// HandleAgentFinish records an agent's final answer
+func (h *Handler) HandleAgentFinish(ctx context.Context, finish schema.AgentFinish) {
+	h.track(ctx, trusera.NewEvent(trusera.EventDecision, "agent_finish").
+		WithPayload("output_keys", keys(finish.ReturnValues)).
+		WithPayload("log", truncate(finish.Log)))
+}
+
+// HandleRetrieverStart records a retriever query
+func (h *Handler) HandleRetrieverStart(ctx context.Context, query string) {
+	h.track(ctx, trusera.NewEvent(trusera.EventDataAccess, "retriever_start").
+		WithPayload("query", truncate(query)))
+}
+
+// HandleRetrieverEnd records how many documents a retriever returned
+func (h *Handler) HandleRetrieverEnd(ctx context.Context, query string, documents []schema.Document) {
+	h.track(ctx, trusera.NewEvent(trusera.EventDataAccess, "retriever_end").
+		WithPayload("query", truncate(query)).
+		WithPayload("documents", len(documents)))
+}
+
+// keys returns the sorted keys of m. Values are not copied because chain
+// inputs and outputs can hold arbitrary, non-serializable types.
+func keys(m map[string]any) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// truncate bounds s to maxText bytes
+func truncate(s string) string {
+	if len(s) <= maxText {
+		return s
+	}
+	return s[:maxText] + "..."
+}
+
+func errString(err error) string {
+	if err == nil {
+		return "unknown error"
+	}
+	return err.Error()
+}
+
+// firstInt returns the first of names present in info as an int
+func firstInt(info map[string]any, names ...string) (int, bool) {
+	for _, name := range names {
+		switch v := info[name].(type) {
+		case int:
+			return v, true
+		case int32:
+			return int(v), true
+		case int64:
+			return int(v), true
+		case float64:
+			return int(v), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,135 @@
+package langchaingo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+type recordingTransport struct {
+	mu     sync.Mutex
+	events []trusera.Event
+}
+
+func (r *recordingTransport) Send(_ context.Context, b trusera.Batch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, b.Events...)
+	return nil
+}
+
+func newTestHandler(t *testing.T) (*Handler, *trusera.Client, *recordingTransport) {
+	t.Helper()
+	rt := &recordingTransport{}
+	client := trusera.NewClient("test-key", trusera.WithTransport(rt))
+	t.Cleanup(func() { client.Close() })
+	return NewHandler(client), client, rt
+}
+
+func TestHandlerLLMUsage(t *testing.T) {
+	h, client, rt := newTestHandler(t)
+	ctx := context.Background()
+
+	h.HandleLLMGenerateContentStart(ctx, []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "hi")})
+	h.HandleLLMGenerateContentEnd(ctx, &llms.ContentResponse{Choices: []*llms.ContentChoice{{
+		Content:    "hello",
+		StopReason: "stop",
+		GenerationInfo: map[string]any{
+			"PromptTokens":     12,
+			"CompletionTokens": 3,
+			"TotalTokens":      15,
+		},
+	}}})
+	h.HandleLLMError(ctx, errors.New("rate limited"))
+	client.Flush()
+
+	if len(rt.events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(rt.events))
+	}
+	end := rt.events[1]
+	if end.Type != trusera.EventLLMInvoke || end.Name != "llm_end" {
+		t.Errorf("expected llm_end event, got %s %s", end.Type, end.Name)
+	}
+	if end.Payload["prompt_tokens"] != 12 {
+		t.Errorf("expected 12 prompt tokens, got %v", end.Payload["prompt_tokens"])
+	}
+	if end.Payload["total_tokens"] != 15 {
+		t.Errorf("expected 15 total tokens, got %v", end.Payload["total_tokens"])
+	}
+	if end.Metadata["framework"] != "langchaingo" {
+		t.Errorf("expected framework metadata, got %v", end.Metadata["framework"])
+	}
+	if rt.events[2].Payload["error"] != "rate limited" {
+		t.Errorf("expected error payload, got %v", rt.events[2].Payload["error"])
+	}
+}
+
+func TestHandlerAnthropicUsage(t *testing.T) {
+	h, client, rt := newTestHandler(t)
+
+	h.HandleLLMGenerateContentEnd(context.Background(), &llms.ContentResponse{Choices: []*llms.ContentChoice{{
+		GenerationInfo: map[string]any{"InputTokens": 7, "OutputTokens": 5},
+	}}})
+	client.Flush()
+
+	if len(rt.events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(rt.events))
+	}
+	if rt.events[0].Payload["total_tokens"] != 12 {
+		t.Errorf("expected 12 total tokens, got %v", rt.events[0].Payload["total_tokens"])
+	}
+}
+
+func TestHandlerAgentAndTools(t *testing.T) {
+	h, client, rt := newTestHandler(t)
+	ctx, span := client.StartSpan(context.Background(), "agent-run")
+
+	h.HandleChainStart(ctx, map[string]any{"question": "x", "history": nil})
+	h.HandleAgentAction(ctx, schema.AgentAction{Tool: "calculator", ToolInput: "2+2"})
+	h.HandleToolStart(ctx, "2+2")
+	h.HandleToolEnd(ctx, "4")
+	h.HandleRetrieverEnd(ctx, "docs", []schema.Document{{PageContent: "a"}, {PageContent: "b"}})
+	h.HandleAgentFinish(ctx, schema.AgentFinish{ReturnValues: map[string]any{"output": "4"}})
+	h.HandleChainEnd(ctx, map[string]any{"output": "4"})
+	h.HandleText(ctx, "ignored")
+	h.HandleStreamingFunc(ctx, []byte("ignored"))
+	client.Flush()
+
+	want := []struct {
+		typ  trusera.EventType
+		name string
+	}{
+		{trusera.EventDecision, "chain_start"},
+		{trusera.EventDecision, "calculator"},
+		{trusera.EventToolCall, "tool_start"},
+		{trusera.EventToolCall, "tool_end"},
+		{trusera.EventDataAccess, "retriever_end"},
+		{trusera.EventDecision, "agent_finish"},
+		{trusera.EventDecision, "chain_end"},
+	}
+	if len(rt.events) != len(want) {
+		t.Fatalf("expected %d events, got %d", len(want), len(rt.events))
+	}
+	for i, w := range want {
+		e := rt.events[i]
+		if e.Type != w.typ || e.Name != w.name {
+			t.Errorf("event %d: expected %s %s, got %s %s", i, w.typ, w.name, e.Type, e.Name)
+		}
+		if e.Metadata["trace_id"] != span.TraceID() {
+			t.Errorf("event %d: expected trace %s, got %v", i, span.TraceID(), e.Metadata["trace_id"])
+		}
+	}
+
+	inputs, _ := rt.events[0].Payload["input_keys"].([]string)
+	if len(inputs) != 2 || inputs[0] != "history" || inputs[1] != "question" {
+		t.Errorf("expected sorted input keys, got %v", rt.events[0].Payload["input_keys"])
+	}
+	if rt.events[4].Payload["documents"] != 2 {
+		t.Errorf("expected 2 documents, got %v", rt.events[4].Payload["documents"])
+	}
+}
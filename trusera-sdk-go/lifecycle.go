@@ -0,0 +1,103 @@
+package trusera
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Lifecycle stages reported as EventLifecycle event names
+const (
+	LifecycleStarted  = "started"
+	LifecycleDraining = "draining"
+	LifecycleStopped  = "stopped"
+)
+
+// WithLifecycleEvents emits EventLifecycle events when the client starts,
+// begins draining on Close, and stops
+func WithLifecycleEvents() Option {
+	return func(c *Client) {
+		c.lifecycleEvents = true
+	}
+}
+
+// trackLifecycle records a lifecycle transition if lifecycle events are enabled
+func (c *Client) trackLifecycle(stage string) {
+	if !c.lifecycleEvents {
+		return
+	}
+
+	c.mu.Lock()
+	fleetID := c.fleetAgentID
+	c.mu.Unlock()
+
+	event := NewEvent(EventLifecycle, stage).
+		WithPayload("agent_name", c.agentName).
+		WithPayload("sdk_version", sdkVersion)
+	if fleetID != "" {
+		event = event.WithPayload("fleet_agent_id", fleetID)
+	}
+	if stage != LifecycleStarted {
+		event = event.WithPayload("uptime_seconds", time.Since(c.startedAt).Seconds())
+	}
+	c.Track(event)
+}
+
+// deregisterFromFleet tells the fleet API the agent is shutting down, or has
+// crashed if Recover reported a panic, so it is not shown as silently dead.
+// Failures are logged and otherwise ignored.
+func (c *Client) deregisterFromFleet(ctx context.Context) {
+	c.mu.Lock()
+	fleetID, crash := c.fleetAgentID, c.crash
+	c.mu.Unlock()
+	if fleetID == "" {
+		return
+	}
+
+	payload := map[string]interface{}{
+		"reason":         "shutdown",
+		"uptime_seconds": time.Since(c.startedAt).Seconds(),
+	}
+	if crash != "" {
+		payload["reason"], payload["panic"] = "crashed", crash
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+
+	if c.sink != nil {
+		c.sink.write("deregister", fleetID, json.RawMessage(body))
+		return
+	}
+
+	url := fmt.Sprintf("%s/api/v1/fleet/%s/deregister", c.baseURL, fleetID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.currentAPIKey())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logf(LogWarn, "fleet deregister failed: %v", err)
+		c.reportError(fmt.Errorf("fleet deregister: %w", err))
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		apiErr := newAPIError(resp)
+		c.logf(LogWarn, "fleet deregister failed: %v", apiErr)
+		c.reportError(fmt.Errorf("fleet deregister: %w", apiErr))
+		return
+	}
+	// Drain body to allow connection reuse
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	c.logf(LogInfo, "fleet deregistered (id=%s)", fleetID)
+}
@@ -0,0 +1,92 @@
+package trusera
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestLifecycleEvents(t *testing.T) {
+	var mu sync.Mutex
+	var names []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch Batch
+		json.NewDecoder(r.Body).Decode(&batch)
+		mu.Lock()
+		for _, e := range batch.Events {
+			if e.Type == EventLifecycle {
+				names = append(names, e.Name)
+			}
+		}
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithLifecycleEvents())
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{LifecycleStarted, LifecycleDraining, LifecycleStopped}
+	if len(names) != len(want) {
+		t.Fatalf("expected lifecycle %v, got %v", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("expected %s at %d, got %s", want[i], i, names[i])
+		}
+	}
+}
+
+func TestLifecycleEventsDisabledByDefault(t *testing.T) {
+	client := NewClient("test-key")
+	defer client.Close()
+
+	if n := len(queuedEvents(client)); n != 0 {
+		t.Errorf("expected no lifecycle events by default, got %d", n)
+	}
+}
+
+func TestCloseDeregistersFromFleet(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		if r.URL.Path == "/api/v1/fleet/register" {
+			json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"id": "fleet-9"}})
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithAutoRegister(), WithLifecycleEvents())
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	client.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	deregisters := 0
+	for i, p := range paths {
+		if p == "/api/v1/fleet/fleet-9/deregister" {
+			deregisters++
+			if i == 0 || paths[i-1] != "/v1/events" {
+				t.Errorf("expected deregistration after final event delivery, got %v", paths)
+			}
+		}
+	}
+	if deregisters != 1 {
+		t.Errorf("expected exactly 1 deregistration, got %d (%v)", deregisters, paths)
+	}
+}
@@ -0,0 +1,350 @@
+package trusera
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Trusera/ai-bom/trusera-sdk-go/tokens"
+)
+
+// maxLLMCapture bounds how much of an LLM request/response body is buffered for parsing
+const maxLLMCapture = 1 << 20
+
+// LLM providers recognized by WrapTransport
+const (
+	ProviderOpenAI      = "openai"
+	ProviderAnthropic   = "anthropic"
+	ProviderAzureOpenAI = "azure_openai"
+)
+
+// WrapTransport wraps an http.RoundTripper so that calls to OpenAI, Anthropic
+// and Azure OpenAI endpoints are recorded as EventLLMInvoke events with model,
+// token usage, latency and status. Streamed responses are recorded when the
+// body is read to the end or closed, with the timings described at
+// WrapStream. Other requests pass through untouched.
+func WrapTransport(base http.RoundTripper, truseraClient Tracker) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &llmTransport{base: base, client: truseraClient}
+}
+
+// llmTransport implements http.RoundTripper for LLM provider calls
+type llmTransport struct {
+	base   http.RoundTripper
+	client Tracker
+}
+
+// llmCall holds what is known about an in-flight provider call
+type llmCall struct {
+	provider  string
+	model     string
+	method    string
+	path      string
+	streaming bool
+	prompt    []tokens.Message // Of streamed or classified calls
+	start     time.Time
+}
+
+// llmUsage is the token usage reported by a provider response
+type llmUsage struct {
+	Model        string
+	InputTokens  int
+	OutputTokens int
+	TotalTokens  int
+}
+
+// RoundTrip forwards the request and records an LLM event once the response body is consumed
+func (t *llmTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	provider := detectLLMProvider(req.URL)
+	if provider == "" {
+		return t.base.RoundTrip(req)
+	}
+
+	call := llmCall{
+		provider: provider,
+		method:   req.Method,
+		path:     req.URL.Path,
+		start:    time.Now(),
+	}
+	safety := safetyOf(t.client)
+	call.model, call.streaming, call.prompt = peekLLMRequest(req, safety != nil)
+	if provider == ProviderAzureOpenAI && call.model == "" {
+		call.model = azureDeployment(req.URL.Path)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		trackContext(t.client, req.Context(), call.event().
+			WithPayload("latency_ms", time.Since(call.start).Milliseconds()).
+			WithPayload("error", err.Error()))
+		return resp, err
+	}
+
+	latency := time.Since(call.start)
+	if call.streaming || strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		// Recorded when the stream ends, with its time to first token
+		resp.Body = newLLMStream(resp.Body, call.start, call.prompt, func(s *llmStream) {
+			event := s.annotate(call.event().
+				WithPayload("status_code", resp.StatusCode).
+				WithPayload("latency_ms", latency.Milliseconds()))
+			event = call.classify(req.Context(), t.client, safety, event, s.text.String())
+			trackContext(t.client, req.Context(), event)
+		})
+		return resp, nil
+	}
+
+	resp.Body = &llmResponseBody{
+		ReadCloser: resp.Body,
+		onDone: func(body []byte) {
+			event := call.event().
+				WithPayload("status_code", resp.StatusCode).
+				WithPayload("latency_ms", latency.Milliseconds())
+			if usage, ok := parseLLMUsage(body); ok {
+				event = usage.annotate(event)
+			}
+			if safety != nil {
+				event = call.classify(req.Context(), t.client, safety, event, responseText(body))
+			}
+			trackContext(t.client, req.Context(), event)
+		},
+	}
+	return resp, nil
+}
+
+// event builds the base EventLLMInvoke for a call
+func (c llmCall) event() Event {
+	name := c.provider
+	if c.model != "" {
+		name += " " + c.model
+	}
+	return NewEvent(EventLLMInvoke, name).
+		WithPayload("provider", c.provider).
+		WithPayload("model", c.model).
+		WithPayload("method", c.method).
+		WithPayload("path", c.path)
+}
+
+// classify scores the call's prompt and output with the client's content
+// classifier
+func (c llmCall) classify(ctx context.Context, t Tracker, s *safety, e Event, output string) Event {
+	parts := make([]string, 0, len(c.prompt))
+	for _, m := range c.prompt {
+		if m.Content != "" {
+			parts = append(parts, m.Content)
+		}
+	}
+	e = s.score(ctx, t, e, ContentPrompt, strings.Join(parts, "\n"))
+	return s.score(ctx, t, e, ContentOutput, output)
+}
+
+// annotate adds token usage to an event
+func (u llmUsage) annotate(e Event) Event {
+	if u.Model != "" {
+		e = e.WithPayload("model", u.Model)
+	}
+	return e.WithPayload("prompt_tokens", u.InputTokens).
+		WithPayload("completion_tokens", u.OutputTokens).
+		WithPayload("total_tokens", u.TotalTokens)
+}
+
+// llmResponseBody buffers a bounded copy of the body and reports it on EOF or Close
+type llmResponseBody struct {
+	io.ReadCloser
+	buf    bytes.Buffer
+	once   sync.Once
+	onDone func([]byte)
+}
+
+func (b *llmResponseBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && b.buf.Len() < maxLLMCapture {
+		room := maxLLMCapture - b.buf.Len()
+		if room > n {
+			room = n
+		}
+		b.buf.Write(p[:room])
+	}
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *llmResponseBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *llmResponseBody) finish() {
+	b.once.Do(func() { b.onDone(b.buf.Bytes()) })
+}
+
+// detectLLMProvider identifies the LLM provider from the request URL
+func detectLLMProvider(u *url.URL) string {
+	host := strings.ToLower(u.Hostname())
+	switch {
+	case host == "api.openai.com":
+		return ProviderOpenAI
+	case host == "api.anthropic.com":
+		return ProviderAnthropic
+	case strings.HasSuffix(host, ".openai.azure.com"):
+		return ProviderAzureOpenAI
+	}
+	return ""
+}
+
+// peekLLMRequest reads the model, stream flag and, for streamed calls or if
+// withPrompt is set, the prompt messages from a JSON request body and
+// restores it
+func peekLLMRequest(req *http.Request, withPrompt bool) (model string, streaming bool, prompt []tokens.Message) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return "", false, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(req.Body, maxLLMCapture+1))
+	req.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), req.Body))
+	if err != nil || len(data) > maxLLMCapture {
+		return "", false, nil
+	}
+
+	var body struct {
+		Model        string          `json:"model"`
+		Stream       bool            `json:"stream"`
+		System       json.RawMessage `json:"system"`       // Anthropic
+		Instructions string          `json:"instructions"` // OpenAI Responses
+		Input        json.RawMessage `json:"input"`        // OpenAI Responses
+		Messages     []struct {
+			Role    string          `json:"role"`
+			Name    string          `json:"name"`
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return "", false, nil
+	}
+	if !body.Stream && !withPrompt {
+		// Non-streaming responses report usage; the prompt is not needed
+		return body.Model, false, nil
+	}
+
+	if text := contentText(body.System); text != "" {
+		prompt = append(prompt, tokens.Message{Role: "system", Content: text})
+	}
+	if body.Instructions != "" {
+		prompt = append(prompt, tokens.Message{Role: "system", Content: body.Instructions})
+	}
+	for _, m := range body.Messages {
+		prompt = append(prompt, tokens.Message{Role: m.Role, Name: m.Name, Content: contentText(m.Content)})
+	}
+	if text := contentText(body.Input); text != "" {
+		prompt = append(prompt, tokens.Message{Role: "user", Content: text})
+	} else {
+		var items []struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
+		}
+		if json.Unmarshal(body.Input, &items) == nil {
+			for _, item := range items {
+				prompt = append(prompt, tokens.Message{Role: item.Role, Content: contentText(item.Content)})
+			}
+		}
+	}
+	return body.Model, body.Stream, prompt
+}
+
+// contentText returns the text of message content: a string, or the text
+// parts of an array of content parts
+func contentText(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var parts []struct {
+		Text string `json:"text"`
+	}
+	if json.Unmarshal(raw, &parts) != nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, p := range parts {
+		sb.WriteString(p.Text)
+	}
+	return sb.String()
+}
+
+// responseText returns the generated text of an OpenAI Chat Completions,
+// OpenAI Responses or Anthropic Messages response
+func responseText(body []byte) string {
+	var resp struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Content json.RawMessage `json:"content"` // Anthropic
+		Output  []struct {
+			Content json.RawMessage `json:"content"`
+		} `json:"output"` // OpenAI Responses
+	}
+	if json.Unmarshal(body, &resp) != nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, c := range resp.Choices {
+		sb.WriteString(c.Message.Content)
+	}
+	sb.WriteString(contentText(resp.Content))
+	for _, o := range resp.Output {
+		sb.WriteString(contentText(o.Content))
+	}
+	return sb.String()
+}
+
+// azureDeployment extracts the deployment name from /openai/deployments/{name}/...
+func azureDeployment(path string) string {
+	const prefix = "/openai/deployments/"
+	if !strings.HasPrefix(path, prefix) {
+		return ""
+	}
+	name, _, _ := strings.Cut(strings.TrimPrefix(path, prefix), "/")
+	return name
+}
+
+// parseLLMUsage extracts token usage from an OpenAI- or Anthropic-shaped response
+func parseLLMUsage(body []byte) (llmUsage, bool) {
+	var resp struct {
+		Model string `json:"model"`
+		Usage *struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+			TotalTokens      int `json:"total_tokens"`
+			InputTokens      int `json:"input_tokens"`
+			OutputTokens     int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Usage == nil {
+		return llmUsage{}, false
+	}
+
+	u := llmUsage{
+		Model:        resp.Model,
+		InputTokens:  resp.Usage.PromptTokens + resp.Usage.InputTokens,
+		OutputTokens: resp.Usage.CompletionTokens + resp.Usage.OutputTokens,
+		TotalTokens:  resp.Usage.TotalTokens,
+	}
+	if u.TotalTokens == 0 {
+		u.TotalTokens = u.InputTokens + u.OutputTokens
+	}
+	return u, true
+}
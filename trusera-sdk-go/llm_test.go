@@ -0,0 +1,253 @@
+package trusera
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// roundTripperFunc adapts a function to http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// cannedResponse returns a RoundTripper that answers every request with body
+func cannedResponse(contentType, body string) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Body != nil {
+			io.Copy(io.Discard, req.Body)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{"Content-Type": []string{contentType}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})
+}
+
+// queuedEvents returns a copy of the client's queued events
+func queuedEvents(c *Client) []Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Event(nil), c.events...)
+}
+
+func TestDetectLLMProvider(t *testing.T) {
+	tests := []struct {
+		url      string
+		provider string
+	}{
+		{"https://api.openai.com/v1/chat/completions", ProviderOpenAI},
+		{"https://api.anthropic.com/v1/messages", ProviderAnthropic},
+		{"https://myco.openai.azure.com/openai/deployments/gpt4/chat/completions", ProviderAzureOpenAI},
+		{"https://api.example.com/v1/chat/completions", ""},
+	}
+
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		if got := detectLLMProvider(u); got != tt.provider {
+			t.Errorf("%s: expected provider %q, got %q", tt.url, tt.provider, got)
+		}
+	}
+}
+
+func TestWrapTransportOpenAI(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	base := cannedResponse("application/json",
+		`{"model":"gpt-4o-2024-08-06","usage":{"prompt_tokens":12,"completion_tokens":30,"total_tokens":42}}`)
+	httpClient := &http.Client{Transport: WrapTransport(base, client)}
+
+	resp, err := httpClient.Post("https://api.openai.com/v1/chat/completions", "application/json",
+		strings.NewReader(`{"model":"gpt-4o","messages":[]}`))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	events := queuedEvents(client)
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+
+	e := events[0]
+	if e.Type != EventLLMInvoke {
+		t.Errorf("expected llm_invoke event, got %s", e.Type)
+	}
+	if e.Payload["provider"] != ProviderOpenAI {
+		t.Errorf("expected provider openai, got %v", e.Payload["provider"])
+	}
+	if e.Payload["model"] != "gpt-4o-2024-08-06" {
+		t.Errorf("expected model from response, got %v", e.Payload["model"])
+	}
+	if e.Payload["prompt_tokens"] != 12 || e.Payload["completion_tokens"] != 30 || e.Payload["total_tokens"] != 42 {
+		t.Errorf("unexpected token usage: %v", e.Payload)
+	}
+	if e.Payload["status_code"] != http.StatusOK {
+		t.Errorf("expected status 200, got %v", e.Payload["status_code"])
+	}
+	if _, ok := e.Payload["latency_ms"]; !ok {
+		t.Error("expected latency_ms in payload")
+	}
+}
+
+func TestWrapTransportAnthropic(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	base := cannedResponse("application/json",
+		`{"model":"claude-3-5-sonnet","usage":{"input_tokens":100,"output_tokens":50}}`)
+	httpClient := &http.Client{Transport: WrapTransport(base, client)}
+
+	resp, err := httpClient.Post("https://api.anthropic.com/v1/messages", "application/json",
+		strings.NewReader(`{"model":"claude-3-5-sonnet"}`))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	events := queuedEvents(client)
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	if events[0].Payload["total_tokens"] != 150 {
+		t.Errorf("expected total_tokens 150, got %v", events[0].Payload["total_tokens"])
+	}
+}
+
+func TestWrapTransportAzureDeployment(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	httpClient := &http.Client{Transport: WrapTransport(cannedResponse("application/json", `{}`), client)}
+
+	resp, err := httpClient.Post("https://myco.openai.azure.com/openai/deployments/gpt4-prod/chat/completions?api-version=2024-02-01",
+		"application/json", strings.NewReader(`{"messages":[]}`))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	events := queuedEvents(client)
+	if len(events) != 1 || events[0].Payload["model"] != "gpt4-prod" {
+		t.Errorf("expected deployment name as model, got %v", events)
+	}
+}
+
+func TestWrapTransportStreaming(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	httpClient := &http.Client{Transport: WrapTransport(cannedResponse("text/event-stream", "data: {}\n\n"), client)}
+
+	resp, err := httpClient.Post("https://api.openai.com/v1/chat/completions", "application/json",
+		strings.NewReader(`{"model":"gpt-4o","stream":true}`))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if events := queuedEvents(client); len(events) != 0 {
+		t.Errorf("expected no event before the stream ends, got %v", events)
+	}
+	io.ReadAll(resp.Body)
+
+	events := queuedEvents(client)
+	if len(events) != 1 || events[0].Payload["streaming"] != true || events[0].Payload["chunk_count"] != 1 {
+		t.Errorf("expected streaming event recorded at the end of the stream, got %v", events)
+	}
+}
+
+func TestWrapTransportStreamingEstimatesUsage(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	stream := `data: {"choices":[{"delta":{"content":"Hello"}}]}` + "\n\n" +
+		`data: {"choices":[{"delta":{"content":" there"}}]}` + "\n\n" +
+		"data: [DONE]\n\n"
+	httpClient := &http.Client{Transport: WrapTransport(cannedResponse("text/event-stream", stream), client)}
+
+	resp, err := httpClient.Post("https://api.openai.com/v1/chat/completions", "application/json",
+		strings.NewReader(`{"model":"gpt-4o","stream":true,"messages":[{"role":"user","content":"Hi"}]}`))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	events := queuedEvents(client)
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	p := events[0].Payload
+	if p["usage_estimated"] != true {
+		t.Errorf("expected usage estimated for a stream without usage, got %v", p)
+	}
+	prompt, _ := p["prompt_tokens"].(int)
+	completion, _ := p["completion_tokens"].(int)
+	if prompt < 8 || completion < 2 || p["total_tokens"] != prompt+completion {
+		t.Errorf("expected estimated prompt and completion tokens, got %v", p)
+	}
+}
+
+func TestWrapTransportPassthrough(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	httpClient := &http.Client{Transport: WrapTransport(cannedResponse("application/json", `{}`), client)}
+
+	resp, err := httpClient.Get("https://api.example.com/data")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if n := len(queuedEvents(client)); n != 0 {
+		t.Errorf("expected non-LLM request to be ignored, got %d events", n)
+	}
+}
+
+func TestWrapTransportError(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	base := roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	})
+	httpClient := &http.Client{Transport: WrapTransport(base, client)}
+
+	if _, err := httpClient.Get("https://api.openai.com/v1/models"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	events := queuedEvents(client)
+	if len(events) != 1 || events[0].Payload["error"] == nil {
+		t.Errorf("expected error event, got %v", events)
+	}
+}
+
+func TestPeekLLMRequestRestoresBody(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "https://api.openai.com/v1/chat/completions",
+		strings.NewReader(`{"model":"gpt-4o"}`))
+
+	model, _, _ := peekLLMRequest(req, false)
+	if model != "gpt-4o" {
+		t.Errorf("expected model gpt-4o, got %s", model)
+	}
+
+	body, _ := io.ReadAll(req.Body)
+	if string(body) != `{"model":"gpt-4o"}` {
+		t.Errorf("expected body to be restored, got %s", body)
+	}
+}
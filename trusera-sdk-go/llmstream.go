@@ -0,0 +1,270 @@
+package trusera
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Trusera/ai-bom/trusera-sdk-go/tokens"
+)
+
+// maxStreamGaps bounds the inter-chunk gaps kept for latency percentiles
+const maxStreamGaps = 10000
+
+// streamControlTypes are SSE payload types that carry no generated tokens
+var streamControlTypes = map[string]bool{
+	"message_start":        true,
+	"message_delta":        true,
+	"message_stop":         true,
+	"content_block_start":  true,
+	"content_block_stop":   true,
+	"ping":                 true,
+	"response.created":     true,
+	"response.in_progress": true,
+	"response.completed":   true,
+}
+
+// WrapStream wraps a streamed LLM response, such as the body of a
+// server-sent events or newline-delimited JSON response, and tracks event
+// with t once the stream ends (EOF, a read error or Close). event is
+// usually an EventLLMInvoke with the provider and model; the stream's
+// timings are added to its payload:
+//
+//   - ttft_ms: time to the first token chunk, from the call to WrapStream
+//   - inter_token_p50_ms, inter_token_p95_ms, inter_token_p99_ms: percentiles
+//     of the gaps between token chunks, in fractional milliseconds
+//   - stream_ms and chunk_count: total stream duration and token chunks
+//
+// Token usage reported in the stream, by OpenAI with include_usage or by
+// Anthropic, is added as for non-streaming calls. When the stream reports
+// none, completion_tokens is counted from the streamed text with the tokens
+// package (prompt_tokens too for WrapTransport, which sees the request), and
+// usage_estimated is set, so the call's cost can still be estimated.
+// WrapTransport applies
+// this to the streamed responses it records; use WrapStream for streams it
+// does not see, such as those of gRPC or WebSocket clients. The stream is
+// read through unchanged, and Close closes r if it is an io.Closer.
+func WrapStream(ctx context.Context, r io.Reader, t Tracker, event Event) io.ReadCloser {
+	return newLLMStream(r, time.Now(), nil, func(s *llmStream) {
+		trackContext(t, ctx, s.annotate(event))
+	})
+}
+
+// llmStream scans a streamed response line by line, timing token chunks
+type llmStream struct {
+	r      io.Reader
+	start  time.Time
+	prompt []tokens.Message // Counted when the stream reports no usage
+	onDone func(*llmStream)
+	once   sync.Once
+
+	pending []byte
+	chunks  int
+	first   time.Duration
+	last    time.Time
+	gaps    []time.Duration
+	end     time.Duration
+	usage   llmUsage
+	usageOK bool
+	text    strings.Builder // Generated text, up to maxLLMCapture bytes
+	err     error
+}
+
+func newLLMStream(r io.Reader, start time.Time, prompt []tokens.Message, onDone func(*llmStream)) *llmStream {
+	return &llmStream{r: r, start: start, prompt: prompt, onDone: onDone}
+}
+
+func (s *llmStream) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if n > 0 {
+		s.feed(p[:n])
+	}
+	if err != nil {
+		if err != io.EOF {
+			s.err = err
+		}
+		s.finish()
+	}
+	return n, err
+}
+
+func (s *llmStream) Close() error {
+	s.finish()
+	if c, ok := s.r.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (s *llmStream) finish() {
+	s.once.Do(func() {
+		if len(s.pending) > 0 {
+			s.line(s.pending)
+			s.pending = nil
+		}
+		s.end = time.Since(s.start)
+		s.onDone(s)
+	})
+}
+
+// feed splits data into lines and handles each complete one
+func (s *llmStream) feed(data []byte) {
+	s.pending = append(s.pending, data...)
+	for {
+		i := bytes.IndexByte(s.pending, '\n')
+		if i < 0 {
+			break
+		}
+		s.line(s.pending[:i])
+		s.pending = s.pending[i+1:]
+	}
+	if len(s.pending) > maxLLMCapture {
+		s.pending = nil
+	}
+}
+
+// line handles one line: an SSE "data:" field or a line of NDJSON. Other
+// SSE fields, comments and blank lines are skipped.
+func (s *llmStream) line(line []byte) {
+	line = bytes.TrimSpace(line)
+	if payload, ok := bytes.CutPrefix(line, []byte("data:")); ok {
+		line = bytes.TrimSpace(payload)
+	} else if !bytes.HasPrefix(line, []byte("{")) {
+		return
+	}
+	if len(line) == 0 || string(line) == "[DONE]" {
+		return
+	}
+
+	var chunk streamChunk
+	if json.Unmarshal(line, &chunk) == nil {
+		s.addUsage(line)
+		if chunk.Type == "message_start" && len(chunk.Message) > 0 {
+			s.addUsage(chunk.Message) // Anthropic reports input tokens here
+		}
+		if streamControlTypes[chunk.Type] {
+			return
+		}
+		if s.text.Len() < maxLLMCapture {
+			s.text.WriteString(chunk.text())
+		}
+	}
+
+	now := time.Now()
+	if s.chunks == 0 {
+		s.first = now.Sub(s.start)
+	} else if len(s.gaps) < maxStreamGaps {
+		s.gaps = append(s.gaps, now.Sub(s.last))
+	}
+	s.chunks++
+	s.last = now
+}
+
+// addUsage merges usage reported by one chunk. Providers report running or
+// final totals, so the largest counts win.
+func (s *llmStream) addUsage(data []byte) {
+	u, ok := parseLLMUsage(data)
+	if !ok {
+		return
+	}
+	s.usageOK = true
+	if u.Model != "" {
+		s.usage.Model = u.Model
+	}
+	if u.InputTokens > s.usage.InputTokens {
+		s.usage.InputTokens = u.InputTokens
+	}
+	if u.OutputTokens > s.usage.OutputTokens {
+		s.usage.OutputTokens = u.OutputTokens
+	}
+	s.usage.TotalTokens = s.usage.InputTokens + s.usage.OutputTokens
+}
+
+// annotate adds the stream's timings and usage to e
+func (s *llmStream) annotate(e Event) Event {
+	e = e.WithPayload("streaming", true).
+		WithPayload("chunk_count", s.chunks).
+		WithPayload("stream_ms", s.end.Milliseconds())
+	if s.chunks > 0 {
+		e = e.WithPayload("ttft_ms", s.first.Milliseconds())
+	}
+	if len(s.gaps) > 0 {
+		gaps := append([]time.Duration(nil), s.gaps...)
+		sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+		e = e.WithPayload("inter_token_p50_ms", percentileMs(gaps, 0.50)).
+			WithPayload("inter_token_p95_ms", percentileMs(gaps, 0.95)).
+			WithPayload("inter_token_p99_ms", percentileMs(gaps, 0.99))
+	}
+	if s.usageOK {
+		e = s.usage.annotate(e)
+	} else if model, _ := e.Payload["model"].(string); s.chunks > 0 {
+		u := llmUsage{OutputTokens: tokens.Count(model, s.text.String())}
+		if len(s.prompt) > 0 {
+			u.InputTokens = tokens.CountMessages(model, s.prompt)
+		}
+		u.TotalTokens = u.InputTokens + u.OutputTokens
+		e = u.annotate(e).WithPayload("usage_estimated", true)
+	}
+	if s.err != nil {
+		e = e.WithPayload("error", s.err.Error())
+	}
+	return e
+}
+
+// streamChunk holds the fields of streamed chunks that carry generated text
+type streamChunk struct {
+	Type     string          `json:"type"`
+	Message  json.RawMessage `json:"message"`
+	Delta    json.RawMessage `json:"delta"`    // Anthropic object, or OpenAI Responses text
+	Response string          `json:"response"` // Ollama /api/generate
+	Choices  []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"` // OpenAI Chat Completions
+}
+
+// text returns the generated text in the chunk
+func (c *streamChunk) text() string {
+	var sb strings.Builder
+	for _, choice := range c.Choices {
+		sb.WriteString(choice.Delta.Content)
+	}
+	if len(c.Delta) > 0 {
+		var delta struct {
+			Text string `json:"text"`
+		}
+		var s string
+		if json.Unmarshal(c.Delta, &delta) == nil {
+			sb.WriteString(delta.Text)
+		} else if json.Unmarshal(c.Delta, &s) == nil {
+			sb.WriteString(s)
+		}
+	}
+	sb.WriteString(c.Response)
+	if len(c.Message) > 0 && c.Type == "" {
+		var m struct {
+			Content string `json:"content"`
+		}
+		if json.Unmarshal(c.Message, &m) == nil {
+			sb.WriteString(m.Content) // Ollama /api/chat
+		}
+	}
+	return sb.String()
+}
+
+// percentileMs returns the nearest-rank percentile of sorted durations in
+// milliseconds, to the microsecond
+func percentileMs(sorted []time.Duration, p float64) float64 {
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return float64(sorted[i].Microseconds()) / 1000
+}
@@ -0,0 +1,105 @@
+package trusera
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// slowReader returns one line per Read, sleeping before each
+type slowReader struct {
+	lines []string
+	delay time.Duration
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if len(r.lines) == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(r.delay)
+	n := copy(p, r.lines[0])
+	r.lines = r.lines[1:]
+	return n, nil
+}
+
+func TestWrapStreamTimings(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	src := &slowReader{delay: 5 * time.Millisecond, lines: []string{
+		"event: message_start\n",
+		`data: {"type":"message_start","message":{"model":"claude-sonnet-4","usage":{"input_tokens":12,"output_tokens":1}}}` + "\n\n",
+		`data: {"type":"ping"}` + "\n\n",
+		`data: {"type":"content_block_delta","delta":{"text":"Hel"}}` + "\n\n",
+		`data: {"type":"content_block_delta","delta":{"text":"lo"}}` + "\n\n",
+		`data: {"type":"content_block_delta","delta":{"text":"!"}}` + "\n\n",
+		`data: {"type":"message_delta","usage":{"output_tokens":3}}` + "\n\n",
+		`data: {"type":"message_stop"}` + "\n\n",
+	}}
+	body := WrapStream(context.Background(), src, client, NewEvent(EventLLMInvoke, "anthropic").WithPayload("provider", ProviderAnthropic))
+	data, err := io.ReadAll(body)
+	if err != nil || !strings.Contains(string(data), "Hel") {
+		t.Fatalf("expected the stream read through, got %q, %v", data, err)
+	}
+	body.Close()
+
+	events := queuedEvents(client)
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	p := events[0].Payload
+	if p["chunk_count"] != 3 {
+		t.Errorf("expected 3 token chunks, got %v", p["chunk_count"])
+	}
+	if ttft, _ := p["ttft_ms"].(int64); ttft < 15 {
+		t.Errorf("expected the first token after the control events, got %v", p["ttft_ms"])
+	}
+	if p50, _ := p["inter_token_p50_ms"].(float64); p50 < 4 {
+		t.Errorf("expected inter-token gaps of about 5ms, got %v", p["inter_token_p50_ms"])
+	}
+	if _, ok := p["inter_token_p99_ms"]; !ok {
+		t.Error("expected a p99 inter-token latency")
+	}
+	if stream, _ := p["stream_ms"].(int64); stream < 35 {
+		t.Errorf("expected the whole stream duration, got %v", p["stream_ms"])
+	}
+	if p["prompt_tokens"] != 12 || p["completion_tokens"] != 3 || p["model"] != "claude-sonnet-4" {
+		t.Errorf("expected usage from the stream, got %v", p)
+	}
+}
+
+func TestWrapStreamNDJSONAndErrors(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	src := io.MultiReader(
+		strings.NewReader("{\"response\":\"a\"}\n{\"response\":\"b\"}"),
+		iotest.ErrReader(errors.New("connection reset")),
+	)
+	body := WrapStream(context.Background(), src, client, NewEvent(EventLLMInvoke, "ollama"))
+	if _, err := io.ReadAll(body); err == nil {
+		t.Fatal("expected the read error")
+	}
+
+	events := queuedEvents(client)
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	if p := events[0].Payload; p["chunk_count"] != 2 || p["error"] != "connection reset" {
+		t.Errorf("expected 2 NDJSON chunks and the error, got %v", p)
+	}
+}
+
+func TestPercentileMs(t *testing.T) {
+	gaps := []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond, 1500 * time.Microsecond * 10}
+	if got := percentileMs(gaps, 0.5); got != 2 {
+		t.Errorf("expected p50 of 2ms, got %v", got)
+	}
+	if got := percentileMs(gaps, 0.99); got != 15 {
+		t.Errorf("expected p99 of 15ms, got %v", got)
+	}
+}
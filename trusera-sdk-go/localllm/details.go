@@ -0,0 +1,268 @@
+package localllm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/discovery"
+)
+
+// detailsTTL is how long a server's model details are reused
+const detailsTTL = 10 * time.Second
+
+// quantization matches the quantization in a GGUF file name, such as
+// llama-3-8b-instruct.Q4_K_M.gguf
+var quantization = regexp.MustCompile(`(?i)(?:^|[.\-_])(IQ\d_[A-Z0-9]+|Q\d(?:_[A-Z0-9]+)*|BF16|F16|F32)(?:[.\-_]|$)`)
+
+// serverDetails is what a server reports about its models
+type serverDetails struct {
+	models       map[string]modelDetails // Ollama, by name
+	modelPath    string                  // llama.cpp
+	kvCacheUsage float64                 // vLLM, fraction of GPU KV cache in use
+	hasKVCache   bool
+}
+
+// modelDetails describes a model loaded by Ollama
+type modelDetails struct {
+	quantization string
+	gpuMemory    int64
+}
+
+// annotate adds the quantization and GPU memory of model to an event
+func (d serverDetails) annotate(e trusera.Event, model string) trusera.Event {
+	if m, ok := d.models[ollamaName(model)]; ok {
+		if m.quantization != "" {
+			e = e.WithPayload("quantization", m.quantization)
+		}
+		if m.gpuMemory > 0 {
+			e = e.WithPayload("gpu_memory_bytes", m.gpuMemory)
+		}
+	}
+	if q := fileQuantization(d.modelPath); q != "" {
+		e = e.WithPayload("quantization", q)
+	}
+	if d.hasKVCache {
+		e = e.WithPayload("gpu_kv_cache_usage", d.kvCacheUsage)
+	}
+	return e
+}
+
+// details returns srv's model details, asking the server again when they
+// are stale or do not cover model. Lookup failures leave them empty.
+func (t *transport) details(srv *server, model string) serverDetails {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	_, known := srv.details.models[ollamaName(model)]
+	if time.Since(srv.fetched) < detailsTTL && (srv.Kind != Ollama || known) {
+		return srv.details
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), statsTimeout)
+	defer cancel()
+	if d, err := fetchDetails(ctx, t.lookup, srv.Server); err == nil {
+		srv.details = d
+	}
+	srv.fetched = time.Now()
+	return srv.details
+}
+
+// fetchDetails asks a server about its models
+func fetchDetails(ctx context.Context, hc *http.Client, s Server) (serverDetails, error) {
+	var d serverDetails
+	switch s.Kind {
+	case Ollama:
+		var ps struct {
+			Models []struct {
+				Name     string `json:"name"`
+				SizeVRAM int64  `json:"size_vram"`
+				Details  struct {
+					QuantizationLevel string `json:"quantization_level"`
+				} `json:"details"`
+			} `json:"models"`
+		}
+		if err := getJSON(ctx, hc, s.endpoint("/api/ps"), &ps); err != nil {
+			return d, err
+		}
+		d.models = make(map[string]modelDetails, len(ps.Models))
+		for _, m := range ps.Models {
+			d.models[ollamaName(m.Name)] = modelDetails{quantization: m.Details.QuantizationLevel, gpuMemory: m.SizeVRAM}
+		}
+	case LlamaCpp:
+		props, err := llamaProps(ctx, hc, s)
+		if err != nil {
+			return d, err
+		}
+		d.modelPath = props.ModelPath
+	case VLLM:
+		usage, err := vllmKVCacheUsage(ctx, hc, s)
+		if err != nil {
+			return d, err
+		}
+		d.kvCacheUsage, d.hasKVCache = usage, true
+	}
+	return d, nil
+}
+
+// Models lists the models the servers serve, for the fleet model inventory.
+// A nil hc uses http.DefaultClient. Servers that cannot be reached are
+// skipped and their errors joined.
+func Models(ctx context.Context, hc *http.Client, servers ...Server) ([]discovery.Model, error) {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	var models []discovery.Model
+	var errs []error
+	for _, s := range servers {
+		found, err := serverModels(ctx, hc, s)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", s.Kind, s.URL, err))
+			continue
+		}
+		models = append(models, found...)
+	}
+	return models, errors.Join(errs...)
+}
+
+// serverModels lists one server's models
+func serverModels(ctx context.Context, hc *http.Client, s Server) ([]discovery.Model, error) {
+	model := func(name string) discovery.Model {
+		return discovery.Model{Name: name, Provider: s.Kind, Source: discovery.SourceServer, Location: s.URL}
+	}
+	var out []discovery.Model
+	switch s.Kind {
+	case Ollama:
+		var tags struct {
+			Models []struct {
+				Name    string `json:"name"`
+				Size    int64  `json:"size"`
+				Details struct {
+					Format            string `json:"format"`
+					QuantizationLevel string `json:"quantization_level"`
+				} `json:"details"`
+			} `json:"models"`
+		}
+		if err := getJSON(ctx, hc, s.endpoint("/api/tags"), &tags); err != nil {
+			return nil, err
+		}
+		for _, t := range tags.Models {
+			m := model(t.Name)
+			m.Format, m.SizeBytes, m.Quantization = t.Details.Format, t.Size, t.Details.QuantizationLevel
+			out = append(out, m)
+		}
+	case VLLM:
+		var list struct {
+			Data []struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		}
+		if err := getJSON(ctx, hc, s.endpoint("/v1/models"), &list); err != nil {
+			return nil, err
+		}
+		for _, d := range list.Data {
+			out = append(out, model(d.ID))
+		}
+	case LlamaCpp:
+		props, err := llamaProps(ctx, hc, s)
+		if err != nil {
+			return nil, err
+		}
+		if props.ModelPath == "" {
+			return nil, nil
+		}
+		m := model(strings.TrimSuffix(path.Base(props.ModelPath), ".gguf"))
+		m.Format, m.Quantization = "gguf", fileQuantization(props.ModelPath)
+		out = append(out, m)
+	default:
+		return nil, fmt.Errorf("unknown server kind %q", s.Kind)
+	}
+	return out, nil
+}
+
+// llamaCppProps is the part of a llama.cpp server's /props used here
+type llamaCppProps struct {
+	ModelPath string `json:"model_path"`
+}
+
+// llamaProps reads a llama.cpp server's /props
+func llamaProps(ctx context.Context, hc *http.Client, s Server) (llamaCppProps, error) {
+	var props llamaCppProps
+	err := getJSON(ctx, hc, s.endpoint("/props"), &props)
+	return props, err
+}
+
+// vllmKVCacheUsage reads the GPU KV cache usage gauge from vLLM's
+// Prometheus metrics
+func vllmKVCacheUsage(ctx context.Context, hc *http.Client, s Server) (float64, error) {
+	body, err := get(ctx, hc, s.endpoint("/metrics"))
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+	lines := bufio.NewScanner(body)
+	for lines.Scan() {
+		line := lines.Text()
+		if !strings.HasPrefix(line, "vllm:gpu_cache_usage_perc") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if v, err := strconv.ParseFloat(fields[len(fields)-1], 64); err == nil {
+			return v, nil
+		}
+	}
+	return 0, errors.New("no vllm:gpu_cache_usage_perc metric")
+}
+
+// fileQuantization returns the quantization named in a model file's name
+func fileQuantization(file string) string {
+	m := quantization.FindStringSubmatch(path.Base(file))
+	if m == nil {
+		return ""
+	}
+	return strings.ToUpper(m[1])
+}
+
+// ollamaName adds the tag Ollama assumes when a model is named without one
+func ollamaName(name string) string {
+	if name != "" && !strings.Contains(name, ":") {
+		return name + ":latest"
+	}
+	return name
+}
+
+// get fetches url, returning the body of a successful response
+func get(ctx context.Context, hc *http.Client, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// getJSON fetches url and decodes the JSON response into v
+func getJSON(ctx context.Context, hc *http.Client, url string, v any) error {
+	body, err := get(ctx, hc, url)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	return json.NewDecoder(io.LimitReader(body, maxCapture)).Decode(v)
+}
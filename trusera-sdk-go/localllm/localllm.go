@@ -0,0 +1,243 @@
+// Package localllm instruments calls to local inference servers (Ollama,
+// vLLM and llama.cpp) that WrapTransport cannot recognize by hostname. Each
+// completion, chat or embedding call is recorded as an llm_invoke event with
+// the model, token counts and throughput, plus the quantization and GPU
+// memory the server reports, and the servers' models can be added to the
+// fleet model inventory.
+//
+//	ollama := localllm.Server{Kind: localllm.Ollama, URL: "http://localhost:11434"}
+//	httpClient := &http.Client{Transport: localllm.WrapTransport(nil, client, ollama)}
+//
+//	inv := discovery.Scan(discovery.Options{})
+//	models, _ := localllm.Models(ctx, nil, ollama)
+//	inv.Models = append(inv.Models, models...)
+package localllm
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+// Server kinds, also reported as the event's provider
+const (
+	Ollama   = "ollama"
+	VLLM     = "vllm"
+	LlamaCpp = "llama_cpp"
+)
+
+// maxCapture bounds how much of a request or response body is buffered. Of
+// longer streamed responses, only the end is kept, where the final counts are.
+const maxCapture = 1 << 20
+
+// statsTimeout bounds the lookup of a server's model details after a call
+const statsTimeout = 2 * time.Second
+
+// Server is a local inference server
+type Server struct {
+	Kind string // Ollama, VLLM or LlamaCpp
+	URL  string // Base URL, e.g. http://localhost:11434
+}
+
+// endpoint returns the URL of a path on the server
+func (s Server) endpoint(path string) string {
+	return strings.TrimSuffix(s.URL, "/") + path
+}
+
+// inferencePaths are the endpoints recorded for each kind of server
+var inferencePaths = map[string][]string{
+	Ollama:   {"/api/generate", "/api/chat", "/api/embed", "/api/embeddings"},
+	VLLM:     {"/v1/completions", "/v1/chat/completions", "/v1/embeddings"},
+	LlamaCpp: {"/completion", "/completions", "/v1/completions", "/chat/completions", "/v1/chat/completions", "/embedding", "/embeddings", "/v1/embeddings"},
+}
+
+// WrapTransport wraps an http.RoundTripper so that inference calls to the
+// given servers are recorded as llm_invoke events. Other requests pass
+// through untouched. A nil base uses http.DefaultTransport.
+func WrapTransport(base http.RoundTripper, client *trusera.Client, servers ...Server) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &transport{base: base, client: client}
+	for _, s := range servers {
+		u, err := url.Parse(s.URL)
+		if err != nil || u.Host == "" || inferencePaths[s.Kind] == nil {
+			continue
+		}
+		t.servers = append(t.servers, &server{Server: s, host: strings.ToLower(u.Host), base: strings.TrimSuffix(u.Path, "/")})
+	}
+	t.lookup = &http.Client{Transport: base}
+	return t
+}
+
+// transport implements http.RoundTripper for local inference servers
+type transport struct {
+	base    http.RoundTripper
+	client  *trusera.Client
+	servers []*server
+	lookup  *http.Client // For model details, not instrumented
+}
+
+// server is a configured server with its cached model details
+type server struct {
+	Server
+	host string
+	base string
+
+	mu      sync.Mutex
+	details serverDetails
+	fetched time.Time
+}
+
+// call holds what is known about an in-flight inference call
+type call struct {
+	srv       *server
+	model     string
+	method    string
+	path      string
+	streaming bool
+	start     time.Time
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	srv, path := t.match(req.URL)
+	if srv == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	c := &call{srv: srv, method: req.Method, path: path, start: time.Now()}
+	c.model, c.streaming = peekRequest(req, srv.Kind)
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.client.TrackContext(req.Context(), c.event().
+			WithPayload("latency_ms", time.Since(c.start).Milliseconds()).
+			WithPayload("error", err.Error()))
+		return resp, err
+	}
+
+	ctx := req.Context()
+	resp.Body = &responseBody{ReadCloser: resp.Body, onDone: func(body []byte) {
+		latency := time.Since(c.start)
+		s, ok := parseStats(body)
+		if c.model == "" {
+			c.model = s.Model
+		}
+		event := c.event().
+			WithPayload("status_code", resp.StatusCode).
+			WithPayload("latency_ms", latency.Milliseconds())
+		if c.streaming {
+			event = event.WithPayload("streaming", true)
+		}
+		if ok {
+			event = s.annotate(event, latency)
+		}
+		if resp.StatusCode >= 400 {
+			t.client.TrackContext(ctx, event)
+			return
+		}
+		// Model details may take a request to the server, which the
+		// caller reading the response should not wait for
+		go func() {
+			t.client.TrackContext(ctx, t.details(srv, c.model).annotate(event, c.model))
+		}()
+	}}
+	return resp, nil
+}
+
+// match returns the server an inference request goes to and the request
+// path relative to the server's base URL
+func (t *transport) match(u *url.URL) (*server, string) {
+	host := strings.ToLower(u.Host)
+	for _, s := range t.servers {
+		if s.host != host || !strings.HasPrefix(u.Path, s.base) {
+			continue
+		}
+		path := strings.TrimPrefix(u.Path, s.base)
+		for _, p := range inferencePaths[s.Kind] {
+			if path == p {
+				return s, path
+			}
+		}
+	}
+	return nil, ""
+}
+
+// event builds the base llm_invoke event for a call
+func (c *call) event() trusera.Event {
+	name := c.srv.Kind
+	if c.model != "" {
+		name += " " + c.model
+	}
+	return trusera.NewEvent(trusera.EventLLMInvoke, name).
+		WithPayload("provider", c.srv.Kind).
+		WithPayload("model", c.model).
+		WithPayload("method", c.method).
+		WithPayload("path", c.path).
+		WithPayload("server", c.srv.URL)
+}
+
+// peekRequest reads the model and stream flag from a JSON request body and
+// restores it. Ollama streams unless told not to; the others only when asked.
+func peekRequest(req *http.Request, kind string) (model string, streaming bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return "", false
+	}
+	data, err := io.ReadAll(io.LimitReader(req.Body, maxCapture+1))
+	req.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), req.Body))
+	if err != nil || len(data) > maxCapture {
+		return "", false
+	}
+
+	var body struct {
+		Model  string `json:"model"`
+		Stream *bool  `json:"stream"`
+	}
+	if json.Unmarshal(data, &body) != nil {
+		return "", false
+	}
+	streaming = body.Stream != nil && *body.Stream
+	if kind == Ollama && body.Stream == nil && !strings.HasPrefix(req.URL.Path, "/api/embed") {
+		streaming = true
+	}
+	return body.Model, streaming
+}
+
+// responseBody keeps the last maxCapture bytes of a response and reports
+// them on EOF or Close
+type responseBody struct {
+	io.ReadCloser
+	buf    []byte
+	once   sync.Once
+	onDone func([]byte)
+}
+
+func (b *responseBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.buf = append(b.buf, p[:n]...)
+		if over := len(b.buf) - maxCapture; over > 0 {
+			b.buf = append(b.buf[:0], b.buf[over:]...)
+		}
+	}
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *responseBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *responseBody) finish() {
+	b.once.Do(func() { b.onDone(b.buf) })
+}
@@ -0,0 +1,199 @@
+package localllm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/discovery"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/truseratest"
+)
+
+// ollamaHandler serves generate, ps and tags like Ollama
+func ollamaHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api/generate":
+		if strings.Contains(r.URL.RawQuery, "stream") {
+			fmt.Fprintln(w, `{"model":"llama3:8b","response":"Hel","done":false}`)
+			fmt.Fprintln(w, `{"model":"llama3:8b","response":"lo","done":false}`)
+		}
+		fmt.Fprintln(w, `{"model":"llama3:8b","response":"","done":true,"prompt_eval_count":12,"eval_count":100,"eval_duration":2000000000,"load_duration":150000000}`)
+	case "/api/ps":
+		fmt.Fprint(w, `{"models":[{"name":"llama3:8b","size_vram":5137025024,"details":{"quantization_level":"Q4_0"}}]}`)
+	case "/api/tags":
+		fmt.Fprint(w, `{"models":[{"name":"llama3:8b","size":4661224676,"details":{"format":"gguf","quantization_level":"Q4_0"}}]}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// waitForEvent returns the first llm_invoke event, which is tracked in the background
+func waitForEvent(t *testing.T, rc *truseratest.RecordingClient) trusera.Event {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if events := rc.Events(truseratest.OfType(trusera.EventLLMInvoke)); len(events) > 0 {
+			return events[0]
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected an llm_invoke event")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func post(t *testing.T, hc *http.Client, url, body string) {
+	t.Helper()
+	resp, err := hc.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var buf [512]byte
+	for {
+		if _, err := resp.Body.Read(buf[:]); err != nil {
+			break
+		}
+	}
+}
+
+func TestOllamaGenerate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(ollamaHandler))
+	defer server.Close()
+	rc := truseratest.NewRecordingClient(trusera.WithStrictValidation())
+	defer rc.Close()
+	hc := &http.Client{Transport: WrapTransport(nil, rc.Client, Server{Kind: Ollama, URL: server.URL})}
+
+	post(t, hc, server.URL+"/api/generate", `{"model":"llama3:8b","prompt":"hi","stream":false}`)
+
+	e := waitForEvent(t, rc)
+	want := map[string]any{
+		"provider": Ollama, "model": "llama3:8b", "path": "/api/generate", "server": server.URL,
+		"prompt_tokens": float64(12), "completion_tokens": float64(100), "total_tokens": float64(112),
+		"tokens_per_second": float64(50), "load_ms": float64(150), "quantization": "Q4_0",
+		"gpu_memory_bytes": 5137025024,
+	}
+	for k, v := range want {
+		if got := fmt.Sprint(e.Payload[k]); got != fmt.Sprint(v) {
+			t.Errorf("expected %s %v, got %v", k, v, e.Payload[k])
+		}
+	}
+	if e.Name != "ollama llama3:8b" || e.Payload["streaming"] != nil {
+		t.Errorf("expected a non-streaming call named after the model, got %q %v", e.Name, e.Payload)
+	}
+	if s := rc.Stats(); s.Invalid != 0 {
+		t.Errorf("expected the event to match the llm_invoke schema, got %d invalid", s.Invalid)
+	}
+}
+
+func TestOllamaStreamsByDefault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(ollamaHandler))
+	defer server.Close()
+	rc := truseratest.NewRecordingClient()
+	defer rc.Close()
+	hc := &http.Client{Transport: WrapTransport(nil, rc.Client, Server{Kind: Ollama, URL: server.URL + "/"})}
+
+	post(t, hc, server.URL+"/api/generate?stream", `{"model":"llama3:8b","prompt":"hi"}`)
+
+	e := waitForEvent(t, rc)
+	if e.Payload["streaming"] != true || fmt.Sprint(e.Payload["completion_tokens"]) != "100" {
+		t.Errorf("expected counts from the final streamed chunk, got %v", e.Payload)
+	}
+}
+
+func TestLlamaCppCompletion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/completion":
+			fmt.Fprint(w, "data: {\"content\":\"Hel\",\"stop\":false}\n\n")
+			fmt.Fprint(w, "data: {\"content\":\"\",\"stop\":true,\"model\":\"llama-3-8b\",\"timings\":{\"prompt_n\":9,\"predicted_n\":40,\"predicted_ms\":800,\"predicted_per_second\":50}}\n\n")
+		case "/props":
+			fmt.Fprint(w, `{"model_path":"/models/Meta-Llama-3-8B-Instruct.Q4_K_M.gguf"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	rc := truseratest.NewRecordingClient()
+	defer rc.Close()
+	hc := &http.Client{Transport: WrapTransport(nil, rc.Client, Server{Kind: LlamaCpp, URL: server.URL})}
+
+	post(t, hc, server.URL+"/completion", `{"prompt":"hi","stream":true}`)
+
+	e := waitForEvent(t, rc)
+	if e.Name != "llama_cpp llama-3-8b" || fmt.Sprint(e.Payload["prompt_tokens"]) != "9" ||
+		fmt.Sprint(e.Payload["tokens_per_second"]) != "50" || e.Payload["quantization"] != "Q4_K_M" {
+		t.Errorf("expected timings and quantization from llama.cpp, got %q %v", e.Name, e.Payload)
+	}
+}
+
+func TestVLLMChatCompletion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			fmt.Fprint(w, `{"model":"mistral-7b","usage":{"prompt_tokens":20,"completion_tokens":30,"total_tokens":50}}`)
+		case "/metrics":
+			fmt.Fprint(w, "# HELP vllm:gpu_cache_usage_perc GPU KV-cache usage.\n"+
+				"vllm:gpu_cache_usage_perc{model_name=\"mistral-7b\"} 0.25\n")
+		case "/v1/models":
+			fmt.Fprint(w, `{"data":[{"id":"mistral-7b"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	rc := truseratest.NewRecordingClient()
+	defer rc.Close()
+	hc := &http.Client{Transport: WrapTransport(nil, rc.Client, Server{Kind: VLLM, URL: server.URL})}
+
+	post(t, hc, server.URL+"/v1/chat/completions", `{"model":"mistral-7b","messages":[]}`)
+	post(t, hc, server.URL+"/v1/models", ``)
+
+	e := waitForEvent(t, rc)
+	if fmt.Sprint(e.Payload["total_tokens"]) != "50" || fmt.Sprint(e.Payload["gpu_kv_cache_usage"]) != "0.25" {
+		t.Errorf("expected usage and KV cache usage from vLLM, got %v", e.Payload)
+	}
+	if tps, _ := e.Payload["tokens_per_second"].(float64); tps <= 0 {
+		t.Errorf("expected throughput from the call's latency, got %v", e.Payload["tokens_per_second"])
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := len(rc.Events(truseratest.OfType(trusera.EventLLMInvoke))); n != 1 {
+		t.Errorf("expected only inference calls recorded, got %d events", n)
+	}
+}
+
+func TestModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(ollamaHandler))
+	defer server.Close()
+
+	models, err := Models(context.Background(), nil,
+		Server{Kind: Ollama, URL: server.URL},
+		Server{Kind: VLLM, URL: server.URL})
+	if err == nil || !strings.Contains(err.Error(), "vllm") {
+		t.Errorf("expected the unreachable vLLM server reported, got %v", err)
+	}
+	want := discovery.Model{Name: "llama3:8b", Provider: Ollama, Source: discovery.SourceServer, Location: server.URL,
+		Format: "gguf", SizeBytes: 4661224676, Quantization: "Q4_0"}
+	if len(models) != 1 || models[0] != want {
+		t.Errorf("expected %+v, got %+v", want, models)
+	}
+}
+
+func TestFileQuantization(t *testing.T) {
+	for file, want := range map[string]string{
+		"/m/llama-3-8b.Q4_K_M.gguf": "Q4_K_M",
+		"/m/phi-3-mini-q8_0.gguf":   "Q8_0",
+		"/m/qwen2-7b-IQ3_XS.gguf":   "IQ3_XS",
+		"/m/gemma-2b-f16.gguf":      "F16",
+		"/m/model.gguf":             "",
+	} {
+		if got := fileQuantization(file); got != want {
+			t.Errorf("%s: expected %q, got %q", file, want, got)
+		}
+	}
+}
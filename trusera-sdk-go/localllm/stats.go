@@ -0,0 +1,131 @@
+package localllm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+// stats are the counts a server reports at the end of a response, in any
+// of the shapes the supported servers use
+type stats struct {
+	Model string `json:"model"`
+
+	// Ollama, durations in nanoseconds
+	Done               bool  `json:"done"`
+	PromptEvalCount    int   `json:"prompt_eval_count"`
+	EvalCount          int   `json:"eval_count"`
+	EvalDuration       int64 `json:"eval_duration"`
+	PromptEvalDuration int64 `json:"prompt_eval_duration"`
+	LoadDuration       int64 `json:"load_duration"`
+
+	// OpenAI-compatible endpoints of vLLM and llama.cpp
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+
+	// llama.cpp
+	TokensEvaluated int `json:"tokens_evaluated"`
+	TokensPredicted int `json:"tokens_predicted"`
+	Timings         *struct {
+		PromptN            int     `json:"prompt_n"`
+		PromptMS           float64 `json:"prompt_ms"`
+		PredictedN         int     `json:"predicted_n"`
+		PredictedMS        float64 `json:"predicted_ms"`
+		PredictedPerSecond float64 `json:"predicted_per_second"`
+	} `json:"timings"`
+}
+
+// final reports whether s carries a response's counts rather than being
+// an intermediate streamed chunk
+func (s *stats) final() bool {
+	return s.Done || s.Usage != nil || s.Timings != nil
+}
+
+// parseStats finds the counts in a response body: a single JSON document,
+// or the last chunk carrying them in an NDJSON or server-sent event stream
+func parseStats(body []byte) (stats, bool) {
+	var s stats
+	if json.Unmarshal(body, &s) == nil {
+		return s, s.final()
+	}
+
+	var found stats
+	ok := false
+	lines := bufio.NewScanner(bytes.NewReader(body))
+	lines.Buffer(nil, maxCapture)
+	for lines.Scan() {
+		line := bytes.TrimSpace(lines.Bytes())
+		line = bytes.TrimSpace(bytes.TrimPrefix(line, []byte("data:")))
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		var chunk stats
+		if json.Unmarshal(line, &chunk) != nil {
+			continue
+		}
+		if chunk.Model != "" {
+			found.Model = chunk.Model
+		}
+		if chunk.final() {
+			model := found.Model
+			found, ok = chunk, true
+			if found.Model == "" {
+				found.Model = model
+			}
+		}
+	}
+	return found, ok
+}
+
+// annotate adds token counts and throughput to an event. elapsed is used for
+// throughput when the server does not time generation itself.
+func (s stats) annotate(e trusera.Event, elapsed time.Duration) trusera.Event {
+	var prompt, completion, total int
+	var perSecond float64
+	switch {
+	case s.Timings != nil:
+		prompt, completion = s.Timings.PromptN, s.Timings.PredictedN
+		perSecond = s.Timings.PredictedPerSecond
+		if perSecond == 0 && s.Timings.PredictedMS > 0 {
+			perSecond = float64(completion) / s.Timings.PredictedMS * 1000
+		}
+	case s.Usage != nil:
+		prompt, completion, total = s.Usage.PromptTokens, s.Usage.CompletionTokens, s.Usage.TotalTokens
+	case s.Done:
+		prompt, completion = s.PromptEvalCount, s.EvalCount
+		if s.EvalDuration > 0 {
+			perSecond = float64(completion) / (float64(s.EvalDuration) / 1e9)
+		}
+		if s.LoadDuration > 0 {
+			e = e.WithPayload("load_ms", s.LoadDuration/1e6)
+		}
+	}
+	if s.Usage != nil && s.Timings != nil {
+		prompt, completion = s.Usage.PromptTokens, s.Usage.CompletionTokens
+	}
+	if prompt == 0 && completion == 0 {
+		prompt, completion = s.TokensEvaluated, s.TokensPredicted
+	}
+	if total == 0 {
+		total = prompt + completion
+	}
+	if perSecond == 0 && completion > 0 && elapsed > 0 {
+		perSecond = float64(completion) / elapsed.Seconds()
+	}
+	if s.Model != "" {
+		e = e.WithPayload("model", s.Model)
+	}
+	e = e.WithPayload("prompt_tokens", prompt).
+		WithPayload("completion_tokens", completion).
+		WithPayload("total_tokens", total)
+	if perSecond > 0 {
+		e = e.WithPayload("tokens_per_second", perSecond)
+	}
+	return e
+}
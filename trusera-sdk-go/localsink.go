@@ -0,0 +1,127 @@
+package trusera
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// defaultLocalSinkPath is used when TRUSERA_MODE=local and TRUSERA_LOCAL_SINK is unset
+const defaultLocalSinkPath = "trusera-events.jsonl"
+
+// LocalSink writes events, BOMs and fleet traffic as JSON lines to a file
+// instead of the network. Each line is a record with a "kind" (event, bom,
+// agent, prompt, register, heartbeat, inventory, eval or deregister), a
+// timestamp and the data that would have been sent.
+type LocalSink struct {
+	mu   sync.Mutex
+	file *os.File
+	err  error
+}
+
+// localRecord is one line written by LocalSink
+type localRecord struct {
+	Kind      string `json:"kind"`
+	Timestamp string `json:"timestamp"`
+	AgentID   string `json:"agent_id,omitempty"`
+	Data      any    `json:"data"`
+}
+
+// NewLocalSink opens path for appending, creating it if needed
+func NewLocalSink(path string) (*LocalSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open local sink: %w", err)
+	}
+	return &LocalSink{file: f}, nil
+}
+
+// WithLocalSink runs the client in local mode: events, BOMs, agent
+// registrations and fleet heartbeats are appended to path as JSON lines and
+// nothing is sent over the network. Setting TRUSERA_MODE=local does the same,
+// writing to TRUSERA_LOCAL_SINK or trusera-events.jsonl.
+func WithLocalSink(path string) Option {
+	return func(c *Client) {
+		c.localSinkPath = path
+	}
+}
+
+// Send writes one event record per event in the batch
+func (s *LocalSink) Send(_ context.Context, batch Batch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range batch.Events {
+		if err := s.writeLocked("event", batch.AgentID, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write appends a single record
+func (s *LocalSink) write(kind, agentID string, data any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writeLocked(kind, agentID, data)
+}
+
+func (s *LocalSink) writeLocked(kind, agentID string, data any) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.file == nil {
+		return os.ErrClosed
+	}
+
+	line, err := json.Marshal(localRecord{
+		Kind:      kind,
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		AgentID:   agentID,
+		Data:      data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s record: %w", kind, err)
+	}
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the underlying file
+func (s *LocalSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// openLocalSink sets up local mode if it was requested by option or environment
+func (c *Client) openLocalSink() {
+	if c.localSinkPath == "" && os.Getenv("TRUSERA_MODE") == "local" {
+		c.localSinkPath = envOrDefault("TRUSERA_LOCAL_SINK", defaultLocalSinkPath)
+	}
+	if c.localSinkPath == "" {
+		return
+	}
+
+	sink, err := NewLocalSink(c.localSinkPath)
+	if err != nil {
+		c.logf(LogError, "%v; events will be dropped", err)
+		sink = &LocalSink{err: err}
+	}
+	c.sink = sink
+	c.transport = sink
+}
+
+// localID returns a placeholder ID for registrations made in local mode
+func localID() string {
+	return "local-" + generateID()[:12]
+}
@@ -0,0 +1,95 @@
+package trusera
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Trusera/ai-bom/trusera-sdk-go/bom"
+)
+
+// readLocalRecords parses a local sink file into its records
+func readLocalRecords(t *testing.T, path string) []localRecord {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open sink: %v", err)
+	}
+	defer f.Close()
+
+	var records []localRecord
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var r localRecord
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", sc.Text(), err)
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestLocalSinkWritesAllTraffic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	client := NewClient("test-key",
+		WithBaseURL("http://127.0.0.1:1"),
+		WithLocalSink(path),
+		WithAutoRegister(),
+	)
+
+	agentID, err := client.RegisterAgent("planner", "custom")
+	if err != nil {
+		t.Fatalf("RegisterAgent failed: %v", err)
+	}
+	client.Track(NewEvent(EventToolCall, "search"))
+	client.sendHeartbeat()
+	if err := client.UploadBOM(bom.NewBuilder("planner").Build()); err != nil {
+		t.Fatalf("UploadBOM failed: %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	kinds := map[string]int{}
+	for _, r := range readLocalRecords(t, path) {
+		kinds[r.Kind]++
+		if r.Kind == "event" && r.AgentID != agentID {
+			t.Errorf("expected event agent_id %s, got %s", agentID, r.AgentID)
+		}
+	}
+	for _, kind := range []string{"register", "agent", "event", "heartbeat", "bom", "deregister"} {
+		if kinds[kind] != 1 {
+			t.Errorf("expected 1 %s record, got %d", kind, kinds[kind])
+		}
+	}
+	if s := client.Stats(); s.Flushed != 1 || s.HeartbeatFailures != 0 {
+		t.Errorf("expected 1 flushed event and no failures, got %+v", s)
+	}
+}
+
+func TestLocalModeFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "env.jsonl")
+	t.Setenv("TRUSERA_MODE", "local")
+	t.Setenv("TRUSERA_LOCAL_SINK", path)
+
+	client := NewClient("test-key")
+	client.Track(NewEvent(EventDecision, "approve"))
+	client.Close()
+
+	records := readLocalRecords(t, path)
+	if len(records) != 1 || records[0].Kind != "event" {
+		t.Fatalf("expected 1 event record, got %+v", records)
+	}
+}
+
+func TestLocalSinkOpenFailure(t *testing.T) {
+	client := NewClient("test-key", WithLocalSink(filepath.Join(t.TempDir(), "missing", "events.jsonl")))
+	client.Track(NewEvent(EventToolCall, "search"))
+
+	if err := client.Flush(); err == nil {
+		t.Error("expected flush to fail when the sink could not be opened")
+	}
+	client.Close()
+}
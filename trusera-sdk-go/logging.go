@@ -0,0 +1,141 @@
+package trusera
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// LogLevel controls which SDK diagnostics are passed to the Logger
+type LogLevel int32
+
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+	LogError
+	LogOff
+)
+
+// ParseLogLevel parses "debug", "info", "warn", "error" or "off"
+func ParseLogLevel(s string) (LogLevel, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return LogDebug, true
+	case "info":
+		return LogInfo, true
+	case "warn", "warning":
+		return LogWarn, true
+	case "error":
+		return LogError, true
+	case "off", "none":
+		return LogOff, true
+	}
+	return LogInfo, false
+}
+
+// String returns the level name ParseLogLevel accepts
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "debug"
+	case LogInfo:
+		return "info"
+	case LogWarn:
+		return "warn"
+	case LogError:
+		return "error"
+	case LogOff:
+		return "off"
+	}
+	return fmt.Sprintf("LogLevel(%d)", int32(l))
+}
+
+// Logger receives the SDK's own diagnostics, such as failed flushes and
+// dropped events. Messages are already formatted and have no prefix.
+type Logger interface {
+	Log(level LogLevel, msg string)
+}
+
+// LoggerFunc adapts a function to the Logger interface
+type LoggerFunc func(level LogLevel, msg string)
+
+// Log calls f(level, msg)
+func (f LoggerFunc) Log(level LogLevel, msg string) {
+	f(level, msg)
+}
+
+// NewSlogLogger returns a Logger that writes diagnostics to l with a
+// logger=trusera attribute. A nil l writes to slog.Default() at the time of
+// each call.
+func NewSlogLogger(l *slog.Logger) Logger {
+	return slogLogger{l}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// sdkLogKey marks the context of the SDK's own log calls, so NewSlogHandler
+// does not track them as events
+type sdkLogKey struct{}
+
+var sdkLogContext = context.WithValue(context.Background(), sdkLogKey{}, true)
+
+func (s slogLogger) Log(level LogLevel, msg string) {
+	l := s.logger
+	if l == nil {
+		l = slog.Default()
+	}
+	l.Log(sdkLogContext, level.slogLevel(), msg, slog.String("logger", "trusera"))
+}
+
+// slogLevel maps l to the matching slog level
+func (l LogLevel) slogLevel() slog.Level {
+	switch l {
+	case LogDebug:
+		return slog.LevelDebug
+	case LogWarn:
+		return slog.LevelWarn
+	case LogError, LogOff:
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
+
+// WithLogLevel sets the minimum level of SDK diagnostics that are logged (default LogInfo)
+func WithLogLevel(level LogLevel) Option {
+	return func(c *Client) {
+		c.logLevel.Store(int32(level))
+	}
+}
+
+// WithLogger sends SDK diagnostics at or above the WithLogLevel level to
+// logger instead of slog.Default(). A nil logger silences the SDK entirely,
+// including the message logged before NewClient exits on invalid
+// configuration.
+func WithLogger(logger Logger) Option {
+	return func(c *Client) {
+		if logger == nil {
+			logger = LoggerFunc(func(LogLevel, string) {})
+		}
+		c.logger = logger
+	}
+}
+
+// logf writes an SDK diagnostic if level is at or above the configured level
+func (c *Client) logf(level LogLevel, format string, args ...any) {
+	if level < LogLevel(c.logLevel.Load()) {
+		return
+	}
+	c.logger.Log(level, fmt.Sprintf(format, args...))
+}
+
+// fatalf logs a configuration error NewClient cannot start with, whatever the
+// log level, and exits
+func (c *Client) fatalf(format string, args ...any) {
+	c.logger.Log(LogError, fmt.Sprintf(format, args...))
+	os.Exit(1)
+}
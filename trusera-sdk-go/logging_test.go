@@ -0,0 +1,86 @@
+package trusera
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	for in, want := range map[string]LogLevel{"debug": LogDebug, "WARN": LogWarn, "off": LogOff} {
+		if got, ok := ParseLogLevel(in); !ok || got != want {
+			t.Errorf("%s: expected %d, got %d", in, want, got)
+		}
+	}
+	if _, ok := ParseLogLevel("verbose"); ok {
+		t.Error("expected unknown level to fail")
+	}
+}
+
+func TestWithLogLevel(t *testing.T) {
+	client := NewClient("test-key", WithLogLevel(LogOff))
+	defer client.Close()
+
+	if LogLevel(client.logLevel.Load()) != LogOff {
+		t.Errorf("expected LogOff, got %d", client.logLevel.Load())
+	}
+}
+
+func TestWithLoggerReceivesDiagnostics(t *testing.T) {
+	var got []string
+	logger := LoggerFunc(func(level LogLevel, msg string) {
+		got = append(got, level.String()+": "+msg)
+	})
+	client := NewClient("", WithLogger(logger), WithLogLevel(LogWarn))
+	defer client.Close()
+	client.logf(LogDebug, "not logged")
+	client.logf(LogWarn, "logged")
+
+	want := []string{"warn: API key is empty, API calls will fail", "warn: logged"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestWithNilLoggerSilencesSDK(t *testing.T) {
+	var buf bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	client := NewClient("", WithLogger(nil))
+	client.logf(LogError, "failed")
+	client.Close()
+
+	if buf.Len() != 0 {
+		t.Errorf("expected no output, got %q", buf.String())
+	}
+}
+
+func TestSlogLoggerWritesStructuredRecords(t *testing.T) {
+	var buf bytes.Buffer
+	client := NewClient("test-key", WithLogger(NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, nil)))))
+	defer client.Close()
+	client.logf(LogWarn, "flush failed: %v", "timeout")
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("expected a JSON record, got %q", buf.String())
+	}
+	if record["level"] != "WARN" || record["msg"] != "flush failed: timeout" || record["logger"] != "trusera" {
+		t.Errorf("expected a warning from the trusera logger, got %v", record)
+	}
+}
+
+func TestDefaultLoggerSkipsSlogHandler(t *testing.T) {
+	client := NewClient("test-key")
+	defer client.Close()
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(NewSlogHandler(client, nil)))
+
+	client.logf(LogWarn, "flush failed")
+	if n := len(queuedEvents(client)); n != 0 {
+		t.Errorf("expected SDK diagnostics not to be tracked, got %d events", n)
+	}
+}
@@ -0,0 +1,295 @@
+// Package mcpgo records the Model Context Protocol (MCP) traffic of clients
+// built with the official MCP Go SDK (github.com/modelcontextprotocol/go-sdk):
+// which MCP servers an agent connects to and which of their tools it calls,
+// with argument and result sizes. The servers and tools seen can be added to
+// the agent's AI-BOM.
+package mcpgo
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/bom"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// MCP methods the recorder inspects
+const (
+	methodInitialize = "initialize"
+	methodDiscover   = "server/discover"
+	methodListTools  = "tools/list"
+	methodCallTool   = "tools/call"
+)
+
+// Tracker records events; *trusera.Client, *trusera.Agent and
+// *trusera.Session satisfy it
+type Tracker interface {
+	TrackContext(ctx context.Context, event trusera.Event)
+}
+
+// Server is an MCP server the agent connected to
+type Server struct {
+	Name    string
+	Title   string
+	Version string
+	Tools   []Tool // Sorted by name
+}
+
+// Tool is a tool offered by an MCP server. Tools called without being listed
+// first have no description or schema.
+type Tool struct {
+	Name        string
+	Description string
+	Schema      map[string]any // JSON Schema of the tool's input
+	Calls       int
+}
+
+// Recorder records the MCP traffic of one or more clients and keeps an
+// inventory of the servers and tools seen
+type Recorder struct {
+	tracker Tracker
+
+	mu      sync.Mutex
+	servers map[string]*Server
+}
+
+// Instrument adds sending middleware to client that records connections as
+// EventAPICall events and tool calls as EventToolCall events. Connect
+// sessions after instrumenting the client. The returned recorder can be
+// shared by several clients.
+func Instrument(client *mcp.Client, tracker Tracker) *Recorder {
+	r := NewRecorder(tracker)
+	client.AddSendingMiddleware(r.Middleware())
+	return r
+}
+
+// NewRecorder returns a recorder to install with Middleware
+func NewRecorder(tracker Tracker) *Recorder {
+	return &Recorder{tracker: tracker, servers: make(map[string]*Server)}
+}
+
+// Middleware returns the sending middleware installed by Instrument
+func (r *Recorder) Middleware() mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			start := time.Now()
+			res, err := next(ctx, method, req)
+			latency := time.Since(start)
+
+			switch method {
+			case methodInitialize:
+				var info *mcp.Implementation
+				var version string
+				if init, ok := res.(*mcp.InitializeResult); ok && err == nil {
+					info, version = init.ServerInfo, init.ProtocolVersion
+				}
+				r.connected(ctx, info, version, latency, err)
+			case methodDiscover:
+				// A failed discovery falls back to initialize, which is recorded
+				if disc, ok := res.(*mcp.DiscoverResult); ok && err == nil {
+					r.connected(ctx, discoveredServer(disc), "", latency, nil)
+				}
+			case methodListTools:
+				if list, ok := res.(*mcp.ListToolsResult); ok && err == nil {
+					r.listed(serverOf(req), list.Tools)
+				}
+			case methodCallTool:
+				params, _ := req.GetParams().(*mcp.CallToolParams)
+				result, _ := res.(*mcp.CallToolResult)
+				r.called(ctx, serverOf(req), params, result, latency, err)
+			}
+			return res, err
+		}
+	}
+}
+
+// connected records a connection attempt
+func (r *Recorder) connected(ctx context.Context, info *mcp.Implementation, protocolVersion string, latency time.Duration, err error) {
+	name := "unknown"
+	e := trusera.NewEvent(trusera.EventAPICall, "mcp connect").
+		WithPayload("protocol", "mcp").
+		WithPayload("latency_ms", latency.Milliseconds())
+	if info != nil && info.Name != "" {
+		name = info.Name
+		r.server(info)
+		e = e.WithPayload("mcp.server_version", info.Version)
+	}
+	e.Name += " " + name
+	e = e.WithPayload("mcp.server", name)
+	if protocolVersion != "" {
+		e = e.WithPayload("mcp.protocol_version", protocolVersion)
+	}
+	if err != nil {
+		e = e.WithPayload("error", err.Error())
+	}
+	r.tracker.TrackContext(ctx, e)
+}
+
+// listed adds the tools a server listed to the inventory
+func (r *Recorder) listed(info *mcp.Implementation, tools []*mcp.Tool) {
+	if info == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := r.serverLocked(info)
+	for _, t := range tools {
+		if t == nil {
+			continue
+		}
+		tool := toolLocked(s, t.Name)
+		tool.Description = t.Description
+		tool.Schema = schemaMap(t.InputSchema)
+	}
+}
+
+// called records a tool call and counts it in the inventory
+func (r *Recorder) called(ctx context.Context, info *mcp.Implementation, params *mcp.CallToolParams, result *mcp.CallToolResult, latency time.Duration, err error) {
+	name := ""
+	if params != nil {
+		name = params.Name
+	}
+
+	e := trusera.NewEvent(trusera.EventToolCall, name).
+		WithPayload("protocol", "mcp").
+		WithPayload("tool", name).
+		WithPayload("latency_ms", latency.Milliseconds())
+	if info != nil {
+		e = e.WithPayload("mcp.server", info.Name).WithPayload("mcp.server_version", info.Version)
+		r.mu.Lock()
+		toolLocked(r.serverLocked(info), name).Calls++
+		r.mu.Unlock()
+	}
+	if params != nil {
+		e = e.WithPayload("argument_bytes", jsonSize(params.Arguments))
+	}
+	if result != nil {
+		e = e.WithPayload("result_bytes", jsonSize(result)).WithPayload("is_error", result.IsError)
+	}
+	if err != nil {
+		e = e.WithPayload("error", err.Error())
+	}
+	r.tracker.TrackContext(ctx, e)
+}
+
+// Servers returns the servers connected to and the tools seen, sorted by name
+func (r *Recorder) Servers() []Server {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]Server, 0, len(r.servers))
+	for _, s := range r.servers {
+		c := *s
+		c.Tools = append([]Tool(nil), s.Tools...)
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// AddToBOM adds every tool seen to an AI-BOM, with an mcp:// endpoint naming
+// its server
+func (r *Recorder) AddToBOM(b *bom.Builder) {
+	for _, s := range r.Servers() {
+		for _, t := range s.Tools {
+			b.AddTool(bom.Tool{
+				Name:        t.Name,
+				Description: t.Description,
+				Endpoint:    "mcp://" + s.Name + "/" + t.Name,
+				Schema:      t.Schema,
+			})
+		}
+	}
+}
+
+// server adds info to the inventory
+func (r *Recorder) server(info *mcp.Implementation) {
+	r.mu.Lock()
+	r.serverLocked(info)
+	r.mu.Unlock()
+}
+
+// serverLocked returns the inventory entry for info, creating it if needed
+func (r *Recorder) serverLocked(info *mcp.Implementation) *Server {
+	s := r.servers[info.Name]
+	if s == nil {
+		s = &Server{Name: info.Name}
+		r.servers[info.Name] = s
+	}
+	s.Title, s.Version = info.Title, info.Version
+	return s
+}
+
+// toolLocked returns the server's tool named name, adding it if needed
+func toolLocked(s *Server, name string) *Tool {
+	i := sort.Search(len(s.Tools), func(i int) bool { return s.Tools[i].Name >= name })
+	if i == len(s.Tools) || s.Tools[i].Name != name {
+		s.Tools = append(s.Tools, Tool{})
+		copy(s.Tools[i+1:], s.Tools[i:])
+		s.Tools[i] = Tool{Name: name}
+	}
+	return &s.Tools[i]
+}
+
+// serverOf returns the server a request is sent to, if the session is
+// initialized
+func serverOf(req mcp.Request) *mcp.Implementation {
+	cs, ok := req.GetSession().(*mcp.ClientSession)
+	if !ok || cs == nil {
+		return nil
+	}
+	if res := cs.InitializeResult(); res != nil && res.ServerInfo != nil && res.ServerInfo.Name != "" {
+		return res.ServerInfo
+	}
+	return nil
+}
+
+// discoveredServer extracts the server info from a server/discover result
+func discoveredServer(res *mcp.DiscoverResult) *mcp.Implementation {
+	raw, ok := res.GetMeta()[mcp.MetaKeyServerInfo]
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var info mcp.Implementation
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil
+	}
+	return &info
+}
+
+// schemaMap converts a tool's input schema to a generic JSON object
+func schemaMap(schema any) map[string]any {
+	if m, ok := schema.(map[string]any); ok {
+		return m
+	}
+	if schema == nil {
+		return nil
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil
+	}
+	return m
+}
+
+// jsonSize returns the size of v encoded as JSON
+func jsonSize(v any) int {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
@@ -0,0 +1,111 @@
+package mcpgo
+
+import (
+	"context"
+	"testing"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/bom"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/truseratest"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type lookupArgs struct {
+	OrderID string `json:"order_id"`
+}
+
+type lookupResult struct {
+	Status string `json:"status"`
+}
+
+// connect starts an in-memory orders server and connects an instrumented client to it
+func connect(t *testing.T, tracker Tracker, opts *mcp.ClientSessionOptions) (*Recorder, *mcp.ClientSession) {
+	t.Helper()
+	ctx := context.Background()
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "orders", Version: "1.2.0"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "lookup_order", Description: "Fetch order status"},
+		func(ctx context.Context, req *mcp.CallToolRequest, args lookupArgs) (*mcp.CallToolResult, lookupResult, error) {
+			return nil, lookupResult{Status: "shipped"}, nil
+		})
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	ss, err := server.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ss.Close() })
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "support-agent", Version: "0.1.0"}, nil)
+	rec := Instrument(client, tracker)
+	cs, err := client.Connect(ctx, clientTransport, opts)
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return rec, cs
+}
+
+func TestRecordsConnectionsAndToolCalls(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts *mcp.ClientSessionOptions
+	}{
+		{"latest", nil},
+		{"legacy initialize", &mcp.ClientSessionOptions{ProtocolVersion: "2025-06-18"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := truseratest.NewRecordingClient()
+			defer rc.Close()
+
+			rec, cs := connect(t, rc.Client, tt.opts)
+			ctx := context.Background()
+			if _, err := cs.ListTools(ctx, nil); err != nil {
+				t.Fatalf("ListTools failed: %v", err)
+			}
+			if _, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "lookup_order", Arguments: lookupArgs{OrderID: "42"}}); err != nil {
+				t.Fatalf("CallTool failed: %v", err)
+			}
+
+			conn := truseratest.AssertEventEmitted(t, rc, truseratest.OfType(trusera.EventAPICall))
+			if conn.Name != "mcp connect orders" || conn.Payload["mcp.server_version"] != "1.2.0" {
+				t.Errorf("expected a connection to orders 1.2.0, got %s %v", conn.Name, conn.Payload)
+			}
+
+			call := truseratest.AssertEventEmitted(t, rc, truseratest.OfType(trusera.EventToolCall))
+			p := call.Payload
+			if call.Name != "lookup_order" || p["mcp.server"] != "orders" || p["is_error"] != false {
+				t.Errorf("unexpected tool call %s %v", call.Name, p)
+			}
+			if p["argument_bytes"] != len(`{"order_id":"42"}`) || p["result_bytes"].(int) == 0 {
+				t.Errorf("expected argument and result sizes, got %v", p)
+			}
+
+			servers := rec.Servers()
+			if len(servers) != 1 || servers[0].Name != "orders" || len(servers[0].Tools) != 1 {
+				t.Fatalf("expected the orders server in the inventory, got %+v", servers)
+			}
+			tool := servers[0].Tools[0]
+			if tool.Description != "Fetch order status" || tool.Schema["type"] != "object" || tool.Calls != 1 {
+				t.Errorf("unexpected tool %+v", tool)
+			}
+		})
+	}
+}
+
+func TestAddToBOM(t *testing.T) {
+	rc := truseratest.NewRecordingClient()
+	defer rc.Close()
+
+	rec, cs := connect(t, rc.Client, nil)
+	if _, err := cs.ListTools(context.Background(), nil); err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+
+	b := bom.NewBuilder("support-agent")
+	rec.AddToBOM(b)
+	doc := b.Build()
+	if len(doc.Tools) != 1 || doc.Tools[0].Endpoint != "mcp://orders/lookup_order" || doc.Tools[0].Schema == nil {
+		t.Errorf("expected the MCP tool in the BOM, got %+v", doc.Tools)
+	}
+}
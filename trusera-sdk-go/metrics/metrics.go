@@ -0,0 +1,75 @@
+// Package metrics exposes Trusera client self-metrics in Prometheus text
+// exposition format and via expvar, so operators can monitor the monitor.
+package metrics
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+// contentType is the Prometheus text exposition format media type
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Source provides client statistics; *trusera.Client satisfies it
+type Source interface {
+	Stats() trusera.Stats
+}
+
+// metric describes a single exported series
+type metric struct {
+	name  string
+	help  string
+	kind  string
+	value func(trusera.Stats) float64
+}
+
+var metricsList = []metric{
+	{"trusera_sdk_events_queued", "Events waiting to be sent.", "gauge",
+		func(s trusera.Stats) float64 { return float64(s.Queued) }},
+	{"trusera_sdk_events_tracked_total", "Events accepted by Track.", "counter",
+		func(s trusera.Stats) float64 { return float64(s.Tracked) }},
+	{"trusera_sdk_events_flushed_total", "Events delivered to the Trusera API.", "counter",
+		func(s trusera.Stats) float64 { return float64(s.Flushed) }},
+	{"trusera_sdk_events_dropped_total", "Events discarded without being delivered.", "counter",
+		func(s trusera.Stats) float64 { return float64(s.Dropped) }},
+	{"trusera_sdk_flush_errors_total", "Failed event delivery attempts.", "counter",
+		func(s trusera.Stats) float64 { return float64(s.FlushErrors) }},
+	{"trusera_sdk_heartbeat_failures_total", "Failed fleet heartbeats.", "counter",
+		func(s trusera.Stats) float64 { return float64(s.HeartbeatFailures) }},
+	{"trusera_sdk_rate_limited_total", "Event deliveries rejected by API rate limiting.", "counter",
+		func(s trusera.Stats) float64 { return float64(s.RateLimited) }},
+}
+
+// WritePrometheus writes stats in Prometheus text exposition format
+func WritePrometheus(w io.Writer, s trusera.Stats) error {
+	bw := bufio.NewWriter(w)
+
+	for _, m := range metricsList {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n%s %g\n", m.name, m.help, m.name, m.kind, m.name, m.value(s))
+	}
+
+	const flush = "trusera_sdk_flush_duration_seconds"
+	fmt.Fprintf(bw, "# HELP %s Time spent delivering event batches.\n# TYPE %s summary\n", flush, flush)
+	fmt.Fprintf(bw, "%s_sum %g\n%s_count %d\n", flush, s.FlushDuration.Seconds(), flush, s.Flushes)
+
+	return bw.Flush()
+}
+
+// Handler returns an http.Handler serving src's stats for Prometheus scraping
+func Handler(src Source) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		_ = WritePrometheus(w, src.Stats())
+	})
+}
+
+// Publish exposes src's stats under name in expvar (served at /debug/vars).
+// Like expvar.Publish, it panics if name is already registered.
+func Publish(name string, src Source) {
+	expvar.Publish(name, expvar.Func(func() any { return src.Stats() }))
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+type staticSource trusera.Stats
+
+func (s staticSource) Stats() trusera.Stats { return trusera.Stats(s) }
+
+var sample = staticSource{
+	Queued:            3,
+	Tracked:           10,
+	Flushed:           7,
+	Dropped:           1,
+	Flushes:           4,
+	FlushErrors:       1,
+	FlushDuration:     1500 * time.Millisecond,
+	HeartbeatFailures: 2,
+}
+
+func TestWritePrometheus(t *testing.T) {
+	var sb strings.Builder
+	if err := WritePrometheus(&sb, trusera.Stats(sample)); err != nil {
+		t.Fatalf("WritePrometheus failed: %v", err)
+	}
+	out := sb.String()
+
+	for _, want := range []string{
+		"# TYPE trusera_sdk_events_queued gauge",
+		"trusera_sdk_events_queued 3\n",
+		"trusera_sdk_events_flushed_total 7\n",
+		"trusera_sdk_events_dropped_total 1\n",
+		"trusera_sdk_heartbeat_failures_total 2\n",
+		"# TYPE trusera_sdk_flush_duration_seconds summary",
+		"trusera_sdk_flush_duration_seconds_sum 1.5\n",
+		"trusera_sdk_flush_duration_seconds_count 4\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q\n%s", want, out)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler(sample).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type: %s", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "trusera_sdk_events_tracked_total 10") {
+		t.Errorf("expected tracked counter in body, got:\n%s", rec.Body.String())
+	}
+}
+
+func TestPublish(t *testing.T) {
+	Publish("trusera_test", sample)
+
+	v := expvar.Get("trusera_test")
+	if v == nil {
+		t.Fatal("expected expvar to be registered")
+	}
+
+	var got trusera.Stats
+	if err := json.Unmarshal([]byte(v.String()), &got); err != nil {
+		t.Fatalf("expvar value is not JSON: %v", err)
+	}
+	if got.Flushed != 7 {
+		t.Errorf("expected flushed 7, got %d", got.Flushed)
+	}
+}
@@ -0,0 +1,158 @@
+// Package middleware instruments inbound HTTP requests to agent servers. Each
+// request is recorded as a span with its route, status and latency, and the
+// span is placed in the request context so LLM calls, tool calls and other
+// events recorded while handling the request join its trace.
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+// SpanStarter starts spans; *trusera.Client and *trusera.Agent satisfy it
+type SpanStarter interface {
+	StartSpan(ctx context.Context, name string) (context.Context, *trusera.Span)
+}
+
+// Identity names the caller of a request
+type Identity struct {
+	UserID    string
+	SessionID string
+}
+
+// Options configures the middleware
+type Options struct {
+	// Route returns the route template of a request, e.g. "/chat/{id}", to
+	// keep span names low-cardinality. Defaults to the URL path. On Go 1.22+
+	// with http.ServeMux, return r.Pattern.
+	Route func(r *http.Request) string
+	// Identify extracts the user and session a request belongs to, e.g. from
+	// a verified token or cookie. Empty fields are omitted.
+	Identify func(r *http.Request) Identity
+	// Skip excludes requests, such as health checks, from instrumentation
+	Skip func(r *http.Request) bool
+}
+
+// Handler wraps next so every request is recorded as a span. Spans join the
+// caller's trace when the request carries a traceparent header.
+func Handler(tracer SpanStarter, next http.Handler, opts Options) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if opts.Skip != nil && opts.Skip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		route := r.URL.Path
+		if opts.Route != nil {
+			if rt := opts.Route(r); rt != "" {
+				route = rt
+			}
+		}
+
+		ctx := trusera.ExtractHeaders(r.Context(), r.Header)
+		ctx, span := tracer.StartSpan(ctx, r.Method+" "+route)
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.route", route)
+		if opts.Identify != nil {
+			id := opts.Identify(r)
+			if id.UserID != "" {
+				span.SetAttribute("user_id", id.UserID)
+			}
+			if id.SessionID != "" {
+				span.SetAttribute("session_id", id.SessionID)
+			}
+		}
+
+		rec := &responseRecorder{ResponseWriter: w}
+		defer func() {
+			if p := recover(); p != nil {
+				if p == http.ErrAbortHandler {
+					finish(span, rec, nil)
+				} else {
+					rec.status = http.StatusInternalServerError
+					finish(span, rec, fmt.Errorf("panic: %v", p))
+				}
+				panic(p)
+			}
+			finish(span, rec, nil)
+		}()
+		next.ServeHTTP(rec, r.WithContext(ctx))
+	})
+}
+
+// Middleware returns Handler as a function for routers that chain
+// func(http.Handler) http.Handler middleware, such as chi
+func Middleware(tracer SpanStarter, opts Options) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return Handler(tracer, next, opts)
+	}
+}
+
+// finish records the response on the span and ends it. Server errors fail
+// the span with err, or with the status if err is nil.
+func finish(span *trusera.Span, rec *responseRecorder, err error) {
+	status := rec.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	span.SetAttribute("http.status", status)
+	span.SetAttribute("http.response_bytes", rec.written)
+	if err == nil && status >= 500 {
+		err = fmt.Errorf("HTTP %d", status)
+	}
+	span.SetError(err)
+	span.End()
+}
+
+// responseRecorder captures the status and size of a response
+type responseRecorder struct {
+	http.ResponseWriter
+	status  int
+	written int64
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.written += int64(n)
+	return n, err
+}
+
+// Flush supports streaming responses such as server-sent events
+func (r *responseRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack supports WebSocket upgrades
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	if r.status == 0 {
+		r.status = http.StatusSwitchingProtocols
+	}
+	return h.Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/truseratest"
+)
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestHandlerCorrelatesLLMCalls(t *testing.T) {
+	rc := truseratest.NewRecordingClient()
+	defer rc.Close()
+
+	llm := &http.Client{Transport: trusera.WrapTransport(roundTripper(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"model":"gpt-4o","usage":{"prompt_tokens":3,"completion_tokens":4}}`)),
+		}, nil
+	}), rc.Client)}
+
+	chat := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodPost, "https://api.openai.com/v1/chat/completions",
+			strings.NewReader(`{"model":"gpt-4o"}`))
+		resp, err := llm.Do(req)
+		if err != nil {
+			t.Errorf("LLM call failed: %v", err)
+			return
+		}
+		io.ReadAll(resp.Body)
+		resp.Body.Close()
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	})
+
+	handler := Handler(rc.Client, chat, Options{
+		Route: func(r *http.Request) string { return "/chat/{id}" },
+		Identify: func(r *http.Request) Identity {
+			return Identity{UserID: r.Header.Get("X-User"), SessionID: "s-1"}
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/chat/42", nil)
+	req.Header.Set("X-User", "u-7")
+	req.Header.Set(trusera.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	span := truseratest.AssertEventEmitted(t, rc, truseratest.OfType(trusera.EventSpan))
+	if span.Name != "POST /chat/{id}" {
+		t.Errorf("expected span named after the route, got %q", span.Name)
+	}
+	p := span.Payload
+	if p["http.route"] != "/chat/{id}" || p["http.status"] != http.StatusCreated || p["http.response_bytes"] != int64(5) {
+		t.Errorf("unexpected request attributes %v", p)
+	}
+	if p["user_id"] != "u-7" || p["session_id"] != "s-1" {
+		t.Errorf("expected identity attributes, got %v", p)
+	}
+	if span.Metadata["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected the caller's trace, got %v", span.Metadata)
+	}
+
+	call := truseratest.AssertEventEmitted(t, rc, truseratest.OfType(trusera.EventLLMInvoke))
+	if call.Metadata["trace_id"] != span.Metadata["trace_id"] || call.Metadata["parent_span_id"] != span.Metadata["span_id"] {
+		t.Errorf("expected the LLM call linked to the request span, got %v and %v", call.Metadata, span.Metadata)
+	}
+}
+
+func TestHandlerRecordsServerErrorsAndPanics(t *testing.T) {
+	rc := truseratest.NewRecordingClient()
+	defer rc.Close()
+
+	mw := Middleware(rc.Client, Options{Skip: func(r *http.Request) bool { return r.URL.Path == "/healthz" }})
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fail":
+			http.Error(w, "boom", http.StatusBadGateway)
+		case "/panic":
+			panic("handler bug")
+		}
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected the panic to propagate")
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	}()
+
+	truseratest.AssertNoEvent(t, rc, truseratest.Named("GET /healthz"))
+	failed := truseratest.AssertEventEmitted(t, rc, truseratest.Named("GET /fail"))
+	if failed.Payload["status"] != "error" || failed.Payload["http.status"] != http.StatusBadGateway {
+		t.Errorf("expected a failed span with status 502, got %v", failed.Payload)
+	}
+	panicked := truseratest.AssertEventEmitted(t, rc, truseratest.Named("GET /panic"))
+	if panicked.Payload["error"] != "panic: handler bug" || panicked.Payload["http.status"] != http.StatusInternalServerError {
+		t.Errorf("expected the panic recorded, got %v", panicked.Payload)
+	}
+}
@@ -0,0 +1,141 @@
+package trusera
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/Trusera/ai-bom/trusera-sdk-go/bom"
+)
+
+// modelCards holds the cards attached by a client, by model ID
+type modelCards struct {
+	mu    sync.Mutex
+	cards map[string]bom.ModelCard
+}
+
+// AttachModelCard uploads the documentation of a model the agent uses to
+// /v1/model-cards, linked to the registered agent, so auditors can pull the
+// cards that shipped with each agent version. Build the card from structured
+// fields, from a Markdown file with bom.ParseModelCard, or both. The card is
+// kept even if the upload fails; see AddModelCardsToBOM.
+func (c *Client) AttachModelCard(modelID string, card bom.ModelCard) error {
+	if modelID == "" {
+		return errors.New("model ID is required")
+	}
+	if card.IsZero() {
+		return errors.New("model card is empty")
+	}
+
+	c.cards.mu.Lock()
+	if c.cards.cards == nil {
+		c.cards.cards = make(map[string]bom.ModelCard)
+	}
+	c.cards.cards[modelID] = card
+	c.cards.mu.Unlock()
+
+	c.mu.Lock()
+	agentID := c.agentID
+	c.mu.Unlock()
+
+	payload := map[string]any{
+		"model_id": modelID,
+		"card":     modelCardPayload(card),
+	}
+	if agentID != "" {
+		payload["agent_id"] = agentID
+	}
+
+	if c.sink != nil {
+		return c.sink.write("model_card", agentID, payload)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal model card: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/v1/model-cards", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.currentAPIKey())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to attach model card: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return newAPIError(resp)
+	}
+	// Drain body to allow connection reuse
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	return nil
+}
+
+// AddModelCardsToBOM attaches every card passed to AttachModelCard to the
+// model of the same name in an AI-BOM, declaring models that are missing
+func (c *Client) AddModelCardsToBOM(b *bom.Builder) {
+	c.cards.mu.Lock()
+	ids := make([]string, 0, len(c.cards.cards))
+	for id := range c.cards.cards {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	cards := make([]bom.ModelCard, len(ids))
+	for i, id := range ids {
+		cards[i] = c.cards.cards[id]
+	}
+	c.cards.mu.Unlock()
+
+	for i, id := range ids {
+		b.AttachModelCard(id, cards[i])
+	}
+}
+
+// modelCardPayload describes a model card for the API
+func modelCardPayload(card bom.ModelCard) map[string]any {
+	out := map[string]any{}
+	if card.Description != "" {
+		out["description"] = card.Description
+	}
+	if card.IntendedUse != "" {
+		out["intended_use"] = card.IntendedUse
+	}
+	if card.OutOfScopeUse != "" {
+		out["out_of_scope_use"] = card.OutOfScopeUse
+	}
+	if len(card.Limitations) > 0 {
+		out["limitations"] = card.Limitations
+	}
+	if len(card.EthicalConsiderations) > 0 {
+		out["ethical_considerations"] = card.EthicalConsiderations
+	}
+	if card.License != "" {
+		out["license"] = card.License
+	}
+	if len(card.Metrics) > 0 {
+		metrics := make([]map[string]string, 0, len(card.Metrics))
+		for _, m := range card.Metrics {
+			metric := map[string]string{"type": m.Type, "value": m.Value}
+			if m.Dataset != "" {
+				metric["dataset"] = m.Dataset
+			}
+			metrics = append(metrics, metric)
+		}
+		out["metrics"] = metrics
+	}
+	if card.Markdown != "" {
+		out["markdown"] = card.Markdown
+		out["markdown_sha256"] = card.MarkdownHash()
+	}
+	return out
+}
@@ -0,0 +1,73 @@
+package trusera
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Trusera/ai-bom/trusera-sdk-go/bom"
+)
+
+func TestAttachModelCard(t *testing.T) {
+	var path string
+	var received map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	defer client.Close()
+
+	card := bom.ParseModelCard("---\nlicense: apache-2.0\n---\n# support-llama\n\nLlama 3 tuned on support tickets.\n")
+	card.Metrics = []bom.Metric{{Type: "accuracy", Value: "0.91", Dataset: "tickets-eval"}}
+	if err := client.AttachModelCard("support-llama", card); err != nil {
+		t.Fatalf("AttachModelCard failed: %v", err)
+	}
+
+	if path != "/v1/model-cards" || received["model_id"] != "support-llama" {
+		t.Errorf("unexpected request to %s: %v", path, received)
+	}
+	sent, _ := received["card"].(map[string]any)
+	if sent["license"] != "apache-2.0" || sent["description"] != "Llama 3 tuned on support tickets." || sent["markdown_sha256"] != card.MarkdownHash() {
+		t.Errorf("unexpected card %v", sent)
+	}
+	if metrics, _ := sent["metrics"].([]any); len(metrics) != 1 {
+		t.Errorf("expected 1 metric, got %v", sent["metrics"])
+	}
+
+	b := bom.NewBuilder("agent").AddModel(bom.Model{Name: "support-llama", Provider: "acme"})
+	client.AddModelCardsToBOM(b)
+	models := b.Build().Models
+	if len(models) != 1 || models[0].Card == nil || models[0].Card.License != "apache-2.0" {
+		t.Errorf("expected card attached to declared model, got %+v", models)
+	}
+}
+
+func TestAttachModelCardErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	defer client.Close()
+
+	if err := client.AttachModelCard("", bom.ModelCard{Description: "x"}); err == nil {
+		t.Error("expected error for missing model ID")
+	}
+	if err := client.AttachModelCard("m", bom.ModelCard{}); err == nil {
+		t.Error("expected error for empty card")
+	}
+	if err := client.AttachModelCard("m", bom.ModelCard{Description: "x"}); err == nil {
+		t.Error("expected error for 400 response")
+	}
+
+	b := bom.NewBuilder("agent")
+	client.AddModelCardsToBOM(b)
+	if models := b.Build().Models; len(models) != 1 || models[0].Name != "m" {
+		t.Errorf("expected card kept after failed upload, got %+v", models)
+	}
+}
@@ -0,0 +1,194 @@
+package trusera
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenExpiryDelta is how long before expiry a cached token is refreshed
+const tokenExpiryDelta = 30 * time.Second
+
+// Token is a short-lived bearer token used instead of a static API key
+type Token struct {
+	AccessToken string
+	Expiry      time.Time // Zero means the token does not expire
+}
+
+// valid reports whether the token can still be used
+func (t *Token) valid() bool {
+	return t != nil && t.AccessToken != "" &&
+		(t.Expiry.IsZero() || time.Until(t.Expiry) > tokenExpiryDelta)
+}
+
+// TokenSource supplies bearer tokens. It mirrors golang.org/x/oauth2's
+// TokenSource so an oauth2 source can be adapted with TokenSourceFunc:
+//
+//	trusera.TokenSourceFunc(func() (*trusera.Token, error) {
+//		t, err := src.Token()
+//		if err != nil {
+//			return nil, err
+//		}
+//		return &trusera.Token{AccessToken: t.AccessToken, Expiry: t.Expiry}, nil
+//	})
+type TokenSource interface {
+	Token() (*Token, error)
+}
+
+// TokenSourceFunc adapts a function to a TokenSource
+type TokenSourceFunc func() (*Token, error)
+
+func (f TokenSourceFunc) Token() (*Token, error) {
+	return f()
+}
+
+// WithTokenSource authenticates API calls with tokens from ts instead of the
+// static API key. Tokens are cached until shortly before they expire, and a
+// request rejected with 401 is retried once with a fresh token.
+func WithTokenSource(ts TokenSource) Option {
+	return func(c *Client) {
+		c.tokenSource = ts
+	}
+}
+
+// ClientCredentials is a TokenSource for the OAuth2 client-credentials grant
+type ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	HTTPClient   *http.Client // Defaults to a client with a 10 second timeout
+}
+
+// Token requests a new access token from the token endpoint
+func (cc *ClientCredentials) Token() (*Token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(cc.Scopes) > 0 {
+		form.Set("scope", strings.Join(cc.Scopes, " "))
+	}
+
+	req, err := http.NewRequest(http.MethodPost, cc.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(cc.ClientID), url.QueryEscape(cc.ClientSecret))
+
+	hc := cc.HTTPClient
+	if hc == nil {
+		hc = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("token endpoint returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if result.AccessToken == "" {
+		return nil, fmt.Errorf("token response has no access_token")
+	}
+
+	tok := &Token{AccessToken: result.AccessToken}
+	if result.ExpiresIn > 0 {
+		tok.Expiry = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	}
+	return tok, nil
+}
+
+// tokenCache reuses a token until it nears expiry or is invalidated
+type tokenCache struct {
+	src TokenSource
+	mu  sync.Mutex
+	tok *Token
+}
+
+// token returns a cached token, fetching a new one if needed or forced
+func (tc *tokenCache) token(force bool) (string, error) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	if force || !tc.tok.valid() {
+		tok, err := tc.src.Token()
+		if err != nil {
+			return "", err
+		}
+		tc.tok = tok
+	}
+	return tc.tok.AccessToken, nil
+}
+
+// authTransport sets the Authorization header on every API request and
+// retries once with a fresh token when the server answers 401
+type authTransport struct {
+	base   http.RoundTripper
+	tokens *tokenCache
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tok, err := t.tokens.token(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain token: %w", err)
+	}
+
+	resp, err := t.base.RoundTrip(authorize(req, tok))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil // body cannot be replayed
+	}
+
+	tok, err = t.tokens.token(true)
+	if err != nil {
+		return resp, nil
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	resp.Body.Close()
+
+	retry := authorize(req, tok)
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	return t.base.RoundTrip(retry)
+}
+
+// authorize returns a copy of req carrying tok as its bearer token
+func authorize(req *http.Request, tok string) *http.Request {
+	r := req.Clone(req.Context())
+	r.Header.Set("Authorization", "Bearer "+tok)
+	return r
+}
+
+// installTokenSource wraps the client's HTTP transport with token authentication
+func (c *Client) installTokenSource() {
+	if c.tokenSource == nil {
+		return
+	}
+	base := c.httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	hc := *c.httpClient
+	hc.Transport = &authTransport{base: base, tokens: &tokenCache{src: c.tokenSource}}
+	c.httpClient = &hc
+}
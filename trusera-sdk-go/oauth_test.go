@@ -0,0 +1,134 @@
+package trusera
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientCredentialsToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != "agent" || secret != "s3cret" {
+			t.Errorf("expected basic auth agent/s3cret, got %s/%s", id, secret)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		if got := r.PostForm.Get("grant_type"); got != "client_credentials" {
+			t.Errorf("expected client_credentials grant, got %q", got)
+		}
+		if got := r.PostForm.Get("scope"); got != "events:write fleet" {
+			t.Errorf("expected space-joined scopes, got %q", got)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "tok-1", "expires_in": 3600})
+	}))
+	defer server.Close()
+
+	cc := &ClientCredentials{
+		TokenURL:     server.URL,
+		ClientID:     "agent",
+		ClientSecret: "s3cret",
+		Scopes:       []string{"events:write", "fleet"},
+	}
+	tok, err := cc.Token()
+	if err != nil {
+		t.Fatalf("Token failed: %v", err)
+	}
+	if tok.AccessToken != "tok-1" {
+		t.Errorf("expected tok-1, got %q", tok.AccessToken)
+	}
+	if time.Until(tok.Expiry) < 59*time.Minute {
+		t.Errorf("expected expiry about an hour out, got %v", tok.Expiry)
+	}
+}
+
+func TestClientCredentialsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	if _, err := (&ClientCredentials{TokenURL: server.URL}).Token(); err == nil {
+		t.Error("expected error for rejected token request")
+	}
+}
+
+func TestTokenSourceAuthorizesRequests(t *testing.T) {
+	var fetched int32
+	ts := TokenSourceFunc(func() (*Token, error) {
+		atomic.AddInt32(&fetched, 1)
+		return &Token{AccessToken: "tok", Expiry: time.Now().Add(time.Hour)}, nil
+	})
+
+	var auth atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth.Store(r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient("", WithBaseURL(server.URL), WithTokenSource(ts))
+	defer client.Close()
+
+	for i := 0; i < 3; i++ {
+		client.Track(NewEvent(EventToolCall, "tool"))
+		if err := client.Flush(); err != nil {
+			t.Fatalf("Flush failed: %v", err)
+		}
+	}
+
+	if got := auth.Load(); got != "Bearer tok" {
+		t.Errorf("expected bearer token from source, got %v", got)
+	}
+	if got := atomic.LoadInt32(&fetched); got != 1 {
+		t.Errorf("expected token to be cached, fetched %d times", got)
+	}
+}
+
+func TestTokenSourceRetriesOnUnauthorized(t *testing.T) {
+	var fetched int32
+	ts := TokenSourceFunc(func() (*Token, error) {
+		n := atomic.AddInt32(&fetched, 1)
+		if n == 1 {
+			return &Token{AccessToken: "revoked"}, nil
+		}
+		return &Token{AccessToken: "fresh"}, nil
+	})
+
+	var requests, events int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body struct {
+			Events []Event `json:"events"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("expected replayed body, got %v", err)
+		}
+		atomic.AddInt32(&events, int32(len(body.Events)))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient("", WithBaseURL(server.URL), WithTokenSource(ts))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "tool"))
+	if err := client.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("expected one retry after 401, got %d requests", got)
+	}
+	if got := atomic.LoadInt32(&events); got != 1 {
+		t.Errorf("expected the event to be delivered on retry, got %d", got)
+	}
+}
@@ -0,0 +1,18 @@
+module github.com/Trusera/ai-bom/trusera-sdk-go/openaigo
+
+go 1.25.0
+
+require (
+	github.com/Trusera/ai-bom/trusera-sdk-go v0.0.0
+	github.com/openai/openai-go/v3 v3.66.0
+)
+
+require (
+	github.com/coder/websocket v1.8.15 // indirect
+	github.com/tidwall/gjson v1.19.0 // indirect
+	github.com/tidwall/match v1.1.1 // indirect
+	github.com/tidwall/pretty v1.2.1 // indirect
+	github.com/tidwall/sjson v1.2.5 // indirect
+)
+
+replace github.com/Trusera/ai-bom/trusera-sdk-go => ../
@@ -0,0 +1,380 @@
+// Package openaigo records calls made with the official OpenAI Go SDK
+// (github.com/openai/openai-go) as Trusera EventLLMInvoke events.
+//
+// Use either this middleware or trusera.WrapTransport for a given client,
+// not both, or calls are recorded twice.
+package openaigo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/openai/openai-go/v3/option"
+)
+
+// maxCapture bounds how much of a request or non-streaming response is buffered
+const maxCapture = 1 << 20
+
+// maxArguments bounds how much of each tool call's arguments is recorded
+const maxArguments = 1024
+
+// Option returns a request option that installs Middleware on an OpenAI client:
+//
+//	client := openai.NewClient(openaigo.Option(truseraClient))
+func Option(client *trusera.Client) option.RequestOption {
+	return option.WithMiddleware(Middleware(client))
+}
+
+// Middleware records each OpenAI API call with its model, a SHA-256 hash of
+// the prompt, token usage, tool-call arguments and, for streams, chunk timings.
+// The event is tracked once the response body has been read or closed.
+func Middleware(client *trusera.Client) option.Middleware {
+	return func(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		call := newCall(req)
+
+		resp, err := next(req)
+		if err != nil {
+			client.TrackContext(req.Context(), call.event().
+				WithPayload("latency_ms", time.Since(call.start).Milliseconds()).
+				WithPayload("error", err.Error()))
+			return resp, err
+		}
+
+		call.statusCode = resp.StatusCode
+		call.latency = time.Since(call.start)
+		done := func(e trusera.Event) { client.TrackContext(req.Context(), e) }
+
+		if call.streaming || strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+			resp.Body = newStreamBody(resp.Body, call, done)
+		} else {
+			resp.Body = newResponseBody(resp.Body, call, done)
+		}
+		return resp, nil
+	}
+}
+
+// call holds what is known about a request before its response arrives
+type call struct {
+	model      string
+	method     string
+	path       string
+	promptHash string
+	streaming  bool
+	start      time.Time
+	statusCode int
+	latency    time.Duration
+}
+
+// toolCall is a function call requested by the model
+type toolCall struct {
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// usage is the token usage reported by a response
+type usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+	InputTokens      int `json:"input_tokens"`
+	OutputTokens     int `json:"output_tokens"`
+}
+
+// newCall reads the model, stream flag and prompt from the request body and restores it
+func newCall(req *http.Request) *call {
+	c := &call{method: req.Method, path: req.URL.Path, start: time.Now()}
+	if req.Body == nil || req.Body == http.NoBody {
+		return c
+	}
+
+	data, err := io.ReadAll(io.LimitReader(req.Body, maxCapture+1))
+	req.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), req.Body))
+	if err != nil || len(data) > maxCapture {
+		return c
+	}
+
+	var body struct {
+		Model    string          `json:"model"`
+		Stream   bool            `json:"stream"`
+		Messages json.RawMessage `json:"messages"`
+		Input    json.RawMessage `json:"input"`
+		Prompt   json.RawMessage `json:"prompt"`
+	}
+	if json.Unmarshal(data, &body) != nil {
+		return c
+	}
+	c.model, c.streaming = body.Model, body.Stream
+	for _, prompt := range []json.RawMessage{body.Messages, body.Input, body.Prompt} {
+		if len(prompt) > 0 {
+			sum := sha256.Sum256(prompt)
+			c.promptHash = hex.EncodeToString(sum[:])
+			break
+		}
+	}
+	return c
+}
+
+// event builds the base EventLLMInvoke for a call
+func (c *call) event() trusera.Event {
+	name := trusera.ProviderOpenAI
+	if c.model != "" {
+		name += " " + c.model
+	}
+	e := trusera.NewEvent(trusera.EventLLMInvoke, name).
+		WithPayload("provider", trusera.ProviderOpenAI).
+		WithPayload("model", c.model).
+		WithPayload("method", c.method).
+		WithPayload("path", c.path)
+	if c.promptHash != "" {
+		e = e.WithPayload("prompt_hash", c.promptHash)
+	}
+	if c.statusCode != 0 {
+		e = e.WithPayload("status_code", c.statusCode).
+			WithPayload("latency_ms", c.latency.Milliseconds())
+	}
+	return e
+}
+
+// annotate adds response details to an event
+func annotate(e trusera.Event, model string, u *usage, finishReason string, calls []toolCall) trusera.Event {
+	if model != "" {
+		e = e.WithPayload("model", model)
+	}
+	if u != nil {
+		in := u.PromptTokens + u.InputTokens
+		out := u.CompletionTokens + u.OutputTokens
+		total := u.TotalTokens
+		if total == 0 {
+			total = in + out
+		}
+		e = e.WithPayload("prompt_tokens", in).
+			WithPayload("completion_tokens", out).
+			WithPayload("total_tokens", total)
+	}
+	if finishReason != "" {
+		e = e.WithPayload("finish_reason", finishReason)
+	}
+	if len(calls) > 0 {
+		for i := range calls {
+			if len(calls[i].Arguments) > maxArguments {
+				calls[i].Arguments = calls[i].Arguments[:maxArguments] + "..."
+			}
+		}
+		e = e.WithPayload("tool_calls", calls)
+	}
+	return e
+}
+
+// responseBody buffers a bounded copy of a JSON response and reports it on EOF or Close
+type responseBody struct {
+	io.ReadCloser
+	call *call
+	done func(trusera.Event)
+	buf  bytes.Buffer
+	once sync.Once
+}
+
+func newResponseBody(rc io.ReadCloser, c *call, done func(trusera.Event)) *responseBody {
+	return &responseBody{ReadCloser: rc, call: c, done: done}
+}
+
+func (b *responseBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && b.buf.Len() < maxCapture {
+		b.buf.Write(p[:min(n, maxCapture-b.buf.Len())])
+	}
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *responseBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *responseBody) finish() {
+	b.once.Do(func() { b.done(parseResponse(b.call.event(), b.buf.Bytes())) })
+}
+
+// parseResponse annotates an event from a Chat Completions or Responses API body
+func parseResponse(e trusera.Event, body []byte) trusera.Event {
+	var resp struct {
+		Model   string `json:"model"`
+		Usage   *usage `json:"usage"`
+		Choices []struct {
+			FinishReason string `json:"finish_reason"`
+			Message      struct {
+				ToolCalls []struct {
+					ID       string   `json:"id"`
+					Function toolCall `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"message"`
+		} `json:"choices"`
+		Status string `json:"status"`
+		Output []struct {
+			Type      string `json:"type"`
+			CallID    string `json:"call_id"`
+			Name      string `json:"name"`
+			Arguments string `json:"arguments"`
+		} `json:"output"`
+	}
+	if json.Unmarshal(body, &resp) != nil {
+		return e
+	}
+
+	var calls []toolCall
+	finishReason := resp.Status
+	if len(resp.Choices) > 0 {
+		finishReason = resp.Choices[0].FinishReason
+		for _, tc := range resp.Choices[0].Message.ToolCalls {
+			calls = append(calls, toolCall{ID: tc.ID, Name: tc.Function.Name, Arguments: tc.Function.Arguments})
+		}
+	}
+	for _, item := range resp.Output {
+		if item.Type == "function_call" {
+			calls = append(calls, toolCall{ID: item.CallID, Name: item.Name, Arguments: item.Arguments})
+		}
+	}
+	return annotate(e, resp.Model, resp.Usage, finishReason, calls)
+}
+
+// streamBody parses server-sent events as they are read and reports chunk
+// timings, accumulated tool calls and usage on EOF or Close
+type streamBody struct {
+	io.ReadCloser
+	call *call
+	done func(trusera.Event)
+
+	pending      []byte
+	chunks       int
+	firstChunk   time.Duration
+	lastChunk    time.Time
+	maxGap       time.Duration
+	model        string
+	finishReason string
+	usage        *usage
+	calls        []toolCall
+	once         sync.Once
+}
+
+func newStreamBody(rc io.ReadCloser, c *call, done func(trusera.Event)) *streamBody {
+	return &streamBody{ReadCloser: rc, call: c, done: done}
+}
+
+func (b *streamBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.feed(p[:n])
+	}
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *streamBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+// feed splits data into lines and handles each complete "data:" line
+func (b *streamBody) feed(data []byte) {
+	b.pending = append(b.pending, data...)
+	for {
+		i := bytes.IndexByte(b.pending, '\n')
+		if i < 0 {
+			break
+		}
+		line := bytes.TrimRight(b.pending[:i], "\r")
+		if payload, ok := bytes.CutPrefix(line, []byte("data:")); ok {
+			b.chunk(bytes.TrimSpace(payload))
+		}
+		b.pending = b.pending[i+1:]
+	}
+	if len(b.pending) > maxCapture {
+		b.pending = nil
+	}
+}
+
+// chunk records one streamed Chat Completions chunk
+func (b *streamBody) chunk(payload []byte) {
+	if len(payload) == 0 || string(payload) == "[DONE]" {
+		return
+	}
+
+	now := time.Now()
+	if b.chunks == 0 {
+		b.firstChunk = now.Sub(b.call.start)
+	} else if gap := now.Sub(b.lastChunk); gap > b.maxGap {
+		b.maxGap = gap
+	}
+	b.chunks++
+	b.lastChunk = now
+
+	var c struct {
+		Model   string `json:"model"`
+		Usage   *usage `json:"usage"`
+		Choices []struct {
+			FinishReason string `json:"finish_reason"`
+			Delta        struct {
+				ToolCalls []struct {
+					Index    int      `json:"index"`
+					ID       string   `json:"id"`
+					Function toolCall `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"delta"`
+		} `json:"choices"`
+	}
+	if json.Unmarshal(payload, &c) != nil {
+		return
+	}
+	if c.Model != "" {
+		b.model = c.Model
+	}
+	if c.Usage != nil {
+		b.usage = c.Usage
+	}
+	for _, choice := range c.Choices {
+		if choice.FinishReason != "" {
+			b.finishReason = choice.FinishReason
+		}
+		for _, tc := range choice.Delta.ToolCalls {
+			for len(b.calls) <= tc.Index {
+				b.calls = append(b.calls, toolCall{})
+			}
+			call := &b.calls[tc.Index]
+			if tc.ID != "" {
+				call.ID = tc.ID
+			}
+			call.Name += tc.Function.Name
+			if len(call.Arguments) <= maxArguments {
+				call.Arguments += tc.Function.Arguments
+			}
+		}
+	}
+}
+
+func (b *streamBody) finish() {
+	b.once.Do(func() {
+		e := b.call.event().
+			WithPayload("streaming", true).
+			WithPayload("chunk_count", b.chunks).
+			WithPayload("stream_ms", time.Since(b.call.start).Milliseconds())
+		if b.chunks > 0 {
+			e = e.WithPayload("first_chunk_ms", b.firstChunk.Milliseconds()).
+				WithPayload("max_chunk_gap_ms", b.maxGap.Milliseconds())
+		}
+		b.done(annotate(e, b.model, b.usage, b.finishReason, b.calls))
+	})
+}
@@ -0,0 +1,180 @@
+package openaigo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+)
+
+type recordingTransport struct {
+	mu     sync.Mutex
+	events []trusera.Event
+}
+
+func (r *recordingTransport) Send(_ context.Context, b trusera.Batch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, b.Events...)
+	return nil
+}
+
+func newTestClients(t *testing.T, handler http.HandlerFunc) (openai.Client, *trusera.Client, *recordingTransport) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	rt := &recordingTransport{}
+	tc := trusera.NewClient("test-key", trusera.WithTransport(rt))
+	t.Cleanup(func() { tc.Close() })
+
+	oc := openai.NewClient(
+		option.WithBaseURL(server.URL),
+		option.WithAPIKey("sk-test"),
+		option.WithMaxRetries(0),
+		Option(tc),
+	)
+	return oc, tc, rt
+}
+
+func TestMiddlewareChatCompletion(t *testing.T) {
+	oc, tc, rt := newTestClients(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{
+			"id": "chatcmpl-1", "object": "chat.completion", "model": "gpt-4o-2024-08-06",
+			"choices": [{"index": 0, "finish_reason": "tool_calls", "message": {"role": "assistant",
+				"tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}}]}}],
+			"usage": {"prompt_tokens": 20, "completion_tokens": 8, "total_tokens": 28}
+		}`)
+	})
+
+	_, err := oc.Chat.Completions.New(context.Background(), openai.ChatCompletionNewParams{
+		Model:    openai.ChatModelGPT4o,
+		Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("weather in Paris?")},
+	})
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	tc.Flush()
+
+	if len(rt.events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(rt.events))
+	}
+	e := rt.events[0]
+	if e.Type != trusera.EventLLMInvoke {
+		t.Errorf("expected llm_invoke, got %s", e.Type)
+	}
+	if e.Payload["model"] != "gpt-4o-2024-08-06" {
+		t.Errorf("expected response model, got %v", e.Payload["model"])
+	}
+	if e.Payload["completion_tokens"] != 8 || e.Payload["prompt_tokens"] != 20 {
+		t.Errorf("expected 20/8 tokens, got %v/%v", e.Payload["prompt_tokens"], e.Payload["completion_tokens"])
+	}
+	if e.Payload["finish_reason"] != "tool_calls" {
+		t.Errorf("expected finish_reason tool_calls, got %v", e.Payload["finish_reason"])
+	}
+	if h, _ := e.Payload["prompt_hash"].(string); len(h) != 64 {
+		t.Errorf("expected sha256 prompt hash, got %v", e.Payload["prompt_hash"])
+	}
+	calls, _ := e.Payload["tool_calls"].([]toolCall)
+	if len(calls) != 1 || calls[0].Name != "get_weather" || calls[0].Arguments != `{"city":"Paris"}` {
+		t.Errorf("expected get_weather tool call, got %v", e.Payload["tool_calls"])
+	}
+}
+
+func TestMiddlewareStreaming(t *testing.T) {
+	oc, tc, rt := newTestClients(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		chunks := []string{
+			`{"id":"c","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"search","arguments":""}}]}}]}`,
+			`{"id":"c","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"q\":"}}]}}]}`,
+			`{"id":"c","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"go\"}"}}]},"finish_reason":"tool_calls"}]}`,
+			`{"id":"c","object":"chat.completion.chunk","model":"gpt-4o","choices":[],"usage":{"prompt_tokens":5,"completion_tokens":7,"total_tokens":12}}`,
+		}
+		for _, c := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", c)
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	stream := oc.Chat.Completions.NewStreaming(context.Background(), openai.ChatCompletionNewParams{
+		Model:    openai.ChatModelGPT4o,
+		Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("search go")},
+	})
+	for stream.Next() {
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	stream.Close()
+	tc.Flush()
+
+	if len(rt.events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(rt.events))
+	}
+	e := rt.events[0]
+	if e.Payload["streaming"] != true {
+		t.Error("expected streaming event")
+	}
+	if e.Payload["chunk_count"] != 4 {
+		t.Errorf("expected 4 chunks, got %v", e.Payload["chunk_count"])
+	}
+	if _, ok := e.Payload["first_chunk_ms"]; !ok {
+		t.Error("expected first_chunk_ms")
+	}
+	if e.Payload["total_tokens"] != 12 {
+		t.Errorf("expected 12 total tokens, got %v", e.Payload["total_tokens"])
+	}
+	calls, _ := e.Payload["tool_calls"].([]toolCall)
+	if len(calls) != 1 || calls[0].Name != "search" || calls[0].Arguments != `{"q":"go"}` {
+		t.Errorf("expected accumulated search tool call, got %v", e.Payload["tool_calls"])
+	}
+}
+
+func TestMiddlewareError(t *testing.T) {
+	oc, tc, rt := newTestClients(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"error":{"message":"rate limited","type":"rate_limit"}}`)
+	})
+
+	_, err := oc.Chat.Completions.New(context.Background(), openai.ChatCompletionNewParams{
+		Model:    openai.ChatModelGPT4o,
+		Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("hi")},
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	tc.Flush()
+
+	if len(rt.events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(rt.events))
+	}
+	if rt.events[0].Payload["status_code"] != http.StatusTooManyRequests {
+		t.Errorf("expected status 429, got %v", rt.events[0].Payload["status_code"])
+	}
+}
+
+func TestPromptHashIsStable(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "https://api.openai.com/v1/chat/completions",
+		strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`))
+	c := newCall(req)
+
+	sum := sha256.Sum256([]byte(`[{"role":"user","content":"hi"}]`))
+	if c.promptHash != hex.EncodeToString(sum[:]) {
+		t.Errorf("expected hash of messages, got %s", c.promptHash)
+	}
+	if c.model != "gpt-4o" {
+		t.Errorf("expected model gpt-4o, got %s", c.model)
+	}
+}
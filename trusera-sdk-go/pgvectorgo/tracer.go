@@ -0,0 +1,172 @@
+// Package pgvectorgo records pgvector (github.com/pgvector/pgvector-go)
+// writes and similarity searches made through pgx as Trusera vector_store
+// events, with the table, top-k, row count and latency of each statement:
+//
+//	config, _ := pgxpool.ParseConfig(databaseURL)
+//	config.ConnConfig.Tracer = pgvectorgo.NewTracer(truseraClient)
+//
+// A statement is recorded when it binds a pgvector argument or orders by a
+// distance operator such as <->. Plain SQL passes through unrecorded.
+package pgvectorgo
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/jackc/pgx/v5"
+	"github.com/pgvector/pgvector-go"
+)
+
+// Store is the store name events are recorded under
+const Store = "pgvector"
+
+var (
+	// distanceOperator matches pgvector's L2, cosine, inner product, L1,
+	// Hamming and Jaccard distance operators
+	distanceOperator = regexp.MustCompile(`<->|<=>|<#>|<\+>|<~>|<%>`)
+	limitClause      = regexp.MustCompile(`(?i)\blimit\s+(\d+|\$\d+)`)
+	tableClause      = regexp.MustCompile(`(?i)\b(?:from|into|update)\s+((?:"[^"]+"|[\w]+)(?:\.(?:"[^"]+"|[\w]+))?)`)
+)
+
+// Tracer is a pgx.QueryTracer that records pgvector statements. It replaces
+// any tracer already set on the connection config.
+type Tracer struct {
+	client *trusera.Client
+}
+
+var _ pgx.QueryTracer = (*Tracer)(nil)
+
+// NewTracer returns a Tracer recording to client
+func NewTracer(client *trusera.Client) *Tracer {
+	return &Tracer{client: client}
+}
+
+type traceKey struct{}
+
+// trace is a statement in flight
+type trace struct {
+	ctx   context.Context
+	event trusera.VectorStoreEvent
+	start time.Time
+}
+
+// TraceQueryStart classifies the statement and starts timing it if it is a
+// vector operation
+func (t *Tracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	v, ok := describe(data.SQL, data.Args)
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, traceKey{}, &trace{ctx: ctx, event: v, start: time.Now()})
+}
+
+// TraceQueryEnd records the statement with the number of rows it returned or
+// wrote
+func (t *Tracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	tr, ok := ctx.Value(traceKey{}).(*trace)
+	if !ok {
+		return
+	}
+	v := tr.event
+	v.Latency = time.Since(tr.start)
+	v.Err = data.Err
+	if data.Err == nil {
+		rows := int(data.CommandTag.RowsAffected())
+		if v.Operation == trusera.VectorQuery {
+			v.Results = rows
+		} else {
+			v.Vectors = rows
+		}
+	}
+	t.client.TrackVectorStore(tr.ctx, v)
+}
+
+// describe builds the event for a statement, reporting false for statements
+// that neither bind a vector nor compute a distance
+func describe(sql string, args []any) (trusera.VectorStoreEvent, bool) {
+	v := trusera.VectorStoreEvent{Store: Store}
+	vectors := 0
+	for _, arg := range args {
+		if dims := dimensions(arg); dims > 0 {
+			vectors++
+			v.Dimensions = dims
+		}
+	}
+
+	verb := strings.ToLower(firstWord(sql))
+	switch {
+	case distanceOperator.MatchString(sql) && (verb == "select" || verb == "with"):
+		v.Operation = trusera.VectorQuery
+		v.TopK = limit(sql, args)
+	case vectors > 0 && (verb == "insert" || verb == "update"):
+		v.Operation = trusera.VectorUpsert
+	default:
+		return v, false
+	}
+	if m := tableClause.FindStringSubmatch(sql); m != nil {
+		v.Collection = strings.ReplaceAll(m[1], `"`, "")
+	}
+	return v, true
+}
+
+// dimensions returns the length of a pgvector argument, or 0 for other values
+func dimensions(arg any) int {
+	switch a := arg.(type) {
+	case pgvector.Vector:
+		return len(a.Slice())
+	case *pgvector.Vector:
+		if a != nil {
+			return len(a.Slice())
+		}
+	case pgvector.HalfVector:
+		return len(a.Slice())
+	case *pgvector.HalfVector:
+		if a != nil {
+			return len(a.Slice())
+		}
+	case pgvector.SparseVector:
+		return int(a.Dimensions())
+	case *pgvector.SparseVector:
+		if a != nil {
+			return int(a.Dimensions())
+		}
+	}
+	return 0
+}
+
+// limit returns the LIMIT of a query, resolving a $n placeholder from args
+func limit(sql string, args []any) int {
+	m := limitClause.FindStringSubmatch(sql)
+	if m == nil {
+		return 0
+	}
+	if !strings.HasPrefix(m[1], "$") {
+		n, _ := strconv.Atoi(m[1])
+		return n
+	}
+	i, _ := strconv.Atoi(m[1][1:])
+	if i < 1 || i > len(args) {
+		return 0
+	}
+	switch n := args[i-1].(type) {
+	case int:
+		return n
+	case int32:
+		return int(n)
+	case int64:
+		return int(n)
+	}
+	return 0
+}
+
+func firstWord(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
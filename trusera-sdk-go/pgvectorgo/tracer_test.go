@@ -0,0 +1,64 @@
+package pgvectorgo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/truseratest"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pgvector/pgvector-go"
+)
+
+// run passes a statement through the tracer as pgx would
+func run(ctx context.Context, tracer *Tracer, sql string, tag string, err error, args ...any) {
+	ctx = tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: sql, Args: args})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag(tag), Err: err})
+}
+
+func TestTracerRecordsVectorStatements(t *testing.T) {
+	rec := truseratest.NewRecordingClient()
+	defer rec.Close()
+	tracer := NewTracer(rec.Client)
+
+	ctx, span := rec.StartSpan(context.Background(), "answer")
+	embedding := pgvector.NewVector([]float32{0.1, 0.2, 0.3})
+	run(ctx, tracer, "INSERT INTO items (content, embedding) VALUES ($1, $2), ($3, $4)", "INSERT 0 2", nil,
+		"a", embedding, "b", pgvector.NewVector([]float32{0.4, 0.5, 0.6}))
+	run(ctx, tracer, `SELECT id, content FROM "rag"."items" ORDER BY embedding <=> $1 LIMIT $2`, "SELECT 4", nil, embedding, 5)
+	run(ctx, tracer, "SELECT id FROM items ORDER BY embedding <-> $1 LIMIT 3", "", errors.New("relation does not exist"), &embedding)
+	run(ctx, tracer, "SELECT count(*) FROM items", "SELECT 1", nil)
+	span.End()
+
+	events := rec.Events(truseratest.OfType(trusera.EventVectorStore))
+	if len(events) != 3 {
+		t.Fatalf("expected 3 vector store events, got %d", len(events))
+	}
+	upsert, query, failed := events[0].Payload, events[1].Payload, events[2].Payload
+	if upsert["operation"] != "upsert" || upsert["collection"] != "items" || upsert["vectors"] != 2 || upsert["dimensions"] != 3 {
+		t.Errorf("unexpected upsert payload: %v", upsert)
+	}
+	if query["operation"] != "query" || query["collection"] != "rag.items" || query["top_k"] != 5 || query["results"] != 4 {
+		t.Errorf("unexpected query payload: %v", query)
+	}
+	if failed["top_k"] != 3 || failed["error"] != "relation does not exist" {
+		t.Errorf("unexpected failed query payload: %v", failed)
+	}
+	if events[0].Metadata["parent_span_id"] != span.SpanID() {
+		t.Errorf("expected statements linked to the span, got %v", events[0].Metadata)
+	}
+}
+
+func TestDescribeSkipsPlainSQL(t *testing.T) {
+	for _, sql := range []string{
+		"INSERT INTO users (name) VALUES ($1)",
+		"UPDATE users SET name = $1",
+		"CREATE INDEX ON items USING hnsw (embedding vector_l2_ops)",
+	} {
+		if _, ok := describe(sql, []any{"x"}); ok {
+			t.Errorf("expected %q to be skipped", sql)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Package pineconego records calls made with the Pinecone Go SDK
+// (github.com/pinecone-io/go-pinecone) as Trusera vector_store events, with
+// the index, namespace, top-k, match count and latency of each upsert,
+// query, fetch and delete:
+//
+//	conn, _ := pc.Index(pinecone.NewIndexConnParams{Host: host, Namespace: "tenant-a"})
+//	index := pineconego.Wrap(conn, "docs", truseraClient)
+//	res, err := index.QueryByVectorValues(ctx, &pinecone.QueryByVectorValuesRequest{Vector: v, TopK: 5})
+package pineconego
+
+import (
+	"context"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/pinecone-io/go-pinecone/v3/pinecone"
+)
+
+// Store is the store name events are recorded under
+const Store = "pinecone"
+
+// indexConn is the part of *pinecone.IndexConnection that Index records
+type indexConn interface {
+	UpsertVectors(ctx context.Context, in []*pinecone.Vector) (uint32, error)
+	QueryByVectorValues(ctx context.Context, in *pinecone.QueryByVectorValuesRequest) (*pinecone.QueryVectorsResponse, error)
+	QueryByVectorId(ctx context.Context, in *pinecone.QueryByVectorIdRequest) (*pinecone.QueryVectorsResponse, error)
+	FetchVectors(ctx context.Context, ids []string) (*pinecone.FetchVectorsResponse, error)
+	DeleteVectorsById(ctx context.Context, ids []string) error
+	DeleteVectorsByFilter(ctx context.Context, metadataFilter *pinecone.MetadataFilter) error
+	DeleteAllVectorsInNamespace(ctx context.Context) error
+}
+
+// Index records the data operations of an index connection. Calls not
+// wrapped here can be made on the connection directly.
+type Index struct {
+	conn      indexConn
+	client    *trusera.Client
+	index     string
+	namespace string
+}
+
+// Wrap returns an Index recording conn's operations under the index name,
+// which the connection itself does not know
+func Wrap(conn *pinecone.IndexConnection, index string, client *trusera.Client) *Index {
+	return &Index{conn: conn, client: client, index: index, namespace: conn.Namespace}
+}
+
+// UpsertVectors upserts vectors and records how many were written
+func (i *Index) UpsertVectors(ctx context.Context, in []*pinecone.Vector) (uint32, error) {
+	v := i.event(trusera.VectorUpsert)
+	if len(in) > 0 && in[0].Values != nil {
+		v.Dimensions = len(*in[0].Values)
+	}
+	start := time.Now()
+	n, err := i.conn.UpsertVectors(ctx, in)
+	v.Vectors = int(n)
+	i.track(ctx, v, start, err)
+	return n, err
+}
+
+// QueryByVectorValues queries by a vector and records the matches returned
+func (i *Index) QueryByVectorValues(ctx context.Context, in *pinecone.QueryByVectorValuesRequest) (*pinecone.QueryVectorsResponse, error) {
+	v := i.event(trusera.VectorQuery)
+	v.TopK = int(in.TopK)
+	v.Dimensions = len(in.Vector)
+	start := time.Now()
+	res, err := i.conn.QueryByVectorValues(ctx, in)
+	if res != nil {
+		v.Results = len(res.Matches)
+	}
+	i.track(ctx, v, start, err)
+	return res, err
+}
+
+// QueryByVectorId queries by a stored vector's ID and records the matches
+// returned
+func (i *Index) QueryByVectorId(ctx context.Context, in *pinecone.QueryByVectorIdRequest) (*pinecone.QueryVectorsResponse, error) {
+	v := i.event(trusera.VectorQuery)
+	v.TopK = int(in.TopK)
+	start := time.Now()
+	res, err := i.conn.QueryByVectorId(ctx, in)
+	if res != nil {
+		v.Results = len(res.Matches)
+	}
+	i.track(ctx, v, start, err)
+	return res, err
+}
+
+// FetchVectors fetches vectors by ID and records how many were found
+func (i *Index) FetchVectors(ctx context.Context, ids []string) (*pinecone.FetchVectorsResponse, error) {
+	v := i.event(trusera.VectorFetch)
+	start := time.Now()
+	res, err := i.conn.FetchVectors(ctx, ids)
+	if res != nil {
+		v.Vectors = len(res.Vectors)
+	}
+	i.track(ctx, v, start, err)
+	return res, err
+}
+
+// DeleteVectorsById deletes vectors by ID
+func (i *Index) DeleteVectorsById(ctx context.Context, ids []string) error {
+	v := i.event(trusera.VectorDelete)
+	v.Vectors = len(ids)
+	start := time.Now()
+	err := i.conn.DeleteVectorsById(ctx, ids)
+	i.track(ctx, v, start, err)
+	return err
+}
+
+// DeleteVectorsByFilter deletes the vectors matching a metadata filter
+func (i *Index) DeleteVectorsByFilter(ctx context.Context, metadataFilter *pinecone.MetadataFilter) error {
+	v := i.event(trusera.VectorDelete)
+	start := time.Now()
+	err := i.conn.DeleteVectorsByFilter(ctx, metadataFilter)
+	i.track(ctx, v, start, err)
+	return err
+}
+
+// DeleteAllVectorsInNamespace deletes every vector in the connection's
+// namespace
+func (i *Index) DeleteAllVectorsInNamespace(ctx context.Context) error {
+	v := i.event(trusera.VectorDelete)
+	start := time.Now()
+	err := i.conn.DeleteAllVectorsInNamespace(ctx)
+	i.track(ctx, v, start, err)
+	return err
+}
+
+// event returns the base event for an operation on the index
+func (i *Index) event(op trusera.VectorOperation) trusera.VectorStoreEvent {
+	return trusera.VectorStoreEvent{Store: Store, Operation: op, Collection: i.index, Namespace: i.namespace}
+}
+
+func (i *Index) track(ctx context.Context, v trusera.VectorStoreEvent, start time.Time, err error) {
+	v.Latency = time.Since(start)
+	v.Err = err
+	i.client.TrackVectorStore(ctx, v)
+}
@@ -0,0 +1,70 @@
+package pineconego
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/truseratest"
+	"github.com/pinecone-io/go-pinecone/v3/pinecone"
+)
+
+var _ indexConn = (*pinecone.IndexConnection)(nil)
+
+// fakeConn answers like an index holding two vectors that match every query
+type fakeConn struct {
+	indexConn
+}
+
+func (fakeConn) UpsertVectors(_ context.Context, in []*pinecone.Vector) (uint32, error) {
+	return uint32(len(in)), nil
+}
+
+func (fakeConn) QueryByVectorValues(context.Context, *pinecone.QueryByVectorValuesRequest) (*pinecone.QueryVectorsResponse, error) {
+	return &pinecone.QueryVectorsResponse{Matches: []*pinecone.ScoredVector{
+		{Vector: &pinecone.Vector{Id: "a"}, Score: 0.9},
+		{Vector: &pinecone.Vector{Id: "b"}, Score: 0.8},
+	}}, nil
+}
+
+func (fakeConn) DeleteVectorsById(context.Context, []string) error {
+	return errors.New("namespace not found")
+}
+
+func TestIndexRecordsOperations(t *testing.T) {
+	rec := truseratest.NewRecordingClient()
+	defer rec.Close()
+	index := &Index{conn: fakeConn{}, client: rec.Client, index: "docs", namespace: "tenant-a"}
+
+	ctx, span := rec.StartSpan(context.Background(), "answer")
+	values := []float32{0.1, 0.2, 0.3, 0.4}
+	if _, err := index.UpsertVectors(ctx, []*pinecone.Vector{{Id: "a", Values: &values}, {Id: "b", Values: &values}}); err != nil {
+		t.Fatalf("UpsertVectors failed: %v", err)
+	}
+	if _, err := index.QueryByVectorValues(ctx, &pinecone.QueryByVectorValuesRequest{Vector: values, TopK: 3}); err != nil {
+		t.Fatalf("QueryByVectorValues failed: %v", err)
+	}
+	if err := index.DeleteVectorsById(ctx, []string{"a"}); err == nil {
+		t.Fatal("expected DeleteVectorsById to fail")
+	}
+	span.End()
+
+	events := rec.Events(truseratest.OfType(trusera.EventVectorStore))
+	if len(events) != 3 {
+		t.Fatalf("expected 3 vector store events, got %d", len(events))
+	}
+	upsert, query, del := events[0].Payload, events[1].Payload, events[2].Payload
+	if upsert["vectors"] != 2 || upsert["dimensions"] != 4 || upsert["namespace"] != "tenant-a" {
+		t.Errorf("unexpected upsert payload: %v", upsert)
+	}
+	if query["operation"] != "query" || query["collection"] != "docs" || query["top_k"] != 3 || query["results"] != 2 {
+		t.Errorf("unexpected query payload: %v", query)
+	}
+	if del["operation"] != "delete" || del["error"] != "namespace not found" {
+		t.Errorf("unexpected delete payload: %v", del)
+	}
+	if events[1].Name != "pinecone query docs" || events[1].Metadata["parent_span_id"] != span.SpanID() {
+		t.Errorf("expected %s linked to the span, got %v", events[1].Name, events[1].Metadata)
+	}
+}
@@ -0,0 +1,277 @@
+package trusera
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// EventPolicyAction is what happens to an event matched by an EventPolicy
+type EventPolicyAction string
+
+const (
+	// EventPolicyAllow sends the event without evaluating later policies
+	EventPolicyAllow EventPolicyAction = "allow"
+	// EventPolicyRedact replaces the policy's fields with a placeholder and
+	// continues with later policies
+	EventPolicyRedact EventPolicyAction = "redact"
+	// EventPolicyBlock discards the event before it is queued
+	EventPolicyBlock EventPolicyAction = "block"
+)
+
+// EventPolicy decides, before transmission, whether an event is sent as is,
+// redacted or blocked. Expression is a CEL expression over the variable
+// event, a map with the keys id, type, name, payload, metadata and
+// timestamp, for example:
+//
+//	event.type == "llm_invoke" && event.payload.model.startsWith("gpt-")
+//
+// Expressions are compiled by the PolicyCompiler registered with
+// RegisterPolicyCompiler. The celgo module registers one built on cel-go
+// when imported; without it, clients with event policies refuse to start.
+//
+// Policies run in order after event processors. An expression that fails to
+// evaluate, such as one selecting a missing key or comparing a string with a
+// number, counts as a match for redact and block policies and as no match
+// for allow policies, so errors fail closed.
+type EventPolicy struct {
+	Name       string            `json:"name"`
+	Expression string            `json:"expression"`
+	Action     EventPolicyAction `json:"action"`
+	// Fields lists the dotted paths redacted by EventPolicyRedact, such as
+	// "payload.prompt" or "metadata.user_id". Empty redacts every payload
+	// field.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// PolicyProgram is a compiled policy expression
+type PolicyProgram interface {
+	// Match evaluates the expression with event bound to the event
+	// variable, reporting an error if it fails or is not a bool
+	Match(event map[string]any) (bool, error)
+}
+
+// PolicyCompiler compiles the expression of an EventPolicy
+type PolicyCompiler func(expression string) (PolicyProgram, error)
+
+var (
+	policyCompilerMu sync.RWMutex
+	policyCompiler   PolicyCompiler
+)
+
+// RegisterPolicyCompiler sets the compiler of event policy expressions. The
+// celgo module calls it when imported:
+//
+//	import _ "github.com/Trusera/ai-bom/trusera-sdk-go/celgo"
+func RegisterPolicyCompiler(c PolicyCompiler) {
+	policyCompilerMu.Lock()
+	policyCompiler = c
+	policyCompilerMu.Unlock()
+}
+
+func lookupPolicyCompiler() PolicyCompiler {
+	policyCompilerMu.RLock()
+	defer policyCompilerMu.RUnlock()
+	return policyCompiler
+}
+
+// compiledPolicy is an EventPolicy with its expression compiled
+type compiledPolicy struct {
+	EventPolicy
+	program PolicyProgram
+}
+
+// WithEventPolicies adds policies evaluated on every tracked event. The
+// client refuses to start if one is invalid.
+func WithEventPolicies(policies ...EventPolicy) Option {
+	return func(c *Client) {
+		c.eventPolicies = append(c.eventPolicies, policies...)
+	}
+}
+
+// WithEventPolicyFile loads policies from a JSON file holding an array of
+// EventPolicy objects, evaluated after those given to WithEventPolicies.
+// The TRUSERA_EVENT_POLICY_FILE environment variable does the same. The
+// client refuses to start if the file cannot be read or holds an invalid
+// policy.
+func WithEventPolicyFile(path string) Option {
+	return func(c *Client) {
+		c.eventPolicyFile = path
+	}
+}
+
+// LoadEventPolicies reads and validates a JSON file of event policies
+func LoadEventPolicies(path string) ([]EventPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event policy file: %w", err)
+	}
+	var policies []EventPolicy
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return nil, fmt.Errorf("failed to parse event policy file: %w", err)
+	}
+	if _, err := compilePolicies(policies); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// compilePolicies validates policies and parses their expressions
+func compilePolicies(policies []EventPolicy) ([]compiledPolicy, error) {
+	out := make([]compiledPolicy, 0, len(policies))
+	for i, p := range policies {
+		name := p.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		switch p.Action {
+		case EventPolicyAllow, EventPolicyRedact, EventPolicyBlock:
+		default:
+			return nil, fmt.Errorf("event policy %s: unknown action %q", name, p.Action)
+		}
+		for _, f := range p.Fields {
+			if !strings.HasPrefix(f, "payload.") && !strings.HasPrefix(f, "metadata.") {
+				return nil, fmt.Errorf("event policy %s: field %q must start with payload. or metadata.", name, f)
+			}
+		}
+		compile := lookupPolicyCompiler()
+		if compile == nil {
+			return nil, fmt.Errorf("event policy %s: no policy compiler registered; import github.com/Trusera/ai-bom/trusera-sdk-go/celgo", name)
+		}
+		program, err := compile(p.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("event policy %s: invalid expression: %w", name, err)
+		}
+		p.Name = name
+		out = append(out, compiledPolicy{EventPolicy: p, program: program})
+	}
+	return out, nil
+}
+
+// loadEventPolicies compiles the policies configured by option, file and
+// environment
+func (c *Client) loadEventPolicies() error {
+	path := c.eventPolicyFile
+	if path == "" {
+		path = os.Getenv("TRUSERA_EVENT_POLICY_FILE")
+	}
+	policies := c.eventPolicies
+	if path != "" {
+		fromFile, err := LoadEventPolicies(path)
+		if err != nil {
+			return err
+		}
+		policies = append(append([]EventPolicy(nil), policies...), fromFile...)
+	}
+	compiled, err := compilePolicies(policies)
+	if err != nil {
+		return err
+	}
+	c.policies = compiled
+	return nil
+}
+
+// applyPolicies runs the local policies, then those pushed by remote config,
+// and returns false if the event is blocked
+func (c *Client) applyPolicies(e Event) (Event, bool) {
+	policies := c.policies
+	if rs := c.remote.Load(); rs != nil && len(rs.policies) > 0 {
+		policies = append(policies[:len(policies):len(policies)], rs.policies...)
+	}
+	if len(policies) == 0 {
+		return e, true
+	}
+
+	view := policyView(e)
+	for _, p := range policies {
+		matched, err := p.program.Match(view)
+		if err != nil {
+			c.logf(LogDebug, "event policy %s failed on %s event: %v", p.Name, e.Type, err)
+			matched = p.Action != EventPolicyAllow
+		}
+		if !matched {
+			continue
+		}
+		switch p.Action {
+		case EventPolicyAllow:
+			return e, true
+		case EventPolicyBlock:
+			c.mu.Lock()
+			c.stats.filtered++
+			c.mu.Unlock()
+			return e, false
+		case EventPolicyRedact:
+			e = redactFields(e, p.Name, p.Fields)
+			view = policyView(e)
+		}
+	}
+	return e, true
+}
+
+// policyView is the event as seen by policy expressions
+func policyView(e Event) map[string]any {
+	payload, metadata := e.Payload, e.Metadata
+	if payload == nil {
+		payload = map[string]any{}
+	}
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	return map[string]any{
+		"id":        e.ID,
+		"type":      string(e.Type),
+		"name":      e.Name,
+		"payload":   payload,
+		"metadata":  metadata,
+		"timestamp": e.Timestamp,
+	}
+}
+
+// redactFields replaces the values at the given paths, or every payload
+// value if there are none, with a placeholder naming the policy
+func redactFields(e Event, policy string, fields []string) Event {
+	placeholder := "[REDACTED:" + policy + "]"
+	if len(fields) == 0 {
+		payload := make(map[string]any, len(e.Payload))
+		for k := range e.Payload {
+			payload[k] = placeholder
+		}
+		e.Payload = payload
+		return e
+	}
+	for _, f := range fields {
+		root, path, _ := strings.Cut(f, ".")
+		keys := strings.Split(path, ".")
+		if root == "payload" {
+			e.Payload = redactPath(e.Payload, keys, placeholder)
+		} else {
+			e.Metadata = redactPath(e.Metadata, keys, placeholder)
+		}
+	}
+	return e
+}
+
+// redactPath returns a copy of m with the value at keys replaced, leaving m
+// untouched. m is returned as is if the path does not exist.
+func redactPath(m map[string]any, keys []string, placeholder string) map[string]any {
+	v, ok := m[keys[0]]
+	if !ok {
+		return m
+	}
+	out := make(map[string]any, len(m))
+	for k, val := range m {
+		out[k] = val
+	}
+	if len(keys) == 1 {
+		out[keys[0]] = placeholder
+		return out
+	}
+	nested, ok := v.(map[string]any)
+	if !ok {
+		return m
+	}
+	out[keys[0]] = redactPath(nested, keys[1:], placeholder)
+	return out
+}
@@ -0,0 +1,214 @@
+package trusera
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// policyFunc is a PolicyProgram written in Go
+type policyFunc func(event map[string]any) (bool, error)
+
+func (f policyFunc) Match(event map[string]any) (bool, error) {
+	return f(event)
+}
+
+var errPolicyType = errors.New("no such overload")
+
+// testPolicies stands in for the CEL programs of the expressions used by
+// these tests, as the core module cannot import cel-go
+var testPolicies = map[string]policyFunc{
+	`event.type == "tool_call" && event.name == "lookup_order"`: func(e map[string]any) (bool, error) {
+		return e["type"] == "tool_call" && e["name"] == "lookup_order", nil
+	},
+	`event.type == "tool_call" && event.name.startsWith("shell")`: func(e map[string]any) (bool, error) {
+		return e["type"] == "tool_call" && strings.HasPrefix(e["name"].(string), "shell"), nil
+	},
+	`has(event.payload.prompt)`: func(e map[string]any) (bool, error) {
+		_, ok := e["payload"].(map[string]any)["prompt"]
+		return ok, nil
+	},
+	`event.name == "vault"`: func(e map[string]any) (bool, error) {
+		return e["name"] == "vault", nil
+	},
+	`event.name == "send_email"`: func(e map[string]any) (bool, error) {
+		return e["name"] == "send_email", nil
+	},
+	`event.payload.tokens > 100`: func(e map[string]any) (bool, error) {
+		n, ok := e["payload"].(map[string]any)["tokens"].(int)
+		if !ok {
+			return false, errPolicyType
+		}
+		return n > 100, nil
+	},
+	`event.payload.cost < 0.01`: func(e map[string]any) (bool, error) {
+		n, ok := e["payload"].(map[string]any)["cost"].(float64)
+		if !ok {
+			return false, errPolicyType
+		}
+		return n < 0.01, nil
+	},
+	`event.metadata.region != "eu"`: func(e map[string]any) (bool, error) {
+		return e["metadata"].(map[string]any)["region"] != "eu", nil
+	},
+	`true`: func(map[string]any) (bool, error) { return true, nil },
+}
+
+// usePolicyCompiler registers a compiler of testPolicies for the test
+func usePolicyCompiler(t *testing.T) {
+	t.Helper()
+	prev := lookupPolicyCompiler()
+	RegisterPolicyCompiler(func(expression string) (PolicyProgram, error) {
+		if p, ok := testPolicies[expression]; ok {
+			return p, nil
+		}
+		return nil, fmt.Errorf("syntax error in %q", expression)
+	})
+	t.Cleanup(func() { RegisterPolicyCompiler(prev) })
+}
+
+func TestEventPolicies(t *testing.T) {
+	usePolicyCompiler(t)
+	client := NewClient("test-key", WithBatchSize(1000), WithEventPolicies(
+		EventPolicy{Name: "trusted-tools", Expression: `event.type == "tool_call" && event.name == "lookup_order"`, Action: EventPolicyAllow},
+		EventPolicy{Name: "no-shell", Expression: `event.type == "tool_call" && event.name.startsWith("shell")`, Action: EventPolicyBlock},
+		EventPolicy{Name: "prompts", Expression: `has(event.payload.prompt)`, Action: EventPolicyRedact,
+			Fields: []string{"payload.prompt", "metadata.user.email"}},
+		EventPolicy{Name: "secrets", Expression: `event.name == "vault"`, Action: EventPolicyRedact},
+	))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "shell_exec"))
+	client.Track(NewEvent(EventToolCall, "lookup_order").WithPayload("prompt", "kept: allowed before redaction"))
+	client.Track(NewEvent(EventLLMInvoke, "chat").
+		WithPayload("prompt", "my card is 4111").
+		WithPayload("model", "gpt-4o").
+		WithMetadata("user", map[string]any{"email": "a@example.com", "id": "u-1"}))
+	client.Track(NewEvent(EventDataAccess, "vault").WithPayload("key", "db-password").WithPayload("size", 12))
+
+	events := queuedEvents(client)
+	if len(events) != 3 {
+		t.Fatalf("expected the shell call blocked, got %d events", len(events))
+	}
+	if events[0].Payload["prompt"] != "kept: allowed before redaction" {
+		t.Errorf("expected the allow policy to stop evaluation, got %v", events[0].Payload)
+	}
+
+	chat := events[1]
+	if chat.Payload["prompt"] != "[REDACTED:prompts]" || chat.Payload["model"] != "gpt-4o" {
+		t.Errorf("expected only the prompt redacted, got %v", chat.Payload)
+	}
+	user := chat.Metadata["user"].(map[string]any)
+	if user["email"] != "[REDACTED:prompts]" || user["id"] != "u-1" {
+		t.Errorf("expected the nested email redacted, got %v", user)
+	}
+
+	if vault := events[2].Payload; vault["key"] != "[REDACTED:secrets]" || vault["size"] != "[REDACTED:secrets]" {
+		t.Errorf("expected the whole payload redacted, got %v", vault)
+	}
+	if stats := client.Stats(); stats.Filtered != 1 {
+		t.Errorf("expected 1 filtered event, got %d", stats.Filtered)
+	}
+}
+
+func TestEventPolicyErrorsFailClosed(t *testing.T) {
+	usePolicyCompiler(t)
+	client := NewClient("test-key", WithBatchSize(1000), WithEventPolicies(
+		EventPolicy{Name: "allow-big", Expression: `event.payload.tokens > 100`, Action: EventPolicyAllow},
+		EventPolicy{Name: "block-cheap", Expression: `event.payload.cost < 0.01`, Action: EventPolicyBlock},
+	))
+	defer client.Close()
+
+	// Comparing strings with numbers fails: the allow policy does not match
+	// and the block policy does
+	client.Track(NewEvent(EventLLMInvoke, "chat").WithPayload("tokens", "many").WithPayload("cost", "unknown"))
+	client.Track(NewEvent(EventLLMInvoke, "chat").WithPayload("tokens", 10).WithPayload("cost", 1.5))
+
+	events := queuedEvents(client)
+	if len(events) != 1 || events[0].Payload["tokens"] != 10 {
+		t.Errorf("expected only the well-typed event kept, got %+v", events)
+	}
+}
+
+func TestLoadEventPolicies(t *testing.T) {
+	usePolicyCompiler(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policies.json")
+	os.WriteFile(path, []byte(`[{"name": "no-email", "expression": "event.name == \"send_email\"", "action": "block"}]`), 0o600)
+
+	policies, err := LoadEventPolicies(path)
+	if err != nil {
+		t.Fatalf("LoadEventPolicies failed: %v", err)
+	}
+	if len(policies) != 1 || policies[0].Action != EventPolicyBlock {
+		t.Errorf("unexpected policies %+v", policies)
+	}
+
+	t.Setenv("TRUSERA_EVENT_POLICY_FILE", path)
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+	client.Track(NewEvent(EventToolCall, "send_email"))
+	if len(queuedEvents(client)) != 0 {
+		t.Error("expected the policy file from the environment applied")
+	}
+
+	for _, bad := range []string{
+		`not json`,
+		`[{"name": "x", "expression": "event.name ==", "action": "block"}]`,
+		`[{"name": "x", "expression": "true", "action": "drop"}]`,
+		`[{"name": "x", "expression": "true", "action": "redact", "fields": ["prompt"]}]`,
+	} {
+		os.WriteFile(path, []byte(bad), 0o600)
+		if _, err := LoadEventPolicies(path); err == nil {
+			t.Errorf("expected error for %s", bad)
+		}
+	}
+	if _, err := LoadEventPolicies(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("expected error for a missing file")
+	}
+}
+
+func TestRemoteEventPolicies(t *testing.T) {
+	usePolicyCompiler(t)
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	err := client.applyRemoteConfig(RemoteConfig{Version: "v1", EventPolicies: []EventPolicy{
+		{Name: "eu-only", Expression: `event.metadata.region != "eu"`, Action: EventPolicyBlock},
+	}})
+	if err != nil {
+		t.Fatalf("applyRemoteConfig failed: %v", err)
+	}
+	client.Track(NewEvent(EventToolCall, "a").WithMetadata("region", "us"))
+	client.Track(NewEvent(EventToolCall, "b").WithMetadata("region", "eu"))
+
+	events := queuedEvents(client)
+	if len(events) != 1 || events[0].Name != "b" {
+		t.Errorf("expected the remote policy applied, got %+v", events)
+	}
+
+	err = client.applyRemoteConfig(RemoteConfig{Version: "v2", EventPolicies: []EventPolicy{{Name: "bad", Expression: "(", Action: EventPolicyBlock}}})
+	if err == nil {
+		t.Error("expected error for an invalid remote policy")
+	}
+	if cfg, _ := client.RemoteConfig(); cfg.Version != "v1" {
+		t.Errorf("expected v1 to stay installed, got %s", cfg.Version)
+	}
+}
+
+func TestEventPoliciesNeedCompiler(t *testing.T) {
+	prev := lookupPolicyCompiler()
+	RegisterPolicyCompiler(nil)
+	defer RegisterPolicyCompiler(prev)
+
+	_, err := compilePolicies([]EventPolicy{{Name: "x", Expression: "true", Action: EventPolicyBlock}})
+	if err == nil || !strings.Contains(err.Error(), "celgo") {
+		t.Errorf("expected an error naming the celgo module, got %v", err)
+	}
+	if _, err := compilePolicies(nil); err != nil {
+		t.Errorf("expected no policies to need no compiler, got %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package trusera
+
+// EventProcessor inspects an event just before it is queued. It returns the
+// event to queue, which may be enriched or scrubbed, and false to discard it.
+type EventProcessor func(Event) (Event, bool)
+
+// WithEventProcessor adds a processor to the chain run on every tracked
+// event after sampling and redaction. Processors run in the order they are
+// added, and the chain stops at the first one that discards the event.
+func WithEventProcessor(p EventProcessor) Option {
+	return func(c *Client) {
+		if p != nil {
+			c.processors = append(c.processors, p)
+		}
+	}
+}
+
+// process runs the event processor chain, counting discarded events
+func (c *Client) process(e Event) (Event, bool) {
+	for _, p := range c.processors {
+		var keep bool
+		if e, keep = p(e); !keep {
+			c.mu.Lock()
+			c.stats.filtered++
+			c.mu.Unlock()
+			return e, false
+		}
+	}
+	return e, true
+}
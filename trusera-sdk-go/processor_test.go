@@ -0,0 +1,67 @@
+package trusera
+
+import "testing"
+
+func TestEventProcessorEnriches(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000), WithEventProcessor(func(e Event) (Event, bool) {
+		return e.WithMetadata("tenant_id", "acme"), true
+	}))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "tool"))
+
+	events := queuedEvents(client)
+	if len(events) != 1 {
+		t.Fatalf("expected 1 queued event, got %d", len(events))
+	}
+	if got := events[0].Metadata["tenant_id"]; got != "acme" {
+		t.Errorf("expected tenant_id acme, got %v", got)
+	}
+}
+
+func TestEventProcessorDrops(t *testing.T) {
+	var calls int
+	client := NewClient("test-key", WithBatchSize(1000),
+		WithEventProcessor(func(e Event) (Event, bool) {
+			return e, e.Type != EventDataAccess
+		}),
+		WithEventProcessor(func(e Event) (Event, bool) {
+			calls++
+			return e, true
+		}),
+	)
+	defer client.Close()
+
+	client.Track(NewEvent(EventDataAccess, "noisy"))
+	client.Track(NewEvent(EventToolCall, "tool"))
+
+	events := queuedEvents(client)
+	if len(events) != 1 || events[0].Name != "tool" {
+		t.Errorf("expected only the tool event to be queued, got %v", events)
+	}
+	if calls != 1 {
+		t.Errorf("expected the chain to stop at the dropping processor, second ran %d times", calls)
+	}
+	stats := client.Stats()
+	if stats.Filtered != 1 || stats.Tracked != 1 {
+		t.Errorf("expected 1 filtered and 1 tracked, got %d and %d", stats.Filtered, stats.Tracked)
+	}
+}
+
+func TestEventProcessorSeesRedactedEvent(t *testing.T) {
+	var seen string
+	client := NewClient("test-key", WithBatchSize(1000),
+		WithRedactor(DefaultRedactor()),
+		WithEventProcessor(func(e Event) (Event, bool) {
+			seen, _ = e.Payload["prompt"].(string)
+			return e, true
+		}),
+	)
+	defer client.Close()
+
+	client.Track(NewEvent(EventLLMInvoke, "chat").WithPayload("prompt", "mail bob@example.com"))
+
+	if seen == "mail bob@example.com" {
+		t.Error("expected processors to run after redaction")
+	}
+}
@@ -0,0 +1,83 @@
+// Package prometheusgo reports Trusera client self-metrics through the
+// Prometheus client library (github.com/prometheus/client_golang), so they
+// are served by an application's existing registry and /metrics handler:
+//
+//	prometheus.MustRegister(prometheusgo.NewCollector(client, nil))
+//
+// The series have the names the metrics package writes.
+package prometheusgo
+
+import (
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metric describes a single exported series
+type metric struct {
+	desc  *prometheus.Desc
+	kind  prometheus.ValueType
+	value func(trusera.Stats) float64
+}
+
+// Collector is a prometheus.Collector reading a client's Stats on each scrape
+type Collector struct {
+	src     metrics.Source
+	metrics []metric
+	flush   *prometheus.Desc
+}
+
+// NewCollector returns a Collector for src. constLabels are added to every
+// series, so several clients can be registered with one registry.
+func NewCollector(src metrics.Source, constLabels prometheus.Labels) *Collector {
+	newMetric := func(name, help string, kind prometheus.ValueType, value func(trusera.Stats) float64) metric {
+		return metric{prometheus.NewDesc(name, help, nil, constLabels), kind, value}
+	}
+	return &Collector{
+		src: src,
+		metrics: []metric{
+			newMetric("trusera_sdk_events_queued", "Events waiting to be sent.", prometheus.GaugeValue,
+				func(s trusera.Stats) float64 { return float64(s.Queued) }),
+			newMetric("trusera_sdk_events_tracked_total", "Events accepted by Track.", prometheus.CounterValue,
+				func(s trusera.Stats) float64 { return float64(s.Tracked) }),
+			newMetric("trusera_sdk_events_flushed_total", "Events delivered to the Trusera API.", prometheus.CounterValue,
+				func(s trusera.Stats) float64 { return float64(s.Flushed) }),
+			newMetric("trusera_sdk_events_dropped_total", "Events discarded without being delivered.", prometheus.CounterValue,
+				func(s trusera.Stats) float64 { return float64(s.Dropped) }),
+			newMetric("trusera_sdk_events_sampled_out_total", "Events discarded by sampling.", prometheus.CounterValue,
+				func(s trusera.Stats) float64 { return float64(s.SampledOut) }),
+			newMetric("trusera_sdk_events_filtered_total", "Events discarded by event processors or event policies.", prometheus.CounterValue,
+				func(s trusera.Stats) float64 { return float64(s.Filtered) }),
+			newMetric("trusera_sdk_events_truncated_total", "Events shortened to fit the batch size limit.", prometheus.CounterValue,
+				func(s trusera.Stats) float64 { return float64(s.Truncated) }),
+			newMetric("trusera_sdk_events_dead_lettered_total", "Events rejected by the API and written to the dead-letter file.", prometheus.CounterValue,
+				func(s trusera.Stats) float64 { return float64(s.DeadLettered) }),
+			newMetric("trusera_sdk_events_invalid_total", "Events dropped by strict validation.", prometheus.CounterValue,
+				func(s trusera.Stats) float64 { return float64(s.Invalid) }),
+			newMetric("trusera_sdk_flush_errors_total", "Failed event delivery attempts.", prometheus.CounterValue,
+				func(s trusera.Stats) float64 { return float64(s.FlushErrors) }),
+			newMetric("trusera_sdk_heartbeat_failures_total", "Failed fleet heartbeats.", prometheus.CounterValue,
+				func(s trusera.Stats) float64 { return float64(s.HeartbeatFailures) }),
+			newMetric("trusera_sdk_rate_limited_total", "Event deliveries rejected by API rate limiting.", prometheus.CounterValue,
+				func(s trusera.Stats) float64 { return float64(s.RateLimited) }),
+		},
+		flush: prometheus.NewDesc("trusera_sdk_flush_duration_seconds", "Time spent delivering event batches.", nil, constLabels),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range c.metrics {
+		ch <- m.desc
+	}
+	ch <- c.flush
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	s := c.src.Stats()
+	for _, m := range c.metrics {
+		ch <- prometheus.MustNewConstMetric(m.desc, m.kind, m.value(s))
+	}
+	ch <- prometheus.MustNewConstSummary(c.flush, s.Flushes, s.FlushDuration.Seconds(), nil)
+}
@@ -0,0 +1,72 @@
+package prometheusgo
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/truseratest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type staticSource trusera.Stats
+
+func (s staticSource) Stats() trusera.Stats { return trusera.Stats(s) }
+
+func TestCollector(t *testing.T) {
+	src := staticSource{
+		Queued:        3,
+		Tracked:       10,
+		Flushed:       7,
+		Dropped:       1,
+		Flushes:       4,
+		FlushDuration: 1500 * time.Millisecond,
+	}
+	c := NewCollector(src, prometheus.Labels{"agent": "support"})
+
+	want := `
+# HELP trusera_sdk_events_queued Events waiting to be sent.
+# TYPE trusera_sdk_events_queued gauge
+trusera_sdk_events_queued{agent="support"} 3
+# HELP trusera_sdk_events_flushed_total Events delivered to the Trusera API.
+# TYPE trusera_sdk_events_flushed_total counter
+trusera_sdk_events_flushed_total{agent="support"} 7
+# HELP trusera_sdk_flush_duration_seconds Time spent delivering event batches.
+# TYPE trusera_sdk_flush_duration_seconds summary
+trusera_sdk_flush_duration_seconds_sum{agent="support"} 1.5
+trusera_sdk_flush_duration_seconds_count{agent="support"} 4
+`
+	err := testutil.CollectAndCompare(c, strings.NewReader(want),
+		"trusera_sdk_events_queued", "trusera_sdk_events_flushed_total", "trusera_sdk_flush_duration_seconds")
+	if err != nil {
+		t.Error(err)
+	}
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err == nil {
+		t.Error("expected the other series to be collected too")
+	}
+}
+
+func TestCollectorReadsClientOnScrape(t *testing.T) {
+	rec := truseratest.NewRecordingClient()
+	defer rec.Close()
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(NewCollector(rec.Client, nil))
+
+	rec.Track(trusera.NewEvent(trusera.EventToolCall, "search"))
+	rec.Track(trusera.NewEvent(trusera.EventToolCall, "fetch"))
+
+	want := `
+# HELP trusera_sdk_events_tracked_total Events accepted by Track.
+# TYPE trusera_sdk_events_tracked_total counter
+trusera_sdk_events_tracked_total 2
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "trusera_sdk_events_tracked_total"); err != nil {
+		t.Error(err)
+	}
+	if n, err := testutil.GatherAndCount(reg); err != nil || n != 13 {
+		t.Errorf("expected 13 series, got %d (%v)", n, err)
+	}
+}
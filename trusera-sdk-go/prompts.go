@@ -0,0 +1,136 @@
+package trusera
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// PromptVersion identifies one version of a registered prompt template
+type PromptVersion struct {
+	Name    string
+	Version string // Content hash of the template, see RegisterPrompt
+}
+
+// prompts holds the templates registered by a client
+type prompts struct {
+	mu       sync.Mutex
+	latest   map[string]string // Name to the most recently registered version
+	uploaded map[PromptVersion]bool
+}
+
+// promptContextKey is the context key for the prompt in use
+type promptContextKey struct{}
+
+// RegisterPrompt registers a prompt template with Trusera and returns its
+// version: the first 12 hex digits of the template's SHA-256, so the same
+// template always has the same version and any edit produces a new one. Each
+// version is uploaded to /v1/prompts once per client; the version is returned
+// even if the upload fails. Use UsePrompt to stamp LLM calls with it.
+func (c *Client) RegisterPrompt(name, template string, metadata map[string]any) (string, error) {
+	if name == "" {
+		return "", errors.New("prompt name is required")
+	}
+	sum := sha256.Sum256([]byte(template))
+	pv := PromptVersion{Name: name, Version: hex.EncodeToString(sum[:6])}
+
+	c.prompts.mu.Lock()
+	if c.prompts.latest == nil {
+		c.prompts.latest = make(map[string]string)
+		c.prompts.uploaded = make(map[PromptVersion]bool)
+	}
+	c.prompts.latest[name] = pv.Version
+	uploaded := c.prompts.uploaded[pv]
+	c.prompts.mu.Unlock()
+	if uploaded {
+		return pv.Version, nil
+	}
+
+	if err := c.uploadPrompt(pv, template, metadata); err != nil {
+		return pv.Version, err
+	}
+	c.prompts.mu.Lock()
+	c.prompts.uploaded[pv] = true
+	c.prompts.mu.Unlock()
+	return pv.Version, nil
+}
+
+// uploadPrompt posts a prompt version to /v1/prompts
+func (c *Client) uploadPrompt(pv PromptVersion, template string, metadata map[string]any) error {
+	c.mu.Lock()
+	agentID := c.agentID
+	c.mu.Unlock()
+
+	payload := map[string]any{
+		"name":     pv.Name,
+		"version":  pv.Version,
+		"template": template,
+	}
+	if agentID != "" {
+		payload["agent_id"] = agentID
+	}
+	if len(metadata) > 0 {
+		payload["metadata"] = metadata
+	}
+
+	if c.sink != nil {
+		return c.sink.write("prompt", agentID, payload)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal prompt: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/v1/prompts", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.currentAPIKey())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to register prompt: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return newAPIError(resp)
+	}
+	// Drain body to allow connection reuse
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	return nil
+}
+
+// UsePrompt returns a copy of ctx carrying the latest registered version of
+// the prompt name. LLM calls recorded with the context, by WrapTransport or
+// TrackContext, carry "prompt_name" and "prompt_version" metadata. ctx is
+// returned unchanged if name was never registered.
+func (c *Client) UsePrompt(ctx context.Context, name string) context.Context {
+	c.prompts.mu.Lock()
+	version, ok := c.prompts.latest[name]
+	c.prompts.mu.Unlock()
+	if !ok {
+		return ctx
+	}
+	return ContextWithPrompt(ctx, PromptVersion{Name: name, Version: version})
+}
+
+// ContextWithPrompt returns a copy of ctx carrying p as the prompt in use
+func ContextWithPrompt(ctx context.Context, p PromptVersion) context.Context {
+	return context.WithValue(ctx, promptContextKey{}, p)
+}
+
+// PromptFromContext returns the prompt in use in ctx, if any
+func PromptFromContext(ctx context.Context) (PromptVersion, bool) {
+	p, ok := ctx.Value(promptContextKey{}).(PromptVersion)
+	return p, ok
+}
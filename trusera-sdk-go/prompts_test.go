@@ -0,0 +1,95 @@
+package trusera
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRegisterPrompt(t *testing.T) {
+	var mu sync.Mutex
+	var uploads []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/prompts" {
+			return
+		}
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		uploads = append(uploads, body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithAgentID("agent-1"))
+	defer client.Close()
+
+	v1, err := client.RegisterPrompt("support", "You are a helpful agent.", map[string]any{"owner": "cx"})
+	if err != nil {
+		t.Fatalf("RegisterPrompt failed: %v", err)
+	}
+	again, _ := client.RegisterPrompt("support", "You are a helpful agent.", nil)
+	v2, _ := client.RegisterPrompt("support", "You are a terse agent.", nil)
+
+	if len(v1) != 12 || again != v1 {
+		t.Errorf("expected a stable 12 digit version, got %q and %q", v1, again)
+	}
+	if v2 == v1 {
+		t.Error("expected a new version for an edited template")
+	}
+	if len(uploads) != 2 {
+		t.Fatalf("expected each version uploaded once, got %d uploads", len(uploads))
+	}
+	first := uploads[0]
+	if first["name"] != "support" || first["version"] != v1 || first["agent_id"] != "agent-1" || first["metadata"] == nil {
+		t.Errorf("unexpected upload %v", first)
+	}
+
+	if _, err := client.RegisterPrompt("", "x", nil); err == nil {
+		t.Error("expected error for empty name")
+	}
+}
+
+func TestUsePromptStampsLLMCalls(t *testing.T) {
+	client := NewClient("test-key", WithLocalSink(t.TempDir()+"/events.jsonl"))
+	defer client.Close()
+
+	version, err := client.RegisterPrompt("support", "You are a helpful agent.", nil)
+	if err != nil {
+		t.Fatalf("RegisterPrompt failed: %v", err)
+	}
+
+	httpClient := &http.Client{Transport: WrapTransport(cannedResponse("application/json",
+		`{"model":"gpt-4o","usage":{"prompt_tokens":1,"completion_tokens":1}}`), client)}
+	ctx := client.UsePrompt(context.Background(), "support")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.openai.com/v1/chat/completions", strings.NewReader(`{"model":"gpt-4o"}`))
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	client.TrackContext(ctx, NewEvent(EventToolCall, "search"))
+
+	events := queuedEvents(client)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Metadata["prompt_name"] != "support" || events[0].Metadata["prompt_version"] != version {
+		t.Errorf("expected the LLM call stamped with the prompt, got %v", events[0].Metadata)
+	}
+	if _, ok := events[1].Metadata["prompt_version"]; ok {
+		t.Errorf("expected only LLM calls stamped, got %v", events[1].Metadata)
+	}
+
+	if client.UsePrompt(ctx, "unknown") != ctx {
+		t.Error("expected ctx unchanged for an unregistered prompt")
+	}
+}
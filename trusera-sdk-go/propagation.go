@@ -0,0 +1,105 @@
+package trusera
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// TraceparentHeader is the W3C Trace Context header used to propagate spans
+// across service boundaries
+const TraceparentHeader = "Traceparent"
+
+// spanContextKey is the context key for the active span
+type spanContextKey struct{}
+
+// ContextWithSpan returns a copy of ctx carrying s as the active span
+func ContextWithSpan(ctx context.Context, s *Span) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, s)
+}
+
+// SpanFromContext returns the active span in ctx, or nil if there is none.
+// A span extracted from incoming headers has no client and records nothing
+// itself, but spans started under it join the remote trace.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanContextKey{}).(*Span)
+	return s
+}
+
+// StartSpan begins a span under the active span in ctx, or a new run if ctx
+// has none, and returns a context carrying it
+func (c *Client) StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	var s *Span
+	if parent := SpanFromContext(ctx); parent != nil {
+		s = c.newSpan(name, SpanKindStep, parent.traceID, parent.spanID)
+		s.tags = parent.tags
+	} else {
+		s = c.newSpan(name, SpanKindRun, generateID(), "")
+	}
+	return ContextWithSpan(ctx, s), s
+}
+
+// TrackContext tracks an event as a child of the active span in ctx, if any
+func (c *Client) TrackContext(ctx context.Context, event Event) {
+	trackContext(c, ctx, event)
+}
+
+// trackContext links event to the active span in ctx, stamps LLM calls with
+// the prompt in use, labels it with the rollout variant and experiments and
+// tracks it with t
+func trackContext(t Tracker, ctx context.Context, event Event) {
+	if s := SpanFromContext(ctx); s != nil {
+		event = s.link(event)
+	}
+	if event.Type == EventLLMInvoke {
+		if p, ok := PromptFromContext(ctx); ok {
+			event = event.WithMetadata("prompt_name", p.Name).WithMetadata("prompt_version", p.Version)
+		}
+	}
+	if v, ok := VariantFromContext(ctx); ok {
+		event = event.WithMetadata("variant", v)
+	}
+	if xs := ExperimentsFromContext(ctx); len(xs) > 0 {
+		m := make(map[string]any, len(xs))
+		for k, v := range xs {
+			m[k] = v
+		}
+		event = event.WithMetadata("experiments", m)
+	}
+	t.Track(event)
+}
+
+// InjectHeaders writes the active span in ctx to h as a W3C traceparent header
+func InjectHeaders(ctx context.Context, h http.Header) {
+	s := SpanFromContext(ctx)
+	if s == nil {
+		return
+	}
+	h.Set(TraceparentHeader, "00-"+s.traceID+"-"+s.spanID+"-01")
+}
+
+// ExtractHeaders returns a copy of ctx carrying the remote span described by
+// the traceparent header in h. ctx is returned unchanged if the header is
+// missing or malformed.
+func ExtractHeaders(ctx context.Context, h http.Header) context.Context {
+	parts := strings.Split(strings.TrimSpace(h.Get(TraceparentHeader)), "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return ctx
+	}
+	traceID, spanID := strings.ToLower(parts[1]), strings.ToLower(parts[2])
+	if len(traceID) != 32 || len(spanID) != 16 || !isHex(traceID) || !isHex(spanID) ||
+		strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+		return ctx
+	}
+	return ContextWithSpan(ctx, &Span{traceID: traceID, spanID: spanID, ended: true})
+}
+
+// isHex reports whether s contains only lowercase hex digits
+func isHex(s string) bool {
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package trusera
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestStartSpanFromContext(t *testing.T) {
+	client := NewClient("test-key")
+	defer client.Close()
+
+	ctx, root := client.StartSpan(context.Background(), "handle-request")
+	if SpanFromContext(ctx) != root {
+		t.Fatal("expected root span in context")
+	}
+	if root.ParentSpanID() != "" {
+		t.Errorf("expected root span without parent, got %s", root.ParentSpanID())
+	}
+
+	done := make(chan *Span)
+	go func() {
+		_, child := client.StartSpan(ctx, "worker")
+		child.End()
+		done <- child
+	}()
+	child := <-done
+
+	if child.TraceID() != root.TraceID() {
+		t.Errorf("expected trace %s, got %s", root.TraceID(), child.TraceID())
+	}
+	if child.ParentSpanID() != root.SpanID() {
+		t.Errorf("expected parent %s, got %s", root.SpanID(), child.ParentSpanID())
+	}
+
+	if SpanFromContext(context.Background()) != nil {
+		t.Error("expected no span in empty context")
+	}
+}
+
+func TestInjectExtractHeaders(t *testing.T) {
+	client := NewClient("test-key")
+	defer client.Close()
+
+	ctx, span := client.StartSpan(context.Background(), "caller")
+	h := http.Header{}
+	InjectHeaders(ctx, h)
+
+	want := "00-" + span.TraceID() + "-" + span.SpanID() + "-01"
+	if got := h.Get("traceparent"); got != want {
+		t.Fatalf("expected traceparent %s, got %s", want, got)
+	}
+
+	remote := ExtractHeaders(context.Background(), h)
+	_, child := client.StartSpan(remote, "callee")
+	if child.TraceID() != span.TraceID() || child.ParentSpanID() != span.SpanID() {
+		t.Errorf("expected child of %s/%s, got %s/%s",
+			span.TraceID(), span.SpanID(), child.TraceID(), child.ParentSpanID())
+	}
+
+	// The extracted span itself records nothing
+	SpanFromContext(remote).End()
+	SpanFromContext(remote).AddEvent(NewEvent(EventDecision, "ignored"))
+	if n := len(queuedEvents(client)); n != 0 {
+		t.Errorf("expected remote span to record nothing, got %d events", n)
+	}
+}
+
+func TestExtractHeadersInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"garbage",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331",
+		"ff-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"00-00000000000000000000000000000000-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319c-0000000000000000-01",
+		"00-0af7651916cd43dd8448eb211c80319z-b7ad6b7169203331-01",
+	}
+	for _, tc := range tests {
+		h := http.Header{}
+		h.Set(TraceparentHeader, tc)
+		if SpanFromContext(ExtractHeaders(context.Background(), h)) != nil {
+			t.Errorf("expected %q to be rejected", tc)
+		}
+	}
+}
+
+func TestWrapTransportLinksContextSpan(t *testing.T) {
+	client := NewClient("test-key")
+	defer client.Close()
+
+	base := cannedResponse("application/json", `{"model":"gpt-4o","usage":{"prompt_tokens":1,"completion_tokens":2}}`)
+	httpClient := &http.Client{Transport: WrapTransport(base, client)}
+
+	ctx, span := client.StartSpan(context.Background(), "agent")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.openai.com/v1/chat/completions",
+		strings.NewReader(`{"model":"gpt-4o"}`))
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	events := queuedEvents(client)
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	if events[0].Metadata["trace_id"] != span.TraceID() || events[0].Metadata["parent_span_id"] != span.SpanID() {
+		t.Errorf("expected LLM event linked to span, got %v", events[0].Metadata)
+	}
+}
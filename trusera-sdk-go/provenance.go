@@ -0,0 +1,138 @@
+package trusera
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"regexp"
+	"runtime/debug"
+	"strings"
+)
+
+// imageDigestEnvVars may carry the digest, or a digest-pinned reference, of
+// the container image. CONTAINER_IMAGE is the variable the k8s package reads.
+var imageDigestEnvVars = []string{"TRUSERA_IMAGE_DIGEST", "IMAGE_DIGEST", "CONTAINER_IMAGE_DIGEST", "CONTAINER_IMAGE"}
+
+// imageDigestPattern matches an OCI content digest
+var imageDigestPattern = regexp.MustCompile(`sha256:[0-9a-f]{64}`)
+
+// containerIDPattern matches the 64-hex container ID in cgroup paths written
+// by Docker, containerd and CRI-O
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// mountContainerIDPattern matches the container ID in the paths of the
+// hostname and resolv.conf files the runtime bind-mounts into the container,
+// which are the only trace of it under cgroup v2 namespaces
+var mountContainerIDPattern = regexp.MustCompile(`/containers/([0-9a-f]{64})/`)
+
+// buildProvenance describes the binary and container image the agent runs
+// from, for fleet registration
+func buildProvenance() map[string]interface{} {
+	p := map[string]interface{}{}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		addBuildInfo(p, info)
+	}
+	if exe, err := os.Executable(); err == nil {
+		if sum, err := fileSHA256(exe); err == nil {
+			p["binary_sha256"] = sum
+		}
+	}
+	if digest := imageDigest(os.Getenv); digest != "" {
+		p["image_digest"] = digest
+	}
+	if id := containerID("/proc/self/cgroup", "/proc/self/mountinfo"); id != "" {
+		p["container_id"] = id
+	}
+	return p
+}
+
+// addBuildInfo records the main module and VCS stamp of a Go binary
+func addBuildInfo(p map[string]interface{}, info *debug.BuildInfo) {
+	if info.Main.Path != "" {
+		p["module_path"] = info.Main.Path
+	}
+	if info.Main.Version != "" {
+		p["module_version"] = info.Main.Version
+	}
+	if info.GoVersion != "" {
+		p["go_version"] = info.GoVersion
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs":
+			p["vcs"] = s.Value
+		case "vcs.revision":
+			p["vcs_revision"] = s.Value
+		case "vcs.time":
+			p["vcs_time"] = s.Value
+		case "vcs.modified":
+			p["vcs_dirty"] = s.Value == "true"
+		}
+	}
+}
+
+// fileSHA256 returns the hex-encoded SHA-256 of a file
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// imageDigest returns the container image digest from the environment
+func imageDigest(getenv func(string) string) string {
+	for _, name := range imageDigestEnvVars {
+		if digest := imageDigestPattern.FindString(getenv(name)); digest != "" {
+			return digest
+		}
+	}
+	return ""
+}
+
+// containerID returns the ID of the container the process runs in, read
+// from its cgroup or mountinfo file, or "" outside a container
+func containerID(cgroupPath, mountinfoPath string) string {
+	id := scanLines(cgroupPath, func(line string) string {
+		// Lines are hierarchy-ID:controllers:path
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			return ""
+		}
+		return containerIDPattern.FindString(parts[2])
+	})
+	if id != "" {
+		return id
+	}
+	return scanLines(mountinfoPath, func(line string) string {
+		if m := mountContainerIDPattern.FindStringSubmatch(line); m != nil {
+			return m[1]
+		}
+		return ""
+	})
+}
+
+// scanLines returns the first non-empty result of match over a file's lines
+func scanLines(path string, match func(string) string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if v := match(scanner.Text()); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,131 @@
+package trusera
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestAddBuildInfo(t *testing.T) {
+	p := map[string]interface{}{}
+	addBuildInfo(p, &debug.BuildInfo{
+		GoVersion: "go1.22.3",
+		Main:      debug.Module{Path: "example.com/agent", Version: "v1.4.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs", Value: "git"},
+			{Key: "vcs.revision", Value: "4f2c1e9"},
+			{Key: "vcs.time", Value: "2026-03-01T12:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+			{Key: "GOOS", Value: "linux"},
+		},
+	})
+
+	want := map[string]interface{}{
+		"module_path":    "example.com/agent",
+		"module_version": "v1.4.0",
+		"go_version":     "go1.22.3",
+		"vcs":            "git",
+		"vcs_revision":   "4f2c1e9",
+		"vcs_time":       "2026-03-01T12:00:00Z",
+		"vcs_dirty":      true,
+	}
+	if len(p) != len(want) {
+		t.Errorf("expected %d fields, got %v", len(want), p)
+	}
+	for k, v := range want {
+		if p[k] != v {
+			t.Errorf("expected %s=%v, got %v", k, v, p[k])
+		}
+	}
+}
+
+func TestFileSHA256(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent")
+	if err := os.WriteFile(path, []byte("binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte("binary"))
+	got, err := fileSHA256(path)
+	if err != nil || got != hex.EncodeToString(sum[:]) {
+		t.Errorf("expected %x, got %s (%v)", sum, got, err)
+	}
+}
+
+func TestImageDigest(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("ab", 32)
+	env := map[string]string{"CONTAINER_IMAGE": "registry.example.com/agent@" + digest}
+	if got := imageDigest(func(k string) string { return env[k] }); got != digest {
+		t.Errorf("expected digest from a pinned image reference, got %q", got)
+	}
+	env = map[string]string{"CONTAINER_IMAGE": "registry.example.com/agent:latest"}
+	if got := imageDigest(func(k string) string { return env[k] }); got != "" {
+		t.Errorf("expected no digest for a tag reference, got %q", got)
+	}
+}
+
+func TestContainerID(t *testing.T) {
+	dir := t.TempDir()
+	id := strings.Repeat("0123abcd", 8)
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	cgroupV1 := write("cgroup-v1", "12:pids:/docker/"+id+"\n1:name=systemd:/docker/"+id+"\n")
+	cgroupV2 := write("cgroup-v2", "0::/kubepods.slice/kubepods-pod1.slice/cri-containerd-"+id+".scope\n")
+	namespaced := write("cgroup-ns", "0::/\n")
+	mountinfo := write("mountinfo", "100 90 0:50 / / rw - overlay overlay rw,lowerdir=/var/lib/docker/overlay2/"+
+		strings.Repeat("f", 64)+"/diff\n"+
+		"110 100 8:1 /var/lib/docker/containers/"+id+"/hostname /etc/hostname rw - ext4 /dev/sda1 rw\n")
+	host := write("host", "0::/user.slice/user-1000.slice/session-2.scope\n")
+	missing := filepath.Join(dir, "missing")
+
+	tests := []struct {
+		name      string
+		cgroup    string
+		mountinfo string
+		want      string
+	}{
+		{"cgroup v1", cgroupV1, missing, id},
+		{"cgroup v2", cgroupV2, missing, id},
+		{"cgroup namespace", namespaced, mountinfo, id},
+		{"host", host, missing, ""},
+	}
+	for _, tt := range tests {
+		if got := containerID(tt.cgroup, tt.mountinfo); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestRegistrationIncludesBuildProvenance(t *testing.T) {
+	var register map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/fleet/register" {
+			_ = json.NewDecoder(r.Body).Decode(&register)
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"id": "fleet-1"}})
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithAutoRegister())
+	defer client.Close()
+
+	provenance, ok := register["build_provenance"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected build_provenance in registration, got %v", register)
+	}
+	if sum, _ := provenance["binary_sha256"].(string); len(sum) != 64 {
+		t.Errorf("expected binary SHA-256 of the test binary, got %v", provenance["binary_sha256"])
+	}
+}
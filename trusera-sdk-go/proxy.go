@@ -0,0 +1,69 @@
+package trusera
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// WithProxy sends all API traffic through the proxy at rawURL, such as
+// "http://proxy.corp:3128" or "socks5://gateway:1080". Credentials can be
+// given in the URL. Without this option the proxy in TRUSERA_PROXY is used,
+// or else HTTP_PROXY, HTTPS_PROXY and NO_PROXY as usual. The proxy is applied
+// to the transport of the client's http.Client.
+func WithProxy(rawURL string) Option {
+	return func(c *Client) {
+		c.proxyURL = rawURL
+	}
+}
+
+// parseProxyURL checks that rawURL names a proxy net/http can use
+func parseProxyURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("proxy URL %q must use http, https, socks5 or socks5h", rawURL)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy URL %q has no host", rawURL)
+	}
+	return u, nil
+}
+
+// applyProxy installs the configured proxy on a copy of the client's
+// http.Client so that a caller-supplied client is not modified. Without one,
+// the default transport already honors the proxy environment variables.
+func (c *Client) applyProxy() error {
+	if c.proxyURL == "" {
+		c.proxyURL = os.Getenv("TRUSERA_PROXY")
+	}
+	if c.proxyURL == "" {
+		return nil
+	}
+	u, err := parseProxyURL(c.proxyURL)
+	if err != nil {
+		return err
+	}
+
+	var base *http.Transport
+	switch t := c.httpClient.Transport.(type) {
+	case nil:
+		base = http.DefaultTransport.(*http.Transport)
+	case *http.Transport:
+		base = t
+	default:
+		return fmt.Errorf("cannot apply proxy to transport of type %T", t)
+	}
+
+	transport := base.Clone()
+	transport.Proxy = http.ProxyURL(u)
+	hc := *c.httpClient
+	hc.Transport = transport
+	c.httpClient = &hc
+	return nil
+}
@@ -0,0 +1,19 @@
+module github.com/Trusera/ai-bom/trusera-sdk-go/qdrantgo
+
+go 1.25.0
+
+require (
+	github.com/Trusera/ai-bom/trusera-sdk-go v0.0.0
+	github.com/qdrant/go-client v1.19.3
+	google.golang.org/grpc v1.84.0
+)
+
+require (
+	golang.org/x/net v0.58.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	golang.org/x/text v0.41.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260706201446-f0a921348800 // indirect
+	google.golang.org/protobuf v1.36.12 // indirect
+)
+
+replace github.com/Trusera/ai-bom/trusera-sdk-go => ../
@@ -0,0 +1,106 @@
+// Package qdrantgo records calls made with the Qdrant Go client
+// (github.com/qdrant/go-client) as Trusera vector_store events, with the
+// collection, top-k, result count and latency of each upsert, query, fetch
+// and delete:
+//
+//	client, err := qdrant.NewClient(&qdrant.Config{
+//		Host:        "localhost",
+//		GrpcOptions: []grpc.DialOption{qdrantgo.DialOption(truseraClient)},
+//	})
+package qdrantgo
+
+import (
+	"context"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/qdrant/go-client/qdrant"
+	"google.golang.org/grpc"
+)
+
+// Store is the store name events are recorded under
+const Store = "qdrant"
+
+// defaultLimit is the number of points Qdrant returns when a query sets no limit
+const defaultLimit = 10
+
+// DialOption installs UnaryClientInterceptor on a Qdrant connection
+func DialOption(client *trusera.Client) grpc.DialOption {
+	return grpc.WithChainUnaryInterceptor(UnaryClientInterceptor(client))
+}
+
+// UnaryClientInterceptor records point upserts, queries, searches, gets and
+// deletes as vector_store events linked to the active span in ctx. Collection
+// management and other RPCs pass through untouched.
+func UnaryClientInterceptor(client *trusera.Client) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		v, ok := describe(req)
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		v.Latency = time.Since(start)
+		v.Err = err
+		if err == nil {
+			switch r := reply.(type) {
+			case *qdrant.QueryResponse:
+				v.Results = len(r.GetResult())
+			case *qdrant.SearchResponse:
+				v.Results = len(r.GetResult())
+			case *qdrant.GetResponse:
+				v.Vectors = len(r.GetResult())
+			}
+		}
+		client.TrackVectorStore(ctx, v)
+		return err
+	}
+}
+
+// describe builds the event for a points request, reporting false for
+// requests that are not recorded
+func describe(req any) (trusera.VectorStoreEvent, bool) {
+	v := trusera.VectorStoreEvent{Store: Store}
+	switch r := req.(type) {
+	case *qdrant.UpsertPoints:
+		v.Operation = trusera.VectorUpsert
+		v.Collection = r.GetCollectionName()
+		v.Vectors = len(r.GetPoints())
+		if len(r.GetPoints()) > 0 {
+			v.Dimensions = dimensions(r.GetPoints()[0].GetVectors().GetVector())
+		}
+	case *qdrant.QueryPoints:
+		v.Operation = trusera.VectorQuery
+		v.Collection = r.GetCollectionName()
+		v.TopK = defaultLimit
+		if r.Limit != nil {
+			v.TopK = int(r.GetLimit())
+		}
+		v.Dimensions = len(r.GetQuery().GetNearest().GetDense().GetData())
+	case *qdrant.SearchPoints:
+		v.Operation = trusera.VectorQuery
+		v.Collection = r.GetCollectionName()
+		v.TopK = int(r.GetLimit())
+		v.Dimensions = len(r.GetVector())
+	case *qdrant.GetPoints:
+		v.Operation = trusera.VectorFetch
+		v.Collection = r.GetCollectionName()
+	case *qdrant.DeletePoints:
+		v.Operation = trusera.VectorDelete
+		v.Collection = r.GetCollectionName()
+		// Deletes by filter do not say how many points they match
+		v.Vectors = len(r.GetPoints().GetPoints().GetIds())
+	default:
+		return v, false
+	}
+	return v, true
+}
+
+// dimensions returns the length of a dense vector, or 0 for other kinds
+func dimensions(vec *qdrant.Vector) int {
+	if d := vec.GetDense(); d != nil {
+		return len(d.GetData())
+	}
+	return len(vec.GetData())
+}
@@ -0,0 +1,112 @@
+package qdrantgo
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/truseratest"
+	"github.com/qdrant/go-client/qdrant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// pointsServer answers queries with two points and rejects deletes
+type pointsServer struct {
+	qdrant.UnimplementedPointsServer
+}
+
+func (pointsServer) Upsert(context.Context, *qdrant.UpsertPoints) (*qdrant.PointsOperationResponse, error) {
+	return &qdrant.PointsOperationResponse{Result: &qdrant.UpdateResult{Status: qdrant.UpdateStatus_Completed}}, nil
+}
+
+func (pointsServer) Query(context.Context, *qdrant.QueryPoints) (*qdrant.QueryResponse, error) {
+	return &qdrant.QueryResponse{Result: []*qdrant.ScoredPoint{
+		{Id: qdrant.NewIDNum(1), Score: 0.9},
+		{Id: qdrant.NewIDNum(2), Score: 0.7},
+	}}, nil
+}
+
+func (pointsServer) Delete(context.Context, *qdrant.DeletePoints) (*qdrant.PointsOperationResponse, error) {
+	return nil, status.Error(codes.NotFound, "collection docs not found")
+}
+
+// newClient starts a fake Qdrant server and connects an instrumented client to it
+func newClient(t *testing.T, rec *truseratest.RecordingClient) *qdrant.Client {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	qdrant.RegisterPointsServer(srv, pointsServer{})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	client, err := qdrant.NewClient(&qdrant.Config{
+		Host:                   "passthrough:///bufnet",
+		SkipCompatibilityCheck: true,
+		PoolSize:               1,
+		GrpcOptions: []grpc.DialOption{
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+			DialOption(rec.Client),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestInterceptorRecordsOperations(t *testing.T) {
+	rec := truseratest.NewRecordingClient()
+	defer rec.Close()
+	client := newClient(t, rec)
+
+	ctx, span := rec.StartSpan(context.Background(), "answer")
+	if _, err := client.Upsert(ctx, &qdrant.UpsertPoints{CollectionName: "docs", Points: []*qdrant.PointStruct{
+		{Id: qdrant.NewIDNum(1), Vectors: qdrant.NewVectors(0.1, 0.2, 0.3)},
+		{Id: qdrant.NewIDNum(2), Vectors: qdrant.NewVectors(0.4, 0.5, 0.6)},
+	}}); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	limit := uint64(5)
+	if _, err := client.Query(ctx, &qdrant.QueryPoints{CollectionName: "docs", Query: qdrant.NewQuery(0.1, 0.2, 0.3), Limit: &limit}); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if _, err := client.Delete(ctx, &qdrant.DeletePoints{CollectionName: "docs", Points: qdrant.NewPointsSelector(qdrant.NewIDNum(1))}); err == nil {
+		t.Fatal("expected Delete to fail")
+	}
+	span.End()
+
+	events := rec.Events(truseratest.OfType(trusera.EventVectorStore))
+	if len(events) != 3 {
+		t.Fatalf("expected 3 vector store events, got %d", len(events))
+	}
+	upsert, query, del := events[0].Payload, events[1].Payload, events[2].Payload
+	if upsert["operation"] != "upsert" || upsert["collection"] != "docs" || upsert["vectors"] != 2 || upsert["dimensions"] != 3 {
+		t.Errorf("unexpected upsert payload: %v", upsert)
+	}
+	if query["operation"] != "query" || query["top_k"] != 5 || query["results"] != 2 || query["dimensions"] != 3 {
+		t.Errorf("unexpected query payload: %v", query)
+	}
+	if del["operation"] != "delete" || del["vectors"] != 1 || del["error"] == nil {
+		t.Errorf("unexpected delete payload: %v", del)
+	}
+	for _, e := range events {
+		if e.Metadata["parent_span_id"] != span.SpanID() || e.Payload["store"] != Store {
+			t.Errorf("expected %s linked to the span, got %v", e.Name, e.Metadata)
+		}
+	}
+}
+
+func TestInterceptorSkipsOtherRPCs(t *testing.T) {
+	if _, ok := describe(&qdrant.CreateCollection{CollectionName: "docs"}); ok {
+		t.Error("expected collection management to be skipped")
+	}
+	v, _ := describe(&qdrant.QueryPoints{CollectionName: "docs"})
+	if v.TopK != defaultLimit {
+		t.Errorf("expected Qdrant's default limit, got %d", v.TopK)
+	}
+}
@@ -0,0 +1,228 @@
+package trusera
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// DropPolicy determines what Track does when the event queue is full
+type DropPolicy string
+
+const (
+	DropOldest  DropPolicy = "drop-oldest" // Discard the oldest queued event to make room
+	DropNewest  DropPolicy = "drop-newest" // Discard the event being tracked
+	BlockOnFull DropPolicy = "block"       // Wait until a flush frees space
+)
+
+// WithMaxQueueSize bounds the number of events held in memory.
+// Zero or a negative value disables the bound.
+func WithMaxQueueSize(n int) Option {
+	return func(c *Client) {
+		c.maxQueueSize = n
+	}
+}
+
+// WithFlushWorkers sets how many goroutines deliver batches (default 2).
+// Batches wait in a channel sized to the pool, so while every worker is busy
+// events accumulate in the bounded queue and the drop policy applies. It
+// also bounds how many batches Flush and Close send at once.
+func WithFlushWorkers(n int) Option {
+	return func(c *Client) {
+		if n > 0 {
+			c.flushWorkers = n
+		}
+	}
+}
+
+// WithDropPolicy sets how Track behaves when the queue is full
+func WithDropPolicy(p DropPolicy) Option {
+	return func(c *Client) {
+		c.dropPolicy = p
+	}
+}
+
+// reserveSlot makes room for one event according to the drop policy.
+// It must be called with c.mu held and reports whether the event may be queued.
+func (c *Client) reserveSlot() bool {
+	if c.maxQueueSize <= 0 {
+		return true
+	}
+
+	for len(c.events) >= c.maxQueueSize {
+		switch c.dropPolicy {
+		case BlockOnFull:
+			if c.closed {
+				c.stats.dropped++
+				return false
+			}
+			c.requestFlush()
+			c.queueCond.Wait()
+		case DropNewest:
+			c.stats.dropped++
+			return false
+		default:
+			// Reslicing instead of shifting keeps this O(1) while Track
+			// outpaces delivery; append reclaims the space when it grows
+			c.events[0] = Event{}
+			c.events = c.events[1:]
+			c.stats.dropped++
+		}
+	}
+	return true
+}
+
+// queueEvent appends e to the queue. When dropping the oldest events has
+// left room at the front of the queue's array, the queue is moved back to
+// the start instead of growing, so a full queue does not allocate. It must
+// be called with c.mu held.
+func (c *Client) queueEvent(e Event) {
+	if n := len(c.events); n == cap(c.events) && n < cap(c.queueBuf) && sameArray(c.events, c.queueBuf) {
+		copy(c.queueBuf, c.events)
+		clear(c.queueBuf[n:])
+		c.events = c.queueBuf[:n]
+	}
+	c.events = append(c.events, e)
+	if !sameArray(c.events, c.queueBuf) {
+		c.queueBuf = c.events[:cap(c.events)]
+	}
+}
+
+// sameArray reports whether a and b end at the same element of the same
+// array, which for the queue means a is a window onto b
+func sameArray(a, b []Event) bool {
+	return cap(a) > 0 && cap(b) > 0 && &a[:cap(a)][cap(a)-1] == &b[:cap(b)][cap(b)-1]
+}
+
+// requestFlush asks the background flusher to flush without blocking
+func (c *Client) requestFlush() {
+	select {
+	case c.flushCh <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch hands queued events to the flush workers in batches of flushSize.
+// With fullOnly set, a trailing partial batch stays queued. It blocks while
+// the workers are busy and returns early when the client closes.
+func (c *Client) dispatch(fullOnly bool) {
+	for {
+		if c.limiter.delay() > 0 {
+			// Rate limited: leave events queued until the pause ends
+			return
+		}
+		batch := c.takeBatch(fullOnly)
+		if len(batch) == 0 {
+			return
+		}
+		select {
+		case c.batches <- batch:
+		case <-c.done:
+			c.requeueEvents(batch)
+			return
+		}
+	}
+}
+
+// takeBatch removes and returns up to flushSize events from the front of the
+// queue. With fullOnly set it returns nothing unless a full batch is queued or
+// the queue has reached its bound.
+func (c *Client) takeBatch(fullOnly bool) []Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := min(len(c.events), c.flushSize)
+	queueFull := c.maxQueueSize > 0 && len(c.events) >= c.maxQueueSize
+	if n == 0 || (fullOnly && n < c.flushSize && !queueFull) {
+		return nil
+	}
+
+	batch := make([]Event, n)
+	copy(batch, c.events)
+	c.events = append(c.events[:0], c.events[n:]...)
+	c.queueCond.Broadcast()
+	return batch
+}
+
+// flushWorker delivers batches until the client closes
+func (c *Client) flushWorker() {
+	defer c.wg.Done()
+	for {
+		select {
+		case batch := <-c.batches:
+			// ErrRateLimited itself means the batch was held back and requeued
+			// without contacting the API, which is not worth reporting
+			if err := c.deliver(context.Background(), batch); err != nil && err != ErrRateLimited {
+				c.reportError(fmt.Errorf("deliver %d events: %w", len(batch), err))
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// pipeline splits events into batches of flushSize and sends up to
+// flushWorkers of them at once. Once a batch fails no more are started. It
+// returns the first error with the events of the failed batches and of those
+// never started, both in queue order.
+func (c *Client) pipeline(events []Event, send func([]Event) error) (failed, unstarted []Event, err error) {
+	var batches [][]Event
+	for len(events) > 0 {
+		n := min(len(events), c.flushSize)
+		batches = append(batches, events[:n:n])
+		events = events[n:]
+	}
+
+	var (
+		wg      sync.WaitGroup
+		stop    atomic.Bool
+		errs    = make([]error, len(batches))
+		slots   = make(chan struct{}, c.flushWorkers)
+		started = len(batches)
+	)
+	for i, batch := range batches {
+		slots <- struct{}{}
+		if stop.Load() {
+			started = i
+			break
+		}
+		wg.Add(1)
+		go func(i int, batch []Event) {
+			defer wg.Done()
+			if errs[i] = send(batch); errs[i] != nil {
+				stop.Store(true)
+			}
+			<-slots
+		}(i, batch)
+	}
+	wg.Wait()
+
+	for i := 0; i < started; i++ {
+		if errs[i] != nil {
+			failed = append(failed, batches[i]...)
+			if err == nil {
+				err = errs[i]
+			}
+		}
+	}
+	for _, batch := range batches[started:] {
+		unstarted = append(unstarted, batch...)
+	}
+	return failed, unstarted, err
+}
+
+// reclaimBatches returns batches no worker picked up, and events waiting for
+// the immediate worker, to the queue so that shutdown can deliver them
+func (c *Client) reclaimBatches() {
+	for {
+		select {
+		case batch := <-c.batches:
+			c.requeueEvents(batch)
+		case e := <-c.immediate:
+			c.requeueEvents([]Event{e})
+		default:
+			return
+		}
+	}
+}
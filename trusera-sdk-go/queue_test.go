@@ -0,0 +1,249 @@
+package trusera
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDropOldest(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000), WithMaxQueueSize(3))
+	defer client.Close()
+
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		client.Track(NewEvent(EventToolCall, name))
+	}
+
+	events := queuedEvents(client)
+	if len(events) != 3 {
+		t.Fatalf("expected queue bounded at 3, got %d", len(events))
+	}
+	if events[0].Name != "c" || events[2].Name != "e" {
+		t.Errorf("expected newest events to be kept, got %s..%s", events[0].Name, events[2].Name)
+	}
+	if got := client.Stats().Dropped; got != 2 {
+		t.Errorf("expected 2 dropped, got %d", got)
+	}
+}
+
+func TestDropNewest(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000), WithMaxQueueSize(3), WithDropPolicy(DropNewest))
+	defer client.Close()
+
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		client.Track(NewEvent(EventToolCall, name))
+	}
+
+	events := queuedEvents(client)
+	if len(events) != 3 || events[2].Name != "c" {
+		t.Errorf("expected oldest events to be kept, got %v", events)
+	}
+	if got := client.Stats().Dropped; got != 2 {
+		t.Errorf("expected 2 dropped, got %d", got)
+	}
+}
+
+func TestBlockOnFullWaitsForFlush(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key",
+		WithBaseURL(server.URL),
+		WithBatchSize(1000),
+		WithMaxQueueSize(2),
+		WithDropPolicy(BlockOnFull),
+	)
+	defer client.Close()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			client.Track(NewEvent(EventToolCall, "tool"))
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Track blocked without the queue being drained")
+	}
+
+	if got := client.Stats().Dropped; got != 0 {
+		t.Errorf("expected no drops in block mode, got %d", got)
+	}
+}
+
+func TestBlockOnFullDropsAfterClose(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000), WithMaxQueueSize(1), WithDropPolicy(BlockOnFull))
+	client.Track(NewEvent(EventToolCall, "a"))
+	client.Close()
+
+	done := make(chan struct{})
+	go func() {
+		client.Track(NewEvent(EventToolCall, "b"))
+		client.Track(NewEvent(EventToolCall, "c"))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Track blocked on a closed client")
+	}
+}
+
+func TestUnboundedQueue(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000), WithMaxQueueSize(0))
+	defer client.Close()
+
+	for i := 0; i < 20; i++ {
+		client.Track(NewEvent(EventToolCall, "tool"))
+	}
+
+	if got := client.Stats().Queued; got != 20 {
+		t.Errorf("expected 20 queued, got %d", got)
+	}
+}
+
+// slowTransport blocks each send for delay and records peak concurrency
+type slowTransport struct {
+	delay    time.Duration
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	sent     int
+}
+
+func (s *slowTransport) Send(_ context.Context, b Batch) error {
+	s.mu.Lock()
+	s.inFlight++
+	s.peak = max(s.peak, s.inFlight)
+	s.mu.Unlock()
+
+	time.Sleep(s.delay)
+
+	s.mu.Lock()
+	s.inFlight--
+	s.sent += len(b.Events)
+	s.mu.Unlock()
+	return nil
+}
+
+func TestTrackDoesNotWaitOnDelivery(t *testing.T) {
+	st := &slowTransport{delay: 200 * time.Millisecond}
+	client := NewClient("test-key", WithTransport(st), WithBatchSize(1), WithMaxQueueSize(0))
+	defer client.Close()
+
+	before := runtime.NumGoroutine()
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		client.Track(NewEvent(EventToolCall, "tool"))
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("expected Track to return without waiting on delivery, took %v", elapsed)
+	}
+	if grown := runtime.NumGoroutine() - before; grown > 0 {
+		t.Errorf("expected no goroutines per flush, got %d more", grown)
+	}
+}
+
+func TestFlushWorkersBoundConcurrency(t *testing.T) {
+	st := &slowTransport{delay: 20 * time.Millisecond}
+	client := NewClient("test-key", WithTransport(st), WithBatchSize(1), WithFlushWorkers(3))
+
+	for i := 0; i < 30; i++ {
+		client.Track(NewEvent(EventToolCall, "tool"))
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.peak > 3 {
+		t.Errorf("expected at most 3 concurrent sends, got %d", st.peak)
+	}
+	if st.sent != 30 {
+		t.Errorf("expected all 30 events delivered by Close, got %d", st.sent)
+	}
+}
+
+// backlog queues n events without triggering a flush, as after an outage
+func backlog(client *Client, n int) {
+	events := make([]Event, n)
+	for i := range events {
+		events[i] = NewEvent(EventToolCall, "tool")
+		events[i].ID = newUUID()
+	}
+	client.requeueEvents(events)
+}
+
+func TestFlushSendsBacklogConcurrently(t *testing.T) {
+	st := &slowTransport{delay: 50 * time.Millisecond}
+	client := NewClient("test-key", WithTransport(st), WithBatchSize(10), WithFlushWorkers(4), WithFlushInterval(time.Hour))
+	defer client.Close()
+
+	backlog(client, 80)
+	start := time.Now()
+	if err := client.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.sent != 80 {
+		t.Errorf("expected 80 events delivered, got %d", st.sent)
+	}
+	if st.peak < 2 || st.peak > 4 {
+		t.Errorf("expected between 2 and 4 concurrent sends, got %d", st.peak)
+	}
+	if elapsed > 300*time.Millisecond {
+		t.Errorf("expected 8 batches to drain in about 2 rounds, took %v", elapsed)
+	}
+}
+
+// failingAfterTransport fails every send after the first n
+type failingAfterTransport struct {
+	mu   sync.Mutex
+	n    int
+	sent []Batch
+}
+
+func (f *failingAfterTransport) Send(_ context.Context, b Batch) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.sent) >= f.n {
+		return errors.New("backend down")
+	}
+	f.sent = append(f.sent, b)
+	return nil
+}
+
+func TestFlushStopsStartingBatchesAfterFailure(t *testing.T) {
+	ft := &failingAfterTransport{n: 1}
+	client := NewClient("test-key", WithTransport(ft), WithBatchSize(10), WithFlushWorkers(1),
+		WithFlushInterval(time.Hour), WithCircuitBreaker(100, time.Minute))
+	defer client.Close()
+
+	backlog(client, 50)
+	if err := client.Flush(); err == nil {
+		t.Fatal("expected Flush to fail")
+	}
+
+	stats := client.Stats()
+	if stats.Flushes != 2 {
+		t.Errorf("expected delivery to stop after the failed batch, got %d attempts", stats.Flushes)
+	}
+	if stats.Queued != 40 || stats.Dropped != 0 {
+		t.Errorf("expected 40 undelivered events kept, got %d queued and %d dropped", stats.Queued, stats.Dropped)
+	}
+}
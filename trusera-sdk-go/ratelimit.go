@@ -0,0 +1,80 @@
+package trusera
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Bounds of the spacing between batches that the client adopts after the
+// API answers 429
+const (
+	minRateLimitInterval = time.Second
+	maxRateLimitInterval = time.Minute
+)
+
+// rateLimiter paces delivery after the API rate limits the client. Each 429
+// pauses sending until the server's Retry-After (or X-RateLimit-Reset) has
+// passed and doubles the minimum spacing between batches; each successful
+// send halves it again until batches flow freely.
+type rateLimiter struct {
+	mu          sync.Mutex
+	interval    time.Duration // Minimum spacing between batches, zero when not limited
+	pausedUntil time.Time     // No batch is sent before this time
+}
+
+// delay returns how long to wait before the next batch may be sent
+func (r *rateLimiter) delay() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return max(time.Until(r.pausedUntil), 0)
+}
+
+// reserve reports whether a batch may be sent now and, if so, holds off the
+// following batch for the current interval
+func (r *rateLimiter) reserve() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(r.pausedUntil) {
+		return false
+	}
+	if r.interval > 0 {
+		r.pausedUntil = now.Add(r.interval)
+	}
+	return true
+}
+
+// observe adapts the send rate to the outcome of a delivery attempt
+func (r *rateLimiter) observe(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err == nil {
+		r.interval /= 2
+		if r.interval < minRateLimitInterval {
+			r.interval = 0
+		}
+		return
+	}
+	if !errors.Is(err, ErrRateLimited) {
+		return
+	}
+
+	r.interval = min(max(r.interval*2, minRateLimitInterval), maxRateLimitInterval)
+	wait := r.interval
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > wait {
+		wait = apiErr.RetryAfter
+	}
+	if until := time.Now().Add(wait); until.After(r.pausedUntil) {
+		r.pausedUntil = until
+	}
+}
+
+// RateLimitDelay returns how long the client will hold off sending events
+// because the API rate limited it. It is zero when delivery is not paused.
+func (c *Client) RateLimitDelay() time.Duration {
+	return c.limiter.delay()
+}
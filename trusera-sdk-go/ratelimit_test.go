@@ -0,0 +1,148 @@
+package trusera
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimitedBatchIsKeptAndPaused(t *testing.T) {
+	var requests, limited atomic.Int32
+	limited.Store(1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if limited.Load() == 1 {
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithBatchSize(1000))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "tool"))
+	if err := client.Flush(); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	if got := client.Stats().Queued; got != 1 {
+		t.Errorf("expected rate limited event to stay queued, got %d", got)
+	}
+	if d := client.RateLimitDelay(); d < 29*time.Second || d > 30*time.Second {
+		t.Errorf("expected a pause of about 30s from Retry-After, got %v", d)
+	}
+
+	// While paused, Flush must not contact the API
+	if err := client.Flush(); err != ErrRateLimited {
+		t.Errorf("expected ErrRateLimited while paused, got %v", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("expected 1 request while paused, got %d", got)
+	}
+
+	// Once the pause is over the queued event is delivered
+	limited.Store(0)
+	client.limiter.mu.Lock()
+	client.limiter.pausedUntil = time.Time{}
+	client.limiter.mu.Unlock()
+	if err := client.Flush(); err != nil {
+		t.Fatalf("Flush failed after pause: %v", err)
+	}
+
+	s := client.Stats()
+	if s.Flushed != 1 || s.Queued != 0 || s.Dropped != 0 {
+		t.Errorf("expected the event to be delivered once, got %+v", s)
+	}
+	if s.RateLimited != 1 {
+		t.Errorf("expected 1 rate limited attempt, got %d", s.RateLimited)
+	}
+}
+
+func TestRateLimitDoesNotTripBreaker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithBatchSize(1000), WithCircuitBreaker(1, time.Minute))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "tool"))
+	_ = client.Flush()
+
+	if got := client.BreakerState(); got != BreakerClosed {
+		t.Errorf("expected breaker to stay closed on 429, got %v", got)
+	}
+}
+
+func TestRateLimiterAdaptsInterval(t *testing.T) {
+	var r rateLimiter
+
+	r.observe(&APIError{StatusCode: http.StatusTooManyRequests})
+	if r.interval != minRateLimitInterval {
+		t.Errorf("expected interval %v after first 429, got %v", minRateLimitInterval, r.interval)
+	}
+	r.observe(&APIError{StatusCode: http.StatusTooManyRequests})
+	if r.interval != 2*minRateLimitInterval {
+		t.Errorf("expected interval to double, got %v", r.interval)
+	}
+	for i := 0; i < 10; i++ {
+		r.observe(ErrRateLimited)
+	}
+	if r.interval != maxRateLimitInterval {
+		t.Errorf("expected interval capped at %v, got %v", maxRateLimitInterval, r.interval)
+	}
+
+	r.observe(errors.New("connection refused"))
+	if r.interval != maxRateLimitInterval {
+		t.Errorf("expected other errors to leave the interval alone, got %v", r.interval)
+	}
+
+	r.observe(nil)
+	if r.interval != maxRateLimitInterval/2 {
+		t.Errorf("expected interval to halve on success, got %v", r.interval)
+	}
+	for i := 0; i < 10; i++ {
+		r.observe(nil)
+	}
+	if r.interval != 0 {
+		t.Errorf("expected interval to reset after repeated successes, got %v", r.interval)
+	}
+}
+
+func TestRateLimiterSpacesBatches(t *testing.T) {
+	r := rateLimiter{interval: time.Minute}
+	if !r.reserve() {
+		t.Fatal("expected first batch to be allowed")
+	}
+	if r.reserve() {
+		t.Error("expected second batch to wait for the interval")
+	}
+}
+
+func TestRetryAfterHeaders(t *testing.T) {
+	reset := time.Now().Add(45 * time.Second).Unix()
+	tests := []struct {
+		name   string
+		header http.Header
+		min    time.Duration
+		max    time.Duration
+	}{
+		{"seconds", http.Header{"Retry-After": {"12"}}, 12 * time.Second, 12 * time.Second},
+		{"http date", http.Header{"Retry-After": {time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)}}, 58 * time.Second, time.Minute},
+		{"reset delta", http.Header{"X-Ratelimit-Reset": {"20"}}, 20 * time.Second, 20 * time.Second},
+		{"reset timestamp", http.Header{"X-Ratelimit-Reset": {strconv.FormatInt(reset, 10)}}, 43 * time.Second, 45 * time.Second},
+		{"none", http.Header{}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryAfter(tt.header); got < tt.min || got > tt.max {
+				t.Errorf("expected %v..%v, got %v", tt.min, tt.max, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package trusera
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// errLocalMode is returned by calls that need the API in local mode
+var errLocalMode = errors.New("not available in local mode")
+
+// AgentInfo is an agent registered with Trusera, as returned by ListAgents
+type AgentInfo struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Framework string    `json:"framework"`
+	CreatedAt time.Time `json:"created_at"`
+	LastSeen  time.Time `json:"last_seen,omitempty"`
+}
+
+// AgentPage is one page of ListAgents results
+type AgentPage struct {
+	Agents []AgentInfo `json:"agents"`
+	// NextCursor is passed as ListOptions.Cursor to fetch the next page,
+	// empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ListOptions pages through list results
+type ListOptions struct {
+	Limit  int    // Results per page; zero uses the API default
+	Cursor string // NextCursor of the previous page; empty for the first
+}
+
+// RunInfo is a run started with StartRun, as returned by GetRun. Its ID is
+// the run's trace ID.
+type RunInfo struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	AgentID   string    `json:"agent_id"`
+	Status    string    `json:"status"` // "running", "ok" or "error"
+	Error     string    `json:"error,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at,omitempty"`
+	Events    []Event   `json:"events"` // Spans and events of the run, oldest first
+}
+
+// EventFilter selects events for QueryEvents. Zero fields do not filter.
+type EventFilter struct {
+	AgentID string
+	Type    EventType
+	RunID   string // Trace ID of a run
+	Since   time.Time
+	Until   time.Time
+	Limit   int    // Results per page; zero uses the API default
+	Cursor  string // NextCursor of the previous page; empty for the first
+}
+
+// EventPage is one page of QueryEvents results
+type EventPage struct {
+	Events []Event `json:"events"`
+	// NextCursor is passed as EventFilter.Cursor to fetch the next page,
+	// empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ListAgents fetches one page of the agents registered in the organization
+// from GET /v1/agents
+func (c *Client) ListAgents(ctx context.Context, opts ListOptions) (*AgentPage, error) {
+	q := url.Values{}
+	setPage(q, opts.Limit, opts.Cursor)
+	var page AgentPage
+	if err := c.getJSON(ctx, "/v1/agents", q, &page); err != nil {
+		return nil, fmt.Errorf("failed to list agents: %w", err)
+	}
+	return &page, nil
+}
+
+// GetRun fetches a run and its events from GET /v1/runs/{id}, where id is
+// the run's trace ID
+func (c *Client) GetRun(ctx context.Context, id string) (*RunInfo, error) {
+	if id == "" {
+		return nil, errors.New("run ID is required")
+	}
+	var run RunInfo
+	if err := c.getJSON(ctx, "/v1/runs/"+url.PathEscape(id), nil, &run); err != nil {
+		return nil, fmt.Errorf("failed to get run: %w", err)
+	}
+	return &run, nil
+}
+
+// QueryEvents fetches one page of events matching filter from GET
+// /v1/events, oldest first. Use EachEvent to walk every page.
+func (c *Client) QueryEvents(ctx context.Context, filter EventFilter) (*EventPage, error) {
+	q := url.Values{}
+	if filter.AgentID != "" {
+		q.Set("agent_id", filter.AgentID)
+	}
+	if filter.Type != "" {
+		q.Set("type", string(filter.Type))
+	}
+	if filter.RunID != "" {
+		q.Set("trace_id", filter.RunID)
+	}
+	if !filter.Since.IsZero() {
+		q.Set("since", filter.Since.UTC().Format(time.RFC3339Nano))
+	}
+	if !filter.Until.IsZero() {
+		q.Set("until", filter.Until.UTC().Format(time.RFC3339Nano))
+	}
+	setPage(q, filter.Limit, filter.Cursor)
+
+	var page EventPage
+	if err := c.getJSON(ctx, "/v1/events", q, &page); err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	return &page, nil
+}
+
+// EachEvent calls fn for every event matching filter, fetching pages until
+// the last one, fn returns an error or ctx is done
+func (c *Client) EachEvent(ctx context.Context, filter EventFilter, fn func(Event) error) error {
+	for {
+		page, err := c.QueryEvents(ctx, filter)
+		if err != nil {
+			return err
+		}
+		for _, e := range page.Events {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == "" || page.NextCursor == filter.Cursor {
+			return nil
+		}
+		filter.Cursor = page.NextCursor
+	}
+}
+
+// setPage adds pagination parameters to q
+func setPage(q url.Values, limit int, cursor string) {
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	if cursor != "" {
+		q.Set("cursor", cursor)
+	}
+}
+
+// getJSON sends an authenticated GET request for path and decodes the JSON
+// response into out
+func (c *Client) getJSON(ctx context.Context, path string, q url.Values, out any) error {
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	return c.doJSON(ctx, http.MethodGet, path, nil, out)
+}
+
+// doJSON sends an authenticated request for path with in, if not nil, as the
+// JSON body, and decodes the JSON response into out, if not nil
+func (c *Client) doJSON(ctx context.Context, method, path string, in, out any) error {
+	if c.sink != nil {
+		return errLocalMode
+	}
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.currentAPIKey())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return newAPIError(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+		return nil
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 32<<20)).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package trusera
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestListAgents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/agents" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("expected API key, got %q", r.Header.Get("Authorization"))
+		}
+		if q := r.URL.Query(); q.Get("limit") != "2" || q.Get("cursor") != "c1" {
+			t.Errorf("expected pagination parameters, got %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"agents": [
+			{"id": "a1", "name": "support", "framework": "langchaingo", "created_at": "2030-01-01T00:00:00Z"},
+			{"id": "a2", "name": "triage", "framework": "custom", "created_at": "2030-01-02T00:00:00Z"}
+		], "next_cursor": "c2"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	defer client.Close()
+
+	page, err := client.ListAgents(context.Background(), ListOptions{Limit: 2, Cursor: "c1"})
+	if err != nil {
+		t.Fatalf("ListAgents failed: %v", err)
+	}
+	if len(page.Agents) != 2 || page.Agents[0].Name != "support" || page.NextCursor != "c2" {
+		t.Errorf("expected two agents and a cursor, got %+v", page)
+	}
+	if want := time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC); !page.Agents[1].CreatedAt.Equal(want) {
+		t.Errorf("expected created_at %v, got %v", want, page.Agents[1].CreatedAt)
+	}
+}
+
+func TestGetRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/runs/trace-1" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "run not found"}`))
+			return
+		}
+		w.Write([]byte(`{"id": "trace-1", "name": "answer", "agent_id": "a1", "status": "ok",
+			"events": [{"id": "e1", "type": "span", "name": "answer"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	defer client.Close()
+
+	run, err := client.GetRun(context.Background(), "trace-1")
+	if err != nil {
+		t.Fatalf("GetRun failed: %v", err)
+	}
+	if run.Name != "answer" || run.Status != "ok" || len(run.Events) != 1 || run.Events[0].Type != EventSpan {
+		t.Errorf("expected the run with its span, got %+v", run)
+	}
+
+	_, err = client.GetRun(context.Background(), "missing")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected a 404 APIError, got %v", err)
+	}
+	if _, err := client.GetRun(context.Background(), ""); err == nil {
+		t.Error("expected error for empty run ID")
+	}
+}
+
+func TestQueryEventsFilterAndPages(t *testing.T) {
+	since := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("agent_id") != "a1" || q.Get("type") != "tool_call" || q.Get("trace_id") != "trace-1" ||
+			q.Get("since") != "2030-01-01T00:00:00Z" || q.Get("until") != "" {
+			t.Errorf("unexpected filter %s", r.URL.RawQuery)
+		}
+		switch q.Get("cursor") {
+		case "":
+			w.Write([]byte(`{"events": [{"id": "e1"}, {"id": "e2"}], "next_cursor": "p2"}`))
+		case "p2":
+			w.Write([]byte(`{"events": [{"id": "e3"}]}`))
+		default:
+			t.Errorf("unexpected cursor %q", q.Get("cursor"))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	defer client.Close()
+
+	filter := EventFilter{AgentID: "a1", Type: EventToolCall, RunID: "trace-1", Since: since}
+	page, err := client.QueryEvents(context.Background(), filter)
+	if err != nil {
+		t.Fatalf("QueryEvents failed: %v", err)
+	}
+	if len(page.Events) != 2 || page.NextCursor != "p2" {
+		t.Errorf("expected the first page, got %+v", page)
+	}
+
+	var ids []string
+	err = client.EachEvent(context.Background(), filter, func(e Event) error {
+		ids = append(ids, e.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("EachEvent failed: %v", err)
+	}
+	if len(ids) != 3 || ids[2] != "e3" {
+		t.Errorf("expected events from both pages, got %v", ids)
+	}
+
+	stop := errors.New("stop")
+	err = client.EachEvent(context.Background(), filter, func(Event) error { return stop })
+	if !errors.Is(err, stop) {
+		t.Errorf("expected the callback error, got %v", err)
+	}
+}
+
+func TestReadAPILocalMode(t *testing.T) {
+	client := NewClient("", WithLocalSink(filepath.Join(t.TempDir(), "events.jsonl")))
+	defer client.Close()
+
+	if _, err := client.ListAgents(context.Background(), ListOptions{}); !errors.Is(err, errLocalMode) {
+		t.Errorf("expected local mode error, got %v", err)
+	}
+	if _, err := client.QueryEvents(context.Background(), EventFilter{}); !errors.Is(err, errLocalMode) {
+		t.Errorf("expected local mode error, got %v", err)
+	}
+}
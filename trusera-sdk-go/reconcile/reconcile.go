@@ -0,0 +1,187 @@
+// Package reconcile compares the models and tools declared in an agent's
+// AI-BOM with the ones its instrumentation observes at runtime, and reports
+// drift when the agent calls a model or tool the BOM does not declare:
+//
+//	r := reconcile.New(builder.Build())
+//	client := trusera.NewClient(apiKey, trusera.WithEventProcessor(r.Process))
+//	r.Attach(client)
+//
+// Each undeclared model or tool is reported once as a trusera.EventDrift
+// event, and the events that used it carry "bom_undeclared" metadata so
+// policies can act on them. Report summarizes the drift seen so far.
+package reconcile
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/bom"
+)
+
+// Kinds of drift, the names of drift events
+const (
+	UndeclaredModel = "undeclared_model"
+	UndeclaredTool  = "undeclared_tool"
+)
+
+// linkedMetadata is copied from the observed event to its drift event, so
+// the drift is attributed to the same agent and trace
+var linkedMetadata = []string{"agent_id", "trace_id", "span_id", "parent_span_id"}
+
+// Report summarizes drift between the BOM and runtime behavior
+type Report struct {
+	UndeclaredModels []string // Observed but not declared
+	UndeclaredTools  []string
+	UnusedModels     []string // Declared but not observed yet
+	UnusedTools      []string
+}
+
+// Drifted reports whether anything undeclared was observed
+func (r Report) Drifted() bool {
+	return len(r.UndeclaredModels) > 0 || len(r.UndeclaredTools) > 0
+}
+
+// Reconciler tracks the models and tools observed against a BOM. It is safe
+// for concurrent use.
+type Reconciler struct {
+	agent  string
+	serial string
+
+	mu         sync.Mutex
+	models     map[string]bool // Declared model names, lower-cased, to whether observed
+	tools      map[string]bool
+	undeclared map[string]map[string]bool // By kind, observed undeclared names
+	tracker    trusera.Tracker
+}
+
+// New creates a reconciler for the models and tools declared in b
+func New(b *bom.BOM) *Reconciler {
+	r := &Reconciler{
+		agent:      b.AgentName,
+		serial:     b.SerialNumber,
+		models:     map[string]bool{},
+		tools:      map[string]bool{},
+		undeclared: map[string]map[string]bool{UndeclaredModel: {}, UndeclaredTool: {}},
+	}
+	for _, m := range b.Models {
+		r.models[strings.ToLower(m.Name)] = false
+	}
+	for _, t := range b.Tools {
+		r.tools[t.Name] = false
+	}
+	return r
+}
+
+// Attach sends drift events to t, such as a *trusera.Client. Without it,
+// drift is only recorded for Report.
+func (r *Reconciler) Attach(t trusera.Tracker) {
+	r.mu.Lock()
+	r.tracker = t
+	r.mu.Unlock()
+}
+
+// Process is a trusera.EventProcessor that checks the model of LLM and
+// embedding events and the name of tool call events against the BOM. Every
+// event is kept.
+func (r *Reconciler) Process(e trusera.Event) (trusera.Event, bool) {
+	switch e.Type {
+	case trusera.EventLLMInvoke, trusera.EventEmbedding:
+		if model, _ := e.Payload["model"].(string); model != "" && !r.observeModel(model) {
+			e = r.drift(e, UndeclaredModel, model)
+		}
+	case trusera.EventToolCall:
+		if e.Name != "" && !r.observeTool(e.Name) {
+			e = r.drift(e, UndeclaredTool, e.Name)
+		}
+	}
+	return e, true
+}
+
+// observeModel records a model call and reports whether it is declared. A
+// declared name also covers dated snapshots of it, so "gpt-4o" matches
+// "gpt-4o-2024-08-06" as returned by the API.
+func (r *Reconciler) observeModel(model string) bool {
+	name := strings.ToLower(model)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.models[name]; ok {
+		r.models[name] = true
+		return true
+	}
+	for declared := range r.models {
+		if strings.HasPrefix(name, declared+"-") {
+			r.models[declared] = true
+			return true
+		}
+	}
+	return false
+}
+
+// observeTool records a tool call and reports whether it is declared
+func (r *Reconciler) observeTool(tool string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tools[tool]; ok {
+		r.tools[tool] = true
+		return true
+	}
+	return false
+}
+
+// drift marks e as undeclared, emitting a drift event the first time name
+// is seen
+func (r *Reconciler) drift(e trusera.Event, kind, name string) trusera.Event {
+	r.mu.Lock()
+	first := !r.undeclared[kind][name]
+	r.undeclared[kind][name] = true
+	tracker := r.tracker
+	r.mu.Unlock()
+
+	if first && tracker != nil {
+		d := trusera.NewEvent(trusera.EventDrift, kind).
+			WithPayload("name", name).
+			WithPayload("observed_event", string(e.Type))
+		if provider, ok := e.Payload["provider"].(string); ok && provider != "" {
+			d = d.WithPayload("provider", provider)
+		}
+		if r.agent != "" {
+			d = d.WithPayload("bom_agent", r.agent)
+		}
+		if r.serial != "" {
+			d = d.WithPayload("bom_serial", r.serial)
+		}
+		for _, k := range linkedMetadata {
+			if v, ok := e.Metadata[k]; ok {
+				d = d.WithMetadata(k, v)
+			}
+		}
+		tracker.Track(d)
+	}
+	return e.WithMetadata("bom_undeclared", kind)
+}
+
+// Report returns the drift observed so far, with names sorted
+func (r *Reconciler) Report() Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Report{
+		UndeclaredModels: keys(r.undeclared[UndeclaredModel], nil),
+		UndeclaredTools:  keys(r.undeclared[UndeclaredTool], nil),
+		UnusedModels:     keys(r.models, func(observed bool) bool { return !observed }),
+		UnusedTools:      keys(r.tools, func(observed bool) bool { return !observed }),
+	}
+}
+
+// keys returns the sorted keys of m whose values pass keep, or all keys
+func keys(m map[string]bool, keep func(bool) bool) []string {
+	var out []string
+	for k, v := range m {
+		if keep == nil || keep(v) {
+			out = append(out, k)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,82 @@
+package reconcile
+
+import (
+	"reflect"
+	"testing"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/bom"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/truseratest"
+)
+
+func declared() *bom.BOM {
+	return bom.NewBuilder("support-bot").
+		AddModel(bom.Model{Name: "gpt-4o", Provider: "openai"}).
+		AddModel(bom.Model{Name: "text-embedding-3-small", Provider: "openai"}).
+		AddTool(bom.Tool{Name: "search"}).
+		AddTool(bom.Tool{Name: "refund"}).
+		Build()
+}
+
+func TestReconcilerEmitsDrift(t *testing.T) {
+	r := New(declared())
+	client := truseratest.NewRecordingClient(trusera.WithEventProcessor(r.Process))
+	defer client.Close()
+	r.Attach(client)
+
+	client.Track(trusera.NewEvent(trusera.EventLLMInvoke, "chat").WithPayload("model", "gpt-4o-2024-08-06"))
+	client.Track(trusera.NewEvent(trusera.EventToolCall, "search"))
+	for i := 0; i < 2; i++ {
+		client.Track(trusera.NewEvent(trusera.EventLLMInvoke, "chat").
+			WithPayload("provider", "anthropic").
+			WithPayload("model", "claude-3-5-sonnet").
+			WithMetadata("trace_id", "trace-1"))
+	}
+	client.Track(trusera.NewEvent(trusera.EventToolCall, "shell"))
+
+	drift := client.Events(truseratest.OfType(trusera.EventDrift))
+	if len(drift) != 2 {
+		t.Fatalf("expected 2 drift events, got %d: %+v", len(drift), drift)
+	}
+	if d := drift[0]; d.Name != UndeclaredModel || d.Payload["name"] != "claude-3-5-sonnet" ||
+		d.Payload["provider"] != "anthropic" || d.Payload["bom_agent"] != "support-bot" || d.Metadata["trace_id"] != "trace-1" {
+		t.Errorf("expected an undeclared model drift event, got %+v", d)
+	}
+	if d := drift[1]; d.Name != UndeclaredTool || d.Payload["name"] != "shell" {
+		t.Errorf("expected an undeclared tool drift event, got %+v", d)
+	}
+
+	marked := client.Events(truseratest.OfType(trusera.EventToolCall), truseratest.Named("shell"))
+	if len(marked) != 1 || marked[0].Metadata["bom_undeclared"] != UndeclaredTool {
+		t.Errorf("expected the tool call marked undeclared, got %+v", marked)
+	}
+	if ok := client.Events(truseratest.Named("search")); ok[0].Metadata["bom_undeclared"] != nil {
+		t.Errorf("expected declared tool calls unmarked, got %v", ok[0].Metadata)
+	}
+}
+
+func TestReconcilerReport(t *testing.T) {
+	r := New(declared())
+	if r.Report().Drifted() {
+		t.Error("expected no drift before any events")
+	}
+	r.Process(trusera.NewEvent(trusera.EventLLMInvoke, "chat").WithPayload("model", "GPT-4o"))
+	r.Process(trusera.NewEvent(trusera.EventEmbedding, "embed").WithPayload("model", "text-embedding-ada-002"))
+	r.Process(trusera.NewEvent(trusera.EventToolCall, "search"))
+	r.Process(trusera.NewEvent(trusera.EventToolCall, "shell"))
+	r.Process(trusera.NewEvent(trusera.EventAPICall, "GET /"))
+
+	got := r.Report()
+	want := Report{
+		UndeclaredModels: []string{"text-embedding-ada-002"},
+		UndeclaredTools:  []string{"shell"},
+		UnusedModels:     []string{"text-embedding-3-small"},
+		UnusedTools:      []string{"refund"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+	if !got.Drifted() {
+		t.Error("expected drift")
+	}
+}
@@ -0,0 +1,198 @@
+package trusera
+
+import (
+	"encoding/json"
+	"regexp"
+)
+
+// Redactor scrubs sensitive data from an event before it is queued for sending
+type Redactor interface {
+	Redact(Event) Event
+}
+
+// RedactorFunc adapts a function to the Redactor interface
+type RedactorFunc func(Event) Event
+
+// Redact calls f(e)
+func (f RedactorFunc) Redact(e Event) Event {
+	return f(e)
+}
+
+// WithRedactor adds a redactor applied to every tracked event. Redactors run
+// in the order they are added.
+func WithRedactor(r Redactor) Option {
+	return func(c *Client) {
+		if r != nil {
+			c.redactors = append(c.redactors, r)
+		}
+	}
+}
+
+// Detector finds one kind of sensitive value in text
+type Detector struct {
+	Name    string
+	Pattern *regexp.Regexp
+	// Validate, if set, filters pattern matches (e.g. a Luhn check for card numbers)
+	Validate func(match string) bool
+}
+
+// Built-in detectors
+var (
+	DetectBearerToken = Detector{
+		Name:    "bearer_token",
+		Pattern: regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`),
+	}
+	DetectAPIKey = Detector{
+		Name: "api_key",
+		Pattern: regexp.MustCompile(`\b(?:sk-(?:ant-|proj-)?[A-Za-z0-9_\-]{20,}|tsk_[A-Za-z0-9_\-]{16,}|` +
+			`AKIA[0-9A-Z]{16}|gh[pousr]_[A-Za-z0-9]{36,}|xox[abposr]-[A-Za-z0-9\-]{10,}|AIza[0-9A-Za-z_\-]{35})\b`),
+	}
+	DetectEmail = Detector{
+		Name:    "email",
+		Pattern: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+	}
+	DetectCreditCard = Detector{
+		Name:     "credit_card",
+		Pattern:  regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`),
+		Validate: luhnValid,
+	}
+)
+
+// PatternRedactor replaces detector matches in event names, payloads and
+// metadata with "[REDACTED:<name>]"
+type PatternRedactor struct {
+	detectors []Detector
+}
+
+// NewPatternRedactor creates a redactor from detectors, applied in order
+func NewPatternRedactor(detectors ...Detector) *PatternRedactor {
+	return &PatternRedactor{detectors: detectors}
+}
+
+// DefaultRedactor returns a redactor using all built-in detectors
+func DefaultRedactor() *PatternRedactor {
+	return NewPatternRedactor(DetectBearerToken, DetectAPIKey, DetectEmail, DetectCreditCard)
+}
+
+// Redact returns a copy of e with sensitive values replaced
+func (r *PatternRedactor) Redact(e Event) Event {
+	e.Name = r.redactString(e.Name)
+	if e.Payload != nil {
+		e.Payload = r.redactValue(e.Payload).(map[string]any)
+	}
+	if e.Metadata != nil {
+		e.Metadata = r.redactValue(e.Metadata).(map[string]any)
+	}
+	return e
+}
+
+// redactValue walks v, returning a copy with all strings redacted. Values of
+// types it does not walk, such as structs and typed slices and maps, are
+// redacted as their JSON encoding, decoded the way the API will see them.
+func (r *PatternRedactor) redactValue(v any) any {
+	switch val := v.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, json.Number:
+		return v
+	case string:
+		return r.redactString(val)
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[k] = r.redactValue(item)
+		}
+		return out
+	case map[string]string:
+		out := make(map[string]string, len(val))
+		for k, item := range val {
+			out[k] = r.redactString(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = r.redactValue(item)
+		}
+		return out
+	case []string:
+		out := make([]string, len(val))
+		for i, item := range val {
+			out[i] = r.redactString(item)
+		}
+		return out
+	case []map[string]any:
+		// Chat message lists
+		out := make([]map[string]any, len(val))
+		for i, item := range val {
+			out[i], _ = r.redactValue(item).(map[string]any)
+		}
+		return out
+	case json.RawMessage:
+		var decoded any
+		if err := json.Unmarshal(val, &decoded); err != nil {
+			return v
+		}
+		data, err := json.Marshal(r.redactValue(decoded))
+		if err != nil {
+			return v
+		}
+		return json.RawMessage(data)
+	case []byte:
+		return []byte(r.redactString(string(val)))
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			// The event cannot be sent either, see ErrUnencodable
+			return v
+		}
+		var decoded any
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			return v
+		}
+		return r.redactValue(decoded)
+	}
+}
+
+func (r *PatternRedactor) redactString(s string) string {
+	for _, d := range r.detectors {
+		replacement := "[REDACTED:" + d.Name + "]"
+		s = d.Pattern.ReplaceAllStringFunc(s, func(match string) string {
+			if d.Validate != nil && !d.Validate(match) {
+				return match
+			}
+			return replacement
+		})
+	}
+	return s
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		ch := s[i]
+		if ch < '0' || ch > '9' {
+			continue
+		}
+		d := int(ch - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
+
+// redact applies all configured redactors to e
+func (c *Client) redact(e Event) Event {
+	for _, r := range c.redactors {
+		e = r.Redact(e)
+	}
+	if rs := c.remote.Load(); rs != nil && rs.redactor != nil {
+		e = rs.redactor.Redact(e)
+	}
+	return e
+}
@@ -0,0 +1,81 @@
+package trusera
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// relayHost is the placeholder host of requests sent over a relay's Unix
+// socket
+const relayHost = "http://trusera-relay"
+
+// WithRelay sends event batches to a local relay, such as trusera-agent
+// running as a sidecar or DaemonSet, instead of to the API directly, so
+// buffering, credentials and egress policy live in one place. addr is a Unix
+// socket, "unix:///var/run/trusera/trusera.sock", or an HTTP address,
+// "http://localhost:4318". Without this option the address in TRUSERA_RELAY
+// is used, if set. The relay receives batches in the API's format; other API
+// calls, such as agent registration and BOM uploads, still go to the base
+// URL. WithStreaming and custom transports take precedence over a relay.
+func WithRelay(addr string) Option {
+	return func(c *Client) {
+		c.relayAddr = addr
+	}
+}
+
+// installRelayTransport makes a relay at c.relayAddr, or TRUSERA_RELAY, the
+// event transport
+func (c *Client) installRelayTransport() error {
+	if c.relayAddr == "" {
+		c.relayAddr = os.Getenv("TRUSERA_RELAY")
+	}
+	if c.relayAddr == "" || c.transport != nil || c.streaming {
+		return nil
+	}
+	baseURL, hc, err := relayClient(c.relayAddr, c.httpClient.Timeout)
+	if err != nil {
+		return err
+	}
+	c.transport = &HTTPTransport{
+		BaseURL:     baseURL,
+		HTTPClient:  hc,
+		Compression: c.compression,
+		Signer:      c.signer,
+		keyFunc:     c.currentAPIKey,
+	}
+	return nil
+}
+
+// relayClient returns the base URL and HTTP client for a relay address
+func relayClient(addr string, timeout time.Duration) (string, *http.Client, error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid relay address: %w", err)
+	}
+	switch u.Scheme {
+	case "unix":
+		path := u.Path
+		if path == "" {
+			return "", nil, fmt.Errorf("relay address %q has no socket path", addr)
+		}
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", path)
+			},
+			MaxIdleConnsPerHost: 4,
+		}
+		return relayHost, &http.Client{Timeout: timeout, Transport: transport}, nil
+	case "http", "https":
+		if u.Host == "" {
+			return "", nil, fmt.Errorf("relay address %q has no host", addr)
+		}
+		return u.Scheme + "://" + u.Host + u.Path, &http.Client{Timeout: timeout}, nil
+	default:
+		return "", nil, fmt.Errorf("relay address %q must use unix, http or https", addr)
+	}
+}
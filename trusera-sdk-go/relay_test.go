@@ -0,0 +1,92 @@
+package trusera
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRelayOverUnixSocket(t *testing.T) {
+	var mu sync.Mutex
+	var got []Batch
+	path := filepath.Join(t.TempDir(), "trusera.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	relay := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/events" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var b Batch
+		json.NewDecoder(r.Body).Decode(&b)
+		mu.Lock()
+		got = append(got, b)
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	})}
+	go relay.Serve(ln)
+	defer relay.Close()
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/events" {
+			t.Error("expected events to go to the relay, not the API")
+		}
+	}))
+	defer api.Close()
+
+	client := NewClient("", WithBaseURL(api.URL), WithRelay("unix://"+path), WithAgentID("agent-1"))
+	client.Track(NewEvent(EventToolCall, "search"))
+	if err := client.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) == 0 || got[0].AgentID != "agent-1" || got[0].Events[0].Name != "search" {
+		t.Errorf("expected the batch at the relay, got %+v", got)
+	}
+}
+
+func TestRelayFromEnvOverHTTP(t *testing.T) {
+	received := make(chan string, 4)
+	relay := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.URL.Path
+	}))
+	defer relay.Close()
+	t.Setenv("TRUSERA_RELAY", relay.URL)
+
+	client := NewClient("test-key", WithBaseURL("http://127.0.0.1:1"))
+	client.Track(NewEvent(EventToolCall, "search"))
+	client.Close()
+
+	select {
+	case path := <-received:
+		if path != "/v1/events" {
+			t.Errorf("expected a batch posted to /v1/events, got %s", path)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected the batch at the relay from TRUSERA_RELAY")
+	}
+}
+
+func TestRelayClientAddresses(t *testing.T) {
+	base, hc, err := relayClient("unix:///var/run/trusera/trusera.sock", time.Second)
+	if err != nil || base != relayHost || hc.Timeout != time.Second {
+		t.Errorf("expected a Unix socket relay, got %q, %v", base, err)
+	}
+	if base, _, err := relayClient("http://localhost:4318", time.Second); err != nil || base != "http://localhost:4318" {
+		t.Errorf("expected an HTTP relay, got %q, %v", base, err)
+	}
+	for _, addr := range []string{"unix://", "tcp://localhost:4318", "http://", "%zz"} {
+		if _, _, err := relayClient(addr, time.Second); err == nil {
+			t.Errorf("expected error for %q", addr)
+		}
+	}
+}
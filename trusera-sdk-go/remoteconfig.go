@@ -0,0 +1,185 @@
+package trusera
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// RemoteConfig is configuration pushed by the fleet API to running agents.
+// Unset fields leave the locally configured behavior in place.
+type RemoteConfig struct {
+	Version              string                `json:"version"`
+	SampleRate           *float64              `json:"sample_rate,omitempty"`
+	SampleRates          map[EventType]float64 `json:"sample_rates,omitempty"`
+	FlushIntervalSeconds int                   `json:"flush_interval_seconds,omitempty"`
+	RedactionRules       []RedactionRule       `json:"redaction_rules,omitempty"`
+	LogLevel             string                `json:"log_level,omitempty"`
+	// EventPolicies run after the client's own policies, see EventPolicy
+	EventPolicies []EventPolicy `json:"event_policies,omitempty"`
+	// Egress replaces the client's egress policy, see EgressPolicy
+	Egress *EgressPolicy `json:"egress,omitempty"`
+}
+
+// RedactionRule is a remotely managed redaction pattern
+type RedactionRule struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+}
+
+// remoteSettings is the compiled form of a RemoteConfig
+type remoteSettings struct {
+	config         RemoteConfig
+	defaultSampler SamplerFunc
+	samplers       map[EventType]SamplerFunc
+	redactor       Redactor
+	policies       []compiledPolicy
+	egress         *egressRules
+}
+
+// WithRemoteConfig polls the fleet config endpoint at the given interval once
+// fleet registration succeeds, applying sampling, flush interval, redaction,
+// event policy, egress policy and log level changes without a redeploy
+func WithRemoteConfig(pollInterval time.Duration) Option {
+	return func(c *Client) {
+		c.configPollInterval = pollInterval
+	}
+}
+
+// RemoteConfig returns the most recently applied remote configuration
+func (c *Client) RemoteConfig() (RemoteConfig, bool) {
+	rs := c.remote.Load()
+	if rs == nil {
+		return RemoteConfig{}, false
+	}
+	return rs.config, true
+}
+
+func (c *Client) configLoop() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.configPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.pollRemoteConfig(context.Background()); err != nil {
+			c.logf(LogWarn, "fleet config poll failed: %v", err)
+			c.reportError(fmt.Errorf("fleet config poll: %w", err))
+		}
+		select {
+		case <-ticker.C:
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// pollRemoteConfig fetches the fleet config and applies it if it changed
+func (c *Client) pollRemoteConfig(ctx context.Context) error {
+	c.mu.Lock()
+	fleetID := c.fleetAgentID
+	c.mu.Unlock()
+	if fleetID == "" {
+		return nil
+	}
+
+	url := fmt.Sprintf("%s/api/v1/fleet/%s/config", c.baseURL, fleetID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.currentAPIKey())
+	if current, ok := c.RemoteConfig(); ok && current.Version != "" {
+		req.Header.Set("If-None-Match", current.Version)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+	if resp.StatusCode >= 400 {
+		return newAPIError(resp)
+	}
+
+	var result struct {
+		Data RemoteConfig `json:"data"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return c.applyRemoteConfig(result.Data)
+}
+
+// applyRemoteConfig compiles and installs a remote configuration
+func (c *Client) applyRemoteConfig(cfg RemoteConfig) error {
+	rs := &remoteSettings{config: cfg}
+
+	if cfg.SampleRate != nil {
+		rs.defaultSampler = Probabilistic(*cfg.SampleRate)
+	}
+	if len(cfg.SampleRates) > 0 {
+		rs.samplers = make(map[EventType]SamplerFunc, len(cfg.SampleRates))
+		for t, rate := range cfg.SampleRates {
+			rs.samplers[t] = Probabilistic(rate)
+		}
+	}
+
+	if len(cfg.RedactionRules) > 0 {
+		detectors := make([]Detector, 0, len(cfg.RedactionRules))
+		for _, rule := range cfg.RedactionRules {
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return fmt.Errorf("invalid redaction rule %q: %w", rule.Name, err)
+			}
+			detectors = append(detectors, Detector{Name: rule.Name, Pattern: re})
+		}
+		rs.redactor = NewPatternRedactor(detectors...)
+	}
+
+	if len(cfg.EventPolicies) > 0 {
+		policies, err := compilePolicies(cfg.EventPolicies)
+		if err != nil {
+			return err
+		}
+		rs.policies = policies
+	}
+
+	if cfg.Egress != nil {
+		rs.egress = compileEgress(*cfg.Egress)
+	}
+
+	if cfg.LogLevel != "" {
+		if level, ok := ParseLogLevel(cfg.LogLevel); ok {
+			c.logLevel.Store(int32(level))
+		}
+	}
+
+	if cfg.FlushIntervalSeconds > 0 {
+		c.ticker.Reset(time.Duration(cfg.FlushIntervalSeconds) * time.Second)
+	}
+
+	c.remote.Store(rs)
+	c.logf(LogInfo, "applied fleet config version %q", cfg.Version)
+	return nil
+}
+
+// remoteSampler returns the remotely configured sampler for t, if any
+func (c *Client) remoteSampler(t EventType) SamplerFunc {
+	rs := c.remote.Load()
+	if rs == nil {
+		return nil
+	}
+	if s, ok := rs.samplers[t]; ok {
+		return s
+	}
+	return rs.defaultSampler
+}
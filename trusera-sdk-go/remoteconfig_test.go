@@ -0,0 +1,120 @@
+package trusera
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newFleetServer serves fleet registration and the given config document
+func newFleetServer(t *testing.T, config string) (*httptest.Server, *int) {
+	var mu sync.Mutex
+	configPolls := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/fleet/register":
+			json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"id": "fleet-1"}})
+		case "/api/v1/fleet/fleet-1/config":
+			mu.Lock()
+			configPolls++
+			mu.Unlock()
+			if r.Header.Get("If-None-Match") == "v1" {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Write([]byte(`{"data":` + config + `}`))
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	return server, &configPolls
+}
+
+func TestRemoteConfigApplied(t *testing.T) {
+	server, _ := newFleetServer(t, `{
+		"version": "v1",
+		"sample_rates": {"api_call": 0},
+		"flush_interval_seconds": 5,
+		"redaction_rules": [{"name": "ticket", "pattern": "TICKET-[0-9]+"}],
+		"log_level": "error"
+	}`)
+	defer server.Close()
+
+	client := NewClient("test-key",
+		WithBaseURL(server.URL),
+		WithAutoRegister(),
+		WithBatchSize(1000),
+		WithRemoteConfig(time.Hour),
+	)
+	defer client.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, ok := client.RemoteConfig(); ok || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cfg, ok := client.RemoteConfig()
+	if !ok || cfg.Version != "v1" {
+		t.Fatalf("expected remote config v1 to be applied, got %+v", cfg)
+	}
+	if LogLevel(client.logLevel.Load()) != LogError {
+		t.Errorf("expected log level error, got %d", client.logLevel.Load())
+	}
+
+	client.Track(NewEvent(EventAPICall, "get"))
+	client.Track(NewEvent(EventToolCall, "close TICKET-42"))
+
+	events := queuedEvents(client)
+	if len(events) != 1 {
+		t.Fatalf("expected api_call to be sampled out remotely, got %d events", len(events))
+	}
+	if events[0].Name != "close [REDACTED:ticket]" {
+		t.Errorf("expected remote redaction rule applied, got %s", events[0].Name)
+	}
+}
+
+func TestRemoteConfigNotModified(t *testing.T) {
+	server, polls := newFleetServer(t, `{"version": "v1", "log_level": "warn"}`)
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	defer client.Close()
+	client.fleetAgentID = "fleet-1"
+
+	ctx := context.Background()
+	if err := client.pollRemoteConfig(ctx); err != nil {
+		t.Fatalf("first poll failed: %v", err)
+	}
+	client.logLevel.Store(int32(LogDebug))
+
+	if err := client.pollRemoteConfig(ctx); err != nil {
+		t.Fatalf("second poll failed: %v", err)
+	}
+	if *polls != 2 {
+		t.Errorf("expected 2 polls, got %d", *polls)
+	}
+	if LogLevel(client.logLevel.Load()) != LogDebug {
+		t.Error("expected 304 response to leave settings untouched")
+	}
+}
+
+func TestApplyRemoteConfigInvalidRule(t *testing.T) {
+	client := NewClient("test-key")
+	defer client.Close()
+
+	err := client.applyRemoteConfig(RemoteConfig{RedactionRules: []RedactionRule{{Name: "bad", Pattern: "("}}})
+	if err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+	if _, ok := client.RemoteConfig(); ok {
+		t.Error("expected invalid config not to be installed")
+	}
+}
@@ -0,0 +1,190 @@
+package trusera
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+)
+
+// Replay re-ingests an event dump, such as a local mode file or a spilled
+// batch file, and returns the number of events delivered. The source may mix
+// local sink records (only "event" records are replayed, under the agent ID
+// they were recorded with), bare events and JSON arrays of events, separated
+// by whitespace or newlines.
+//
+// Events are sent as they were recorded, keeping their IDs and original
+// timestamps, without sampling, redaction or enrichment, which were applied
+// when they were first tracked. Because event IDs are kept, batches carry the
+// same idempotency keys on every replay attempt. Replay waits out rate limits
+// and stops at the first other delivery error.
+//
+// A spill file encrypted with WithSpillEncryption is decrypted with the
+// client's spill key. The file is authenticated by its name, so source must
+// be the *os.File it was written to, or another reader with a Name method
+// returning that name, such as a file moved to the quarantine subdirectory.
+func (c *Client) Replay(ctx context.Context, source io.Reader) (int, error) {
+	source, err := c.openReplaySource(source)
+	if err != nil {
+		return 0, err
+	}
+	dec := json.NewDecoder(source)
+	var (
+		sent    int
+		agentID string
+		pending []Event
+	)
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		n, err := c.replayBatch(ctx, agentID, pending)
+		sent += n
+		pending = nil
+		return err
+	}
+
+	for n := 1; ; n++ {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return sent, fmt.Errorf("failed to decode record %d: %w", n, err)
+		}
+		recAgent, events, err := decodeReplayRecord(raw)
+		if err != nil {
+			return sent, fmt.Errorf("failed to decode record %d: %w", n, err)
+		}
+		if len(events) == 0 {
+			continue
+		}
+		if recAgent != agentID {
+			if err := flush(); err != nil {
+				return sent, err
+			}
+			agentID = recAgent
+		}
+		pending = append(pending, events...)
+		if len(pending) >= c.flushSize {
+			if err := flush(); err != nil {
+				return sent, err
+			}
+		}
+	}
+	return sent, flush()
+}
+
+// openReplaySource decrypts source if it is an encrypted spill file
+func (c *Client) openReplaySource(source io.Reader) (io.Reader, error) {
+	r := bufio.NewReader(source)
+	if magic, _ := r.Peek(len(spillMagic)); !bytes.Equal(magic, spillMagic) {
+		return r, nil
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var name string
+	if f, ok := source.(interface{ Name() string }); ok {
+		name = filepath.Base(f.Name())
+	}
+	if data, err = c.openSpill(name, data); err != nil {
+		return nil, fmt.Errorf("failed to decrypt spill file %q: %w", name, err)
+	}
+	return bytes.NewReader(data), nil
+}
+
+// decodeReplayRecord returns the events in one value of a dump and the agent
+// ID they were recorded under, if any
+func decodeReplayRecord(raw json.RawMessage) (string, []Event, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '[' {
+		var events []Event
+		err := json.Unmarshal(raw, &events)
+		return "", events, err
+	}
+
+	var rec struct {
+		Kind    string          `json:"kind"`
+		AgentID string          `json:"agent_id"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &rec); err != nil {
+		return "", nil, err
+	}
+	if rec.Kind == "" {
+		var e Event
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return "", nil, err
+		}
+		return "", []Event{e}, nil
+	}
+	if rec.Kind != "event" {
+		return "", nil, nil
+	}
+	var e Event
+	if err := json.Unmarshal(rec.Data, &e); err != nil {
+		return "", nil, err
+	}
+	return rec.AgentID, []Event{e}, nil
+}
+
+// replayBatch delivers replayed events in batches of at most
+// maxBatchBytes, truncating or dropping events too large for one as live
+// sends do, waiting while the API is rate limiting the client. It returns how
+// many events were delivered.
+func (c *Client) replayBatch(ctx context.Context, agentID string, events []Event) (int, error) {
+	for i := range events {
+		if events[i].ID == "" {
+			events[i].ID = newUUID()
+		}
+	}
+	if agentID == "" {
+		c.mu.Lock()
+		agentID = c.agentID
+		c.mu.Unlock()
+	}
+
+	events, sizes := c.fitEvents(events)
+	sent := 0
+	for len(events) > 0 {
+		n := c.batchLen(sizes)
+		if err := c.replayRequest(ctx, agentID, events[:n]); err != nil {
+			return sent, err
+		}
+		sent += n
+		events, sizes = events[n:], sizes[n:]
+	}
+	return sent, nil
+}
+
+// replayRequest sends one batch of replayed events, waiting while the API is
+// rate limiting the client
+func (c *Client) replayRequest(ctx context.Context, agentID string, events []Event) error {
+	for {
+		for !c.limiter.reserve() {
+			select {
+			case <-time.After(c.limiter.delay()):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if !c.breaker.allow() {
+			return ErrCircuitOpen
+		}
+
+		err := c.sendBatch(ctx, agentID, events)
+		if errors.Is(err, ErrRateLimited) {
+			c.breaker.done(nil)
+			continue
+		}
+		c.breaker.done(err)
+		return err
+	}
+}
@@ -0,0 +1,133 @@
+package trusera
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReplayLocalSinkDump(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	local := NewClient("k", WithLocalSink(path))
+	agentID, _ := local.RegisterAgent("agent", "custom")
+	local.Track(Event{ID: "e1", Type: EventToolCall, Name: "search", Timestamp: "2030-01-01T00:00:00Z"})
+	local.Track(Event{ID: "e2", Type: EventToolCall, Name: "fetch", Timestamp: "2030-01-01T00:00:01Z"})
+	local.Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	transport := &recordingTransport{}
+	client := NewClient("k", WithTransport(transport), WithAgentID("replayer"), WithFlushInterval(time.Hour))
+	defer client.Close()
+
+	n, err := client.Replay(context.Background(), f)
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if n != 2 || len(transport.batches) != 1 {
+		t.Fatalf("expected 2 events in 1 batch, got %d in %d", n, len(transport.batches))
+	}
+	batch := transport.batches[0]
+	if batch.Events[0].ID != "e1" || batch.Events[0].Timestamp != "2030-01-01T00:00:00Z" {
+		t.Errorf("expected original ID and timestamp kept, got %+v", batch.Events[0])
+	}
+	if batch.AgentID != agentID {
+		t.Errorf("expected the recorded agent ID %q, got %q", agentID, batch.AgentID)
+	}
+	if batch.IdempotencyKey != batchKey(batch.Events) {
+		t.Errorf("expected the batch idempotency key, got %q", batch.IdempotencyKey)
+	}
+}
+
+func TestReplayMixedSources(t *testing.T) {
+	dump := strings.Join([]string{
+		`[{"id":"s1","type":"tool_call","name":"a","timestamp":"2030-01-01T00:00:00Z"},{"id":"s2","type":"tool_call","name":"b","timestamp":"2030-01-01T00:00:01Z"}]`,
+		`{"id":"b1","type":"api_call","name":"c","timestamp":"2030-01-01T00:00:02Z"}`,
+		`{"kind":"event","agent_id":"agent-7","data":{"id":"r1","type":"tool_call","name":"d","timestamp":"2030-01-01T00:00:03Z"}}`,
+		`{"kind":"heartbeat","data":{}}`,
+	}, "\n")
+
+	transport := &recordingTransport{}
+	client := NewClient("k", WithTransport(transport), WithBatchSize(2), WithFlushInterval(time.Hour))
+	defer client.Close()
+
+	n, err := client.Replay(context.Background(), strings.NewReader(dump))
+	if err != nil || n != 4 {
+		t.Fatalf("expected 4 events replayed, got %d (%v)", n, err)
+	}
+	if len(transport.batches) != 3 {
+		t.Fatalf("expected batches split by size and agent, got %d", len(transport.batches))
+	}
+	if last := transport.batches[2]; last.AgentID != "agent-7" || last.Events[0].ID != "r1" {
+		t.Errorf("expected the recorded agent ID, got %+v", last)
+	}
+
+	if _, err := client.Replay(context.Background(), strings.NewReader(`{"id":`)); err == nil {
+		t.Error("expected an error for a truncated dump")
+	}
+}
+
+func TestReplayWaitsOutRateLimit(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	client := NewClient("k", WithBaseURL(server.URL), WithFlushInterval(time.Hour))
+	defer client.Close()
+
+	n, err := client.Replay(context.Background(), strings.NewReader(`{"id":"e1","type":"tool_call","name":"a"}`))
+	if err != nil || n != 1 {
+		t.Fatalf("expected the event replayed after the 429, got %d (%v)", n, err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected a retry after the 429, got %d calls", calls.Load())
+	}
+}
+
+func TestReplayFitsBatchSizeLimit(t *testing.T) {
+	var lines []string
+	for i := 0; i < 4; i++ {
+		lines = append(lines, `{"id":"e`+string(rune('1'+i))+`","type":"tool_call","name":"a","payload":{"output":"`+strings.Repeat("x", 400)+`"}}`)
+	}
+	lines = append(lines, `{"id":"big","type":"tool_call","name":"b","payload":{"output":"`+strings.Repeat("y", 5000)+`"}}`)
+
+	transport := &recordingTransport{}
+	client := NewClient("k", WithTransport(transport), WithMaxBatchBytes(1200), WithFlushInterval(time.Hour))
+	defer client.Close()
+
+	n, err := client.Replay(context.Background(), strings.NewReader(strings.Join(lines, "\n")))
+	if err != nil || n != 5 {
+		t.Fatalf("expected 5 events replayed, got %d (%v)", n, err)
+	}
+	if len(transport.batches) < 3 {
+		t.Fatalf("expected the dump split into several batches, got %d", len(transport.batches))
+	}
+	for _, b := range transport.batches {
+		var buf bytes.Buffer
+		encodeBatch(&buf, b)
+		if buf.Len() > 1200 {
+			t.Errorf("expected batches within the limit, got %d bytes", buf.Len())
+		}
+	}
+	last := transport.batches[len(transport.batches)-1].Events[0]
+	if last.ID != "big" || last.Metadata["truncated_from_bytes"] == nil {
+		t.Errorf("expected the oversized event truncated, got %+v", last.Metadata)
+	}
+}
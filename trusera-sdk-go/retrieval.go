@@ -0,0 +1,116 @@
+package trusera
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/Trusera/ai-bom/trusera-sdk-go/bom"
+)
+
+// RetrievedDoc is a document chunk retrieved to ground a model's answer.
+// Chunk content is never sent, only its SHA-256 hash.
+type RetrievedDoc struct {
+	ID        string  // Document or chunk ID in the store
+	SourceURI string  // Where the document came from, e.g. a URL or s3:// path
+	Content   string  // Hashed before sending
+	ChunkHash string  // Used instead of hashing Content, if set
+	Score     float64 // Similarity or rerank score
+}
+
+// retrievalSources counts the documents retrieved from each source URI
+type retrievalSources struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// AddRetrieval records the documents retrieved within s as a retrieval
+// event, so that model outputs in the run can be traced back to the content
+// they were grounded on. The span's own event lists the documents' IDs, or
+// their chunk hashes for documents without one.
+func (s *Span) AddRetrieval(docs []RetrievedDoc) {
+	if s.client == nil || len(docs) == 0 {
+		return
+	}
+
+	records := make([]map[string]any, len(docs))
+	refs := make([]string, 0, len(docs))
+	for i, d := range docs {
+		hash := d.ChunkHash
+		if hash == "" && d.Content != "" {
+			hash = HashContent(d.Content)
+		}
+		r := map[string]any{"score": d.Score}
+		if d.ID != "" {
+			r["id"] = d.ID
+		}
+		if d.SourceURI != "" {
+			r["source_uri"] = d.SourceURI
+		}
+		if hash != "" {
+			r["chunk_hash"] = hash
+		}
+		records[i] = r
+
+		if ref := d.ID; ref != "" {
+			refs = append(refs, ref)
+		} else if hash != "" {
+			refs = append(refs, hash)
+		}
+	}
+
+	s.mu.Lock()
+	s.retrieved = append(s.retrieved, refs...)
+	s.mu.Unlock()
+	s.client.retrievals.add(docs)
+
+	s.AddEvent(NewEvent(EventRetrieval, s.name).
+		WithPayload("documents", records).
+		WithPayload("document_count", len(docs)))
+}
+
+func (r *retrievalSources) add(docs []RetrievedDoc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, d := range docs {
+		if d.SourceURI == "" {
+			continue
+		}
+		if r.counts == nil {
+			r.counts = make(map[string]int)
+		}
+		r.counts[d.SourceURI]++
+	}
+}
+
+// RetrievalSources returns the source URIs of the documents retrieved so
+// far, with how many times documents from each were retrieved
+func (c *Client) RetrievalSources() map[string]int {
+	c.retrievals.mu.Lock()
+	defer c.retrievals.mu.Unlock()
+	out := make(map[string]int, len(c.retrievals.counts))
+	for uri, n := range c.retrievals.counts {
+		out[uri] = n
+	}
+	return out
+}
+
+// AddRetrievalSourcesToBOM declares every source documents were retrieved
+// from as a dataset of an AI-BOM, recording the agent's data lineage
+func (c *Client) AddRetrievalSourcesToBOM(b *bom.Builder) {
+	sources := c.RetrievalSources()
+	uris := make([]string, 0, len(sources))
+	for uri := range sources {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	for _, uri := range uris {
+		b.AddDataset(bom.Dataset{
+			Name:          uri,
+			Type:          "retrieval",
+			ProvenanceURI: uri,
+			Properties:    map[string]string{"trusera:retrieval_count": strconv.Itoa(sources[uri])},
+		})
+		b.AddLineage(bom.DatasetRef(uri), b.AgentRef(), bom.LineageGrounds)
+	}
+}
@@ -0,0 +1,68 @@
+package trusera
+
+import (
+	"testing"
+
+	"github.com/Trusera/ai-bom/trusera-sdk-go/bom"
+)
+
+func TestAddRetrieval(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	run := client.StartRun("answer")
+	retrieve := run.StartSpan("retrieve")
+	retrieve.AddRetrieval([]RetrievedDoc{
+		{ID: "doc-1#3", SourceURI: "s3://kb/handbook.pdf", Content: "Refunds take 5 days", Score: 0.91},
+		{SourceURI: "https://wiki.example.com/refunds", ChunkHash: "abc123", Score: 0.74},
+		{SourceURI: "s3://kb/handbook.pdf", Content: "Refunds need a receipt", Score: 0.7},
+	})
+	retrieve.AddRetrieval(nil)
+	retrieve.End()
+
+	events := queuedEvents(client)
+	if len(events) != 2 {
+		t.Fatalf("expected a retrieval and a span event, got %d", len(events))
+	}
+	e := events[0]
+	if e.Type != EventRetrieval || e.Name != "retrieve" || e.Payload["document_count"] != 3 {
+		t.Errorf("unexpected event %s %s %v", e.Type, e.Name, e.Payload["document_count"])
+	}
+	if e.Metadata["trace_id"] != run.TraceID() || e.Metadata["parent_span_id"] != retrieve.SpanID() {
+		t.Errorf("expected the retrieval linked to its span, got %v", e.Metadata)
+	}
+	docs := e.Payload["documents"].([]map[string]any)
+	if docs[0]["id"] != "doc-1#3" || docs[0]["chunk_hash"] != HashContent("Refunds take 5 days") || docs[0]["score"] != 0.91 {
+		t.Errorf("unexpected document: %v", docs[0])
+	}
+	if _, ok := docs[1]["id"]; ok || docs[1]["chunk_hash"] != "abc123" {
+		t.Errorf("expected the given chunk hash, got %v", docs[1])
+	}
+	for _, d := range docs {
+		for _, v := range d {
+			if v == "Refunds take 5 days" {
+				t.Error("expected chunk content never to be sent")
+			}
+		}
+	}
+
+	span := events[1].Payload["retrieved_docs"].([]string)
+	if len(span) != 3 || span[0] != "doc-1#3" || span[1] != "abc123" {
+		t.Errorf("expected the span to list the documents, got %v", span)
+	}
+
+	sources := client.RetrievalSources()
+	if sources["s3://kb/handbook.pdf"] != 2 || sources["https://wiki.example.com/refunds"] != 1 {
+		t.Errorf("unexpected sources: %v", sources)
+	}
+	b := bom.NewBuilder("agent")
+	client.AddRetrievalSourcesToBOM(b)
+	doc := b.Build()
+	datasets := doc.Datasets
+	if len(datasets) != 2 || datasets[1].ProvenanceURI != "s3://kb/handbook.pdf" || datasets[1].Properties["trusera:retrieval_count"] != "2" {
+		t.Errorf("unexpected BOM datasets: %+v", datasets)
+	}
+	if len(doc.Lineage) != 2 || doc.Lineage[1].To != "agent:agent" || doc.Lineage[1].Relation != bom.LineageGrounds {
+		t.Errorf("expected sources to ground the agent, got %+v", doc.Lineage)
+	}
+}
@@ -0,0 +1,151 @@
+package trusera
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// immediateQueueSize bounds the events waiting for the immediate worker.
+// When it is full, events of immediate routes are queued and batched.
+const immediateQueueSize = 256
+
+// RouteConfig controls how events of one type are delivered
+type RouteConfig struct {
+	// Path is the API endpoint the events are posted to, such as
+	// "/v1/security-events". Empty keeps /v1/events.
+	Path string
+	// Immediate sends each event as soon as it is tracked instead of
+	// waiting for a full batch or the flush interval
+	Immediate bool
+	// Transport delivers the events instead of an HTTPTransport for Path
+	Transport Transport
+}
+
+// WithRoute delivers events of eventType as cfg describes, for example
+// guardrail violations to a security endpoint without batching:
+//
+//	trusera.WithRoute(trusera.EventGuardrailViolation, trusera.RouteConfig{
+//		Path:      "/v1/security-events",
+//		Immediate: true,
+//	})
+//
+// Events of other types keep the client's transport and batching. In local
+// mode, routed events are written to the local sink unless cfg sets a
+// Transport.
+func WithRoute(eventType EventType, cfg RouteConfig) Option {
+	return func(c *Client) {
+		if c.routes == nil {
+			c.routes = make(map[EventType]RouteConfig)
+		}
+		c.routes[eventType] = cfg
+	}
+}
+
+// buildRoutes creates the transports of the configured routes
+func (c *Client) buildRoutes() error {
+	for eventType, r := range c.routes {
+		if r.Path != "" && !strings.HasPrefix(r.Path, "/") {
+			return fmt.Errorf("route for %s events: path %q must start with /", eventType, r.Path)
+		}
+		t := r.Transport
+		c.bindTransport(t)
+		if t == nil && r.Path != "" && c.sink == nil {
+			t = &HTTPTransport{
+				BaseURL:     c.baseURL,
+				Path:        r.Path,
+				APIKey:      c.apiKey,
+				HTTPClient:  c.httpClient,
+				Compression: c.compression,
+				Signer:      c.signer,
+				keyFunc:     c.currentAPIKey,
+			}
+		}
+		if t != nil {
+			if c.routeTransports == nil {
+				c.routeTransports = make(map[EventType]Transport)
+			}
+			c.routeTransports[eventType] = t
+		}
+		if r.Immediate && c.immediate == nil {
+			c.immediate = make(chan Event, immediateQueueSize)
+		}
+	}
+	return nil
+}
+
+// routeGroup is the part of a batch delivered by one transport
+type routeGroup struct {
+	transport Transport
+	events    []Event
+}
+
+// routeEvents splits events by the transport of their route, keeping their
+// order within each group
+func (c *Client) routeEvents(events []Event) []routeGroup {
+	if len(c.routeTransports) == 0 {
+		return []routeGroup{{transport: c.transport, events: events}}
+	}
+	var groups []routeGroup
+	for _, e := range events {
+		t, ok := c.routeTransports[e.Type]
+		if !ok {
+			t = c.transport
+		}
+		i := 0
+		for i < len(groups) && groups[i].transport != t {
+			i++
+		}
+		if i == len(groups) {
+			groups = append(groups, routeGroup{transport: t})
+		}
+		groups[i].events = append(groups[i].events, e)
+	}
+	return groups
+}
+
+// sendImmediately hands an event of an immediate route to the immediate
+// worker, stamping and counting it, and reports false if it must be queued
+// instead. It must be called with c.mu held; since every send happens under
+// the lock, a channel with room does not block.
+func (c *Client) sendImmediately(e *Event) bool {
+	if c.immediate == nil || c.closed || !c.routes[e.Type].Immediate || len(c.immediate) == cap(c.immediate) {
+		return false
+	}
+	c.stampEvent(e)
+	c.immediate <- *e
+	c.stats.tracked++
+	return true
+}
+
+// immediateWorker delivers events of immediate routes as they arrive,
+// together with any others already waiting
+func (c *Client) immediateWorker() {
+	defer c.wg.Done()
+	for {
+		select {
+		case e := <-c.immediate:
+			batch := append([]Event{e}, c.takeImmediate()...)
+			if err := c.deliver(context.Background(), batch); err != nil && err != ErrRateLimited {
+				c.reportError(fmt.Errorf("deliver %d immediate events: %w", len(batch), err))
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// takeImmediate removes and returns the events waiting for the immediate
+// worker, up to a batch
+func (c *Client) takeImmediate() []Event {
+	var events []Event
+	for len(events) < c.flushSize {
+		select {
+		case e := <-c.immediate:
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+	return events
+}
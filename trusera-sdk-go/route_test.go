@@ -0,0 +1,101 @@
+package trusera
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRouteSendsEventTypeToPath(t *testing.T) {
+	var mu sync.Mutex
+	received := map[string][]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch Batch
+		json.NewDecoder(r.Body).Decode(&batch)
+		mu.Lock()
+		for _, e := range batch.Events {
+			received[r.URL.Path] = append(received[r.URL.Path], e.Name)
+		}
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	t.Setenv("TRUSERA_API_URL", server.URL)
+	client := NewClient("k", WithFlushInterval(time.Hour),
+		WithRoute(EventGuardrailViolation, RouteConfig{Path: "/v1/security-events"}))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "search"))
+	client.Track(NewEvent(EventGuardrailViolation, "pii"))
+	client.Track(NewEvent(EventLLMInvoke, "chat"))
+	if err := client.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := received["/v1/security-events"]; len(got) != 1 || got[0] != "pii" {
+		t.Errorf("expected the guardrail event on /v1/security-events, got %v", got)
+	}
+	if got := received["/v1/events"]; len(got) != 2 || got[0] != "search" || got[1] != "chat" {
+		t.Errorf("expected the other events on /v1/events in order, got %v", got)
+	}
+}
+
+func TestImmediateRouteBypassesBatching(t *testing.T) {
+	security := &recordingTransport{}
+	client := NewClient("k", WithTransport(&recordingTransport{}), WithFlushInterval(time.Hour),
+		WithRoute(EventGuardrailViolation, RouteConfig{Immediate: true, Transport: security}))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "search"))
+	client.Track(NewEvent(EventGuardrailViolation, "pii"))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		security.mu.Lock()
+		n := len(security.batches)
+		security.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the guardrail event to be sent without a flush")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	security.mu.Lock()
+	if e := security.batches[0].Events[0]; e.Name != "pii" || e.Sequence == 0 {
+		t.Errorf("expected the stamped guardrail event, got %+v", e)
+	}
+	security.mu.Unlock()
+	if stats := client.Stats(); stats.Queued != 1 || stats.Tracked != 2 {
+		t.Errorf("expected the tool call to stay batched, got %d queued of %d tracked", stats.Queued, stats.Tracked)
+	}
+}
+
+func TestRouteWithoutTransportUsesLocalSink(t *testing.T) {
+	path := t.TempDir() + "/events.jsonl"
+	client := NewClient("k", WithLocalSink(path),
+		WithRoute(EventGuardrailViolation, RouteConfig{Path: "/v1/security-events"}))
+
+	client.Track(NewEvent(EventGuardrailViolation, "pii"))
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	records := readLocalRecords(t, path)
+	found := false
+	for _, r := range records {
+		if r.Kind == "event" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected the routed event in the local sink")
+	}
+}
@@ -0,0 +1,132 @@
+package trusera
+
+import (
+	"os"
+	"runtime"
+	"runtime/metrics"
+	"time"
+)
+
+// defaultRuntimeMetricsInterval is used when WithRuntimeMetrics is given no interval
+const defaultRuntimeMetricsInterval = 30 * time.Second
+
+// WithRuntimeMetrics samples the process every interval (default 30s) for
+// capacity planning: goroutines, heap size, garbage collections and their
+// pauses, CPU time and, on Linux, open file descriptors. Each sample is
+// tracked as an EventResource event and the latest is attached to fleet
+// heartbeats as "runtime".
+func WithRuntimeMetrics(interval time.Duration) Option {
+	return func(c *Client) {
+		if interval <= 0 {
+			interval = defaultRuntimeMetricsInterval
+		}
+		c.runtimeInterval = interval
+	}
+}
+
+// runtimeSampler turns successive readings of the runtime into samples of
+// what happened between them
+type runtimeSampler struct {
+	at      time.Time
+	numGC   uint32
+	pauseNs uint64
+	cpu     float64
+	reading []metrics.Sample
+}
+
+func newRuntimeSampler() *runtimeSampler {
+	s := &runtimeSampler{reading: []metrics.Sample{
+		{Name: "/cpu/classes/total:cpu-seconds"},
+		{Name: "/cpu/classes/idle:cpu-seconds"},
+	}}
+	s.sample()
+	return s
+}
+
+// sample returns the process's resource use since the previous call
+func (s *runtimeSampler) sample() map[string]any {
+	now := time.Now()
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	cpu := s.cpuSeconds()
+
+	gcs := mem.NumGC - s.numGC
+	// PauseNs is a ring of the last 256 pauses, the latest at (NumGC+255)%256
+	var maxPause uint64
+	for i := uint32(0); i < gcs && i < uint32(len(mem.PauseNs)); i++ {
+		maxPause = max(maxPause, mem.PauseNs[(mem.NumGC-i+255)%256])
+	}
+	out := map[string]any{
+		"goroutines":        runtime.NumGoroutine(),
+		"heap_alloc_bytes":  mem.HeapAlloc,
+		"heap_inuse_bytes":  mem.HeapInuse,
+		"heap_objects":      mem.HeapObjects,
+		"sys_bytes":         mem.Sys,
+		"gc_count":          gcs,
+		"gc_pause_total_ms": float64(mem.PauseTotalNs-s.pauseNs) / 1e6,
+		"gc_pause_max_ms":   float64(maxPause) / 1e6,
+		"cpu_seconds":       cpu - s.cpu,
+		"interval_seconds":  now.Sub(s.at).Seconds(),
+		"gomaxprocs":        runtime.GOMAXPROCS(0),
+	}
+	if fds, ok := openFDs(); ok {
+		out["open_fds"] = fds
+	}
+	s.at, s.numGC, s.pauseNs, s.cpu = now, mem.NumGC, mem.PauseTotalNs, cpu
+	return out
+}
+
+// cpuSeconds returns the CPU time the Go runtime estimates the process has used
+func (s *runtimeSampler) cpuSeconds() float64 {
+	metrics.Read(s.reading)
+	var v [2]float64
+	for i, r := range s.reading {
+		if r.Value.Kind() == metrics.KindFloat64 {
+			v[i] = r.Value.Float64()
+		}
+	}
+	return v[0] - v[1]
+}
+
+// openFDs counts the process's open file descriptors where /proc exposes them
+func openFDs() (int, bool) {
+	if runtime.GOOS != "linux" {
+		return 0, false
+	}
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return 0, false
+	}
+	// Reading the directory holds one descriptor open itself
+	return len(entries) - 1, true
+}
+
+// runtimeMetricsLoop tracks a runtime sample every interval
+func (c *Client) runtimeMetricsLoop() {
+	defer c.wg.Done()
+	sampler := newRuntimeSampler()
+	ticker := time.NewTicker(c.runtimeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.trackRuntime(sampler.sample())
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// trackRuntime keeps a sample for heartbeats and tracks it as an event
+func (c *Client) trackRuntime(sample map[string]any) {
+	c.mu.Lock()
+	c.lastRuntime = sample
+	c.mu.Unlock()
+
+	event := NewEvent(EventResource, "runtime")
+	for k, v := range sample {
+		event = event.WithPayload(k, v)
+	}
+	c.Track(event)
+}
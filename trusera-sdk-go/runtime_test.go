@@ -0,0 +1,61 @@
+package trusera
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestRuntimeSamplerReportsDeltas(t *testing.T) {
+	s := newRuntimeSampler()
+	runtime.GC()
+	runtime.GC()
+	sample := s.sample()
+
+	if n, _ := sample["gc_count"].(uint32); n < 2 {
+		t.Errorf("expected at least 2 collections since the last sample, got %v", sample["gc_count"])
+	}
+	if g, _ := sample["goroutines"].(int); g < 1 {
+		t.Errorf("expected a goroutine count, got %v", sample["goroutines"])
+	}
+	if _, ok := sample["open_fds"]; ok != (runtime.GOOS == "linux") {
+		t.Errorf("expected open_fds only where /proc has them, got %v", sample)
+	}
+	if cpu, _ := sample["cpu_seconds"].(float64); cpu < 0 {
+		t.Errorf("expected non-negative CPU time, got %v", cpu)
+	}
+}
+
+func TestRuntimeMetricsTrackedAndSentWithHeartbeat(t *testing.T) {
+	var heartbeat map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/fleet/fleet-1/heartbeat" {
+			json.NewDecoder(r.Body).Decode(&heartbeat)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithRuntimeMetrics(10*time.Millisecond),
+		WithStrictValidation(), WithFlushInterval(time.Hour))
+	defer client.Close()
+	client.fleetAgentID = "fleet-1"
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(queuedEvents(client)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected a resource event")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if e := queuedEvents(client)[0]; e.Type != EventResource || e.Payload["heap_alloc_bytes"] == nil {
+		t.Errorf("expected a runtime sample, got %+v", e)
+	}
+
+	client.sendHeartbeat()
+	if rt, ok := heartbeat["runtime"].(map[string]any); !ok || rt["goroutines"] == nil {
+		t.Errorf("expected the latest sample in the heartbeat, got %v", heartbeat["runtime"])
+	}
+}
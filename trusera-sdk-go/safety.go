@@ -0,0 +1,224 @@
+package trusera
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultClassifyTimeout bounds a classification when SafetyOptions.Timeout is unset
+const defaultClassifyTimeout = 2 * time.Second
+
+// SafetyCategory is a kind of unsafe content a classifier scores
+type SafetyCategory string
+
+const (
+	SafetyToxicity  SafetyCategory = "toxicity"
+	SafetyPII       SafetyCategory = "pii"
+	SafetyJailbreak SafetyCategory = "jailbreak"
+)
+
+// ContentKind says whether classified content was sent to a model or
+// generated by one
+type ContentKind string
+
+const (
+	ContentPrompt ContentKind = "prompt"
+	ContentOutput ContentKind = "output"
+)
+
+// SafetyScores are a classifier's scores by category, from 0 (safe) to 1
+type SafetyScores map[SafetyCategory]float64
+
+// Classifier scores content for safety, with a local model or a moderation
+// API. It may score any categories, including its own.
+type Classifier interface {
+	Classify(ctx context.Context, kind ContentKind, text string) (SafetyScores, error)
+}
+
+// ClassifierFunc adapts a function to the Classifier interface
+type ClassifierFunc func(ctx context.Context, kind ContentKind, text string) (SafetyScores, error)
+
+// Classify calls f(ctx, kind, text)
+func (f ClassifierFunc) Classify(ctx context.Context, kind ContentKind, text string) (SafetyScores, error) {
+	return f(ctx, kind, text)
+}
+
+// DefaultSafetyThresholds are the thresholds used when SafetyOptions has none
+var DefaultSafetyThresholds = map[SafetyCategory]float64{
+	SafetyToxicity:  0.8,
+	SafetyPII:       0.5,
+	SafetyJailbreak: 0.8,
+}
+
+// SafetyOptions configures WithContentClassifier
+type SafetyOptions struct {
+	// Thresholds maps categories to the score at or above which a
+	// guardrail_violation event is tracked, DefaultSafetyThresholds if nil.
+	// Categories without a threshold are scored but never reported.
+	Thresholds map[SafetyCategory]float64
+	// Timeout bounds each classification, 2 seconds by default
+	Timeout time.Duration
+}
+
+// safety holds the content classifier of a client
+type safety struct {
+	classifier Classifier
+	thresholds map[SafetyCategory]float64
+	timeout    time.Duration
+}
+
+// safetyKeys are the content payload keys classified as each kind
+var safetyKeys = map[ContentKind][]string{
+	ContentPrompt: {"prompt", "prompts", "messages", "input"},
+	ContentOutput: {"completion", "response", "output", "content"},
+}
+
+// WithContentClassifier scores prompts and outputs with cl: the content of
+// tracked events (their prompt, messages, input, completion, response,
+// output and content payloads), the LLM calls recorded by WrapTransport, and
+// text passed to ClassifyContent. Scores are added to the event as
+// prompt_safety and output_safety, or prompt_safety_error and
+// output_safety_error if cl fails, and a guardrail_violation event is
+// tracked for each category scoring at or above its threshold. cl sees
+// content before redaction and capture levels apply, and runs in Track, so
+// a slow API classifier delays the caller by up to the timeout.
+func WithContentClassifier(cl Classifier, opts SafetyOptions) Option {
+	return func(c *Client) {
+		if cl == nil {
+			c.safety = nil
+			return
+		}
+		if opts.Thresholds == nil {
+			opts.Thresholds = DefaultSafetyThresholds
+		}
+		if opts.Timeout <= 0 {
+			opts.Timeout = defaultClassifyTimeout
+		}
+		c.safety = &safety{classifier: cl, thresholds: opts.Thresholds, timeout: opts.Timeout}
+	}
+}
+
+// ClassifyContent scores text with the classifier set by
+// WithContentClassifier and tracks a guardrail_violation event, linked to the
+// active span in ctx, for each category at or above its threshold. It
+// returns the scores, so the caller can block or rewrite the content, or
+// nil without a classifier.
+func (c *Client) ClassifyContent(ctx context.Context, kind ContentKind, text string) (SafetyScores, error) {
+	return c.safety.check(ctx, c, kind, text, nil)
+}
+
+// ClassifyContent is Client.ClassifyContent, tagging violations with the
+// agent's ID
+func (a *Agent) ClassifyContent(ctx context.Context, kind ContentKind, text string) (SafetyScores, error) {
+	return a.client.safety.check(ctx, a, kind, text, nil)
+}
+
+// safetyOf returns the content classifier of the client behind t, if any
+func safetyOf(t Tracker) *safety {
+	if c := clientOf(t); c != nil {
+		return c.safety
+	}
+	return nil
+}
+
+// classify scores the prompt and output content of an event that has not
+// been scored yet
+func (c *Client) classify(e Event) Event {
+	if c.safety == nil || e.Type == EventGuardrailViolation || len(e.Payload) == 0 {
+		return e
+	}
+	for _, kind := range []ContentKind{ContentPrompt, ContentOutput} {
+		if _, ok := e.Payload[string(kind)+"_safety"]; ok {
+			continue
+		}
+		var parts []string
+		for _, key := range safetyKeys[kind] {
+			if text := payloadText(e.Payload[key]); text != "" {
+				parts = append(parts, text)
+			}
+		}
+		e = c.safety.score(context.Background(), c, e, kind, strings.Join(parts, "\n"))
+	}
+	return e
+}
+
+// payloadText returns the text of a content payload value
+func payloadText(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return fmt.Sprint(v)
+}
+
+// score classifies text of kind, adds the scores to e and tracks a violation
+// referring to e, with e's metadata, for each category over its threshold
+func (s *safety) score(ctx context.Context, t Tracker, e Event, kind ContentKind, text string) Event {
+	if s == nil || text == "" {
+		return e
+	}
+	if e.ID == "" {
+		e.ID = newUUID()
+	}
+	metadata := make(map[string]any, len(e.Metadata)+1)
+	for k, v := range e.Metadata {
+		metadata[k] = v
+	}
+	metadata["event_id"] = e.ID
+
+	scores, err := s.check(ctx, t, kind, text, metadata)
+	if err != nil {
+		return e.WithPayload(string(kind)+"_safety_error", err.Error())
+	}
+	return e.WithPayload(string(kind)+"_safety", scores)
+}
+
+// check classifies text and tracks violations with t, adding metadata to them
+func (s *safety) check(ctx context.Context, t Tracker, kind ContentKind, text string, metadata map[string]any) (SafetyScores, error) {
+	if s == nil {
+		return nil, nil
+	}
+	cctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	scores, err := s.classifier.Classify(cctx, kind, text)
+	if err != nil {
+		return nil, fmt.Errorf("content classifier: %w", err)
+	}
+
+	categories := make([]SafetyCategory, 0, len(scores))
+	for category := range scores {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i] < categories[j] })
+	for _, category := range categories {
+		score := scores[category]
+		threshold, ok := s.thresholds[category]
+		if !ok || score < threshold {
+			continue
+		}
+		g := GuardrailEvent{
+			Policy:      string(category),
+			Severity:    SeverityMedium,
+			ContentHash: HashContent(text),
+			Source:      "content_classifier",
+			Message:     fmt.Sprintf("%s scored %.2f for %s, threshold %.2f", kind, score, category, threshold),
+			Metadata:    map[string]any{"content_kind": string(kind), "score": score, "threshold": threshold},
+		}
+		if score >= 0.9 {
+			g.Severity = SeverityHigh
+		}
+		for k, v := range metadata {
+			g.Metadata[k] = v
+		}
+		event, _ := g.event()
+		trackContext(t, ctx, event)
+	}
+	return scores, nil
+}
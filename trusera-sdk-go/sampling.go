@@ -0,0 +1,115 @@
+package trusera
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// SamplerFunc decides whether an event is kept. It must be safe for concurrent use.
+type SamplerFunc func(Event) bool
+
+// WithSampler samples events of the given types with s, or all events without
+// a type-specific sampler if no types are given. Events carrying an error,
+// guardrail violations, security and audit events, feedback and experiment
+// exposures are always kept.
+func WithSampler(s SamplerFunc, types ...EventType) Option {
+	return func(c *Client) {
+		if s == nil {
+			return
+		}
+		if len(types) == 0 {
+			c.defaultSampler = s
+			return
+		}
+		if c.samplers == nil {
+			c.samplers = make(map[EventType]SamplerFunc)
+		}
+		for _, t := range types {
+			c.samplers[t] = s
+		}
+	}
+}
+
+// sampleRand is the random source for sampling, replaceable in tests
+var sampleRand = rand.Float64
+
+// Probabilistic keeps each event independently with probability rate (0.0 to 1.0)
+func Probabilistic(rate float64) SamplerFunc {
+	return func(Event) bool {
+		return rate >= 1 || (rate > 0 && sampleRand() < rate)
+	}
+}
+
+// RateLimited keeps at most perSecond events per second, allowing bursts of up to burst events
+func RateLimited(perSecond float64, burst int) SamplerFunc {
+	if burst < 1 {
+		burst = 1
+	}
+	var mu sync.Mutex
+	tokens := float64(burst)
+	last := time.Now()
+
+	return func(Event) bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		now := time.Now()
+		tokens = math.Min(float64(burst), tokens+now.Sub(last).Seconds()*perSecond)
+		last = now
+		if tokens < 1 {
+			return false
+		}
+		tokens--
+		return true
+	}
+}
+
+// HeadBased makes one keep/drop decision per trace so that a trace is either
+// captured whole or not at all. The decision is derived from the event's
+// "trace_id" metadata; events without a trace ID are sampled probabilistically.
+func HeadBased(rate float64) SamplerFunc {
+	probabilistic := Probabilistic(rate)
+	return func(e Event) bool {
+		traceID, _ := e.Metadata["trace_id"].(string)
+		if traceID == "" {
+			return probabilistic(e)
+		}
+		h := fnv.New64a()
+		h.Write([]byte(traceID))
+		return float64(h.Sum64())/math.MaxUint64 < rate
+	}
+}
+
+// sample reports whether e should be kept
+func (c *Client) sample(e Event) bool {
+	s := c.remoteSampler(e.Type)
+	if s == nil {
+		var ok bool
+		if s, ok = c.samplers[e.Type]; !ok {
+			s = c.defaultSampler
+		}
+	}
+	if s == nil || alwaysKeep(e) || s(e) {
+		return true
+	}
+
+	c.mu.Lock()
+	c.stats.sampledOut++
+	c.mu.Unlock()
+	return false
+}
+
+// alwaysKeep reports whether e must bypass sampling
+func alwaysKeep(e Event) bool {
+	if e.Type == EventGuardrailViolation || e.Type == EventSecurity || e.Type == EventAudit ||
+		e.Type == EventFeedback || e.Type == EventExposure {
+		return true
+	}
+	if err, ok := e.Payload["error"]; ok && err != nil {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package trusera
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithSamplerPerType(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000),
+		WithSampler(Probabilistic(0), EventAPICall),
+	)
+	defer client.Close()
+
+	for i := 0; i < 10; i++ {
+		client.Track(NewEvent(EventAPICall, "get"))
+		client.Track(NewEvent(EventLLMInvoke, "chat"))
+	}
+
+	events := queuedEvents(client)
+	if len(events) != 10 {
+		t.Fatalf("expected only the 10 llm events, got %d", len(events))
+	}
+	for _, e := range events {
+		if e.Type != EventLLMInvoke {
+			t.Errorf("expected api_call events to be sampled out, got %s", e.Type)
+		}
+	}
+	if got := client.Stats().SampledOut; got != 10 {
+		t.Errorf("expected 10 sampled out, got %d", got)
+	}
+}
+
+func TestWithSamplerDefault(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000),
+		WithSampler(Probabilistic(0)),
+		WithSampler(Probabilistic(1), EventDecision),
+	)
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "tool"))
+	client.Track(NewEvent(EventDecision, "approve"))
+
+	events := queuedEvents(client)
+	if len(events) != 1 || events[0].Type != EventDecision {
+		t.Errorf("expected type-specific sampler to override default, got %v", events)
+	}
+}
+
+func TestSamplingAlwaysKeepsErrorsAndViolations(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000), WithSampler(Probabilistic(0)))
+	defer client.Close()
+
+	client.Track(NewEvent(EventAPICall, "error").WithPayload("error", "connection refused"))
+	client.Track(NewEvent(EventGuardrailViolation, "pii_detected"))
+	client.Track(NewEvent(EventAPICall, "ok"))
+
+	if got := len(queuedEvents(client)); got != 2 {
+		t.Errorf("expected error and violation to bypass sampling, got %d events", got)
+	}
+}
+
+func TestProbabilistic(t *testing.T) {
+	rolls := []float64{0.1, 0.5, 0.2, 0.9}
+	orig := sampleRand
+	sampleRand = func() float64 {
+		r := rolls[0]
+		rolls = rolls[1:]
+		return r
+	}
+	defer func() { sampleRand = orig }()
+
+	s := Probabilistic(0.25)
+	kept := 0
+	for i := 0; i < 4; i++ {
+		if s(Event{}) {
+			kept++
+		}
+	}
+	if kept != 2 {
+		t.Errorf("expected 2 of 4 kept, got %d", kept)
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	s := RateLimited(10, 3)
+
+	kept := 0
+	for i := 0; i < 10; i++ {
+		if s(Event{}) {
+			kept++
+		}
+	}
+	if kept != 3 {
+		t.Errorf("expected burst of 3, got %d", kept)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if !s(Event{}) {
+		t.Error("expected tokens to refill over time")
+	}
+}
+
+func TestHeadBasedKeepsWholeTraces(t *testing.T) {
+	s := HeadBased(0.5)
+
+	for _, traceID := range []string{"trace-a", "trace-b", "trace-c", "trace-d"} {
+		first := s(NewEvent(EventToolCall, "x").WithMetadata("trace_id", traceID))
+		for i := 0; i < 5; i++ {
+			if got := s(NewEvent(EventLLMInvoke, "y").WithMetadata("trace_id", traceID)); got != first {
+				t.Errorf("%s: expected consistent decision across the trace", traceID)
+			}
+		}
+	}
+
+	if !HeadBased(1)(NewEvent(EventToolCall, "x").WithMetadata("trace_id", "t")) {
+		t.Error("expected rate 1 to keep every trace")
+	}
+	if HeadBased(0)(NewEvent(EventToolCall, "x").WithMetadata("trace_id", "t")) {
+		t.Error("expected rate 0 to drop every trace")
+	}
+}
+
+func TestSampledOutLLMEventsStillCountTowardCost(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000), WithSampler(Probabilistic(0), EventLLMInvoke))
+	defer client.Close()
+
+	client.Track(NewEvent(EventLLMInvoke, "chat").
+		WithPayload("model", "gpt-4o").
+		WithPayload("prompt_tokens", 100).
+		WithPayload("completion_tokens", 100))
+
+	if got := client.CostSummary().Total.Calls; got != 1 {
+		t.Errorf("expected spend recorded for sampled-out call, got %d calls", got)
+	}
+}
@@ -0,0 +1,69 @@
+package trusera
+
+import (
+	"net/http"
+	"os"
+)
+
+// Headers scoping every API request to a project and team
+const (
+	ProjectHeader = "X-Trusera-Project"
+	TeamHeader    = "X-Trusera-Team"
+)
+
+// WithProject scopes the client to a project, or workspace, of the
+// organization: every API request carries the X-Trusera-Project header and
+// every event its ProjectID, so teams sharing one API key hierarchy keep
+// their telemetry apart. Defaults to TRUSERA_PROJECT.
+func WithProject(id string) Option {
+	return func(c *Client) {
+		c.projectID = id
+	}
+}
+
+// WithTeam scopes the client to a team, like WithProject, using the
+// X-Trusera-Team header and the events' TeamID. Defaults to TRUSERA_TEAM.
+func WithTeam(id string) Option {
+	return func(c *Client) {
+		c.teamID = id
+	}
+}
+
+// installScopeTransport applies TRUSERA_PROJECT and TRUSERA_TEAM if no scope
+// was set and wraps the client's HTTP transport with the scoping headers
+func (c *Client) installScopeTransport() {
+	if c.projectID == "" {
+		c.projectID = os.Getenv("TRUSERA_PROJECT")
+	}
+	if c.teamID == "" {
+		c.teamID = os.Getenv("TRUSERA_TEAM")
+	}
+	if c.projectID == "" && c.teamID == "" {
+		return
+	}
+	base := c.httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	hc := *c.httpClient
+	hc.Transport = &scopeTransport{base: base, project: c.projectID, team: c.teamID}
+	c.httpClient = &hc
+}
+
+// scopeTransport sets the scoping headers on every request
+type scopeTransport struct {
+	base    http.RoundTripper
+	project string
+	team    string
+}
+
+func (t *scopeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if t.project != "" {
+		req.Header.Set(ProjectHeader, t.project)
+	}
+	if t.team != "" {
+		req.Header.Set(TeamHeader, t.team)
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,60 @@
+package trusera
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Trusera/ai-bom/trusera-sdk-go/bom"
+)
+
+func TestWithProjectAndTeam(t *testing.T) {
+	var mu sync.Mutex
+	headers := map[string]http.Header{}
+	var batch Batch
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		headers[r.URL.Path] = r.Header.Clone()
+		if r.URL.Path == "/v1/events" {
+			_ = json.NewDecoder(r.Body).Decode(&batch)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithProject("payments"), WithTeam("risk"))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "search"))
+	if err := client.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if err := client.UploadBOM(bom.NewBuilder("agent").Build()); err != nil {
+		t.Fatalf("UploadBOM failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, path := range []string{"/v1/events", "/v1/bom"} {
+		if h := headers[path]; h.Get(ProjectHeader) != "payments" || h.Get(TeamHeader) != "risk" {
+			t.Errorf("expected scoping headers on %s, got %v", path, h)
+		}
+	}
+	if len(batch.Events) != 1 || batch.Events[0].ProjectID != "payments" || batch.Events[0].TeamID != "risk" {
+		t.Errorf("expected scoped event, got %+v", batch.Events)
+	}
+}
+
+func TestScopeFromEnvironment(t *testing.T) {
+	t.Setenv("TRUSERA_PROJECT", "search")
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "a"))
+	events := queuedEvents(client)
+	if len(events) != 1 || events[0].ProjectID != "search" || events[0].TeamID != "" {
+		t.Errorf("expected project from TRUSERA_PROJECT, got %+v", events)
+	}
+}
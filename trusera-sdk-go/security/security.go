@@ -0,0 +1,282 @@
+// Package security detects prompt injection in content entering an agent's
+// context, such as tool outputs and retrieved documents. Built-in heuristics
+// look for instructions that try to override the agent's own, invisible
+// Unicode that hides text from humans, and URLs built to exfiltrate data:
+//
+//	scanner := security.New()
+//	client := trusera.NewClient(apiKey, trusera.WithEventProcessor(scanner.Process))
+//	scanner.Attach(client)
+//
+//	if findings := scanner.ScanDocuments(ctx, docs); len(findings) > 0 {
+//		// Drop or quarantine the documents
+//	}
+//
+// Suspicious content is reported as a trusera.EventSecurity event with the
+// rules that matched and a SHA-256 hash of the content, never the content
+// itself. The heuristics favor recall; treat findings as signals to review
+// or to act on with policies, not as proof of an attack.
+package security
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+// Rules, the kinds of suspicious content
+const (
+	InstructionOverride = "instruction_override" // Text addressing the model to change its instructions
+	InvisibleUnicode    = "invisible_unicode"    // Characters that render as nothing or reorder text
+	DataExfiltration    = "data_exfiltration"    // URLs or instructions that carry data out
+)
+
+// Sources of scanned content
+const (
+	SourceToolOutput = "tool_output"
+	SourceDocument   = "document"
+)
+
+// maxScan bounds how much of a text is scanned
+const maxScan = 1 << 20
+
+// linkedMetadata is copied from a scanned event to its security event, so
+// the finding is attributed to the same agent and trace
+var linkedMetadata = []string{"agent_id", "trace_id", "span_id", "parent_span_id"}
+
+// outputKeys are the tool call payload keys scanned by Process
+var outputKeys = []string{"output", "result", "response", "content"}
+
+// Finding is one suspicious match in scanned content
+type Finding struct {
+	Rule    string // InstructionOverride, InvisibleUnicode or DataExfiltration
+	Pattern string // Heuristic that matched, such as "ignore_previous"
+	Offset  int    // Byte offset of the first match
+	Count   int    // Number of matches
+	Match   string // First matched text; not sent with events
+}
+
+// pattern is a regular expression heuristic
+type pattern struct {
+	rule string
+	name string
+	re   *regexp.Regexp
+}
+
+var patterns = []pattern{
+	{InstructionOverride, "ignore_previous", regexp.MustCompile(`(?i)\b(?:ignore|disregard|forget|override|bypass)\b[^.\n]{0,40}?\b(?:previous|prior|above|earlier|preceding|all|any|your|the)\b[^.\n]{0,20}?\b(?:instructions?|prompts?|rules|directions|directives|guidelines|guardrails)\b`)},
+	{InstructionOverride, "new_instructions", regexp.MustCompile(`(?i)\b(?:new|updated|real|actual|revised|important)\s+(?:system\s+)?instructions?\s*:`)},
+	{InstructionOverride, "role_reassignment", regexp.MustCompile(`(?i)\b(?:you are now|from now on,? you (?:are|will|must)|pretend (?:to be|you are)|act as (?:an? )?(?:unrestricted|unfiltered|jailbroken|DAN)\b)`)},
+	{InstructionOverride, "chat_template", regexp.MustCompile(`(?i)<\|im_start\|>|<\|im_end\|>|<\|(?:system|user|assistant)\|>|\[/?INST\]|<<SYS>>|</?system>|(?m:^\s*(?:system|assistant)\s*:\s)`)},
+	{InstructionOverride, "prompt_leak", regexp.MustCompile(`(?i)\b(?:reveal|print|output|repeat|show|disclose)\b[^.\n]{0,30}?\b(?:system prompt|your (?:instructions|prompt|rules)|hidden instructions)\b`)},
+	{InstructionOverride, "conceal_from_user", regexp.MustCompile(`(?i)\bdo not (?:tell|inform|mention|reveal|alert|notify)\b[^.\n]{0,30}?\bthe user\b`)},
+
+	{DataExfiltration, "markdown_image_query", regexp.MustCompile(`!\[[^\]]*\]\(\s*https?://[^)\s]*\?[^)\s]*=`)},
+	{DataExfiltration, "url_placeholder", regexp.MustCompile(`(?i)https?://[^\s)"'>]*(?:\{\{|%7B%7B|\$\{|\[(?:data|secret|conversation|history|summary|[A-Z_]{4,})\])`)},
+	{DataExfiltration, "encoded_query", regexp.MustCompile(`https?://[^\s)"'>]*[?&][\w.-]+=[A-Za-z0-9+/_-]{48,}={0,2}`)},
+	{DataExfiltration, "exfil_instruction", regexp.MustCompile(`(?i)\b(?:send|post|upload|forward|exfiltrate|transmit|leak|append)\b[^\n]{0,60}?\b(?:conversation|chat history|history|messages|api[ _-]?keys?|passwords?|tokens?|secrets?|credentials|emails?|personal data|user data)\b[^\n]{0,60}?https?://`)},
+}
+
+// invisible classifies runes that render as nothing or reorder text
+func invisible(r rune) string {
+	switch {
+	case r >= 0xE0000 && r <= 0xE007F:
+		return "unicode_tags" // Can spell out hidden ASCII text
+	case r >= 0x202A && r <= 0x202E, r >= 0x2066 && r <= 0x2069:
+		return "bidi_control"
+	case r == 0x200B, r == 0x200C, r == 0x200E, r == 0x200F, r >= 0x2060 && r <= 0x2064, r == 0xFEFF, r == 0x180E:
+		return "zero_width" // U+200D is left out, as it joins emoji
+	}
+	return ""
+}
+
+// Scan returns the findings of the built-in heuristics in text, sorted by
+// offset. Only the first megabyte is scanned.
+func Scan(text string) []Finding {
+	if len(text) > maxScan {
+		text = text[:maxScan]
+	}
+	var findings []Finding
+	for _, p := range patterns {
+		locs := p.re.FindAllStringIndex(text, -1)
+		if len(locs) == 0 {
+			continue
+		}
+		findings = append(findings, Finding{
+			Rule:    p.rule,
+			Pattern: p.name,
+			Offset:  locs[0][0],
+			Count:   len(locs),
+			Match:   text[locs[0][0]:locs[0][1]],
+		})
+	}
+
+	hidden := map[string]*Finding{}
+	for i, r := range text {
+		name := invisible(r)
+		if name == "" || (r == 0xFEFF && i == 0) {
+			continue
+		}
+		if f, ok := hidden[name]; ok {
+			f.Count++
+			continue
+		}
+		hidden[name] = &Finding{Rule: InvisibleUnicode, Pattern: name, Offset: i, Count: 1, Match: string(r)}
+	}
+	for _, f := range hidden {
+		findings = append(findings, *f)
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].Offset != findings[j].Offset {
+			return findings[i].Offset < findings[j].Offset
+		}
+		return findings[i].Pattern < findings[j].Pattern
+	})
+	return findings
+}
+
+// Scanner scans content entering an agent's context and reports what it
+// finds. It is safe for concurrent use.
+type Scanner struct {
+	mu      sync.Mutex
+	tracker trusera.Tracker
+}
+
+// New creates a scanner using the built-in heuristics
+func New() *Scanner {
+	return &Scanner{}
+}
+
+// Attach sends security events to t, such as a *trusera.Client. Without it,
+// findings are only returned.
+func (s *Scanner) Attach(t trusera.Tracker) {
+	s.mu.Lock()
+	s.tracker = t
+	s.mu.Unlock()
+}
+
+// ScanToolOutput scans the output of tool before it is passed to the model,
+// reporting findings as a security event linked to the active span in ctx
+func (s *Scanner) ScanToolOutput(ctx context.Context, tool, output string) []Finding {
+	findings := Scan(output)
+	if len(findings) > 0 {
+		s.report(ctx, s.event(SourceToolOutput, tool, output, findings), nil)
+	}
+	return findings
+}
+
+// ScanDocuments scans retrieved documents before they are added to a
+// prompt, reporting a security event for each suspicious one. It returns the
+// findings by index in docs.
+func (s *Scanner) ScanDocuments(ctx context.Context, docs []trusera.RetrievedDoc) map[int][]Finding {
+	found := map[int][]Finding{}
+	for i, d := range docs {
+		findings := Scan(d.Content)
+		if len(findings) == 0 {
+			continue
+		}
+		found[i] = findings
+		source := d.ID
+		if source == "" {
+			source = d.SourceURI
+		}
+		e := s.event(SourceDocument, source, d.Content, findings)
+		if d.SourceURI != "" {
+			e = e.WithPayload("source_uri", d.SourceURI)
+		}
+		s.report(ctx, e, nil)
+	}
+	return found
+}
+
+// Process is a trusera.EventProcessor that scans the output, result,
+// response and content payloads of tool call events. Suspicious events get
+// "injection_suspected" metadata and a security event is reported; every
+// event is kept. Content hashed or removed by the client's capture level
+// cannot be scanned, so use ScanToolOutput with those.
+func (s *Scanner) Process(e trusera.Event) (trusera.Event, bool) {
+	if e.Type != trusera.EventToolCall {
+		return e, true
+	}
+	for _, key := range outputKeys {
+		text, ok := e.Payload[key].(string)
+		if !ok || text == "" {
+			continue
+		}
+		findings := Scan(text)
+		if len(findings) == 0 {
+			continue
+		}
+		s.report(context.Background(), s.event(SourceToolOutput, e.Name, text, findings).
+			WithPayload("event_id", e.ID), e.Metadata)
+		return e.WithMetadata("injection_suspected", true), true
+	}
+	return e, true
+}
+
+// event builds the security event for findings in text from source
+func (s *Scanner) event(sourceType, source, text string, findings []Finding) trusera.Event {
+	rules := map[string]bool{}
+	records := make([]map[string]any, len(findings))
+	for i, f := range findings {
+		rules[f.Rule] = true
+		records[i] = map[string]any{"rule": f.Rule, "pattern": f.Pattern, "offset": f.Offset, "count": f.Count}
+	}
+	names := make([]string, 0, len(rules))
+	for r := range rules {
+		names = append(names, r)
+	}
+	sort.Strings(names)
+
+	severity := trusera.SeverityMedium
+	if rules[InstructionOverride] || rules[DataExfiltration] {
+		severity = trusera.SeverityHigh
+	}
+	if len(rules) > 1 {
+		severity = trusera.SeverityCritical
+	}
+
+	e := trusera.NewEvent(trusera.EventSecurity, "prompt_injection").
+		WithPayload("source_type", sourceType).
+		WithPayload("rules", names).
+		WithPayload("findings", records).
+		WithPayload("severity", string(severity)).
+		WithPayload("content_hash", trusera.HashContent(text)).
+		WithPayload("message", fmt.Sprintf("suspicious %s: %s", strings.ReplaceAll(sourceType, "_", " "), strings.Join(names, ", ")))
+	if source != "" {
+		e = e.WithPayload("source", source)
+	}
+	return e
+}
+
+// contextTracker is implemented by trackers that link events to the active
+// span in a context, such as *trusera.Client and *trusera.Agent
+type contextTracker interface {
+	TrackContext(ctx context.Context, event trusera.Event)
+}
+
+// report tracks e with the attached tracker, copying the linked keys of
+// metadata
+func (s *Scanner) report(ctx context.Context, e trusera.Event, metadata map[string]any) {
+	s.mu.Lock()
+	tracker := s.tracker
+	s.mu.Unlock()
+	if tracker == nil {
+		return
+	}
+	for _, k := range linkedMetadata {
+		if v, ok := metadata[k]; ok {
+			e = e.WithMetadata(k, v)
+		}
+	}
+	if ct, ok := tracker.(contextTracker); ok {
+		ct.TrackContext(ctx, e)
+		return
+	}
+	tracker.Track(e)
+}
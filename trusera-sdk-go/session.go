@@ -0,0 +1,96 @@
+package trusera
+
+import "context"
+
+// Session is a handle for one user's multi-turn conversation. Events tracked
+// through it, and spans started from it, carry "session_id" and "user_id"
+// metadata so the backend can group the conversation and total its tokens
+// and cost. Like Agent, a Session shares its client's queue and transport.
+type Session struct {
+	client *Client
+	id     string
+	userID string
+	meta   map[string]any // Metadata stamped on events, never modified
+}
+
+var _ Tracker = (*Session)(nil)
+
+// StartSession returns a handle for the conversation sessionID of userID. An
+// empty sessionID starts a new conversation with a random ID; an empty
+// userID is omitted from events.
+func (c *Client) StartSession(userID, sessionID string) *Session {
+	return newSession(c, nil, userID, sessionID)
+}
+
+// StartSession returns a session handle whose events are also tagged with
+// the agent's ID
+func (a *Agent) StartSession(userID, sessionID string) *Session {
+	return newSession(a.client, a.tags(), userID, sessionID)
+}
+
+func newSession(c *Client, base map[string]any, userID, sessionID string) *Session {
+	if sessionID == "" {
+		sessionID = newUUID()
+	}
+	tags := map[string]any{"session_id": sessionID}
+	if userID != "" {
+		tags["user_id"] = userID
+	}
+	return &Session{client: c, id: sessionID, userID: userID, meta: mergeTags(base, tags)}
+}
+
+// ID returns the session ID events are tagged with
+func (s *Session) ID() string {
+	return s.id
+}
+
+// UserID returns the user the session belongs to, or ""
+func (s *Session) UserID() string {
+	return s.userID
+}
+
+// Client returns the client the handle shares
+func (s *Session) Client() *Client {
+	return s.client
+}
+
+// Track queues an event tagged with the session
+func (s *Session) Track(event Event) {
+	s.client.Track(withTags(event, s.meta))
+}
+
+// TrackContext tracks an event as a child of the active span in ctx, if any
+func (s *Session) TrackContext(ctx context.Context, event Event) {
+	trackContext(s, ctx, event)
+}
+
+// Flush flushes the shared client, including events outside the session
+func (s *Session) Flush() error {
+	return s.client.Flush()
+}
+
+// Close is a no-op: the shared client is closed by its owner
+func (s *Session) Close() error {
+	return nil
+}
+
+// RegisterAgent registers an agent with Trusera through the shared client
+func (s *Session) RegisterAgent(name, framework string) (string, error) {
+	return s.client.RegisterAgent(name, framework)
+}
+
+// StartRun begins a new trace, such as one conversation turn, whose spans
+// and events are tagged with the session
+func (s *Session) StartRun(name string) *Run {
+	run := s.client.StartRun(name)
+	run.tags = s.meta
+	return run
+}
+
+// StartSpan begins a span under the active span in ctx, or a new run if ctx
+// has none, tagged with the session
+func (s *Session) StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	ctx, span := s.client.StartSpan(ctx, name)
+	span.tags = mergeTags(span.tags, s.meta)
+	return ctx, span
+}
@@ -0,0 +1,55 @@
+package trusera
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSessionTagsEvents(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	session := client.StartSession("user-1", "conv-9")
+	anonymous := client.StartSession("", "")
+	if anonymous.ID() == "" || anonymous.ID() == session.ID() {
+		t.Fatalf("expected a fresh session ID, got %q", anonymous.ID())
+	}
+
+	session.Track(NewEvent(EventLLMInvoke, "gpt-4o"))
+	anonymous.Track(NewEvent(EventLLMInvoke, "gpt-4o"))
+
+	events := queuedEvents(client)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Metadata["session_id"] != "conv-9" || events[0].Metadata["user_id"] != "user-1" {
+		t.Errorf("expected session and user tags, got %v", events[0].Metadata)
+	}
+	if _, ok := events[1].Metadata["user_id"]; ok || events[1].Metadata["session_id"] != anonymous.ID() {
+		t.Errorf("expected only the session tag, got %v", events[1].Metadata)
+	}
+}
+
+func TestSessionSpansAndAgents(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	session := client.ForAgent("support").StartSession("user-1", "conv-9")
+	turn := session.StartRun("turn-1")
+	ctx := ContextWithSpan(context.Background(), turn.Span)
+	_, step := client.StartSpan(ctx, "retrieve")
+	step.AddEvent(NewEvent(EventToolCall, "search"))
+	step.End()
+	session.TrackContext(ctx, NewEvent(EventLLMInvoke, "gpt-4o"))
+	turn.End()
+
+	events := queuedEvents(client)
+	if len(events) != 4 {
+		t.Fatalf("expected 4 events, got %d", len(events))
+	}
+	for _, e := range events {
+		if e.Metadata["session_id"] != "conv-9" || e.Metadata["agent_id"] != "support" || e.Metadata["trace_id"] != turn.TraceID() {
+			t.Errorf("expected session, agent and trace tags on %s %q, got %v", e.Type, e.Name, e.Metadata)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package trusera
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Headers carrying the detached signature of a request body
+const (
+	SignatureHeader      = "X-Trusera-Signature"        // Base64 signature of the body
+	SignatureKeyIDHeader = "X-Trusera-Signature-Key-Id" // SigningKeyID of the public key
+)
+
+// ErrInvalidSignature is returned by VerifySignature when a signature does
+// not match the body
+var ErrInvalidSignature = errors.New("trusera: invalid signature")
+
+// WithSigningKey signs every uploaded BOM and event batch with signer, so the
+// API can verify where payloads came from and that no proxy changed them.
+// The signature of the exact request body, after compression, is sent in the
+// X-Trusera-Signature header, along with the key ID in
+// X-Trusera-Signature-Key-Id. An ed25519.PrivateKey signs the body itself;
+// other keys, such as ECDSA keys or KMS-backed signers, sign its SHA-256
+// digest as cosign does. A GRPCTransport given to the client signs the
+// length-prefixed gRPC message it sends. Batches sent by WithStreaming are not
+// signed.
+func WithSigningKey(signer crypto.Signer) Option {
+	return func(c *Client) {
+		c.signer = signer
+	}
+}
+
+// SigningKeyID identifies a public key: the hex-encoded SHA-256 of its PKIX
+// encoding
+func SigningKeyID(pub crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// signRequest sets the signature headers of req for body
+func signRequest(req *http.Request, signer crypto.Signer, body []byte) error {
+	keyID, err := SigningKeyID(signer.Public())
+	if err != nil {
+		return fmt.Errorf("failed to sign payload: %w", err)
+	}
+	var sig []byte
+	if _, ok := signer.Public().(ed25519.PublicKey); ok {
+		sig, err = signer.Sign(rand.Reader, body, crypto.Hash(0))
+	} else {
+		digest := sha256.Sum256(body)
+		sig, err = signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sign payload: %w", err)
+	}
+	req.Header.Set(SignatureHeader, base64.StdEncoding.EncodeToString(sig))
+	req.Header.Set(SignatureKeyIDHeader, keyID)
+	return nil
+}
+
+// VerifySignature checks a base64 signature from the X-Trusera-Signature
+// header against the request body it was sent with. Ed25519, ECDSA and RSA
+// (PKCS #1 v1.5) public keys are supported.
+func VerifySignature(pub crypto.PublicKey, body []byte, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	digest := sha256.Sum256(body)
+
+	var ok bool
+	switch pub := pub.(type) {
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(pub, body, sig)
+	case *ecdsa.PublicKey:
+		ok = ecdsa.VerifyASN1(pub, digest[:], sig)
+	case *rsa.PublicKey:
+		ok = rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+	if !ok {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package trusera
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Trusera/ai-bom/trusera-sdk-go/bom"
+)
+
+func TestSigningKeySignsBatchesAndBOMs(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	keyID, err := SigningKeyID(pub)
+	if err != nil {
+		t.Fatalf("SigningKeyID failed: %v", err)
+	}
+
+	var mu sync.Mutex
+	verified := map[string]error{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		err := VerifySignature(pub, body, r.Header.Get(SignatureHeader))
+		if err == nil && r.Header.Get(SignatureKeyIDHeader) != keyID {
+			err = errors.New("unexpected key ID " + r.Header.Get(SignatureKeyIDHeader))
+		}
+		mu.Lock()
+		verified[r.URL.Path] = err
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithSigningKey(priv), WithCompression("gzip"))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "search"))
+	if err := client.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if err := client.UploadBOM(bom.NewBuilder("agent").Build()); err != nil {
+		t.Fatalf("UploadBOM failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, path := range []string{"/v1/events", "/v1/bom"} {
+		if err, ok := verified[path]; !ok || err != nil {
+			t.Errorf("expected valid signature on %s, got %v (sent: %v)", path, err, ok)
+		}
+	}
+}
+
+func TestVerifySignatureECDSA(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	body := []byte(`{"agent_id":"a","events":[]}`)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/events", nil)
+	if err := signRequest(req, key, body); err != nil {
+		t.Fatalf("signRequest failed: %v", err)
+	}
+	sig := req.Header.Get(SignatureHeader)
+
+	if err := VerifySignature(&key.PublicKey, body, sig); err != nil {
+		t.Errorf("expected valid signature, got %v", err)
+	}
+	if err := VerifySignature(&key.PublicKey, []byte(`{"agent_id":"b","events":[]}`), sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for tampered body, got %v", err)
+	}
+	if err := VerifySignature(&key.PublicKey, body, "not base64!"); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for malformed signature, got %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package trusera
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SlogHandlerOptions configures NewSlogHandler
+type SlogHandlerOptions struct {
+	// Level is the minimum level forwarded as events (default slog.LevelWarn)
+	Level slog.Leveler
+
+	// RatePerSecond limits how many records per second are forwarded, with
+	// bursts of up to Burst records. Zero means no limit.
+	RatePerSecond float64
+	Burst         int
+
+	// AddSource records the file and line of the log call
+	AddSource bool
+}
+
+// slogHandler implements slog.Handler by tracking records as EventLog events
+type slogHandler struct {
+	client *Client
+	level  slog.Leveler
+	allow  SamplerFunc
+	source bool
+	attrs  map[string]any
+	group  string
+}
+
+// NewSlogHandler returns a slog.Handler that forwards log records at or above
+// the configured level to client as EventLog events, with their attributes as
+// payload. Records are linked to the active span in the logging context.
+// Pass nil opts for the defaults.
+func NewSlogHandler(client *Client, opts *SlogHandlerOptions) slog.Handler {
+	if opts == nil {
+		opts = &SlogHandlerOptions{}
+	}
+	h := &slogHandler{client: client, level: opts.Level, source: opts.AddSource}
+	if h.level == nil {
+		h.level = slog.LevelWarn
+	}
+	if opts.RatePerSecond > 0 {
+		h.allow = RateLimited(opts.RatePerSecond, opts.Burst)
+	}
+	return h
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	// The SDK's own diagnostics would otherwise feed back into the queue
+	if ctx.Value(sdkLogKey{}) != nil || strings.HasPrefix(r.Message, "[trusera]") {
+		return nil
+	}
+
+	event := NewEvent(EventLog, r.Message).
+		WithPayload("level", r.Level.String()).
+		WithPayload("message", r.Message)
+	if !r.Time.IsZero() {
+		event.Timestamp = r.Time.UTC().Format(time.RFC3339Nano)
+	}
+	if h.allow != nil && !h.allow(event) {
+		h.client.mu.Lock()
+		h.client.stats.sampledOut++
+		h.client.mu.Unlock()
+		return nil
+	}
+
+	attrs := make(map[string]any, len(h.attrs)+r.NumAttrs())
+	for k, v := range h.attrs {
+		attrs[k] = v
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addSlogAttr(attrs, h.group, a)
+		return true
+	})
+	if len(attrs) > 0 {
+		event = event.WithPayload("attributes", attrs)
+	}
+
+	if h.source && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		if frame.File != "" {
+			event = event.WithPayload("source", frame.File+":"+strconv.Itoa(frame.Line))
+		}
+	}
+
+	h.client.TrackContext(ctx, event)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(as []slog.Attr) slog.Handler {
+	if len(as) == 0 {
+		return h
+	}
+	clone := *h
+	clone.attrs = make(map[string]any, len(h.attrs)+len(as))
+	for k, v := range h.attrs {
+		clone.attrs[k] = v
+	}
+	for _, a := range as {
+		addSlogAttr(clone.attrs, h.group, a)
+	}
+	return &clone
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.group = h.group + name + "."
+	return &clone
+}
+
+// addSlogAttr flattens a into m, joining group names with dots
+func addSlogAttr(m map[string]any, prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		group := v.Group()
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range group {
+			addSlogAttr(m, prefix, ga)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+
+	switch v.Kind() {
+	case slog.KindTime:
+		m[prefix+a.Key] = v.Time().UTC().Format(time.RFC3339Nano)
+	case slog.KindDuration:
+		m[prefix+a.Key] = v.Duration().String()
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			m[prefix+a.Key] = err.Error()
+			return
+		}
+		m[prefix+a.Key] = v.Any()
+	default:
+		m[prefix+a.Key] = v.Any()
+	}
+}
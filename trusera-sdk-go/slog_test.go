@@ -0,0 +1,104 @@
+package trusera
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogHandlerLevelThreshold(t *testing.T) {
+	client := NewClient("test-key")
+	defer client.Close()
+
+	logger := slog.New(NewSlogHandler(client, nil))
+	logger.Info("ignored")
+	logger.Warn("disk almost full", "free_mb", 42)
+	logger.Error("tool failed", "err", errors.New("timeout"))
+
+	events := queuedEvents(client)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Type != EventLog || events[0].Name != "disk almost full" {
+		t.Errorf("expected log event, got %s %s", events[0].Type, events[0].Name)
+	}
+	if events[0].Payload["level"] != "WARN" {
+		t.Errorf("expected level WARN, got %v", events[0].Payload["level"])
+	}
+	attrs, _ := events[0].Payload["attributes"].(map[string]any)
+	if attrs["free_mb"] != int64(42) {
+		t.Errorf("expected free_mb attribute, got %v", attrs["free_mb"])
+	}
+	attrs, _ = events[1].Payload["attributes"].(map[string]any)
+	if attrs["err"] != "timeout" {
+		t.Errorf("expected error rendered as string, got %v", attrs["err"])
+	}
+}
+
+func TestSlogHandlerAttrsAndGroups(t *testing.T) {
+	client := NewClient("test-key")
+	defer client.Close()
+
+	logger := slog.New(NewSlogHandler(client, &SlogHandlerOptions{Level: slog.LevelInfo, AddSource: true})).
+		With("agent", "planner").
+		WithGroup("req").
+		With("id", "r-1")
+	logger.Info("handled", slog.Group("llm", "model", "gpt-4o"), "status", 200)
+
+	events := queuedEvents(client)
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	attrs, _ := events[0].Payload["attributes"].(map[string]any)
+	want := map[string]any{
+		"agent":         "planner",
+		"req.id":        "r-1",
+		"req.llm.model": "gpt-4o",
+		"req.status":    int64(200),
+	}
+	for k, v := range want {
+		if attrs[k] != v {
+			t.Errorf("expected %s=%v, got %v", k, v, attrs[k])
+		}
+	}
+	if src, _ := events[0].Payload["source"].(string); !strings.Contains(src, "slog_test.go:") {
+		t.Errorf("expected source location, got %q", src)
+	}
+}
+
+func TestSlogHandlerRateLimit(t *testing.T) {
+	client := NewClient("test-key")
+	defer client.Close()
+
+	logger := slog.New(NewSlogHandler(client, &SlogHandlerOptions{RatePerSecond: 0.001, Burst: 3}))
+	for i := 0; i < 10; i++ {
+		logger.Error("flapping")
+	}
+
+	if n := len(queuedEvents(client)); n != 3 {
+		t.Errorf("expected 3 events within burst, got %d", n)
+	}
+	if s := client.Stats(); s.SampledOut != 7 {
+		t.Errorf("expected 7 sampled out, got %d", s.SampledOut)
+	}
+}
+
+func TestSlogHandlerLinksSpanAndSkipsOwnDiagnostics(t *testing.T) {
+	client := NewClient("test-key")
+	defer client.Close()
+
+	logger := slog.New(NewSlogHandler(client, nil))
+	ctx, span := client.StartSpan(context.Background(), "run")
+	logger.WarnContext(ctx, "retrying")
+	logger.Warn("[trusera] fleet heartbeat failed")
+
+	events := queuedEvents(client)
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	if events[0].Metadata["trace_id"] != span.TraceID() {
+		t.Errorf("expected trace %s, got %v", span.TraceID(), events[0].Metadata["trace_id"])
+	}
+}
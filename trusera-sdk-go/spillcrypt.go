@@ -0,0 +1,94 @@
+package trusera
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// spillMagic starts every encrypted spill file, followed by the nonce and
+// the AES-GCM sealed batch
+var spillMagic = []byte("TRUSERA-AESGCM1\n")
+
+// errSpillKeyMissing is returned for an encrypted spill file when no key is set
+var errSpillKeyMissing = errors.New("spill file is encrypted and no spill key is set")
+
+// errSpillUnencrypted is returned for a plaintext spill file when a key is
+// set, as anyone able to write to the spill directory could have planted it
+var errSpillUnencrypted = errors.New("spill file is not encrypted although a spill key is set")
+
+// WithSpillEncryption encrypts batches written by WithSpillDir with AES-GCM,
+// so prompts buffered on disk during an outage cannot be read by other users
+// of the host. key must be 16, 24 or 32 bytes, selecting AES-128, AES-192 or
+// AES-256; fetch it from a KMS or secret store at startup. Without this
+// option the base64-encoded key in TRUSERA_SPILL_KEY is used, if set.
+// Files that cannot be decrypted, and unencrypted files such as those left by
+// runs without a key, are moved to the quarantine subdirectory instead of
+// being replayed; Replay sends them once they have been checked.
+func WithSpillEncryption(key []byte) Option {
+	return func(c *Client) {
+		c.spillKey = append([]byte(nil), key...)
+	}
+}
+
+// resolveSpillKey applies TRUSERA_SPILL_KEY if no key was set and prepares
+// the cipher
+func (c *Client) resolveSpillKey() error {
+	if c.spillKey == nil {
+		if v := os.Getenv("TRUSERA_SPILL_KEY"); v != "" {
+			key, err := base64.StdEncoding.DecodeString(v)
+			if err != nil {
+				return fmt.Errorf("TRUSERA_SPILL_KEY is not base64: %w", err)
+			}
+			c.spillKey = key
+		}
+	}
+	if c.spillKey == nil {
+		return nil
+	}
+	block, err := aes.NewCipher(c.spillKey)
+	if err != nil {
+		return fmt.Errorf("invalid spill key: %w", err)
+	}
+	c.spillAEAD, err = cipher.NewGCM(block)
+	return err
+}
+
+// sealSpill encrypts a batch if a spill key is set. The file name is
+// authenticated so batches cannot be swapped between files unnoticed.
+func (c *Client) sealSpill(name string, data []byte) ([]byte, error) {
+	if c.spillAEAD == nil {
+		return data, nil
+	}
+	nonce := make([]byte, c.spillAEAD.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := append(append([]byte(nil), spillMagic...), nonce...)
+	return c.spillAEAD.Seal(out, nonce, data, []byte(name)), nil
+}
+
+// openSpill decrypts a spill file written by sealSpill. Unencrypted files are
+// returned unchanged unless a spill key is set.
+func (c *Client) openSpill(name string, data []byte) ([]byte, error) {
+	sealed, ok := bytes.CutPrefix(data, spillMagic)
+	if !ok {
+		if c.spillAEAD != nil {
+			return nil, errSpillUnencrypted
+		}
+		return data, nil
+	}
+	if c.spillAEAD == nil {
+		return nil, errSpillKeyMissing
+	}
+	n := c.spillAEAD.NonceSize()
+	if len(sealed) < n {
+		return nil, errors.New("spill file is truncated")
+	}
+	return c.spillAEAD.Open(nil, sealed[:n], sealed[n:], []byte(name))
+}
@@ -0,0 +1,148 @@
+package trusera
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// spillOne spills a single event with the given options and returns the
+// file it was written to
+func spillOne(t *testing.T, dir string, opts ...Option) string {
+	t.Helper()
+	ft := &flakyTransport{}
+	ft.down.Store(true)
+	client := NewClient("test-key", append([]Option{WithTransport(ft), WithCircuitBreaker(1, time.Hour), WithSpillDir(dir)}, opts...)...)
+	client.Track(NewEvent(EventLLMInvoke, "secret-prompt"))
+	client.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "batch-*.json"))
+	if len(files) != 1 {
+		t.Fatalf("expected 1 spill file, got %d", len(files))
+	}
+	return files[0]
+}
+
+func TestSpillEncryption(t *testing.T) {
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{7}, 32)
+	path := spillOne(t, dir, WithSpillEncryption(key))
+
+	data, _ := os.ReadFile(path)
+	if bytes.Contains(data, []byte("secret-prompt")) || !bytes.HasPrefix(data, spillMagic) {
+		t.Fatalf("expected encrypted spill file, got %q", data)
+	}
+
+	t.Setenv("TRUSERA_SPILL_KEY", base64.StdEncoding.EncodeToString(key))
+	ft := &flakyTransport{}
+	withKey := NewClient("test-key", WithTransport(ft), WithSpillDir(dir))
+	defer withKey.Close()
+	if err := withKey.Flush(); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if ft.sent.Load() != 1 {
+		t.Errorf("expected 1 replayed event, got %d", ft.sent.Load())
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected spill file removed after replay, got %v", err)
+	}
+}
+
+func TestSpillEncryptionQuarantinesUndecryptableFiles(t *testing.T) {
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{7}, 32)
+	path := spillOne(t, dir, WithSpillEncryption(key))
+
+	// Without the key the file is moved out of the spill budget
+	ft := &flakyTransport{}
+	noKey := NewClient("test-key", WithTransport(ft), WithSpillDir(dir))
+	noKey.Flush()
+	noKey.Close()
+	if ft.sent.Load() != 0 {
+		t.Errorf("expected nothing replayed without the key, got %d", ft.sent.Load())
+	}
+	quarantined := filepath.Join(dir, spillQuarantineDir, filepath.Base(path))
+	if _, err := os.Stat(quarantined); err != nil {
+		t.Fatalf("expected undecryptable spill file quarantined, got %v", err)
+	}
+	if files, _ := noKey.spillFiles(); len(files) != 0 {
+		t.Errorf("expected quarantined files not counted, got %v", files)
+	}
+
+	// Replay decrypts it with the key, authenticating the original name
+	withKey := NewClient("test-key", WithTransport(ft), WithSpillEncryption(key))
+	defer withKey.Close()
+	f, err := os.Open(quarantined)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	n, err := withKey.Replay(context.Background(), f)
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 event replayed, got %d (%v)", n, err)
+	}
+	if ft.sent.Load() != 1 {
+		t.Errorf("expected 1 event sent, got %d", ft.sent.Load())
+	}
+}
+
+func TestReplayEncryptedSpillRequiresName(t *testing.T) {
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{7}, 32)
+	data, _ := os.ReadFile(spillOne(t, dir, WithSpillEncryption(key)))
+
+	client := NewClient("test-key", WithTransport(&flakyTransport{}), WithSpillEncryption(key))
+	defer client.Close()
+	if _, err := client.Replay(context.Background(), bytes.NewReader(data)); err == nil {
+		t.Error("expected an unnamed encrypted spill file to fail")
+	}
+}
+
+func TestSpillEncryptionRejectsTampering(t *testing.T) {
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{7}, 16)
+	path := spillOne(t, dir, WithSpillEncryption(key))
+
+	// A batch moved to another file name no longer authenticates
+	moved := filepath.Join(dir, "batch-99999999999999999999-000001.json")
+	os.Rename(path, moved)
+
+	ft := &flakyTransport{}
+	client := NewClient("test-key", WithTransport(ft), WithSpillDir(dir), WithSpillEncryption(key))
+	defer client.Close()
+	client.Flush()
+	if ft.sent.Load() != 0 {
+		t.Errorf("expected tampered batch not replayed, got %d events", ft.sent.Load())
+	}
+}
+
+func TestSpillEncryptionQuarantinesPlaintextFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := spillOne(t, dir)
+
+	ft := &flakyTransport{}
+	client := NewClient("test-key", WithTransport(ft), WithSpillDir(dir), WithSpillEncryption(bytes.Repeat([]byte{1}, 32)))
+	defer client.Close()
+	client.Flush()
+	if ft.sent.Load() != 0 {
+		t.Errorf("expected plaintext batch not replayed, got %d events", ft.sent.Load())
+	}
+	quarantined := filepath.Join(dir, spillQuarantineDir, filepath.Base(path))
+	if _, err := os.Stat(quarantined); err != nil {
+		t.Fatalf("expected plaintext spill file quarantined, got %v", err)
+	}
+
+	// Once checked, it can be replayed explicitly
+	f, err := os.Open(quarantined)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if n, err := client.Replay(context.Background(), f); err != nil || n != 1 {
+		t.Fatalf("expected 1 event replayed, got %d (%v)", n, err)
+	}
+}
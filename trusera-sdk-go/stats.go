@@ -0,0 +1,100 @@
+package trusera
+
+import (
+	"errors"
+	"time"
+)
+
+// Stats is a snapshot of the client's internal counters
+type Stats struct {
+	Queued            int           `json:"queued"`              // Events waiting to be sent
+	Tracked           uint64        `json:"tracked"`             // Events accepted by Track
+	Flushed           uint64        `json:"flushed"`             // Events delivered to the API
+	Dropped           uint64        `json:"dropped"`             // Events discarded without being delivered
+	SampledOut        uint64        `json:"sampled_out"`         // Events discarded by sampling
+	Filtered          uint64        `json:"filtered"`            // Events discarded by event processors or event policies
+	Truncated         uint64        `json:"truncated"`           // Events shortened to fit the batch size limit
+	DeadLettered      uint64        `json:"dead_lettered"`       // Events rejected by the API and written to the dead-letter file
+	Invalid           uint64        `json:"invalid"`             // Events dropped by WithStrictValidation
+	Flushes           uint64        `json:"flushes"`             // Delivery attempts
+	FlushErrors       uint64        `json:"flush_errors"`        // Failed delivery attempts
+	FlushDuration     time.Duration `json:"flush_duration"`      // Cumulative time spent delivering
+	LastFlushDuration time.Duration `json:"last_flush_duration"` // Duration of the most recent attempt
+	LastFlushAt       time.Time     `json:"last_flush_at"`       // When the most recent attempt finished
+	LastFlushError    string        `json:"last_flush_error"`    // Error of the most recent attempt, empty if it succeeded
+	HeartbeatFailures uint64        `json:"heartbeat_failures"`  // Failed fleet heartbeats
+	RateLimited       uint64        `json:"rate_limited"`        // Delivery attempts rejected with 429
+}
+
+// counters holds the mutable values behind Stats
+type counters struct {
+	tracked           uint64
+	flushed           uint64
+	dropped           uint64
+	sampledOut        uint64
+	filtered          uint64
+	truncated         uint64
+	deadLettered      uint64
+	invalid           uint64
+	flushes           uint64
+	flushErrors       uint64
+	flushDuration     time.Duration
+	lastFlushDuration time.Duration
+	lastFlushAt       time.Time
+	lastFlushError    string
+	heartbeatFailures uint64
+	rateLimited       uint64
+}
+
+// Stats returns a snapshot of the client's internal counters
+func (c *Client) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return Stats{
+		Queued:            len(c.events),
+		Tracked:           c.stats.tracked,
+		Flushed:           c.stats.flushed,
+		Dropped:           c.stats.dropped,
+		SampledOut:        c.stats.sampledOut,
+		Filtered:          c.stats.filtered,
+		Truncated:         c.stats.truncated,
+		DeadLettered:      c.stats.deadLettered,
+		Invalid:           c.stats.invalid,
+		Flushes:           c.stats.flushes,
+		FlushErrors:       c.stats.flushErrors,
+		FlushDuration:     c.stats.flushDuration,
+		LastFlushDuration: c.stats.lastFlushDuration,
+		LastFlushAt:       c.stats.lastFlushAt,
+		LastFlushError:    c.stats.lastFlushError,
+		HeartbeatFailures: c.stats.heartbeatFailures,
+		RateLimited:       c.stats.rateLimited,
+	}
+}
+
+// recordFlush updates delivery counters after an attempt to send n events
+func (c *Client) recordFlush(n int, d time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.flushes++
+	c.stats.flushDuration += d
+	c.stats.lastFlushDuration = d
+	c.stats.lastFlushAt = time.Now()
+	c.stats.lastFlushError = ""
+	if err != nil {
+		c.stats.flushErrors++
+		c.stats.lastFlushError = err.Error()
+		if errors.Is(err, ErrRateLimited) {
+			c.stats.rateLimited++
+		}
+		return
+	}
+	c.stats.flushed += uint64(n)
+}
+
+func (c *Client) recordHeartbeatFailure() {
+	c.mu.Lock()
+	c.stats.heartbeatFailures++
+	c.mu.Unlock()
+}
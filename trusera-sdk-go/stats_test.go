@@ -0,0 +1,83 @@
+package trusera
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	if s := client.Stats(); s.Queued != 0 || s.Dropped != 0 {
+		t.Errorf("expected empty stats, got %+v", s)
+	}
+
+	client.Track(NewEvent(EventToolCall, "a"))
+	client.Track(NewEvent(EventToolCall, "b"))
+
+	if got := client.Stats().Queued; got != 2 {
+		t.Errorf("expected 2 queued, got %d", got)
+	}
+}
+
+func TestStatsCountsEventsDroppedOnClose(t *testing.T) {
+	client := NewClient("test-key", WithBaseURL("http://127.0.0.1:1"), WithBatchSize(1000))
+	client.Track(NewEvent(EventToolCall, "a"))
+	client.Close()
+
+	if got := client.Stats().Dropped; got != 1 {
+		t.Errorf("expected 1 dropped, got %d", got)
+	}
+}
+
+func TestStatsRecordsFlushes(t *testing.T) {
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithBatchSize(1000))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "a"))
+	client.Track(NewEvent(EventToolCall, "b"))
+	if err := client.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	fail = true
+	client.Track(NewEvent(EventToolCall, "c"))
+	_ = client.Flush()
+
+	s := client.Stats()
+	if s.Tracked != 3 || s.Flushed != 2 || s.Flushes != 2 || s.FlushErrors != 1 || s.Dropped != 1 {
+		t.Errorf("unexpected stats: %+v", s)
+	}
+	if s.FlushDuration <= 0 || s.LastFlushDuration <= 0 {
+		t.Errorf("expected flush durations to be recorded, got %+v", s)
+	}
+}
+
+func TestStatsRecordsHeartbeatFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	defer client.Close()
+
+	client.fleetAgentID = "fleet-1"
+	client.sendHeartbeat()
+
+	if got := client.Stats().HeartbeatFailures; got != 1 {
+		t.Errorf("expected 1 heartbeat failure, got %d", got)
+	}
+}
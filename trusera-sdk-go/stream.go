@@ -0,0 +1,337 @@
+package trusera
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// streamPath is the WebSocket endpoint events are streamed to
+const streamPath = "/v1/events/stream"
+
+// defaultStreamRedialDelay is how long a StreamTransport waits before trying
+// to reconnect after a failed handshake, using its fallback in the meantime
+const defaultStreamRedialDelay = 30 * time.Second
+
+// websocketGUID is appended to the handshake key to compute the accept value (RFC 6455)
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket opcodes used by StreamTransport
+const (
+	wsText  = 0x1
+	wsClose = 0x8
+	wsPing  = 0x9
+	wsPong  = 0xA
+)
+
+// ErrStreamClosed is returned by StreamTransport.Send after Close
+var ErrStreamClosed = errors.New("trusera: stream closed")
+
+// WithStreaming delivers every event as soon as it is tracked over a
+// persistent WebSocket connection to /v1/events/stream, instead of batching
+// until the flush interval or batch size is reached. While the connection
+// cannot be established, events are posted to /v1/events as usual.
+// Compression does not apply to streamed events.
+func WithStreaming() Option {
+	return func(c *Client) {
+		c.streaming = true
+	}
+}
+
+// StreamTransport sends each batch as a JSON text message over a persistent
+// WebSocket connection. It connects on the first Send and reconnects after
+// the connection drops; a batch whose write fails is reported as an error so
+// the client can retry it.
+type StreamTransport struct {
+	BaseURL    string
+	APIKey     string
+	HTTPClient *http.Client // Used for the upgrade handshake
+	// Fallback delivers batches while no connection can be established.
+	// Without one, Send returns the handshake error.
+	Fallback Transport
+	// RedialDelay is how long to wait after a failed handshake before the
+	// next attempt. Defaults to 30s.
+	RedialDelay time.Duration
+
+	keyFunc func() string // Overrides APIKey so the client can rotate keys
+
+	mu         sync.Mutex
+	conn       io.ReadWriteCloser
+	closed     bool
+	nextDial   time.Time
+	writeMu    sync.Mutex
+	readerDone chan struct{}
+}
+
+// NewStreamTransport creates a WebSocket stream transport for baseURL
+func NewStreamTransport(baseURL, apiKey string) *StreamTransport {
+	return &StreamTransport{BaseURL: baseURL, APIKey: apiKey}
+}
+
+// streamMessage is the JSON message carrying one batch
+type streamMessage struct {
+	AgentID        string  `json:"agent_id"`
+	Events         []Event `json:"events"`
+	IdempotencyKey string  `json:"idempotency_key"`
+}
+
+// Send writes a batch to the stream, connecting first if needed
+func (t *StreamTransport) Send(ctx context.Context, batch Batch) error {
+	conn, err := t.connection(ctx)
+	if err != nil {
+		if t.Fallback != nil && !errors.Is(err, ErrStreamClosed) {
+			return t.Fallback.Send(ctx, batch)
+		}
+		return err
+	}
+
+	msg, err := json.Marshal(streamMessage{AgentID: batch.AgentID, Events: batch.Events, IdempotencyKey: batch.idempotencyKey()})
+	if err != nil {
+		return fmt.Errorf("failed to marshal events: %w: %w", ErrUnencodable, err)
+	}
+
+	t.writeMu.Lock()
+	err = writeFrame(conn, wsText, msg)
+	t.writeMu.Unlock()
+	if err != nil {
+		t.drop(conn)
+		return fmt.Errorf("failed to stream events: %w", err)
+	}
+	return nil
+}
+
+// Close closes the connection. Later sends return ErrStreamClosed.
+func (t *StreamTransport) Close() error {
+	t.mu.Lock()
+	t.closed = true
+	conn, done := t.conn, t.readerDone
+	t.conn = nil
+	t.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+	t.writeMu.Lock()
+	_ = writeFrame(conn, wsClose, []byte{0x03, 0xE8}) // 1000: normal closure
+	t.writeMu.Unlock()
+	err := conn.Close()
+	<-done
+	return err
+}
+
+// Connected reports whether the stream currently has an open connection
+func (t *StreamTransport) Connected() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.conn != nil
+}
+
+// connection returns the open connection, dialing one if none is open and
+// the redial delay after the last failure has passed
+func (t *StreamTransport) connection(ctx context.Context) (io.ReadWriteCloser, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return nil, ErrStreamClosed
+	}
+	if t.conn != nil {
+		return t.conn, nil
+	}
+	if time.Now().Before(t.nextDial) {
+		return nil, errors.New("stream reconnect pending")
+	}
+
+	conn, err := t.dial(ctx)
+	if err != nil {
+		delay := t.RedialDelay
+		if delay <= 0 {
+			delay = defaultStreamRedialDelay
+		}
+		t.nextDial = time.Now().Add(delay)
+		return nil, fmt.Errorf("stream handshake failed: %w", err)
+	}
+	t.conn = conn
+	t.readerDone = make(chan struct{})
+	go t.readLoop(conn, t.readerDone)
+	return conn, nil
+}
+
+// drop closes a broken connection so the next Send reconnects
+func (t *StreamTransport) drop(conn io.ReadWriteCloser) {
+	t.mu.Lock()
+	if t.conn == conn {
+		t.conn = nil
+	}
+	t.mu.Unlock()
+	conn.Close()
+}
+
+// dial performs the WebSocket upgrade handshake
+func (t *StreamTransport) dial(ctx context.Context) (io.ReadWriteCloser, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	// The upgraded connection outlives the request, so it must not be tied
+	// to a caller's deadline or the client's overall timeout
+	req, err := http.NewRequestWithContext(context.WithoutCancel(ctx), http.MethodGet, t.BaseURL+streamPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	apiKey := t.APIKey
+	if t.keyFunc != nil {
+		apiKey = t.keyFunc()
+	}
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+
+	hc := http.Client{}
+	if t.HTTPClient != nil {
+		hc = *t.HTTPClient
+		hc.Timeout = 0
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		defer resp.Body.Close()
+		return nil, newAPIError(resp)
+	}
+	conn, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		resp.Body.Close()
+		return nil, errors.New("connection does not support upgrades")
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != websocketAccept(key) {
+		conn.Close()
+		return nil, errors.New("invalid Sec-WebSocket-Accept")
+	}
+	return conn, nil
+}
+
+// readLoop answers pings and notices when the server closes the connection.
+// Data messages from the server are ignored.
+func (t *StreamTransport) readLoop(conn io.ReadWriteCloser, done chan struct{}) {
+	defer close(done)
+	r := bufio.NewReader(conn)
+	for {
+		opcode, payload, err := readFrame(r)
+		if err != nil {
+			t.drop(conn)
+			return
+		}
+		switch opcode {
+		case wsPing:
+			t.writeMu.Lock()
+			err = writeFrame(conn, wsPong, payload)
+			t.writeMu.Unlock()
+			if err != nil {
+				t.drop(conn)
+				return
+			}
+		case wsClose:
+			t.drop(conn)
+			return
+		}
+	}
+}
+
+// websocketAccept computes the Sec-WebSocket-Accept value for a key
+func websocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// writeFrame writes a single masked frame, as required of clients
+func writeFrame(w io.Writer, opcode byte, payload []byte) error {
+	header := make([]byte, 2, 14)
+	header[0] = 0x80 | opcode // FIN
+	switch n := len(payload); {
+	case n < 126:
+		header[1] = 0x80 | byte(n)
+	case n <= 0xFFFF:
+		header[1] = 0x80 | 126
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header[1] = 0x80 | 127
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	var mask [4]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		return err
+	}
+	frame := make([]byte, 0, len(header)+4+len(payload))
+	frame = append(append(frame, header...), mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, err := w.Write(frame)
+	return err
+}
+
+// maxStreamFrame bounds the frames read from the server
+const maxStreamFrame = 1 << 20
+
+// readFrame reads one frame and returns its opcode and unmasked payload
+func readFrame(r io.Reader) (byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	opcode := head[0] & 0x0F
+	masked := head[1]&0x80 != 0
+
+	n := uint64(head[1] & 0x7F)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if n > maxStreamFrame {
+		return 0, nil, fmt.Errorf("frame of %d bytes exceeds limit", n)
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(r, mask[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return opcode, payload, nil
+}
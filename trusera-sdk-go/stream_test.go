@@ -0,0 +1,257 @@
+package trusera
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// streamServer accepts WebSocket connections on /v1/events/stream and
+// records the messages it receives. onMessage, if set, runs after each one
+// with a function writing a frame back to the client.
+type streamServer struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	handshakes int
+	auth       string
+	messages   []streamMessage
+	pongs      [][]byte
+	received   chan struct{}
+	onMessage  func(n int, send func(opcode byte, payload []byte))
+	posted     []Batch
+}
+
+func newStreamServer(t *testing.T) *streamServer {
+	t.Helper()
+	s := &streamServer{received: make(chan struct{}, 100)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *streamServer) handle(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/v1/events" {
+		var batch Batch
+		_ = json.NewDecoder(r.Body).Decode(&batch)
+		s.mu.Lock()
+		s.posted = append(s.posted, batch)
+		s.mu.Unlock()
+		s.received <- struct{}{}
+		return
+	}
+	if r.URL.Path != streamPath || r.Header.Get("Upgrade") != "websocket" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	s.mu.Lock()
+	s.handshakes++
+	s.auth = r.Header.Get("Authorization")
+	s.mu.Unlock()
+
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + websocketAccept(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+	rw.Flush()
+
+	var writeMu sync.Mutex
+	send := func(opcode byte, payload []byte) {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		frame := []byte{0x80 | opcode, byte(len(payload))}
+		conn.Write(append(frame, payload...))
+	}
+
+	for {
+		opcode, payload, err := readFrame(rw.Reader)
+		if err != nil {
+			return
+		}
+		switch opcode {
+		case wsText:
+			var msg streamMessage
+			_ = json.Unmarshal(payload, &msg)
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			n := len(s.messages)
+			s.mu.Unlock()
+			s.received <- struct{}{}
+			if s.onMessage != nil {
+				s.onMessage(n, send)
+			}
+		case wsPong:
+			s.mu.Lock()
+			s.pongs = append(s.pongs, payload)
+			s.mu.Unlock()
+			s.received <- struct{}{}
+		case wsClose:
+			return
+		}
+	}
+}
+
+func (s *streamServer) wait(t *testing.T) {
+	t.Helper()
+	select {
+	case <-s.received:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the server")
+	}
+}
+
+func TestStreamingDeliversImmediately(t *testing.T) {
+	server := newStreamServer(t)
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithStreaming(), WithFlushInterval(time.Hour))
+	defer client.Close()
+
+	event := NewEvent(EventToolCall, "search")
+	client.Track(event)
+	server.wait(t)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.auth != "Bearer test-key" {
+		t.Errorf("expected API key on the handshake, got %q", server.auth)
+	}
+	if len(server.messages) != 1 || len(server.messages[0].Events) != 1 || server.messages[0].Events[0].ID != event.ID {
+		t.Fatalf("expected the event to be streamed without a flush, got %+v", server.messages)
+	}
+	if server.messages[0].IdempotencyKey != batchKey([]Event{event}) {
+		t.Errorf("expected the batch idempotency key, got %q", server.messages[0].IdempotencyKey)
+	}
+	if len(server.posted) != 0 {
+		t.Errorf("expected no HTTP batches, got %d", len(server.posted))
+	}
+}
+
+func TestStreamingReconnectsAfterServerClose(t *testing.T) {
+	server := newStreamServer(t)
+	server.onMessage = func(n int, send func(byte, []byte)) {
+		if n == 1 {
+			send(wsClose, nil)
+		}
+	}
+
+	st := NewStreamTransport(server.URL, "k")
+	defer st.Close()
+
+	ctx := context.Background()
+	if err := st.Send(ctx, Batch{Events: []Event{NewEvent(EventToolCall, "a")}}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	server.wait(t)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for st.Connected() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if st.Connected() {
+		t.Fatal("expected the transport to notice the close frame")
+	}
+
+	if err := st.Send(ctx, Batch{Events: []Event{NewEvent(EventToolCall, "b")}}); err != nil {
+		t.Fatalf("Send after reconnect failed: %v", err)
+	}
+	server.wait(t)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.handshakes != 2 || len(server.messages) != 2 {
+		t.Errorf("expected 2 handshakes and 2 messages, got %d and %d", server.handshakes, len(server.messages))
+	}
+}
+
+func TestStreamingAnswersPing(t *testing.T) {
+	server := newStreamServer(t)
+	server.onMessage = func(n int, send func(byte, []byte)) {
+		send(wsPing, []byte("are-you-there"))
+	}
+
+	st := NewStreamTransport(server.URL, "k")
+	defer st.Close()
+	if err := st.Send(context.Background(), Batch{Events: []Event{NewEvent(EventToolCall, "a")}}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	server.wait(t) // message
+	server.wait(t) // pong
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if len(server.pongs) != 1 || string(server.pongs[0]) != "are-you-there" {
+		t.Errorf("expected pong echoing the ping payload, got %q", server.pongs)
+	}
+}
+
+func TestStreamingFallsBackToHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == streamPath {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	fallback := &recordingTransport{}
+	st := &StreamTransport{BaseURL: server.URL, APIKey: "k", Fallback: fallback, RedialDelay: time.Hour}
+	defer st.Close()
+
+	for i := 0; i < 2; i++ {
+		if err := st.Send(context.Background(), Batch{Events: []Event{NewEvent(EventToolCall, "a")}}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+	if len(fallback.batches) != 2 {
+		t.Errorf("expected both batches on the fallback, got %d", len(fallback.batches))
+	}
+
+	st.Fallback = nil
+	st.nextDial = time.Time{}
+	if err := st.Send(context.Background(), Batch{}); err == nil {
+		t.Error("expected the handshake error without a fallback")
+	}
+}
+
+func TestStreamTransportClosed(t *testing.T) {
+	st := NewStreamTransport("http://127.0.0.1:1", "k")
+	st.Fallback = &recordingTransport{}
+	st.Close()
+	if err := st.Send(context.Background(), Batch{}); err != ErrStreamClosed {
+		t.Errorf("expected ErrStreamClosed, got %v", err)
+	}
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	for _, n := range []int{0, 125, 126, 65535, 70000} {
+		payload := bytes.Repeat([]byte{'x'}, n)
+		var buf bytes.Buffer
+		if err := writeFrame(&buf, wsText, payload); err != nil {
+			t.Fatal(err)
+		}
+		raw := buf.Bytes()
+		if raw[1]&0x80 == 0 {
+			t.Errorf("%d bytes: expected a masked client frame", n)
+		}
+		opcode, got, err := readFrame(bufio.NewReader(&buf))
+		if err != nil || opcode != wsText || !bytes.Equal(got, payload) {
+			t.Errorf("%d bytes: round trip failed (opcode %d, %d bytes, %v)", n, opcode, len(got), err)
+		}
+	}
+
+	huge := []byte{0x82, 127, 0, 0, 0, 0, 0, 0, 0, 0}
+	binary.BigEndian.PutUint64(huge[2:], maxStreamFrame+1)
+	if _, _, err := readFrame(bytes.NewReader(huge)); err == nil {
+		t.Error("expected oversized frames to be rejected")
+	}
+}
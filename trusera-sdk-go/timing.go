@@ -0,0 +1,146 @@
+package trusera
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// timingBuckets are the upper bounds, in milliseconds, of the latency
+// histogram buckets. Slower operations fall in a final overflow bucket.
+var timingBuckets = []float64{1, 2.5, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000}
+
+// histogram aggregates the latencies of one operation
+type histogram struct {
+	start  time.Time
+	count  uint64
+	sum    time.Duration
+	min    time.Duration
+	max    time.Duration
+	counts []uint64 // One per timingBuckets entry, plus the overflow bucket
+}
+
+// timings holds the histograms recorded since the last flush
+type timings struct {
+	mu    sync.Mutex
+	hists map[string]*histogram
+}
+
+// TimeOperation starts timing an operation and returns a function that stops
+// the timer, records the elapsed time and returns it. Timings are aggregated
+// per operation name into a histogram and reported as one EventTiming
+// summary event per operation on every flush, so frequent operations cost a
+// few counter updates rather than an event each:
+//
+//	defer client.TimeOperation("vector_search")()
+func (c *Client) TimeOperation(name string) func() time.Duration {
+	start := time.Now()
+	var once sync.Once
+	var elapsed time.Duration
+	return func() time.Duration {
+		once.Do(func() {
+			elapsed = time.Since(start)
+			c.ObserveDuration(name, elapsed)
+		})
+		return elapsed
+	}
+}
+
+// ObserveDuration records a latency measured elsewhere under name, as if it
+// had been timed with TimeOperation
+func (c *Client) ObserveDuration(name string, d time.Duration) {
+	c.timings.mu.Lock()
+	defer c.timings.mu.Unlock()
+
+	if c.timings.hists == nil {
+		c.timings.hists = make(map[string]*histogram)
+	}
+	h := c.timings.hists[name]
+	if h == nil {
+		h = &histogram{start: time.Now(), min: d, counts: make([]uint64, len(timingBuckets)+1)}
+		c.timings.hists[name] = h
+	}
+	h.count++
+	h.sum += d
+	h.min = min(h.min, d)
+	h.max = max(h.max, d)
+
+	ms := durationMs(d)
+	i := 0
+	for i < len(timingBuckets) && ms > timingBuckets[i] {
+		i++
+	}
+	h.counts[i]++
+}
+
+// flushTimings tracks a summary event for every operation timed since the
+// last call and resets the histograms
+func (c *Client) flushTimings() {
+	c.timings.mu.Lock()
+	hists := c.timings.hists
+	c.timings.hists = nil
+	c.timings.mu.Unlock()
+
+	now := time.Now()
+	for name, h := range hists {
+		c.Track(h.event(name, now))
+	}
+}
+
+// event builds the summary event for the histogram
+func (h *histogram) event(name string, end time.Time) Event {
+	buckets := make(map[string]uint64, len(h.counts))
+	for i, n := range h.counts {
+		if n == 0 {
+			continue
+		}
+		le := "+Inf"
+		if i < len(timingBuckets) {
+			le = strconv.FormatFloat(timingBuckets[i], 'f', -1, 64)
+		}
+		buckets[le] = n
+	}
+
+	return NewEvent(EventTiming, name).
+		WithPayload("count", h.count).
+		WithPayload("sum_ms", durationMs(h.sum)).
+		WithPayload("min_ms", durationMs(h.min)).
+		WithPayload("max_ms", durationMs(h.max)).
+		WithPayload("mean_ms", durationMs(h.sum)/float64(h.count)).
+		WithPayload("p50_ms", h.quantile(0.5)).
+		WithPayload("p90_ms", h.quantile(0.9)).
+		WithPayload("p99_ms", h.quantile(0.99)).
+		WithPayload("buckets_ms", buckets).
+		WithPayload("window_start", h.start.UTC().Format(time.RFC3339Nano)).
+		WithPayload("window_end", end.UTC().Format(time.RFC3339Nano))
+}
+
+// quantile estimates the q-quantile in milliseconds by interpolating within
+// the bucket it falls in, clamped to the observed min and max
+func (h *histogram) quantile(q float64) float64 {
+	rank := q * float64(h.count)
+	var seen uint64
+	for i, n := range h.counts {
+		if n == 0 || float64(seen+n) < rank {
+			seen += n
+			continue
+		}
+		lower := 0.0
+		if i > 0 {
+			lower = timingBuckets[i-1]
+		}
+		upper := durationMs(h.max)
+		if i < len(timingBuckets) {
+			upper = timingBuckets[i]
+		}
+		v := lower + (upper-lower)*(rank-float64(seen))/float64(n)
+		return math.Min(math.Max(v, durationMs(h.min)), durationMs(h.max))
+	}
+	return durationMs(h.max)
+}
+
+// durationMs converts d to fractional milliseconds
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,77 @@
+package trusera
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeOperationSummaries(t *testing.T) {
+	transport := &recordingTransport{}
+	client := NewClient("k", WithTransport(transport), WithFlushInterval(time.Hour))
+	defer client.Close()
+
+	for i := 1; i <= 100; i++ {
+		client.ObserveDuration("search", time.Duration(i)*time.Millisecond)
+	}
+	stop := client.TimeOperation("fetch")
+	first := stop()
+	if again := stop(); again != first {
+		t.Errorf("expected later calls to return the first measurement, got %v and %v", first, again)
+	}
+
+	if n := len(queuedEvents(client)); n != 0 {
+		t.Fatalf("expected timings to stay aggregated until flush, got %d events", n)
+	}
+	if err := client.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	summaries := map[string]Event{}
+	for _, b := range transport.batches {
+		for _, e := range b.Events {
+			if e.Type == EventTiming {
+				summaries[e.Name] = e
+			}
+		}
+	}
+	if len(summaries) != 2 {
+		t.Fatalf("expected one summary per operation, got %+v", summaries)
+	}
+
+	search := summaries["search"].Payload
+	if search["count"] != uint64(100) || search["min_ms"] != 1.0 || search["max_ms"] != 100.0 || search["mean_ms"] != 50.5 {
+		t.Errorf("unexpected summary %v", search)
+	}
+	if p50 := search["p50_ms"].(float64); p50 < 45 || p50 > 55 {
+		t.Errorf("expected p50 near 50ms, got %v", p50)
+	}
+	if p99 := search["p99_ms"].(float64); p99 < 90 || p99 > 100 {
+		t.Errorf("expected p99 near 100ms, got %v", p99)
+	}
+	buckets := search["buckets_ms"].(map[string]uint64)
+	if buckets["1"] != 1 || buckets["100"] != 50 {
+		t.Errorf("unexpected buckets %v", buckets)
+	}
+	if summaries["fetch"].Payload["count"] != uint64(1) {
+		t.Errorf("expected one fetch timing, got %v", summaries["fetch"].Payload)
+	}
+
+	transport.batches = nil
+	if err := client.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(transport.batches) != 0 {
+		t.Errorf("expected histograms reset after a flush, got %+v", transport.batches)
+	}
+}
+
+func TestTimingsFlushedOnClose(t *testing.T) {
+	transport := &recordingTransport{}
+	client := NewClient("k", WithTransport(transport), WithFlushInterval(time.Hour))
+	client.ObserveDuration("search", 3*time.Millisecond)
+	client.Close()
+
+	if len(transport.batches) != 1 || transport.batches[0].Events[0].Type != EventTiming {
+		t.Fatalf("expected the summary delivered on close, got %+v", transport.batches)
+	}
+}
@@ -0,0 +1,89 @@
+package trusera
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// WithTLSConfig sets the TLS configuration for API connections, e.g. to trust
+// a private CA or present a client certificate for mutual TLS. It is applied
+// to the transport of the client's http.Client.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *Client) {
+		c.tlsConfig = cfg
+	}
+}
+
+// buildTLSConfig combines the configured TLS settings with certificates named
+// by TRUSERA_CA_CERT, TRUSERA_CLIENT_CERT and TRUSERA_CLIENT_KEY. It returns
+// nil if no TLS customization was requested.
+func (c *Client) buildTLSConfig() (*tls.Config, error) {
+	caPath := os.Getenv("TRUSERA_CA_CERT")
+	certPath := os.Getenv("TRUSERA_CLIENT_CERT")
+	keyPath := os.Getenv("TRUSERA_CLIENT_KEY")
+	if c.tlsConfig == nil && caPath == "" && certPath == "" && keyPath == "" {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{}
+	if c.tlsConfig != nil {
+		cfg = c.tlsConfig.Clone()
+	}
+	if cfg.MinVersion == 0 {
+		cfg.MinVersion = tls.VersionTLS12
+	}
+
+	if caPath != "" {
+		pem, err := os.ReadFile(caPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TRUSERA_CA_CERT: %w", err)
+		}
+		pool := cfg.RootCAs
+		if pool == nil {
+			if pool, err = x509.SystemCertPool(); err != nil {
+				pool = x509.NewCertPool()
+			}
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in TRUSERA_CA_CERT %s", caPath)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if certPath != "" || keyPath != "" {
+		if certPath == "" || keyPath == "" {
+			return nil, errors.New("TRUSERA_CLIENT_CERT and TRUSERA_CLIENT_KEY must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = append(cfg.Certificates, cert)
+	}
+	return cfg, nil
+}
+
+// applyTLSConfig installs cfg on a copy of the client's http.Client so that
+// a caller-supplied client is not modified
+func (c *Client) applyTLSConfig(cfg *tls.Config) error {
+	var base *http.Transport
+	switch t := c.httpClient.Transport.(type) {
+	case nil:
+		base = http.DefaultTransport.(*http.Transport)
+	case *http.Transport:
+		base = t
+	default:
+		return fmt.Errorf("cannot apply TLS config to transport of type %T", t)
+	}
+
+	transport := base.Clone()
+	transport.TLSClientConfig = cfg
+	hc := *c.httpClient
+	hc.Transport = transport
+	c.httpClient = &hc
+	return nil
+}
@@ -0,0 +1,141 @@
+package trusera
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeClientCert creates a self-signed client certificate and key in dir
+func writeClientCert(t *testing.T, dir string) (certPath, keyPath string, cert *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "trusera-agent"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ = x509.ParseCertificate(der)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPath = filepath.Join(dir, "client.crt")
+	keyPath = filepath.Join(dir, "client.key")
+	os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return certPath, keyPath, cert
+}
+
+func TestMutualTLSFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath, clientCert := writeClientCert(t, dir)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+
+	var received bool
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = len(r.TLS.PeerCertificates) > 0
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	caPath := filepath.Join(dir, "ca.crt")
+	os.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600)
+
+	t.Setenv("TRUSERA_CA_CERT", caPath)
+	t.Setenv("TRUSERA_CLIENT_CERT", certPath)
+	t.Setenv("TRUSERA_CLIENT_KEY", keyPath)
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	client.Track(NewEvent(EventToolCall, "tool"))
+	if err := client.Close(); err != nil {
+		t.Fatalf("expected delivery over mutual TLS, got %v", err)
+	}
+	if !received {
+		t.Error("expected server to see a client certificate")
+	}
+}
+
+func TestWithTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	hc := &http.Client{Timeout: time.Second}
+	client := NewClient("test-key",
+		WithBaseURL(server.URL),
+		WithHTTPClient(hc),
+		WithTLSConfig(&tls.Config{RootCAs: pool}),
+	)
+	client.Track(NewEvent(EventToolCall, "tool"))
+	if err := client.Close(); err != nil {
+		t.Fatalf("expected delivery with custom CA, got %v", err)
+	}
+	if hc.Transport != nil {
+		t.Error("expected caller's http.Client to be left unmodified")
+	}
+}
+
+func TestBuildTLSConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty.pem")
+	os.WriteFile(empty, []byte("not a certificate"), 0o600)
+
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{"missing CA file", map[string]string{"TRUSERA_CA_CERT": filepath.Join(dir, "missing.pem")}},
+		{"CA without certificates", map[string]string{"TRUSERA_CA_CERT": empty}},
+		{"cert without key", map[string]string{"TRUSERA_CLIENT_CERT": empty}},
+		{"invalid key pair", map[string]string{"TRUSERA_CLIENT_CERT": empty, "TRUSERA_CLIENT_KEY": empty}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			c := &Client{}
+			if _, err := c.buildTLSConfig(); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	c := &Client{}
+	if cfg, err := c.buildTLSConfig(); cfg != nil || err != nil {
+		t.Errorf("expected no TLS config without settings, got %v, %v", cfg, err)
+	}
+}
@@ -0,0 +1,243 @@
+package tokens
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Encoding names a tiktoken encoding
+type Encoding string
+
+// Encodings used by OpenAI models
+const (
+	CL100K Encoding = "cl100k_base" // GPT-4, GPT-3.5 Turbo, text-embedding-3
+	O200K  Encoding = "o200k_base"  // GPT-4o, GPT-4.1, o1, o3 and later
+)
+
+// modelEncodings maps model name prefixes to encodings, longest prefixes
+// first so "gpt-4o" is not taken for "gpt-4"
+var modelEncodings = []struct {
+	prefix string
+	enc    Encoding
+}{
+	{"text-embedding-3-", CL100K},
+	{"text-embedding-ada-002", CL100K},
+	{"gpt-3.5-turbo", CL100K},
+	{"gpt-4.1", O200K},
+	{"gpt-4.5", O200K},
+	{"chatgpt-4o", O200K},
+	{"gpt-4o", O200K},
+	{"gpt-4", CL100K},
+	{"gpt-5", O200K},
+	{"o1", O200K},
+	{"o3", O200K},
+	{"o4", O200K},
+}
+
+// EncodingForModel returns the tiktoken encoding of an OpenAI model
+func EncodingForModel(model string) (Encoding, bool) {
+	for _, m := range modelEncodings {
+		if strings.HasPrefix(model, m.prefix) {
+			return m.enc, true
+		}
+	}
+	return "", false
+}
+
+// BPE is a byte pair encoding tokenizer compatible with tiktoken
+type BPE struct {
+	ranks    map[string]int
+	splitter *splitter
+}
+
+// LoadBPE reads a tiktoken rank file, lines of a base64 token and its rank,
+// for encoding enc. The file is published by OpenAI, for example at
+// https://openaipublic.blob.core.windows.net/encodings/cl100k_base.tiktoken.
+func LoadBPE(r io.Reader, enc Encoding) (*BPE, error) {
+	sp, ok := splitters[enc]
+	if !ok {
+		return nil, fmt.Errorf("unknown encoding %q", enc)
+	}
+	ranks := make(map[string]int, 200000)
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		tok, rank, ok := strings.Cut(text, " ")
+		if !ok {
+			return nil, fmt.Errorf("invalid rank file line %d", line)
+		}
+		b, err := base64.StdEncoding.DecodeString(tok)
+		if err != nil {
+			return nil, fmt.Errorf("invalid token on rank file line %d: %w", line, err)
+		}
+		n, err := strconv.Atoi(rank)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rank on rank file line %d: %w", line, err)
+		}
+		ranks[string(b)] = n
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rank file: %w", err)
+	}
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("rank file for %s is empty", enc)
+	}
+	return &BPE{ranks: ranks, splitter: sp}, nil
+}
+
+// LoadEncodingFile loads the tiktoken rank file at path and uses it for
+// every model of encoding enc
+func LoadEncodingFile(enc Encoding, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	b, err := LoadBPE(f, enc)
+	if err != nil {
+		return err
+	}
+	registry.mu.Lock()
+	registry.bpe[enc] = b
+	registry.mu.Unlock()
+	return nil
+}
+
+// Encode returns the token ranks of text. Special tokens such as
+// <|endoftext|> are encoded as plain text.
+func (b *BPE) Encode(text string) []int {
+	var out []int
+	for _, piece := range b.splitter.split(text) {
+		if rank, ok := b.ranks[piece]; ok {
+			out = append(out, rank)
+			continue
+		}
+		out = b.merge(piece, out)
+	}
+	return out
+}
+
+// Count returns the number of tokens in text
+func (b *BPE) Count(text string) int {
+	n := 0
+	for _, piece := range b.splitter.split(text) {
+		if _, ok := b.ranks[piece]; ok {
+			n++
+			continue
+		}
+		n += len(b.merge(piece, nil))
+	}
+	return n
+}
+
+// merge appends the tokens of piece, repeatedly merging the adjacent pair
+// of parts with the lowest rank as tiktoken does
+func (b *BPE) merge(piece string, out []int) []int {
+	// bounds[i] is where part i starts; parts begin as single bytes
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+	for len(bounds) > 2 {
+		best, at := math.MaxInt, -1
+		for i := 0; i+2 < len(bounds); i++ {
+			if rank, ok := b.ranks[piece[bounds[i]:bounds[i+2]]]; ok && rank < best {
+				best, at = rank, i
+			}
+		}
+		if at < 0 {
+			break
+		}
+		bounds = append(bounds[:at+1], bounds[at+2:]...)
+	}
+	for i := 0; i+1 < len(bounds); i++ {
+		rank, ok := b.ranks[piece[bounds[i]:bounds[i+1]]]
+		if !ok {
+			rank = -1 // Byte missing from a partial rank file
+		}
+		out = append(out, rank)
+	}
+	return out
+}
+
+// splitter splits text into the pieces an encoding merges independently.
+// tiktoken's patterns end with \s+(?!\S)|\s+, whose lookahead Go's regexp
+// lacks; split implements those two alternatives by hand.
+type splitter struct {
+	re *regexp.Regexp // Every alternative before \s+(?!\S), anchored
+}
+
+// ws is tiktoken's \s, Unicode white space, as a character class body
+const ws = `\t\n\v\f\r \x{85}\p{Z}`
+
+// contractions is tiktoken's (?i:'s|'t|'re|'ve|'m|'ll|'d)
+const contractions = `(?i:'s|'t|'re|'ve|'m|'ll|'d)`
+
+var (
+	cl100kSplitter = &splitter{re: regexp.MustCompile(`^(?:` + contractions +
+		`|[^\r\n\p{L}\p{N}]?\p{L}+` +
+		`|\p{N}{1,3}` +
+		`| ?[^` + ws + `\p{L}\p{N}]+[\r\n]*` +
+		`|[` + ws + `]*[\r\n]+)`)}
+	o200kSplitter = &splitter{re: regexp.MustCompile(`^(?:` +
+		`[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+` + contractions + `?` +
+		`|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*` + contractions + `?` +
+		`|\p{N}{1,3}` +
+		`| ?[^` + ws + `\p{L}\p{N}]+[\r\n/]*` +
+		`|[` + ws + `]*[\r\n]+)`)}
+
+	splitters = map[Encoding]*splitter{CL100K: cl100kSplitter, O200K: o200kSplitter}
+)
+
+// split returns the pieces of text
+func (s *splitter) split(text string) []string {
+	var pieces []string
+	for pos := 0; pos < len(text); {
+		if loc := s.re.FindStringIndex(text[pos:]); loc != nil && loc[1] > 0 {
+			pieces = append(pieces, text[pos:pos+loc[1]])
+			pos += loc[1]
+			continue
+		}
+
+		// A run of white space: \s+(?!\S) takes all of it at the end of the
+		// text, or all but its last character before anything else, which
+		// then starts the next piece; \s+ takes a lone character
+		end, last := pos, pos
+		for end < len(text) {
+			r, size := utf8.DecodeRuneInString(text[end:])
+			if !isSpace(r) {
+				break
+			}
+			last = end
+			end += size
+		}
+		switch {
+		case end == pos:
+			// Unreachable with these patterns; keep going one rune at a time
+			_, size := utf8.DecodeRuneInString(text[pos:])
+			end = pos + size
+		case end < len(text) && last > pos:
+			end = last
+		}
+		pieces = append(pieces, text[pos:end])
+		pos = end
+	}
+	return pieces
+}
+
+// isSpace reports whether r is white space as tiktoken's \s matches it
+func isSpace(r rune) bool {
+	return unicode.IsSpace(r) || unicode.Is(unicode.Z, r)
+}
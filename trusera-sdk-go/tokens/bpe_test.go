@@ -0,0 +1,119 @@
+package tokens
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// rankFile builds a tiktoken rank file with every byte and then merges
+func rankFile(merges ...string) string {
+	var sb strings.Builder
+	for i := 0; i < 256; i++ {
+		fmt.Fprintf(&sb, "%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(i)}), i)
+	}
+	for i, m := range merges {
+		fmt.Fprintf(&sb, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(m)), 256+i)
+	}
+	return sb.String()
+}
+
+func TestSplitCL100K(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Hello world", []string{"Hello", " world"}},
+		{"Hello, world!", []string{"Hello", ",", " world", "!"}},
+		{"don't stop", []string{"don", "'t", " stop"}},
+		{"1234567", []string{"123", "456", "7"}},
+		{"a  b", []string{"a", " ", " b"}},
+		{"hi\n\nthere", []string{"hi", "\n\n", "there"}},
+		{"x   ", []string{"x", "   "}},
+		{"price: 42", []string{"price", ":", " ", "42"}},
+		{"  hello", []string{" ", " hello"}},
+	}
+	for _, tc := range tests {
+		if got := cl100kSplitter.split(tc.text); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("split(%q): expected %q, got %q", tc.text, tc.want, got)
+		}
+	}
+}
+
+func TestSplitO200K(t *testing.T) {
+	got := o200kSplitter.split("HelloWorld don't")
+	want := []string{"Hello", "World", " don't"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestBPE(t *testing.T) {
+	b, err := LoadBPE(strings.NewReader(rankFile("ab", "abc", " a")), CL100K)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if got := b.Encode("abc"); !reflect.DeepEqual(got, []int{257}) {
+		t.Errorf("expected a whole piece as one token, got %v", got)
+	}
+	if got := b.Encode("abcab"); !reflect.DeepEqual(got, []int{257, 256}) {
+		t.Errorf("expected lowest ranks merged first, got %v", got)
+	}
+	if got := b.Count("abcab xyz"); got != 6 {
+		t.Errorf("expected 6 tokens, got %d", got)
+	}
+}
+
+func TestLoadBPEErrors(t *testing.T) {
+	for _, data := range []string{"", "YQ==\n", "!!! 1\n", "YQ== x\n"} {
+		if _, err := LoadBPE(strings.NewReader(data), CL100K); err == nil {
+			t.Errorf("expected error for %q", data)
+		}
+	}
+	if _, err := LoadBPE(strings.NewReader(rankFile()), "p50k_base"); err == nil {
+		t.Error("expected error for an unknown encoding")
+	}
+}
+
+func TestEncodingForModel(t *testing.T) {
+	for model, want := range map[string]Encoding{
+		"gpt-4o-mini":            O200K,
+		"gpt-4-turbo":            CL100K,
+		"gpt-4.1-nano":           O200K,
+		"gpt-3.5-turbo-0125":     CL100K,
+		"o3-mini":                O200K,
+		"text-embedding-3-small": CL100K,
+	} {
+		if got, _ := EncodingForModel(model); got != want {
+			t.Errorf("%s: expected %s, got %s", model, want, got)
+		}
+	}
+	if _, ok := EncodingForModel("claude-sonnet-4"); ok {
+		t.Error("expected no encoding for a non-OpenAI model")
+	}
+}
+
+func TestTiktokenDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "o200k_base.tiktoken"), []byte(rankFile("ab")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TRUSERA_TIKTOKEN_DIR", dir)
+	t.Cleanup(func() {
+		registry.mu.Lock()
+		delete(registry.bpe, O200K)
+		delete(registry.tried, O200K)
+		registry.mu.Unlock()
+	})
+
+	if _, ok := ForModel("gpt-4o").(*BPE); !ok {
+		t.Fatal("expected the BPE loaded from TRUSERA_TIKTOKEN_DIR")
+	}
+	if got := Count("gpt-4o", "abab"); got != 2 {
+		t.Errorf("expected 2 tokens, got %d", got)
+	}
+}
@@ -0,0 +1,173 @@
+// Package tokens counts LLM tokens on the client, for calls whose provider
+// response omits usage, such as streamed responses without usage chunks,
+// so their cost can still be estimated.
+//
+// Counts are exact for OpenAI models once the tiktoken rank file of their
+// encoding is loaded with LoadEncodingFile, or found in the directory named
+// by TRUSERA_TIKTOKEN_DIR as <encoding>.tiktoken (for example
+// cl100k_base.tiktoken). Without one, and for other models, an estimate from
+// the text's words, numbers and punctuation is used. Register plugs in
+// tokenizers for other model families.
+package tokens
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tokenizer counts the tokens in a text
+type Tokenizer interface {
+	Count(text string) int
+}
+
+// TokenizerFunc adapts a function to the Tokenizer interface
+type TokenizerFunc func(text string) int
+
+// Count calls f(text)
+func (f TokenizerFunc) Count(text string) int {
+	return f(text)
+}
+
+// Approximate estimates token counts without a vocabulary, using Estimate.
+// It is good enough for cost estimates, not for fitting a context window.
+var Approximate Tokenizer = TokenizerFunc(Estimate)
+
+// registry holds the tokenizers registered and loaded by model prefix
+var registry = struct {
+	mu       sync.RWMutex
+	prefixes map[string]Tokenizer
+	bpe      map[Encoding]*BPE
+	tried    map[Encoding]bool // Encodings looked up in TRUSERA_TIKTOKEN_DIR
+}{
+	prefixes: map[string]Tokenizer{},
+	bpe:      map[Encoding]*BPE{},
+	tried:    map[Encoding]bool{},
+}
+
+// Register sets the tokenizer for models whose names start with prefix,
+// such as "claude-" or "llama-3". The longest matching prefix wins, and
+// registered tokenizers take precedence over loaded encodings.
+func Register(prefix string, t Tokenizer) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if t == nil {
+		delete(registry.prefixes, prefix)
+		return
+	}
+	registry.prefixes[prefix] = t
+}
+
+// ForModel returns the tokenizer for model: a registered one, the BPE of
+// the model's tiktoken encoding if loaded, or Approximate
+func ForModel(model string) Tokenizer {
+	registry.mu.RLock()
+	best, bestLen := Tokenizer(nil), -1
+	for prefix, t := range registry.prefixes {
+		if strings.HasPrefix(model, prefix) && len(prefix) > bestLen {
+			best, bestLen = t, len(prefix)
+		}
+	}
+	registry.mu.RUnlock()
+	if best != nil {
+		return best
+	}
+
+	enc, ok := EncodingForModel(model)
+	if !ok {
+		return Approximate
+	}
+	if b := loadedBPE(enc); b != nil {
+		return b
+	}
+	return Approximate
+}
+
+// loadedBPE returns the BPE of enc, loading it from TRUSERA_TIKTOKEN_DIR the
+// first time it is needed
+func loadedBPE(enc Encoding) *BPE {
+	registry.mu.RLock()
+	b, tried := registry.bpe[enc], registry.tried[enc]
+	registry.mu.RUnlock()
+	if b != nil || tried {
+		return b
+	}
+
+	registry.mu.Lock()
+	registry.tried[enc] = true
+	registry.mu.Unlock()
+	dir := os.Getenv("TRUSERA_TIKTOKEN_DIR")
+	if dir == "" {
+		return nil
+	}
+	if err := LoadEncodingFile(enc, filepath.Join(dir, string(enc)+".tiktoken")); err != nil {
+		return nil
+	}
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	return registry.bpe[enc]
+}
+
+// Count counts the tokens of text for model
+func Count(model, text string) int {
+	return ForModel(model).Count(text)
+}
+
+// Message is one chat message, for CountMessages
+type Message struct {
+	Role    string
+	Name    string
+	Content string
+}
+
+// CountMessages counts the prompt tokens of a chat request for model,
+// including the per-message framing OpenAI chat models add: 3 tokens per
+// message, 1 more for a name, and 3 priming the reply
+func CountMessages(model string, messages []Message) int {
+	t := ForModel(model)
+	n := 3
+	for _, m := range messages {
+		n += 3 + t.Count(m.Role) + t.Count(m.Content)
+		if m.Name != "" {
+			n += 1 + t.Count(m.Name)
+		}
+	}
+	return n
+}
+
+// Estimate approximates the token count of text by splitting it as the
+// cl100k_base encoding does and pricing each piece: one token per word of
+// up to 6 letters and one more per further 6, one per number of up to 3
+// digits, one per 2 symbols, and one per non-Latin letter, as scripts
+// like Chinese or Japanese take about one token per character
+func Estimate(text string) int {
+	n := 0
+	for _, piece := range cl100kSplitter.split(text) {
+		n += estimatePiece(piece)
+	}
+	return n
+}
+
+func estimatePiece(piece string) int {
+	letters, other, wide := 0, 0, 0
+	for _, r := range piece {
+		switch {
+		case r < utf8.RuneSelf && unicode.IsLetter(r):
+			letters++
+		case unicode.IsLetter(r) && !unicode.Is(unicode.Latin, r):
+			wide++
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			letters++
+		case !unicode.IsSpace(r):
+			other++
+		}
+	}
+	n := (letters+5)/6 + (other+1)/2 + wide
+	if n == 0 {
+		n = 1 // Whitespace
+	}
+	return n
+}
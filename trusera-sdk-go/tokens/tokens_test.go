@@ -0,0 +1,56 @@
+package tokens
+
+import "testing"
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"Hello, world!", 4},
+		{"The quick brown fox jumps over the lazy dog.", 10},
+		{"internationalization", 4},
+		{"你好世界", 4},
+		{"x := 12345", 5},
+	}
+	for _, tc := range tests {
+		if got := Estimate(tc.text); got != tc.want {
+			t.Errorf("Estimate(%q): expected %d, got %d", tc.text, tc.want, got)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	words := TokenizerFunc(func(s string) int { return 100 })
+	Register("claude-", words)
+	Register("claude-3-haiku", TokenizerFunc(func(s string) int { return 7 }))
+	t.Cleanup(func() {
+		Register("claude-", nil)
+		Register("claude-3-haiku", nil)
+	})
+
+	if got := Count("claude-sonnet-4", "hi"); got != 100 {
+		t.Errorf("expected the registered tokenizer, got %d", got)
+	}
+	if got := Count("claude-3-haiku-20240307", "hi"); got != 7 {
+		t.Errorf("expected the longest prefix to win, got %d", got)
+	}
+	if got := Count("llama-3.1-8b", "Hello, world!"); got != 4 {
+		t.Errorf("expected the estimate for unknown models, got %d", got)
+	}
+}
+
+func TestCountMessages(t *testing.T) {
+	Register("test-", TokenizerFunc(func(s string) int { return len(s) }))
+	t.Cleanup(func() { Register("test-", nil) })
+
+	got := CountMessages("test-model", []Message{
+		{Role: "system", Content: "abc"},
+		{Role: "user", Name: "bob", Content: "hello"},
+	})
+	// 3 priming + (3+6+3) + (3+4+5+1+3)
+	if got != 31 {
+		t.Errorf("expected 31, got %d", got)
+	}
+}
@@ -0,0 +1,172 @@
+package trusera
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Span kinds reported in the "span_kind" payload of EventSpan events
+const (
+	SpanKindRun  = "run"
+	SpanKindStep = "step"
+)
+
+// Run is the root span of a multi-step agent execution. Every span and event
+// recorded under it shares the run's trace ID.
+type Run struct {
+	*Span
+}
+
+// Span is one timed step of a run. A span is reported as a single EventSpan
+// event when End is called; events added to it carry its trace and span IDs.
+type Span struct {
+	client   *Client
+	name     string
+	kind     string
+	traceID  string
+	spanID   string
+	parentID string
+	tags     map[string]any // Metadata from Agent and Session handles, never modified
+	start    time.Time
+
+	mu        sync.Mutex
+	err       error
+	attrs     map[string]any
+	retrieved []string // IDs of documents passed to AddRetrieval
+	ended     bool
+}
+
+// StartRun begins a new trace for a multi-step agent execution
+func (c *Client) StartRun(name string) *Run {
+	return &Run{Span: c.newSpan(name, SpanKindRun, generateID(), "")}
+}
+
+// newSpan creates a span with a fresh span ID
+func (c *Client) newSpan(name, kind, traceID, parentID string) *Span {
+	return &Span{
+		client:   c,
+		name:     name,
+		kind:     kind,
+		traceID:  traceID,
+		spanID:   newSpanID(),
+		parentID: parentID,
+		start:    time.Now(),
+	}
+}
+
+// StartSpan begins a child span of s. Spans extracted from incoming headers
+// have no client, so start children of those with Client.StartSpan.
+func (s *Span) StartSpan(name string) *Span {
+	child := s.client.newSpan(name, SpanKindStep, s.traceID, s.spanID)
+	child.tags = s.tags
+	return child
+}
+
+// TraceID returns the ID shared by every span in the run
+func (s *Span) TraceID() string {
+	return s.traceID
+}
+
+// SpanID returns the span's own ID
+func (s *Span) SpanID() string {
+	return s.spanID
+}
+
+// ParentSpanID returns the ID of the parent span, or "" for a run
+func (s *Span) ParentSpanID() string {
+	return s.parentID
+}
+
+// AddEvent tracks an event as a child of s
+func (s *Span) AddEvent(e Event) {
+	if s.client == nil {
+		return
+	}
+	s.client.Track(s.link(e))
+}
+
+// SetError marks the span as failed. The last non-nil error wins.
+func (s *Span) SetError(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+}
+
+// SetAttribute adds a key to the payload of the span's event, such as the
+// route of the request it covers. Keys set by End take precedence.
+func (s *Span) SetAttribute(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attrs == nil {
+		s.attrs = make(map[string]any)
+	}
+	s.attrs[key] = value
+}
+
+// End records the span with its duration and status. Calls after the first are no-ops.
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended || s.client == nil {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	err := s.err
+	attrs := s.attrs
+	retrieved := s.retrieved
+	s.mu.Unlock()
+
+	duration := time.Since(s.start)
+	event := NewEvent(EventSpan, s.name)
+	for k, v := range attrs {
+		event = event.WithPayload(k, v)
+	}
+	event = event.
+		WithPayload("span_kind", s.kind).
+		WithPayload("start_time", s.start.UTC().Format(time.RFC3339Nano)).
+		WithPayload("duration_ms", duration.Milliseconds()).
+		WithPayload("status", "ok")
+	if err != nil {
+		event = event.WithPayload("status", "error").WithPayload("error", err.Error())
+	}
+	if len(retrieved) > 0 {
+		event = event.WithPayload("retrieved_docs", retrieved)
+	}
+	s.client.Track(s.link(event))
+}
+
+// link stamps trace and span IDs onto an event's metadata
+func (s *Span) link(e Event) Event {
+	if e.ID == "" {
+		e.ID = newUUID()
+	}
+	if e.Timestamp == "" {
+		e.Timestamp = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	if len(s.tags) > 0 {
+		e = withTags(e, s.tags)
+	}
+	e = e.WithMetadata("trace_id", s.traceID)
+	if e.Type == EventSpan {
+		e = e.WithMetadata("span_id", s.spanID)
+		if s.parentID != "" {
+			e = e.WithMetadata("parent_span_id", s.parentID)
+		}
+		return e
+	}
+	return e.WithMetadata("parent_span_id", s.spanID)
+}
+
+// newSpanID returns a random 8-byte hex span ID
+func newSpanID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,111 @@
+package trusera
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRunSpanTree(t *testing.T) {
+	client := NewClient("test-key")
+	defer client.Close()
+
+	run := client.StartRun("answer-question")
+	plan := run.StartSpan("plan")
+	plan.End()
+
+	tool := run.StartSpan("search")
+	tool.AddEvent(NewEvent(EventToolCall, "web_search").WithPayload("query", "go"))
+	tool.End()
+	run.End()
+
+	events := queuedEvents(client)
+	if len(events) != 4 {
+		t.Fatalf("expected 4 events, got %d", len(events))
+	}
+
+	for _, e := range events {
+		if e.Metadata["trace_id"] != run.TraceID() {
+			t.Errorf("expected trace_id %s on %s, got %v", run.TraceID(), e.Name, e.Metadata["trace_id"])
+		}
+	}
+
+	if events[0].Type != EventSpan || events[0].Name != "plan" {
+		t.Errorf("expected plan span first, got %s %s", events[0].Type, events[0].Name)
+	}
+	if events[0].Metadata["parent_span_id"] != run.SpanID() {
+		t.Errorf("expected plan parent %s, got %v", run.SpanID(), events[0].Metadata["parent_span_id"])
+	}
+	if events[1].Type != EventToolCall || events[1].Metadata["parent_span_id"] != tool.SpanID() {
+		t.Errorf("expected tool call under span %s, got %v", tool.SpanID(), events[1].Metadata)
+	}
+	if events[3].Payload["span_kind"] != SpanKindRun {
+		t.Errorf("expected run span kind, got %v", events[3].Payload["span_kind"])
+	}
+	if _, ok := events[3].Metadata["parent_span_id"]; ok {
+		t.Error("expected run span to have no parent")
+	}
+	if events[3].Payload["status"] != "ok" {
+		t.Errorf("expected status ok, got %v", events[3].Payload["status"])
+	}
+}
+
+func TestSpanSetError(t *testing.T) {
+	client := NewClient("test-key")
+	defer client.Close()
+
+	run := client.StartRun("failing")
+	run.SetError(nil)
+	run.SetError(errors.New("tool timed out"))
+	run.End()
+	run.End()
+
+	events := queuedEvents(client)
+	if len(events) != 1 {
+		t.Fatalf("expected End to record once, got %d events", len(events))
+	}
+	if events[0].Payload["status"] != "error" {
+		t.Errorf("expected status error, got %v", events[0].Payload["status"])
+	}
+	if events[0].Payload["error"] != "tool timed out" {
+		t.Errorf("expected error message, got %v", events[0].Payload["error"])
+	}
+}
+
+func TestSpanSetAttribute(t *testing.T) {
+	client := NewClient("test-key")
+	defer client.Close()
+
+	run := client.StartRun("request")
+	run.SetAttribute("http.route", "/chat")
+	run.SetAttribute("status", "overridden")
+	run.End()
+
+	events := queuedEvents(client)
+	if len(events) != 1 || events[0].Payload["http.route"] != "/chat" {
+		t.Fatalf("expected the attribute in the span payload, got %+v", events)
+	}
+	if events[0].Payload["status"] != "ok" {
+		t.Errorf("expected End's keys to take precedence, got %v", events[0].Payload["status"])
+	}
+}
+
+func TestRunHeadBasedSamplingKeepsWholeTrace(t *testing.T) {
+	client := NewClient("test-key", WithSampler(HeadBased(0.5)))
+	defer client.Close()
+
+	for i := 0; i < 20; i++ {
+		run := client.StartRun("run")
+		run.StartSpan("step").End()
+		run.End()
+	}
+
+	perTrace := map[any]int{}
+	for _, e := range queuedEvents(client) {
+		perTrace[e.Metadata["trace_id"]]++
+	}
+	for id, n := range perTrace {
+		if n != 2 {
+			t.Errorf("expected trace %v to be kept whole, got %d events", id, n)
+		}
+	}
+}
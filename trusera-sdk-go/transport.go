@@ -0,0 +1,253 @@
+package trusera
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// IdempotencyKeyHeader carries a batch's idempotency key to the API
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Batch is a set of events delivered in a single transport call
+type Batch struct {
+	AgentID string  `json:"agent_id"`
+	Events  []Event `json:"events"`
+
+	// IdempotencyKey identifies the batch for server-side deduplication. It
+	// is derived from the event IDs, so a retry of the same events carries
+	// the same key even after a timeout hid whether the API received it.
+	IdempotencyKey string `json:"-"`
+}
+
+// batchKey derives an idempotency key from the IDs of events
+func batchKey(events []Event) string {
+	h := sha256.New()
+	for _, e := range events {
+		h.Write([]byte(e.ID))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// idempotencyKey returns the batch's key, deriving it if unset
+func (b Batch) idempotencyKey() string {
+	if b.IdempotencyKey != "" {
+		return b.IdempotencyKey
+	}
+	return batchKey(b.Events)
+}
+
+// Transport delivers event batches to Trusera. Implementations must be safe
+// for concurrent use.
+type Transport interface {
+	Send(ctx context.Context, batch Batch) error
+}
+
+// WithTransport replaces the default HTTP/JSON transport
+func WithTransport(t Transport) Option {
+	return func(c *Client) {
+		c.bindTransport(t)
+		c.transport = t
+	}
+}
+
+// HTTPTransport posts JSON-encoded batches to the /v1/events endpoint, or to
+// Path if it is set.
+// It is the default transport.
+type HTTPTransport struct {
+	BaseURL     string
+	Path        string // Endpoint batches are posted to, default /v1/events
+	APIKey      string
+	HTTPClient  *http.Client
+	Compression string        // Content-Encoding for request bodies, e.g. "gzip"
+	Signer      crypto.Signer // Signs request bodies, see WithSigningKey
+
+	keyFunc func() string // Overrides APIKey so the client can rotate keys
+}
+
+// Send posts a batch to the events endpoint
+func (t *HTTPTransport) Send(ctx context.Context, batch Batch) error {
+	buf := getBuffer()
+	if err := t.encode(buf, batch); err != nil {
+		putBuffer(buf)
+		return err
+	}
+
+	body := newPooledBody(buf)
+	defer body.release()
+
+	path := t.Path
+	if path == "" {
+		path = "/v1/events"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.BaseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Body = body.reader()
+	req.GetBody = func() (io.ReadCloser, error) { return body.reader(), nil }
+	req.ContentLength = int64(buf.Len())
+
+	key := t.APIKey
+	if t.keyFunc != nil {
+		key = t.keyFunc()
+	}
+	// Sized up front with canonical keys, so setting headers does not grow
+	// the map or canonicalize names on every send
+	req.Header = make(http.Header, 4)
+	req.Header["Content-Type"] = jsonContentType
+	req.Header["Authorization"] = []string{"Bearer " + key}
+	req.Header[IdempotencyKeyHeader] = []string{batch.idempotencyKey()}
+	if t.Compression != "" {
+		req.Header["Content-Encoding"] = []string{t.Compression}
+	}
+	if t.Signer != nil {
+		if err := signRequest(req, t.Signer, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	httpClient := t.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send events: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return newAPIError(resp)
+	}
+	// Drain body to allow connection reuse
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	return nil
+}
+
+// encode writes the batch to buf as JSON, compressed if configured
+func (t *HTTPTransport) encode(buf *bytes.Buffer, batch Batch) error {
+	if t.Compression == "" {
+		if err := encodeBatch(buf, batch); err != nil {
+			return fmt.Errorf("failed to marshal events: %w: %w", ErrUnencodable, err)
+		}
+		return nil
+	}
+
+	c := lookupCompressor(t.Compression)
+	if c == nil {
+		return fmt.Errorf("failed to compress events: unknown compression %q", t.Compression)
+	}
+	w, err := c(buf)
+	if err != nil {
+		return fmt.Errorf("failed to compress events: %w", err)
+	}
+	if err := encodeBatch(w, batch); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to marshal events: %w: %w", ErrUnencodable, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to compress events: %w", err)
+	}
+	return nil
+}
+
+// encodeBatch streams the batch to w as JSON one event at a time, so that
+// no copy of the whole payload is built besides the one in w
+func encodeBatch(w io.Writer, batch Batch) error {
+	agentID, err := json.Marshal(batch.AgentID)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	bw.WriteString(`{"agent_id":`)
+	bw.Write(agentID)
+	bw.WriteString(`,"events":[`)
+	enc := json.NewEncoder(bw)
+	for i, e := range batch.Events {
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	bw.WriteString("]}")
+	return bw.Flush()
+}
+
+// jsonContentType is shared by every request; net/http does not modify it
+var jsonContentType = []string{"application/json"}
+
+// maxPooledBuffer is the largest request buffer kept for reuse, so that one
+// unusually large batch does not pin its memory for the life of the process
+const maxPooledBuffer = 8 << 20
+
+var bufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// pooledBody is a request body backed by a pooled buffer. A RoundTripper may
+// still be reading the body after Do returns, on errors and HTTP/2 retries in
+// particular, so the buffer goes back to the pool only once every reader the
+// transport was given has been closed and Send has released its own
+// reference. Readers a transport never closes leave the buffer to the
+// garbage collector.
+type pooledBody struct {
+	buf  *bytes.Buffer
+	refs atomic.Int32
+}
+
+// newPooledBody wraps buf, holding one reference for the caller
+func newPooledBody(buf *bytes.Buffer) *pooledBody {
+	b := &pooledBody{buf: buf}
+	b.refs.Store(1)
+	return b
+}
+
+// reader returns a new reader of the whole body, holding a reference
+func (b *pooledBody) reader() io.ReadCloser {
+	b.refs.Add(1)
+	return &pooledReader{Reader: bytes.NewReader(b.buf.Bytes()), body: b}
+}
+
+// release drops a reference, returning the buffer to the pool with the last
+func (b *pooledBody) release() {
+	if b.refs.Add(-1) == 0 {
+		putBuffer(b.buf)
+	}
+}
+
+// pooledReader reads a pooledBody, releasing it when first closed
+type pooledReader struct {
+	*bytes.Reader
+	body *pooledBody
+	once sync.Once
+}
+
+// Close releases the reader's reference to the body
+func (r *pooledReader) Close() error {
+	r.once.Do(r.body.release)
+	return nil
+}
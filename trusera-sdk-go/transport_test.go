@@ -0,0 +1,227 @@
+package trusera
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingTransport captures batches instead of sending them
+type recordingTransport struct {
+	mu      sync.Mutex
+	batches []Batch
+	err     error
+}
+
+func (r *recordingTransport) Send(ctx context.Context, batch Batch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, batch)
+	return r.err
+}
+
+func TestWithTransport(t *testing.T) {
+	rt := &recordingTransport{}
+	client := NewClient("test-key", WithAgentID("agent-1"), WithTransport(rt))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "a"))
+	client.Track(NewEvent(EventToolCall, "b"))
+	if err := client.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if len(rt.batches) != 1 {
+		t.Fatalf("expected 1 batch, got %d", len(rt.batches))
+	}
+	if rt.batches[0].AgentID != "agent-1" || len(rt.batches[0].Events) != 2 {
+		t.Errorf("unexpected batch: %+v", rt.batches[0])
+	}
+}
+
+func TestHTTPTransport(t *testing.T) {
+	var received Batch
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/events" || r.Header.Get("Authorization") != "Bearer k" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tr := &HTTPTransport{BaseURL: server.URL, APIKey: "k"}
+	err := tr.Send(context.Background(), Batch{AgentID: "a", Events: []Event{NewEvent(EventDecision, "d")}})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if received.AgentID != "a" || len(received.Events) != 1 {
+		t.Errorf("unexpected payload: %+v", received)
+	}
+
+	tr.APIKey = "wrong"
+	if err := tr.Send(context.Background(), Batch{}); err == nil {
+		t.Error("expected error for 401 response")
+	}
+}
+
+func TestIdempotencyKeyStableAcrossRetries(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+	var ids [][]string
+	fail := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch Batch
+		_ = json.NewDecoder(r.Body).Decode(&batch)
+		mu.Lock()
+		defer mu.Unlock()
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		var batchIDs []string
+		for _, e := range batch.Events {
+			batchIDs = append(batchIDs, e.ID)
+		}
+		ids = append(ids, batchIDs)
+		if fail {
+			// Simulate a response lost after the server stored the batch
+			fail = false
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithBatchSize(1000), WithCircuitBreaker(5, time.Minute))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "a"))
+	client.Track(Event{Type: EventToolCall, Name: "no-id"})
+	if err := client.Flush(); err == nil {
+		t.Fatal("expected first attempt to fail")
+	}
+	if err := client.Flush(); err != nil {
+		t.Fatalf("retry failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 attempts, got %d", len(keys))
+	}
+	if keys[0] == "" || keys[0] != keys[1] {
+		t.Errorf("expected the same idempotency key on retry, got %q and %q", keys[0], keys[1])
+	}
+	if ids[1][1] == "" || ids[0][1] != ids[1][1] {
+		t.Errorf("expected events without an ID to get a stable one, got %v", ids)
+	}
+}
+
+func TestIdempotencyKeyDiffersPerBatch(t *testing.T) {
+	a := batchKey([]Event{NewEvent(EventToolCall, "a")})
+	b := batchKey([]Event{NewEvent(EventToolCall, "a")})
+	if a == b {
+		t.Error("expected different batches to get different keys")
+	}
+	if got := (Batch{IdempotencyKey: "custom"}).idempotencyKey(); got != "custom" {
+		t.Errorf("expected explicit key to be kept, got %q", got)
+	}
+}
+
+func TestEncodeBatchMatchesMarshal(t *testing.T) {
+	batch := Batch{AgentID: `agent "1"`, Events: []Event{
+		NewEvent(EventLLMInvoke, "chat").WithPayload("prompt", "<b>hi</b>\n"),
+		NewEvent(EventToolCall, "search").WithMetadata("user", "u-1"),
+	}}
+
+	var buf bytes.Buffer
+	if err := encodeBatch(&buf, batch); err != nil {
+		t.Fatalf("encodeBatch failed: %v", err)
+	}
+	want, _ := json.Marshal(batch)
+
+	var got, expected any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("expected valid JSON, got %v: %s", err, buf.Bytes())
+	}
+	json.Unmarshal(want, &expected)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %s, got %s", want, buf.Bytes())
+	}
+}
+
+// holdingTransport answers before reading or closing request bodies, as a
+// RoundTripper still writing a body after the response arrives would
+type holdingTransport struct {
+	bodies []io.ReadCloser
+}
+
+func (h *holdingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	h.bodies = append(h.bodies, req.Body)
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+}
+
+func TestHTTPTransportKeepsBodyUntilClosed(t *testing.T) {
+	ht := &holdingTransport{}
+	tr := &HTTPTransport{BaseURL: "http://trusera.test", APIKey: "k", HTTPClient: &http.Client{Transport: ht}}
+
+	first := Batch{AgentID: "a", Events: []Event{NewEvent(EventToolCall, "first-batch")}}
+	second := Batch{AgentID: "a", Events: []Event{NewEvent(EventToolCall, "second-batch")}}
+	for _, batch := range []Batch{first, second} {
+		if err := tr.Send(context.Background(), batch); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+
+	data, _ := io.ReadAll(ht.bodies[0])
+	if !bytes.Contains(data, []byte("first-batch")) {
+		t.Errorf("expected the first body intact after a later send, got %s", data)
+	}
+}
+
+func TestPooledBodyReleasedAfterLastClose(t *testing.T) {
+	buf := getBuffer()
+	buf.WriteString("payload")
+	body := newPooledBody(buf)
+	r1, r2 := body.reader(), body.reader()
+
+	body.release()
+	r1.Close()
+	r1.Close() // Closing twice releases once
+	if buf.Len() == 0 {
+		t.Fatal("expected the buffer kept while a reader is open")
+	}
+	r2.Close()
+	if buf.Len() != 0 {
+		t.Error("expected the buffer returned to the pool after the last close")
+	}
+}
+
+func BenchmarkHTTPTransportSend(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+	}))
+	defer server.Close()
+
+	tr := &HTTPTransport{BaseURL: server.URL, APIKey: "k"}
+	events := make([]Event, 100)
+	for i := range events {
+		events[i] = NewEvent(EventLLMInvoke, "chat").WithPayload("prompt", strings.Repeat("x", 10000))
+	}
+	batch := Batch{AgentID: "a", Events: events}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := tr.Send(context.Background(), batch); err != nil {
+			b.Fatalf("Send failed: %v", err)
+		}
+	}
+}
@@ -2,48 +2,207 @@ package trusera
 
 import (
 	"bytes"
+	"context"
+	"crypto"
+	"crypto/cipher"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/Trusera/ai-bom/trusera-sdk-go/bom"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/costs"
 )
 
 const (
-	defaultBaseURL            = "https://api.trusera.io"
-	defaultFlushInterval      = 30 * time.Second
-	defaultBatchSize          = 100
-	defaultHeartbeatInterval  = 60 * time.Second
-	sdkVersion                = "1.0.0"
+	defaultBaseURL           = "https://api.trusera.io"
+	defaultFlushInterval     = 30 * time.Second
+	defaultBatchSize         = 100
+	defaultHeartbeatInterval = 60 * time.Second
+	defaultMaxQueueSize      = 10000
+	defaultFlushWorkers      = 2
+	sdkVersion               = "1.0.0"
+	closeRetryBackoff        = 100 * time.Millisecond
+	maxCloseRetryBackoff     = 2 * time.Second
 )
 
+// Tracker is the event-recording surface of Client. Accept a Tracker instead
+// of *Client to substitute a fake or recording implementation in tests.
+type Tracker interface {
+	Track(event Event)
+	Flush() error
+	Close() error
+	RegisterAgent(name, framework string) (string, error)
+}
+
+var _ Tracker = (*Client)(nil)
+
 // Client sends agent events to Trusera API
 type Client struct {
-	apiKey     string
+	apiKey     string // Guarded by keyMu
+	keyMu      sync.RWMutex
 	baseURL    string
 	agentID    string
 	httpClient *http.Client
 	events     []Event
+	queueBuf   []Event // The whole array events lives in, see queueEvent
 	mu         sync.Mutex
 	flushSize  int
 	done       chan struct{}
 	ticker     *time.Ticker
 	wg         sync.WaitGroup
+	closeOnce  sync.Once
+
+	// Queue bounds
+	maxQueueSize int
+	dropPolicy   DropPolicy
+	queueCond    *sync.Cond
+	flushCh      chan struct{}
+	closed       bool
+	flushWorkers int
+	batches      chan []Event
+
+	// Self-metrics, guarded by mu
+	stats              counters
+	lastHeartbeatStats Stats
+
+	// Event delivery
+	transport   Transport
+	compression string
+	streaming   bool
+	stream      *StreamTransport // Owned by the client when streaming
+	breaker     *breaker
+	spillDir    string
+	spillSeq    uint64
+	spillMu     sync.Mutex // Serializes spill replay across concurrent flushes
+	spillKey    []byte
+	spillAEAD   cipher.AEAD // Encrypts spill files, set if spillKey is
+	limiter     rateLimiter
+
+	// Per-event-type routing
+	routes          map[EventType]RouteConfig
+	routeTransports map[EventType]Transport
+	immediate       chan Event // Events of immediate routes, nil if there are none
+
+	maxBatchBytes    int
+	deadLetters      deadLetters
+	strictValidation bool
+
+	// TLS and authentication
+	tlsConfig *tls.Config
+	proxyURL  string
+
+	// Project and team scoping, see WithProject
+	projectID string
+	teamID    string
+
+	// Local relay for event batches, see WithRelay
+	relayAddr string
+
+	// Regional failover, see WithEndpoints
+	fallbackURLs       []string
+	failbackInterval   time.Duration
+	endpoints          *endpoints
+	signer             crypto.Signer
+	tokenSource        TokenSource
+	apiKeyFile         string
+	apiKeyFileInterval time.Duration
+
+	// Local mode
+	localSinkPath string
+	sink          *LocalSink
 
 	// Fleet auto-registration
 	autoRegister      bool
 	agentName         string
 	agentType         string
+	tools             []bom.Tool
+	compliance        *bom.Compliance
 	environment       string
 	heartbeatInterval time.Duration
 	fleetAgentID      string
+	healthChecks      []healthCheck
+	enrichments       []enrichment
+	enrichEvents      bool
+
+	// Operation timings, counters and gauges, reported as events on flush
+	timings     timings
+	instruments instruments
+
+	// Prompt templates registered with RegisterPrompt
+	prompts prompts
+
+	// Sources of documents passed to Span.AddRetrieval
+	retrievals retrievalSources
+
+	// Model cards passed to AttachModelCard
+	cards modelCards
+
+	// Configuration last reported with ReportConfig
+	agentConfig agentConfig
+
+	// Experiments created with Experiment
+	experiments experiments
+
+	// Event policies, see WithEventPolicies
+	eventPolicies   []EventPolicy
+	eventPolicyFile string
+	policies        []compiledPolicy
+
+	// Event ordering
+	sequence    uint64               // Last sequence number assigned, guarded by mu
+	auditChains map[string]auditLink // End of each agent's audit chain, guarded by mu
+	clock       *clockSkew           // Set by WithClockSkewEstimate
+
+	// Diagnostics
+	logLevel     atomic.Int32
+	logger       Logger
+	debug        bool // Set by WithDebug or TRUSERA_DEBUG
+	errorHandler func(error)
+
+	// Lifecycle
+	lifecycleEvents bool
+	crashReporting  bool        // Set by WithCrashReporting
+	crashSignals    []os.Signal // Caught by WithCrashReporting
+	crash           string      // Panic reported by Recover, guarded by mu
+	startedAt       time.Time
+
+	// Runtime telemetry, see WithRuntimeMetrics
+	runtimeInterval time.Duration
+	lastRuntime     map[string]any // Latest sample, guarded by mu
+
+	// Fleet remote configuration
+	configPollInterval time.Duration
+	remote             atomic.Pointer[remoteSettings]
+
+	// Fleet commands
+	commands      *commandChannel
+	capturePaused atomic.Bool
+
+	// Event processing
+	redactors      []Redactor
+	processors     []EventProcessor
+	captureLevel   CaptureLevel
+	captureLimit   int
+	captureKeys    []string
+	samplers       map[EventType]SamplerFunc
+	defaultSampler SamplerFunc
+	safety         *safety      // Content classifier, see WithContentClassifier
+	egress         *egressRules // Local egress policy, see WithEgressPolicy
+	fieldCrypt     *fieldCrypt  // See WithFieldEncryption
+
+	// LLM cost accounting
+	pricing     *costs.Table
+	costTracker *costs.Tracker
 }
 
 // Option configures a Client
@@ -73,6 +232,16 @@ func WithFlushInterval(d time.Duration) Option {
 	}
 }
 
+// WithHTTPClient sets the HTTP client used for all API calls, e.g. to route
+// through a proxy, customize TLS or substitute a test double
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		if hc != nil {
+			c.httpClient = hc
+		}
+	}
+}
+
 // WithBatchSize sets the max events before auto-flush
 func WithBatchSize(n int) Option {
 	return func(c *Client) {
@@ -110,6 +279,26 @@ func WithEnvironment(env string) Option {
 	}
 }
 
+// WithTools declares the tools the agent exposes for fleet registration, so
+// the fleet inventory shows which agents can run code, call internal APIs or
+// send email. Add the same tools to the AI-BOM with bom.Builder.AddTool.
+func WithTools(tools ...bom.Tool) Option {
+	return func(c *Client) {
+		c.tools = append(c.tools, tools...)
+	}
+}
+
+// WithCompliance declares the agent's EU AI Act and NIST AI RMF metadata for
+// fleet registration, so it can be exported per system. Incomplete metadata
+// is still sent, with a warning logged. Add the same metadata to the AI-BOM
+// with bom.Builder.WithCompliance.
+func WithCompliance(meta bom.Compliance) Option {
+	return func(c *Client) {
+		meta.ModelCards = append([]string(nil), meta.ModelCards...)
+		c.compliance = &meta
+	}
+}
+
 // WithHeartbeatInterval sets the fleet heartbeat interval
 func WithHeartbeatInterval(d time.Duration) Option {
 	return func(c *Client) {
@@ -127,8 +316,8 @@ func envOrDefault(key, fallback string) string {
 }
 
 // validateBaseURL ensures the base URL uses a secure scheme.
-// Allows http:// only for localhost development.
-func validateBaseURL(rawURL string) error {
+// Allows http:// only for localhost development, and warns otherwise.
+func (c *Client) validateBaseURL(rawURL string) error {
 	if rawURL == "" {
 		return nil
 	}
@@ -144,7 +333,7 @@ func validateBaseURL(rawURL string) error {
 		if host == "localhost" || host == "127.0.0.1" || host == "::1" {
 			return nil
 		}
-		log.Printf("[trusera] WARNING: Using insecure http:// base URL for non-localhost host: %s", host)
+		c.logf(LogWarn, "using insecure http:// base URL for non-localhost host: %s", host)
 		return nil
 	default:
 		return fmt.Errorf("unsupported base URL scheme %q, use https://", u.Scheme)
@@ -163,29 +352,118 @@ func NewClient(apiKey string, opts ...Option) *Client {
 	hostname, _ := os.Hostname()
 
 	c := &Client{
-		apiKey:            apiKey,
-		baseURL:           envOrDefault("TRUSERA_API_URL", defaultBaseURL),
-		httpClient:        &http.Client{Timeout: 10 * time.Second},
-		events:            make([]Event, 0, defaultBatchSize),
-		flushSize:         defaultBatchSize,
-		done:              make(chan struct{}),
-		ticker:            time.NewTicker(defaultFlushInterval),
-		heartbeatInterval: defaultHeartbeatInterval,
-		agentName:         envOrDefault("TRUSERA_AGENT_NAME", hostname),
-		agentType:         os.Getenv("TRUSERA_AGENT_TYPE"),
-		environment:       os.Getenv("TRUSERA_ENVIRONMENT"),
+		apiKey:             apiKey,
+		baseURL:            envOrDefault("TRUSERA_API_URL", defaultBaseURL),
+		httpClient:         &http.Client{Timeout: 10 * time.Second},
+		events:             make([]Event, 0, defaultBatchSize),
+		flushSize:          defaultBatchSize,
+		maxQueueSize:       defaultMaxQueueSize,
+		maxBatchBytes:      defaultMaxBatchBytes,
+		dropPolicy:         DropOldest,
+		flushCh:            make(chan struct{}, 1),
+		flushWorkers:       defaultFlushWorkers,
+		done:               make(chan struct{}),
+		ticker:             time.NewTicker(defaultFlushInterval),
+		heartbeatInterval:  defaultHeartbeatInterval,
+		apiKeyFileInterval: defaultAPIKeyFileInterval,
+		agentName:          envOrDefault("TRUSERA_AGENT_NAME", hostname),
+		agentType:          os.Getenv("TRUSERA_AGENT_TYPE"),
+		environment:        os.Getenv("TRUSERA_ENVIRONMENT"),
+		captureLimit:       defaultCaptureLimit,
+		pricing:            costs.DefaultTable(),
+		costTracker:        costs.NewTracker(),
+		logger:             NewSlogLogger(nil),
 	}
 
+	c.logLevel.Store(int32(LogInfo))
+
 	for _, opt := range opts {
 		opt(c)
 	}
+	if c.apiKeyFile != "" {
+		key, err := c.readAPIKeyFile()
+		if err != nil {
+			c.fatalf("API key file unreadable (refusing to start): %v", err)
+		}
+		c.apiKey = key
+	}
+	c.queueCond = sync.NewCond(&c.mu)
+	c.batches = make(chan []Event, c.flushWorkers)
+	tlsConfig, err := c.buildTLSConfig()
+	if err == nil && tlsConfig != nil {
+		err = c.applyTLSConfig(tlsConfig)
+	}
+	if err != nil {
+		c.fatalf("TLS configuration failed (refusing to start): %v", err)
+	}
+	if err := c.applyProxy(); err != nil {
+		c.fatalf("proxy configuration failed (refusing to start): %v", err)
+	}
+	if err := c.installFailoverTransport(); err != nil {
+		c.fatalf("endpoint configuration failed (refusing to start): %v", err)
+	}
+	if err := c.resolveCompression(); err != nil {
+		c.fatalf("compression configuration failed (refusing to start): %v", err)
+	}
+	if err := c.resolveSpillKey(); err != nil {
+		c.fatalf("spill encryption failed (refusing to start): %v", err)
+	}
+	if c.signer != nil {
+		if _, err := SigningKeyID(c.signer.Public()); err != nil {
+			c.fatalf("signing key is unusable (refusing to start): %v", err)
+		}
+	}
+	if c.fieldCrypt != nil && c.fieldCrypt.err != nil {
+		c.fatalf("field encryption key is unusable (refusing to start): %v", c.fieldCrypt.err)
+	}
+	c.installTokenSource()
+	c.installClockTransport()
+	c.installScopeTransport()
+	c.installDebugTransport()
+	c.openLocalSink()
+	if err := c.installRelayTransport(); err != nil {
+		c.fatalf("relay configuration failed (refusing to start): %v", err)
+	}
+	if c.transport == nil {
+		c.transport = &HTTPTransport{
+			BaseURL:     c.baseURL,
+			APIKey:      c.apiKey,
+			HTTPClient:  c.httpClient,
+			Compression: c.compression,
+			Signer:      c.signer,
+			keyFunc:     c.currentAPIKey,
+		}
+		if c.streaming {
+			c.stream = &StreamTransport{
+				BaseURL:    c.baseURL,
+				APIKey:     c.apiKey,
+				HTTPClient: c.httpClient,
+				Fallback:   c.transport,
+				keyFunc:    c.currentAPIKey,
+			}
+			c.transport = c.stream
+			// Every tracked event fills a batch and is handed to the workers at once
+			c.flushSize = 1
+		}
+	}
+
+	c.resolveDeadLetters()
+	if err := c.buildRoutes(); err != nil {
+		c.fatalf("route configuration failed (refusing to start): %v", err)
+	}
 
-	if err := validateBaseURL(c.baseURL); err != nil {
-		log.Fatalf("[trusera] base URL validation failed (refusing to start): %v", err)
+	if err := c.validateBaseURL(c.baseURL); err != nil {
+		c.fatalf("base URL validation failed (refusing to start): %v", err)
+	}
+	if err := c.resolveCaptureLevel(); err != nil {
+		c.fatalf("capture level validation failed (refusing to start): %v", err)
+	}
+	if err := c.loadEventPolicies(); err != nil {
+		c.fatalf("event policy validation failed (refusing to start): %v", err)
 	}
 
-	if c.apiKey == "" {
-		log.Printf("[trusera] WARNING: API key is empty, API calls will fail")
+	if c.apiKey == "" && c.tokenSource == nil && c.relayAddr == "" {
+		c.logf(LogWarn, "API key is empty, API calls will fail")
 	}
 
 	// Env var override for auto-register
@@ -201,25 +479,63 @@ func NewClient(apiKey string, opts ...Option) *Client {
 		c.registerWithFleet()
 	}
 
-	c.wg.Add(1)
+	c.startedAt = time.Now()
+	c.trackLifecycle(LifecycleStarted)
+
+	c.wg.Add(1 + c.flushWorkers)
 	go c.backgroundFlusher()
+	for i := 0; i < c.flushWorkers; i++ {
+		go c.flushWorker()
+	}
+	if c.immediate != nil {
+		c.wg.Add(1)
+		go c.immediateWorker()
+	}
+	if c.runtimeInterval > 0 {
+		c.wg.Add(1)
+		go c.runtimeMetricsLoop()
+	}
+	c.installCrashReporting()
 
-	// Start heartbeat if fleet registration succeeded
+	if c.apiKeyFile != "" {
+		c.wg.Add(1)
+		go c.apiKeyFileLoop()
+	}
+	if c.endpoints != nil {
+		c.wg.Add(1)
+		go c.failbackLoop()
+	}
+
+	// Start heartbeat and config polling if fleet registration succeeded
 	if c.fleetAgentID != "" {
 		c.wg.Add(1)
 		go c.heartbeatLoop()
+
+		if c.configPollInterval > 0 && c.sink == nil {
+			c.wg.Add(1)
+			go c.configLoop()
+		}
+
+		if c.commands != nil && c.sink == nil {
+			c.wg.Add(1)
+			go c.commandLoop()
+		}
 	}
 
 	return c
 }
 
-// backgroundFlusher periodically flushes events
+// backgroundFlusher hands queued events to the flush workers, full batches
+// whenever Track fills one and everything on each tick
 func (c *Client) backgroundFlusher() {
 	defer c.wg.Done()
 	for {
 		select {
 		case <-c.ticker.C:
-			_ = c.Flush()
+			c.flushAggregates()
+			c.dispatch(false)
+		case <-c.flushCh:
+			c.dispatch(true)
 		case <-c.done:
 			return
 		}
@@ -228,61 +544,240 @@ func (c *Client) backgroundFlusher() {
 
 // Track queues an event for sending
 func (c *Client) Track(event Event) {
+	event, ok := c.prepare(event)
+	if !ok {
+		return
+	}
+
 	c.mu.Lock()
-	c.events = append(c.events, event)
-	shouldFlush := len(c.events) >= c.flushSize
+	if c.sendImmediately(&event) {
+		c.mu.Unlock()
+		return
+	}
+	if !c.reserveSlot() {
+		c.mu.Unlock()
+		return
+	}
+	c.stampEvent(&event)
+	c.queueEvent(event)
+	c.stats.tracked++
+	if len(c.events) >= c.flushSize {
+		// Delivery happens on the worker pool; Track never waits on the network
+		c.requestFlush()
+	}
 	c.mu.Unlock()
+}
 
-	if shouldFlush {
-		// Flush synchronously to avoid unbounded goroutine accumulation.
-		// The background flusher handles periodic async flushes.
-		_ = c.Flush()
+// prepare runs an event through sampling, redaction, capture, enrichment,
+// processors, policies and validation, and reports whether to keep it
+func (c *Client) prepare(event Event) (Event, bool) {
+	if c.capturePaused.Load() {
+		return event, false
+	}
+	if event.ID == "" {
+		event.ID = newUUID()
+	}
+	if event.Timestamp == "" {
+		event.Timestamp = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	event = c.annotateCost(event)
+	if !c.sample(event) {
+		return event, false
+	}
+	event = c.classify(event)
+	event = c.enrich(c.capture(c.redact(event)))
+	event, ok := c.process(event)
+	if !ok {
+		return event, false
+	}
+	if event, ok = c.applyPolicies(event); !ok {
+		return event, false
+	}
+	if c.strictValidation && !c.validate(event) {
+		return event, false
 	}
+	return c.encryptFields(event), true
 }
 
-// Flush sends all queued events to the API
+// Flush sends all queued events to the API, including summaries of the
+// operations timed and metrics recorded since the last flush. Batches
+// spilled to disk are replayed first. A backlog larger than the batch size
+// is sent as several batches, up to WithFlushWorkers of them at once; after
+// a batch fails no more are started and the rest are kept or dropped like
+// the failed one. While the circuit breaker is open it returns
+// ErrCircuitOpen without contacting the API.
 func (c *Client) Flush() error {
-	c.mu.Lock()
-	if len(c.events) == 0 {
-		c.mu.Unlock()
-		return nil
+	c.flushAggregates()
+	ctx := context.Background()
+	events := c.takeEvents()
+	if waiting := c.takeImmediate(); len(waiting) > 0 {
+		events = append(waiting, events...)
+	}
+	if len(events) <= c.flushSize {
+		return c.deliver(ctx, events)
 	}
 
-	events := make([]Event, len(c.events))
-	copy(events, c.events)
-	c.events = c.events[:0]
-	c.mu.Unlock()
-
-	payload := map[string]interface{}{
-		"agent_id": c.agentID,
-		"events":   events,
+	// A rejected batch has been dead-lettered and the API is up, so it does
+	// not stop the other batches
+	var rejected atomic.Pointer[error]
+	_, unstarted, err := c.pipeline(events, func(batch []Event) error {
+		err := c.deliver(ctx, batch)
+		if errors.Is(err, ErrRejected) {
+			rejected.CompareAndSwap(nil, &err)
+			return nil
+		}
+		return err
+	})
+	if errors.Is(err, ErrRateLimited) {
+		c.requeueEvents(unstarted)
+	} else {
+		c.retainEvents(unstarted)
+	}
+	if err == nil && rejected.Load() != nil {
+		err = *rejected.Load()
 	}
+	return err
+}
 
-	body, err := json.Marshal(payload)
+// deliver sends events, replaying spilled batches first and keeping or
+// dropping the events if delivery fails. Events are split into batches of
+// at most maxBatchBytes; once one batch fails, it and the batches after it
+// are kept or dropped together. Events the API rejects are dead-lettered and
+// batches that fail to encode are dropped, and the error is returned once
+// the other batches are sent.
+func (c *Client) deliver(ctx context.Context, events []Event) error {
+	events, sizes := c.fitEvents(events)
+	reserved := len(events) > 0
+	if reserved && !c.limiter.reserve() {
+		c.requeueEvents(events)
+		return ErrRateLimited
+	}
+	// The first request sent, spilled or not, takes the reservation
+	replayed, err := c.replaySpill(ctx, reserved)
+	if errors.Is(err, ErrRateLimited) {
+		c.requeueEvents(events)
+		return err
+	}
 	if err != nil {
-		return fmt.Errorf("failed to marshal events: %w", err)
+		c.retainEvents(events)
+		return err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/v1/events", bytes.NewReader(body))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	var rejected error
+	for sent := replayed; len(events) > 0; sent++ {
+		if sent > 0 && !c.limiter.reserve() {
+			c.requeueEvents(events)
+			return ErrRateLimited
+		}
+		if !c.breaker.allow() {
+			c.retainEvents(events)
+			return ErrCircuitOpen
+		}
+
+		n := c.batchLen(sizes)
+		err := c.sendEvents(ctx, events[:n])
+		if errors.Is(err, ErrUnencodable) {
+			// Nothing was sent, so the API's health is unknown, and resending
+			// the same events cannot succeed
+			c.breaker.cancel()
+			c.dropUnencodable(events[:n], err)
+			if rejected == nil {
+				rejected = err
+			}
+			events, sizes = events[n:], sizes[n:]
+			continue
+		}
+		if errors.Is(err, ErrRejected) {
+			// The API is up, and resending the same events cannot succeed
+			c.breaker.done(nil)
+			if rejected == nil {
+				rejected = err
+			}
+			n, err = c.isolateRejected(ctx, events[:n], err)
+			events, sizes = events[n:], sizes[n:]
+			if err == nil {
+				continue
+			}
+		}
+		if errors.Is(err, ErrRateLimited) {
+			// The API is up, so a 429 does not count against the breaker, and
+			// the events wait in the queue until the limiter lets them through
+			c.breaker.done(nil)
+			c.requeueEvents(events)
+			return err
+		}
+		c.breaker.done(err)
+		if err != nil {
+			c.retainEvents(events)
+			return err
+		}
+		events, sizes = events[n:], sizes[n:]
 	}
+	return rejected
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+// retainEvents keeps undelivered events for a later flush when a circuit
+// breaker or spill directory is configured, and drops them otherwise
+func (c *Client) retainEvents(events []Event) {
+	if len(events) == 0 {
+		return
+	}
+	if (c.breaker != nil || c.spillDir != "") && c.holdEvents(events) {
+		return
+	}
+	c.mu.Lock()
+	c.stats.dropped += uint64(len(events))
+	c.mu.Unlock()
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send events: %w", err)
+// takeEvents removes and returns all queued events
+func (c *Client) takeEvents() []Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.events) == 0 {
+		return nil
 	}
-	defer resp.Body.Close()
-	// Drain body to allow connection reuse
-	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
 
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	events := make([]Event, len(c.events))
+	copy(events, c.events)
+	c.events = c.events[:0]
+	c.queueCond.Broadcast()
+	return events
+}
+
+// requeueEvents puts undelivered events back at the front of the queue,
+// discarding the oldest if that would exceed the queue bound
+func (c *Client) requeueEvents(events []Event) {
+	c.mu.Lock()
+	c.events = append(events, c.events...)
+	if over := len(c.events) - c.maxQueueSize; c.maxQueueSize > 0 && over > 0 {
+		c.events = append(c.events[:0], c.events[over:]...)
+		c.stats.dropped += uint64(over)
 	}
+	c.mu.Unlock()
+}
+
+// sendEvents delivers a batch of events through the configured transport
+func (c *Client) sendEvents(ctx context.Context, events []Event) error {
+	c.mu.Lock()
+	agentID := c.agentID
+	c.mu.Unlock()
+	return c.sendBatch(ctx, agentID, events)
+}
 
+// sendBatch sends events on behalf of agentID through the transports of
+// their routes and records the outcome, stopping at the first failure
+func (c *Client) sendBatch(ctx context.Context, agentID string, events []Event) error {
+	for _, g := range c.routeEvents(events) {
+		start := time.Now()
+		err := g.transport.Send(ctx, Batch{AgentID: agentID, Events: g.events, IdempotencyKey: batchKey(g.events)})
+		c.recordFlush(len(g.events), time.Since(start), err)
+		c.limiter.observe(err)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -297,6 +792,17 @@ func (c *Client) RegisterAgent(name, framework string) (string, error) {
 		"framework": framework,
 	}
 
+	if c.sink != nil {
+		id := localID()
+		if err := c.sink.write("agent", id, payload); err != nil {
+			return "", fmt.Errorf("failed to register agent: %w", err)
+		}
+		c.mu.Lock()
+		c.agentID = id
+		c.mu.Unlock()
+		return id, nil
+	}
+
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal payload: %w", err)
@@ -308,7 +814,7 @@ func (c *Client) RegisterAgent(name, framework string) (string, error) {
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Authorization", "Bearer "+c.currentAPIKey())
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -317,8 +823,7 @@ func (c *Client) RegisterAgent(name, framework string) (string, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
-		return "", fmt.Errorf("API returned status %d", resp.StatusCode)
+		return "", newAPIError(resp)
 	}
 
 	var result struct {
@@ -336,6 +841,52 @@ func (c *Client) RegisterAgent(name, framework string) (string, error) {
 	return result.AgentID, nil
 }
 
+// UploadBOM pushes an AI-BOM to Trusera as a CycloneDX 1.6 document
+func (c *Client) UploadBOM(b *bom.BOM) error {
+	if b == nil {
+		return errors.New("bom is required")
+	}
+
+	body, err := b.MarshalCycloneDX()
+	if err != nil {
+		return fmt.Errorf("failed to marshal bom: %w", err)
+	}
+
+	if c.sink != nil {
+		c.mu.Lock()
+		agentID := c.agentID
+		c.mu.Unlock()
+		return c.sink.write("bom", agentID, json.RawMessage(body))
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/v1/bom", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", bom.CycloneDXMediaType)
+	req.Header.Set("Authorization", "Bearer "+c.currentAPIKey())
+	if c.signer != nil {
+		if err := signRequest(req, c.signer, body); err != nil {
+			return err
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload bom: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return newAPIError(resp)
+	}
+	// Drain body to allow connection reuse
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+
+	return nil
+}
+
 // -- Fleet auto-registration --
 
 func (c *Client) getProcessInfo() map[string]interface{} {
@@ -348,6 +899,42 @@ func (c *Client) getProcessInfo() map[string]interface{} {
 	}
 }
 
+// toolsPayload describes declared tools for fleet registration
+func toolsPayload(tools []bom.Tool) []map[string]any {
+	out := make([]map[string]any, 0, len(tools))
+	for _, t := range tools {
+		tool := map[string]any{"name": t.Name}
+		if t.Description != "" {
+			tool["description"] = t.Description
+		}
+		if t.Endpoint != "" {
+			tool["endpoint"] = t.Endpoint
+		}
+		if len(t.Schema) > 0 {
+			tool["input_schema"] = t.Schema
+		}
+		if len(t.Permissions) > 0 {
+			tool["permissions"] = t.Permissions
+		}
+		out = append(out, tool)
+	}
+	return out
+}
+
+// compliancePayload describes compliance metadata for fleet registration
+func compliancePayload(meta *bom.Compliance) map[string]any {
+	out := map[string]any{
+		"intended_purpose": meta.IntendedPurpose,
+		"risk_category":    meta.RiskCategory,
+		"human_oversight":  meta.Oversight,
+		"role":             meta.Role,
+	}
+	if len(meta.ModelCards) > 0 {
+		out["model_cards"] = meta.ModelCards
+	}
+	return out
+}
+
 func (c *Client) getNetworkInfo() map[string]interface{} {
 	info := map[string]interface{}{}
 	hostname, err := os.Hostname()
@@ -378,6 +965,7 @@ func (c *Client) registerWithFleet() {
 		"hostname":         hostname,
 		"process_info":     c.getProcessInfo(),
 		"network_info":     c.getNetworkInfo(),
+		"build_provenance": buildProvenance(),
 	}
 	if c.agentType != "" {
 		payload["framework"] = c.agentType
@@ -385,31 +973,56 @@ func (c *Client) registerWithFleet() {
 	if c.environment != "" {
 		payload["environment"] = c.environment
 	}
+	if len(c.tools) > 0 {
+		payload["tools"] = toolsPayload(c.tools)
+	}
+	if c.compliance != nil {
+		if err := c.compliance.Validate(); err != nil {
+			c.logf(LogWarn, "registering with %v", err)
+		}
+		payload["compliance"] = compliancePayload(c.compliance)
+	}
+	c.addEnrichments(payload)
+
+	if c.sink != nil {
+		id := localID()
+		if err := c.sink.write("register", id, payload); err != nil {
+			c.logf(LogWarn, "fleet register failed (continuing without): %v", err)
+			c.reportError(fmt.Errorf("fleet register: %w", err))
+			return
+		}
+		c.mu.Lock()
+		c.fleetAgentID = id
+		c.mu.Unlock()
+		return
+	}
 
 	body, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("[trusera] fleet register marshal error: %v", err)
+		c.logf(LogError, "fleet register marshal error: %v", err)
 		return
 	}
 
 	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/api/v1/fleet/register", bytes.NewReader(body))
 	if err != nil {
-		log.Printf("[trusera] fleet register request error: %v", err)
+		c.logf(LogError, "fleet register request error: %v", err)
 		return
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Authorization", "Bearer "+c.currentAPIKey())
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		log.Printf("[trusera] fleet register failed (continuing without): %v", err)
+		c.logf(LogWarn, "fleet register failed (continuing without): %v", err)
+		c.reportError(fmt.Errorf("fleet register: %w", err))
 		return
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
-		log.Printf("[trusera] fleet register returned status %d (continuing without)", resp.StatusCode)
+		apiErr := newAPIError(resp)
+		c.logf(LogWarn, "fleet register failed (continuing without): %v", apiErr)
+		c.reportError(fmt.Errorf("fleet register: %w", apiErr))
 		return
 	}
 
@@ -419,7 +1032,7 @@ func (c *Client) registerWithFleet() {
 		} `json:"data"`
 	}
 	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
-		log.Printf("[trusera] fleet register decode error: %v", err)
+		c.logf(LogError, "fleet register decode error: %v", err)
 		return
 	}
 
@@ -427,7 +1040,7 @@ func (c *Client) registerWithFleet() {
 		c.mu.Lock()
 		c.fleetAgentID = result.Data.ID
 		c.mu.Unlock()
-		log.Printf("[trusera] fleet auto-register succeeded (id=%s)", result.Data.ID)
+		c.logf(LogInfo, "fleet auto-register succeeded (id=%s)", result.Data.ID)
 	}
 }
 
@@ -454,9 +1067,26 @@ func (c *Client) sendHeartbeat() {
 		return
 	}
 
+	health, reported := c.healthInfo()
 	payload := map[string]interface{}{
 		"process_info": c.getProcessInfo(),
 		"network_info": c.getNetworkInfo(),
+		"health":       health,
+	}
+	c.mu.Lock()
+	if c.lastRuntime != nil {
+		payload["runtime"] = c.lastRuntime
+	}
+	c.mu.Unlock()
+	c.addHeartbeatEnrichments(payload)
+
+	if c.sink != nil {
+		if err := c.sink.write("heartbeat", fleetID, payload); err != nil {
+			c.recordHeartbeatFailure()
+			return
+		}
+		c.markHeartbeat(reported)
+		return
 	}
 
 	body, err := json.Marshal(payload)
@@ -470,27 +1100,153 @@ func (c *Client) sendHeartbeat() {
 		return
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Authorization", "Bearer "+c.currentAPIKey())
 
+	if !c.breaker.allow() {
+		return
+	}
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		log.Printf("[trusera] fleet heartbeat failed: %v", err)
+		c.breaker.done(err)
+		c.recordHeartbeatFailure()
+		c.logf(LogWarn, "fleet heartbeat failed: %v", err)
+		c.reportError(fmt.Errorf("fleet heartbeat: %w", err))
 		return
 	}
 	defer resp.Body.Close()
-	// Drain body to allow connection reuse
-	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
 
 	if resp.StatusCode >= 400 {
-		log.Printf("[trusera] fleet heartbeat returned status %d", resp.StatusCode)
+		apiErr := newAPIError(resp)
+		c.breaker.done(apiErr)
+		c.recordHeartbeatFailure()
+		c.logf(LogWarn, "fleet heartbeat failed: %v", apiErr)
+		c.reportError(fmt.Errorf("fleet heartbeat: %w", apiErr))
+		return
 	}
+	// Drain body to allow connection reuse
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	c.breaker.done(nil)
+	c.markHeartbeat(reported)
+}
+
+// CloseError reports events that could not be delivered before the client shut down
+type CloseError struct {
+	Dropped int   // Number of events discarded
+	Err     error // Last delivery error
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("trusera: %d events dropped on close: %v", e.Dropped, e.Err)
+}
+
+func (e *CloseError) Unwrap() error {
+	return e.Err
 }
 
-// Close flushes remaining events and stops background goroutine
+// Close stops background goroutines and makes a single attempt to deliver
+// remaining events. Undelivered events are reported as a *CloseError.
 func (c *Client) Close() error {
-	c.ticker.Stop()
-	close(c.done)
+	return c.shutdown(context.Background(), false)
+}
+
+// CloseWithTimeout is like CloseContext with a deadline of d from now
+func (c *Client) CloseWithTimeout(d time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return c.CloseContext(ctx)
+}
+
+// CloseContext stops background goroutines and retries delivery of remaining
+// events until they are sent or ctx is done. Events still queued when ctx
+// expires are dropped and reported as a *CloseError.
+func (c *Client) CloseContext(ctx context.Context) error {
+	return c.shutdown(ctx, true)
+}
+
+// closeError records n events as dropped and wraps err
+func (c *Client) closeError(n int, err error) error {
+	c.mu.Lock()
+	c.stats.dropped += uint64(n)
+	c.mu.Unlock()
+	return &CloseError{Dropped: n, Err: err}
+}
+
+// abandonEvents spills events that could not be delivered on close, or
+// drops them and reports a *CloseError if there is no spill directory
+func (c *Client) abandonEvents(events []Event, err error) error {
+	if len(events) == 0 {
+		return nil
+	}
+	if c.spillDir != "" {
+		if spillErr := c.spill(events); spillErr == nil {
+			return nil
+		}
+	}
+	return c.closeError(len(events), err)
+}
+
+func (c *Client) shutdown(ctx context.Context, retry bool) error {
+	first := false
+	c.closeOnce.Do(func() {
+		first = true
+		c.trackLifecycle(LifecycleDraining)
+		crashReporter.CompareAndSwap(c, nil)
+
+		c.ticker.Stop()
+		close(c.done)
+
+		c.mu.Lock()
+		c.closed = true
+		c.queueCond.Broadcast()
+		c.mu.Unlock()
+	})
 	c.wg.Wait()
+	c.reclaimBatches()
+
+	if !first {
+		return c.drain(ctx, retry)
+	}
 
-	return c.Flush()
+	c.flushAggregates()
+	c.trackLifecycle(LifecycleStopped)
+	err := c.drain(ctx, retry)
+	c.deregisterFromFleet(ctx)
+	if c.stream != nil {
+		c.stream.Close()
+	}
+	if c.sink != nil {
+		c.sink.Close()
+	}
+	return err
+}
+
+// drain delivers all queued events, retrying until ctx is done if retry is set
+func (c *Client) drain(ctx context.Context, retry bool) error {
+	backoff := closeRetryBackoff
+	for {
+		events := c.takeEvents()
+		if len(events) == 0 {
+			return nil
+		}
+
+		failed, unstarted, err := c.pipeline(events, func(batch []Event) error {
+			return c.sendFitted(ctx, batch)
+		})
+		if err == nil {
+			continue
+		}
+
+		undelivered := append(failed, unstarted...)
+		if !retry {
+			return c.abandonEvents(append(undelivered, c.takeEvents()...), err)
+		}
+
+		c.requeueEvents(undelivered)
+		select {
+		case <-ctx.Done():
+			return c.abandonEvents(c.takeEvents(), err)
+		case <-time.After(max(backoff, c.limiter.delay())):
+			backoff = min(backoff*2, maxCloseRetryBackoff)
+		}
+	}
 }
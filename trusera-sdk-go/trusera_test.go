@@ -1,12 +1,16 @@
 package trusera
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/Trusera/ai-bom/trusera-sdk-go/bom"
 )
 
 func TestNewClient(t *testing.T) {
@@ -196,6 +200,114 @@ func TestRegisterAgentEmptyName(t *testing.T) {
 	}
 }
 
+func TestUploadBOM(t *testing.T) {
+	var contentType string
+	var received map[string]any
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/bom" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		contentType = r.Header.Get("Content-Type")
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	defer client.Close()
+
+	doc := bom.NewBuilder("test-agent").AddModel(bom.Model{Name: "gpt-4o"}).Build()
+	if err := client.UploadBOM(doc); err != nil {
+		t.Fatalf("UploadBOM failed: %v", err)
+	}
+
+	if contentType != bom.CycloneDXMediaType {
+		t.Errorf("expected content type %s, got %s", bom.CycloneDXMediaType, contentType)
+	}
+	if received["bomFormat"] != "CycloneDX" {
+		t.Errorf("expected CycloneDX document, got %v", received["bomFormat"])
+	}
+}
+
+func TestUploadBOMErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	defer client.Close()
+
+	if err := client.UploadBOM(nil); err == nil {
+		t.Error("expected error for nil bom")
+	}
+	if err := client.UploadBOM(bom.NewBuilder("a").Build()); err == nil {
+		t.Error("expected error for 400 response")
+	}
+}
+
+func TestRegistrationIncludesTools(t *testing.T) {
+	var register map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/fleet/register" {
+			_ = json.NewDecoder(r.Body).Decode(&register)
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"id": "fleet-1"}})
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithAutoRegister(), WithTools(
+		bom.Tool{Name: "send_email", Permissions: []bom.Permission{bom.PermissionEmail}},
+		bom.Tool{Name: "search", Schema: map[string]any{"type": "object"}},
+	))
+	defer client.Close()
+
+	tools, ok := register["tools"].([]any)
+	if !ok || len(tools) != 2 {
+		t.Fatalf("expected 2 tools in registration, got %v", register["tools"])
+	}
+	email := tools[0].(map[string]any)
+	if email["name"] != "send_email" || len(email["permissions"].([]any)) != 1 || email["permissions"].([]any)[0] != "email" {
+		t.Errorf("unexpected tool %v", email)
+	}
+	if search := tools[1].(map[string]any); search["input_schema"] == nil || search["permissions"] != nil {
+		t.Errorf("unexpected tool %v", search)
+	}
+}
+
+func TestRegistrationIncludesCompliance(t *testing.T) {
+	var register map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/fleet/register" {
+			_ = json.NewDecoder(r.Body).Decode(&register)
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"id": "fleet-1"}})
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithAutoRegister(), WithCompliance(bom.Compliance{
+		IntendedPurpose: "Answer customer support tickets",
+		RiskCategory:    bom.RiskLimited,
+		Oversight:       bom.OversightOnTheLoop,
+		Role:            bom.RoleDeployer,
+		ModelCards:      []string{"https://example.com/model-card"},
+	}))
+	defer client.Close()
+
+	meta, ok := register["compliance"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected compliance in registration, got %v", register)
+	}
+	if meta["risk_category"] != "limited" || meta["human_oversight"] != "human_on_the_loop" || meta["role"] != "deployer" {
+		t.Errorf("unexpected compliance %v", meta)
+	}
+	if cards, _ := meta["model_cards"].([]any); len(cards) != 1 {
+		t.Errorf("expected 1 model card, got %v", meta["model_cards"])
+	}
+}
+
 func TestBackgroundFlusher(t *testing.T) {
 	var flushCount int
 	var mu sync.Mutex
@@ -292,6 +404,93 @@ func TestClose(t *testing.T) {
 	}
 }
 
+func TestCloseReportsDroppedEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithBatchSize(100))
+	client.Track(NewEvent(EventToolCall, "a"))
+	client.Track(NewEvent(EventToolCall, "b"))
+
+	err := client.Close()
+
+	var closeErr *CloseError
+	if !errors.As(err, &closeErr) {
+		t.Fatalf("expected *CloseError, got %v", err)
+	}
+	if closeErr.Dropped != 2 {
+		t.Errorf("expected 2 dropped events, got %d", closeErr.Dropped)
+	}
+}
+
+func TestCloseWithTimeoutRetriesUntilDelivered(t *testing.T) {
+	var attempts int
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		n := attempts
+		mu.Unlock()
+		if n < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	client.Track(NewEvent(EventToolCall, "a"))
+
+	if err := client.CloseWithTimeout(5 * time.Second); err != nil {
+		t.Fatalf("expected delivery after retries, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestCloseContextDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	client.Track(NewEvent(EventToolCall, "a"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := client.CloseContext(ctx)
+
+	var closeErr *CloseError
+	if !errors.As(err, &closeErr) || closeErr.Dropped != 1 {
+		t.Fatalf("expected 1 dropped event, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected close to honor deadline, took %v", elapsed)
+	}
+}
+
+func TestCloseIsIdempotent(t *testing.T) {
+	client := NewClient("test-key")
+
+	if err := client.Close(); err != nil {
+		t.Errorf("first Close failed: %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Errorf("second Close failed: %v", err)
+	}
+}
+
 // ─── Environment variable configuration tests ─────────────────────────
 
 func TestEnvVarAPIKey(t *testing.T) {
@@ -354,3 +553,87 @@ func TestNoEnvVarsSetUsesDefaults(t *testing.T) {
 		t.Errorf("expected default baseURL %s, got %s", defaultBaseURL, client.baseURL)
 	}
 }
+
+func TestWithHTTPClient(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	hc := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		paths = append(paths, req.URL.Path)
+		mu.Unlock()
+		if req.URL.Path == "/v1/agents" {
+			return cannedResponse("application/json", `{"agent_id":"agent-7"}`).RoundTrip(req)
+		}
+		return cannedResponse("application/json", `{}`).RoundTrip(req)
+	})}
+
+	client := NewClient("test-key", WithBaseURL("https://api.example.test"), WithHTTPClient(hc))
+	id, err := client.RegisterAgent("agent", "custom")
+	if err != nil {
+		t.Fatalf("RegisterAgent failed: %v", err)
+	}
+	if id != "agent-7" {
+		t.Errorf("expected agent-7, got %s", id)
+	}
+	client.Track(NewEvent(EventToolCall, "tool"))
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(paths) != 2 || paths[0] != "/v1/agents" || paths[1] != "/v1/events" {
+		t.Errorf("expected agent and events requests through the custom client, got %v", paths)
+	}
+}
+
+// fakeTracker is a minimal Tracker used to check that helpers accept the interface
+type fakeTracker struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (f *fakeTracker) Track(e Event) {
+	f.mu.Lock()
+	f.events = append(f.events, e)
+	f.mu.Unlock()
+}
+func (f *fakeTracker) Flush() error                                 { return nil }
+func (f *fakeTracker) Close() error                                 { return nil }
+func (f *fakeTracker) RegisterAgent(string, string) (string, error) { return "fake", nil }
+
+func TestInterceptorAcceptsTracker(t *testing.T) {
+	ft := &fakeTracker{}
+	httpClient := WrapHTTPClient(&http.Client{Transport: cannedResponse("text/plain", "ok")}, ft, InterceptorOptions{})
+
+	resp, err := httpClient.Get("https://example.test/resource")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if len(ft.events) != 2 {
+		t.Errorf("expected request and response events on the fake tracker, got %d", len(ft.events))
+	}
+}
+
+func TestTrackDoesNotAllocate(t *testing.T) {
+	client := NewClient("test-key", WithTransport(&recordingTransport{}), WithBatchSize(10000), WithFlushInterval(time.Hour))
+	defer client.Close()
+
+	event := NewEvent(EventToolCall, "search").WithPayload("query", "weather")
+	if allocs := testing.AllocsPerRun(1000, func() { client.Track(event) }); allocs != 0 {
+		t.Errorf("expected Track to enqueue without allocating, got %v allocations", allocs)
+	}
+}
+
+func BenchmarkTrack(b *testing.B) {
+	client := NewClient("test-key", WithTransport(&recordingTransport{}), WithFlushInterval(time.Hour))
+	defer client.Close()
+
+	event := NewEvent(EventToolCall, "search").WithPayload("query", "weather")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		client.Track(event)
+	}
+}
@@ -0,0 +1,121 @@
+// Package truseratest provides an in-memory Trusera client and assertion
+// helpers for unit testing instrumentation without a live API.
+package truseratest
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+// RecordingClient is a real *trusera.Client whose events are recorded in
+// memory instead of being sent. Sampling, redaction and cost annotation run
+// as usual, so the recorded events are what the API would have received.
+// The embedded Client can be passed anywhere a *trusera.Client is expected,
+// such as trusera.WrapTransport.
+type RecordingClient struct {
+	*trusera.Client
+	rec *recorder
+}
+
+var _ trusera.Tracker = (*RecordingClient)(nil)
+
+// Filter selects recorded events
+type Filter func(trusera.Event) bool
+
+// recorder is a trusera.Transport that keeps every batch in memory
+type recorder struct {
+	mu     sync.Mutex
+	events []trusera.Event
+}
+
+func (r *recorder) Send(_ context.Context, b trusera.Batch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, b.Events...)
+	return nil
+}
+
+// NewRecordingClient returns a client that records events in memory.
+// Options are applied as for trusera.NewClient, except that the transport
+// is always the recorder.
+func NewRecordingClient(opts ...trusera.Option) *RecordingClient {
+	rec := &recorder{}
+	opts = append(opts, trusera.WithTransport(rec))
+	return &RecordingClient{Client: trusera.NewClient("truseratest", opts...), rec: rec}
+}
+
+// Events flushes queued events and returns those matching every filter, in
+// the order they were tracked
+func (rc *RecordingClient) Events(filters ...Filter) []trusera.Event {
+	_ = rc.Client.Flush()
+
+	rc.rec.mu.Lock()
+	defer rc.rec.mu.Unlock()
+
+	var out []trusera.Event
+	for _, e := range rc.rec.events {
+		if matchAll(e, filters) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Reset discards recorded events
+func (rc *RecordingClient) Reset() {
+	_ = rc.Client.Flush()
+
+	rc.rec.mu.Lock()
+	rc.rec.events = nil
+	rc.rec.mu.Unlock()
+}
+
+// OfType matches events of type t
+func OfType(t trusera.EventType) Filter {
+	return func(e trusera.Event) bool { return e.Type == t }
+}
+
+// Named matches events with the given name
+func Named(name string) Filter {
+	return func(e trusera.Event) bool { return e.Name == name }
+}
+
+// WithPayload matches events whose payload has key set to value
+func WithPayload(key string, value any) Filter {
+	return func(e trusera.Event) bool {
+		v, ok := e.Payload[key]
+		return ok && v == value
+	}
+}
+
+// AssertEventEmitted fails the test unless an event matching every filter
+// was recorded, and returns the first such event
+func AssertEventEmitted(t testing.TB, rc *RecordingClient, filters ...Filter) trusera.Event {
+	t.Helper()
+	events := rc.Events(filters...)
+	if len(events) == 0 {
+		t.Errorf("expected a matching event, got none among %d recorded", len(rc.Events()))
+		return trusera.Event{}
+	}
+	return events[0]
+}
+
+// AssertNoEvent fails the test if an event matching every filter was recorded
+func AssertNoEvent(t testing.TB, rc *RecordingClient, filters ...Filter) {
+	t.Helper()
+	if events := rc.Events(filters...); len(events) > 0 {
+		t.Errorf("expected no matching event, got %d (first: %s %q)", len(events), events[0].Type, events[0].Name)
+	}
+}
+
+func matchAll(e trusera.Event, filters []Filter) bool {
+	for _, f := range filters {
+		if !f(e) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package truseratest
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+func TestRecordingClientCapturesEvents(t *testing.T) {
+	rc := NewRecordingClient()
+	defer rc.Close()
+
+	rc.Track(trusera.NewEvent(trusera.EventToolCall, "search").WithPayload("query", "go"))
+	rc.Track(trusera.NewEvent(trusera.EventDecision, "approve"))
+
+	if n := len(rc.Events()); n != 2 {
+		t.Fatalf("expected 2 events, got %d", n)
+	}
+	e := AssertEventEmitted(t, rc, OfType(trusera.EventToolCall), WithPayload("query", "go"))
+	if e.Name != "search" {
+		t.Errorf("expected search event, got %s", e.Name)
+	}
+	AssertNoEvent(t, rc, Named("reject"))
+
+	rc.Reset()
+	if n := len(rc.Events()); n != 0 {
+		t.Errorf("expected no events after Reset, got %d", n)
+	}
+}
+
+func TestRecordingClientAppliesPipeline(t *testing.T) {
+	rc := NewRecordingClient(trusera.WithRedactor(trusera.DefaultRedactor()))
+	defer rc.Close()
+
+	rc.Track(trusera.NewEvent(trusera.EventDataAccess, "lookup").WithPayload("email", "jane@example.com"))
+
+	e := AssertEventEmitted(t, rc, Named("lookup"))
+	if e.Payload["email"] != "[REDACTED:email]" {
+		t.Errorf("expected redacted email, got %v", e.Payload["email"])
+	}
+}
+
+func TestRecordingClientWithWrapTransport(t *testing.T) {
+	rc := NewRecordingClient()
+	defer rc.Close()
+
+	base := roundTripper(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"model":"gpt-4o","usage":{"prompt_tokens":3,"completion_tokens":4}}`)),
+		}, nil
+	})
+	httpClient := &http.Client{Transport: trusera.WrapTransport(base, rc.Client)}
+
+	resp, err := httpClient.Post("https://api.openai.com/v1/chat/completions", "application/json",
+		strings.NewReader(`{"model":"gpt-4o"}`))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	AssertEventEmitted(t, rc, OfType(trusera.EventLLMInvoke), WithPayload("model", "gpt-4o"))
+}
+
+func TestAssertEventEmittedFails(t *testing.T) {
+	rc := NewRecordingClient()
+	defer rc.Close()
+
+	ft := &fakeT{}
+	AssertEventEmitted(ft, rc, Named("missing"))
+	if !ft.failed {
+		t.Error("expected AssertEventEmitted to fail when no event matches")
+	}
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// fakeT records failures without failing the enclosing test
+type fakeT struct {
+	testing.TB
+	failed bool
+}
+
+func (f *fakeT) Helper()               {}
+func (f *fakeT) Errorf(string, ...any) { f.failed = true }
@@ -0,0 +1,282 @@
+package trusera
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidEvent matches the *ValidationError returned by ValidateEvent
+var ErrInvalidEvent = errors.New("trusera: invalid event")
+
+// ValidationError lists why an event does not match the schema of its type
+type ValidationError struct {
+	Type     EventType
+	Name     string
+	Problems []string // One per invalid field, such as "payload.prompt_tokens: expected integer, got string"
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s event %q: %s", e.Type, e.Name, strings.Join(e.Problems, "; "))
+}
+
+// Is reports whether target is ErrInvalidEvent
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidEvent
+}
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+var (
+	schemasOnce sync.Once
+	schemas     map[EventType]*jsonSchema
+)
+
+// jsonSchema is the subset of JSON Schema the embedded schemas use
+type jsonSchema struct {
+	Type                 any                    `json:"type"` // A type name or a list of them
+	Required             []string               `json:"required"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	Enum                 []any                  `json:"enum"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	ExclusiveMinimum     *float64               `json:"exclusiveMinimum"`
+	MinLength            *int                   `json:"minLength"`
+	Format               string                 `json:"format"`
+}
+
+// WithStrictValidation checks every tracked event against the JSON Schema
+// embedded for its type, after redaction, processors and policies. Events
+// that do not match are dropped instead of being rejected by the API later,
+// logged, counted in Stats().Invalid and passed to the error handler as a
+// *ValidationError.
+func WithStrictValidation() Option {
+	return func(c *Client) {
+		c.strictValidation = true
+	}
+}
+
+// ValidateEvent checks that e has an ID, a type and an RFC 3339 timestamp,
+// and that its payload matches the JSON Schema embedded for its type. Events
+// of types without a schema only get the first checks. The error is a
+// *ValidationError listing every problem found.
+func ValidateEvent(e Event) error {
+	var problems []string
+	if e.ID == "" {
+		problems = append(problems, "id: required")
+	}
+	if e.Type == "" {
+		problems = append(problems, "type: required")
+	}
+	if e.Timestamp == "" {
+		problems = append(problems, "timestamp: required")
+	} else if _, err := time.Parse(time.RFC3339Nano, e.Timestamp); err != nil {
+		problems = append(problems, fmt.Sprintf("timestamp: %q is not an RFC 3339 date-time", e.Timestamp))
+	}
+
+	if schema := eventSchema(e.Type); schema != nil {
+		payload, err := jsonValue(e.Payload)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("payload: %v", err))
+		} else {
+			if payload == nil {
+				payload = map[string]any{}
+			}
+			problems = schema.validate("payload", payload, problems)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Type: e.Type, Name: e.Name, Problems: problems}
+	}
+	return nil
+}
+
+// validate reports whether e passes ValidateEvent, dropping and reporting
+// it if not
+func (c *Client) validate(e Event) bool {
+	err := ValidateEvent(e)
+	if err == nil {
+		return true
+	}
+	c.mu.Lock()
+	c.stats.invalid++
+	c.mu.Unlock()
+	c.logf(LogWarn, "dropping %v", err)
+	c.reportError(err)
+	return false
+}
+
+// eventSchema returns the embedded schema for an event type, if any
+func eventSchema(t EventType) *jsonSchema {
+	schemasOnce.Do(func() {
+		schemas = make(map[EventType]*jsonSchema)
+		files, _ := schemaFiles.ReadDir("schemas")
+		for _, f := range files {
+			data, err := schemaFiles.ReadFile("schemas/" + f.Name())
+			if err != nil {
+				panic(err)
+			}
+			var s jsonSchema
+			if err := json.Unmarshal(data, &s); err != nil {
+				panic(fmt.Sprintf("trusera: invalid embedded schema %s: %v", f.Name(), err))
+			}
+			schemas[EventType(strings.TrimSuffix(f.Name(), path.Ext(f.Name())))] = &s
+		}
+	})
+	return schemas[t]
+}
+
+// jsonValue converts v to the generic form encoding/json decodes into, so
+// it is validated as the API will see it
+func jsonValue(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	err = json.Unmarshal(data, &out)
+	return out, err
+}
+
+// validate appends the ways v does not match s to problems, naming fields
+// by their dotted path from at
+func (s *jsonSchema) validate(at string, v any, problems []string) []string {
+	if !s.matchesType(v) {
+		return append(problems, fmt.Sprintf("%s: expected %s, got %s", at, strings.Join(s.typeNames(), " or "), jsonType(v)))
+	}
+	if len(s.Enum) > 0 && !s.inEnum(v) {
+		return append(problems, fmt.Sprintf("%s: %s is not one of %s", at, describe(v), describe(s.Enum)))
+	}
+
+	switch v := v.(type) {
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			problems = append(problems, fmt.Sprintf("%s: %v is less than %v", at, v, *s.Minimum))
+		}
+		if s.ExclusiveMinimum != nil && v <= *s.ExclusiveMinimum {
+			problems = append(problems, fmt.Sprintf("%s: %v must be greater than %v", at, v, *s.ExclusiveMinimum))
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			problems = append(problems, fmt.Sprintf("%s: %v is greater than %v", at, v, *s.Maximum))
+		}
+	case string:
+		if s.MinLength != nil && len([]rune(v)) < *s.MinLength {
+			problems = append(problems, fmt.Sprintf("%s: shorter than %d characters", at, *s.MinLength))
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, v); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %q is not an RFC 3339 date-time", at, v))
+			}
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range v {
+				problems = s.Items.validate(fmt.Sprintf("%s[%d]", at, i), item, problems)
+			}
+		}
+	case map[string]any:
+		for _, key := range s.Required {
+			if _, ok := v[key]; !ok {
+				problems = append(problems, fmt.Sprintf("%s.%s: required", at, key))
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if prop, ok := s.Properties[k]; ok {
+				problems = prop.validate(at+"."+k, v[k], problems)
+			} else if s.AdditionalProperties != nil {
+				problems = s.AdditionalProperties.validate(at+"."+k, v[k], problems)
+			}
+		}
+	}
+	return problems
+}
+
+// typeNames returns the types s allows
+func (s *jsonSchema) typeNames() []string {
+	switch t := s.Type.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		names := make([]string, 0, len(t))
+		for _, n := range t {
+			if name, ok := n.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// matchesType reports whether v has one of the types s allows
+func (s *jsonSchema) matchesType(v any) bool {
+	names := s.typeNames()
+	if len(names) == 0 {
+		return true
+	}
+	actual := jsonType(v)
+	for _, name := range names {
+		if name == actual || (name == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// inEnum reports whether v is one of the values s allows
+func (s *jsonSchema) inEnum(v any) bool {
+	for _, allowed := range s.Enum {
+		if reflect.DeepEqual(v, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType names the JSON type of a decoded value
+func jsonType(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// describe formats a value for a validation message
+func describe(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,105 @@
+package trusera
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateEventReportsEveryProblem(t *testing.T) {
+	e := NewEvent(EventLLMInvoke, "chat").
+		WithPayload("model", "gpt-4o").
+		WithPayload("prompt_tokens", "12").
+		WithPayload("completion_tokens", -3).
+		WithPayload("latency_ms", 1.5)
+	e.Timestamp = "yesterday"
+
+	err := ValidateEvent(e)
+	if !errors.Is(err, ErrInvalidEvent) {
+		t.Fatalf("expected ErrInvalidEvent, got %v", err)
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected a *ValidationError, got %T", err)
+	}
+	want := []string{
+		`timestamp: "yesterday" is not an RFC 3339 date-time`,
+		"payload.completion_tokens: -3 is less than 0",
+		"payload.prompt_tokens: expected integer, got string",
+	}
+	if strings.Join(verr.Problems, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected problems %q, got %q", want, verr.Problems)
+	}
+}
+
+func TestValidateEventSchemas(t *testing.T) {
+	cases := []struct {
+		event   Event
+		problem string
+	}{
+		{NewEvent(EventGuardrailViolation, "pii").WithPayload("policy", "pii").WithPayload("action", "blocked"), "payload.severity: required"},
+		{NewEvent(EventGuardrailViolation, "pii").WithPayload("policy", "pii").WithPayload("severity", "severe").WithPayload("action", "blocked"), `payload.severity: "severe" is not one of ["low","medium","high","critical"]`},
+		{NewEvent(EventMetric, "m").WithPayload("kind", "counter").WithPayload("value", "1"), "payload.value: expected number, got string"},
+		{NewEvent(EventTiming, "t").WithPayload("count", 1).WithPayload("buckets_ms", map[string]any{"10": 1.5}), "payload.buckets_ms.10: expected integer, got number"},
+		{NewEvent(EventAPICall, "GET").WithPayload("status_code", 999), "payload.status_code: 999 is greater than 599"},
+		{NewEvent(EventFeedback, "f").WithPayload("max_rating", 0), "payload.max_rating: 0 must be greater than 0"},
+		{NewEvent(EventSpan, "s").WithPayload("start_time", "noon"), `payload.start_time: "noon" is not an RFC 3339 date-time`},
+		{Event{Type: EventToolCall, Timestamp: time.Now().UTC().Format(time.RFC3339Nano)}, "id: required"},
+	}
+	for _, tc := range cases {
+		err := ValidateEvent(tc.event)
+		var verr *ValidationError
+		if !errors.As(err, &verr) || len(verr.Problems) != 1 || verr.Problems[0] != tc.problem {
+			t.Errorf("expected %q for %s event, got %v", tc.problem, tc.event.Type, err)
+		}
+	}
+
+	custom := NewEvent(EventType("custom"), "x").WithPayload("anything", []int{1})
+	if err := ValidateEvent(custom); err != nil {
+		t.Errorf("expected types without a schema to pass, got %v", err)
+	}
+}
+
+func TestStrictValidationDropsInvalidEvents(t *testing.T) {
+	var reported error
+	client := NewClient("k", WithTransport(&recordingTransport{}), WithStrictValidation(), WithFlushInterval(time.Hour),
+		WithErrorHandler(func(err error) { reported = err }))
+	defer client.Close()
+
+	client.Track(NewEvent(EventLLMInvoke, "chat").WithPayload("total_tokens", "lots"))
+	client.Track(NewEvent(EventLLMInvoke, "chat").WithPayload("total_tokens", 10))
+
+	stats := client.Stats()
+	if stats.Invalid != 1 || stats.Queued != 1 {
+		t.Errorf("expected 1 invalid event dropped and 1 queued, got %d and %d", stats.Invalid, stats.Queued)
+	}
+	if !errors.Is(reported, ErrInvalidEvent) || !strings.Contains(reported.Error(), "payload.total_tokens: expected integer, got string") {
+		t.Errorf("expected a descriptive validation error, got %v", reported)
+	}
+}
+
+func TestSDKEventsPassStrictValidation(t *testing.T) {
+	client := NewClient("k", WithTransport(&recordingTransport{}), WithStrictValidation(), WithFlushInterval(time.Hour))
+	defer client.Close()
+
+	client.ReportGuardrail(GuardrailEvent{Policy: "pii", Severity: SeverityHigh, Action: GuardrailBlocked})
+	run := client.StartRun("run")
+	step := run.StartSpan("step")
+	step.AddRetrieval([]RetrievedDoc{{ID: "doc-1", SourceURI: "s3://kb/a.pdf", Content: "chunk", Score: 0.9}})
+	step.End()
+	run.End()
+	client.RecordFeedback(FeedbackEvent{RunID: run.TraceID(), Rating: 4, MaxRating: 5, Thumbs: ThumbsUp})
+	client.Counter("requests").Inc()
+	client.Gauge("depth").Set(3)
+	client.TimeOperation("op")()
+	client.Track(llmCall{provider: "openai", model: "gpt-4o", method: "POST", path: "/v1/chat/completions"}.event())
+	client.TrackEmbedding(context.Background(), EmbeddingEvent{Model: "text-embedding-3-small", Inputs: 2, Dimensions: 1536, Tokens: 12})
+	client.TrackVectorStore(context.Background(), VectorStoreEvent{Store: "qdrant", Operation: VectorQuery, TopK: 5, Results: 5})
+	client.flushAggregates()
+
+	if stats := client.Stats(); stats.Invalid != 0 || stats.Queued < 11 {
+		t.Errorf("expected every SDK event to be valid, got %d invalid of %d", stats.Invalid, stats.Queued+int(stats.Invalid))
+	}
+}
@@ -0,0 +1,21 @@
+package trusera
+
+import "context"
+
+// variantContextKey is the context key for the rollout variant in use
+type variantContextKey struct{}
+
+// ContextWithVariant returns a copy of ctx labeled with a rollout variant,
+// such as "canary" or "shadow". Events tracked with the context, by
+// WrapTransport or TrackContext, carry it as "variant" metadata, so the
+// variants of a model or prompt rollout can be compared side by side. The
+// canary package chooses variants and runs shadow calls.
+func ContextWithVariant(ctx context.Context, variant string) context.Context {
+	return context.WithValue(ctx, variantContextKey{}, variant)
+}
+
+// VariantFromContext returns the rollout variant in ctx, if any
+func VariantFromContext(ctx context.Context) (string, bool) {
+	v, ok := ctx.Value(variantContextKey{}).(string)
+	return v, ok && v != ""
+}
@@ -0,0 +1,32 @@
+package trusera
+
+import (
+	"context"
+	"testing"
+)
+
+func TestContextWithVariantLabelsEvents(t *testing.T) {
+	client := NewClient("test-key", WithLocalSink(t.TempDir()+"/events.jsonl"))
+	defer client.Close()
+
+	ctx := ContextWithVariant(context.Background(), "canary")
+	if v, ok := VariantFromContext(ctx); !ok || v != "canary" {
+		t.Errorf("expected variant canary, got %q", v)
+	}
+	client.TrackContext(ctx, NewEvent(EventToolCall, "search"))
+	client.TrackContext(context.Background(), NewEvent(EventToolCall, "search"))
+
+	events := queuedEvents(client)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Metadata["variant"] != "canary" {
+		t.Errorf("expected the variant label, got %v", events[0].Metadata)
+	}
+	if _, ok := events[1].Metadata["variant"]; ok {
+		t.Errorf("expected no variant without one in the context, got %v", events[1].Metadata)
+	}
+	if _, ok := VariantFromContext(ContextWithVariant(ctx, "")); ok {
+		t.Error("expected an empty variant to count as none")
+	}
+}
@@ -0,0 +1,130 @@
+package trusera
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// VectorOperation is the kind of call made to a vector store
+type VectorOperation string
+
+const (
+	VectorUpsert VectorOperation = "upsert"
+	VectorQuery  VectorOperation = "query"
+	VectorFetch  VectorOperation = "fetch"
+	VectorDelete VectorOperation = "delete"
+)
+
+// EmbeddingEvent describes a call that turned text into vectors, the first
+// step of a RAG pipeline
+type EmbeddingEvent struct {
+	Provider   string // e.g. "openai" or "ollama"
+	Model      string // Required
+	Inputs     int    // Number of texts embedded
+	Dimensions int    // Length of each vector
+	Tokens     int    // Input tokens billed, used for cost accounting
+	Latency    time.Duration
+	Err        error
+	Metadata   map[string]any
+}
+
+// VectorStoreEvent describes one call to a vector database, such as an upsert
+// of document chunks or a similarity query made while answering
+type VectorStoreEvent struct {
+	Store      string          // Database, e.g. "pgvector" or "qdrant", required
+	Operation  VectorOperation // Required
+	Collection string          // Table, index, collection or class
+	Namespace  string          // Partition within the collection, if the store has them
+	TopK       int             // Number of neighbours a query asked for
+	Vectors    int             // Number of vectors written, fetched or deleted
+	Results    int             // Number of matches a query returned
+	Dimensions int
+	Latency    time.Duration
+	Err        error
+	Metadata   map[string]any
+}
+
+// TrackEmbedding records an embedding call, linked to the active span in ctx.
+// Token counts are priced like LLM calls when the model has a known price.
+func (c *Client) TrackEmbedding(ctx context.Context, e EmbeddingEvent) error {
+	if e.Model == "" {
+		return errors.New("embedding model is required")
+	}
+
+	name := e.Model
+	if e.Provider != "" {
+		name = e.Provider + " " + e.Model
+	}
+	event := NewEvent(EventEmbedding, name).
+		WithPayload("model", e.Model).
+		WithPayload("latency_ms", e.Latency.Milliseconds())
+	if e.Provider != "" {
+		event = event.WithPayload("provider", e.Provider)
+	}
+	if e.Inputs > 0 {
+		event = event.WithPayload("inputs", e.Inputs)
+	}
+	if e.Dimensions > 0 {
+		event = event.WithPayload("dimensions", e.Dimensions)
+	}
+	if e.Tokens > 0 {
+		event = event.WithPayload("prompt_tokens", e.Tokens)
+	}
+	if e.Err != nil {
+		event = event.WithPayload("error", e.Err.Error())
+	}
+	for k, v := range e.Metadata {
+		event = event.WithMetadata(k, v)
+	}
+
+	c.TrackContext(ctx, event)
+	return nil
+}
+
+// TrackVectorStore records a vector store call, linked to the active span in
+// ctx so retrieval shows up under the run that used it
+func (c *Client) TrackVectorStore(ctx context.Context, v VectorStoreEvent) error {
+	if v.Store == "" {
+		return errors.New("vector store is required")
+	}
+	if v.Operation == "" {
+		return errors.New("vector store operation is required")
+	}
+
+	name := v.Store + " " + string(v.Operation)
+	if v.Collection != "" {
+		name += " " + v.Collection
+	}
+	event := NewEvent(EventVectorStore, name).
+		WithPayload("store", v.Store).
+		WithPayload("operation", string(v.Operation)).
+		WithPayload("latency_ms", v.Latency.Milliseconds())
+	if v.Collection != "" {
+		event = event.WithPayload("collection", v.Collection)
+	}
+	if v.Namespace != "" {
+		event = event.WithPayload("namespace", v.Namespace)
+	}
+	if v.TopK > 0 {
+		event = event.WithPayload("top_k", v.TopK)
+	}
+	if v.Vectors > 0 {
+		event = event.WithPayload("vectors", v.Vectors)
+	}
+	if v.Operation == VectorQuery && v.Err == nil {
+		event = event.WithPayload("results", v.Results)
+	}
+	if v.Dimensions > 0 {
+		event = event.WithPayload("dimensions", v.Dimensions)
+	}
+	if v.Err != nil {
+		event = event.WithPayload("error", v.Err.Error())
+	}
+	for k, val := range v.Metadata {
+		event = event.WithMetadata(k, val)
+	}
+
+	c.TrackContext(ctx, event)
+	return nil
+}
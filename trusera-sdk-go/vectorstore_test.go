@@ -0,0 +1,76 @@
+package trusera
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTrackEmbedding(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	ctx, span := client.StartSpan(context.Background(), "ingest")
+	err := client.TrackEmbedding(ctx, EmbeddingEvent{
+		Provider:   "openai",
+		Model:      "text-embedding-3-small",
+		Inputs:     16,
+		Dimensions: 1536,
+		Tokens:     1000000,
+		Latency:    120 * time.Millisecond,
+	})
+	span.End()
+	if err != nil {
+		t.Fatalf("TrackEmbedding failed: %v", err)
+	}
+	if err := client.TrackEmbedding(ctx, EmbeddingEvent{Provider: "openai"}); err == nil {
+		t.Error("expected an error for an embedding without a model")
+	}
+
+	e := queuedEvents(client)[0]
+	if e.Type != EventEmbedding || e.Name != "openai text-embedding-3-small" {
+		t.Errorf("unexpected event %s %s", e.Type, e.Name)
+	}
+	if e.Payload["inputs"] != 16 || e.Payload["dimensions"] != 1536 || e.Payload["latency_ms"] != int64(120) {
+		t.Errorf("unexpected payload: %v", e.Payload)
+	}
+	if e.Payload["cost_usd"] != 0.02 {
+		t.Errorf("expected embedding tokens to be priced, got %v", e.Payload["cost_usd"])
+	}
+	if e.Metadata["parent_span_id"] != span.SpanID() {
+		t.Errorf("expected embedding linked to the span, got %v", e.Metadata)
+	}
+}
+
+func TestTrackVectorStore(t *testing.T) {
+	client := NewClient("test-key", WithBatchSize(1000))
+	defer client.Close()
+
+	ctx := context.Background()
+	client.TrackVectorStore(ctx, VectorStoreEvent{Store: "qdrant", Operation: VectorQuery, Collection: "docs",
+		Namespace: "tenant-a", TopK: 5, Results: 0, Dimensions: 768, Latency: 8 * time.Millisecond})
+	client.TrackVectorStore(ctx, VectorStoreEvent{Store: "pinecone", Operation: VectorUpsert, Vectors: 100,
+		Err: errors.New("quota exceeded")})
+
+	events := queuedEvents(client)
+	query, upsert := events[0], events[1]
+	if query.Type != EventVectorStore || query.Name != "qdrant query docs" {
+		t.Errorf("unexpected event %s %s", query.Type, query.Name)
+	}
+	if query.Payload["namespace"] != "tenant-a" || query.Payload["top_k"] != 5 || query.Payload["results"] != 0 {
+		t.Errorf("expected an empty result set to be recorded, got %v", query.Payload)
+	}
+	if upsert.Payload["vectors"] != 100 || upsert.Payload["error"] != "quota exceeded" {
+		t.Errorf("unexpected payload: %v", upsert.Payload)
+	}
+	if _, ok := upsert.Payload["results"]; ok {
+		t.Error("expected no result count outside queries")
+	}
+
+	for _, v := range []VectorStoreEvent{{Operation: VectorQuery}, {Store: "qdrant"}} {
+		if err := client.TrackVectorStore(ctx, v); err == nil {
+			t.Errorf("expected an error for %+v", v)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package trusera
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Trusera/ai-bom/trusera-sdk-go/bom"
+)
+
+var _ bom.VulnerabilitySource = (*Client)(nil)
+
+// LookupVulnerabilities queries the Trusera API for known vulnerabilities in
+// Go module dependencies. It lets a Client be passed to
+// bom.BOM.ScanVulnerabilities alongside or instead of OSV.
+func (c *Client) LookupVulnerabilities(ctx context.Context, deps []bom.Dependency) ([]bom.Vulnerability, error) {
+	if c.sink != nil {
+		return nil, errors.New("vulnerability lookup is not available in local mode")
+	}
+	if len(deps) == 0 {
+		return nil, nil
+	}
+
+	type dependency struct {
+		Path    string `json:"path"`
+		Version string `json:"version"`
+	}
+	query := make([]dependency, len(deps))
+	for i, d := range deps {
+		query[i] = dependency{Path: d.Path, Version: d.Version}
+	}
+	body, err := json.Marshal(map[string]any{"dependencies": query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal dependencies: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/v1/bom/vulnerabilities", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.currentAPIKey())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vulnerability lookup failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, newAPIError(resp)
+	}
+
+	var result struct {
+		Vulnerabilities []struct {
+			ID       string   `json:"id"`
+			Aliases  []string `json:"aliases"`
+			Summary  string   `json:"summary"`
+			Severity string   `json:"severity"`
+			CVSS     string   `json:"cvss"`
+			Affects  []string `json:"affects"`
+			FixedIn  string   `json:"fixed_in"`
+			State    string   `json:"state"`
+		} `json:"vulnerabilities"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 8<<20)).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	out := make([]bom.Vulnerability, 0, len(result.Vulnerabilities))
+	for _, v := range result.Vulnerabilities {
+		out = append(out, bom.Vulnerability{
+			ID:       v.ID,
+			Aliases:  v.Aliases,
+			Summary:  v.Summary,
+			Severity: v.Severity,
+			CVSS:     v.CVSS,
+			Source:   "trusera",
+			Affects:  v.Affects,
+			FixedIn:  v.FixedIn,
+			State:    v.State,
+		})
+	}
+	return out, nil
+}
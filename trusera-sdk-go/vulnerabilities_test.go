@@ -0,0 +1,44 @@
+package trusera
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Trusera/ai-bom/trusera-sdk-go/bom"
+)
+
+func TestLookupVulnerabilities(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/bom/vulnerabilities" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var body struct {
+			Dependencies []struct{ Path, Version string } `json:"dependencies"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.Dependencies) != 1 {
+			t.Errorf("expected one dependency in query, got %+v (%v)", body, err)
+		}
+		_, _ = w.Write([]byte(`{"vulnerabilities": [{"id": "TRU-1", "severity": "high", "affects": ["golang.org/x/net@v0.20.0"], "state": "exploitable"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	defer client.Close()
+
+	doc := bom.NewBuilder("agent").Build()
+	doc.Dependencies = []bom.Dependency{{Path: "golang.org/x/net", Version: "v0.20.0"}}
+	if err := doc.ScanVulnerabilities(context.Background(), client); err != nil {
+		t.Fatalf("ScanVulnerabilities failed: %v", err)
+	}
+
+	if len(doc.Vulnerabilities) != 1 {
+		t.Fatalf("expected 1 finding, got %+v", doc.Vulnerabilities)
+	}
+	v := doc.Vulnerabilities[0]
+	if v.ID != "TRU-1" || v.Source != "trusera" || v.State != bom.VEXExploitable {
+		t.Errorf("unexpected finding %+v", v)
+	}
+}
@@ -0,0 +1,7 @@
+module github.com/Trusera/ai-bom/trusera-sdk-go/weaviatego
+
+go 1.21.0
+
+require github.com/Trusera/ai-bom/trusera-sdk-go v0.0.0
+
+replace github.com/Trusera/ai-bom/trusera-sdk-go => ../
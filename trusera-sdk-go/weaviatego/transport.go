@@ -0,0 +1,283 @@
+// Package weaviatego records calls made with the Weaviate Go client
+// (github.com/weaviate/weaviate-go-client) as Trusera vector_store events,
+// with the class, tenant, limit, result count and latency of each object
+// write, batch import, GraphQL Get query, fetch and delete:
+//
+//	client, err := weaviate.NewClient(weaviate.Config{
+//		Host:             "localhost:8080",
+//		Scheme:           "http",
+//		ConnectionClient: &http.Client{Transport: weaviatego.WrapTransport(nil, truseraClient)},
+//	})
+//
+// Only the REST and GraphQL API is seen. Batch imports sent over gRPC, when
+// the client has a GrpcConfig, are not recorded.
+package weaviatego
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+// Store is the store name events are recorded under
+const Store = "weaviate"
+
+// maxCapture bounds how much of a request or response body is buffered
+const maxCapture = 1 << 20
+
+// Weaviate REST endpoints that carry data operations
+const (
+	objectsPath = "/v1/objects"
+	batchPath   = "/v1/batch/objects"
+	graphQLPath = "/v1/graphql"
+)
+
+// Arguments of a GraphQL Get query
+var (
+	getClass   = regexp.MustCompile(`\bGet\s*\{\s*(\w+)`)
+	limitArg   = regexp.MustCompile(`\blimit\s*:\s*(\d+)`)
+	tenantArg  = regexp.MustCompile(`\btenant\s*:\s*"([^"]*)"`)
+	nearVector = regexp.MustCompile(`\bvector\s*:\s*\[([^\]]*)\]`)
+)
+
+// WrapTransport wraps the transport of the http.Client given to the Weaviate
+// client so that data operations are recorded as vector_store events linked
+// to the active span in the request's context. Schema and other calls pass
+// through untouched.
+func WrapTransport(base http.RoundTripper, client *trusera.Client) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base, client: client}
+}
+
+type transport struct {
+	base   http.RoundTripper
+	client *trusera.Client
+}
+
+// RoundTrip forwards the request and records the operation once the response
+// body has been read or closed
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	v, ok := describe(req)
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		v.Latency = time.Since(start)
+		v.Err = err
+		t.client.TrackVectorStore(req.Context(), v)
+		return resp, err
+	}
+
+	latency := time.Since(start)
+	resp.Body = &responseBody{ReadCloser: resp.Body, onDone: func(body []byte) {
+		v.Latency = latency
+		annotate(&v, resp.StatusCode, body)
+		t.client.TrackVectorStore(req.Context(), v)
+	}}
+	return resp, nil
+}
+
+// describe builds the event for a request, reporting false for requests that
+// are not data operations
+func describe(req *http.Request) (trusera.VectorStoreEvent, bool) {
+	v := trusera.VectorStoreEvent{Store: Store}
+	path := strings.TrimSuffix(req.URL.Path, "/")
+	switch {
+	case path == graphQLPath && req.Method == http.MethodPost:
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.Unmarshal(peekBody(req), &body)
+		m := getClass.FindStringSubmatch(body.Query)
+		if m == nil {
+			return v, false // Aggregate, Explore and introspection
+		}
+		v.Operation = trusera.VectorQuery
+		v.Collection = m[1]
+		if m := limitArg.FindStringSubmatch(body.Query); m != nil {
+			v.TopK, _ = strconv.Atoi(m[1])
+		}
+		if m := tenantArg.FindStringSubmatch(body.Query); m != nil {
+			v.Namespace = m[1]
+		}
+		if m := nearVector.FindStringSubmatch(body.Query); m != nil && strings.TrimSpace(m[1]) != "" {
+			v.Dimensions = strings.Count(m[1], ",") + 1
+		}
+	case path == batchPath && req.Method == http.MethodPost:
+		var body struct {
+			Objects []object `json:"objects"`
+		}
+		json.Unmarshal(peekBody(req), &body)
+		v.Operation = trusera.VectorUpsert
+		v.Vectors = len(body.Objects)
+		if len(body.Objects) > 0 {
+			body.Objects[0].annotate(&v)
+		}
+	case path == batchPath && req.Method == http.MethodDelete:
+		var body struct {
+			Match struct {
+				Class string `json:"class"`
+			} `json:"match"`
+		}
+		json.Unmarshal(peekBody(req), &body)
+		v.Operation = trusera.VectorDelete
+		v.Collection = body.Match.Class
+		v.Namespace = req.URL.Query().Get("tenant")
+	case path == objectsPath || strings.HasPrefix(path, objectsPath+"/"):
+		// /v1/objects or /v1/objects/{class}/{id}
+		parts := strings.Split(strings.TrimPrefix(path, objectsPath), "/")
+		if len(parts) == 3 {
+			v.Collection = parts[1]
+		}
+		v.Namespace = req.URL.Query().Get("tenant")
+		switch req.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			var obj object
+			json.Unmarshal(peekBody(req), &obj)
+			v.Operation = trusera.VectorUpsert
+			v.Vectors = 1
+			obj.annotate(&v)
+		case http.MethodGet:
+			if len(parts) != 3 {
+				return v, false // Listing, not a fetch by ID
+			}
+			v.Operation = trusera.VectorFetch
+		case http.MethodDelete:
+			v.Operation = trusera.VectorDelete
+			v.Vectors = 1
+		default:
+			return v, false // HEAD checks existence
+		}
+	default:
+		return v, false
+	}
+	return v, true
+}
+
+// object is the part of a Weaviate object that is recorded
+type object struct {
+	Class  string    `json:"class"`
+	Tenant string    `json:"tenant"`
+	Vector []float32 `json:"vector"`
+}
+
+func (o object) annotate(v *trusera.VectorStoreEvent) {
+	if o.Class != "" {
+		v.Collection = o.Class
+	}
+	if o.Tenant != "" {
+		v.Namespace = o.Tenant
+	}
+	v.Dimensions = len(o.Vector)
+}
+
+// annotate adds the outcome of a response to the event
+func annotate(v *trusera.VectorStoreEvent, status int, body []byte) {
+	if status >= 400 {
+		v.Err = apiError(status, body)
+		return
+	}
+	switch v.Operation {
+	case trusera.VectorQuery:
+		var resp struct {
+			Data struct {
+				Get map[string][]json.RawMessage `json:"Get"`
+			} `json:"data"`
+			Errors []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		json.Unmarshal(body, &resp)
+		if len(resp.Errors) > 0 {
+			// GraphQL reports errors with a 200 status
+			v.Err = errors.New(resp.Errors[0].Message)
+			return
+		}
+		v.Results = len(resp.Data.Get[v.Collection])
+	case trusera.VectorFetch:
+		v.Vectors = 1
+	case trusera.VectorDelete:
+		if v.Vectors == 0 {
+			var resp struct {
+				Results struct {
+					Successful int `json:"successful"`
+				} `json:"results"`
+			}
+			json.Unmarshal(body, &resp)
+			v.Vectors = resp.Results.Successful
+		}
+	}
+}
+
+// apiError describes a failed response from Weaviate's error body
+func apiError(status int, body []byte) error {
+	var resp struct {
+		Error []struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &resp) == nil && len(resp.Error) > 0 {
+		return fmt.Errorf("weaviate returned status %d: %s", status, resp.Error[0].Message)
+	}
+	return fmt.Errorf("weaviate returned status %d", status)
+}
+
+// peekBody reads a request body and restores it for the base transport
+func peekBody(req *http.Request) []byte {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	data, err := io.ReadAll(io.LimitReader(req.Body, maxCapture+1))
+	req.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), req.Body))
+	if err != nil || len(data) > maxCapture {
+		return nil
+	}
+	return data
+}
+
+// responseBody buffers a bounded copy of the body and reports it on EOF or Close
+type responseBody struct {
+	io.ReadCloser
+	buf    bytes.Buffer
+	once   sync.Once
+	onDone func([]byte)
+}
+
+func (b *responseBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && b.buf.Len() < maxCapture {
+		room := maxCapture - b.buf.Len()
+		if room > n {
+			room = n
+		}
+		b.buf.Write(p[:room])
+	}
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *responseBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *responseBody) finish() {
+	b.once.Do(func() { b.onDone(b.buf.Bytes()) })
+}
@@ -0,0 +1,123 @@
+package weaviatego
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/truseratest"
+)
+
+// Requests as the Weaviate Go client (v5) sends them for a batch import of
+// two objects, a GraphQL Get with nearVector and a delete, all for a tenant.
+// They are replayed with net/http so the module does not depend on the
+// client, which needs a newer Go than the SDK.
+const (
+	batchBody  = `{"fields":["ALL"],"objects":[{"class":"Article","tenant":"acme","vector":[0.1,0.2,0.3]},{"class":"Article","tenant":"acme","vector":[0.4,0.5,0.6]}]}`
+	getBody    = `{"query":"{Get {Article (tenant: \"acme\", nearVector:{vector: [0.1,0.2,0.3]}, limit: 3) {title}}}"}`
+	deletePath = objectsPath + "/0f6d9ba3-2f3e-4a3c-9a9e-6a2a3c1f3d11?tenant=acme"
+)
+
+// newServer starts a fake Weaviate server and returns its URL and an HTTP
+// client instrumented with rec
+func newServer(t *testing.T, rec *truseratest.RecordingClient) (string, *http.Client) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == batchPath:
+			var body struct {
+				Objects []json.RawMessage `json:"objects"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			results := make([]map[string]any, len(body.Objects))
+			for i := range results {
+				results[i] = map[string]any{"class": "Article", "result": map[string]any{}}
+			}
+			json.NewEncoder(w).Encode(results)
+		case r.URL.Path == graphQLPath:
+			w.Write([]byte(`{"data":{"Get":{"Article":[{"title":"a"},{"title":"b"}]}}}`))
+		case strings.HasPrefix(r.URL.Path, objectsPath+"/") && r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":[{"message":"tenant not found"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, &http.Client{Transport: WrapTransport(nil, rec.Client)}
+}
+
+// call sends a request with ctx, reads the response as the client would and
+// returns its status
+func call(t *testing.T, ctx context.Context, client *http.Client, method, url, body string) int {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, url, err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestTransportRecordsOperations(t *testing.T) {
+	rec := truseratest.NewRecordingClient()
+	defer rec.Close()
+	url, client := newServer(t, rec)
+
+	ctx, span := rec.StartSpan(context.Background(), "answer")
+	if status := call(t, ctx, client, http.MethodPost, url+batchPath, batchBody); status != http.StatusOK {
+		t.Fatalf("batch import failed with %d", status)
+	}
+	if status := call(t, ctx, client, http.MethodPost, url+graphQLPath, getBody); status != http.StatusOK {
+		t.Fatalf("Get failed with %d", status)
+	}
+	if status := call(t, ctx, client, http.MethodDelete, url+deletePath, ""); status != http.StatusNotFound {
+		t.Fatalf("expected the delete to fail, got %d", status)
+	}
+	span.End()
+
+	events := rec.Events(truseratest.OfType(trusera.EventVectorStore))
+	if len(events) != 3 {
+		t.Fatalf("expected 3 vector store events, got %d", len(events))
+	}
+	upsert, query, del := events[0].Payload, events[1].Payload, events[2].Payload
+	if upsert["operation"] != "upsert" || upsert["collection"] != "Article" || upsert["namespace"] != "acme" ||
+		upsert["vectors"] != 2 || upsert["dimensions"] != 3 {
+		t.Errorf("unexpected upsert payload: %v", upsert)
+	}
+	if query["operation"] != "query" || query["namespace"] != "acme" || query["top_k"] != 3 || query["results"] != 2 || query["dimensions"] != 3 {
+		t.Errorf("unexpected query payload: %v", query)
+	}
+	if del["operation"] != "delete" || del["namespace"] != "acme" || del["error"] != "weaviate returned status 404: tenant not found" {
+		t.Errorf("unexpected delete payload: %v", del)
+	}
+	if events[1].Metadata["parent_span_id"] != span.SpanID() {
+		t.Errorf("expected the query linked to the span, got %v", events[1].Metadata)
+	}
+}
+
+func TestDescribeSkipsSchemaCalls(t *testing.T) {
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodGet, "/v1/schema"},
+		{http.MethodGet, "/v1/meta"},
+		{http.MethodGet, "/v1/objects"},
+		{http.MethodHead, "/v1/objects/Article/0f6d9ba3"},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		if _, ok := describe(req); ok {
+			t.Errorf("expected %s %s to be skipped", tc.method, tc.path)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+// Package webhooks receives webhooks sent by Trusera, such as alerts and
+// policy violations. Handler verifies the HMAC signature and timestamp of
+// each delivery before decoding it, so a receiver is a few lines:
+//
+//	http.Handle("/hooks/trusera", webhooks.Handler(secret, func(ctx context.Context, e webhooks.Event) error {
+//		if e.Type == webhooks.EventAlert {
+//			var alert webhooks.Alert
+//			if err := e.Decode(&alert); err != nil {
+//				return err
+//			}
+//			page(alert)
+//		}
+//		return nil
+//	}))
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader carries the delivery signature as "t=<unix seconds>,v1=<hex
+// HMAC-SHA256>". The HMAC covers the timestamp, a dot and the raw body. While
+// a secret is rotated, one v1 entry is sent per active secret.
+const SignatureHeader = "X-Trusera-Webhook-Signature"
+
+// DefaultTolerance is how far a delivery's timestamp may be from the local
+// clock before it is rejected as a replay
+const DefaultTolerance = 5 * time.Minute
+
+// maxBody bounds the size of a delivery read by Handler
+const maxBody = 1 << 20
+
+var (
+	// ErrInvalidSignature is returned when no signature matches the body
+	ErrInvalidSignature = errors.New("webhooks: invalid signature")
+	// ErrExpired is returned when the signed timestamp is outside the tolerance
+	ErrExpired = errors.New("webhooks: timestamp outside tolerance")
+	// ErrNoSecret is returned when the secret is empty, as anyone can compute
+	// signatures with an empty key
+	ErrNoSecret = errors.New("webhooks: empty secret")
+)
+
+// Event types sent by Trusera
+const (
+	EventAlert           = "alert.triggered"
+	EventPolicyViolation = "policy.violation"
+	EventAgentOffline    = "agent.offline"
+)
+
+// Event is the envelope of every delivery
+type Event struct {
+	ID        string          `json:"id"` // Unique per event, repeated on redelivery
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"` // Alert, PolicyViolation or AgentOffline, by Type
+}
+
+// Decode unmarshals the event's data into v, such as an *Alert
+func (e Event) Decode(v any) error {
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return fmt.Errorf("webhooks: failed to decode %s data: %w", e.Type, err)
+	}
+	return nil
+}
+
+// Alert is the data of an alert.triggered event
+type Alert struct {
+	ID       string   `json:"id"`
+	Rule     string   `json:"rule"`     // Name of the alert rule that fired
+	Severity string   `json:"severity"` // "low", "medium", "high" or "critical"
+	AgentID  string   `json:"agent_id"`
+	Message  string   `json:"message"`
+	EventIDs []string `json:"event_ids,omitempty"` // Events that triggered the alert
+	URL      string   `json:"url,omitempty"`       // Alert in the Trusera dashboard
+}
+
+// PolicyViolation is the data of a policy.violation event
+type PolicyViolation struct {
+	Policy   string `json:"policy"`
+	Severity string `json:"severity"`
+	Action   string `json:"action"` // "logged", "warned" or "blocked"
+	AgentID  string `json:"agent_id"`
+	EventID  string `json:"event_id"` // Event that violated the policy
+	Reason   string `json:"reason,omitempty"`
+}
+
+// AgentOffline is the data of an agent.offline event
+type AgentOffline struct {
+	AgentID  string    `json:"agent_id"`
+	Name     string    `json:"name"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Sign returns the SignatureHeader value for body signed with secret at t.
+// It is mainly useful to test receivers.
+func Sign(secret string, body []byte, t time.Time) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac(secret, ts, body))
+}
+
+// Verify checks a SignatureHeader value against the raw body of a delivery.
+// Timestamps more than tolerance from now are rejected; zero uses
+// DefaultTolerance. An empty secret fails with ErrNoSecret.
+func Verify(secret string, body []byte, header string, tolerance time.Duration) error {
+	if secret == "" {
+		return ErrNoSecret
+	}
+	if tolerance == 0 {
+		tolerance = DefaultTolerance
+	}
+	var ts string
+	var sigs [][]byte
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			if sig, err := hex.DecodeString(value); err == nil {
+				sigs = append(sigs, sig)
+			}
+		}
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || len(sigs) == 0 {
+		return ErrInvalidSignature
+	}
+
+	want := mac(secret, ts, body)
+	for _, sig := range sigs {
+		if hmac.Equal(sig, want) {
+			if d := time.Since(time.Unix(unix, 0)); d > tolerance || d < -tolerance {
+				return ErrExpired
+			}
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// mac computes the HMAC-SHA256 of a timestamp and body
+func mac(secret, ts string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(ts))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// HandlerFunc processes a verified event. Returning an error answers 500, so
+// Trusera delivers the event again later; deliveries can repeat, so handlers
+// should be idempotent on Event.ID.
+type HandlerFunc func(ctx context.Context, e Event) error
+
+// Handler returns an http.Handler that verifies deliveries signed with secret
+// and passes them to fn. Requests with a missing or invalid signature are
+// answered 401 without calling fn, malformed bodies 400, and handled events
+// 204. Handler panics if secret is empty, such as when the environment
+// variable holding it is unset, since every delivery would be rejected.
+func Handler(secret string, fn HandlerFunc) http.Handler {
+	if secret == "" {
+		panic(ErrNoSecret)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBody+1))
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		if len(body) > maxBody {
+			http.Error(w, "body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err := Verify(secret, body, r.Header.Get(SignatureHeader), 0); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		var e Event
+		if err := json.Unmarshal(body, &e); err != nil || e.Type == "" {
+			http.Error(w, "malformed event", http.StatusBadRequest)
+			return
+		}
+		if err := fn(r.Context(), e); err != nil {
+			http.Error(w, "failed to handle event", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
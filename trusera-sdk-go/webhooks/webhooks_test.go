@@ -0,0 +1,132 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testBody = `{"id": "evt-1", "type": "alert.triggered", "created_at": "2030-01-01T00:00:00Z",
+	"data": {"id": "al-1", "rule": "pii-leak", "severity": "high", "agent_id": "agent-42"}}`
+
+func TestVerify(t *testing.T) {
+	body := []byte(testBody)
+	now := time.Now()
+
+	if err := Verify("secret", body, Sign("secret", body, now), 0); err != nil {
+		t.Errorf("expected valid signature, got %v", err)
+	}
+	if err := Verify("other", body, Sign("secret", body, now), 0); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for wrong secret, got %v", err)
+	}
+	if err := Verify("secret", []byte(testBody+" "), Sign("secret", body, now), 0); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for modified body, got %v", err)
+	}
+	if err := Verify("secret", body, Sign("secret", body, now.Add(-time.Hour)), 0); !errors.Is(err, ErrExpired) {
+		t.Errorf("expected ErrExpired for old delivery, got %v", err)
+	}
+	if err := Verify("secret", body, Sign("secret", body, now.Add(-time.Hour)), 2*time.Hour); err != nil {
+		t.Errorf("expected delivery within custom tolerance, got %v", err)
+	}
+	for _, header := range []string{"", "t=abc,v1=00", "v1=00"} {
+		if err := Verify("secret", body, header, 0); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("expected ErrInvalidSignature for %q, got %v", header, err)
+		}
+	}
+}
+
+func TestVerifyDuringSecretRotation(t *testing.T) {
+	body := []byte(testBody)
+	now := time.Now()
+	old := Sign("old-secret", body, now)
+	_, newSig, _ := strings.Cut(Sign("new-secret", body, now), ",")
+	header := old + "," + newSig
+
+	for _, secret := range []string{"old-secret", "new-secret"} {
+		if err := Verify(secret, body, header, 0); err != nil {
+			t.Errorf("expected %s to verify, got %v", secret, err)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	var got Event
+	var alert Alert
+	h := Handler("secret", func(ctx context.Context, e Event) error {
+		got = e
+		return e.Decode(&alert)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(testBody))
+	req.Header.Set(SignatureHeader, Sign("secret", []byte(testBody), time.Now()))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got.ID != "evt-1" || got.Type != EventAlert {
+		t.Errorf("expected the alert event, got %+v", got)
+	}
+	if alert.Rule != "pii-leak" || alert.Severity != "high" || alert.AgentID != "agent-42" {
+		t.Errorf("expected decoded alert, got %+v", alert)
+	}
+}
+
+func TestHandlerRejects(t *testing.T) {
+	called := false
+	h := Handler("secret", func(ctx context.Context, e Event) error {
+		called = true
+		return errors.New("downstream unavailable")
+	})
+	sign := func(body string) string { return Sign("secret", []byte(body), time.Now()) }
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		header string
+		want   int
+	}{
+		{"unsigned", http.MethodPost, testBody, "", http.StatusUnauthorized},
+		{"forged", http.MethodPost, testBody, Sign("guess", []byte(testBody), time.Now()), http.StatusUnauthorized},
+		{"get", http.MethodGet, "", "", http.StatusMethodNotAllowed},
+		{"malformed", http.MethodPost, `{"id":`, sign(`{"id":`), http.StatusBadRequest},
+		{"handler error", http.MethodPost, testBody, sign(testBody), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called = false
+			req := httptest.NewRequest(tt.method, "/hooks", strings.NewReader(tt.body))
+			if tt.header != "" {
+				req.Header.Set(SignatureHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, rec.Code)
+			}
+			if called != (tt.want == http.StatusInternalServerError) {
+				t.Errorf("expected the handler called only for verified events, called=%v", called)
+			}
+		})
+	}
+}
+
+func TestEmptySecretIsRejected(t *testing.T) {
+	body := []byte(testBody)
+	if err := Verify("", body, Sign("", body, time.Now()), 0); !errors.Is(err, ErrNoSecret) {
+		t.Errorf("expected ErrNoSecret, got %v", err)
+	}
+
+	defer func() {
+		if r := recover(); r != ErrNoSecret {
+			t.Errorf("expected Handler to panic with ErrNoSecret, got %v", r)
+		}
+	}()
+	Handler("", func(ctx context.Context, e Event) error { return nil })
+}
@@ -0,0 +1,10 @@
+module github.com/Trusera/ai-bom/trusera-sdk-go/zstdgo
+
+go 1.25.0
+
+require (
+	github.com/Trusera/ai-bom/trusera-sdk-go v0.0.0
+	github.com/klauspost/compress v1.20.1
+)
+
+replace github.com/Trusera/ai-bom/trusera-sdk-go => ../
@@ -0,0 +1,26 @@
+// Package zstdgo registers the zstd content encoding for
+// trusera.WithCompression, using github.com/klauspost/compress/zstd.
+// Programs import it for its side effect:
+//
+//	import _ "github.com/Trusera/ai-bom/trusera-sdk-go/zstdgo"
+//
+//	client := trusera.NewClient("api-key", trusera.WithCompression("zstd"))
+package zstdgo
+
+import (
+	"io"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/klauspost/compress/zstd"
+)
+
+func init() {
+	trusera.RegisterCompressor("zstd", NewWriter)
+}
+
+// NewWriter returns a zstd encoder writing to w. It is registered with
+// trusera.RegisterCompressor when the package is imported. Each batch is
+// compressed by one goroutine, as batches are already sent concurrently.
+func NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+}
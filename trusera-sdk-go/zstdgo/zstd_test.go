@@ -0,0 +1,46 @@
+package zstdgo
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestClientSendsZstd(t *testing.T) {
+	var encoding string
+	var received trusera.Batch
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding = r.Header.Get("Content-Encoding")
+		dec, err := zstd.NewReader(r.Body)
+		if err != nil {
+			t.Errorf("failed to open zstd body: %v", err)
+			return
+		}
+		defer dec.Close()
+		body, err := io.ReadAll(dec)
+		if err != nil {
+			t.Errorf("failed to decompress body: %v", err)
+		}
+		_ = json.Unmarshal(body, &received)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	client := trusera.NewClient("k", trusera.WithBaseURL(server.URL), trusera.WithCompression("zstd"))
+	client.Track(trusera.NewEvent(trusera.EventToolCall, "search"))
+	if err := client.Close(); err != nil {
+		t.Fatalf("expected clean close, got %v", err)
+	}
+
+	if encoding != "zstd" {
+		t.Errorf("expected Content-Encoding zstd, got %q", encoding)
+	}
+	if len(received.Events) != 1 || received.Events[0].Name != "search" {
+		t.Errorf("expected the event after decompression, got %+v", received.Events)
+	}
+}